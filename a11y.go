@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/chromedp"
+)
+
+// axNode is a simplified accessibility tree node: ignored nodes are removed
+// and their children attached to the nearest visible ancestor.
+type axNode struct {
+	Role        string                     `json:"role"`
+	Name        string                     `json:"name,omitempty"`
+	Value       string                     `json:"value,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Properties  map[string]json.RawMessage `json:"properties,omitempty"`
+	DOMNodeID   int64                      `json:"backendDOMNodeId,omitempty"`
+	Children    []*axNode                  `json:"children,omitempty"`
+}
+
+// captureAccessibilityTree dumps the full accessibility tree of the current
+// page to a11y/<page>.json.
+func (e *AgicapExplorer) captureAccessibilityTree(pageName string) {
+	var nodes []*accessibility.Node
+	err := chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		nodes, err = accessibility.GetFullAXTree().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		e.log("⚠️ Accessibility tree failed for %s: %v", pageName, err)
+		return
+	}
+
+	data, err := json.MarshalIndent(buildAXTree(nodes), "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("a11y", sanitize(pageName)+".json", data); err != nil {
+		e.log("⚠️ Failed to write accessibility tree for %s: %v", pageName, err)
+	}
+}
+
+// buildAXTree nests the flat CDP node list, returning the root nodes.
+func buildAXTree(nodes []*accessibility.Node) []*axNode {
+	byID := make(map[accessibility.NodeID]*accessibility.Node, len(nodes))
+	for _, n := range nodes {
+		byID[n.NodeID] = n
+	}
+
+	var convert func(n *accessibility.Node) []*axNode
+	convert = func(n *accessibility.Node) []*axNode {
+		var children []*axNode
+		for _, id := range n.ChildIDs {
+			if child, ok := byID[id]; ok {
+				children = append(children, convert(child)...)
+			}
+		}
+		if n.Ignored {
+			return children
+		}
+
+		out := &axNode{
+			Role:        axValue(n.Role),
+			Name:        axValue(n.Name),
+			Value:       axValue(n.Value),
+			Description: axValue(n.Description),
+			DOMNodeID:   int64(n.BackendDOMNodeID),
+			Children:    children,
+		}
+		for _, p := range n.Properties {
+			if p.Value == nil {
+				continue
+			}
+			if out.Properties == nil {
+				out.Properties = make(map[string]json.RawMessage)
+			}
+			out.Properties[string(p.Name)] = json.RawMessage(p.Value.Value)
+		}
+		return []*axNode{out}
+	}
+
+	var roots []*axNode
+	for _, n := range nodes {
+		if n.ParentID == "" {
+			roots = append(roots, convert(n)...)
+		}
+	}
+	return roots
+}
+
+// axValue unwraps a string-like AX value.
+func axValue(v *accessibility.Value) string {
+	if v == nil || len(v.Value) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(v.Value, &s); err == nil {
+		return s
+	}
+	return string(v.Value)
+}
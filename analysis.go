@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// pageAnalysis mirrors components/<page>_analysis.json.
+type pageAnalysis struct {
+	Components       []componentInfo   `json:"components"`
+	Layout           layoutInfo        `json:"layout"`
+	Colors           []string          `json:"colors"`
+	Fonts            []string          `json:"fonts"`
+	Spacing          []string          `json:"spacing"`
+	CustomProperties map[string]string `json:"customProperties"`
+	PageInfo         pageInfo          `json:"pageInfo"`
+}
+
+type componentInfo struct {
+	ID         string              `json:"id"`
+	Type       string              `json:"type"`
+	Selector   string              `json:"selector"` // the most stable of Selectors
+	Selectors  []selectorCandidate `json:"selectors,omitempty"`
+	HTML       string              `json:"html"`
+	CSS        map[string]string   `json:"css"`
+	Text       string              `json:"text"`
+	Position   componentBox        `json:"position"`
+	Attributes map[string]string   `json:"attributes"`
+	Screenshot string              `json:"screenshot,omitempty"`
+
+	States map[string]componentState `json:"states,omitempty"`
+}
+
+type componentBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type layoutInfo struct {
+	HasHeader   bool   `json:"hasHeader"`
+	HasSidebar  bool   `json:"hasSidebar"`
+	HasFooter   bool   `json:"hasFooter"`
+	GridSystem  string `json:"gridSystem"`
+	MainContent bool   `json:"mainContent"`
+}
+
+type pageInfo struct {
+	URL      string `json:"url"`
+	Title    string `json:"title"`
+	Viewport struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"viewport"`
+}
+
+// componentSelectors find the components worth analyzing on a page.
+var componentSelectors = []string{
+	"button", ".btn", `[role="button"]`, `input[type="button"]`, `input[type="submit"]`,
+	".card", ".panel", `[class*="Card"]`, `[class*="Panel"]`, `[class*="card"]`, `[class*="panel"]`,
+	"input", "select", "textarea", "form",
+	"table", `[role="grid"]`, ".table", ".data-grid", `[class*="Table"]`, `[class*="Grid"]`,
+	"header", "nav", `[role="banner"]`, `[role="navigation"]`,
+	".sidebar", "aside", `[class*="Sidebar"]`, `[class*="Menu"]`,
+	".modal", `[role="dialog"]`, `[class*="Modal"]`, `[class*="Dialog"]`,
+	".dropdown", `[class*="Dropdown"]`, `[class*="Select"]`,
+	".chart", `[class*="Chart"]`, `[class*="Graph"]`, "canvas", "svg",
+}
+
+// componentScan is the result of componentTagJS: the tagged components
+// and the page-level part of the analysis.
+type componentScan struct {
+	Found            []taggedComponent `json:"found"`
+	Layout           layoutInfo        `json:"layout"`
+	CustomProperties map[string]string `json:"customProperties"`
+	PageInfo         pageInfo          `json:"pageInfo"`
+}
+
+type taggedComponent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// componentTagJS tags up to %[2]d elements per selector of %[1]s with
+// data-explorer-id (an element matching several selectors keeps its first
+// ID) and returns them with the page layout and CSS custom properties.
+const componentTagJS = `
+(function(selectors, perSelector) {
+	document.querySelectorAll('[data-explorer-id]').forEach(el => el.removeAttribute('data-explorer-id'));
+	let nextId = 1;
+	const found = [];
+	selectors.forEach(selector => {
+		const matches = document.querySelectorAll(selector);
+		for (let i = 0; i < matches.length && i < perSelector; i++) {
+			const el = matches[i];
+			let id = el.getAttribute('data-explorer-id');
+			if (!id) {
+				id = String(nextId++).padStart(3, '0');
+				el.setAttribute('data-explorer-id', id);
+			}
+			found.push({id: id, type: selector.split(' ')[0].replace(/[\[\]\.#]/g, '')});
+		}
+	});
+
+	const layout = {
+		hasHeader: document.querySelector('header, [role="banner"]') !== null,
+		hasSidebar: document.querySelector('aside, .sidebar, [class*="Sidebar"]') !== null,
+		hasFooter: document.querySelector('footer, [role="contentinfo"]') !== null,
+		gridSystem: document.querySelector('[class*="grid"]') ? 'grid' :
+					document.querySelector('[class*="flex"]') ? 'flexbox' : 'unknown',
+		mainContent: document.querySelector('main, .main, [role="main"]') ? true : false
+	};
+
+	const customProperties = {};
+	const rootStyles = getComputedStyle(document.documentElement);
+	for (let i = 0; i < rootStyles.length; i++) {
+		const prop = rootStyles[i];
+		if (prop.startsWith('--')) {
+			customProperties[prop] = rootStyles.getPropertyValue(prop);
+		}
+	}
+
+	return {
+		found: found,
+		layout: layout,
+		customProperties: customProperties,
+		pageInfo: {
+			url: window.location.href,
+			title: document.title,
+			viewport: {width: window.innerWidth, height: window.innerHeight}
+		}
+	};
+})(%[1]s, %[2]d)
+`
+
+// componentDetailsJS serializes the tagged elements with the IDs %[1]s,
+// cutting their HTML and attribute values to %[2]d characters and their
+// text to %[3]d, with the selectors that find them again.
+const componentDetailsJS = `
+(function(ids, maxHTML, maxText) {` + domKeyJS + stableSelectorJS + `
+	return ids.map(id => {
+		const el = document.querySelector('[data-explorer-id="' + id + '"]');
+		if (!el) return null;
+		const styles = window.getComputedStyle(el);
+		const rect = el.getBoundingClientRect();
+		const selectors = selectorsOf(el);
+		el.removeAttribute('data-explorer-id');
+		const html = el.outerHTML.substring(0, maxHTML);
+		el.setAttribute('data-explorer-id', id);
+		const attributes = {};
+		Array.from(el.attributes).forEach(attr => {
+			if (attr.name !== 'data-explorer-id') {
+				attributes[attr.name] = attr.value.substring(0, maxHTML);
+			}
+		});
+		return {
+			id: id,
+			selector: selectors.length ? selectors[0].selector : el.tagName.toLowerCase(),
+			selectors: selectors,
+			html: html,
+			css: {
+				backgroundColor: styles.backgroundColor,
+				color: styles.color,
+				fontSize: styles.fontSize,
+				fontFamily: styles.fontFamily,
+				fontWeight: styles.fontWeight,
+				padding: styles.padding,
+				margin: styles.margin,
+				border: styles.border,
+				borderRadius: styles.borderRadius,
+				boxShadow: styles.boxShadow,
+				display: styles.display,
+				width: styles.width,
+				height: styles.height,
+				position: styles.position,
+				zIndex: styles.zIndex
+			},
+			text: el.textContent.trim().substring(0, maxText),
+			position: {x: rect.x, y: rect.y, width: rect.width, height: rect.height},
+			attributes: attributes
+		};
+	}).filter(c => c);
+})(%[1]s, %[2]d, %[3]d)
+`
+
+// componentDetails serializes the tagged components chunk by chunk. A chunk
+// that fails is logged and left out.
+func (e *AgicapExplorer) componentDetails(pageName string, found []taggedComponent) map[string]componentInfo {
+	chunk := e.config.GetInt("explorer.capture.evaluation_chunk")
+	if chunk <= 0 {
+		chunk = len(found)
+	}
+	maxHTML := e.config.GetInt("explorer.capture.max_component_html")
+	maxText := e.config.GetInt("explorer.capture.max_component_text")
+
+	details := make(map[string]componentInfo)
+	var ids []string
+	seen := make(map[string]bool)
+	for _, f := range found {
+		if !seen[f.ID] {
+			seen[f.ID] = true
+			ids = append(ids, f.ID)
+		}
+	}
+	for start := 0; start < len(ids); start += chunk {
+		end := min(start+chunk, len(ids))
+		list, _ := json.Marshal(ids[start:end])
+		var components []componentInfo
+		if err := chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(componentDetailsJS, list, maxHTML, maxText), &components)); err != nil {
+			e.log("⚠️ Failed to analyze components %d-%d of %s: %v", start+1, end, pageName, err)
+			continue
+		}
+		for _, c := range components {
+			details[c.ID] = c
+		}
+	}
+	return details
+}
+
+// collectStyles adds the colors, fonts and spacing of a component's styles
+// to the page-wide lists.
+func (a *pageAnalysis) collectStyles(css map[string]string) {
+	if v := css["backgroundColor"]; v != "" && v != "rgba(0, 0, 0, 0)" {
+		a.Colors = appendUnique(a.Colors, v)
+	}
+	if v := css["color"]; v != "" && v != "rgba(0, 0, 0, 0)" {
+		a.Colors = appendUnique(a.Colors, v)
+	}
+	if v := css["border"]; v != "" && v != "none" {
+		a.Colors = appendUnique(a.Colors, v)
+	}
+	if v := css["fontFamily"]; v != "" {
+		a.Fonts = appendUnique(a.Fonts, v)
+	}
+	if v := css["padding"]; v != "" && v != "0px" {
+		a.Spacing = appendUnique(a.Spacing, v)
+	}
+	if v := css["margin"]; v != "" && v != "0px" {
+		a.Spacing = appendUnique(a.Spacing, v)
+	}
+}
+
+// captureComponentScreenshots saves a PNG of every visible component whose
+// type matches the configured kinds into components/<page>/.
+func (e *AgicapExplorer) captureComponentScreenshots(pageName string, analysis *pageAnalysis) {
+	if !e.config.GetBool("explorer.capture.component_screenshots") {
+		return
+	}
+	kinds := e.config.GetStringSlice("explorer.capture.component_kinds")
+	limit := e.config.GetInt("explorer.capture.max_component_screenshots")
+
+	dir := filepath.Join(e.outputDir, "components", sanitize(pageName))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		e.log("⚠️ Failed to create %s: %v", dir, err)
+		return
+	}
+
+	taken := make(map[string]string)
+	for i := range analysis.Components {
+		c := &analysis.Components[i]
+		if path, ok := taken[c.ID]; ok {
+			c.Screenshot = path
+			continue
+		}
+		if len(taken) >= limit || c.Position.Width < 4 || c.Position.Height < 4 || !matchesKind(c.Type, kinds) {
+			continue
+		}
+
+		var buf []byte
+		selector := fmt.Sprintf(`[data-explorer-id="%s"]`, c.ID)
+		if err := chromedp.Run(e.ctx, chromedp.Screenshot(selector, &buf, chromedp.ByQuery, chromedp.AtLeast(0))); err != nil {
+			e.log("⚠️ Component screenshot %s failed: %v", c.ID, err)
+			continue
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.png", c.ID, sanitize(c.Type)))
+		if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+			continue
+		}
+		c.Screenshot = path
+		taken[c.ID] = path
+	}
+
+	if len(taken) > 0 {
+		e.log("🧩 Saved %d component screenshots for %s", len(taken), pageName)
+	}
+}
+
+func matchesKind(componentType string, kinds []string) bool {
+	t := strings.ToLower(componentType)
+	for _, k := range kinds {
+		if strings.Contains(t, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAnalysis stores the analysis as components/<page>_analysis.json.
+func (e *AgicapExplorer) writeAnalysis(pageName string, analysis *pageAnalysis) error {
+	data, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return err
+	}
+	componentsPath := filepath.Join(e.outputDir, "components", sanitize(pageName)+"_analysis.json")
+	defer e.bench.Start("disk_writes")()
+	return ioutil.WriteFile(componentsPath, data, 0644)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
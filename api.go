@@ -0,0 +1,431 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxEndpointExamples bounds how many concrete URLs are kept per endpoint.
+const maxEndpointExamples = 3
+
+// apiEndpoint aggregates the XHR/fetch calls that share a method and path
+// template.
+type apiEndpoint struct {
+	Method           string                 `json:"method"`
+	Host             string                 `json:"host"`
+	Path             string                 `json:"path"`
+	Calls            int                    `json:"calls"`
+	Statuses         []int64                `json:"statuses"`
+	QueryParams      []string               `json:"query_params,omitempty"`
+	RequestType      string                 `json:"request_content_type,omitempty"`
+	ResponseType     string                 `json:"response_content_type,omitempty"`
+	RequestSchema    map[string]interface{} `json:"request_schema,omitempty"`
+	ResponseSchema   map[string]interface{} `json:"response_schema,omitempty"`
+	Examples         []string               `json:"examples"`
+	Pages            []string               `json:"pages,omitempty"`
+	Fixtures         []string               `json:"fixtures,omitempty"`
+	AverageLatencyMS float64                `json:"average_latency_ms"`
+
+	samples []string
+}
+
+// buildAPIInventory groups the recorded API calls into endpoints, ordered by
+// host, path and method, keeping up to maxSamples distinct JSON response
+// bodies per endpoint.
+func buildAPIInventory(entries []networkEntry, maxSamples int) []*apiEndpoint {
+	byKey := make(map[string]*apiEndpoint)
+	totalLatency := make(map[string]float64)
+
+	for _, entry := range entries {
+		if entry.kind != "XHR" && entry.kind != "Fetch" {
+			continue
+		}
+		u, err := url.Parse(entry.request.URL)
+		if err != nil {
+			continue
+		}
+		path := routeTemplate(u.String())
+		key := entry.request.Method + " " + u.Host + path
+
+		ep, ok := byKey[key]
+		if !ok {
+			ep = &apiEndpoint{Method: entry.request.Method, Host: u.Host, Path: path}
+			byKey[key] = ep
+		}
+		ep.Calls++
+		ep.Statuses = appendUniqueInt(ep.Statuses, entry.response.Status)
+		for name := range u.Query() {
+			ep.QueryParams = appendUnique(ep.QueryParams, name)
+		}
+		if len(ep.Examples) < maxEndpointExamples {
+			ep.Examples = appendUnique(ep.Examples, entry.request.URL)
+		}
+		if entry.page != "" {
+			ep.Pages = appendUnique(ep.Pages, entry.page)
+		}
+		totalLatency[key] += harTotal(entry.timings())
+
+		if entry.request.PostData != "" {
+			ep.RequestType = headerValue(entry.request.Headers, "Content-Type")
+			var body interface{}
+			if json.Unmarshal([]byte(entry.request.PostData), &body) == nil {
+				ep.RequestSchema = mergeSchemas(ep.RequestSchema, inferSchema(body))
+			}
+		}
+		ep.ResponseType = entry.response.MimeType
+		if entry.body != "" {
+			var body interface{}
+			if json.Unmarshal([]byte(entry.body), &body) == nil {
+				ep.ResponseSchema = mergeSchemas(ep.ResponseSchema, inferSchema(body))
+				if len(ep.samples) < maxSamples {
+					ep.samples = appendUnique(ep.samples, entry.body)
+				}
+			}
+		}
+	}
+
+	endpoints := make([]*apiEndpoint, 0, len(byKey))
+	for key, ep := range byKey {
+		sort.Strings(ep.QueryParams)
+		sort.Slice(ep.Statuses, func(i, j int) bool { return ep.Statuses[i] < ep.Statuses[j] })
+		ep.AverageLatencyMS = totalLatency[key] / float64(ep.Calls)
+		endpoints = append(endpoints, ep)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return endpoints
+}
+
+// inferSchema describes a decoded JSON value as an OpenAPI schema.
+func inferSchema(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{"nullable": true}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	case float64:
+		if v == float64(int64(v)) {
+			return map[string]interface{}{"type": "integer"}
+		}
+		return map[string]interface{}{"type": "number"}
+	case string:
+		schema := map[string]interface{}{"type": "string"}
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			schema["format"] = "date-time"
+		} else if _, err := time.Parse("2006-01-02", v); err == nil {
+			schema["format"] = "date"
+		} else if uuidSegment.MatchString(v) {
+			schema["format"] = "uuid"
+		}
+		return schema
+	case []interface{}:
+		var items map[string]interface{}
+		for _, item := range v {
+			items = mergeSchemas(items, inferSchema(item))
+		}
+		if items == nil {
+			items = map[string]interface{}{}
+		}
+		return map[string]interface{}{"type": "array", "items": items}
+	case map[string]interface{}:
+		props := make(map[string]interface{}, len(v))
+		for name, field := range v {
+			props[name] = inferSchema(field)
+		}
+		return map[string]interface{}{"type": "object", "properties": props}
+	}
+	return map[string]interface{}{}
+}
+
+// mergeSchemas combines two inferred schemas: object properties are united,
+// array items merged, integer widens to number and null makes a field
+// nullable. On other conflicts the first schema wins.
+func mergeSchemas(a, b map[string]interface{}) map[string]interface{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	ta, _ := a["type"].(string)
+	tb, _ := b["type"].(string)
+	switch {
+	case ta == "":
+		merged := copySchema(b)
+		if a["nullable"] == true {
+			merged["nullable"] = true
+		}
+		return merged
+	case tb == "":
+		if b["nullable"] == true {
+			merged := copySchema(a)
+			merged["nullable"] = true
+			return merged
+		}
+		return a
+	case ta != tb:
+		if (ta == "integer" && tb == "number") || (ta == "number" && tb == "integer") {
+			merged := copySchema(a)
+			merged["type"] = "number"
+			return merged
+		}
+		return a
+	case ta == "object":
+		merged := copySchema(a)
+		props := make(map[string]interface{})
+		pa, _ := a["properties"].(map[string]interface{})
+		pb, _ := b["properties"].(map[string]interface{})
+		for name, s := range pa {
+			props[name] = s
+		}
+		for name, s := range pb {
+			existing, _ := props[name].(map[string]interface{})
+			props[name] = mergeSchemas(existing, s.(map[string]interface{}))
+		}
+		merged["properties"] = props
+		return merged
+	case ta == "array":
+		merged := copySchema(a)
+		ia, _ := a["items"].(map[string]interface{})
+		ib, _ := b["items"].(map[string]interface{})
+		merged["items"] = mergeSchemas(ia, ib)
+		return merged
+	case ta == "string" && a["format"] != b["format"]:
+		merged := copySchema(a)
+		delete(merged, "format")
+		return merged
+	}
+	return a
+}
+
+func copySchema(s map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(s))
+	for k, v := range s {
+		c[k] = v
+	}
+	return c
+}
+
+// buildOpenAPI renders the inventory as a best-effort OpenAPI 3 document.
+// The most frequently called host becomes the server; paths on other hosts
+// override it with their own servers entry.
+func buildOpenAPI(endpoints []*apiEndpoint, title string) map[string]interface{} {
+	hostCalls := make(map[string]int)
+	for _, ep := range endpoints {
+		hostCalls[ep.Host] += ep.Calls
+	}
+	primary := ""
+	for host, calls := range hostCalls {
+		if calls > hostCalls[primary] || (calls == hostCalls[primary] && host < primary) {
+			primary = host
+		}
+	}
+
+	paths := make(map[string]interface{})
+	for _, ep := range endpoints {
+		path, params := openAPIPath(ep.Path)
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			if ep.Host != primary {
+				item["servers"] = []map[string]string{{"url": "https://" + ep.Host}}
+			}
+			paths[path] = item
+		}
+
+		var parameters []map[string]interface{}
+		for _, name := range params {
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "path", "required": true,
+				"schema": map[string]string{"type": "string"},
+			})
+		}
+		for _, name := range ep.QueryParams {
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "query",
+				"schema": map[string]string{"type": "string"},
+			})
+		}
+
+		responses := make(map[string]interface{})
+		for _, status := range ep.Statuses {
+			description := http.StatusText(int(status))
+			if description == "" {
+				description = "Observed response"
+			}
+			resp := map[string]interface{}{"description": description}
+			if ep.ResponseSchema != nil && status < 300 {
+				resp["content"] = map[string]interface{}{
+					contentTypeOr(ep.ResponseType, "application/json"): map[string]interface{}{"schema": ep.ResponseSchema},
+				}
+			}
+			responses[strconv.FormatInt(status, 10)] = resp
+		}
+
+		op := map[string]interface{}{
+			"operationId": operationID(ep.Method, ep.Path),
+			"summary":     ep.Method + " " + ep.Path,
+			"responses":   responses,
+		}
+		if len(parameters) > 0 {
+			op["parameters"] = parameters
+		}
+		if ep.RequestSchema != nil {
+			op["requestBody"] = map[string]interface{}{
+				"content": map[string]interface{}{
+					contentTypeOr(ep.RequestType, "application/json"): map[string]interface{}{"schema": ep.RequestSchema},
+				},
+			}
+		}
+		if len(ep.Pages) > 0 {
+			op["x-pages"] = ep.Pages
+		}
+		item[strings.ToLower(ep.Method)] = op
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":       title,
+			"version":     "0.0.0",
+			"description": "Inferred from browser traffic recorded by the explorer; schemas are best-effort.",
+		},
+		"paths": paths,
+	}
+	if primary != "" {
+		doc["servers"] = []map[string]string{{"url": "https://" + primary}}
+	}
+	return doc
+}
+
+// openAPIPath converts :id style placeholders into numbered {param}
+// segments and returns their names.
+func openAPIPath(template string) (string, []string) {
+	segments := strings.Split(template, "/")
+	var params []string
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		name := seg[1:]
+		if len(params) > 0 {
+			name += strconv.Itoa(len(params) + 1)
+		}
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID builds a camelCase identifier like getInvoicesById.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" {
+			continue
+		}
+		if strings.HasPrefix(seg, ":") {
+			seg = "by_" + seg[1:]
+		}
+		for _, word := range strings.FieldsFunc(seg, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func contentTypeOr(contentType, fallback string) string {
+	if contentType = strings.TrimSpace(strings.Split(contentType, ";")[0]); contentType != "" {
+		return contentType
+	}
+	return fallback
+}
+
+// writeAPIInventory writes api_inventory.json and openapi.json from the
+// traffic recorded during the run.
+func (e *AgicapExplorer) writeAPIInventory() error {
+	endpoints := buildAPIInventory(e.network.All(), e.config.GetInt("explorer.network.fixtures_per_endpoint"))
+	if e.config.GetBool("explorer.network.fixtures") {
+		if err := e.writeFixtures(endpoints); err != nil {
+			e.log("⚠️ Failed to write fixtures: %v", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(endpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(e.outputDir, "api_inventory.json"), data, 0644); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(buildOpenAPI(endpoints, "Agicap API (reconstructed)"), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(e.outputDir, "openapi.json"), data, 0644); err != nil {
+		return err
+	}
+	e.log("🔌 Inventoried %d API endpoints", len(endpoints))
+	return nil
+}
+
+// apiSection lists the most called endpoints for the rebuild guide.
+func (e *AgicapExplorer) apiSection() string {
+	if !e.network.enabled {
+		return "Network recording is disabled.\n"
+	}
+	endpoints := buildAPIInventory(e.network.All(), 0)
+	if len(endpoints) == 0 {
+		return "No API calls were recorded.\n"
+	}
+	sorted := append([]*apiEndpoint(nil), endpoints...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Calls > sorted[j].Calls })
+
+	var b strings.Builder
+	for i, ep := range sorted {
+		if i == 30 {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(sorted)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- `%s %s%s` - %d calls, status %v\n", ep.Method, ep.Host, ep.Path, ep.Calls, ep.Statuses)
+	}
+	return b.String()
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func appendUniqueInt(list []int64, n int64) []int64 {
+	for _, existing := range list {
+		if existing == n {
+			return list
+		}
+	}
+	return append(list, n)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveManifest is MANIFEST.json inside the archive. It describes the
+// archived content, which differs from run.json's checksums when
+// screenshots were downscaled or files were left out for the size limits.
+type archiveManifest struct {
+	RunID    string         `json:"run_id,omitempty"`
+	Created  string         `json:"created"`
+	Source   string         `json:"source"`
+	Format   string         `json:"format"`
+	Bytes    int64          `json:"bytes"`
+	Files    []archivedFile `json:"files"`
+	Skipped  []archivedFile `json:"skipped,omitempty"`
+	MaxBytes int64          `json:"max_bytes,omitempty"`
+}
+
+type archivedFile struct {
+	Path         string `json:"path"`
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256,omitempty"`
+	OriginalSize int64  `json:"original_size,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// archiveOptions are the explorer.archive settings.
+type archiveOptions struct {
+	Format          string
+	Directory       string
+	MaxBytes        int64
+	MaxFileBytes    int64
+	ScreenshotWidth int
+}
+
+// archiveEntries writes files into a zip or tar.gz archive.
+type archiveEntries interface {
+	Add(name string, data []byte, modified time.Time) error
+	Close() error
+}
+
+type zipEntries struct{ w *zip.Writer }
+
+func (z zipEntries) Add(name string, data []byte, modified time.Time) error {
+	f, err := z.w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func (z zipEntries) Close() error { return z.w.Close() }
+
+type tarEntries struct {
+	gz *gzip.Writer
+	w  *tar.Writer
+}
+
+func (t tarEntries) Add(name string, data []byte, modified time.Time) error {
+	if err := t.w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modified}); err != nil {
+		return err
+	}
+	_, err := t.w.Write(data)
+	return err
+}
+
+func (t tarEntries) Close() error {
+	if err := t.w.Close(); err != nil {
+		return err
+	}
+	return t.gz.Close()
+}
+
+// archiveOptions reads the explorer.archive settings.
+func (e *AgicapExplorer) archiveOptions() archiveOptions {
+	return archiveOptions{
+		Format:          e.config.GetString("explorer.archive.format"),
+		Directory:       e.config.GetString("explorer.archive.directory"),
+		MaxBytes:        e.config.GetInt64("explorer.archive.max_bytes"),
+		MaxFileBytes:    e.config.GetInt64("explorer.archive.max_file_bytes"),
+		ScreenshotWidth: e.config.GetInt("explorer.archive.screenshot_width"),
+	}
+}
+
+// writeArchive packages the run directory into a timestamped zip or tar.gz
+// next to it (or in opts.Directory) and returns its path. Screenshots wider
+// than opts.ScreenshotWidth are downscaled; files over opts.MaxFileBytes
+// are left out, and once opts.MaxBytes is reached the remaining files are
+// too, smallest files going in first. Everything left out is listed in
+// MANIFEST.json.
+func writeArchive(runDir, runID string, opts archiveOptions) (string, error) {
+	format := strings.TrimPrefix(strings.ToLower(opts.Format), ".")
+	switch format {
+	case "", "zip":
+		format = "zip"
+	case "tar", "tgz", "tar.gz":
+		format = "tar.gz"
+	default:
+		return "", fmt.Errorf("unknown archive format %q (zip or tar.gz)", opts.Format)
+	}
+
+	abs, err := filepath.Abs(runDir)
+	if err != nil {
+		return "", err
+	}
+	dir := opts.Directory
+	if dir == "" {
+		dir = filepath.Dir(abs)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	base := filepath.Base(abs)
+	now := time.Now()
+	path := filepath.Join(dir, base+"-"+now.Format("20060102-150405")+"."+format)
+
+	type file struct {
+		rel  string
+		path string
+		info os.FileInfo
+	}
+	var files []file
+	err = filepath.Walk(abs, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(abs, p)
+		files = append(files, file{filepath.ToSlash(rel), p, info})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].info.Size() != files[j].info.Size() {
+			return files[i].info.Size() < files[j].info.Size()
+		}
+		return files[i].rel < files[j].rel
+	})
+
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	var entries archiveEntries
+	if format == "zip" {
+		entries = zipEntries{zip.NewWriter(out)}
+	} else {
+		gz := gzip.NewWriter(out)
+		entries = tarEntries{gz, tar.NewWriter(gz)}
+	}
+
+	manifest := archiveManifest{RunID: runID, Created: now.Format(time.RFC3339), Source: base, Format: format, MaxBytes: opts.MaxBytes}
+	for _, f := range files {
+		size := f.info.Size()
+		if opts.MaxFileBytes > 0 && size > opts.MaxFileBytes {
+			manifest.Skipped = append(manifest.Skipped, archivedFile{Path: f.rel, Size: size, Reason: "max_file_bytes"})
+			continue
+		}
+		data, err := ioutil.ReadFile(f.path)
+		if err != nil {
+			manifest.Skipped = append(manifest.Skipped, archivedFile{Path: f.rel, Size: size, Reason: err.Error()})
+			continue
+		}
+		entry := archivedFile{Path: f.rel}
+		if opts.ScreenshotWidth > 0 && strings.HasSuffix(f.rel, ".png") && strings.Contains(f.rel, "screenshots/") {
+			if scaled, ok := downscalePNG(data, opts.ScreenshotWidth); ok {
+				entry.OriginalSize = size
+				data = scaled
+			}
+		}
+		entry.Size = int64(len(data))
+		if opts.MaxBytes > 0 && manifest.Bytes+entry.Size > opts.MaxBytes {
+			manifest.Skipped = append(manifest.Skipped, archivedFile{Path: f.rel, Size: entry.Size, Reason: "max_bytes"})
+			continue
+		}
+		sum := sha256.Sum256(data)
+		entry.SHA256 = hex.EncodeToString(sum[:])
+		if err := entries.Add(base+"/"+f.rel, data, f.info.ModTime()); err != nil {
+			return "", err
+		}
+		manifest.Bytes += entry.Size
+		manifest.Files = append(manifest.Files, entry)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := entries.Add(base+"/MANIFEST.json", data, now); err != nil {
+		return "", err
+	}
+	if err := entries.Close(); err != nil {
+		return "", err
+	}
+	return path, out.Close()
+}
+
+// downscalePNG re-encodes a PNG scaled down to width. ok is false when the
+// image is already narrow enough or the result would not be smaller.
+func downscalePNG(data []byte, width int) ([]byte, bool) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil || img.Bounds().Dx() <= width {
+		return nil, false
+	}
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, scaleToWidth(img, width)); err != nil || buf.Len() >= len(data) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
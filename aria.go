@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/chromedp/chromedp"
+)
+
+// collectARIA counts the explicit role attributes and the aria-* attributes
+// of the page, with up to %d example elements each and the distinct values
+// of every attribute.
+const collectARIA = `
+(function() {
+	const maxExamples = %d;
+	const example = (el) => {
+		const clone = el.cloneNode(false);
+		const html = clone.outerHTML;
+		return {element: el.tagName.toLowerCase() + (el.id ? '#' + el.id : ''), html: html.substring(0, 300), text: el.textContent.trim().replace(/\s+/g, ' ').substring(0, 80)};
+	};
+	const roles = {};
+	const attributes = {};
+	for (const el of document.querySelectorAll('*')) {
+		const role = el.getAttribute('role');
+		if (role) {
+			for (const r of role.trim().split(/\s+/)) {
+				const entry = roles[r] || (roles[r] = {count: 0, examples: []});
+				entry.count++;
+				if (entry.examples.length < maxExamples) entry.examples.push(example(el));
+			}
+		}
+		for (const attr of el.attributes) {
+			if (!attr.name.startsWith('aria-')) continue;
+			const entry = attributes[attr.name] || (attributes[attr.name] = {count: 0, values: [], examples: []});
+			entry.count++;
+			const value = attr.value.substring(0, 80);
+			if (entry.values.length < 20 && !entry.values.includes(value)) entry.values.push(value);
+			if (entry.examples.length < maxExamples) entry.examples.push(example(el));
+		}
+	}
+	return {roles: roles, attributes: attributes};
+})()
+`
+
+// pageARIA is a11y/<page>_aria.json.
+type pageARIA struct {
+	Roles      map[string]*ariaUse `json:"roles"`
+	Attributes map[string]*ariaUse `json:"attributes"`
+}
+
+// ariaUse is how often a role or attribute is used, with example elements.
+type ariaUse struct {
+	Count    int           `json:"count"`
+	Values   []string      `json:"values,omitempty"`
+	Examples []ariaExample `json:"examples"`
+}
+
+type ariaExample struct {
+	Element string `json:"element"`
+	HTML    string `json:"html"`
+	Text    string `json:"text,omitempty"`
+	Page    string `json:"page,omitempty"`
+}
+
+// captureARIA writes the ARIA roles and attributes of the current page to
+// a11y/<page>_aria.json.
+func (e *AgicapExplorer) captureARIA(pageName string) {
+	var usage pageARIA
+	script := fmt.Sprintf(collectARIA, e.config.GetInt("explorer.capture.aria_examples"))
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(script, &usage)); err != nil {
+		e.log("⚠️ ARIA inventory failed for %s: %v", pageName, err)
+		return
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("a11y", sanitize(pageName)+"_aria.json", data); err != nil {
+		e.log("⚠️ Failed to write ARIA inventory for %s: %v", pageName, err)
+	}
+}
+
+// ariaUsage is aria_usage.json: the roles and attributes used across the
+// run, most used first.
+type ariaUsage struct {
+	Roles      []ariaEntry `json:"roles"`
+	Attributes []ariaEntry `json:"attributes"`
+}
+
+type ariaEntry struct {
+	Name     string        `json:"name"`
+	Count    int           `json:"count"`
+	Pages    []string      `json:"pages"`
+	Values   []string      `json:"values,omitempty"`
+	Examples []ariaExample `json:"examples"`
+}
+
+// ariaRollup combines the a11y/<page>_aria.json files of the run.
+func (e *AgicapExplorer) ariaRollup() ariaUsage {
+	maxExamples := e.config.GetInt("explorer.capture.aria_examples")
+	roles := make(map[string]*ariaEntry)
+	attributes := make(map[string]*ariaEntry)
+	add := func(entries map[string]*ariaEntry, name, page string, use *ariaUse) {
+		entry, ok := entries[name]
+		if !ok {
+			entry = &ariaEntry{Name: name, Examples: []ariaExample{}}
+			entries[name] = entry
+		}
+		entry.Count += use.Count
+		entry.Pages = appendUnique(entry.Pages, page)
+		for _, v := range use.Values {
+			if len(entry.Values) < 20 {
+				entry.Values = appendUnique(entry.Values, v)
+			}
+		}
+		for _, ex := range use.Examples {
+			if len(entry.Examples) >= maxExamples {
+				break
+			}
+			ex.Page = page
+			entry.Examples = append(entry.Examples, ex)
+		}
+	}
+
+	for _, item := range e.navigationMap {
+		key := pageKey(item)
+		data, err := ioutil.ReadFile(filepath.Join(e.outputDir, "a11y", key+"_aria.json"))
+		if err != nil {
+			continue
+		}
+		var usage pageARIA
+		if json.Unmarshal(data, &usage) != nil {
+			continue
+		}
+		for name, use := range usage.Roles {
+			add(roles, name, key, use)
+		}
+		for name, use := range usage.Attributes {
+			add(attributes, name, key, use)
+		}
+	}
+	return ariaUsage{Roles: sortedARIA(roles), Attributes: sortedARIA(attributes)}
+}
+
+func sortedARIA(entries map[string]*ariaEntry) []ariaEntry {
+	sorted := make([]ariaEntry, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, *entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// writeARIAUsage saves aria_usage.json.
+func (e *AgicapExplorer) writeARIAUsage(usage ariaUsage) error {
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "aria_usage.json"), data, 0644)
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+)
+
+// pageAsset is a resource referenced by a captured page.
+type pageAsset struct {
+	URL  string `json:"url"`
+	Raw  string `json:"raw"`
+	Kind string `json:"kind"`
+}
+
+// assetDownloader saves stylesheets, fonts, images and icons under a
+// subdirectory of the output (assets/ by default), downloading each URL once
+// per run with the browser's cookies.
+type assetDownloader struct {
+	mu       sync.Mutex
+	client   *http.Client
+	root     string
+	subdir   string
+	dir      string
+	maxBytes int64
+	saved    map[string]string
+}
+
+// cssURLPattern matches url(...) references inside stylesheets.
+var cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+
+// collectAssets lists the resources the current page uses: referenced
+// stylesheets, images and SVGs plus everything the resource timing API saw
+// (which includes webfonts loaded from CSS).
+const collectAssets = `
+(function() {
+	const assets = [];
+	const push = (raw, url, kind) => { if (url && /^https?:/.test(url)) assets.push({raw: raw || url, url: url, kind: kind}); };
+	document.querySelectorAll('link[rel~="stylesheet"][href]').forEach(el => push(el.getAttribute('href'), el.href, 'css'));
+	document.querySelectorAll('link[rel~="icon"][href], link[rel="apple-touch-icon"][href]').forEach(el => push(el.getAttribute('href'), el.href, 'images'));
+	document.querySelectorAll('img[src]').forEach(el => push(el.getAttribute('src'), el.src, 'images'));
+	document.querySelectorAll('use[href], use[*|href]').forEach(el => {
+		const raw = el.getAttribute('href') || el.getAttribute('xlink:href');
+		if (raw && !raw.startsWith('#')) push(raw, new URL(raw.split('#')[0], location.href).href, 'icons');
+	});
+	performance.getEntriesByType('resource').forEach(entry => {
+		const isScript = /\.m?js(\?|$)/.test(entry.name);
+		if (!isScript && (entry.initiatorType === 'css' || entry.initiatorType === 'link' || entry.initiatorType === 'img')) {
+			push(entry.name, entry.name, '');
+		}
+	});
+	return assets;
+})()
+`
+
+func newAssetDownloader(outputDir, subdir string, maxBytes int64) *assetDownloader {
+	jar, _ := cookiejar.New(nil)
+	return &assetDownloader{
+		client:   &http.Client{Jar: jar, Timeout: 30 * time.Second},
+		root:     outputDir,
+		subdir:   subdir,
+		dir:      filepath.Join(outputDir, subdir),
+		maxBytes: maxBytes,
+		saved:    make(map[string]string),
+	}
+}
+
+// syncCookies copies the browser's cookies into the HTTP client so assets
+// behind the login can be fetched.
+func (d *assetDownloader) syncCookies(ctx context.Context) error {
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		cookies, err := storage.GetCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range cookies {
+			host := strings.TrimPrefix(c.Domain, ".")
+			u := &url.URL{Scheme: "https", Host: host, Path: "/"}
+			d.client.Jar.SetCookies(u, []*http.Cookie{{
+				Name:   c.Name,
+				Value:  c.Value,
+				Path:   c.Path,
+				Domain: c.Domain,
+				Secure: c.Secure,
+			}})
+		}
+		return nil
+	}))
+}
+
+// Download saves an asset and returns its path relative to the output
+// directory. Stylesheets have their url() references downloaded and rewritten.
+func (d *assetDownloader) Download(rawURL, kind string) (string, error) {
+	d.mu.Lock()
+	if rel, ok := d.saved[rawURL]; ok {
+		d.mu.Unlock()
+		return rel, nil
+	}
+	d.mu.Unlock()
+
+	resp, err := d.client.Get(rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, d.maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(body)) > d.maxBytes {
+		return "", fmt.Errorf("larger than %d bytes", d.maxBytes)
+	}
+
+	if kind == "" {
+		kind = assetKind(rawURL, resp.Header.Get("Content-Type"))
+	}
+	rel := filepath.ToSlash(filepath.Join(d.subdir, kind, assetFileName(rawURL)))
+
+	// Register before rewriting so circular @imports terminate
+	d.mu.Lock()
+	d.saved[rawURL] = rel
+	d.mu.Unlock()
+
+	if kind == "css" {
+		body = []byte(d.rewriteCSS(string(body), rawURL))
+	}
+
+	dest := filepath.Join(d.root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	return rel, ioutil.WriteFile(dest, body, 0644)
+}
+
+// rewriteCSS downloads everything a stylesheet references and points the
+// url() values at the local copies (relative to assets/css/).
+func (d *assetDownloader) rewriteCSS(css, cssURL string) string {
+	base, err := url.Parse(cssURL)
+	if err != nil {
+		return css
+	}
+	return cssURLPattern.ReplaceAllStringFunc(css, func(match string) string {
+		ref := cssURLPattern.FindStringSubmatch(match)[1]
+		if strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+			return match
+		}
+		target, err := base.Parse(ref)
+		if err != nil {
+			return match
+		}
+		rel, err := d.Download(target.String(), "")
+		if err != nil {
+			return match
+		}
+		return fmt.Sprintf("url(%q)", "../"+strings.TrimPrefix(rel, d.subdir+"/"))
+	})
+}
+
+// downloadAssets saves every asset used by the current page and rewrites the
+// saved HTML file to reference the local copies.
+func (e *AgicapExplorer) downloadAssets(pageName, htmlPath, pageHTML string) []pageAsset {
+	if e.assets == nil {
+		return nil
+	}
+	if err := e.assets.syncCookies(e.ctx); err != nil {
+		e.log("⚠️ Failed to read browser cookies for asset download: %v", err)
+	}
+
+	var found []pageAsset
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(collectAssets, &found)); err != nil {
+		e.log("⚠️ Failed to list assets for %s: %v", pageName, err)
+		return nil
+	}
+
+	var saved []pageAsset
+	seen := make(map[string]bool)
+	for _, a := range found {
+		if seen[a.URL] {
+			continue
+		}
+		seen[a.URL] = true
+
+		rel, err := e.assets.Download(a.URL, a.Kind)
+		if err != nil {
+			e.log("⚠️ Asset download failed (%s): %v", a.URL, err)
+			continue
+		}
+		local := "../" + rel
+		pageHTML = strings.ReplaceAll(pageHTML, `"`+a.Raw+`"`, `"`+local+`"`)
+		if a.Raw != a.URL {
+			pageHTML = strings.ReplaceAll(pageHTML, `"`+a.URL+`"`, `"`+local+`"`)
+		}
+		a.Kind = strings.Split(rel, "/")[1]
+		saved = append(saved, a)
+	}
+
+	if err := ioutil.WriteFile(htmlPath, []byte(pageHTML), 0644); err != nil {
+		e.log("⚠️ Failed to rewrite %s: %v", htmlPath, err)
+	}
+	e.log("📦 Saved %d assets for %s", len(saved), pageName)
+	return saved
+}
+
+// assetKind classifies an asset into a subdirectory of assets/.
+func assetKind(rawURL, contentType string) string {
+	ext := strings.ToLower(path.Ext(strings.SplitN(rawURL, "?", 2)[0]))
+	switch {
+	case ext == ".css" || strings.Contains(contentType, "text/css"):
+		return "css"
+	case ext == ".svg" || strings.Contains(contentType, "svg"):
+		return "icons"
+	case ext == ".woff" || ext == ".woff2" || ext == ".ttf" || ext == ".otf" || ext == ".eot" || strings.Contains(contentType, "font"):
+		return "fonts"
+	case strings.HasPrefix(contentType, "image/") || ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif" || ext == ".webp" || ext == ".ico":
+		return "images"
+	}
+	return "other"
+}
+
+// assetFileName builds a collision-free local file name for an asset URL.
+func assetFileName(rawURL string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	prefix := hex.EncodeToString(sum[:])[:10]
+
+	name := "asset"
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "/" && base != "." {
+			name = base
+		}
+	}
+	return prefix + "_" + sanitize(name)
+}
+
+// WriteIndex stores the URL -> local path mapping as <subdir>/index.json.
+func (d *assetDownloader) WriteIndex() error {
+	d.mu.Lock()
+	data, err := json.MarshalIndent(d.saved, "", "  ")
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(d.dir, "index.json"), data, 0644)
+}
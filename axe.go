@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// bundledAxe holds third_party/axe-core/axe.min.js, the copy of axe-core
+// injected unless explorer.capture.axe_script names another.
+//
+//go:embed third_party/axe-core
+var bundledAxe embed.FS
+
+// axeScript is the axe-core source, loaded once from the bundled copy or
+// explorer.capture.axe_script (a file, or a URL when opted in) and shared
+// by the tabs.
+type axeScript struct {
+	once   sync.Once
+	source string
+}
+
+// axeViolation is a failed axe-core rule with the elements that fail it.
+type axeViolation struct {
+	ID          string    `json:"id"`
+	Impact      string    `json:"impact"`
+	Description string    `json:"description"`
+	Help        string    `json:"help"`
+	HelpURL     string    `json:"helpUrl"`
+	Tags        []string  `json:"tags"`
+	Nodes       []axeNode `json:"nodes"`
+	NodeCount   int       `json:"nodeCount"`
+}
+
+type axeNode struct {
+	Target         []string `json:"target"`
+	HTML           string   `json:"html"`
+	FailureSummary string   `json:"failureSummary"`
+}
+
+// axeAudit is a11y/<page>_axe.json.
+type axeAudit struct {
+	URL        string         `json:"url"`
+	Version    string         `json:"axeVersion"`
+	Tags       []string       `json:"tags"`
+	Violations []axeViolation `json:"violations"`
+}
+
+// runAxeJS runs the rules of the tags (%s, a JSON array) and keeps the
+// first %d elements of every violation.
+const runAxeJS = `
+axe.run(document, {runOnly: {type: 'tag', values: %s}, resultTypes: ['violations']}).then(r => ({
+	url: r.url,
+	axeVersion: r.testEngine.version,
+	violations: r.violations.map(v => ({
+		id: v.id,
+		impact: v.impact || '',
+		description: v.description,
+		help: v.help,
+		helpUrl: v.helpUrl,
+		tags: v.tags,
+		nodeCount: v.nodes.length,
+		nodes: v.nodes.slice(0, %d).map(n => ({
+			target: n.target.map(String),
+			html: n.html.substring(0, 300),
+			failureSummary: n.failureSummary || '',
+		})),
+	})),
+}))
+`
+
+// checkAxeBundle fails when the audit would use the bundled axe-core but
+// the build does not contain it.
+func checkAxeBundle(v *viper.Viper) error {
+	if !v.GetBool("explorer.capture.axe") || v.GetString("explorer.capture.axe_script") != "" {
+		return nil
+	}
+	if _, err := bundledAxe.ReadFile("third_party/axe-core/axe.min.js"); err != nil {
+		return fmt.Errorf("explorer.capture.axe is enabled but this build has no third_party/axe-core/axe.min.js; add it and rebuild, set explorer.capture.axe_script or disable explorer.capture.axe")
+	}
+	return nil
+}
+
+// load reads the axe-core source on first use. It returns "" when it could
+// not be read, after logging why once.
+func (a *axeScript) load(e *AgicapExplorer) string {
+	a.once.Do(func() {
+		location := e.config.GetString("explorer.capture.axe_script")
+		var data []byte
+		var err error
+		switch {
+		case location == "":
+			location = "the bundled copy"
+			data, err = bundledAxe.ReadFile("third_party/axe-core/axe.min.js")
+		case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+			// The script runs in the authenticated app with access to the
+			// session, so a remote copy is only used when configured
+			e.log("⚠️ Injecting axe-core from %s into the app's pages", location)
+			data, err = downloadText(location)
+		default:
+			data, err = ioutil.ReadFile(location)
+		}
+		if err != nil {
+			e.log("⚠️ Failed to load axe-core from %s, skipping the accessibility audit: %v", location, err)
+			return
+		}
+		a.source = string(data)
+	})
+	return a.source
+}
+
+func downloadText(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// captureAxeAudit injects axe-core into the current page, runs the WCAG rules
+// of explorer.capture.axe_tags and writes the violations to
+// a11y/<page>_axe.json.
+func (e *AgicapExplorer) captureAxeAudit(pageName string) {
+	source := e.axe.load(e)
+	if source == "" {
+		return
+	}
+	tags := e.config.GetStringSlice("explorer.capture.axe_tags")
+	tagsJSON, _ := json.Marshal(tags)
+
+	// Evaluated through CDP, so the page's Content-Security-Policy does not
+	// block it
+	var loaded bool
+	audit := axeAudit{Tags: tags}
+	err := chromedp.Run(e.ctx,
+		chromedp.Evaluate(`typeof axe !== 'undefined'`, &loaded),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if loaded {
+				return nil
+			}
+			return chromedp.Evaluate(source, nil).Do(ctx)
+		}),
+		chromedp.Evaluate(fmt.Sprintf(runAxeJS, tagsJSON, e.config.GetInt("explorer.capture.max_axe_nodes")), &audit, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+	)
+	if err != nil {
+		e.log("⚠️ Accessibility audit failed for %s: %v", pageName, err)
+		return
+	}
+	if audit.Violations == nil {
+		audit.Violations = []axeViolation{}
+	}
+
+	data, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("a11y", sanitize(pageName)+"_axe.json", data); err != nil {
+		e.log("⚠️ Failed to write accessibility audit for %s: %v", pageName, err)
+	}
+	if len(audit.Violations) > 0 {
+		e.log("♿ %d accessibility rules violated on %s", len(audit.Violations), pageName)
+	}
+}
+
+// axeImpacts orders violations from the most severe.
+var axeImpacts = map[string]int{"critical": 0, "serious": 1, "moderate": 2, "minor": 3}
+
+// axeRule is a rule violated somewhere in the run, for the roll-up in
+// report.html.
+type axeRule struct {
+	ID      string
+	Impact  string
+	Help    string
+	HelpURL string
+	Pages   []string
+	Nodes   int
+}
+
+// axeRollup combines the a11y/<page>_axe.json files of the run by rule, most
+// severe and most frequent first.
+func (e *AgicapExplorer) axeRollup() []axeRule {
+	byID := make(map[string]*axeRule)
+	for _, item := range e.navigationMap {
+		data, err := ioutil.ReadFile(filepath.Join(e.outputDir, "a11y", pageKey(item)+"_axe.json"))
+		if err != nil {
+			continue
+		}
+		var audit axeAudit
+		if json.Unmarshal(data, &audit) != nil {
+			continue
+		}
+		for _, v := range audit.Violations {
+			rule, ok := byID[v.ID]
+			if !ok {
+				rule = &axeRule{ID: v.ID, Impact: v.Impact, Help: v.Help, HelpURL: v.HelpURL}
+				byID[v.ID] = rule
+			}
+			rule.Pages = append(rule.Pages, item.Title)
+			rule.Nodes += v.NodeCount
+		}
+	}
+
+	rules := make([]axeRule, 0, len(byID))
+	for _, rule := range byID {
+		rules = append(rules, *rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if ia, ib := impactRank(a.Impact), impactRank(b.Impact); ia != ib {
+			return ia < ib
+		}
+		if a.Nodes != b.Nodes {
+			return a.Nodes > b.Nodes
+		}
+		return a.ID < b.ID
+	})
+	return rules
+}
+
+func impactRank(impact string) int {
+	if rank, ok := axeImpacts[impact]; ok {
+		return rank
+	}
+	return len(axeImpacts)
+}
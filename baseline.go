@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// baselineActions are the actions of `explorer baseline <action>`.
+var baselineActions = map[string]func(args []string) error{
+	"set":   runBaselineSet,
+	"check": runBaselineCheck,
+}
+
+// runBaseline manages the screenshots later runs are checked against for
+// visual regressions:
+//
+//	explorer baseline set [-dir baseline] run/
+//	explorer baseline check [-dir baseline] [-thresholds file] [-tolerance n] [-sensitivity s] [-json] [-out file] [-html dir] [-locale en|de] run/
+func runBaseline(args []string) error {
+	if len(args) == 0 || baselineActions[args[0]] == nil {
+		return fmt.Errorf("usage: baseline <set|check> [flags] <run>")
+	}
+	return baselineActions[args[0]](args[1:])
+}
+
+// baselineInfo is baseline.json, written next to the baseline's
+// navigation map and screenshots.
+type baselineInfo struct {
+	Run     string    `json:"run"`
+	Created time.Time `json:"created"`
+	Pages   int       `json:"pages"`
+}
+
+// baselineThresholds is thresholds.json in the baseline directory: the
+// perceptual difference in percent a page may show before the check fails,
+// by the first pattern matching its URL or title, else Default.
+type baselineThresholds struct {
+	Default float64             `json:"default"`
+	Pages   []baselineThreshold `json:"pages"`
+}
+
+type baselineThreshold struct {
+	Match     string  `json:"match"` // regular expression
+	Threshold float64 `json:"threshold"`
+	re        *regexp.Regexp
+}
+
+// For returns the threshold of a page.
+func (t *baselineThresholds) For(item NavigationItem) float64 {
+	for _, p := range t.Pages {
+		if p.re.MatchString(item.CanonicalURL) || p.re.MatchString(item.URL) || p.re.MatchString(item.Title) {
+			return p.Threshold
+		}
+	}
+	return t.Default
+}
+
+func loadBaselineThresholds(path string) (*baselineThresholds, error) {
+	t := &baselineThresholds{Default: 0.1}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range t.Pages {
+		if t.Pages[i].re, err = regexp.Compile(t.Pages[i].Match); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return t, nil
+}
+
+// runBaselineSet stores a run as the baseline: its navigation map and the
+// screenshot of every page, the lossless original where the run kept one,
+// with the page's ignore regions.
+// Existing thresholds.json is kept, a default one is written otherwise.
+func runBaselineSet(args []string) error {
+	fs := flag.NewFlagSet("baseline set", flag.ContinueOnError)
+	dir := fs.String("dir", "baseline", "baseline directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: baseline set [-dir baseline] <run>")
+	}
+	run := fs.Arg(0)
+	items, err := loadNavigationMap(run)
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(filepath.Join(*dir, "screenshots")); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+	copied := 0
+	for _, item := range items {
+		source := comparableScreenshot(run, item)
+		rel, _ := filepath.Rel(run, source)
+		data, err := ioutil.ReadFile(source)
+		if err != nil {
+			fmt.Printf("⚠️ No screenshot of %s: %v\n", item.URL, err)
+			continue
+		}
+		target := filepath.Join(*dir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(target, data, 0644); err != nil {
+			return err
+		}
+		if data, err := ioutil.ReadFile(ignoreFile(run, item)); err == nil {
+			if err := ioutil.WriteFile(ignoreFile(*dir, item), data, 0644); err != nil {
+				return err
+			}
+		}
+		copied++
+	}
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(*dir, "navigation_map.json"), data, 0644); err != nil {
+		return err
+	}
+	if data, err = json.MarshalIndent(baselineInfo{Run: run, Created: time.Now(), Pages: copied}, "", "  "); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(*dir, "baseline.json"), data, 0644); err != nil {
+		return err
+	}
+	thresholds := filepath.Join(*dir, "thresholds.json")
+	if _, err := os.Stat(thresholds); os.IsNotExist(err) {
+		data, _ := json.MarshalIndent(baselineThresholds{Default: 0.1, Pages: []baselineThreshold{}}, "", "  ")
+		if err := ioutil.WriteFile(thresholds, data, 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("📌 Baseline %s set from %s with %d screenshots\n", *dir, run, copied)
+	return nil
+}
+
+// baselineCheck is the result of `explorer baseline check`.
+type baselineCheck struct {
+	Baseline string         `json:"baseline"`
+	Run      string         `json:"run"`
+	Failed   int            `json:"failed"`
+	Pages    []baselinePage `json:"pages"`
+	Missing  []comparedPage `json:"missing"` // baseline pages the run did not capture
+	Added    []comparedPage `json:"added"`   // pages not in the baseline
+}
+
+// baselinePage is the difference of a page's screenshot from its baseline.
+// Perceptual is the share of pixels whose color difference in YIQ space is
+// visible at the sensitivity, which ignores the noise of anti-aliasing and
+// compression that Pixel counts.
+type baselinePage struct {
+	URL        string  `json:"url"`
+	Title      string  `json:"title"`
+	Pixel      float64 `json:"pixel_diff_percent"`
+	Perceptual float64 `json:"perceptual_diff_percent"`
+	Threshold  float64 `json:"threshold_percent"`
+	Resized    bool    `json:"resized,omitempty"`
+	Failed     bool    `json:"failed"`
+	Error      string  `json:"error,omitempty"`
+	Diff       string  `json:"diff,omitempty"` // diff image under -html
+	Ignored    int     `json:"ignored_regions,omitempty"`
+
+	key, before, after string
+	ignore             []image.Rectangle
+}
+
+// runBaselineCheck compares the screenshots of a run with the baseline and
+// fails when a page's perceptual difference exceeds its threshold or a
+// baseline page is missing from the run.
+func runBaselineCheck(args []string) error {
+	fs := flag.NewFlagSet("baseline check", flag.ContinueOnError)
+	dir := fs.String("dir", "baseline", "baseline directory")
+	thresholdsPath := fs.String("thresholds", "", "per-page thresholds (default <dir>/thresholds.json)")
+	tolerance := fs.Int("tolerance", 16, "per-channel difference (0-255) below which pixels count as equal")
+	sensitivity := fs.Float64("sensitivity", 0.1, "perceptual color difference (0-1) below which pixels count as equal")
+	asJSON := fs.Bool("json", false, "print the check as JSON instead of Markdown")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	htmlDir := fs.String("html", "", "write comparison.html with diff images of the changed pages into this directory")
+	locale := fs.String("locale", "", "language of comparison.html (en, de)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: baseline check [-dir baseline] [-thresholds file] [-tolerance n] [-sensitivity s] [-json] [-out file] [-html dir] [-locale en|de] <run>")
+	}
+	if *thresholdsPath == "" {
+		*thresholdsPath = filepath.Join(*dir, "thresholds.json")
+	}
+	thresholds, err := loadBaselineThresholds(*thresholdsPath)
+	if err != nil {
+		return err
+	}
+
+	check, err := checkBaseline(*dir, fs.Arg(0), thresholds, *tolerance, *sensitivity)
+	if err != nil {
+		return err
+	}
+	if *htmlDir != "" {
+		if err := baselineHTML(check, *htmlDir, *locale, *sensitivity); err != nil {
+			return err
+		}
+	}
+	var report []byte
+	if *asJSON {
+		if report, err = json.MarshalIndent(check, "", "  "); err != nil {
+			return err
+		}
+	} else {
+		report = []byte(baselineMarkdown(check))
+	}
+	if *out != "" {
+		err = ioutil.WriteFile(*out, report, 0644)
+	} else {
+		_, err = os.Stdout.Write(report)
+	}
+	if err != nil {
+		return err
+	}
+	if failed := check.Failed + len(check.Missing); failed > 0 {
+		return fmt.Errorf("%d pages differ from the baseline", failed)
+	}
+	return nil
+}
+
+// checkBaseline matches the pages of a run to the baseline by canonical URL
+// and diffs their screenshots, masking the ignore regions of both.
+func checkBaseline(dir, run string, thresholds *baselineThresholds, tolerance int, sensitivity float64) (*baselineCheck, error) {
+	baseline, err := loadNavigationMap(dir)
+	if err != nil {
+		return nil, fmt.Errorf("no baseline in %s: %w", dir, err)
+	}
+	items, err := loadNavigationMap(run)
+	if err != nil {
+		return nil, err
+	}
+	key := func(item NavigationItem) string {
+		if item.CanonicalURL != "" {
+			return item.CanonicalURL
+		}
+		return item.URL
+	}
+	current := make(map[string]NavigationItem)
+	for _, item := range items {
+		if _, ok := current[key(item)]; !ok {
+			current[key(item)] = item
+		}
+	}
+
+	check := &baselineCheck{Baseline: dir, Run: run, Pages: []baselinePage{}, Missing: []comparedPage{}, Added: []comparedPage{}}
+	seen := make(map[string]bool)
+	for _, b := range baseline {
+		url := key(b)
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+		item, ok := current[url]
+		if !ok {
+			check.Missing = append(check.Missing, comparedPage{url, b.Title})
+			continue
+		}
+		page := baselinePage{URL: url, Title: item.Title, Threshold: thresholds.For(b), key: pageKey(item)}
+		page.before, page.after = comparableScreenshot(dir, b), comparableScreenshot(run, item)
+		page.ignore = append(ignoredRegions(dir, b), ignoredRegions(run, item)...)
+		page.Ignored = len(page.ignore)
+		before, errB := decodeMasked(page.before, page.ignore)
+		after, errA := decodeMasked(page.after, page.ignore)
+		switch {
+		case errB != nil:
+			page.Error = "baseline screenshot: " + errB.Error()
+		case errA != nil:
+			page.Error = "screenshot: " + errA.Error()
+		default:
+			page.Pixel = pixelDiff(before, after, tolerance)
+			page.Perceptual = perceptualDiff(before, after, sensitivity)
+			page.Resized = before.Bounds().Size() != after.Bounds().Size()
+		}
+		page.Failed = page.Error != "" || page.Perceptual > page.Threshold
+		if page.Failed {
+			check.Failed++
+		}
+		check.Pages = append(check.Pages, page)
+	}
+	for _, item := range items {
+		if url := key(item); !seen[url] {
+			seen[url] = true
+			check.Added = append(check.Added, comparedPage{url, item.Title})
+		}
+	}
+	sort.SliceStable(check.Pages, func(i, j int) bool { return check.Pages[i].Perceptual > check.Pages[j].Perceptual })
+	return check, nil
+}
+
+// baselineHTML writes the diff images of the pages that differ from the
+// baseline and comparison.html showing them, failed pages open, into dir.
+func baselineHTML(c *baselineCheck, dir, locale string, sensitivity float64) error {
+	data := comparisonData{
+		Heading: "Baseline Check",
+		RunA:    c.Baseline,
+		RunB:    c.Run,
+		Counts:  []reportCount{{"Checked", len(c.Pages)}, {"Failed", c.Failed}, {"Missing", len(c.Missing)}, {"New", len(c.Added)}},
+	}
+	for i := range c.Pages {
+		p := &c.Pages[i]
+		if p.Error != "" || p.Perceptual == 0 {
+			continue
+		}
+		before, errB := decodeMasked(p.before, p.ignore)
+		after, errA := decodeMasked(p.after, p.ignore)
+		if errB != nil || errA != nil {
+			continue
+		}
+		page := comparisonPage{
+			Title:   p.Title,
+			URL:     p.URL,
+			Failed:  p.Failed,
+			Summary: fmt.Sprintf("%.2f%% / %.2f%%", p.Perceptual, p.Threshold),
+		}
+		if err := writeTriptych(dir, p.key, before, after, sensitivity, &page); err != nil {
+			return err
+		}
+		p.Diff = filepath.Join(dir, filepath.FromSlash(page.Diff))
+		data.Pages = append(data.Pages, page)
+	}
+	return writeComparisonHTML(dir, locale, data)
+}
+
+// perceptualDiff returns the percentage of pixels whose YIQ color
+// difference, the measure pixelmatch uses, exceeds sensitivity (0-1) of
+// the largest possible one. Alpha is blended onto white; pixels outside the
+// overlap of differently sized images count as different.
+func perceptualDiff(a, b image.Image, sensitivity float64) float64 {
+	ba, bb := a.Bounds(), b.Bounds()
+	w, h := min(ba.Dx(), bb.Dx()), min(ba.Dy(), bb.Dy())
+	total := max(ba.Dx()*ba.Dy(), bb.Dx()*bb.Dy())
+	if total == 0 {
+		return 0
+	}
+	limit := 35215 * sensitivity * sensitivity
+	differing := total - w*h
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if colorDelta(a.At(ba.Min.X+x, ba.Min.Y+y), b.At(bb.Min.X+x, bb.Min.Y+y)) > limit {
+				differing++
+			}
+		}
+	}
+	return float64(differing) * 100 / float64(total)
+}
+
+// colorDelta is the squared YIQ distance of two colors, 0 to 35215.
+func colorDelta(c1, c2 color.Color) float64 {
+	yiq := func(c color.Color) (float64, float64, float64) {
+		r, g, b, a := c.RGBA()
+		// Premultiplied, so blending onto white adds the missing coverage
+		white := float64(0xffff - a)
+		rf := (float64(r) + white) / 257
+		gf := (float64(g) + white) / 257
+		bf := (float64(b) + white) / 257
+		return rf*0.29889531 + gf*0.58662247 + bf*0.11448223,
+			rf*0.59597799 - gf*0.27417610 - bf*0.32180189,
+			rf*0.21147017 - gf*0.52261711 + bf*0.31114694
+	}
+	y1, i1, q1 := yiq(c1)
+	y2, i2, q2 := yiq(c2)
+	dy, di, dq := y1-y2, i1-i2, q1-q2
+	return 0.5053*dy*dy + 0.299*di*di + 0.1957*dq*dq
+}
+
+// baselineMarkdown renders the check with the failing pages first.
+func baselineMarkdown(c *baselineCheck) string {
+	var b strings.Builder
+	b.WriteString("# 📌 Baseline Check\n\n")
+	fmt.Fprintf(&b, "**Generated:** %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Checking `%s` against `%s`\n\n", c.Run, c.Baseline)
+	fmt.Fprintf(&b, "%d pages checked, %d failed, %d missing, %d new.\n", len(c.Pages), c.Failed, len(c.Missing), len(c.Added))
+
+	if len(c.Pages) > 0 {
+		b.WriteString("\n## 🖼️ Pages\n\n| | Page | Perceptual Diff | Threshold | Pixel Diff |\n|---|---|---|---|---|\n")
+		pages := append([]baselinePage(nil), c.Pages...)
+		sort.SliceStable(pages, func(i, j int) bool { return pages[i].Failed && !pages[j].Failed })
+		for _, p := range pages {
+			status := "✅"
+			if p.Failed {
+				status = "❌"
+			}
+			diff := fmt.Sprintf("%.2f%%", p.Perceptual)
+			switch {
+			case p.Error != "":
+				diff = p.Error
+			case p.Resized:
+				diff += " (resized)"
+			}
+			if p.Ignored > 0 {
+				diff += fmt.Sprintf(" (%d regions ignored)", p.Ignored)
+			}
+			if p.Diff != "" {
+				diff = fmt.Sprintf("[%s](%s)", diff, filepath.ToSlash(p.Diff))
+			}
+			fmt.Fprintf(&b, "| %s | %s `%s` | %s | %.2f%% | %.2f%% |\n", status, p.Title, p.URL, diff, p.Threshold, p.Pixel)
+		}
+	}
+	pages := func(title string, list []comparedPage) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		for _, p := range list {
+			fmt.Fprintf(&b, "- **%s** - `%s`\n", p.Title, p.URL)
+		}
+	}
+	pages("➖ Missing Pages", c.Missing)
+	pages("➕ New Pages", c.Added)
+	return b.String()
+}
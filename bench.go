@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases of a run timed by -bench. They can nest: disk_writes includes the
+// files written while taking screenshots or analyzing components, and
+// capture contains most of the others.
+var benchPhases = []string{"login", "navigation", "capture", "screenshot", "component_analysis", "interaction", "disk_writes", "report"}
+
+// benchmark adds up the time a run spends per phase and per page. A nil
+// benchmark (without -bench) records nothing.
+type benchmark struct {
+	mu      sync.Mutex
+	started time.Time
+	phases  map[string]*phaseTiming
+	pages   map[string]time.Duration
+}
+
+type phaseTiming struct {
+	total time.Duration
+	count int
+	max   time.Duration
+}
+
+// timingsReport is timings.json.
+type timingsReport struct {
+	TotalMS int64          `json:"total_ms"`
+	Phases  []phaseReport  `json:"phases"`
+	Slowest []pageTimingMS `json:"slowest_pages"`
+}
+
+type phaseReport struct {
+	Phase   string  `json:"phase"`
+	TotalMS int64   `json:"total_ms"`
+	Count   int     `json:"count"`
+	AvgMS   int64   `json:"avg_ms"`
+	MaxMS   int64   `json:"max_ms"`
+	Share   float64 `json:"share"` // of the run's wall-clock time
+}
+
+type pageTimingMS struct {
+	Page string `json:"page"`
+	MS   int64  `json:"ms"`
+}
+
+func newBenchmark(started time.Time) *benchmark {
+	return &benchmark{started: started, phases: make(map[string]*phaseTiming), pages: make(map[string]time.Duration)}
+}
+
+// Start times a phase until the returned function is called.
+func (b *benchmark) Start(phase string) func() {
+	if b == nil {
+		return func() {}
+	}
+	started := time.Now()
+	return func() { b.Add(phase, time.Since(started)) }
+}
+
+// Add records a phase that took d.
+func (b *benchmark) Add(phase string, d time.Duration) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t, ok := b.phases[phase]
+	if !ok {
+		t = &phaseTiming{}
+		b.phases[phase] = t
+	}
+	t.total += d
+	t.count++
+	if d > t.max {
+		t.max = d
+	}
+}
+
+// Page records the time from navigating to a page until its interactions
+// were done.
+func (b *benchmark) Page(pageName string, d time.Duration) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pages[pageName] += d
+}
+
+// Report summarizes the timings with the 10 slowest pages.
+func (b *benchmark) Report() timingsReport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	total := time.Since(b.started)
+	report := timingsReport{TotalMS: total.Milliseconds(), Phases: []phaseReport{}, Slowest: []pageTimingMS{}}
+	for _, phase := range benchPhases {
+		t, ok := b.phases[phase]
+		if !ok {
+			continue
+		}
+		report.Phases = append(report.Phases, phaseReport{
+			Phase:   phase,
+			TotalMS: t.total.Milliseconds(),
+			Count:   t.count,
+			AvgMS:   (t.total / time.Duration(t.count)).Milliseconds(),
+			MaxMS:   t.max.Milliseconds(),
+			Share:   float64(t.total) / float64(total),
+		})
+	}
+	for page, d := range b.pages {
+		report.Slowest = append(report.Slowest, pageTimingMS{Page: page, MS: d.Milliseconds()})
+	}
+	sort.Slice(report.Slowest, func(i, j int) bool {
+		return report.Slowest[i].MS > report.Slowest[j].MS
+	})
+	if len(report.Slowest) > 10 {
+		report.Slowest = report.Slowest[:10]
+	}
+	return report
+}
+
+// writeTimings saves timings.json and logs where the run's time went.
+func (e *AgicapExplorer) writeTimings() error {
+	report := e.bench.Report()
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(e.outputDir, "timings.json"), data, 0644); err != nil {
+		return err
+	}
+
+	e.log("⏱️ Run took %s", time.Duration(report.TotalMS)*time.Millisecond)
+	for _, p := range report.Phases {
+		e.log("   %-20s %10s  %5.1f%%  %4d× avg %s, max %s", p.Phase,
+			time.Duration(p.TotalMS)*time.Millisecond, p.Share*100, p.Count,
+			time.Duration(p.AvgMS)*time.Millisecond, time.Duration(p.MaxMS)*time.Millisecond)
+	}
+	if len(report.Slowest) > 0 {
+		slowest := make([]string, 0, 3)
+		for _, p := range report.Slowest[:min(3, len(report.Slowest))] {
+			slowest = append(slowest, p.Page+" ("+(time.Duration(p.MS)*time.Millisecond).String()+")")
+		}
+		e.log("   slowest pages: %s", strings.Join(slowest, ", "))
+	}
+	return nil
+}
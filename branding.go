@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// brandAsset is a favicon, logo or social image found on a page.
+type brandAsset struct {
+	Kind   string `json:"kind"` // favicon, touch_icon, logo, og_image
+	URL    string `json:"url,omitempty"`
+	Alt    string `json:"alt,omitempty"`
+	Page   string `json:"page"`
+	File   string `json:"file"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// collectBranding finds favicons, touch icons, social preview images and
+// logos (images or inline SVGs whose class, id, alt or label mention "logo").
+const collectBranding = `
+(function() {
+	const found = [];
+	const add = (kind, url, alt, svg) => found.push({kind: kind, url: url || '', alt: alt || '', svg: svg || ''});
+	document.querySelectorAll('link[rel~="icon"][href], link[rel="shortcut icon"][href], link[rel="mask-icon"][href]').forEach(el => add('favicon', el.href));
+	document.querySelectorAll('link[rel^="apple-touch-icon"][href]').forEach(el => add('touch_icon', el.href));
+	document.querySelectorAll('meta[property="og:image"], meta[name="twitter:image"]').forEach(el => {
+		if (el.content) add('og_image', new URL(el.content, location.href).href);
+	});
+	const isLogo = el => /logo|brand/i.test([
+		typeof el.className === 'string' ? el.className : el.getAttribute('class'),
+		el.id, el.getAttribute('alt'), el.getAttribute('aria-label'), el.getAttribute('src'), el.getAttribute('title')
+	].join(' '));
+	document.querySelectorAll('img').forEach(el => {
+		if (isLogo(el) || (el.closest('a') && isLogo(el.closest('a')))) add('logo', el.currentSrc || el.src, el.alt);
+	});
+	document.querySelectorAll('svg').forEach(el => {
+		const holder = el.closest('a, div, span') || el;
+		if (isLogo(el) || isLogo(holder)) add('logo', '', el.getAttribute('aria-label') || '', el.outerHTML);
+	});
+	if (!found.some(f => f.kind === 'favicon')) add('favicon', new URL('/favicon.ico', location.href).href);
+	return found;
+})()
+`
+
+// extractBranding saves the brand artwork of the current page into branding/.
+func (e *AgicapExplorer) extractBranding(pageName string) {
+	if e.branding == nil {
+		return
+	}
+	if err := e.branding.syncCookies(e.ctx); err != nil {
+		e.log("⚠️ Failed to read browser cookies for branding: %v", err)
+	}
+
+	var found []struct {
+		Kind string `json:"kind"`
+		URL  string `json:"url"`
+		Alt  string `json:"alt"`
+		SVG  string `json:"svg"`
+	}
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(collectBranding, &found)); err != nil {
+		e.log("⚠️ Failed to collect branding for %s: %v", pageName, err)
+		return
+	}
+
+	for _, f := range found {
+		key := f.URL
+		if f.SVG != "" {
+			sum := sha1.Sum([]byte(f.SVG))
+			key = "inline:" + hex.EncodeToString(sum[:])
+		}
+		e.mu.Lock()
+		seen := e.shared().brandSeen
+		duplicate := key == "" || seen[key]
+		seen[key] = true
+		e.mu.Unlock()
+		if duplicate {
+			continue
+		}
+
+		asset := brandAsset{Kind: f.Kind, URL: f.URL, Alt: f.Alt, Page: pageName}
+		if f.SVG != "" {
+			name := fmt.Sprintf("%s.svg", strings.TrimPrefix(key, "inline:")[:12])
+			path, err := e.writeArtifact(filepath.Join("branding", f.Kind), name, []byte(f.SVG))
+			if err != nil {
+				continue
+			}
+			asset.File, _ = filepath.Rel(e.outputDir, path)
+			asset.Inline = true
+		} else {
+			rel, err := e.branding.Download(f.URL, f.Kind)
+			if err != nil {
+				e.log("⚠️ Brand asset download failed (%s): %v", f.URL, err)
+				continue
+			}
+			asset.File = rel
+		}
+		e.mu.Lock()
+		s := e.shared()
+		s.brandAssets = append(s.brandAssets, asset)
+		e.mu.Unlock()
+	}
+}
+
+// writeBrandingIndex stores the list of brand assets as branding/index.json.
+func (e *AgicapExplorer) writeBrandingIndex() error {
+	data, err := json.MarshalIndent(e.brandAssets, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = e.writeArtifact("branding", "index.json", data)
+	return err
+}
+
+// brandingSection renders the brand assets for the rebuild guide.
+func (e *AgicapExplorer) brandingSection() string {
+	if len(e.brandAssets) == 0 {
+		return "No brand assets were extracted.\n"
+	}
+	var b strings.Builder
+	for _, a := range e.brandAssets {
+		label := a.URL
+		if a.Inline {
+			label = "inline SVG"
+		}
+		if a.Alt != "" {
+			label += " (" + a.Alt + ")"
+		}
+		fmt.Fprintf(&b, "- **%s** `%s` - %s\n", a.Kind, a.File, label)
+	}
+	return b.String()
+}
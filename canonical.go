@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// URLNormalizer rewrites a parsed URL in place as one step of canonicalization.
+type URLNormalizer interface {
+	Normalize(u *url.URL)
+}
+
+// NormalizerFunc adapts a plain function to the URLNormalizer interface.
+type NormalizerFunc func(u *url.URL)
+
+func (f NormalizerFunc) Normalize(u *url.URL) { f(u) }
+
+// defaultStrippedParams are tracking parameters that never change page content.
+var defaultStrippedParams = []string{"utm_*", "fbclid", "gclid", "mc_cid", "mc_eid", "ref"}
+
+// Canonicalizer turns raw URLs into a stable form used for deduplication.
+type Canonicalizer struct {
+	normalizers []URLNormalizer
+}
+
+// NewCanonicalizer builds the normalizer chain from configuration.
+func NewCanonicalizer(v *viper.Viper) *Canonicalizer {
+	params := v.GetStringSlice("explorer.canonical.strip_params")
+	if !v.GetBool("explorer.canonical.keep_tracking_params") {
+		params = append(params, defaultStrippedParams...)
+	}
+
+	c := &Canonicalizer{}
+	c.Use(NormalizerFunc(lowercaseHost))
+	c.Use(NormalizerFunc(dropFragment))
+	c.Use(stripQueryParams(params))
+	c.Use(NormalizerFunc(sortQuery))
+	if v.GetBool("explorer.canonical.trim_trailing_slash") {
+		c.Use(NormalizerFunc(trimTrailingSlash))
+	}
+	return c
+}
+
+// Use appends a normalizer to the chain.
+func (c *Canonicalizer) Use(n URLNormalizer) {
+	c.normalizers = append(c.normalizers, n)
+}
+
+// Canonical returns the canonical form of rawURL, or rawURL unchanged if it
+// cannot be parsed.
+func (c *Canonicalizer) Canonical(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	for _, n := range c.normalizers {
+		n.Normalize(u)
+	}
+	return u.String()
+}
+
+func lowercaseHost(u *url.URL) {
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "https" && strings.HasSuffix(u.Host, ":443")) || (u.Scheme == "http" && strings.HasSuffix(u.Host, ":80")) {
+		u.Host = u.Host[:strings.LastIndex(u.Host, ":")]
+	}
+}
+
+func dropFragment(u *url.URL) {
+	u.Fragment = ""
+	u.RawFragment = ""
+}
+
+func sortQuery(u *url.URL) {
+	// Encode sorts by key, so equivalent queries serialize identically
+	u.RawQuery = u.Query().Encode()
+}
+
+func trimTrailingSlash(u *url.URL) {
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = ""
+	}
+}
+
+// stripQueryParams removes the named query parameters; a trailing "*" matches
+// any parameter with that prefix (e.g. "utm_*").
+func stripQueryParams(params []string) URLNormalizer {
+	exact := make(map[string]bool)
+	var prefixes []string
+	for _, p := range params {
+		p = strings.ToLower(p)
+		if strings.HasSuffix(p, "*") {
+			prefixes = append(prefixes, strings.TrimSuffix(p, "*"))
+		} else {
+			exact[p] = true
+		}
+	}
+	sort.Strings(prefixes)
+
+	return NormalizerFunc(func(u *url.URL) {
+		if u.RawQuery == "" {
+			return
+		}
+		q := u.Query()
+		for key := range q {
+			lower := strings.ToLower(key)
+			if exact[lower] {
+				q.Del(key)
+				continue
+			}
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(lower, prefix) {
+					q.Del(key)
+					break
+				}
+			}
+		}
+		u.RawQuery = q.Encode()
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// writeArtifact stores data as <outputDir>/<subdir>/<name>, creating the
+// directory on first use, and returns the written path.
+func (e *AgicapExplorer) writeArtifact(subdir, name string, data []byte) (string, error) {
+	dir := filepath.Join(e.outputDir, subdir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	defer e.bench.Start("disk_writes")()
+	return path, ioutil.WriteFile(path, data, 0644)
+}
+
+// capturePDF prints the current page to pdf/<page>.pdf with backgrounds so
+// it matches the on-screen rendering.
+func (e *AgicapExplorer) capturePDF(pageName string) string {
+	var buf []byte
+	err := chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		buf, _, err = page.PrintToPDF().
+			WithPrintBackground(true).
+			WithPreferCSSPageSize(true).
+			Do(ctx)
+		return err
+	}))
+	if err != nil {
+		e.log("⚠️ PDF export failed for %s: %v", pageName, err)
+		return ""
+	}
+
+	path, err := e.writeArtifact("pdf", sanitize(pageName)+".pdf", buf)
+	if err != nil {
+		e.log("⚠️ Failed to write PDF for %s: %v", pageName, err)
+		return ""
+	}
+	return path
+}
+
+// captureMHTML saves a self-contained MHTML snapshot (HTML plus CSS, images
+// and fonts) next to the raw HTML so pages stay viewable offline.
+func (e *AgicapExplorer) captureMHTML(pageName string) string {
+	var snapshot string
+	err := chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		snapshot, err = page.CaptureSnapshot().WithFormat(page.CaptureSnapshotFormatMhtml).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		e.log("⚠️ MHTML snapshot failed for %s: %v", pageName, err)
+		return ""
+	}
+
+	path, err := e.writeArtifact("html", sanitize(pageName)+".mhtml", []byte(snapshot))
+	if err != nil {
+		e.log("⚠️ Failed to write MHTML for %s: %v", pageName, err)
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,1153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// extractCharts finds the charts on the page. Chart.js, ECharts and
+// Highcharts expose their data, which is read directly; for any other SVG
+// chart the marks (paths, rects, circles in page coordinates relative to the
+// SVG), the texts and the legend are returned for parsing on the Go side.
+// Each chart element is tagged with data-explorer-chart for screenshots.
+const extractCharts = `
+(function() {
+	const probe = document.createElement('span');
+	document.body.appendChild(probe);
+	const color = (c) => {
+		if (typeof c !== 'string' || !c) return '';
+		probe.style.color = '';
+		probe.style.color = c;
+		return probe.style.color ? getComputedStyle(probe).color : '';
+	};
+	const visible = (el) => {
+		const s = getComputedStyle(el), r = el.getBoundingClientRect();
+		return s.display !== 'none' && s.visibility !== 'hidden' && r.width >= 120 && r.height >= 80;
+	};
+	const number = (v) => {
+		if (v && typeof v === 'object') v = 'y' in v ? v.y : 'value' in v ? v.value : Array.isArray(v) ? v[v.length - 1] : null;
+		return typeof v === 'number' || (typeof v === 'string' && v.trim() !== '' && !isNaN(v)) ? Number(v) : null;
+	};
+	const title = (el) => {
+		let node = el.parentElement;
+		for (let i = 0; node && i < 5; i++, node = node.parentElement) {
+			const h = Array.from(node.querySelectorAll('h1, h2, h3, h4, h5, h6, [class*="title" i]'))
+				.find(h => !h.closest('svg') && !el.contains(h) && h.textContent.trim() && h.textContent.trim().length <= 80);
+			if (h) return h.textContent.trim();
+		}
+		return '';
+	};
+	const legend = (el) => {
+		let node = el.parentElement;
+		for (let i = 0; node && i < 4; i++, node = node.parentElement) {
+			const items = Array.from(node.querySelectorAll('[class*="legend" i] li, [class*="legend-item" i], [class*="legendItem" i]'));
+			if (!items.length) continue;
+			return items.map(item => {
+				let c = '';
+				const mark = item.querySelector('path, rect, circle, line');
+				if (mark) {
+					const s = getComputedStyle(mark);
+					c = s.fill !== 'none' && !s.fill.startsWith('url') ? s.fill : s.stroke;
+				}
+				if (!c) {
+					const swatch = Array.from(item.querySelectorAll('*')).find(e => {
+						const r = e.getBoundingClientRect(), bg = getComputedStyle(e).backgroundColor;
+						return r.width <= 20 && r.height <= 20 && bg !== 'rgba(0, 0, 0, 0)' && bg !== 'transparent';
+					});
+					if (swatch) c = getComputedStyle(swatch).backgroundColor;
+				}
+				return {text: item.textContent.trim().slice(0, 60), color: c};
+			}).filter(item => item.text);
+		}
+		return [];
+	};
+	const skipped = /axis|grid|legend|tooltip|tick|label|cursor|reference|background/i;
+	const ignore = (el, svg) => {
+		for (let n = el; n && n !== svg; n = n.parentElement) {
+			if (['defs', 'clipPath', 'mask', 'pattern', 'marker', 'text'].includes(n.tagName)) return true;
+			if (skipped.test(n.getAttribute('class') || '')) return true;
+		}
+		return false;
+	};
+
+	const charts = [];
+	const add = (el, chart) => {
+		const r = el.getBoundingClientRect();
+		chart.index = charts.length;
+		chart.title = title(el);
+		chart.width = Math.round(r.width);
+		chart.height = Math.round(r.height);
+		chart.legend = legend(el);
+		el.setAttribute('data-explorer-chart', chart.index);
+		charts.push(chart);
+	};
+
+	document.querySelectorAll('canvas').forEach(canvas => {
+		if (!visible(canvas)) return;
+		const chart = {kind: 'canvas', library: 'canvas'};
+		try {
+			const c = window.Chart && window.Chart.getChart && window.Chart.getChart(canvas);
+			if (c) {
+				chart.library = 'chartjs';
+				chart.type = c.config.type;
+				chart.labels = (c.data.labels || []).map(String);
+				chart.datasets = c.data.datasets.map(d => ({name: d.label || '', type: d.type || '',
+					color: color(Array.isArray(d.borderColor) ? '' : d.borderColor) || color(Array.isArray(d.backgroundColor) ? d.backgroundColor[0] : d.backgroundColor),
+					values: (d.data || []).map(number)}));
+			}
+		} catch (e) {}
+		try {
+			const host = canvas.closest('[_echarts_instance_]');
+			const c = host && window.echarts && window.echarts.getInstanceByDom(host);
+			if (c) {
+				const option = c.getOption();
+				const axis = (option.xAxis || [])[0] || {};
+				chart.library = 'echarts';
+				chart.labels = (axis.data || []).map(d => String(d && typeof d === 'object' ? d.value : d));
+				chart.datasets = (option.series || []).map(s => ({name: s.name || '', type: s.type || '', color: color(s.itemStyle && s.itemStyle.color),
+					values: (s.data || []).map(number)}));
+				const pie = (option.series || []).find(s => s.type === 'pie');
+				if (pie) chart.labels = (pie.data || []).map(d => String(d.name || ''));
+			}
+		} catch (e) {}
+		add(canvas, chart);
+	});
+
+	document.querySelectorAll('svg').forEach(svg => {
+		if (!visible(svg) || svg.parentElement.closest('svg') || svg.closest('[data-explorer-chart]')) return;
+		if (svg.querySelectorAll('path, rect, circle, polyline').length < 2) return;
+		const cls = (svg.getAttribute('class') || '') + ' ' + (svg.parentElement.getAttribute('class') || '');
+		const chart = {kind: 'svg', library: /recharts/.test(cls) ? 'recharts' : /highcharts/.test(cls) ? 'highcharts' :
+			/apexcharts/.test(cls) ? 'apexcharts' : /nivo/.test(cls) ? 'nivo' : 'svg'};
+		try {
+			const c = window.Highcharts && (window.Highcharts.charts || []).find(c => c && c.container && c.container.contains(svg));
+			if (c) {
+				chart.library = 'highcharts';
+				chart.labels = ((c.xAxis && c.xAxis[0] && (c.xAxis[0].categories || c.xAxis[0].names)) || []).map(String);
+				chart.datasets = c.series.map(s => ({name: s.name || '', type: s.type || '', color: color(s.color),
+					values: (s.yData || s.points.map(p => p.y)).map(number)}));
+				const pie = c.series.find(s => s.type === 'pie');
+				if (pie) chart.labels = pie.points.map(p => String(p.name || ''));
+			}
+		} catch (e) {}
+
+		if (!chart.datasets) {
+			const origin = svg.getBoundingClientRect();
+			chart.marks = [];
+			svg.querySelectorAll('path, rect, circle, polyline, polygon').forEach(el => {
+				if (chart.marks.length >= 2000 || ignore(el, svg)) return;
+				const s = getComputedStyle(el);
+				if (s.display === 'none' || s.visibility === 'hidden' || s.opacity === '0') return;
+				const r = el.getBoundingClientRect();
+				const mark = {shape: el.tagName.toLowerCase(), fill: s.fill, stroke: s.strokeWidth === '0px' ? 'none' : s.stroke,
+					class: el.getAttribute('class') || '',
+					x: r.x - origin.x, y: r.y - origin.y, width: r.width, height: r.height};
+				if (mark.shape === 'path' || mark.shape === 'polyline' || mark.shape === 'polygon') {
+					const m = el.getScreenCTM();
+					if (!m) return;
+					mark.m = [m.a, m.b, m.c, m.d, m.e - origin.x, m.f - origin.y];
+					mark.d = (mark.shape === 'path' ? el.getAttribute('d') : 'M' + el.getAttribute('points') + (mark.shape === 'polygon' ? 'Z' : '')) || '';
+					if (mark.d.length > 50000) return;
+				}
+				chart.marks.push(mark);
+			});
+			chart.texts = Array.from(svg.querySelectorAll('text')).map(t => {
+				const r = t.getBoundingClientRect();
+				let axis = '';
+				for (let n = t; n && n !== svg; n = n.parentElement) {
+					const c = n.getAttribute('class') || '';
+					if (/x-?axis/i.test(c)) { axis = 'x'; break; }
+					if (/y-?axis/i.test(c)) { axis = 'y'; break; }
+				}
+				return {text: t.textContent.trim(), x: r.x + r.width / 2 - origin.x, y: r.y + r.height / 2 - origin.y, axis: axis};
+			}).filter(t => t.text && t.text.length <= 40);
+		}
+		add(svg, chart);
+	});
+
+	probe.remove();
+	return charts;
+})()
+`
+
+// rawChart is one chart as returned by extractCharts.
+type rawChart struct {
+	Index    int            `json:"index"`
+	Kind     string         `json:"kind"`
+	Library  string         `json:"library"`
+	Title    string         `json:"title"`
+	Width    float64        `json:"width"`
+	Height   float64        `json:"height"`
+	Type     string         `json:"type"`
+	Labels   []string       `json:"labels"`
+	Datasets []chartDataset `json:"datasets"`
+	Marks    []chartMark    `json:"marks"`
+	Texts    []chartText    `json:"texts"`
+	Legend   []chartLegend  `json:"legend"`
+}
+
+type chartDataset struct {
+	Name   string     `json:"name"`
+	Type   string     `json:"type"`
+	Color  string     `json:"color"`
+	Values []*float64 `json:"values"`
+}
+
+// chartMark is a drawn shape. Paths keep their d attribute and the matrix
+// mapping their user space to the SVG's box.
+type chartMark struct {
+	Shape  string    `json:"shape"`
+	D      string    `json:"d"`
+	M      []float64 `json:"m"`
+	Fill   string    `json:"fill"`
+	Stroke string    `json:"stroke"`
+	Class  string    `json:"class"`
+	X      float64   `json:"x"`
+	Y      float64   `json:"y"`
+	Width  float64   `json:"width"`
+	Height float64   `json:"height"`
+}
+
+type chartText struct {
+	Text string  `json:"text"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Axis string  `json:"axis"`
+}
+
+type chartLegend struct {
+	Text  string `json:"text"`
+	Color string `json:"color"`
+}
+
+// chartSpec describes a chart in Recharts terms: the chart component, one
+// Line/Bar/Area/Pie/Scatter per series and the data array they read.
+type chartSpec struct {
+	ID         string                   `json:"id"`
+	Page       string                   `json:"page"`
+	Title      string                   `json:"title,omitempty"`
+	Library    string                   `json:"library"`
+	Type       string                   `json:"type"` // line, bar, area, pie, scatter, radar, composed, unknown
+	Component  string                   `json:"component,omitempty"`
+	Source     string                   `json:"source"` // library, api, svg, image
+	Width      float64                  `json:"width"`
+	Height     float64                  `json:"height"`
+	XAxis      *chartAxis               `json:"xAxis,omitempty"`
+	YAxis      *chartAxis               `json:"yAxis,omitempty"`
+	Series     []chartSeries            `json:"series"`
+	Data       []map[string]interface{} `json:"data"`
+	API        *chartAPISource          `json:"api,omitempty"`
+	Screenshot string                   `json:"screenshot,omitempty"`
+}
+
+// chartAxis holds the category labels of the x axis or the tick labels of
+// the y axis. Relative values are 0–1 fractions of the plot height, used
+// when the ticks couldn't be read as numbers.
+type chartAxis struct {
+	DataKey  string   `json:"dataKey,omitempty"`
+	Labels   []string `json:"labels,omitempty"`
+	Relative bool     `json:"relative,omitempty"`
+}
+
+type chartSeries struct {
+	DataKey string `json:"dataKey"`
+	Name    string `json:"name"`
+	Type    string `json:"type"` // Recharts element: Line, Bar, Area, Pie, Scatter, Radar
+	Color   string `json:"color,omitempty"`
+}
+
+// chartAPISource is the API response the chart data was found in.
+type chartAPISource struct {
+	URL  string `json:"url"`
+	Path string `json:"path"`
+	XKey string `json:"xKey"`
+}
+
+// chartElements maps library chart types to Recharts elements.
+var chartElements = map[string]string{
+	"line": "Line", "spline": "Line",
+	"bar": "Bar", "column": "Bar",
+	"area": "Area", "areaspline": "Area",
+	"pie": "Pie", "doughnut": "Pie",
+	"scatter": "Scatter", "bubble": "Scatter",
+	"radar": "Radar",
+}
+
+// chartComponents maps a single series element to its Recharts chart.
+var chartComponents = map[string]string{
+	"Line": "LineChart", "Bar": "BarChart", "Area": "AreaChart",
+	"Pie": "PieChart", "Scatter": "ScatterChart", "Radar": "RadarChart",
+}
+
+// captureCharts extracts the charts of the current page, matches their
+// data against the page's JSON responses and writes charts/<page>.json
+// with a screenshot per chart. It returns the number of charts.
+func (e *AgicapExplorer) captureCharts(pageName string) int {
+	var raws []rawChart
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(extractCharts, &raws)); err != nil {
+		e.log("⚠️ Chart extraction failed for %s: %v", pageName, err)
+		return 0
+	}
+	if len(raws) == 0 {
+		return 0
+	}
+
+	var entries []networkEntry
+	if e.network.enabled {
+		entries = e.network.Entries()
+		for i := range entries {
+			entries[i].body = e.redactor.Redact(entries[i].body)
+		}
+	}
+	specs := make([]*chartSpec, 0, len(raws))
+	for i := range raws {
+		raw := &raws[i]
+		spec := raw.spec()
+		spec.ID = fmt.Sprintf("%s-%d", sanitize(pageName), raw.Index+1)
+		spec.Page = pageName
+		matchChartAPI(spec, entries)
+
+		var shot []byte
+		selector := fmt.Sprintf(`[data-explorer-chart="%d"]`, raw.Index)
+		if err := chromedp.Run(e.ctx, chromedp.Screenshot(selector, &shot, chromedp.ByQuery, chromedp.AtLeast(0))); err == nil {
+			if path, err := e.writeArtifact("charts", spec.ID+".png", shot); err == nil {
+				spec.Screenshot, _ = filepath.Rel(e.outputDir, path)
+			}
+		}
+		specs = append(specs, spec)
+	}
+
+	data, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return len(specs)
+	}
+	if _, err := e.writeArtifact("charts", sanitize(pageName)+".json", data); err != nil {
+		e.log("⚠️ Failed to write charts for %s: %v", pageName, err)
+		return len(specs)
+	}
+	e.log("📈 Extracted %d charts on %s", len(specs), pageName)
+	return len(specs)
+}
+
+// writeChartSpecs combines charts/*.json into chart_specs.json.
+func (e *AgicapExplorer) writeChartSpecs() error {
+	files, _ := filepath.Glob(filepath.Join(e.outputDir, "charts", "*.json"))
+	sort.Strings(files)
+	var specs []*chartSpec
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var page []*chartSpec
+		if err := json.Unmarshal(data, &page); err != nil {
+			continue
+		}
+		specs = append(specs, page...)
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return err
+	}
+	e.log("📈 %d chart specs across %d pages", len(specs), len(files))
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "chart_specs.json"), data, 0644)
+}
+
+// spec turns the raw chart into a chart spec, from the library's data when
+// available and otherwise from the SVG marks. Canvas charts of other
+// libraries only keep their screenshot.
+func (raw *rawChart) spec() *chartSpec {
+	spec := &chartSpec{Library: raw.Library, Title: raw.Title, Width: raw.Width, Height: raw.Height, Type: "unknown", Source: "image"}
+	switch {
+	case len(raw.Datasets) > 0:
+		raw.fromDatasets(spec)
+	case len(raw.Marks) > 0:
+		raw.fromMarks(spec)
+	}
+	if spec.Data == nil {
+		spec.Data = []map[string]interface{}{}
+	}
+	if spec.Series == nil {
+		spec.Series = []chartSeries{}
+	}
+
+	elements := make(map[string]bool)
+	for _, s := range spec.Series {
+		elements[s.Type] = true
+	}
+	switch len(elements) {
+	case 0:
+	case 1:
+		spec.Component = chartComponents[spec.Series[0].Type]
+		spec.Type = strings.ToLower(spec.Series[0].Type)
+	default:
+		spec.Component, spec.Type = "ComposedChart", "composed"
+	}
+	return spec
+}
+
+// fromDatasets fills the spec from the data a chart library exposed.
+func (raw *rawChart) fromDatasets(spec *chartSpec) {
+	spec.Source = "library"
+	keys := newChartKeys()
+	for i, d := range raw.Datasets {
+		typ := d.Type
+		if typ == "" {
+			typ = raw.Type
+		}
+		element := chartElements[typ]
+		if element == "" {
+			element = "Line"
+		}
+		name := d.Name
+		if name == "" {
+			name = fmt.Sprintf("Series %d", i+1)
+		}
+		hex, _ := cssHex(d.Color)
+		spec.Series = append(spec.Series, chartSeries{DataKey: keys.add(name), Name: name, Type: element, Color: hex})
+	}
+
+	if spec.Series[0].Type == "Pie" {
+		spec.Series = spec.Series[:1]
+		spec.Series[0].DataKey = "value"
+		for i, v := range raw.Datasets[0].Values {
+			row := map[string]interface{}{"name": chartLabel(raw.Labels, i), "value": nil}
+			if v != nil {
+				row["value"] = *v
+			}
+			spec.Data = append(spec.Data, row)
+		}
+		return
+	}
+
+	spec.XAxis = &chartAxis{DataKey: "name", Labels: raw.Labels}
+	for i, d := range raw.Datasets {
+		for j, v := range d.Values {
+			for len(spec.Data) <= j {
+				spec.Data = append(spec.Data, map[string]interface{}{"name": chartLabel(raw.Labels, len(spec.Data))})
+			}
+			if v != nil {
+				spec.Data[j][spec.Series[i].DataKey] = *v
+			}
+		}
+	}
+}
+
+func chartLabel(labels []string, i int) string {
+	if i < len(labels) {
+		return labels[i]
+	}
+	return strconv.Itoa(i + 1)
+}
+
+// chartKeys hands out unique camelCase data keys.
+type chartKeys map[string]bool
+
+func newChartKeys() chartKeys { return chartKeys{"name": true} }
+
+func (k chartKeys) add(name string) string {
+	base := camelCase(name)
+	key := base
+	for i := 2; k[key]; i++ {
+		key = fmt.Sprintf("%s%d", base, i)
+	}
+	k[key] = true
+	return key
+}
+
+type point struct{ X, Y float64 }
+
+// pathArc is an elliptical arc segment, enough to measure a pie slice.
+type pathArc struct {
+	From, To point
+	Radius   float64
+	Large    bool
+}
+
+// pathShape is a parsed path: the end point of every segment, its arcs
+// and whether it is an axis-aligned rectangle.
+type pathShape struct {
+	Points []point
+	Arcs   []pathArc
+	Closed bool
+}
+
+var pathTokenPattern = regexp.MustCompile(`[MmLlHhVvCcSsQqTtAaZz]|[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// pathArgs is the number of arguments each path command takes.
+var pathArgs = map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'C': 6, 'S': 4, 'Q': 4, 'T': 2, 'A': 7, 'Z': 0}
+
+// parsePath reads an SVG path and maps its points through the matrix
+// [a b c d e f]. Curves are reduced to their end points, which are the data
+// points for the curves chart libraries draw.
+func parsePath(d string, m []float64) pathShape {
+	if len(m) != 6 {
+		m = []float64{1, 0, 0, 1, 0, 0}
+	}
+	transform := func(p point) point {
+		return point{m[0]*p.X + m[2]*p.Y + m[4], m[1]*p.X + m[3]*p.Y + m[5]}
+	}
+	scale := math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+
+	var shape pathShape
+	var cur, start point
+	var cmd byte
+	var args []float64
+	tokens := pathTokenPattern.FindAllString(d, -1)
+	flush := func() {
+		n := pathArgs[cmd&^0x20]
+		relative := cmd >= 'a'
+		for n > 0 && len(args) >= n {
+			a := args[:n]
+			args = args[n:]
+			next := cur
+			switch cmd &^ 0x20 {
+			case 'H':
+				next.X = a[0]
+				if relative {
+					next.X += cur.X
+				}
+			case 'V':
+				next.Y = a[0]
+				if relative {
+					next.Y += cur.Y
+				}
+			default:
+				next = point{a[n-2], a[n-1]}
+				if relative {
+					next.X += cur.X
+					next.Y += cur.Y
+				}
+			}
+			if cmd&^0x20 == 'A' {
+				shape.Arcs = append(shape.Arcs, pathArc{From: transform(cur), To: transform(next), Radius: a[0] * scale, Large: a[3] != 0})
+			}
+			if cmd&^0x20 == 'M' {
+				start = next
+				// Further pairs after a moveto are linetos
+				if relative {
+					cmd = 'l'
+				} else {
+					cmd = 'L'
+				}
+			}
+			cur = next
+			shape.Points = append(shape.Points, transform(cur))
+		}
+		args = nil
+	}
+	for _, t := range tokens {
+		if c := t[0] | 0x20; c >= 'a' && c <= 'z' {
+			c = t[0]
+			flush()
+			cmd = c
+			if c == 'Z' || c == 'z' {
+				shape.Closed = true
+				cur = start
+			}
+			continue
+		}
+		v, err := strconv.ParseFloat(t, 64)
+		if err == nil {
+			args = append(args, v)
+		}
+	}
+	flush()
+	return shape
+}
+
+// rect returns the bounds of a path drawn as an axis-aligned rectangle.
+func (s pathShape) rect() (x, y, w, h float64, ok bool) {
+	if len(s.Arcs) > 0 || len(s.Points) < 4 || len(s.Points) > 6 {
+		return 0, 0, 0, 0, false
+	}
+	xs, ys := make(map[int]bool), make(map[int]bool)
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range s.Points {
+		xs[int(math.Round(p.X))] = true
+		ys[int(math.Round(p.Y))] = true
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	if len(xs) > 2 || len(ys) > 2 {
+		return 0, 0, 0, 0, false
+	}
+	return minX, minY, maxX - minX, maxY - minY, true
+}
+
+// increasing keeps the leading points with strictly increasing x: the data
+// points of a line, or the top edge of an area before it returns along the
+// baseline.
+func increasing(points []point) []point {
+	for i := 1; i < len(points); i++ {
+		if points[i].X <= points[i-1].X+0.5 {
+			return points[:i]
+		}
+	}
+	return points
+}
+
+// markSeries is a series recovered from the SVG marks, in SVG pixels.
+type markSeries struct {
+	element string
+	color   string
+	points  []point
+	bars    [][4]float64 // x, y, width, height
+	slices  []float64    // pie slice angles in radians
+}
+
+// fromMarks rebuilds the series from the drawn shapes: rects are bars,
+// filled paths with arcs pie slices, stroked paths lines, filled paths
+// areas and lone circles a scatter plot. Values are read against the
+// numeric y-axis ticks when there are at least two.
+func (raw *rawChart) fromMarks(spec *chartSpec) {
+	var series []*markSeries
+	bars := make(map[string]*markSeries)
+	var pie *markSeries
+	var dots []point
+	dotColor := ""
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	extend := func(x, y, w, h float64) {
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x+w)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y+h)
+	}
+	addBar := func(color string, x, y, w, h float64) {
+		if w < 1 || h < 0.5 || w*h > raw.Width*raw.Height*0.8 {
+			return
+		}
+		s, ok := bars[color]
+		if !ok {
+			s = &markSeries{element: "Bar", color: color}
+			bars[color] = s
+			series = append(series, s)
+		}
+		s.bars = append(s.bars, [4]float64{x, y, w, h})
+		extend(x, y, w, h)
+	}
+
+	for _, mark := range raw.Marks {
+		fill, hasFill := cssHex(mark.Fill)
+		stroke, hasStroke := cssHex(mark.Stroke)
+		switch mark.Shape {
+		case "rect":
+			if hasFill {
+				addBar(fill, mark.X, mark.Y, mark.Width, mark.Height)
+			}
+			continue
+		case "circle":
+			if hasFill || hasStroke {
+				dots = append(dots, point{mark.X + mark.Width/2, mark.Y + mark.Height/2})
+				if dotColor == "" {
+					dotColor = fill
+					if !hasFill {
+						dotColor = stroke
+					}
+				}
+			}
+			continue
+		}
+
+		shape := parsePath(mark.D, mark.M)
+		if x, y, w, h, ok := shape.rect(); ok && hasFill {
+			addBar(fill, x, y, w, h)
+			continue
+		}
+		switch {
+		case len(shape.Arcs) > 0 && hasFill:
+			if pie == nil {
+				pie = &markSeries{element: "Pie", color: fill}
+				series = append(series, pie)
+			}
+			angle := 0.0
+			for _, arc := range shape.Arcs {
+				chord := math.Hypot(arc.To.X-arc.From.X, arc.To.Y-arc.From.Y)
+				if arc.Radius <= 0 {
+					continue
+				}
+				a := 2 * math.Asin(math.Min(1, chord/(2*arc.Radius)))
+				if arc.Large {
+					a = 2*math.Pi - a
+				}
+				// Donut slices have an outer and an inner arc of the same angle
+				angle = math.Max(angle, a)
+			}
+			pie.slices = append(pie.slices, angle)
+			pie.bars = append(pie.bars, [4]float64{mark.X, mark.Y, mark.Width, mark.Height})
+		case hasStroke && !hasFill && len(shape.Points) >= 2:
+			points := increasing(shape.Points)
+			if len(points) == 2 && (math.Abs(points[0].Y-points[1].Y) < 0.5 || math.Abs(points[0].X-points[1].X) < 0.5) {
+				continue // a grid or reference line
+			}
+			series = append(series, &markSeries{element: "Line", color: stroke, points: points})
+		case hasFill && len(shape.Points) >= 4 && shape.Closed:
+			series = append(series, &markSeries{element: "Area", color: fill, points: increasing(shape.Points)})
+		default:
+			continue
+		}
+		for _, p := range shape.Points {
+			extend(p.X, p.Y, 0, 0)
+		}
+	}
+
+	// An area's outline is usually drawn as a separate stroked path
+	areas := make(map[string]bool)
+	for _, s := range series {
+		if s.element == "Area" {
+			areas[s.color] = true
+		}
+	}
+	kept := series[:0]
+	for _, s := range series {
+		if s.element != "Line" || !areas[s.color] {
+			kept = append(kept, s)
+		}
+	}
+	series = kept
+	if len(series) == 0 && len(dots) >= 3 {
+		series = append(series, &markSeries{element: "Scatter", color: dotColor, points: dots})
+		for _, p := range dots {
+			extend(p.X, p.Y, 0, 0)
+		}
+	}
+	if len(series) == 0 {
+		return
+	}
+	spec.Source = "svg"
+
+	if pie != nil && len(series) == 1 {
+		raw.pieData(spec, pie)
+		return
+	}
+
+	// Axis labels: hinted by the axis groups, otherwise below and left of
+	// the plot
+	var xLabels, yLabels []chartText
+	for _, t := range raw.Texts {
+		switch {
+		case t.Axis == "x":
+			xLabels = append(xLabels, t)
+		case t.Axis == "y":
+			yLabels = append(yLabels, t)
+		case t.Axis == "" && t.Y > maxY && t.X >= minX-20 && t.X <= maxX+20:
+			xLabels = append(xLabels, t)
+		case t.Axis == "" && t.X < minX:
+			yLabels = append(yLabels, t)
+		}
+	}
+	sort.Slice(xLabels, func(i, j int) bool { return xLabels[i].X < xLabels[j].X })
+	sort.Slice(yLabels, func(i, j int) bool { return yLabels[i].Y < yLabels[j].Y })
+
+	value, relative := yScale(yLabels, minY, maxY)
+	spec.YAxis = &chartAxis{Relative: relative}
+	for _, t := range yLabels {
+		spec.YAxis.Labels = append(spec.YAxis.Labels, t.Text)
+	}
+
+	// Categories: the x labels, or the distinct x positions of the marks
+	type category struct {
+		name string
+		x    float64
+	}
+	var categories []category
+	for _, t := range xLabels {
+		categories = append(categories, category{t.Text, t.X})
+	}
+	if len(categories) == 0 {
+		var xs []float64
+		for _, s := range series {
+			for _, p := range s.points {
+				xs = append(xs, p.X)
+			}
+			for _, b := range s.bars {
+				xs = append(xs, b[0]+b[2]/2)
+			}
+		}
+		sort.Float64s(xs)
+		for _, x := range xs {
+			if len(categories) == 0 || x-categories[len(categories)-1].x > 3 {
+				categories = append(categories, category{strconv.Itoa(len(categories) + 1), x})
+			}
+		}
+	}
+	spec.XAxis = &chartAxis{DataKey: "name"}
+	rows := make([]map[string]interface{}, len(categories))
+	for i, c := range categories {
+		spec.XAxis.Labels = append(spec.XAxis.Labels, c.name)
+		rows[i] = map[string]interface{}{"name": c.name}
+	}
+	nearest := func(x float64) int {
+		best := 0
+		for i, c := range categories {
+			if math.Abs(c.x-x) < math.Abs(categories[best].x-x) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	zero := math.NaN()
+	if !relative {
+		// Pixel position of the zero line, for bars below it
+		zero = bisectZero(value, minY, maxY)
+	}
+	keys := newChartKeys()
+	names := raw.seriesNames(series)
+	for i, s := range series {
+		key := keys.add(names[i])
+		spec.Series = append(spec.Series, chartSeries{DataKey: key, Name: names[i], Type: s.element, Color: s.color})
+		for _, p := range s.points {
+			rows[nearest(p.X)][key] = value(p.Y)
+		}
+		for _, b := range s.bars {
+			y := b[1]
+			if !math.IsNaN(zero) && b[1] >= zero-1 {
+				y = b[1] + b[3]
+			}
+			rows[nearest(b[0]+b[2]/2)][key] = value(y)
+		}
+	}
+	spec.Data = rows
+}
+
+// pieData turns the slice angles into percentages named after the legend
+// or the texts of the chart.
+func (raw *rawChart) pieData(spec *chartSpec, pie *markSeries) {
+	var labels []string
+	for _, l := range raw.Legend {
+		labels = append(labels, l.Text)
+	}
+	if len(labels) != len(pie.slices) {
+		labels = nil
+		for _, t := range raw.Texts {
+			labels = append(labels, t.Text)
+		}
+	}
+	if len(labels) != len(pie.slices) {
+		labels = nil
+	}
+	spec.Series = []chartSeries{{DataKey: "value", Name: "Share", Type: "Pie", Color: pie.color}}
+	for i, angle := range pie.slices {
+		spec.Data = append(spec.Data, map[string]interface{}{
+			"name":  chartLabel(labels, i),
+			"value": math.Round(angle/(2*math.Pi)*1000) / 10,
+		})
+	}
+}
+
+// seriesNames names the series after the legend entry of the same color,
+// the legend entry in the same position, or their number.
+func (raw *rawChart) seriesNames(series []*markSeries) []string {
+	names := make([]string, len(series))
+	byColor := make(map[string]string)
+	for _, l := range raw.Legend {
+		if hex, ok := cssHex(l.Color); ok {
+			byColor[hex] = l.Text
+		}
+	}
+	for i, s := range series {
+		switch {
+		case byColor[s.color] != "":
+			names[i] = byColor[s.color]
+		case len(raw.Legend) == len(series):
+			names[i] = raw.Legend[i].Text
+		default:
+			names[i] = fmt.Sprintf("Series %d", i+1)
+		}
+	}
+	return names
+}
+
+// yScale fits a linear scale to the numeric y-axis ticks. Without two
+// numeric ticks it falls back to fractions of the plot height.
+func yScale(ticks []chartText, top, bottom float64) (func(y float64) float64, bool) {
+	var n, sumY, sumV, sumYY, sumYV float64
+	for _, t := range ticks {
+		v, ok := parseChartNumber(t.Text)
+		if !ok {
+			continue
+		}
+		n++
+		sumY += t.Y
+		sumV += v
+		sumYY += t.Y * t.Y
+		sumYV += t.Y * v
+	}
+	if den := n*sumYY - sumY*sumY; n >= 2 && math.Abs(den) > 1e-9 {
+		slope := (n*sumYV - sumY*sumV) / den
+		intercept := (sumV - slope*sumY) / n
+		return func(y float64) float64 { return roundTo(intercept+slope*y, 2) }, false
+	}
+	height := bottom - top
+	if height <= 0 {
+		height = 1
+	}
+	return func(y float64) float64 { return roundTo((bottom-y)/height, 3) }, true
+}
+
+// bisectZero finds the pixel row where the scale crosses zero, or NaN when
+// it is outside the plot.
+func bisectZero(value func(float64) float64, top, bottom float64) float64 {
+	a, b := value(top), value(bottom)
+	if a == b || (a > 0) == (b > 0) && a != 0 && b != 0 {
+		return math.NaN()
+	}
+	return top + (bottom-top)*a/(a-b)
+}
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
+
+var chartNumberPattern = regexp.MustCompile(`^([-+]?)([^\d\s+-]{0,3})([-+]?)(\d[\d.,]*)(k|K|M|Md|Mrd|B|bn)?([^\d\s]{0,3})$`)
+
+// parseChartNumber reads an axis tick such as "1,2 k€", "$5,000", "-20 %"
+// or "1.5M", guessing the decimal separator: with both separators present
+// the last one is decimal; a single separator followed by exactly three
+// digits groups thousands.
+func parseChartNumber(s string) (float64, bool) {
+	s = strings.NewReplacer("−", "-", " ", "", " ", "", " ", "", "(", "-", ")", "").Replace(strings.TrimSpace(s))
+	m := chartNumberPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	digits := m[4]
+	dot, comma := strings.LastIndex(digits, "."), strings.LastIndex(digits, ",")
+	switch {
+	case dot >= 0 && comma >= 0:
+		decimal, group := ".", ","
+		if comma > dot {
+			decimal, group = ",", "."
+		}
+		digits = strings.Replace(strings.ReplaceAll(digits, group, ""), decimal, ".", 1)
+	case dot >= 0 || comma >= 0:
+		sep := "."
+		if comma >= 0 {
+			sep = ","
+		}
+		i := strings.LastIndex(digits, sep)
+		if strings.Count(digits, sep) > 1 || (len(digits)-i-1 == 3 && !strings.HasPrefix(digits, "0")) {
+			digits = strings.ReplaceAll(digits, sep, "")
+		} else {
+			digits = strings.Replace(digits, sep, ".", 1)
+		}
+	}
+	v, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch m[5] {
+	case "k", "K":
+		v *= 1e3
+	case "M":
+		v *= 1e6
+	case "Md", "Mrd", "B", "bn":
+		v *= 1e9
+	}
+	if m[1] == "-" || m[3] == "-" {
+		v = -v
+	}
+	return v, true
+}
+
+// matchChartAPI looks for the chart's categories in the JSON responses of
+// the page: an array of objects with a field holding at least half of the
+// labels is taken as the data source. Charts parsed from SVG take the exact
+// values from the response, each series mapped to the numeric field with
+// the closest proportions.
+func matchChartAPI(spec *chartSpec, entries []networkEntry) {
+	var labels []string
+	for _, row := range spec.Data {
+		if name, ok := row["name"].(string); ok && name != "" {
+			labels = append(labels, name)
+		}
+	}
+	if len(labels) < 2 {
+		return
+	}
+	want := make(map[string]bool)
+	for _, l := range labels {
+		want[strings.ToLower(l)] = true
+	}
+
+	var best struct {
+		score int
+		entry networkEntry
+		path  string
+		key   string
+		items []map[string]interface{}
+	}
+	for _, entry := range entries {
+		if !entry.isJSONCall() || entry.body == "" {
+			continue
+		}
+		var body interface{}
+		if json.Unmarshal([]byte(entry.body), &body) != nil {
+			continue
+		}
+		walkJSONArrays(body, "$", 0, func(path string, items []map[string]interface{}) {
+			counts := make(map[string]int)
+			for _, item := range items {
+				for key, v := range item {
+					if want[strings.ToLower(strings.TrimSpace(jsonScalar(v)))] {
+						counts[key]++
+					}
+				}
+			}
+			for key, n := range counts {
+				if n > best.score || (n == best.score && key < best.key) {
+					best.score, best.entry, best.path, best.key, best.items = n, entry, path, key, items
+				}
+			}
+		})
+	}
+	if best.score < 2 || best.score*2 < len(labels) {
+		return
+	}
+	spec.API = &chartAPISource{URL: best.entry.request.URL, Path: best.path, XKey: best.key}
+	if spec.Source != "svg" {
+		return
+	}
+
+	var numeric []string
+	for key, v := range best.items[0] {
+		if _, ok := v.(float64); ok && key != best.key {
+			numeric = append(numeric, key)
+		}
+	}
+	sort.Strings(numeric)
+	if len(numeric) == 0 {
+		return
+	}
+
+	// Proportions make relative and absolute values comparable
+	profile := func(values []float64) []float64 {
+		var sum float64
+		for _, v := range values {
+			sum += math.Abs(v)
+		}
+		out := make([]float64, len(values))
+		for i, v := range values {
+			if sum > 0 {
+				out[i] = v / sum
+			}
+		}
+		return out
+	}
+	apiRows := make(map[string]map[string]interface{})
+	for _, item := range best.items {
+		apiRows[strings.ToLower(strings.TrimSpace(jsonScalar(item[best.key])))] = item
+	}
+	used := make(map[string]bool)
+	for i, s := range spec.Series {
+		var svgValues []float64
+		apiValues := make(map[string][]float64)
+		for _, row := range spec.Data {
+			item := apiRows[strings.ToLower(fmt.Sprint(row["name"]))]
+			v, ok := row[s.DataKey].(float64)
+			if item == nil || !ok {
+				continue
+			}
+			svgValues = append(svgValues, v)
+			for _, key := range numeric {
+				n, _ := item[key].(float64)
+				apiValues[key] = append(apiValues[key], n)
+			}
+		}
+		bestKey, bestErr := "", math.Inf(1)
+		svgProfile := profile(svgValues)
+		for _, key := range numeric {
+			if used[key] {
+				continue
+			}
+			var errSum float64
+			for j, v := range profile(apiValues[key]) {
+				errSum += math.Abs(v - svgProfile[j])
+			}
+			if errSum < bestErr {
+				bestKey, bestErr = key, errSum
+			}
+		}
+		if bestKey == "" {
+			bestKey = numeric[0]
+		}
+		used[bestKey] = true
+		spec.Series[i].DataKey = bestKey
+	}
+
+	spec.Source = "api"
+	if spec.Type != "pie" {
+		spec.XAxis = &chartAxis{DataKey: best.key, Labels: labels}
+	}
+	if spec.YAxis != nil {
+		spec.YAxis.Relative = false
+	}
+	spec.Data = nil
+	for _, item := range best.items {
+		row := map[string]interface{}{best.key: item[best.key]}
+		for _, s := range spec.Series {
+			row[s.DataKey] = item[s.DataKey]
+		}
+		spec.Data = append(spec.Data, row)
+	}
+}
+
+// walkJSONArrays calls fn for every array of objects in the document, up
+// to a few levels deep. Only the first element of other arrays is
+// descended into.
+func walkJSONArrays(v interface{}, path string, depth int, fn func(path string, items []map[string]interface{})) {
+	if depth > 6 {
+		return
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedInterfaceKeys(t) {
+			walkJSONArrays(t[key], path+"."+key, depth+1, fn)
+		}
+	case []interface{}:
+		var items []map[string]interface{}
+		for _, item := range t {
+			if obj, ok := item.(map[string]interface{}); ok {
+				items = append(items, obj)
+			}
+		}
+		if len(items) >= 2 && len(items) == len(t) {
+			fn(path, items)
+		}
+		if len(t) > 0 {
+			walkJSONArrays(t[0], path+"[0]", depth+1, fn)
+		}
+	}
+}
+
+func sortedInterfaceKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonScalar formats a JSON string or number for comparison with a label.
+func jsonScalar(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return ""
+}
@@ -628,7 +628,8 @@ func (e *AgicapExplorer) log(format string, args ...interface{}) {
 
 func main() {
 	fmt.Println("🚀 Agicap UI Explorer")
-	fmt.Println("=====================\n")
+	fmt.Println("=====================")
+	fmt.Println()
 
 	// Configuration
 	loginURL := "https://app.agicap.com/de/app/cashflow/forecast"
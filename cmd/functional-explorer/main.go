@@ -0,0 +1,1977 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+type FunctionalExplorer struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	config        *viper.Viper
+	visitedURLs   map[string]bool
+	navigationMap []NavigationItem
+	features      []FeatureTest
+	requests      *requestLog
+	verbose       bool
+}
+
+type NavigationItem struct {
+	URL        string   `json:"url"`
+	Title      string   `json:"title"`
+	Screenshot string   `json:"screenshot"`
+	Navigation []string `json:"navigation"`
+	Timestamp  string   `json:"timestamp"`
+}
+
+type FeatureTest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Page        string                 `json:"page"`
+	Actions     []Action               `json:"actions"`
+	Results     map[string]interface{} `json:"results"`
+	Status      string                 `json:"status"` // success, failed, partial
+	Timestamp   string                 `json:"timestamp"`
+
+	since int // request log mark at the start
+}
+
+type Action struct {
+	Type        string `json:"type"`             // click, fill, select, navigate, assert, capture, drag, slide, upload
+	Assert      string `json:"assert,omitempty"` // exists, text_contains, url_matches, request_observed, value_equals
+	Selector    string `json:"selector"`
+	Target      string `json:"target,omitempty"` // drag: the element dropped onto
+	Value       string `json:"value,omitempty"`
+	Description string `json:"description"`
+	Result      string `json:"result,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// assertionKinds lists the checks of assert actions. exists, text_contains
+// and value_equals look at Selector, url_matches matches the page URL and
+// request_observed the requests sent since the feature started (as
+// "METHOD URL") against the regular expression in Value.
+var assertionKinds = map[string]bool{"exists": true, "text_contains": true, "url_matches": true, "request_observed": true, "value_equals": true}
+
+// requestLog records the requests of the tab for request_observed
+// assertions.
+type requestLog struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (l *requestLog) listen(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if ev, ok := ev.(*network.EventRequestWillBeSent); ok {
+			l.mu.Lock()
+			l.requests = append(l.requests, ev.Request.Method+" "+ev.Request.URL)
+			l.mu.Unlock()
+		}
+	})
+}
+
+// mark returns the position of the next request, to look at the requests
+// sent from then on.
+func (l *requestLog) mark() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.requests)
+}
+
+// observed reports whether a request since mark matches re.
+func (l *requestLog) observed(mark int, re *regexp.Regexp) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, request := range l.requests[mark:] {
+		if re.MatchString(request) {
+			return true
+		}
+	}
+	return false
+}
+
+func NewFunctionalExplorer(configFile string, verbose bool) (*FunctionalExplorer, error) {
+	// Load configuration
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	v.SetConfigType("yaml")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Create output directories
+	outputDir := v.GetString("explorer.output.directory")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Create subdirectories
+	dirs := []string{"screenshots", "html", "features", "reports"}
+	for _, dir := range dirs {
+		os.MkdirAll(filepath.Join(outputDir, dir), 0755)
+	}
+
+	// Browser options with enhanced capabilities
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", v.GetBool("explorer.browser.headless")),
+		chromedp.Flag("disable-gpu", false),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-web-security", true),
+		chromedp.Flag("disable-features", "VizDisplayCompositor"),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("disable-plugins", true),
+		chromedp.Flag("disable-images", false),
+		chromedp.Flag("disable-javascript", false),
+		chromedp.Flag("window-size", v.GetString("explorer.browser.window_size")),
+		chromedp.UserAgent(v.GetString("explorer.browser.user_agent")),
+		chromedp.Flag("enable-automation", false),
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+	)
+
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+
+	// Create context with configurable timeout
+	timeoutMinutes := v.GetInt("explorer.browser.timeout_minutes")
+	ctx, cancelCtx := context.WithTimeout(allocCtx, time.Duration(timeoutMinutes)*time.Minute)
+
+	// Create browser context with custom logger
+	browserCtx, _ := chromedp.NewContext(ctx, chromedp.WithLogf(func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		// Filter out known CDP errors
+		if v.GetBool("explorer.error_handling.ignore_cdp_errors") {
+			if strings.Contains(msg, "cookiePart") ||
+				strings.Contains(msg, "parse error") ||
+				strings.Contains(msg, "initialFrameNavigation") ||
+				strings.Contains(msg, "unknown ClientNavigationReason") {
+				return
+			}
+		}
+		if verbose {
+			log.Printf(msg)
+		}
+	}))
+
+	requests := &requestLog{}
+	requests.listen(browserCtx)
+
+	return &FunctionalExplorer{
+		ctx:           browserCtx,
+		cancel:        func() { cancelCtx(); cancel() },
+		config:        v,
+		visitedURLs:   make(map[string]bool),
+		navigationMap: []NavigationItem{},
+		features:      []FeatureTest{},
+		requests:      requests,
+		verbose:       verbose,
+	}, nil
+}
+
+func (e *FunctionalExplorer) Close() {
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+func (e *FunctionalExplorer) Login(loginURL, email, password string) error {
+	e.log("🔐 Logging in to: %s", loginURL)
+
+	var err error
+	retryAttempts := e.config.GetInt("explorer.error_handling.retry_attempts")
+	retryDelay := time.Duration(e.config.GetInt("explorer.error_handling.retry_delay")) * time.Second
+
+	for i := 0; i < retryAttempts; i++ {
+		err = chromedp.Run(e.ctx,
+			chromedp.Navigate(loginURL),
+			chromedp.Sleep(3*time.Second),
+		)
+		if err == nil {
+			break
+		}
+		e.log("⚠️ Navigation attempt %d failed: %v", i+1, err)
+		time.Sleep(retryDelay)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to navigate after %d attempts: %w", retryAttempts, err)
+	}
+
+	e.log("🔑 Filling credentials...")
+
+	// Fill email/username
+	if err := chromedp.Run(e.ctx,
+		chromedp.Sleep(3*time.Second),
+		chromedp.WaitVisible(`input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"], input[placeholder*="email" i]`, chromedp.ByQuery, chromedp.NodeVisible),
+		chromedp.SendKeys(`input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"], input[placeholder*="email" i]`, email, chromedp.ByQuery),
+		chromedp.Sleep(1*time.Second),
+	); err != nil {
+		e.log("⚠️ Email input failed, trying alternative selectors...")
+		chromedp.Run(e.ctx,
+			chromedp.Click(`input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"]`, chromedp.ByQuery),
+			chromedp.Sleep(1*time.Second),
+			chromedp.SendKeys(`input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"]`, email, chromedp.ByQuery),
+		)
+	}
+
+	// Fill password
+	if err := chromedp.Run(e.ctx,
+		chromedp.WaitVisible(`input[type="password"]`, chromedp.ByQuery, chromedp.NodeVisible),
+		chromedp.SendKeys(`input[type="password"]`, password, chromedp.ByQuery),
+		chromedp.Sleep(1*time.Second),
+	); err != nil {
+		e.log("⚠️ Password input failed, trying alternative approach...")
+		chromedp.Run(e.ctx,
+			chromedp.Click(`input[type="password"]`, chromedp.ByQuery),
+			chromedp.Sleep(500*time.Millisecond),
+			chromedp.SendKeys(`input[type="password"]`, password, chromedp.ByQuery),
+		)
+	}
+
+	e.log("📤 Submitting login form...")
+	if err := chromedp.Run(e.ctx,
+		chromedp.Click(`button[type="submit"], input[type="submit"]`, chromedp.ByQuery),
+		chromedp.Sleep(5*time.Second),
+	); err != nil {
+		e.log("⚠️ Submit button click failed, trying Enter key...")
+		chromedp.Run(e.ctx,
+			chromedp.KeyEvent("\r"),
+			chromedp.Sleep(5*time.Second),
+		)
+	}
+
+	var currentURL string
+	chromedp.Run(e.ctx, chromedp.Evaluate("window.location.href", &currentURL))
+
+	if strings.Contains(currentURL, "login") || strings.Contains(currentURL, "signin") || strings.Contains(currentURL, "sign_in") {
+		var buf []byte
+		chromedp.Run(e.ctx, chromedp.CaptureScreenshot(&buf))
+		ioutil.WriteFile(filepath.Join(e.config.GetString("explorer.output.directory"), "screenshots", "login_failed.png"), buf, 0644)
+
+		return fmt.Errorf("login appears to have failed - still on login page: %s", currentURL)
+	}
+
+	e.log("✅ Login successful! Current URL: %s", currentURL)
+	return nil
+}
+
+func (e *FunctionalExplorer) CapturePage(pageName string) error {
+	e.log("📸 Capturing: %s", pageName)
+
+	var currentURL, pageTitle, pageHTML string
+	err := chromedp.Run(e.ctx,
+		chromedp.Sleep(2*time.Second),
+		chromedp.Evaluate("window.location.href", &currentURL),
+		chromedp.Evaluate("document.title", &pageTitle),
+		chromedp.OuterHTML("html", &pageHTML),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to capture page: %w", err)
+	}
+
+	e.visitedURLs[currentURL] = true
+
+	var screenshot []byte
+	screenshotPath := filepath.Join(e.config.GetString("explorer.output.directory"), "screenshots", sanitize(pageName)+".png")
+	chromedp.Run(e.ctx, chromedp.CaptureScreenshot(&screenshot))
+	ioutil.WriteFile(screenshotPath, screenshot, 0644)
+
+	htmlPath := filepath.Join(e.config.GetString("explorer.output.directory"), "html", sanitize(pageName)+".html")
+	ioutil.WriteFile(htmlPath, []byte(pageHTML), 0644)
+
+	var navLinks []string
+	chromedp.Run(e.ctx,
+		chromedp.Evaluate(`Array.from(document.querySelectorAll('a[href], button, [role="link"], [role="button"]'))
+			.map(el => ({text: el.textContent.trim(), href: el.href || el.getAttribute('onclick') || ''}))
+			.filter(l => l.text && l.text.length < 100)
+			.map(l => l.text + ' → ' + l.href)
+		`, &navLinks),
+	)
+
+	e.navigationMap = append(e.navigationMap, NavigationItem{
+		URL:        currentURL,
+		Title:      pageTitle,
+		Screenshot: screenshotPath,
+		Navigation: navLinks,
+		Timestamp:  time.Now().Format(time.RFC3339),
+	})
+
+	e.log("✅ Captured: %s", pageTitle)
+	return nil
+}
+
+func (e *FunctionalExplorer) TestLiquidityFeatures() {
+	e.log("💰 Testing Liquidity Planning Features...")
+
+	feature := FeatureTest{
+		Name:        "Liquidity Planning",
+		Description: "Test all liquidity planning and cash flow features",
+		Page:        "Liquidity Dashboard",
+		Actions:     []Action{},
+		Results:     make(map[string]interface{}),
+		Status:      "in_progress",
+		Timestamp:   time.Now().Format(time.RFC3339),
+		since:       e.requests.mark(),
+	}
+
+	// Navigate to liquidity page
+	chromedp.Run(e.ctx,
+		chromedp.Navigate("https://app.agicap.com/liquidity"),
+		chromedp.Sleep(3*time.Second),
+	)
+	e.assert(&feature, Action{
+		Type:        "assert",
+		Assert:      "url_matches",
+		Value:       "/liquidity",
+		Description: "Stay on /liquidity without being sent to the login",
+	})
+
+	e.CapturePage("liquidity_dashboard")
+
+	// Test scenario switching
+	scenarios := []string{"optimistic", "realistic", "pessimistic"}
+	for _, scenario := range scenarios {
+		e.log("🔄 Testing scenario: %s", scenario)
+
+		action := Action{
+			Type:        "select",
+			Selector:    `select[name*="scenario"], select[class*="scenario"]`,
+			Value:       scenario,
+			Description: fmt.Sprintf("Switch to %s scenario", scenario),
+		}
+
+		err := chromedp.Run(e.ctx,
+			chromedp.Click(`select[name*="scenario"], select[class*="scenario"]`, chromedp.ByQuery),
+			chromedp.Sleep(500*time.Millisecond),
+			chromedp.SendKeys(`select[name*="scenario"], select[class*="scenario"]`, scenario, chromedp.ByQuery),
+			chromedp.Sleep(2*time.Second),
+		)
+
+		if err != nil {
+			action.Result = "failed"
+		} else {
+			action.Result = "success"
+		}
+
+		feature.Actions = append(feature.Actions, action)
+	}
+
+	// Test manual transaction entry
+	e.log("📝 Testing manual transaction entry...")
+
+	// Look for add transaction button
+	addButtonSelectors := []string{
+		`button[class*="add"]`,
+		`button[class*="new"]`,
+		`button[class*="create"]`,
+		`[data-testid*="add"]`,
+		`[data-testid*="new"]`,
+		`button:contains("Add")`,
+		`button:contains("New")`,
+		`button:contains("Create")`,
+	}
+
+	for _, selector := range addButtonSelectors {
+		err := chromedp.Run(e.ctx,
+			chromedp.Click(selector, chromedp.ByQuery),
+			chromedp.Sleep(2*time.Second),
+		)
+		if err == nil {
+			e.log("✅ Found add transaction button: %s", selector)
+			e.CapturePage("transaction_form")
+			break
+		}
+	}
+
+	// Test form filling if modal opened
+	e.TestTransactionForm()
+
+	feature.Status = feature.status()
+	e.features = append(e.features, feature)
+}
+
+func (e *FunctionalExplorer) TestTransactionForm() {
+	e.log("📝 Testing transaction form...")
+
+	// Look for form fields and fill them
+	formFields := map[string]string{
+		"amount":      "100.00",
+		"description": "Test transaction from functional explorer",
+		"category":    "Office & Administration",
+		"date":        "2024-01-15",
+		"type":        "expense",
+	}
+
+	for fieldName, value := range formFields {
+		selectors := []string{
+			fmt.Sprintf(`input[name*="%s"]`, fieldName),
+			fmt.Sprintf(`input[id*="%s"]`, fieldName),
+			fmt.Sprintf(`select[name*="%s"]`, fieldName),
+			fmt.Sprintf(`select[id*="%s"]`, fieldName),
+			fmt.Sprintf(`textarea[name*="%s"]`, fieldName),
+			fmt.Sprintf(`textarea[id*="%s"]`, fieldName),
+		}
+
+		for _, selector := range selectors {
+			err := chromedp.Run(e.ctx,
+				chromedp.Click(selector, chromedp.ByQuery),
+				chromedp.Sleep(500*time.Millisecond),
+				chromedp.SendKeys(selector, value, chromedp.ByQuery),
+				chromedp.Sleep(500*time.Millisecond),
+			)
+			if err == nil {
+				e.log("✅ Filled field %s with %s", fieldName, value)
+				break
+			}
+		}
+	}
+
+	// Try to save the form
+	saveSelectors := []string{
+		`button[type="submit"]`,
+		`button[class*="save"]`,
+		`button[class*="submit"]`,
+		`button[class*="create"]`,
+		`[data-testid*="save"]`,
+		`[data-testid*="submit"]`,
+	}
+
+	for _, selector := range saveSelectors {
+		err := chromedp.Run(e.ctx,
+			chromedp.Click(selector, chromedp.ByQuery),
+			chromedp.Sleep(2*time.Second),
+		)
+		if err == nil {
+			e.log("✅ Form saved successfully")
+			break
+		}
+	}
+}
+
+func (e *FunctionalExplorer) TestCashFlowFeatures() {
+	e.log("📊 Testing Cash Flow Forecasting Features...")
+
+	feature := FeatureTest{
+		Name:        "Cash Flow Forecasting",
+		Description: "Test AI-powered cash flow forecasting features",
+		Page:        "Cash Flow Dashboard",
+		Actions:     []Action{},
+		Results:     make(map[string]interface{}),
+		Status:      "in_progress",
+		Timestamp:   time.Now().Format(time.RFC3339),
+		since:       e.requests.mark(),
+	}
+
+	// Navigate to cash flow page
+	chromedp.Run(e.ctx,
+		chromedp.Navigate("https://app.agicap.com/cashflow"),
+		chromedp.Sleep(3*time.Second),
+	)
+	e.assert(&feature, Action{
+		Type:        "assert",
+		Assert:      "url_matches",
+		Value:       "/cashflow",
+		Description: "Stay on /cashflow without being sent to the login",
+	})
+
+	e.CapturePage("cashflow_dashboard")
+
+	// Test timeframe selection
+	timeframes := []string{"6", "12", "24"}
+	for _, timeframe := range timeframes {
+		e.log("🕐 Testing timeframe: %s months", timeframe)
+
+		action := Action{
+			Type:        "select",
+			Selector:    `select[name*="timeframe"], select[class*="timeframe"]`,
+			Value:       timeframe,
+			Description: fmt.Sprintf("Set timeframe to %s months", timeframe),
+		}
+
+		err := chromedp.Run(e.ctx,
+			chromedp.Click(`select[name*="timeframe"], select[class*="timeframe"]`, chromedp.ByQuery),
+			chromedp.Sleep(500*time.Millisecond),
+			chromedp.SendKeys(`select[name*="timeframe"], select[class*="timeframe"]`, timeframe, chromedp.ByQuery),
+			chromedp.Sleep(2*time.Second),
+		)
+
+		if err != nil {
+			action.Result = "failed"
+		} else {
+			action.Result = "success"
+		}
+
+		feature.Actions = append(feature.Actions, action)
+	}
+
+	// Test AI insights
+	e.log("🤖 Testing AI insights...")
+
+	// Look for AI insights section
+	insightSelectors := []string{
+		`[class*="insight"]`,
+		`[class*="ai"]`,
+		`[data-testid*="insight"]`,
+		`[data-testid*="ai"]`,
+	}
+
+	for _, selector := range insightSelectors {
+		var insights []string
+		chromedp.Run(e.ctx,
+			chromedp.Evaluate(fmt.Sprintf(`Array.from(document.querySelectorAll('%s')).map(el => el.textContent.trim())`, selector), &insights),
+		)
+		if len(insights) > 0 {
+			e.log("✅ Found %d AI insights", len(insights))
+			feature.Results["ai_insights"] = insights
+			break
+		}
+	}
+
+	feature.Status = feature.status()
+	e.features = append(e.features, feature)
+}
+
+func (e *FunctionalExplorer) TestBankingFeatures() {
+	e.log("🏦 Testing Banking Features...")
+
+	feature := FeatureTest{
+		Name:        "Banking Integration",
+		Description: "Test bank account management and integration features",
+		Page:        "Banking Dashboard",
+		Actions:     []Action{},
+		Results:     make(map[string]interface{}),
+		Status:      "in_progress",
+		Timestamp:   time.Now().Format(time.RFC3339),
+		since:       e.requests.mark(),
+	}
+
+	// Navigate to banking page
+	chromedp.Run(e.ctx,
+		chromedp.Navigate("https://app.agicap.com/bank"),
+		chromedp.Sleep(3*time.Second),
+	)
+	e.assert(&feature, Action{
+		Type:        "assert",
+		Assert:      "url_matches",
+		Value:       "/bank",
+		Description: "Stay on /bank without being sent to the login",
+	})
+
+	e.CapturePage("banking_dashboard")
+
+	// Test bank account management
+	e.log("💳 Testing bank account management...")
+
+	// Look for add bank account button
+	addBankSelectors := []string{
+		`button[class*="add"]`,
+		`button[class*="connect"]`,
+		`button[class*="link"]`,
+		`[data-testid*="add-bank"]`,
+		`[data-testid*="connect-bank"]`,
+	}
+
+	for _, selector := range addBankSelectors {
+		err := chromedp.Run(e.ctx,
+			chromedp.Click(selector, chromedp.ByQuery),
+			chromedp.Sleep(2*time.Second),
+		)
+		if err == nil {
+			e.log("✅ Found add bank account button")
+			e.CapturePage("add_bank_account")
+			break
+		}
+	}
+
+	feature.Status = feature.status()
+	e.features = append(e.features, feature)
+}
+
+func (e *FunctionalExplorer) TestAllFeatures() {
+	e.log("🚀 Starting comprehensive feature testing...")
+
+	// Test core financial features
+	e.TestLiquidityFeatures()
+	e.TestCashFlowFeatures()
+	e.TestBankingFeatures()
+
+	// Test additional features
+	e.TestSettingsFeatures()
+	e.TestExportFeatures()
+	e.TestReportingFeatures()
+}
+
+func (e *FunctionalExplorer) TestSettingsFeatures() {
+	e.log("⚙️ Testing Settings Features...")
+
+	feature := FeatureTest{
+		Name:        "Settings & Configuration",
+		Description: "Test application settings and configuration options",
+		Page:        "Settings",
+		Actions:     []Action{},
+		Results:     make(map[string]interface{}),
+		Status:      "in_progress",
+		Timestamp:   time.Now().Format(time.RFC3339),
+		since:       e.requests.mark(),
+	}
+
+	// Navigate to settings
+	chromedp.Run(e.ctx,
+		chromedp.Navigate("https://app.agicap.com/settings"),
+		chromedp.Sleep(3*time.Second),
+	)
+	e.assert(&feature, Action{
+		Type:        "assert",
+		Assert:      "url_matches",
+		Value:       "/settings",
+		Description: "Stay on /settings without being sent to the login",
+	})
+
+	e.CapturePage("settings_dashboard")
+
+	// Test various settings sections
+	settingsSections := []string{"profile", "company", "notifications", "integrations", "billing"}
+	for _, section := range settingsSections {
+		e.log("🔧 Testing %s settings", section)
+
+		selector := fmt.Sprintf(`[href*="%s"], [class*="%s"]`, section, section)
+		chromedp.Run(e.ctx,
+			chromedp.Click(selector, chromedp.ByQuery),
+			chromedp.Sleep(2*time.Second),
+		)
+
+		e.CapturePage(fmt.Sprintf("settings_%s", section))
+	}
+
+	feature.Status = feature.status()
+	e.features = append(e.features, feature)
+}
+
+func (e *FunctionalExplorer) TestExportFeatures() {
+	e.log("📤 Testing Export Features...")
+
+	feature := FeatureTest{
+		Name:        "Export & Reporting",
+		Description: "Test data export and reporting features",
+		Page:        "Export Dashboard",
+		Actions:     []Action{},
+		Results:     make(map[string]interface{}),
+		Status:      "in_progress",
+		Timestamp:   time.Now().Format(time.RFC3339),
+		since:       e.requests.mark(),
+	}
+
+	// Navigate to export page
+	chromedp.Run(e.ctx,
+		chromedp.Navigate("https://app.agicap.com/export"),
+		chromedp.Sleep(3*time.Second),
+	)
+	e.assert(&feature, Action{
+		Type:        "assert",
+		Assert:      "url_matches",
+		Value:       "/export",
+		Description: "Stay on /export without being sent to the login",
+	})
+
+	e.CapturePage("export_dashboard")
+
+	// Test different export formats
+	exportFormats := []string{"pdf", "excel", "csv", "datev"}
+	for _, format := range exportFormats {
+		e.log("📄 Testing %s export", format)
+
+		selector := fmt.Sprintf(`button[class*="%s"], [data-format="%s"]`, format, format)
+		chromedp.Run(e.ctx,
+			chromedp.Click(selector, chromedp.ByQuery),
+			chromedp.Sleep(2*time.Second),
+		)
+	}
+
+	feature.Status = feature.status()
+	e.features = append(e.features, feature)
+}
+
+func (e *FunctionalExplorer) TestReportingFeatures() {
+	e.log("📊 Testing Reporting Features...")
+
+	feature := FeatureTest{
+		Name:        "Advanced Reporting",
+		Description: "Test advanced reporting and analytics features",
+		Page:        "Reports Dashboard",
+		Actions:     []Action{},
+		Results:     make(map[string]interface{}),
+		Status:      "in_progress",
+		Timestamp:   time.Now().Format(time.RFC3339),
+		since:       e.requests.mark(),
+	}
+
+	// Navigate to reports page
+	chromedp.Run(e.ctx,
+		chromedp.Navigate("https://app.agicap.com/reports"),
+		chromedp.Sleep(3*time.Second),
+	)
+	e.assert(&feature, Action{
+		Type:        "assert",
+		Assert:      "url_matches",
+		Value:       "/reports",
+		Description: "Stay on /reports without being sent to the login",
+	})
+
+	e.CapturePage("reports_dashboard")
+
+	// Test different report types
+	reportTypes := []string{"profit", "loss", "balance", "cashflow", "forecast"}
+	for _, reportType := range reportTypes {
+		e.log("📈 Testing %s report", reportType)
+
+		selector := fmt.Sprintf(`[class*="%s"], [data-report="%s"]`, reportType, reportType)
+		chromedp.Run(e.ctx,
+			chromedp.Click(selector, chromedp.ByQuery),
+			chromedp.Sleep(2*time.Second),
+		)
+
+		e.CapturePage(fmt.Sprintf("report_%s", reportType))
+	}
+
+	feature.Status = feature.status()
+	e.features = append(e.features, feature)
+}
+
+// Scenario is a scripted flow loaded from a YAML file of the scenarios
+// directory, so new flows need no recompiling:
+//
+//	name: Liquidity Planning
+//	page: Liquidity Dashboard
+//	steps:
+//	  - type: navigate
+//	    value: /liquidity
+//	  - type: select
+//	    selector: select[name*="scenario"]
+//	    value: optimistic
+//	  - type: capture
+//	    value: liquidity_optimistic
+type Scenario struct {
+	Name        string         `mapstructure:"name"`
+	Description string         `mapstructure:"description"`
+	Page        string         `mapstructure:"page"`
+	Steps       []ScenarioStep `mapstructure:"steps"`
+}
+
+// ScenarioStep is one step of a scenario. navigate opens Value (relative
+// to login_url), click, fill and select act on Selector, assert runs the
+// check of Assert (text_contains when there is a Value, exists otherwise),
+// capture saves the page as Value (<scenario>_<step> by default). drag
+// drags Selector onto Target, or by the offset in Value ("dx,dy" in
+// pixels); slide moves the slider of Selector to Value, a value or a
+// percentage like "75%". upload attaches the files of Value (comma
+// separated, relative to the scenarios directory) to the file input of
+// Selector, input[type="file"] by default. wizard goes through a
+// multi-step form while its next button (Selector) is visible, see
+// runWizard. Wait is the pause after the step, Timeout how long the step
+// may wait for its element or assertion.
+type ScenarioStep struct {
+	Type        string        `mapstructure:"type"`
+	Assert      string        `mapstructure:"assert"`
+	Selector    string        `mapstructure:"selector"`
+	Target      string        `mapstructure:"target"`
+	Value       string        `mapstructure:"value"`
+	Description string        `mapstructure:"description"`
+	Wait        time.Duration `mapstructure:"wait"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	Fill        []WizardField `mapstructure:"fill"`      // wizard: values entered on each screen
+	MaxSteps    int           `mapstructure:"max_steps"` // wizard: most screens, 10 by default
+}
+
+// WizardField is a value a wizard step enters into the field of Selector
+// on each screen it shows up empty. A list rather than a map, since viper
+// lowercases map keys.
+type WizardField struct {
+	Selector string `mapstructure:"selector"`
+	Value    string `mapstructure:"value"`
+}
+
+var scenarioStepTypes = map[string]bool{"navigate": true, "click": true, "fill": true, "select": true, "assert": true, "capture": true, "drag": true, "slide": true, "upload": true, "wizard": true}
+
+// LoadScenarios reads the *.yaml and *.yml files of dir in name order. A
+// missing dir means there are no scenarios.
+func LoadScenarios(dir string) ([]Scenario, error) {
+	if info, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	var scenarios []Scenario
+	for _, file := range files {
+		v := viper.New()
+		v.SetConfigFile(file)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		var s Scenario
+		if err := v.Unmarshal(&s); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if s.Name == "" {
+			s.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+		for i, step := range s.Steps {
+			if (step.Type == "capture" || step.Type == "wizard") && step.Value == "" {
+				s.Steps[i].Value = fmt.Sprintf("%s_%d", s.Name, i+1)
+			}
+			if step.Type == "upload" {
+				if step.Selector == "" {
+					s.Steps[i].Selector = `input[type="file"]`
+				}
+				var files []string
+				for _, file := range uploadFiles(step.Value) {
+					// Chrome reads the files from its own working directory
+					if abs, err := filepath.Abs(filepath.Join(dir, file)); err == nil && !filepath.IsAbs(file) {
+						file = abs
+					}
+					files = append(files, file)
+				}
+				s.Steps[i].Value = strings.Join(files, ",")
+			}
+			if step.Type == "assert" && step.Assert == "" {
+				s.Steps[i].Assert = "exists"
+				if step.Value != "" {
+					s.Steps[i].Assert = "text_contains"
+				}
+			}
+			if err := s.Steps[i].check(); err != nil {
+				return nil, fmt.Errorf("%s: step %d: %w", file, i+1, err)
+			}
+		}
+		scenarios = append(scenarios, s)
+	}
+	return scenarios, nil
+}
+
+func (step ScenarioStep) check() error {
+	if !scenarioStepTypes[step.Type] {
+		return fmt.Errorf("unknown type %q (navigate, click, fill, select, assert, capture, drag, slide, upload, wizard)", step.Type)
+	}
+	if step.Type == "drag" && step.Target == "" {
+		if _, _, err := dragOffset(step.Value); err != nil {
+			return fmt.Errorf("drag needs a target or an offset: %w", err)
+		}
+	}
+	if step.Type == "slide" && step.Value == "" {
+		return fmt.Errorf("slide needs a value")
+	}
+	if step.Type == "upload" {
+		if step.Value == "" {
+			return fmt.Errorf("upload needs a file")
+		}
+		for _, file := range uploadFiles(step.Value) {
+			if _, err := os.Stat(file); err != nil {
+				return fmt.Errorf("upload: %w", err)
+			}
+		}
+	}
+	if step.Type == "assert" {
+		if !assertionKinds[step.Assert] {
+			return fmt.Errorf("unknown assertion %q (exists, text_contains, url_matches, request_observed, value_equals)", step.Assert)
+		}
+		if step.Assert == "url_matches" || step.Assert == "request_observed" {
+			if _, err := regexp.Compile(step.Value); err != nil {
+				return fmt.Errorf("%s: %w", step.Assert, err)
+			}
+			return nil
+		}
+	}
+	if step.Selector == "" && step.Type != "navigate" && step.Type != "capture" {
+		return fmt.Errorf("%s needs a selector", step.Type)
+	}
+	return nil
+}
+
+// RunScenario performs the steps of a scenario in order and records them as
+// the actions of a feature test. A failed step does not stop the scenario.
+func (e *FunctionalExplorer) RunScenario(s Scenario) {
+	e.log("🎬 Running scenario: %s", s.Name)
+
+	feature := FeatureTest{
+		Name:        s.Name,
+		Description: s.Description,
+		Page:        s.Page,
+		Actions:     []Action{},
+		Results:     make(map[string]interface{}),
+		Status:      "in_progress",
+		Timestamp:   time.Now().Format(time.RFC3339),
+		since:       e.requests.mark(),
+	}
+
+	for _, step := range s.Steps {
+		if step.Type == "wizard" {
+			feature.Actions = append(feature.Actions, e.runWizard(step)...)
+			continue
+		}
+		action := Action{
+			Type:        step.Type,
+			Assert:      step.Assert,
+			Selector:    step.Selector,
+			Target:      step.Target,
+			Value:       step.Value,
+			Description: step.Description,
+		}
+		if action.Description == "" {
+			action.Description = strings.Join(strings.Fields(fmt.Sprintf("%s %s %s %s %s", step.Type, step.Assert, step.Selector, step.Target, step.Value)), " ")
+		}
+
+		if err := e.runStep(step, feature.since); err != nil {
+			e.log("⚠️ %s: %v", action.Description, err)
+			action.Result = "failed"
+			action.Error = err.Error()
+		} else {
+			action.Result = "success"
+		}
+		feature.Actions = append(feature.Actions, action)
+	}
+
+	feature.Status = feature.status()
+	e.features = append(e.features, feature)
+}
+
+// assert runs an assert action of a built-in feature test and records it.
+func (e *FunctionalExplorer) assert(feature *FeatureTest, action Action) {
+	ctx, cancel := context.WithTimeout(e.ctx, e.stepTimeout(0))
+	defer cancel()
+	if err := e.checkAssertion(ctx, action.Assert, action.Selector, action.Value, feature.since); err != nil {
+		e.log("❌ %s: %v", action.Description, err)
+		action.Result = "failed"
+		action.Error = err.Error()
+	} else {
+		action.Result = "success"
+	}
+	feature.Actions = append(feature.Actions, action)
+}
+
+// checkAssertion polls until the assertion holds or ctx is done, and
+// returns why it failed.
+func (e *FunctionalExplorer) checkAssertion(ctx context.Context, kind, selector, value string, since int) error {
+	var re *regexp.Regexp
+	if kind == "url_matches" || kind == "request_observed" {
+		var err error
+		if re, err = regexp.Compile(value); err != nil {
+			return err
+		}
+	}
+
+	query, _ := json.Marshal(selector)
+
+	var last error
+	for {
+		var actual string
+		var err error
+		switch kind {
+		case "exists":
+			var found bool
+			err = chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(`document.querySelector(%s) !== null`, query), &found))
+			if err == nil && !found {
+				err = fmt.Errorf("no element matches %s", selector)
+			}
+		case "text_contains":
+			err = chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(`(document.querySelector(%s) || {}).textContent || ''`, query), &actual))
+			if err == nil && !strings.Contains(actual, value) {
+				err = fmt.Errorf("text of %s does not contain %q", selector, value)
+			}
+		case "value_equals":
+			err = chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(`(document.querySelector(%s) || {}).value || ''`, query), &actual))
+			if err == nil && actual != value {
+				err = fmt.Errorf("value of %s is %q, not %q", selector, actual, value)
+			}
+		case "url_matches":
+			err = chromedp.Run(ctx, chromedp.Evaluate(`window.location.href`, &actual))
+			if err == nil && !re.MatchString(actual) {
+				err = fmt.Errorf("URL %s does not match %s", actual, value)
+			}
+		case "request_observed":
+			if !e.requests.observed(since, re) {
+				err = fmt.Errorf("no request matching %s was sent", value)
+			}
+		default:
+			return fmt.Errorf("unknown assertion %q", kind)
+		}
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			if last == nil {
+				last = err
+			}
+			return last
+		}
+		last = err
+		select {
+		case <-ctx.Done():
+			return last
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// stepTimeout is how long a step waits for its element or assertion:
+// timeout, else explorer.features.step_timeout, else 10s.
+func (e *FunctionalExplorer) stepTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		timeout = e.config.GetDuration("explorer.features.step_timeout")
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return timeout
+}
+
+// runStep performs one scenario step, giving up on its element after the
+// step timeout. since is the request log mark of the scenario.
+func (e *FunctionalExplorer) runStep(step ScenarioStep, since int) error {
+	timeout := e.stepTimeout(step.Timeout)
+	wait := step.Wait
+	if wait <= 0 {
+		wait = 2 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, timeout)
+	defer cancel()
+
+	var err error
+	switch step.Type {
+	case "navigate":
+		target := step.Value
+		if base, perr := url.Parse(e.config.GetString("explorer.login_url")); perr == nil {
+			if ref, perr := url.Parse(step.Value); perr == nil {
+				target = base.ResolveReference(ref).String()
+			}
+		}
+		err = chromedp.Run(ctx, chromedp.Navigate(target))
+	case "click":
+		err = chromedp.Run(ctx, chromedp.Click(step.Selector, chromedp.ByQuery))
+	case "fill", "select":
+		err = chromedp.Run(ctx,
+			chromedp.Click(step.Selector, chromedp.ByQuery),
+			chromedp.Sleep(500*time.Millisecond),
+			chromedp.SendKeys(step.Selector, step.Value, chromedp.ByQuery),
+		)
+	case "drag":
+		err = e.drag(ctx, step.Selector, step.Target, step.Value)
+	case "slide":
+		err = e.slide(ctx, step.Selector, step.Value)
+	case "upload":
+		err = chromedp.Run(ctx, chromedp.SetUploadFiles(step.Selector, uploadFiles(step.Value), chromedp.ByQuery))
+	case "assert":
+		err = e.checkAssertion(ctx, step.Assert, step.Selector, step.Value, since)
+		wait = 0
+	case "capture":
+		return e.CapturePage(step.Value)
+	}
+	if err != nil {
+		return err
+	}
+	return chromedp.Run(e.ctx, chromedp.Sleep(wait))
+}
+
+// uploadFiles splits the comma separated files of an upload step.
+func uploadFiles(value string) []string {
+	var files []string
+	for _, file := range strings.Split(value, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// dragPointJS scrolls the element of selector %s into view when %t and
+// returns its center in viewport coordinates, and whether it is a native
+// HTML5 draggable.
+const dragPointJS = `
+(function(selector, scroll) {
+	const el = document.querySelector(selector);
+	if (!el) return null;
+	if (scroll) el.scrollIntoView({block: 'center', inline: 'center'});
+	const rect = el.getBoundingClientRect();
+	return {x: rect.left + rect.width / 2, y: rect.top + rect.height / 2, draggable: el.draggable === true};
+})(%s, %t)
+`
+
+type dragPoint struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Draggable bool    `json:"draggable"`
+}
+
+// dragOffset parses the "dx,dy" offset of a drag step.
+func dragOffset(value string) (float64, float64, error) {
+	dx, dy, ok := strings.Cut(value, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("offset %q is not dx,dy", value)
+	}
+	x, err := strconv.ParseFloat(strings.TrimSpace(dx), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("offset %q: %w", value, err)
+	}
+	y, err := strconv.ParseFloat(strings.TrimSpace(dy), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("offset %q: %w", value, err)
+	}
+	return x, y, nil
+}
+
+// drag drags the element of selector onto the element of target, or by
+// offset when there is no target. Native HTML5 draggables get their drag
+// intercepted and replayed as drag events at the drop point, everything
+// else (pointer-based libraries like dnd-kit or react-beautiful-dnd) a
+// pressed mouse moving there in steps.
+func (e *FunctionalExplorer) drag(ctx context.Context, selector, target, offset string) error {
+	query, _ := json.Marshal(selector)
+	var from *dragPoint
+	if err := chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(dragPointJS, query, true), &from)); err != nil {
+		return err
+	}
+	if from == nil {
+		return fmt.Errorf("no element matches %s", selector)
+	}
+	to := &dragPoint{}
+	if target != "" {
+		targetQuery, _ := json.Marshal(target)
+		if err := chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(dragPointJS, targetQuery, false), &to)); err != nil {
+			return err
+		}
+		if to == nil {
+			return fmt.Errorf("no element matches %s", target)
+		}
+	} else {
+		dx, dy, err := dragOffset(offset)
+		if err != nil {
+			return err
+		}
+		to.X, to.Y = from.X+dx, from.Y+dy
+	}
+
+	if !from.Draggable {
+		return chromedp.Run(ctx, mouseDrag(from.X, from.Y, to.X, to.Y))
+	}
+	// The listener goes away with lctx, the intercept mode and the pressed
+	// button with the cleanup, also when the drag fails or times out
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	releaseX, releaseY := from.X, from.Y
+	defer func() {
+		cleanup, cancel := context.WithTimeout(e.ctx, 2*time.Second)
+		defer cancel()
+		chromedp.Run(cleanup,
+			input.DispatchMouseEvent(input.MouseReleased, releaseX, releaseY).WithButton(input.Left).WithClickCount(1),
+			input.SetInterceptDrags(false),
+		)
+	}()
+	intercepted := make(chan *input.DragData, 1)
+	chromedp.ListenTarget(lctx, func(ev interface{}) {
+		if ev, ok := ev.(*input.EventDragIntercepted); ok {
+			select {
+			case intercepted <- ev.Data:
+			default:
+			}
+		}
+	})
+	err := chromedp.Run(ctx,
+		input.SetInterceptDrags(true),
+		input.DispatchMouseEvent(input.MouseMoved, from.X, from.Y),
+		input.DispatchMouseEvent(input.MousePressed, from.X, from.Y).WithButton(input.Left).WithButtons(1).WithClickCount(1),
+		input.DispatchMouseEvent(input.MouseMoved, from.X+10, from.Y+10).WithButton(input.Left).WithButtons(1),
+	)
+	if err != nil {
+		return err
+	}
+	var data *input.DragData
+	select {
+	case data = <-intercepted:
+	case <-time.After(2 * time.Second):
+		return fmt.Errorf("%s did not start a drag", selector)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	releaseX, releaseY = to.X, to.Y
+	return chromedp.Run(ctx,
+		input.DispatchDragEvent(input.DragEnter, to.X, to.Y, data),
+		input.DispatchDragEvent(input.DragOver, to.X, to.Y, data),
+		input.DispatchDragEvent(input.Drop, to.X, to.Y, data),
+	)
+}
+
+// mouseDrag presses the left mouse button at one point and moves it to the
+// other in 10 steps before releasing it, pausing between moves so drag
+// libraries with an activation delay or distance pick it up.
+func mouseDrag(fromX, fromY, toX, toY float64) chromedp.Tasks {
+	tasks := chromedp.Tasks{
+		input.DispatchMouseEvent(input.MouseMoved, fromX, fromY),
+		input.DispatchMouseEvent(input.MousePressed, fromX, fromY).WithButton(input.Left).WithButtons(1).WithClickCount(1),
+		chromedp.Sleep(150 * time.Millisecond),
+	}
+	const steps = 10
+	for i := 1; i <= steps; i++ {
+		x := fromX + (toX-fromX)*float64(i)/steps
+		y := fromY + (toY-fromY)*float64(i)/steps
+		tasks = append(tasks,
+			input.DispatchMouseEvent(input.MouseMoved, x, y).WithButton(input.Left).WithButtons(1),
+			chromedp.Sleep(30*time.Millisecond),
+		)
+	}
+	return append(tasks, input.DispatchMouseEvent(input.MouseReleased, toX, toY).WithButton(input.Left).WithClickCount(1))
+}
+
+// sliderJS sets the slider of selector %s to %s, a value or a percentage
+// of its range. Range inputs get the value through the native setter and
+// input and change events, which React and Vue pick up; for ARIA sliders
+// (aria-valuemin/max/now on the thumb) it returns the thumb and the point
+// on its track to drag it to.
+const sliderJS = `
+(function(selector, value) {
+	const el = document.querySelector(selector);
+	if (!el) return {error: 'no element matches ' + selector};
+	el.scrollIntoView({block: 'center', inline: 'center'});
+	const range = el.matches('input[type="range"]');
+	const thumb = range ? el : (el.matches('[role="slider"]') ? el : el.querySelector('[role="slider"]'));
+	if (!thumb) return {error: selector + ' is neither a range input nor an ARIA slider'};
+	const min = parseFloat(range ? (el.min || 0) : thumb.getAttribute('aria-valuemin') || 0);
+	const max = parseFloat(range ? (el.max || 100) : thumb.getAttribute('aria-valuemax') || 100);
+	let target = value.endsWith('%%') ? min + (max - min) * parseFloat(value) / 100 : parseFloat(value);
+	if (isNaN(target)) return {error: 'value ' + value + ' is not a number'};
+	target = Math.min(max, Math.max(min, target));
+	if (range) {
+		Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, 'value').set.call(el, String(target));
+		el.dispatchEvent(new Event('input', {bubbles: true}));
+		el.dispatchEvent(new Event('change', {bubbles: true}));
+		return {value: el.value};
+	}
+	const vertical = thumb.getAttribute('aria-orientation') === 'vertical';
+	const track = thumb.parentElement.getBoundingClientRect().width > thumb.getBoundingClientRect().width * 2 ? thumb.parentElement : thumb.parentElement.parentElement;
+	const t = track.getBoundingClientRect(), r = thumb.getBoundingClientRect();
+	const fraction = max > min ? (target - min) / (max - min) : 0;
+	return {
+		fromX: r.left + r.width / 2, fromY: r.top + r.height / 2,
+		toX: vertical ? r.left + r.width / 2 : t.left + t.width * fraction,
+		toY: vertical ? t.bottom - t.height * fraction : r.top + r.height / 2,
+		value: thumb.getAttribute('aria-valuenow') || '',
+		target: String(target)
+	};
+})(%s, %s)
+`
+
+// slide moves the slider of selector to value, setting range inputs
+// directly and dragging the thumb of other sliders.
+func (e *FunctionalExplorer) slide(ctx context.Context, selector, value string) error {
+	query, _ := json.Marshal(selector)
+	valueQuery, _ := json.Marshal(value)
+	var result struct {
+		Error  string  `json:"error"`
+		Value  string  `json:"value"`
+		Target string  `json:"target"`
+		FromX  float64 `json:"fromX"`
+		FromY  float64 `json:"fromY"`
+		ToX    float64 `json:"toX"`
+		ToY    float64 `json:"toY"`
+	}
+	if err := chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(sliderJS, query, valueQuery), &result)); err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	if result.FromX == 0 && result.FromY == 0 {
+		return nil
+	}
+	if err := chromedp.Run(ctx, mouseDrag(result.FromX, result.FromY, result.ToX, result.ToY)); err != nil {
+		return err
+	}
+	var now string
+	chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(`(function(el) { el = el && (el.matches('[role="slider"]') ? el : el.querySelector('[role="slider"]')); return el ? el.getAttribute('aria-valuenow') || '' : ''; })(document.querySelector(%s))`, query), &now))
+	if now == result.Value && now != result.Target {
+		return fmt.Errorf("slider %s stayed at %s", selector, now)
+	}
+	e.log("🎚️ %s moved from %s to %s", selector, result.Value, now)
+	return nil
+}
+
+// wizardStateJS returns whether the next button of selector %s is visible
+// and enabled, and a fingerprint of the screen to tell whether clicking it
+// moved on.
+const wizardStateJS = `
+(function(selector) {
+	const el = document.querySelector(selector);
+	let next = false;
+	if (el) {
+		const rect = el.getBoundingClientRect();
+		next = rect.width > 0 && rect.height > 0 && getComputedStyle(el).visibility !== 'hidden' &&
+			!el.disabled && el.getAttribute('aria-disabled') !== 'true';
+	}
+	return {next: next, screen: location.href + '|' + (document.body.innerText || '').substring(0, 4000)};
+})(%s)
+`
+
+// wizardRequiredJS lists the visible required fields that are still empty,
+// by id or name, with a sample value by type. Selects get their first
+// option with a value, checkboxes are checked.
+const wizardRequiredJS = `
+(function(skip) {
+	const fields = [];
+	for (const el of document.querySelectorAll('input[required], input[aria-required="true"], select[required], select[aria-required="true"], textarea[required], textarea[aria-required="true"]')) {
+		const rect = el.getBoundingClientRect();
+		if (rect.width === 0 || rect.height === 0 || el.disabled || el.readOnly) continue;
+		const selector = el.id ? '#' + CSS.escape(el.id) : el.name ? el.tagName.toLowerCase() + '[name="' + el.name + '"]' : '';
+		if (!selector || skip.includes(selector)) continue;
+		const type = el.tagName === 'SELECT' ? 'select' : el.tagName === 'TEXTAREA' ? 'textarea' : (el.type || 'text');
+		if (type === 'checkbox' || type === 'radio') {
+			if (!el.checked && !(type === 'radio' && document.querySelector('input[type="radio"][name="' + el.name + '"]:checked'))) fields.push({selector: selector, type: type, value: ''});
+			continue;
+		}
+		if (el.value) continue;
+		let value = 'Test';
+		switch (type) {
+		case 'select': {
+			const option = Array.from(el.options).find(o => o.value && !o.disabled);
+			value = option ? option.text.trim() : '';
+			break;
+		}
+		case 'email': value = 'test@example.com'; break;
+		case 'number': value = el.min || '1'; break;
+		case 'tel': value = '+49 30 1234567'; break;
+		case 'url': value = 'https://example.com'; break;
+		case 'date': value = new Date().toISOString().substring(0, 10); break;
+		case 'password': value = 'Test-1234!'; break;
+		}
+		if (value) fields.push({selector: selector, type: type, value: value});
+	}
+	return fields;
+})(%s)
+`
+
+// runWizard goes through a multi-step form such as onboarding or a bank
+// connection. On each screen it captures the page as <Value>_<n>, enters
+// the values of Fill into the fields of the screen that are empty, gives
+// the other required fields that are empty a sample value, and clicks the
+// next button, until the button is gone or disabled, or after MaxSteps
+// screens. A click that does not lead to another screen (a validation
+// error, say) stops the wizard as failed. Every fill, click and capture is
+// returned as an action.
+func (e *FunctionalExplorer) runWizard(step ScenarioStep) []Action {
+	maxSteps := step.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = 10
+	}
+	wait := step.Wait
+	if wait <= 0 {
+		wait = 2 * time.Second
+	}
+	timeout := e.stepTimeout(step.Timeout)
+	query, _ := json.Marshal(step.Selector)
+	selectors := make([]string, 0, len(step.Fill))
+	for _, field := range step.Fill {
+		selectors = append(selectors, field.Selector)
+	}
+	skip, _ := json.Marshal(selectors)
+
+	var actions []Action
+	record := func(action Action, err error) bool {
+		action.Result = "success"
+		if err != nil {
+			e.log("⚠️ %s: %v", action.Description, err)
+			action.Result = "failed"
+			action.Error = err.Error()
+		}
+		actions = append(actions, action)
+		return err == nil
+	}
+	var state struct {
+		Next   bool   `json:"next"`
+		Screen string `json:"screen"`
+	}
+	readState := func() error {
+		return chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(wizardStateJS, query), &state))
+	}
+
+	for screen := 1; screen <= maxSteps; screen++ {
+		e.log("🧙 Wizard screen %d", screen)
+		name := fmt.Sprintf("%s_%d", step.Value, screen)
+		record(Action{Type: "capture", Value: name, Description: fmt.Sprintf("Wizard screen %d", screen)}, e.CapturePage(name))
+		if err := readState(); err != nil || !state.Next {
+			return actions
+		}
+
+		for _, field := range step.Fill {
+			var empty bool
+			fieldQuery, _ := json.Marshal(field.Selector)
+			chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(`(function(el) { return !!el && el.getBoundingClientRect().width > 0 && !el.value; })(document.querySelector(%s))`, fieldQuery), &empty))
+			if !empty {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(e.ctx, timeout)
+			err := chromedp.Run(ctx, chromedp.Click(field.Selector, chromedp.ByQuery), chromedp.SendKeys(field.Selector, field.Value, chromedp.ByQuery))
+			cancel()
+			record(Action{Type: "fill", Selector: field.Selector, Value: field.Value, Description: fmt.Sprintf("Wizard screen %d: fill %s", screen, field.Selector)}, err)
+		}
+		var required []struct {
+			Selector string `json:"selector"`
+			Type     string `json:"type"`
+			Value    string `json:"value"`
+		}
+		chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(wizardRequiredJS, skip), &required))
+		for _, field := range required {
+			ctx, cancel := context.WithTimeout(e.ctx, timeout)
+			action := Action{Type: "fill", Selector: field.Selector, Value: field.Value, Description: fmt.Sprintf("Wizard screen %d: required %s", screen, field.Selector)}
+			var err error
+			switch field.Type {
+			case "checkbox", "radio":
+				action.Type = "click"
+				err = chromedp.Run(ctx, chromedp.Click(field.Selector, chromedp.ByQuery))
+			case "select":
+				action.Type = "select"
+				err = chromedp.Run(ctx, chromedp.SendKeys(field.Selector, field.Value, chromedp.ByQuery))
+			default:
+				err = chromedp.Run(ctx, chromedp.Click(field.Selector, chromedp.ByQuery), chromedp.SendKeys(field.Selector, field.Value, chromedp.ByQuery))
+			}
+			cancel()
+			record(action, err)
+		}
+
+		if err := readState(); err != nil || !state.Next {
+			return actions
+		}
+		before := state.Screen
+		ctx, cancel := context.WithTimeout(e.ctx, timeout)
+		err := chromedp.Run(ctx, chromedp.Click(step.Selector, chromedp.ByQuery))
+		cancel()
+		if !record(Action{Type: "click", Selector: step.Selector, Description: fmt.Sprintf("Wizard screen %d: next", screen)}, err) {
+			return actions
+		}
+		chromedp.Run(e.ctx, chromedp.Sleep(wait))
+		if err := readState(); err == nil && state.Screen == before {
+			record(Action{Type: "wizard", Selector: step.Selector, Description: fmt.Sprintf("Wizard screen %d: advance", screen)},
+				fmt.Errorf("still on screen %d after clicking next", screen))
+			return actions
+		}
+	}
+	e.log("⚠️ Wizard stopped after %d screens", maxSteps)
+	return actions
+}
+
+func (e *FunctionalExplorer) GenerateComprehensiveReport() error {
+	e.log("📝 Generating comprehensive functional report...")
+
+	// Save features data, in the versioned format checked by validate-output
+	generated := time.Now().Format(time.RFC3339)
+	featuresJSON, _ := json.MarshalIndent(map[string]interface{}{
+		"$schema":        "../schemas/feature_tests.v1.schema.json",
+		"schema_version": 1,
+		"generated":      generated,
+		"features":       e.features,
+	}, "", "  ")
+	ioutil.WriteFile(filepath.Join(e.config.GetString("explorer.output.directory"), "features", "feature_tests.json"), featuresJSON, 0644)
+
+	// Save navigation data
+	navJSON, _ := json.MarshalIndent(map[string]interface{}{
+		"$schema":        "schemas/navigation_map.v1.schema.json",
+		"schema_version": 1,
+		"generated":      generated,
+		"pages":          e.navigationMap,
+	}, "", "  ")
+	ioutil.WriteFile(filepath.Join(e.config.GetString("explorer.output.directory"), "navigation_map.json"), navJSON, 0644)
+
+	// CI test reporting formats
+	if e.config.GetBool("explorer.features.junit") {
+		if err := e.writeJUnitReport(filepath.Join(e.config.GetString("explorer.output.directory"), "features", "feature_tests.xml")); err != nil {
+			e.log("⚠️ Failed to write JUnit report: %v", err)
+		}
+	}
+	if e.config.GetBool("explorer.features.sarif") {
+		if err := e.writeSARIFReport(filepath.Join(e.config.GetString("explorer.output.directory"), "features", "feature_tests.sarif")); err != nil {
+			e.log("⚠️ Failed to write SARIF report: %v", err)
+		}
+	}
+
+	// Generate comprehensive rebuild guide
+	rebuildGuide := e.generateFunctionalRebuildGuide()
+	ioutil.WriteFile(filepath.Join(e.config.GetString("explorer.output.directory"), "FUNCTIONAL_REBUILD_GUIDE.md"), []byte(rebuildGuide), 0644)
+
+	e.log("✅ Comprehensive functional report generated!")
+	return nil
+}
+
+// outcome maps a feature's Status to a test result: "passed", "failed" or
+// "skipped". Partial runs count as failures; a test that never finished
+// (still in_progress) or has an unknown status is skipped.
+func (f FeatureTest) outcome() string {
+	switch f.Status {
+	case "success":
+		return "passed"
+	case "failed", "partial":
+		return "failed"
+	}
+	return "skipped"
+}
+
+// status derives a finished feature's Status from its actions: failed when
+// an assertion or every action failed, partial when some other action
+// failed, success otherwise.
+func (f FeatureTest) status() string {
+	failed := 0
+	for _, action := range f.Actions {
+		if action.Result != "failed" {
+			continue
+		}
+		if action.Type == "assert" {
+			return "failed"
+		}
+		failed++
+	}
+	switch {
+	case failed == 0:
+		return "success"
+	case failed == len(f.Actions):
+		return "failed"
+	}
+	return "partial"
+}
+
+// failedActions lists the descriptions of the feature's failed actions,
+// with the reason when known.
+func (f FeatureTest) failedActions() []string {
+	var failed []string
+	for _, action := range f.Actions {
+		if action.Result == "failed" {
+			if action.Error != "" {
+				failed = append(failed, fmt.Sprintf("%s (%s)", action.Description, action.Error))
+			} else {
+				failed = append(failed, action.Description)
+			}
+		}
+	}
+	return failed
+}
+
+// message explains a failed or skipped feature.
+func (f FeatureTest) message() string {
+	if f.outcome() == "skipped" {
+		return fmt.Sprintf("status %q", f.Status)
+	}
+	if failed := f.failedActions(); len(failed) > 0 {
+		return fmt.Sprintf("%s: %d of %d actions failed: %s", f.Status, len(failed), len(f.Actions), strings.Join(failed, "; "))
+	}
+	return f.Status
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes the feature tests as JUnit XML, one test case per
+// feature with the actions and their results as its output.
+func (e *FunctionalExplorer) writeJUnitReport(path string) error {
+	suite := junitSuite{Name: "Agicap functional tests", Tests: len(e.features)}
+	if len(e.features) > 0 {
+		suite.Timestamp = e.features[0].Timestamp
+	}
+	for _, feature := range e.features {
+		c := junitCase{Name: feature.Name, ClassName: "agicap"}
+		if feature.Page != "" {
+			c.ClassName += "." + sanitize(feature.Page)
+		}
+		for _, action := range feature.Actions {
+			c.SystemOut += fmt.Sprintf("[%s] %s %s\n", action.Result, action.Type, action.Description)
+		}
+		switch feature.outcome() {
+		case "failed":
+			suite.Failures++
+			c.Failure = &junitMessage{Message: feature.message(), Type: feature.Status, Text: strings.Join(feature.failedActions(), "\n")}
+		case "skipped":
+			suite.Skipped++
+			c.Skipped = &junitMessage{Message: feature.message()}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	doc := junitSuites{Name: "agicap-functional-explorer", Tests: suite.Tests, Failures: suite.Failures, Skipped: suite.Skipped, Suites: []junitSuite{suite}}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Kind      string          `json:"kind"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// writeSARIFReport writes the feature tests as a SARIF 2.1.0 log: a rule per
+// feature and a result of kind pass, fail or notApplicable located at the
+// tested page.
+func (e *FunctionalExplorer) writeSARIFReport(path string) error {
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "agicap-functional-explorer"}}, Results: []sarifResult{}}
+	for _, feature := range e.features {
+		id := sanitize(feature.Name)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, Name: feature.Name, ShortDescription: sarifMessage{feature.Description}})
+
+		result := sarifResult{RuleID: id, Kind: "pass", Level: "none", Message: sarifMessage{feature.Name + " passed"}}
+		switch feature.outcome() {
+		case "failed":
+			result.Kind, result.Level, result.Message.Text = "fail", "error", feature.message()
+			if feature.Status == "partial" {
+				result.Level = "warning"
+			}
+		case "skipped":
+			result.Kind, result.Message.Text = "notApplicable", feature.Name+" skipped: "+feature.message()
+		}
+		if feature.Page != "" {
+			result.Locations = []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{Name: feature.Page, Kind: "page"}}}}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	data, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (e *FunctionalExplorer) generateFunctionalRebuildGuide() string {
+	return fmt.Sprintf(`# 🚀 Agicap Functional Rebuild Guide
+
+**Generated:** %s
+**Features Tested:** %d
+**Pages Captured:** %d
+
+## 🎯 Tested Features
+
+%s
+
+## 📱 Captured Pages
+%s
+
+## 🔧 Implementation Requirements
+
+### 1. Core Financial Features
+- **Liquidity Planning**: Real-time cash position tracking
+- **Cash Flow Forecasting**: AI-powered predictions with confidence scoring
+- **Scenario Management**: Optimistic/Realistic/Pessimistic views
+- **Bank Account Integration**: Multi-account balance management
+
+### 2. Data Entry & Management
+- **Manual Transaction Entry**: Comprehensive form with categories
+- **Recurring Transactions**: Automated recurring payment setup
+- **Transaction Categorization**: Hierarchical category system
+- **Bulk Operations**: Mass transaction management
+
+### 3. AI & Analytics
+- **AI Insights**: Pattern recognition and recommendations
+- **Confidence Scoring**: Accuracy percentages for forecasts
+- **Trend Analysis**: Growth rate and seasonality detection
+- **Risk Assessment**: Volatility and risk level analysis
+
+### 4. User Interface Components
+- **Dashboard Widgets**: Financial overview cards
+- **Interactive Charts**: Real-time data visualization
+- **Modal Forms**: Transaction entry and editing
+- **Responsive Design**: Mobile-first approach
+
+### 5. Advanced Features
+- **Export Functionality**: PDF, Excel, CSV, DATEV formats
+- **Settings Management**: User and company configuration
+- **Notification System**: Smart alerts and warnings
+- **Multi-Company Support**: Tenant management
+
+## 🛠️ Technical Implementation
+
+### Frontend Stack
+- **Framework**: Next.js 15+ with TypeScript
+- **Styling**: Tailwind CSS with custom design system
+- **Charts**: Recharts for data visualization
+- **Forms**: React Hook Form with validation
+- **State**: Zustand for state management
+
+### Backend Requirements
+- **API**: RESTful API with GraphQL support
+- **Database**: PostgreSQL with Prisma ORM
+- **Authentication**: NextAuth.js with JWT
+- **File Storage**: AWS S3 for document storage
+- **AI Integration**: OpenAI API for forecasting
+
+### Key Components to Build
+1. **LiquidityDashboard** - Main cash flow overview
+2. **CashFlowForecast** - AI-powered predictions
+3. **TransactionForm** - Manual data entry
+4. **BankAccountManager** - Account integration
+5. **ScenarioSelector** - Forecast scenarios
+6. **AIInsightsPanel** - Smart recommendations
+7. **ExportManager** - Data export functionality
+8. **SettingsPanel** - Configuration management
+
+## 📊 Feature Test Results
+
+%s
+
+## 🎨 Design System
+
+### Colors
+- Primary: Blue (#3B82F6)
+- Success: Green (#10B981)
+- Warning: Yellow (#F59E0B)
+- Error: Red (#EF4444)
+- Neutral: Gray (#6B7280)
+
+### Typography
+- Headings: Inter (600-700 weight)
+- Body: Inter (400-500 weight)
+- Monospace: JetBrains Mono
+
+### Spacing
+- Base unit: 4px
+- Common: 8px, 16px, 24px, 32px, 48px
+- Layout: 64px, 96px, 128px
+
+## 🚀 Next Steps
+
+1. **Phase 1**: Implement core financial features
+2. **Phase 2**: Add AI-powered forecasting
+3. **Phase 3**: Integrate bank APIs
+4. **Phase 4**: Build advanced analytics
+5. **Phase 5**: Add export functionality
+
+---
+
+**Ready to rebuild Agicap with full functionality! 🚀**
+`,
+		time.Now().Format("2006-01-02 15:04:05"),
+		len(e.features),
+		len(e.navigationMap),
+		func() string {
+			features := ""
+			for _, feature := range e.features {
+				status := "✅"
+				if feature.Status == "failed" {
+					status = "❌"
+				} else if feature.Status == "partial" {
+					status = "⚠️"
+				}
+				features += fmt.Sprintf("- **%s** %s - %s\n", feature.Name, status, feature.Description)
+			}
+			return features
+		}(),
+		func() string {
+			pages := ""
+			for _, item := range e.navigationMap {
+				pages += fmt.Sprintf("- **%s** - `%s`\n", item.Title, item.URL)
+			}
+			return pages
+		}(),
+		func() string {
+			results := ""
+			for _, feature := range e.features {
+				results += fmt.Sprintf("### %s\n", feature.Name)
+				results += fmt.Sprintf("- **Status**: %s\n", feature.Status)
+				results += fmt.Sprintf("- **Actions**: %d\n", len(feature.Actions))
+				results += fmt.Sprintf("- **Description**: %s\n\n", feature.Description)
+			}
+			return results
+		}(),
+	)
+}
+
+func sanitize(s string) string {
+	s = strings.ToLower(s)
+	s = strings.ReplaceAll(s, " ", "_")
+	s = strings.ReplaceAll(s, "/", "_")
+	s = strings.ReplaceAll(s, "\\", "_")
+	s = strings.ReplaceAll(s, ":", "_")
+	s = strings.ReplaceAll(s, "*", "_")
+	s = strings.ReplaceAll(s, "?", "_")
+	s = strings.ReplaceAll(s, "\"", "_")
+	s = strings.ReplaceAll(s, "<", "_")
+	s = strings.ReplaceAll(s, ">", "_")
+	s = strings.ReplaceAll(s, "|", "_")
+	if len(s) > 100 {
+		s = s[:100]
+	}
+	return s
+}
+
+func (e *FunctionalExplorer) log(format string, args ...interface{}) {
+	if e.verbose {
+		fmt.Printf(format+"\n", args...)
+	}
+}
+
+func main() {
+	fmt.Println("🚀 Agicap Functional Explorer")
+	fmt.Println("=============================")
+	fmt.Println()
+
+	// Load configuration
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	v.AddConfigPath(".")
+
+	if err := v.ReadInConfig(); err != nil {
+		log.Fatalf("❌ Fatal error config file: %s \n", err)
+	}
+
+	loginURL := v.GetString("explorer.login_url")
+	email := v.GetString("explorer.credentials.email")
+	password := v.GetString("explorer.credentials.password")
+	verbose := true
+
+	// Invalid scenarios fail before the browser starts, not after the
+	// feature tests
+	var scenarios []Scenario
+	if dir := v.GetString("explorer.features.scenarios"); dir != "" {
+		var err error
+		if scenarios, err = LoadScenarios(dir); err != nil {
+			log.Fatalf("❌ Invalid scenario: %v", err)
+		}
+	}
+
+	explorer, err := NewFunctionalExplorer("config.yaml", verbose)
+	if err != nil {
+		log.Fatalf("❌ Failed to create explorer: %v", err)
+	}
+	defer explorer.Close()
+
+	fmt.Println("Step 1: Logging in...")
+	if err := explorer.Login(loginURL, email, password); err != nil {
+		log.Fatalf("❌ Login failed: %v", err)
+	}
+
+	fmt.Println("\nStep 2: Testing all features...")
+	explorer.TestAllFeatures()
+
+	for _, scenario := range scenarios {
+		explorer.RunScenario(scenario)
+	}
+
+	fmt.Println("\nStep 3: Generating comprehensive report...")
+	if err := explorer.GenerateComprehensiveReport(); err != nil {
+		log.Fatalf("❌ Report generation failed: %v", err)
+	}
+
+	fmt.Println("\n✅ Functional exploration complete!")
+	fmt.Printf("📂 Results: %s\n", v.GetString("explorer.output.directory"))
+	fmt.Println("\n📄 Files generated:")
+	fmt.Println("  • FUNCTIONAL_REBUILD_GUIDE.md - Complete rebuild guide")
+	fmt.Println("  • features/feature_tests.json - Detailed test results")
+	if v.GetBool("explorer.features.junit") {
+		fmt.Println("  • features/feature_tests.xml - JUnit XML for CI")
+	}
+	if v.GetBool("explorer.features.sarif") {
+		fmt.Println("  • features/feature_tests.sarif - SARIF log")
+	}
+	fmt.Println("  • navigation_map.json - Page structure")
+	fmt.Println("  • screenshots/ - All page screenshots")
+	fmt.Println("  • html/ - Page source code")
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadScenariosMissingDirectory(t *testing.T) {
+	scenarios, err := LoadScenarios(filepath.Join(t.TempDir(), "scenarios"))
+	if err != nil {
+		t.Fatalf("missing directory: %v", err)
+	}
+	if len(scenarios) != 0 {
+		t.Errorf("got %d scenarios, want none", len(scenarios))
+	}
+}
+
+func TestLoadScenariosInvalidStep(t *testing.T) {
+	dir := t.TempDir()
+	yaml := "name: broken\nsteps:\n  - type: teleport\n    selector: '#x'\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "broken.yaml"), []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadScenarios(dir)
+	if err == nil || !strings.Contains(err.Error(), "step 1") {
+		t.Errorf("got %v, want an error for step 1", err)
+	}
+}
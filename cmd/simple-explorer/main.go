@@ -394,7 +394,8 @@ func (e *SimpleExplorer) log(format string, args ...interface{}) {
 
 func main() {
 	fmt.Println("🚀 Simple Agicap UI Explorer")
-	fmt.Println("============================\n")
+	fmt.Println("============================")
+	fmt.Println()
 
 	// Configuration
 	loginURL := "https://app.agicap.com/de/app/cashflow/forecast"
@@ -81,9 +81,9 @@ func NewViperExplorer(configFile string, verbose bool) (*ViperExplorer, error) {
 		// Filter out known CDP errors if configured to do so
 		if v.GetBool("explorer.error_handling.ignore_cdp_errors") {
 			if strings.Contains(msg, "cookiePart") ||
-			   strings.Contains(msg, "parse error") ||
-			   strings.Contains(msg, "initialFrameNavigation") ||
-			   strings.Contains(msg, "unknown ClientNavigationReason") {
+				strings.Contains(msg, "parse error") ||
+				strings.Contains(msg, "initialFrameNavigation") ||
+				strings.Contains(msg, "unknown ClientNavigationReason") {
 				return
 			}
 		}
@@ -414,17 +414,17 @@ func (e *ViperExplorer) GenerateReport() error {
 
 **Ready to rebuild Agicap 1:1! 🚀**
 `, time.Now().Format("2006-01-02 15:04:05"),
-	len(e.navigationMap),
-	func() string {
-		pages := ""
-		for _, item := range e.navigationMap {
-			pages += fmt.Sprintf("- **%s** - %s\n", item.Title, item.URL)
-		}
-		return pages
-	}(),
-	e.config.GetInt("explorer.exploration.max_pages"),
-	e.config.GetBool("explorer.browser.headless"),
-	e.config.GetInt("explorer.browser.timeout_minutes"))
+		len(e.navigationMap),
+		func() string {
+			pages := ""
+			for _, item := range e.navigationMap {
+				pages += fmt.Sprintf("- **%s** - %s\n", item.Title, item.URL)
+			}
+			return pages
+		}(),
+		e.config.GetInt("explorer.exploration.max_pages"),
+		e.config.GetBool("explorer.browser.headless"),
+		e.config.GetInt("explorer.browser.timeout_minutes"))
 
 	ioutil.WriteFile(filepath.Join(outputDir, "REBUILD_GUIDE.md"), []byte(rebuildGuide), 0644)
 
@@ -458,7 +458,8 @@ func (e *ViperExplorer) log(format string, args ...interface{}) {
 
 func main() {
 	fmt.Println("🚀 Viper-Based Agicap UI Explorer")
-	fmt.Println("==================================\n")
+	fmt.Println("==================================")
+	fmt.Println()
 
 	// Load configuration
 	configFile := "config.yaml"
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// codegenTargets are the generators of `explorer codegen <target>`.
+var codegenTargets = map[string]func(args []string) error{
+	"components": runCodegenComponents,
+	"react":      runCodegenReact,
+	"next":       runCodegenNext,
+	"tests":      runCodegenTests,
+}
+
+// runCodegen scaffolds code for the rebuild from a finished run:
+//
+//	explorer codegen components [-framework react|vue|svelte] [-out dir] [-stories=false] run/
+//	explorer codegen next [-out dir] run/
+//	explorer codegen tests [-framework playwright|cypress] run/
+func runCodegen(args []string) error {
+	if len(args) == 0 || codegenTargets[args[0]] == nil {
+		targets := make([]string, 0, len(codegenTargets))
+		for name := range codegenTargets {
+			targets = append(targets, name)
+		}
+		sort.Strings(targets)
+		return fmt.Errorf("usage: codegen <%s> [flags] <run>", strings.Join(targets, "|"))
+	}
+	return codegenTargets[args[0]](args[1:])
+}
+
+// componentFramework renders the component models for one UI framework.
+type componentFramework struct {
+	extension string
+	component func(m *componentModel) string
+	export    func(m *componentModel) string
+	stories   func(m *componentModel) string
+}
+
+var componentFrameworks = map[string]componentFramework{
+	"react":  {extension: ".tsx", component: reactComponent, export: reactExport, stories: reactStories},
+	"vue":    {extension: ".vue", component: vueComponent, export: vueExport, stories: vueStories},
+	"svelte": {extension: ".svelte", component: svelteComponent, export: svelteExport, stories: svelteStories},
+}
+
+// runCodegenComponents writes a skeleton component per component group of
+// component_library.json, with a variant prop, props for the attributes that
+// vary between instances and Tailwind classes mapped from the design tokens,
+// and a CSF3 story per variant next to it.
+func runCodegenComponents(args []string) error {
+	fs := flag.NewFlagSet("codegen components", flag.ContinueOnError)
+	framework := fs.String("framework", "react", "UI framework: react, vue or svelte")
+	out := fs.String("out", "", "output directory (default <run>/generated/components)")
+	stories := fs.Bool("stories", true, "write a Storybook story per component variant")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fw, ok := componentFrameworks[*framework]
+	if fs.NArg() != 1 || !ok {
+		return fmt.Errorf("usage: codegen components [-framework react|vue|svelte] [-out dir] [-stories=false] <run>")
+	}
+	runDir := fs.Arg(0)
+	if *out == "" {
+		*out = filepath.Join(runDir, "generated", "components")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(runDir, "component_library.json"))
+	if err != nil {
+		return err
+	}
+	var lib componentLibrary
+	if err := json.Unmarshal(data, &lib); err != nil {
+		return fmt.Errorf("component_library.json: %w", err)
+	}
+	// Without design tokens every style becomes an arbitrary value
+	system, _ := loadDesignSystem(runDir)
+	mapper := newTailwindMapper(system)
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	var exports []string
+	for _, group := range lib.Components {
+		if len(group.Variants) == 0 {
+			continue
+		}
+		m := newComponentModel(group, mapper)
+		if err := ioutil.WriteFile(filepath.Join(*out, m.Name+fw.extension), []byte(fw.component(m)), 0644); err != nil {
+			return err
+		}
+		if *stories {
+			if err := ioutil.WriteFile(filepath.Join(*out, m.Name+".stories.ts"), []byte(fw.stories(m)), 0644); err != nil {
+				return err
+			}
+		}
+		exports = append(exports, fw.export(m))
+	}
+	if err := ioutil.WriteFile(filepath.Join(*out, "index.ts"), []byte(strings.Join(exports, "")), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Generated %d %s components in %s\n", len(exports), *framework, *out)
+	return nil
+}
+
+// runCodegenReact is `codegen components -framework react`.
+func runCodegenReact(args []string) error {
+	return runCodegenComponents(append([]string{"-framework", "react"}, args...))
+}
+
+var (
+	identifierSplit = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	unionValue      = regexp.MustCompile(`^[\w .:/-]{1,30}$`)
+)
+
+// pascalCase turns "text field" or "nav-bar" into "TextField" / "NavBar".
+func pascalCase(s string) string {
+	var b strings.Builder
+	for _, part := range identifierSplit.Split(s, -1) {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	if b.Len() == 0 || (b.String()[0] >= '0' && b.String()[0] <= '9') {
+		return "Component" + b.String()
+	}
+	return b.String()
+}
+
+// camelCase turns "aria-label" into "ariaLabel".
+func camelCase(s string) string {
+	p := pascalCase(s)
+	return strings.ToLower(p[:1]) + p[1:]
+}
+
+// componentModel is the framework-neutral description of a component group
+// the React, Vue and Svelte generators render.
+type componentModel struct {
+	Name     string
+	Tag      string
+	Void     bool
+	Count    int
+	Props    []componentProp
+	Fixed    []componentAttr
+	Variants []variantModel
+}
+
+// componentProp is an attribute that varies between instances, or a boolean
+// one.
+type componentProp struct {
+	Name      string
+	Attribute string
+	Type      string
+}
+
+// componentAttr is an attribute with the same value on every instance.
+type componentAttr struct {
+	Name  string
+	Value string
+}
+
+// variantModel is a variant with its styles mapped to Tailwind classes.
+type variantModel struct {
+	*componentVariant
+	Classes string
+}
+
+// newComponentModel derives the root element, props and variant classes of
+// a component group.
+func newComponentModel(group *componentGroup, mapper *tailwindMapper) *componentModel {
+	m := &componentModel{Name: pascalCase(group.Name), Tag: group.Variants[0].Tag, Count: group.Count}
+	if m.Tag == "" {
+		m.Tag = "div"
+	}
+	m.Void = isVoidElement(m.Tag)
+	for _, v := range group.Variants {
+		m.Variants = append(m.Variants, variantModel{v, strings.Join(mapper.classes(v.Styles), " ")})
+	}
+
+	values := make(map[string]map[string]bool)
+	for _, v := range group.Variants {
+		for attr, list := range v.Attributes {
+			if attr == "class" || attr == "style" || strings.HasPrefix(attr, "data-") || strings.HasPrefix(attr, "on") {
+				continue
+			}
+			if values[attr] == nil {
+				values[attr] = make(map[string]bool)
+			}
+			for _, value := range list {
+				values[attr][value] = true
+			}
+		}
+	}
+	attrs := make([]string, 0, len(values))
+	for attr := range values {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	for _, attr := range attrs {
+		list := sortedSetKeys(values[attr])
+		switch {
+		case (len(list) == 1 && list[0] == "") || attr == "disabled" || attr == "required" || attr == "checked" || attr == "readonly":
+			m.Props = append(m.Props, componentProp{camelCase(attr), attr, "boolean"})
+		case len(list) == 1:
+			m.Fixed = append(m.Fixed, componentAttr{attr, list[0]})
+		default:
+			m.Props = append(m.Props, componentProp{camelCase(attr), attr, unionType(list)})
+		}
+	}
+	return m
+}
+
+// variantType is the string literal union of the variant names.
+func (m *componentModel) variantType() string {
+	names := make([]string, len(m.Variants))
+	for i, v := range m.Variants {
+		names[i] = "'" + v.Name + "'"
+	}
+	return strings.Join(names, " | ")
+}
+
+// writeHeader writes the generated-file note and the sample markup of each
+// variant as // comments.
+func (m *componentModel) writeHeader(b *strings.Builder, indent string) {
+	fmt.Fprintf(b, "%s// Generated by agicap-explorer from component_library.json: %d instances in %d variants.\n", indent, m.Count, len(m.Variants))
+	fmt.Fprintf(b, "%s// This is a starting point; check the structure against the sample markup below.\n", indent)
+	for _, v := range m.Variants {
+		fmt.Fprintf(b, "%s//\n%s// %s (%d×, %s):\n", indent, indent, v.Name, v.Count, strings.Join(v.Pages, ", "))
+		// A literal </script> would end the Vue and Svelte script blocks
+		markup := strings.ReplaceAll(strings.Join(strings.Fields(v.HTML), " "), "</script", `<\/script`)
+		fmt.Fprintf(b, "%s//   %s\n", indent, truncate(markup, 300))
+		if len(v.Texts) > 0 {
+			fmt.Fprintf(b, "%s//   texts: %s\n", indent, truncate(strings.Join(v.Texts, " · "), 200))
+		}
+	}
+}
+
+// writeVariantClasses writes the variantClasses lookup.
+func (m *componentModel) writeVariantClasses(b *strings.Builder, indent, variantType string) {
+	fmt.Fprintf(b, "%sconst variantClasses: Record<%s, string> = {\n", indent, variantType)
+	for _, v := range m.Variants {
+		fmt.Fprintf(b, "%s  '%s': '%s',\n", indent, v.Name, v.Classes)
+	}
+	fmt.Fprintf(b, "%s};\n", indent)
+}
+
+// componentStories renders a CSF3 stories file with a story per variant, its
+// args taken from the texts and attribute values seen in the app; slotArg
+// names the arg holding the content, if the framework takes it as an arg.
+func componentStories(m *componentModel, storybook, importLine, slotArg string) string {
+	options := make([]string, len(m.Variants))
+	for i, v := range m.Variants {
+		options[i] = "'" + v.Name + "'"
+	}
+
+	var b strings.Builder
+	b.WriteString("// Generated by agicap-explorer from component_library.json\n")
+	fmt.Fprintf(&b, "import type { Meta, StoryObj } from '%s';\n", storybook)
+	b.WriteString(importLine + "\n\n")
+	b.WriteString("const meta = {\n")
+	fmt.Fprintf(&b, "  title: 'Captured/%s',\n", m.Name)
+	fmt.Fprintf(&b, "  component: %s,\n", m.Name)
+	b.WriteString("  tags: ['autodocs'],\n")
+	fmt.Fprintf(&b, "  argTypes: {\n    variant: { control: 'select', options: [%s] },\n  },\n", strings.Join(options, ", "))
+	fmt.Fprintf(&b, "} satisfies Meta<typeof %s>;\n\n", m.Name)
+	b.WriteString("export default meta;\ntype Story = StoryObj<typeof meta>;\n")
+
+	used := make(map[string]bool)
+	for _, v := range m.Variants {
+		story := pascalCase(v.Name)
+		for base, i := story, 2; used[story]; i++ {
+			story = fmt.Sprintf("%s%d", base, i)
+		}
+		used[story] = true
+
+		args := []string{fmt.Sprintf("variant: '%s'", v.Name)}
+		for _, p := range m.Props {
+			values, ok := v.Attributes[p.Attribute]
+			switch {
+			case !ok:
+			case p.Type == "boolean":
+				args = append(args, p.Name+": true")
+			case len(values) > 0:
+				args = append(args, fmt.Sprintf("%s: %s", p.Name, jsString(values[0])))
+			}
+		}
+		if slotArg != "" && !m.Void && len(v.Texts) > 0 {
+			args = append(args, fmt.Sprintf("%s: %s", slotArg, jsString(v.Texts[0])))
+		}
+		fmt.Fprintf(&b, "\n// %d× on %s\n", v.Count, strings.Join(v.Pages, ", "))
+		fmt.Fprintf(&b, "export const %s: Story = {\n  args: {\n    %s,\n  },\n};\n", story, strings.Join(args, ",\n    "))
+	}
+	return b.String()
+}
+
+// unionType lists a few short values as a string literal union; anything
+// else is a plain string.
+func unionType(values []string) string {
+	if len(values) >= 5 {
+		return "string"
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		if !unionValue.MatchString(v) {
+			return "string"
+		}
+		quoted[i] = "'" + v + "'"
+	}
+	return strings.Join(quoted, " | ")
+}
+
+// tailwindMapper maps computed styles onto the theme keys written to
+// tailwind.config.js, falling back to arbitrary values.
+type tailwindMapper struct {
+	colors    map[string]string // hex -> dotted color name
+	fontSizes map[float64]string
+	weights   map[string]string
+	families  map[string]string
+	spacing   map[float64]string
+	radii     map[string]string
+	shadows   map[string]string
+}
+
+func newTailwindMapper(system map[string]interface{}) *tailwindMapper {
+	m := &tailwindMapper{
+		colors:    make(map[string]string),
+		fontSizes: make(map[float64]string),
+		weights:   make(map[string]string),
+		families:  make(map[string]string),
+		spacing:   make(map[float64]string),
+		radii:     make(map[string]string),
+		shadows:   make(map[string]string),
+	}
+	for name, hex := range flattenTokens("", system["colors"]) {
+		m.colors[hex] = name
+	}
+	typography, _ := system["typography"].(map[string]interface{})
+	for name, value := range flattenTokens("", typography["fontSize"]) {
+		if px, err := strconv.ParseFloat(strings.TrimSuffix(value, "px"), 64); err == nil {
+			m.fontSizes[px] = name
+		}
+	}
+	for name, value := range flattenTokens("", typography["fontWeight"]) {
+		m.weights[value] = name
+	}
+	for name, value := range flattenTokens("", typography["fontFamily"]) {
+		if name == "primary" {
+			name = "sans"
+		}
+		m.families[value] = name
+	}
+	for name, value := range flattenTokens("", system["spacing"]) {
+		if px, err := strconv.ParseFloat(strings.TrimSuffix(value, "px"), 64); err == nil {
+			m.spacing[px] = name
+		}
+	}
+	for name, value := range flattenTokens("", system["borderRadius"]) {
+		m.radii[value] = name
+	}
+	for name, value := range flattenTokens("", system["shadows"]) {
+		m.shadows[value] = name
+	}
+	return m
+}
+
+// classes returns the Tailwind classes for a variant's representative
+// styles.
+func (m *tailwindMapper) classes(styles map[string]string) []string {
+	var classes []string
+	switch display := styles["display"]; display {
+	case "flex", "inline-flex", "grid", "inline-grid", "inline-block", "inline":
+		classes = append(classes, display)
+	}
+	if c := m.color("bg", styles["backgroundColor"]); c != "" {
+		classes = append(classes, c)
+	}
+	if c := m.color("text", styles["color"]); c != "" {
+		classes = append(classes, c)
+	}
+	if px, err := strconv.ParseFloat(strings.TrimSuffix(styles["fontSize"], "px"), 64); err == nil {
+		classes = append(classes, "text-"+m.nearest(m.fontSizes, px, 0.5))
+	}
+	if name, ok := m.weights[styles["fontWeight"]]; ok && styles["fontWeight"] != "400" {
+		classes = append(classes, "font-"+name)
+	}
+	if name, ok := m.families[styles["fontFamily"]]; ok && name != "sans" {
+		classes = append(classes, "font-"+name)
+	}
+	classes = append(classes, m.boxSides("p", styles["padding"])...)
+	if border := styles["border"]; border != "" && !strings.HasPrefix(border, "0px") && !strings.Contains(border, " none") {
+		classes = append(classes, "border")
+		if c := m.color("border", cssColorPattern.FindString(border)); c != "" {
+			classes = append(classes, c)
+		}
+	}
+	if radius := strings.Fields(styles["borderRadius"]); len(radius) > 0 && radius[0] != "0px" {
+		if name, ok := m.radii[radius[0]]; ok {
+			classes = append(classes, "rounded-"+name)
+		} else {
+			classes = append(classes, "rounded-["+radius[0]+"]")
+		}
+	}
+	if shadow := styles["boxShadow"]; shadow != "" && shadow != "none" {
+		if name, ok := m.shadows[shadow]; ok {
+			classes = append(classes, "shadow-"+name)
+		} else {
+			classes = append(classes, "shadow-["+strings.ReplaceAll(strings.ReplaceAll(shadow, ", ", ","), " ", "_")+"]")
+		}
+	}
+	return classes
+}
+
+// color maps a computed color onto the nearest semantic color within ΔE 10.
+func (m *tailwindMapper) color(prefix, value string) string {
+	hex, ok := cssHex(value)
+	if !ok {
+		return ""
+	}
+	switch name := m.colorName(hex); {
+	case name != "":
+		return prefix + "-" + strings.ReplaceAll(name, ".", "-")
+	case hex == "#ffffff":
+		return prefix + "-white"
+	case hex == "#000000":
+		return prefix + "-black"
+	}
+	return prefix + "-[" + hex + "]"
+}
+
+// colorName returns the dotted name of the semantic color within ΔE 10 of
+// hex, or "".
+func (m *tailwindMapper) colorName(hex string) string {
+	best, bestDist := "", 10.0
+	for candidate, name := range m.colors {
+		if d := hexDistance(hex, candidate); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// cssHex converts a computed color to hex, skipping fully transparent ones.
+func cssHex(value string) (string, bool) {
+	hex, _, ok := parseCSSColor(value)
+	if !ok || (len(hex) == 9 && hex[7:] == "00") {
+		return "", false
+	}
+	return hex, true
+}
+
+// nearest returns the scale step within tolerance of px, or an arbitrary
+// value.
+func (m *tailwindMapper) nearest(scale map[float64]string, px, tolerance float64) string {
+	best, bestDist := "", tolerance
+	for value, name := range scale {
+		if d := math.Abs(value - px); d < bestDist || (d == bestDist && (best == "" || name < best)) {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" {
+		return "[" + formatPx(px) + "]"
+	}
+	return best
+}
+
+// boxSides turns a padding shorthand into p-, px-/py- or per-side classes.
+func (m *tailwindMapper) boxSides(prefix, shorthand string) []string {
+	var px []float64
+	for _, match := range cssLengthPattern.FindAllStringSubmatch(shorthand, -1) {
+		v, _ := strconv.ParseFloat(match[1], 64)
+		px = append(px, v)
+	}
+	// top right bottom left, CSS shorthand expansion
+	switch len(px) {
+	case 1:
+		px = []float64{px[0], px[0], px[0], px[0]}
+	case 2:
+		px = []float64{px[0], px[1], px[0], px[1]}
+	case 3:
+		px = []float64{px[0], px[1], px[2], px[1]}
+	case 4:
+	default:
+		return nil
+	}
+	step := func(side string, v float64) []string {
+		if v == 0 {
+			return nil
+		}
+		return []string{prefix + side + "-" + m.nearest(m.spacing, v, 0.5)}
+	}
+	switch {
+	case px[0] == px[1] && px[1] == px[2] && px[2] == px[3]:
+		return step("", px[0])
+	case px[0] == px[2] && px[1] == px[3]:
+		return append(step("y", px[0]), step("x", px[1])...)
+	}
+	return append(append(append(step("t", px[0]), step("r", px[1])...), step("b", px[2])...), step("l", px[3])...)
+}
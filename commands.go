@@ -0,0 +1,18 @@
+package main
+
+// subcommands are run instead of a crawl when their name is the first
+// argument, e.g. `explorer diff-tokens runA/ runB/`.
+var subcommands = map[string]func(args []string) error{
+	"diff-tokens":     runDiffTokens,
+	"codegen":         runCodegen,
+	"site":            runSite,
+	"compare":         runCompare,
+	"validate-output": runValidateOutput,
+	"sqlite":          runSQLite,
+	"baseline":        runBaseline,
+	"watch":           runWatch,
+	"heatmap":         runHeatmap,
+	"selectors":       runSelectors,
+	"record":          runRecord,
+	"upload":          runUpload,
+}
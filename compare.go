@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runComparison is the difference between the pages of two runs, matched
+// by canonical URL.
+type runComparison struct {
+	RunA      string           `json:"run_a"`
+	RunB      string           `json:"run_b"`
+	Added     []comparedPage   `json:"added"`
+	Removed   []comparedPage   `json:"removed"`
+	Changed   []pageComparison `json:"changed"`
+	Unchanged int              `json:"unchanged"`
+}
+
+type comparedPage struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// pageComparison lists what changed on a page present in both runs.
+// PixelDiff is the percentage of differing pixels, nil when either
+// screenshot is missing; DOM is nil when either DOM snapshot is.
+type pageComparison struct {
+	URL          string   `json:"url"`
+	TitleBefore  string   `json:"title_before"`
+	TitleAfter   string   `json:"title_after,omitempty"`
+	PixelDiff    *float64 `json:"pixel_diff_percent,omitempty"`
+	LinksAdded   []string `json:"links_added,omitempty"`
+	LinksRemoved []string `json:"links_removed,omitempty"`
+	Diff         string   `json:"diff,omitempty"` // diff image under -html
+	Ignored      int      `json:"ignored_regions,omitempty"`
+	DOM          *domDiff `json:"dom,omitempty"` // structural changes
+
+	key, shotA, shotB string
+	ignore            []image.Rectangle
+}
+
+// runCompare compares the pages of two runs:
+//
+//	explorer compare [-json] [-out file] [-tolerance n] [-min-diff pct] [-html dir] [-locale en|de] runA/ runB/
+//
+// With -html, comparison.html in dir shows the before, after and diff
+// images of every visually changed page and the structural changes.
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the comparison as JSON instead of Markdown")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	tolerance := fs.Int("tolerance", 16, "per-channel difference (0-255) below which pixels count as equal")
+	minDiff := fs.Float64("min-diff", 0.1, "screenshot difference in percent below which a page counts as unchanged")
+	htmlDir := fs.String("html", "", "write comparison.html with diff images of the changed pages into this directory")
+	sensitivity := fs.Float64("sensitivity", 0.1, "perceptual color difference (0-1) the diff images highlight")
+	locale := fs.String("locale", "", "language of comparison.html (en, de)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: compare [-json] [-out file] [-tolerance n] [-min-diff pct] [-html dir] [-locale en|de] <runA> <runB>")
+	}
+
+	comparison, err := compareRuns(fs.Arg(0), fs.Arg(1), *tolerance, *minDiff)
+	if err != nil {
+		return err
+	}
+	if *htmlDir != "" {
+		if err := comparisonHTML(comparison, *htmlDir, *locale, *sensitivity); err != nil {
+			return err
+		}
+	}
+
+	var report []byte
+	if *asJSON {
+		if report, err = json.MarshalIndent(comparison, "", "  "); err != nil {
+			return err
+		}
+	} else {
+		report = []byte(comparisonMarkdown(comparison))
+	}
+	if *out != "" {
+		return ioutil.WriteFile(*out, report, 0644)
+	}
+	_, err = os.Stdout.Write(report)
+	return err
+}
+
+// compareRuns matches the pages of two runs by canonical URL and compares
+// their titles, screenshots, DOM structure and navigation links.
+func compareRuns(runA, runB string, tolerance int, minDiff float64) (*runComparison, error) {
+	before, err := loadNavigationMap(runA)
+	if err != nil {
+		return nil, err
+	}
+	after, err := loadNavigationMap(runB)
+	if err != nil {
+		return nil, err
+	}
+	key := func(item NavigationItem) string {
+		if item.CanonicalURL != "" {
+			return item.CanonicalURL
+		}
+		return item.URL
+	}
+	pagesB := make(map[string]NavigationItem)
+	for _, item := range after {
+		if _, ok := pagesB[key(item)]; !ok {
+			pagesB[key(item)] = item
+		}
+	}
+
+	c := &runComparison{RunA: runA, RunB: runB}
+	seen := make(map[string]bool)
+	for _, a := range before {
+		url := key(a)
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+		b, ok := pagesB[url]
+		if !ok {
+			c.Removed = append(c.Removed, comparedPage{url, a.Title})
+			continue
+		}
+
+		page := pageComparison{URL: url, TitleBefore: a.Title, key: pageKey(b)}
+		changed := false
+		if a.Title != b.Title {
+			page.TitleAfter, changed = b.Title, true
+		}
+		page.shotA = comparableScreenshot(runA, a)
+		page.shotB = comparableScreenshot(runB, b)
+		page.ignore = append(ignoredRegions(runA, a), ignoredRegions(runB, b)...)
+		page.Ignored = len(page.ignore)
+		if diff, err := screenshotDiff(page.shotA, page.shotB, tolerance, page.ignore); err == nil {
+			page.PixelDiff = &diff
+			changed = changed || diff >= minDiff
+		}
+		if domA, err := loadDOMTree(runA, a); err == nil {
+			if domB, err := loadDOMTree(runB, b); err == nil {
+				page.DOM = diffDOM(domA, domB)
+				changed = changed || page.DOM.Count() > 0
+			}
+		}
+		page.LinksRemoved, page.LinksAdded = diffStrings(a.Navigation, b.Navigation)
+		changed = changed || len(page.LinksAdded) > 0 || len(page.LinksRemoved) > 0
+
+		if changed {
+			c.Changed = append(c.Changed, page)
+		} else {
+			c.Unchanged++
+		}
+	}
+	for _, b := range after {
+		url := key(b)
+		if !seen[url] {
+			seen[url] = true
+			c.Added = append(c.Added, comparedPage{url, b.Title})
+		}
+	}
+	sort.SliceStable(c.Changed, func(i, j int) bool {
+		return diffValue(c.Changed[i].PixelDiff) > diffValue(c.Changed[j].PixelDiff)
+	})
+	return c, nil
+}
+
+// comparisonHTML writes the diff images of the pages whose screenshots
+// changed and comparison.html showing them with the structural changes
+// into dir.
+func comparisonHTML(c *runComparison, dir, locale string, sensitivity float64) error {
+	data := comparisonData{
+		Heading: "Run Comparison",
+		RunA:    c.RunA,
+		RunB:    c.RunB,
+		Counts:  []reportCount{{"Added", len(c.Added)}, {"Removed", len(c.Removed)}, {"Changed", len(c.Changed)}, {"Unchanged", c.Unchanged}},
+	}
+	for i := range c.Changed {
+		p := &c.Changed[i]
+		page := comparisonPage{Title: p.TitleBefore, URL: p.URL}
+		if p.DOM.Count() > 0 {
+			page.Structure = p.DOM
+		}
+		if diffValue(p.PixelDiff) > 0 {
+			a, errA := decodeMasked(p.shotA, p.ignore)
+			b, errB := decodeMasked(p.shotB, p.ignore)
+			if errA == nil && errB == nil {
+				page.Summary = fmt.Sprintf("%.2f%%", *p.PixelDiff)
+				if err := writeTriptych(dir, p.key, a, b, sensitivity, &page); err != nil {
+					return err
+				}
+				p.Diff = filepath.Join(dir, filepath.FromSlash(page.Diff))
+			}
+		}
+		if page.Diff != "" || page.Structure != nil {
+			data.Pages = append(data.Pages, page)
+		}
+	}
+	return writeComparisonHTML(dir, locale, data)
+}
+
+func diffValue(p *float64) float64 {
+	if p == nil {
+		return -1
+	}
+	return *p
+}
+
+// diffStrings returns the values only in a and only in b, sorted.
+func diffStrings(a, b []string) (onlyA, onlyB []string) {
+	inA, inB := make(map[string]bool), make(map[string]bool)
+	for _, s := range a {
+		inA[s] = true
+	}
+	for _, s := range b {
+		inB[s] = true
+	}
+	for _, s := range sortedSetKeys(inA) {
+		if !inB[s] {
+			onlyA = append(onlyA, s)
+		}
+	}
+	for _, s := range sortedSetKeys(inB) {
+		if !inA[s] {
+			onlyB = append(onlyB, s)
+		}
+	}
+	return onlyA, onlyB
+}
+
+// comparableScreenshot is the screenshot of a page to diff: the lossless
+// original when a run kept one next to its JPEG or WebP screenshots.
+func comparableScreenshot(runDir string, item NavigationItem) string {
+	original := filepath.Join(runDir, "screenshots", "original", pageKey(item)+".png")
+	if _, err := os.Stat(original); err == nil {
+		return original
+	}
+	return filepath.Join(runDir, "screenshots", filepath.Base(item.Screenshot))
+}
+
+// screenshotDiff returns the percentage of pixels that differ between two
+// PNG or JPEG screenshots by more than tolerance in any channel. Pixels
+// outside the overlap of differently sized screenshots count as different,
+// the ignored regions of either run as equal.
+func screenshotDiff(pathA, pathB string, tolerance int, ignore []image.Rectangle) (float64, error) {
+	a, err := decodeMasked(pathA, ignore)
+	if err != nil {
+		return 0, err
+	}
+	b, err := decodeMasked(pathB, ignore)
+	if err != nil {
+		return 0, err
+	}
+	return pixelDiff(a, b, tolerance), nil
+}
+
+// pixelDiff is screenshotDiff for decoded images.
+func pixelDiff(a, b image.Image, tolerance int) float64 {
+	ba, bb := a.Bounds(), b.Bounds()
+	w, h := min(ba.Dx(), bb.Dx()), min(ba.Dy(), bb.Dy())
+	total := max(ba.Dx()*ba.Dy(), bb.Dx()*bb.Dy())
+	if total == 0 {
+		return 0
+	}
+
+	limit := uint32(tolerance) * 0x101
+	differing := total - w*h
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r1, g1, b1, a1 := a.At(ba.Min.X+x, ba.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			if absDiff(r1, r2) > limit || absDiff(g1, g2) > limit || absDiff(b1, b2) > limit || absDiff(a1, a2) > limit {
+				differing++
+			}
+		}
+	}
+	return float64(differing) * 100 / float64(total)
+}
+
+// decodeImage decodes a PNG or JPEG; WebP screenshots cannot be diffed.
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// comparisonMarkdown renders the comparison with the most visually changed
+// pages first.
+func comparisonMarkdown(c *runComparison) string {
+	var b strings.Builder
+	b.WriteString("# 🔍 Run Comparison\n\n")
+	fmt.Fprintf(&b, "**Generated:** %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Comparing `%s` → `%s`\n\n", c.RunA, c.RunB)
+	fmt.Fprintf(&b, "%d pages added, %d removed, %d changed, %d unchanged.\n", len(c.Added), len(c.Removed), len(c.Changed), c.Unchanged)
+
+	pages := func(title string, list []comparedPage) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		for _, p := range list {
+			fmt.Fprintf(&b, "- **%s** - `%s`\n", p.Title, p.URL)
+		}
+	}
+	pages("➕ Added Pages", c.Added)
+	pages("➖ Removed Pages", c.Removed)
+
+	if len(c.Changed) > 0 {
+		b.WriteString("\n## ✏️ Changed Pages\n\n| Page | Title | Pixel Diff | Structure | Links |\n|---|---|---|---|---|\n")
+		for _, p := range c.Changed {
+			title := "unchanged"
+			if p.TitleAfter != "" {
+				title = fmt.Sprintf("%s → %s", p.TitleBefore, p.TitleAfter)
+			}
+			diff := "n/a"
+			if p.PixelDiff != nil {
+				diff = fmt.Sprintf("%.2f%%", *p.PixelDiff)
+			}
+			if p.Ignored > 0 {
+				diff += fmt.Sprintf(" (%d regions ignored)", p.Ignored)
+			}
+			if p.Diff != "" {
+				diff = fmt.Sprintf("[%s](%s)", diff, filepath.ToSlash(p.Diff))
+			}
+			structure := "n/a"
+			if p.DOM != nil {
+				structure = "unchanged"
+				if p.DOM.Count() > 0 {
+					structure = p.DOM.Summary()
+				}
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | +%d / -%d |\n", p.URL, title, diff, structure, len(p.LinksAdded), len(p.LinksRemoved))
+		}
+		for _, p := range c.Changed {
+			if len(p.LinksAdded) == 0 && len(p.LinksRemoved) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n", p.TitleBefore)
+			for _, link := range p.LinksAdded {
+				fmt.Fprintf(&b, "- ➕ %s\n", link)
+			}
+			for _, link := range p.LinksRemoved {
+				fmt.Fprintf(&b, "- ➖ %s\n", link)
+			}
+		}
+		structural := false
+		for _, p := range c.Changed {
+			if p.DOM.Count() == 0 {
+				continue
+			}
+			if !structural {
+				b.WriteString("\n## 🧱 Structural Changes\n")
+				structural = true
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n", p.TitleBefore)
+			b.WriteString(domDiffMarkdown(p.DOM, 10))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	htmlTagPattern   = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)([^>]*?)(/?)>`)
+	htmlClassPattern = regexp.MustCompile(`\sclass="([^"]*)"`)
+	// styled-components, emotion and styled-jsx class names are pure hashes
+	classHashPattern = regexp.MustCompile(`^(sc|css|jsx|emotion)-[A-Za-z0-9]+$`)
+	// CSS-module suffixes: Button_primary__x7Yz1, card-3f9a1c
+	classSuffixPattern = regexp.MustCompile(`(__|-)[A-Za-z0-9_-]{5,}$`)
+	hasDigitPattern    = regexp.MustCompile(`[0-9]`)
+	classWordSplit     = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// componentSimilarity is the Jaccard similarity above which two components
+// are treated as the same variant.
+const componentSimilarity = 0.6
+
+// variantKeywords name a variant when one of them appears in the classes
+// that set it apart from its siblings.
+var variantKeywords = []string{"primary", "secondary", "tertiary", "danger", "destructive", "error", "success", "warning", "info",
+	"ghost", "outline", "outlined", "link", "icon", "elevated", "flat", "filled", "compact", "small", "large", "sm", "md", "lg", "xl"}
+
+// componentCategories maps the analysis' component types to library names.
+var componentCategories = []struct {
+	name     string
+	patterns []string
+}{
+	{"Button", []string{"button", "btn", "submit"}},
+	{"Card", []string{"card", "panel"}},
+	{"Input", []string{"input", "textarea"}},
+	{"Select", []string{"select", "dropdown"}},
+	{"Form", []string{"form"}},
+	{"Table", []string{"table", "grid"}},
+	{"Navigation", []string{"header", "nav", "banner", "navigation"}},
+	{"Sidebar", []string{"sidebar", "aside", "menu"}},
+	{"Modal", []string{"modal", "dialog"}},
+	{"Chart", []string{"chart", "graph", "canvas", "svg"}},
+}
+
+// componentSize is the range of rendered sizes of a variant.
+type componentSize struct {
+	MinWidth  float64 `json:"minWidth"`
+	MaxWidth  float64 `json:"maxWidth"`
+	MinHeight float64 `json:"minHeight"`
+	MaxHeight float64 `json:"maxHeight"`
+}
+
+// componentVariant is a cluster of structurally similar components.
+type componentVariant struct {
+	Name       string                    `json:"name"`
+	Count      int                       `json:"count"`
+	Tag        string                    `json:"tag"`
+	Classes    []string                  `json:"classes"`
+	Pages      []string                  `json:"pages"`
+	HTML       string                    `json:"html"`
+	Styles     map[string]string         `json:"styles"`
+	Texts      []string                  `json:"texts,omitempty"`
+	Attributes map[string][]string       `json:"attributes,omitempty"`
+	Size       componentSize             `json:"size"`
+	Screenshot string                    `json:"screenshot,omitempty"`
+	States     map[string]componentState `json:"states,omitempty"`
+
+	features map[string]bool
+	members  []componentInfo
+}
+
+// componentGroup is one kind of component (Button, Card, ...).
+type componentGroup struct {
+	Name        string              `json:"name"`
+	Count       int                 `json:"count"`
+	Variants    []*componentVariant `json:"variants"`
+	StateMatrix *stateMatrix        `json:"stateMatrix,omitempty"`
+}
+
+// componentLibrary is component_library.json.
+type componentLibrary struct {
+	Generated  string            `json:"generated"`
+	Pages      int               `json:"pages"`
+	Components []*componentGroup `json:"components"`
+	Layouts    map[string]int    `json:"layouts"`
+}
+
+// buildComponentLibrary clusters the components of every
+// components/*_analysis.json in a run directory by tag structure and classes
+// and names the resulting variants.
+func buildComponentLibrary(outputDir string) *componentLibrary {
+	lib := &componentLibrary{Generated: time.Now().Format(time.RFC3339), Layouts: make(map[string]int)}
+	groups := make(map[string]*componentGroup)
+
+	files, _ := filepath.Glob(filepath.Join(outputDir, "components", "*_analysis.json"))
+	sort.Strings(files)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var analysis pageAnalysis
+		if err := json.Unmarshal(data, &analysis); err != nil {
+			continue
+		}
+		lib.Pages++
+		page := strings.TrimSuffix(filepath.Base(file), "_analysis.json")
+		for section, present := range map[string]bool{
+			"header": analysis.Layout.HasHeader, "sidebar": analysis.Layout.HasSidebar,
+			"footer": analysis.Layout.HasFooter, "main": analysis.Layout.MainContent,
+		} {
+			if present {
+				lib.Layouts[section]++
+			}
+		}
+		if analysis.Layout.GridSystem != "" {
+			lib.Layouts["grid:"+analysis.Layout.GridSystem]++
+		}
+
+		for _, c := range analysis.Components {
+			category := componentCategory(c.Type)
+			if category == "" || c.Position.Width == 0 || c.Position.Height == 0 {
+				continue
+			}
+			group, ok := groups[category]
+			if !ok {
+				group = &componentGroup{Name: category}
+				groups[category] = group
+			}
+			group.Count++
+			group.add(c, page)
+		}
+	}
+
+	for _, g := range groups {
+		sort.SliceStable(g.Variants, func(i, j int) bool { return g.Variants[i].Count > g.Variants[j].Count })
+		g.nameVariants()
+		for _, v := range g.Variants {
+			v.summarize()
+		}
+		g.StateMatrix = g.buildStateMatrix()
+		lib.Components = append(lib.Components, g)
+	}
+	sort.Slice(lib.Components, func(i, j int) bool { return lib.Components[i].Count > lib.Components[j].Count })
+	return lib
+}
+
+// componentCategory returns the library name for an analysis type.
+func componentCategory(componentType string) string {
+	t := strings.ToLower(componentType)
+	for _, c := range componentCategories {
+		for _, p := range c.patterns {
+			if strings.Contains(t, p) {
+				return c.name
+			}
+		}
+	}
+	return ""
+}
+
+// add puts a component into the most similar variant or starts a new one.
+func (g *componentGroup) add(c componentInfo, page string) {
+	features := componentFeatures(c.HTML)
+	var best *componentVariant
+	bestScore := componentSimilarity
+	for _, v := range g.Variants {
+		if score := jaccard(features, v.features); score >= bestScore {
+			best, bestScore = v, score
+		}
+	}
+	if best == nil {
+		best = &componentVariant{features: features}
+		g.Variants = append(g.Variants, best)
+	}
+	best.Count++
+	best.members = append(best.members, c)
+	best.Pages = appendUnique(best.Pages, page)
+}
+
+// componentFeatures describes a component's markup as a set of parent>child
+// tag pairs plus its normalized class names.
+func componentFeatures(markup string) map[string]bool {
+	features := make(map[string]bool)
+	var stack []string
+	for _, m := range htmlTagPattern.FindAllStringSubmatch(markup, -1) {
+		closing, tag, attrs, selfClosing := m[1] == "/", strings.ToLower(m[2]), m[3], m[4] == "/"
+		if closing {
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == tag {
+					stack = stack[:i]
+					break
+				}
+			}
+			continue
+		}
+		parent := "^"
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+		features["tag:"+parent+">"+tag] = true
+		if cls := htmlClassPattern.FindStringSubmatch(attrs); cls != nil && len(stack) < 2 {
+			for _, name := range normalizeClasses(cls[1]) {
+				features["class:"+name] = true
+			}
+		}
+		if !selfClosing && !isVoidElement(tag) {
+			stack = append(stack, tag)
+		}
+	}
+	return features
+}
+
+// normalizeClasses drops build hashes so generated class names compare
+// equal across builds and pages.
+func normalizeClasses(classes string) []string {
+	var names []string
+	for _, c := range strings.Fields(classes) {
+		if classHashPattern.MatchString(c) {
+			continue
+		}
+		// Only suffixes with a digit are hashes; card__header is BEM
+		if suffix := classSuffixPattern.FindString(c); suffix != "" && hasDigitPattern.MatchString(suffix) {
+			c = strings.TrimSuffix(c, suffix)
+		}
+		if c != "" {
+			names = append(names, c)
+		}
+	}
+	return names
+}
+
+func isVoidElement(tag string) bool {
+	switch tag {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "source", "track", "wbr", "path", "circle", "rect", "line", "polyline", "polygon", "ellipse", "use":
+		return true
+	}
+	return false
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// nameVariants names each variant after the variant keyword in the classes
+// that set it apart from the others (primary, outline, sm, ...), falling
+// back to its look.
+func (g *componentGroup) nameVariants() {
+	shared := make(map[string]int)
+	for _, v := range g.Variants {
+		for feature := range v.features {
+			shared[feature]++
+		}
+	}
+	used := make(map[string]bool)
+	for i, v := range g.Variants {
+		name := ""
+		for _, keyword := range variantKeywords {
+			for feature := range v.features {
+				if len(g.Variants) > 1 && shared[feature] == len(g.Variants) {
+					continue
+				}
+				if strings.HasPrefix(feature, "class:") && classHasWord(strings.TrimPrefix(feature, "class:"), keyword) && !used[keyword] {
+					name = keyword
+					break
+				}
+			}
+			if name != "" {
+				break
+			}
+		}
+		if name == "" && len(v.members) > 0 {
+			css := v.members[0].CSS
+			transparent := css["backgroundColor"] == "" || css["backgroundColor"] == "rgba(0, 0, 0, 0)"
+			hasBorder := css["border"] != "" && !strings.HasPrefix(css["border"], "0px")
+			switch {
+			case i == 0 && !used["default"]:
+				name = "default"
+			case transparent && hasBorder:
+				name = "outline"
+			case transparent:
+				name = "ghost"
+			case css["boxShadow"] != "" && css["boxShadow"] != "none":
+				name = "elevated"
+			}
+		}
+		if name == "" || used[name] {
+			name = fmt.Sprintf("variant-%d", i+1)
+		}
+		used[name] = true
+		v.Name = name
+	}
+}
+
+// classHasWord reports whether a class name contains word as a segment
+// (btn-primary, Button_primary, isPrimary).
+func classHasWord(class, word string) bool {
+	lower := strings.ToLower(class)
+	for _, part := range classWordSplit.Split(lower, -1) {
+		if part == word {
+			return true
+		}
+	}
+	return strings.HasSuffix(lower, word) && len(class) > len(word) && class[len(class)-len(word)] >= 'A' && class[len(class)-len(word)] <= 'Z'
+}
+
+// summarize fills in the representative sample of a variant: the most common
+// value of each style property, its markup, texts, varying attributes,
+// size range and a screenshot and states when one was captured.
+func (v *componentVariant) summarize() {
+	styleCounts := make(map[string]tokenCounts)
+	attrValues := make(map[string]map[string]bool)
+	v.Size = componentSize{MinWidth: -1, MinHeight: -1}
+	for _, m := range v.members {
+		for prop, value := range m.CSS {
+			if styleCounts[prop] == nil {
+				styleCounts[prop] = tokenCounts{}
+			}
+			styleCounts[prop][value]++
+		}
+		for name, value := range m.Attributes {
+			if name == "id" || name == "style" || strings.HasPrefix(name, "data-explorer") {
+				continue
+			}
+			if attrValues[name] == nil {
+				attrValues[name] = make(map[string]bool)
+			}
+			attrValues[name][value] = true
+		}
+		if text := strings.TrimSpace(m.Text); text != "" && len(v.Texts) < 10 {
+			v.Texts = appendUnique(v.Texts, truncate(text, 80))
+		}
+		if v.HTML == "" {
+			v.HTML = m.HTML
+		}
+		if v.Screenshot == "" && m.Screenshot != "" {
+			v.Screenshot = m.Screenshot
+		}
+		if v.States == nil && len(m.States) > 0 {
+			v.States = m.States
+		}
+		if v.Size.MinWidth < 0 || m.Position.Width < v.Size.MinWidth {
+			v.Size.MinWidth = m.Position.Width
+		}
+		if v.Size.MinHeight < 0 || m.Position.Height < v.Size.MinHeight {
+			v.Size.MinHeight = m.Position.Height
+		}
+		if m.Position.Width > v.Size.MaxWidth {
+			v.Size.MaxWidth = m.Position.Width
+		}
+		if m.Position.Height > v.Size.MaxHeight {
+			v.Size.MaxHeight = m.Position.Height
+		}
+	}
+
+	v.Styles = make(map[string]string)
+	for prop, counts := range styleCounts {
+		v.Styles[prop] = counts.byCount()[0]
+	}
+	// Attributes whose values differ between instances are likely props
+	v.Attributes = make(map[string][]string)
+	for name, values := range attrValues {
+		if name == "class" {
+			continue
+		}
+		var list []string
+		for value := range values {
+			list = append(list, value)
+		}
+		sort.Strings(list)
+		if len(list) > 5 {
+			list = list[:5]
+		}
+		v.Attributes[name] = list
+	}
+
+	for feature := range v.features {
+		if strings.HasPrefix(feature, "class:") {
+			v.Classes = append(v.Classes, strings.TrimPrefix(feature, "class:"))
+		} else if strings.HasPrefix(feature, "tag:^>") && v.Tag == "" {
+			v.Tag = strings.TrimPrefix(feature, "tag:^>")
+		}
+	}
+	sort.Strings(v.Classes)
+}
+
+// stateMatrix compares the interactive states of a component type: for
+// every variant and state, the values of the properties that any state
+// changes.
+type stateMatrix struct {
+	States     []string         `json:"states"`
+	Properties []string         `json:"properties"`
+	Rows       []stateMatrixRow `json:"rows"`
+}
+
+type stateMatrixRow struct {
+	Variant     string                       `json:"variant"`
+	Values      map[string]map[string]string `json:"values"`
+	Screenshots map[string]string            `json:"screenshots,omitempty"`
+}
+
+// stateOrder lists the known states in the order the matrix shows them;
+// other states follow alphabetically.
+var stateOrder = []string{"default", "hover", "focus", "active", "disabled", "error"}
+
+// buildStateMatrix returns nil when no variant of the group has states.
+func (g *componentGroup) buildStateMatrix() *stateMatrix {
+	seen := make(map[string]bool)
+	props := make(map[string]bool)
+	for _, v := range g.Variants {
+		for state, s := range v.States {
+			seen[state] = true
+			for _, prop := range s.Changed {
+				props[prop] = true
+			}
+		}
+	}
+	if len(seen) == 0 {
+		return nil
+	}
+
+	m := &stateMatrix{Properties: sortedSetKeys(props)}
+	for _, state := range stateOrder {
+		if seen[state] {
+			m.States = append(m.States, state)
+			delete(seen, state)
+		}
+	}
+	m.States = append(m.States, sortedSetKeys(seen)...)
+
+	for _, v := range g.Variants {
+		if len(v.States) == 0 {
+			continue
+		}
+		row := stateMatrixRow{Variant: v.Name, Values: make(map[string]map[string]string), Screenshots: make(map[string]string)}
+		for state, s := range v.States {
+			values := make(map[string]string)
+			for _, prop := range m.Properties {
+				if value, ok := s.CSS[prop]; ok {
+					values[prop] = value
+				}
+			}
+			row.Values[state] = values
+			if s.Screenshot != "" {
+				row.Screenshots[state] = s.Screenshot
+			}
+		}
+		m.Rows = append(m.Rows, row)
+	}
+	return m
+}
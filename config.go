@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// flagKeys maps command-line flags to the config keys they override.
+var flagKeys = map[string]string{
+	"output":       "explorer.output.directory",
+	"headless":     "explorer.browser.headless",
+	"max-pages":    "explorer.exploration.max_pages",
+	"max-depth":    "explorer.exploration.max_depth",
+	"max-duration": "explorer.exploration.max_duration",
+	"concurrency":  "explorer.exploration.concurrency",
+	"seed":         "explorer.seeds.urls",
+	"seed-file":    "explorer.seeds.file",
+	"sitemap":      "explorer.seeds.sitemap",
+	"polite":       "explorer.politeness.enabled",
+	"pdf":          "explorer.capture.pdf",
+	"assets":       "explorer.assets.enabled",
+	"record":       "explorer.recording.enabled",
+	"responsive":   "explorer.responsive.enabled",
+	"viewports":    "explorer.viewports.enabled",
+	"throttling":   "explorer.throttling.enabled",
+	"print":        "explorer.print.enabled",
+	"scroll":       "explorer.scroll.enabled",
+	"overlays":     "explorer.overlays.enabled",
+	"tables":       "explorer.tables.enabled",
+	"zoom":         "explorer.zoom.enabled",
+	"locales":      "explorer.locales.enabled",
+	"mock":         "explorer.mocking.fixtures",
+	"read-only":    "explorer.safety.read_only",
+	"archive":      "explorer.archive.enabled",
+	"upload":       "explorer.upload.enabled",
+	"sqlite":       "explorer.storage.sqlite.enabled",
+	"lossless":     "explorer.screenshots.lossless",
+	"scale-factor": "explorer.screenshots.scale_factor",
+	"incremental":  "explorer.incremental.enabled",
+	"bench":        "explorer.bench.enabled",
+	"previous":     "explorer.incremental.previous",
+	"locale":       "explorer.output.locale",
+	"include":      "explorer.scope.include",
+	"exclude":      "explorer.scope.exclude",
+	"schedule":     "explorer.watch.schedule",
+	"once":         "explorer.watch.once",
+	"metrics":      "explorer.watch.metrics",
+}
+
+// listFlag is a repeatable string flag (-exclude a -exclude b).
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *listFlag) Get() interface{} {
+	return []string(*l)
+}
+
+// loadConfig reads the YAML configuration (if present) and applies any
+// command-line flags on top of it.
+func loadConfig(args []string) (*viper.Viper, error) {
+	fs := flag.NewFlagSet("explorer", flag.ContinueOnError)
+	configFile := fs.String("config", "config.yaml", "path to the YAML configuration file")
+	fs.String("output", "", "output directory")
+	fs.Bool("headless", true, "run the browser without a window")
+	fs.Int("max-pages", 0, "maximum number of pages to capture")
+	fs.Int("max-depth", 0, "maximum link depth from the start page")
+	fs.Duration("max-duration", 0, "wall-clock budget for discovering pages, e.g. 20m")
+	fs.Int("concurrency", 0, "number of browser tabs crawling in parallel")
+	fs.Var(&listFlag{}, "seed", "extra start URL, absolute or relative to login_url (repeatable)")
+	fs.String("seed-file", "", "file with one seed URL per line")
+	fs.String("sitemap", "", `sitemap URL to seed from, or "true" for /sitemap.xml`)
+	fs.Bool("polite", false, "rate-limit navigations and back off on 429/503")
+	fs.Bool("pdf", false, "also save every page as PDF")
+	fs.Bool("assets", false, "download stylesheets, fonts, images and icons into assets/")
+	fs.Bool("record", false, "record a screencast of each page's interactions")
+	fs.Bool("responsive", false, "re-render each page at several widths and infer its breakpoints")
+	fs.Bool("viewports", false, "also capture each page at every device of the viewport matrix")
+	fs.Bool("zoom", false, "also capture selected pages at 200% and 400% browser zoom")
+	fs.Bool("scroll", false, "also screenshot long pages at each scroll step and stitch the full page")
+	fs.Bool("overlays", false, "also open each menu, dialog and drawer on its own and capture it")
+	fs.Bool("tables", false, "also sort, page through and filter each data table and capture every state")
+	fs.Bool("print", false, "also render report-style pages with print media and keep their print CSS")
+	fs.Bool("throttling", false, "also load each page over slow and offline network profiles to capture its loading states")
+	fs.Bool("locales", false, "re-render each page in every configured locale and report missing translations")
+	fs.String("locale", "", "language of report.html, the site, summary.pdf and the rebuild guide (en, de)")
+	fs.Bool("archive", false, "package the output directory into a timestamped zip when done")
+	fs.Bool("upload", false, "upload the output directory or archive to explorer.upload.bucket when done")
+	fs.Bool("sqlite", false, "also load the run into a SQLite database (needs a build with -tags sqlite)")
+	fs.Bool("lossless", false, "keep PNG originals next to JPEG or WebP screenshots")
+	fs.Float64("scale-factor", 1, "device pixel ratio of the screenshots, 2 for retina")
+	fs.Bool("incremental", false, "reuse the captures of pages unchanged since the previous run")
+	fs.String("previous", "", "run directory an incremental run compares against (default: the output directory)")
+	fs.Bool("bench", false, "time every phase of the run and write timings.json")
+	fs.String("mock", "", "api_inventory.json of a previous run whose fixtures answer API calls")
+	fs.Bool("read-only", false, "block every request after the login except GET, HEAD, OPTIONS and GraphQL queries")
+	fs.Var(&listFlag{}, "include", "regex a URL must match to be crawled (repeatable)")
+	fs.Var(&listFlag{}, "exclude", "regex that stops a URL from being crawled (repeatable)")
+	fs.String("schedule", "", `cron schedule of watch, e.g. "0 */6 * * *" or "@every 30m"`)
+	fs.Bool("once", false, "with watch, check once instead of on the schedule")
+	fs.String("metrics", "", "with watch, serve Prometheus metrics on this address, e.g. :9090")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	v := viper.New()
+	v.SetConfigFile(*configFile)
+	v.SetConfigType("yaml")
+
+	// Defaults mirror the values the explorer used before it was configurable
+	v.SetDefault("explorer.login_url", "https://app.agicap.com/de/app/cashflow/forecast")
+	v.SetDefault("explorer.browser.headless", true)
+	v.SetDefault("explorer.browser.window_size", "1920,1080")
+	v.SetDefault("explorer.browser.user_agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	v.SetDefault("explorer.browser.timeout_minutes", 10)
+	v.SetDefault("explorer.browser.max_restarts", 3)
+	v.SetDefault("explorer.browser.max_heap_mb", 1024)
+	v.SetDefault("explorer.browser.health_timeout", "10s")
+	v.SetDefault("explorer.exploration.max_pages", 20)
+	v.SetDefault("explorer.exploration.max_depth", 3)
+	v.SetDefault("explorer.exploration.report_reserve", "3m")
+	v.SetDefault("explorer.exploration.samples_per_route", 3)
+	v.SetDefault("explorer.exploration.delay_between_pages", 2)
+	v.SetDefault("explorer.exploration.concurrency", 1)
+	v.SetDefault("explorer.exploration.isolate_tabs", true)
+	v.SetDefault("explorer.scheduling.enabled", true)
+	v.SetDefault("explorer.scheduling.slow_threshold", "8s")
+	v.SetDefault("explorer.waits.network_quiet", "500ms")
+	v.SetDefault("explorer.waits.dom_quiet", "300ms")
+	v.SetDefault("explorer.waits.navigation.strategy", []string{"network_idle", "dom_stable"})
+	v.SetDefault("explorer.waits.navigation.timeout", "15s")
+	v.SetDefault("explorer.waits.login.strategy", []string{"network_idle", "dom_stable"})
+	v.SetDefault("explorer.waits.login.timeout", "20s")
+	v.SetDefault("explorer.waits.capture.strategy", []string{"dom_stable"})
+	v.SetDefault("explorer.waits.capture.timeout", "5s")
+	v.SetDefault("explorer.waits.interaction.strategy", []string{"network_idle", "dom_stable"})
+	v.SetDefault("explorer.waits.interaction.timeout", "6s")
+	v.SetDefault("explorer.waits.form.strategy", []string{"dom_stable"})
+	v.SetDefault("explorer.waits.form.timeout", "2s")
+	v.SetDefault("explorer.screenshots.format", "png")
+	v.SetDefault("explorer.screenshots.quality", 80)
+	v.SetDefault("explorer.screenshots.scale_factor", 1.0)
+	v.SetDefault("explorer.screenshots.thumbnails.enabled", true)
+	v.SetDefault("explorer.screenshots.thumbnails.width", 480)
+	v.SetDefault("explorer.capture.max_per_selector", 50)
+	v.SetDefault("explorer.capture.max_components", 1000)
+	v.SetDefault("explorer.capture.max_component_html", 1000)
+	v.SetDefault("explorer.capture.max_component_text", 200)
+	v.SetDefault("explorer.capture.evaluation_chunk", 100)
+	v.SetDefault("explorer.capture.component_screenshots", true)
+	v.SetDefault("explorer.capture.component_kinds", []string{"button", "btn", "card", "panel", "table", "grid"})
+	v.SetDefault("explorer.capture.max_component_screenshots", 40)
+	v.SetDefault("explorer.capture.mhtml", true)
+	v.SetDefault("explorer.capture.accessibility_tree", true)
+	v.SetDefault("explorer.capture.axe", true)
+	v.SetDefault("explorer.capture.axe_script", "")
+	v.SetDefault("explorer.capture.axe_tags", []string{"wcag2a", "wcag2aa", "wcag21a", "wcag21aa"})
+	v.SetDefault("explorer.capture.max_axe_nodes", 20)
+	v.SetDefault("explorer.capture.images", true)
+	v.SetDefault("explorer.capture.aria", true)
+	v.SetDefault("explorer.capture.aria_examples", 3)
+	v.SetDefault("explorer.capture.console", true)
+	v.SetDefault("explorer.capture.performance", true)
+	v.SetDefault("explorer.capture.stylesheets", true)
+	v.SetDefault("explorer.capture.contrast", true)
+	v.SetDefault("explorer.capture.regions", true)
+	v.SetDefault("explorer.capture.outline", true)
+	v.SetDefault("explorer.capture.charts", true)
+	v.SetDefault("explorer.capture.motion", true)
+	v.SetDefault("explorer.capture.max_motion_elements", 300)
+	v.SetDefault("explorer.capture.dom_tree", true)
+	v.SetDefault("explorer.capture.max_dom_nodes", 5000)
+	v.SetDefault("explorer.capture.strings", true)
+	v.SetDefault("explorer.capture.max_strings", 2000)
+	v.SetDefault("explorer.capture.string_languages", []string{"de", "en"})
+	v.SetDefault("explorer.capture.icons", true)
+	v.SetDefault("explorer.capture.max_icon_size", 64)
+	v.SetDefault("explorer.capture.states", true)
+	v.SetDefault("explorer.capture.state_kinds", []string{"button", "btn", "input", "select", "textarea", "menu", "tab"})
+	v.SetDefault("explorer.capture.state_list", []string{"hover", "focus", "active", "disabled", "error"})
+	v.SetDefault("explorer.capture.max_state_components", 15)
+	v.SetDefault("explorer.assets.max_bytes", 10<<20)
+	v.SetDefault("explorer.features.scenarios", "scenarios")
+	v.SetDefault("explorer.forms.profile", "de")
+	v.SetDefault("explorer.forms.seed", 1)
+	v.SetDefault("explorer.safety.enabled", true)
+	v.SetDefault("explorer.safety.deny_text", []string{
+		`(?i)\b(delete|remove|erase|discard)|löschen|entfern|verwerfen`,
+		`(?i)\b(pay|payment|transfer|wire)\b|\bzahl|bezahl|überweis`,
+		`(?i)\b(send|submit|approve|sign)\b|senden|einreichen|freigeben|unterschreiben`,
+		`(?i)\bcancel\b.*\b(subscription|plan|account)|unsubscribe|kündig|abbestellen`,
+		`(?i)\b(disconnect|revoke|deactivate)|trennen|widerruf|deaktivier`,
+		`(?i)\b(log ?out|sign ?out)\b|abmelden`,
+	})
+	v.SetDefault("explorer.safety.deny_selectors", []string{`[class*="danger"]`, `[class*="destructive"]`, `[class*="delete"]`, `[data-testid*="delete"]`, `a[href*="logout"]`})
+	v.SetDefault("explorer.safety.confirm_writes", true)
+	v.SetDefault("explorer.recording.quality", 70)
+	v.SetDefault("explorer.recording.gif_width", 640)
+	v.SetDefault("explorer.branding.enabled", true)
+	v.SetDefault("explorer.network.enabled", true)
+	v.SetDefault("explorer.network.resource_types", []string{"Document", "XHR", "Fetch"})
+	v.SetDefault("explorer.network.max_body_bytes", 256<<10)
+	v.SetDefault("explorer.network.fixtures", true)
+	v.SetDefault("explorer.network.fixtures_per_endpoint", 3)
+	v.SetDefault("explorer.network.websocket", true)
+	v.SetDefault("explorer.network.max_websocket_frames", 1000)
+	v.SetDefault("explorer.graphql.enabled", true)
+	v.SetDefault("explorer.graphql.introspect", true)
+	v.SetDefault("explorer.design_tokens.color_distance", 6)
+	v.SetDefault("explorer.design_tokens.max_colors", 24)
+	v.SetDefault("explorer.design_tokens.export", true)
+	v.SetDefault("explorer.design_tokens.tailwind", true)
+	v.SetDefault("explorer.design_tokens.figma", true)
+	v.SetDefault("explorer.scroll.step", 0.9)
+	v.SetDefault("explorer.scroll.max_steps", 20)
+	v.SetDefault("explorer.scroll.wait", "500ms")
+	v.SetDefault("explorer.overlays.max_triggers", 15)
+	v.SetDefault("explorer.overlays.wait", "500ms")
+	v.SetDefault("explorer.tables.max_tables", 3)
+	v.SetDefault("explorer.tables.max_sorts", 3)
+	v.SetDefault("explorer.tables.wait", "500ms")
+	v.SetDefault("explorer.zoom.levels", []int{200, 400})
+	v.SetDefault("explorer.responsive.widths", []int{375, 768, 1024, 1440})
+	v.SetDefault("explorer.responsive.height", 900)
+	v.SetDefault("explorer.viewports.components", true)
+	v.SetDefault("explorer.viewports.matrix", []map[string]interface{}{
+		{"name": "mobile", "width": 390, "height": 844, "scale": 3, "mobile": true},
+		{"name": "tablet", "width": 820, "height": 1180, "scale": 2, "mobile": true},
+		{"name": "desktop", "width": 1920, "height": 1080, "scale": 1},
+	})
+	v.SetDefault("explorer.print.pages", []string{`(?i)report|export|print|statement|invoice|bericht|auswertung|rechnung`})
+	v.SetDefault("explorer.print.pdf", true)
+	v.SetDefault("explorer.throttling.profiles", []string{"fast_3g", "slow_4g", "offline"})
+	v.SetDefault("explorer.throttling.frames", []string{"250ms", "500ms", "1s", "2s", "4s"})
+	v.SetDefault("explorer.throttling.timeout", "30s")
+	v.SetDefault("explorer.locales.list", []string{"de", "en"})
+	v.SetDefault("explorer.locales.switch", "prefix")
+	v.SetDefault("explorer.locales.key", "i18nextLng")
+	v.SetDefault("explorer.vision.enabled", true)
+	v.SetDefault("explorer.vision.charts", true)
+	v.SetDefault("explorer.vision.deficiencies", []string{"protanopia", "deuteranopia"})
+	v.SetDefault("explorer.dedupe.enabled", true)
+	v.SetDefault("explorer.dedupe.max_distance", 3)
+	v.SetDefault("explorer.redaction.keys", []string{"password", "passwd", "secret", "token", "authorization", "cookie", "session", "api[-_]?key", "iban", "^bic$", "account_?number"})
+	v.SetDefault("explorer.redaction.patterns", []string{`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`, `(?i)bearer\s+[A-Za-z0-9._~+/=-]+`, `\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){3,7}(?: ?[A-Z0-9]{1,3})?\b`})
+	v.SetDefault("explorer.politeness.requests_per_minute", 20)
+	v.SetDefault("explorer.politeness.jitter", 0.3)
+	v.SetDefault("explorer.politeness.backoff_initial", "10s")
+	v.SetDefault("explorer.politeness.backoff_max", "2m")
+	v.SetDefault("explorer.output.directory", "./agicap_ui_analysis")
+	v.SetDefault("explorer.output.locale", "en")
+	v.SetDefault("explorer.output.site", true)
+	v.SetDefault("explorer.output.summary.enabled", true)
+	v.SetDefault("explorer.output.summary.screenshots", 6)
+	v.SetDefault("explorer.archive.format", "zip")
+	v.SetDefault("explorer.upload.provider", "s3")
+	v.SetDefault("explorer.upload.prefix", "agicap-explorer")
+	v.SetDefault("explorer.upload.content", "directory")
+	v.SetDefault("explorer.upload.concurrency", 4)
+	v.SetDefault("explorer.watch.schedule", "0 * * * *")
+	v.SetDefault("explorer.watch.directory", "./watch")
+	v.SetDefault("explorer.watch.keep", 10)
+	v.SetDefault("explorer.watch.tolerance", 16)
+	v.SetDefault("explorer.watch.sensitivity", 0.1)
+	v.SetDefault("explorer.watch.thresholds.pixel", 1.0)
+	v.SetDefault("explorer.watch.thresholds.dom", 10)
+	v.SetDefault("explorer.watch.thresholds.pages", true)
+	v.SetDefault("explorer.storage.sqlite.path", "explorer.db")
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFound *os.PathError
+		if !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			v.Set(key, f.Value.(flag.Getter).Get())
+		}
+	})
+
+	return v, nil
+}
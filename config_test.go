@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigSeedFlags(t *testing.T) {
+	v, err := loadConfig([]string{
+		"-config", filepath.Join(t.TempDir(), "missing.yaml"),
+		"-seed", "/reports", "-seed", "https://app.example.com/settings",
+		"-seed-file", "seeds.txt",
+		"-sitemap", "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v.GetStringSlice("explorer.seeds.urls"), []string{"/reports", "https://app.example.com/settings"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("explorer.seeds.urls = %v, want %v", got, want)
+	}
+	if got := v.GetString("explorer.seeds.file"); got != "seeds.txt" {
+		t.Errorf("explorer.seeds.file = %q, want seeds.txt", got)
+	}
+	if got := v.GetString("explorer.seeds.sitemap"); got != "true" {
+		t.Errorf("explorer.seeds.sitemap = %q, want true", got)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// consoleMessage is a console call, uncaught exception or browser log entry.
+type consoleMessage struct {
+	Source string    `json:"source"`
+	Level  string    `json:"level"`
+	Text   string    `json:"text"`
+	URL    string    `json:"url,omitempty"`
+	Line   int64     `json:"line,omitempty"`
+	Column int64     `json:"column,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// isJSError reports whether the message is an uncaught exception or a
+// console.error call, as opposed to browser-side errors like failed requests.
+func (m consoleMessage) isJSError() bool {
+	return m.Source == "exception" || (m.Source == "console" && m.Level == "error")
+}
+
+// consoleRecorder buffers the console output of the current page.
+type consoleRecorder struct {
+	mu       sync.Mutex
+	enabled  bool
+	messages []consoleMessage
+}
+
+func newConsoleRecorder(enabled bool) *consoleRecorder {
+	return &consoleRecorder{enabled: enabled}
+}
+
+// Listen collects Runtime console calls and exceptions plus Log domain
+// entries (interventions, violations, network errors).
+func (c *consoleRecorder) Listen(ctx context.Context) {
+	if !c.enabled {
+		return
+	}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		var msg consoleMessage
+		switch ev := ev.(type) {
+		case *runtime.EventConsoleAPICalled:
+			args := make([]string, 0, len(ev.Args))
+			for _, arg := range ev.Args {
+				args = append(args, remoteObjectText(arg))
+			}
+			msg = consoleMessage{Source: "console", Level: string(ev.Type), Text: strings.Join(args, " ")}
+			if ev.StackTrace != nil && len(ev.StackTrace.CallFrames) > 0 {
+				frame := ev.StackTrace.CallFrames[0]
+				msg.URL, msg.Line, msg.Column = frame.URL, frame.LineNumber+1, frame.ColumnNumber+1
+			}
+		case *runtime.EventExceptionThrown:
+			d := ev.ExceptionDetails
+			msg = consoleMessage{Source: "exception", Level: "error", Text: d.Text, URL: d.URL, Line: d.LineNumber + 1, Column: d.ColumnNumber + 1}
+			if d.Exception != nil && d.Exception.Description != "" {
+				msg.Text = d.Exception.Description
+			}
+		case *cdplog.EventEntryAdded:
+			e := ev.Entry
+			msg = consoleMessage{Source: string(e.Source), Level: string(e.Level), Text: e.Text, URL: e.URL, Line: e.LineNumber}
+		default:
+			return
+		}
+		msg.Time = time.Now()
+
+		c.mu.Lock()
+		c.messages = append(c.messages, msg)
+		c.mu.Unlock()
+	})
+}
+
+// Reset clears the buffer before the next page is loaded.
+func (c *consoleRecorder) Reset() {
+	c.mu.Lock()
+	c.messages = nil
+	c.mu.Unlock()
+}
+
+// Messages returns a copy of the messages recorded since the last Reset.
+func (c *consoleRecorder) Messages() []consoleMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]consoleMessage(nil), c.messages...)
+}
+
+// remoteObjectText renders a console argument the way DevTools prints it.
+func remoteObjectText(obj *runtime.RemoteObject) string {
+	if obj == nil {
+		return ""
+	}
+	if len(obj.Value) > 0 {
+		var s string
+		if json.Unmarshal(obj.Value, &s) == nil {
+			return s
+		}
+		return string(obj.Value)
+	}
+	if obj.UnserializableValue != "" {
+		return string(obj.UnserializableValue)
+	}
+	if obj.Description != "" {
+		return obj.Description
+	}
+	return string(obj.Type)
+}
+
+// captureConsole writes the current page's console output to
+// console/<page>.json and returns the path, the number of JavaScript errors
+// and the first error message.
+func (e *AgicapExplorer) captureConsole(pageName string) (string, int, string) {
+	if !e.console.enabled {
+		return "", 0, ""
+	}
+	messages := e.console.Messages()
+
+	jsErrors, first := 0, ""
+	for _, m := range messages {
+		if m.isJSError() {
+			if jsErrors == 0 {
+				first = m.Text
+			}
+			jsErrors++
+		}
+	}
+
+	data, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return "", jsErrors, first
+	}
+	path, err := e.writeArtifact("console", sanitize(pageName)+".json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write console log for %s: %v", pageName, err)
+		return "", jsErrors, first
+	}
+	if jsErrors > 0 {
+		e.log("🐞 %d JavaScript errors on %s: %s", jsErrors, pageName, truncate(first, 120))
+	}
+	return path, jsErrors, first
+}
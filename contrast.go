@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// collectTextColors lists the distinct foreground/background pairs used by
+// visible text. Backgrounds are resolved by compositing translucent ancestor
+// backgrounds down to an opaque one (white at the root); text over
+// background images is reported separately since its contrast can't be
+// computed from styles.
+const collectTextColors = `
+(function() {
+	const parse = (c) => {
+		const m = c && c.match(/rgba?\(([\d.]+),\s*([\d.]+),\s*([\d.]+)(?:,\s*([\d.]+))?\)/);
+		return m ? [+m[1], +m[2], +m[3], m[4] === undefined ? 1 : +m[4]] : null;
+	};
+	const over = (top, bottom) => [0, 1, 2].map(i => top[i] * top[3] + bottom[i] * (1 - top[3])).concat(1);
+	const fmt = (c) => 'rgb(' + c.slice(0, 3).map(Math.round).join(', ') + ')';
+	const describe = (el) => el.tagName.toLowerCase() + (el.id ? '#' + el.id : '') +
+		(typeof el.className === 'string' && el.className.trim() ? '.' + el.className.trim().split(/\s+/).slice(0, 2).join('.') : '');
+
+	const background = (el) => {
+		const layers = [];
+		for (let node = el; node && node.nodeType === 1; node = node.parentElement) {
+			const style = getComputedStyle(node);
+			if (style.backgroundImage && style.backgroundImage !== 'none') return null;
+			const bg = parse(style.backgroundColor);
+			if (bg && bg[3] > 0) {
+				layers.push(bg);
+				if (bg[3] >= 1) break;
+			}
+		}
+		let result = [255, 255, 255, 1];
+		for (let i = layers.length - 1; i >= 0; i--) result = over(layers[i], result);
+		return result;
+	};
+
+	const pairs = {};
+	const walker = document.createTreeWalker(document.body, NodeFilter.SHOW_TEXT);
+	const seen = new Set();
+	let node;
+	while ((node = walker.nextNode())) {
+		const el = node.parentElement;
+		if (!el || seen.has(el) || !node.textContent.trim()) continue;
+		seen.add(el);
+		const style = getComputedStyle(el);
+		const rect = el.getBoundingClientRect();
+		if (style.visibility === 'hidden' || style.display === 'none' || +style.opacity === 0 || rect.width === 0 || rect.height === 0) continue;
+
+		const fg = parse(style.color);
+		if (!fg || fg[3] === 0) continue;
+		const bg = background(el);
+		const size = parseFloat(style.fontSize);
+		const weight = parseInt(style.fontWeight, 10) || 400;
+		const large = size >= 24 || (size >= 18.66 && weight >= 700);
+		const key = bg ? fmt(over(fg, bg)) + '|' + fmt(bg) + '|' + large : 'image|' + fmt(fg);
+		if (!pairs[key]) {
+			pairs[key] = {
+				foreground: bg ? fmt(over(fg, bg)) : fmt(fg),
+				background: bg ? fmt(bg) : '',
+				overImage: !bg,
+				large: large,
+				count: 0,
+				selector: describe(el),
+				text: node.textContent.trim().substring(0, 60),
+			};
+		}
+		pairs[key].count++;
+	}
+	return Object.values(pairs);
+})()
+`
+
+// contrastPair is a text color on its effective background with its WCAG
+// contrast ratio.
+type contrastPair struct {
+	Foreground string   `json:"foreground"`
+	Background string   `json:"background"`
+	OverImage  bool     `json:"overImage,omitempty"`
+	Large      bool     `json:"large"`
+	Count      int      `json:"count"`
+	Selector   string   `json:"selector"`
+	Text       string   `json:"text"`
+	Ratio      float64  `json:"ratio"`
+	AA         bool     `json:"aa"`
+	AAA        bool     `json:"aaa"`
+	Pages      []string `json:"pages,omitempty"`
+}
+
+// captureContrast checks the contrast of every text color pair on the page
+// and writes components/<page>_contrast.json.
+func (e *AgicapExplorer) captureContrast(pageName string) {
+	var pairs []contrastPair
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(collectTextColors, &pairs)); err != nil {
+		e.log("⚠️ Contrast check failed for %s: %v", pageName, err)
+		return
+	}
+
+	failures := 0
+	for i := range pairs {
+		p := &pairs[i]
+		if p.OverImage {
+			continue
+		}
+		p.Ratio, p.AA, p.AAA = wcagContrast(p.Foreground, p.Background, p.Large)
+		if !p.AA {
+			failures++
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Ratio < pairs[j].Ratio })
+
+	data, err := json.MarshalIndent(pairs, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("components", sanitize(pageName)+"_contrast.json", data); err != nil {
+		e.log("⚠️ Failed to write contrast report for %s: %v", pageName, err)
+		return
+	}
+	if failures > 0 {
+		e.log("🌓 %d text color pairs below WCAG AA on %s", failures, pageName)
+	}
+}
+
+// wcagContrast returns the contrast ratio of two opaque rgb() colors and
+// whether it meets AA and AAA for normal or large text.
+func wcagContrast(foreground, background string, large bool) (ratio float64, aa, aaa bool) {
+	_, fg, ok1 := parseCSSColor(foreground)
+	_, bg, ok2 := parseCSSColor(background)
+	if !ok1 || !ok2 {
+		return 0, false, false
+	}
+	l1, l2 := relativeLuminance(fg), relativeLuminance(bg)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	ratio = math.Round((l1+0.05)/(l2+0.05)*100) / 100
+	if large {
+		return ratio, ratio >= 3, ratio >= 4.5
+	}
+	return ratio, ratio >= 4.5, ratio >= 7
+}
+
+// relativeLuminance implements the WCAG 2 definition for sRGB colors.
+func relativeLuminance(rgb [3]float64) float64 {
+	var l [3]float64
+	for i, c := range rgb {
+		c /= 255
+		if c <= 0.03928 {
+			l[i] = c / 12.92
+		} else {
+			l[i] = math.Pow((c+0.055)/1.055, 2.4)
+		}
+	}
+	return 0.2126*l[0] + 0.7152*l[1] + 0.0722*l[2]
+}
+
+// contrastSection aggregates components/*_contrast.json into the
+// accessibility part of design_system.json: every failing pair with the
+// pages it appears on, so the rebuild can pick compliant colors instead of
+// copying the originals.
+func (e *AgicapExplorer) contrastSection() orderedTokens {
+	files, _ := filepath.Glob(filepath.Join(e.outputDir, "components", "*_contrast.json"))
+	byPair := make(map[string]*contrastPair)
+	checked, overImage := 0, 0
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var pairs []contrastPair
+		if err := json.Unmarshal(data, &pairs); err != nil {
+			continue
+		}
+		page := strings.TrimSuffix(filepath.Base(file), "_contrast.json")
+		for _, p := range pairs {
+			if p.OverImage {
+				overImage += p.Count
+				continue
+			}
+			checked += p.Count
+			p.Ratio, p.AA, p.AAA = wcagContrast(p.Foreground, p.Background, p.Large)
+			if p.AA {
+				continue
+			}
+			key := p.Foreground + "|" + p.Background
+			if p.Large {
+				key += "|large"
+			}
+			agg, ok := byPair[key]
+			if !ok {
+				first := p
+				first.Count = 0
+				agg = &first
+				byPair[key] = agg
+			}
+			agg.Count += p.Count
+			agg.Pages = appendUnique(agg.Pages, page)
+		}
+	}
+
+	failures := make([]*contrastPair, 0, len(byPair))
+	failing := 0
+	for _, p := range byPair {
+		failures = append(failures, p)
+		failing += p.Count
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Ratio != failures[j].Ratio {
+			return failures[i].Ratio < failures[j].Ratio
+		}
+		return failures[i].Count > failures[j].Count
+	})
+
+	out := make([]orderedTokens, 0, len(failures))
+	for _, p := range failures {
+		fgHex, _, _ := parseCSSColor(p.Foreground)
+		bgHex, _, _ := parseCSSColor(p.Background)
+		required := 4.5
+		if p.Large {
+			required = 3
+		}
+		out = append(out, orderedTokens{
+			{"foreground", fgHex},
+			{"background", bgHex},
+			{"ratio", p.Ratio},
+			{"required", required},
+			{"largeText", p.Large},
+			{"elements", p.Count},
+			{"example", p.Selector + ": " + p.Text},
+			{"pages", p.Pages},
+		})
+	}
+
+	return orderedTokens{
+		{"textElementsChecked", checked},
+		{"textOverImages", overImage},
+		{"failingElements", failing},
+		{"contrastFailures", out},
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"container/heap"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// crawlTarget is a page waiting in the crawl frontier.
+type crawlTarget struct {
+	URL       string
+	Canonical string
+	Text      string
+	Depth     int
+	Section   string
+	Priority  int
+	Slow      time.Duration // capture time in earlier runs, if it was slow
+	order     int
+}
+
+// frontier is the queue of pages to visit. Targets are served by section
+// priority first, then pages that were slow to capture in earlier runs
+// (slowest first), then breadth-first (shallowest depth, then insertion
+// order), and are deduplicated by canonical URL.
+type frontier struct {
+	queue      targetHeap
+	seen       map[string]bool
+	priorities map[string]int
+	slow       map[string]time.Duration // by canonical URL, see crawlProfile
+	pushed     int
+}
+
+func newFrontier(priorities map[string]int) *frontier {
+	return &frontier{seen: make(map[string]bool), priorities: priorities}
+}
+
+// Push enqueues a target unless its canonical URL has already been queued.
+func (f *frontier) Push(t crawlTarget) bool {
+	if f.seen[t.Canonical] {
+		return false
+	}
+	f.seen[t.Canonical] = true
+	t.Priority = f.priorities[t.Section]
+	t.Slow = f.slow[t.Canonical]
+	t.order = f.pushed
+	f.pushed++
+	heap.Push(&f.queue, t)
+	return true
+}
+
+// Requeue puts back a target that was popped but not captured. It keeps
+// its place in the ordering and skips the deduplication, which would
+// drop it since its canonical URL was already queued.
+func (f *frontier) Requeue(t crawlTarget) {
+	heap.Push(&f.queue, t)
+}
+
+// Pop removes and returns the next target.
+func (f *frontier) Pop() (crawlTarget, bool) {
+	if len(f.queue) == 0 {
+		return crawlTarget{}, false
+	}
+	return heap.Pop(&f.queue).(crawlTarget), true
+}
+
+func (f *frontier) Len() int {
+	return len(f.queue)
+}
+
+// targetHeap implements heap.Interface for the frontier ordering.
+type targetHeap []crawlTarget
+
+func (h targetHeap) Len() int { return len(h) }
+
+func (h targetHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	if h[i].Slow != h[j].Slow {
+		return h[i].Slow > h[j].Slow
+	}
+	if h[i].Depth != h[j].Depth {
+		return h[i].Depth < h[j].Depth
+	}
+	return h[i].order < h[j].order
+}
+
+func (h targetHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *targetHeap) Push(x interface{}) { *h = append(*h, x.(crawlTarget)) }
+
+func (h *targetHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return t
+}
+
+// sectionPriorities reads the section -> priority map from configuration.
+func sectionPriorities(v *viper.Viper) map[string]int {
+	priorities := make(map[string]int)
+	for section, p := range v.GetStringMap("explorer.exploration.priorities") {
+		priorities[strings.ToLower(section)] = cast.ToInt(p)
+	}
+	return priorities
+}
+
+// sectionOf returns the top-level area of the app a URL belongs to, skipping
+// locale prefixes and the "app" segment (e.g. /de/app/cashflow/forecast -> cashflow).
+func sectionOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "root"
+	}
+	for _, seg := range strings.Split(u.Path, "/") {
+		if seg == "" || seg == "app" || len(seg) == 2 {
+			continue
+		}
+		return strings.ToLower(seg)
+	}
+	return "root"
+}
+
+// harvestLinks collects navigation links from the current page.
+func (e *AgicapExplorer) harvestLinks() []map[string]interface{} {
+	var navItems []map[string]interface{}
+	chromedp.Run(e.ctx,
+		chromedp.Evaluate(`
+		(function() {
+			const items = [];
+			const selectors = [
+				'nav a',
+				'[role="navigation"] a',
+				'.sidebar a',
+				'.menu a',
+				'[class*="Nav"] a',
+				'[class*="Menu"] a',
+				'[class*="Sidebar"] a',
+				'header a',
+				'main a[href]',
+				'.tab', '[role="tab"]',
+				'.dropdown-item', '.menu-item'
+			];
+
+			selectors.forEach(sel => {
+				document.querySelectorAll(sel).forEach(el => {
+					const text = el.textContent.trim();
+					const href = el.href || el.getAttribute('data-href') || el.getAttribute('onclick');
+					if (text && href && !href.includes('javascript:') && !href.includes('#') && text.length < 50) {
+						items.push({
+							text: text,
+							href: href,
+							selector: el.className || el.id,
+							type: el.tagName.toLowerCase()
+						});
+					}
+				});
+			});
+
+			// Remove duplicates
+			const unique = [];
+			const seen = new Set();
+			items.forEach(item => {
+				if (!seen.has(item.href)) {
+					seen.add(item.href);
+					unique.push(item);
+				}
+			});
+
+			return unique;
+		})()
+		`, &navItems),
+	)
+	return navItems
+}
+
+// enqueueLinks pushes the links harvested from the current page into the
+// frontier at the given depth and returns how many were new.
+func (e *AgicapExplorer) enqueueLinks(c *crawlState, depth int) int {
+	links := e.harvestLinks()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	added := 0
+	for _, item := range links {
+		text, _ := item["text"].(string)
+		href, _ := item["href"].(string)
+		if href == "" {
+			continue
+		}
+		canonical := e.canonical.Canonical(href)
+		if e.shared().visitedURLs[canonical] {
+			continue
+		}
+		if ok, _ := e.scope.Allows(href); !ok {
+			continue
+		}
+		if c.queue.Push(crawlTarget{URL: href, Canonical: canonical, Text: text, Depth: depth, Section: sectionOf(href)}) {
+			added++
+		}
+	}
+	c.wake.Broadcast()
+	return added
+}
+
+// pagesBySection groups captured pages by section, returning the section
+// names ordered by configured priority and then alphabetically.
+func (e *AgicapExplorer) pagesBySection() ([]string, map[string][]NavigationItem) {
+	priorities := sectionPriorities(e.config)
+	bySection := make(map[string][]NavigationItem)
+	var sections []string
+	for _, item := range e.navigationMap {
+		section := item.Section
+		if section == "" {
+			section = "root"
+		}
+		if _, ok := bySection[section]; !ok {
+			sections = append(sections, section)
+		}
+		bySection[section] = append(bySection[section], item)
+	}
+
+	sort.Slice(sections, func(i, j int) bool {
+		pi, pj := priorities[sections[i]], priorities[sections[j]]
+		if pi != pj {
+			return pi > pj
+		}
+		return sections[i] < sections[j]
+	})
+	return sections, bySection
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a five-field cron expression (minute, hour, day of month,
+// month, day of week) with *, lists, ranges and steps, or one of @hourly,
+// @daily, @weekly and @every <duration>.
+type cronSchedule struct {
+	fields  [5][]bool
+	anyDay  bool // day of month or day of week is *, so both have to match
+	every   time.Duration
+	literal string
+}
+
+// cronRanges are the bounds of the five fields; 7 is Sunday as well as 0.
+var cronRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+var cronAliases = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+}
+
+func parseCron(spec string) (*cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	c := &cronSchedule{literal: spec}
+	if rest := strings.TrimPrefix(spec, "@every "); rest != spec {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || every < time.Minute {
+			return nil, fmt.Errorf("schedule %q: @every needs a duration of at least 1m", spec)
+		}
+		c.every = every
+		return c, nil
+	}
+	if alias, ok := cronAliases[spec]; ok {
+		spec = alias
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: want minute hour day month weekday", c.literal)
+	}
+	for i, field := range fields {
+		lo, hi := cronRanges[i][0], cronRanges[i][1]
+		c.fields[i] = make([]bool, hi+1)
+		for _, part := range strings.Split(field, ",") {
+			if err := c.setRange(i, part, lo, hi); err != nil {
+				return nil, fmt.Errorf("schedule %q: %w", c.literal, err)
+			}
+		}
+	}
+	c.fields[4][0] = c.fields[4][0] || c.fields[4][7]
+	c.anyDay = strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// setRange marks the values of a list item: *, n, a-b, each optionally
+// followed by /step.
+func (c *cronSchedule) setRange(field int, part string, lo, hi int) error {
+	step := 1
+	if i := strings.Index(part, "/"); i >= 0 {
+		n, err := strconv.Atoi(part[i+1:])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid step in %q", part)
+		}
+		step, part = n, part[:i]
+	}
+	from, to := lo, hi
+	if part != "*" {
+		bounds := strings.SplitN(part, "-", 2)
+		var err error
+		if from, err = strconv.Atoi(bounds[0]); err != nil {
+			return fmt.Errorf("invalid value %q", part)
+		}
+		to = from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return fmt.Errorf("invalid value %q", part)
+			}
+		} else if step > 1 {
+			to = hi
+		}
+	}
+	if from < lo || to > hi || from > to {
+		return fmt.Errorf("%q is out of range %d-%d", part, lo, hi)
+	}
+	for v := from; v <= to; v += step {
+		c.fields[field][v] = true
+	}
+	return nil
+}
+
+// Next returns the first time after t the schedule fires, at a whole
+// minute in t's location.
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Add(c.every)
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Five years cover every valid expression, including February 29
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case !c.fields[3][int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.fields[1][t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.fields[0][t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := c.fields[2][t.Day()], c.fields[4][int(t.Weekday())]
+	if c.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (c *cronSchedule) String() string {
+	return c.literal
+}
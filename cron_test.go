@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		spec       string
+		from, want time.Time
+	}{
+		{"*/15 * * * *", at(1, 10, 7), at(1, 10, 15)},
+		{"*/15 * * * *", at(1, 10, 45), at(1, 11, 0)},
+		{"1-5/2 * * * *", at(1, 10, 1), at(1, 10, 3)},
+		{"1-5/2 * * * *", at(1, 10, 5), at(1, 11, 1)},
+		{"0 9 * * 1-5/2", at(1, 9, 0), at(3, 9, 0)},
+		{"0 9 * * 1-5/2", at(5, 9, 0), at(8, 9, 0)},
+		{"0 0 * * 7", at(1, 0, 0), at(7, 0, 0)},
+		{"0 0 * * 0", at(1, 0, 0), at(7, 0, 0)},
+		// The 13th or a Friday, since neither day field is *
+		{"0 0 13 * 5", at(1, 0, 0), at(5, 0, 0)},
+		{"0 0 13 * 5", at(12, 0, 0), at(13, 0, 0)},
+		{"0 0 13 * 5", at(13, 0, 0), at(19, 0, 0)},
+		{"0 0 31 2 *", at(1, 0, 0), time.Time{}},
+		{"@every 90m", at(1, 10, 7), at(1, 11, 37)},
+	}
+	for _, tt := range tests {
+		c, err := parseCron(tt.spec)
+		if err != nil {
+			t.Errorf("parseCron(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := c.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q after %s = %s, want %s", tt.spec, tt.from.Format(time.RFC3339), got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestParseCronRejects(t *testing.T) {
+	for _, spec := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "0 0 0 * *", "@every 30s"} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("parseCron(%q) accepted an invalid schedule", spec)
+		}
+	}
+}
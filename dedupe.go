@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+
+	"github.com/chromedp/chromedp"
+)
+
+// pageFingerprint links a structural hash to its entry in navigationMap.
+type pageFingerprint struct {
+	hash  uint64
+	index int
+}
+
+// domFeatures extracts the page skeleton (tag paths with roles) while ignoring
+// text and hashed class names, so differently populated pages of the same
+// screen produce the same features.
+const domFeatures = `
+(function() {
+	const features = [];
+	const root = document.querySelector('main, [role="main"]') || document.body;
+	const walk = (el, path, depth) => {
+		if (depth > 12 || features.length > 5000) return;
+		const tag = el.tagName.toLowerCase();
+		if (tag === 'script' || tag === 'style' || tag === 'noscript') return;
+		const role = el.getAttribute('role');
+		const node = role ? tag + '[' + role + ']' : tag;
+		const current = path + '>' + node;
+		features.push(current);
+		Array.from(el.children).forEach(child => walk(child, current, depth + 1));
+	};
+	if (root) walk(root, '', 0);
+	return features;
+})()
+`
+
+// domHash returns a 64-bit simhash of the current page structure.
+func (e *AgicapExplorer) domHash() (uint64, error) {
+	var features []string
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(domFeatures, &features)); err != nil {
+		return 0, err
+	}
+	return simhash(features), nil
+}
+
+// simhash combines feature hashes so similar feature sets yield hashes with a
+// small Hamming distance.
+func simhash(features []string) uint64 {
+	var weights [64]int
+	for _, f := range features {
+		h := fnv.New64a()
+		h.Write([]byte(f))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, w := range weights {
+		if w > 0 {
+			hash |= 1 << uint(bit)
+		}
+	}
+	return hash
+}
+
+// duplicateOf returns the navigationMap index of a captured page whose
+// structure is within the configured distance of hash, or -1.
+func (e *AgicapExplorer) duplicateOf(hash uint64) int {
+	maxDistance := e.config.GetInt("explorer.dedupe.max_distance")
+	for _, fp := range e.fingerprints {
+		if bits.OnesCount64(fp.hash^hash) <= maxDistance {
+			return fp.index
+		}
+	}
+	return -1
+}
+
+// rememberFingerprint stores hash for the page this tab captured last.
+func (e *AgicapExplorer) rememberFingerprint(hash uint64) {
+	if e.lastCapture < 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.shared()
+	s.navigationMap[e.lastCapture].ContentHash = fmt.Sprintf("%016x", hash)
+	s.streamItem(e.lastCapture)
+	s.fingerprints = append(s.fingerprints, pageFingerprint{hash: hash, index: e.lastCapture})
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	cssColorPattern  = regexp.MustCompile(`rgba?\(\s*([\d.]+),\s*([\d.]+),\s*([\d.]+)(?:,\s*([\d.]+))?\s*\)`)
+	cssLengthPattern = regexp.MustCompile(`(-?[\d.]+)px`)
+)
+
+// orderedTokens is a JSON object that keeps its keys in insertion order, so
+// scales read from smallest to largest.
+type orderedTokens []tokenEntry
+
+type tokenEntry struct {
+	Key   string
+	Value interface{}
+}
+
+func (o orderedTokens) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.Key)
+		value, err := json.Marshal(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// tokenCounts counts how often each raw CSS value was used.
+type tokenCounts map[string]int
+
+// byCount returns the values ordered by usage, most used first.
+func (c tokenCounts) byCount() []string {
+	values := make([]string, 0, len(c))
+	for v := range c {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if c[values[i]] != c[values[j]] {
+			return c[values[i]] > c[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+// designAggregate collects raw style values from every component analysis.
+type designAggregate struct {
+	pages      int
+	components int
+	colors     *colorPalette
+	fontSizes  tokenCounts
+	families   tokenCounts
+	weights    tokenCounts
+	spacing    tokenCounts
+	radii      tokenCounts
+	shadows    tokenCounts
+	variables  map[string]string
+}
+
+// loadDesignAggregate reads components/*_analysis.json from the output
+// directory.
+func (e *AgicapExplorer) loadDesignAggregate() *designAggregate {
+	agg := &designAggregate{
+		colors:    newColorPalette(),
+		fontSizes: tokenCounts{},
+		families:  tokenCounts{},
+		weights:   tokenCounts{},
+		spacing:   tokenCounts{},
+		radii:     tokenCounts{},
+		shadows:   tokenCounts{},
+		variables: make(map[string]string),
+	}
+
+	files, _ := filepath.Glob(filepath.Join(e.outputDir, "components", "*_analysis.json"))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var analysis pageAnalysis
+		if err := json.Unmarshal(data, &analysis); err != nil {
+			continue
+		}
+		agg.pages++
+		for name, value := range analysis.CustomProperties {
+			if value = strings.TrimSpace(value); value != "" {
+				agg.variables[name] = value
+			}
+		}
+		for _, c := range analysis.Components {
+			agg.add(c)
+		}
+	}
+	return agg
+}
+
+// add records the style values of one component.
+func (agg *designAggregate) add(c componentInfo) {
+	css := c.CSS
+	agg.components++
+	interactive := isInteractiveComponent(c.Type)
+	agg.colors.Add(css["color"], "text", interactive)
+	agg.colors.Add(css["backgroundColor"], "background", interactive)
+	if border := css["border"]; border != "" && !strings.HasPrefix(border, "0px") {
+		agg.colors.Add(cssColorPattern.FindString(border), "border", interactive)
+	}
+
+	if size := css["fontSize"]; size != "" {
+		agg.fontSizes[size]++
+	}
+	if family := css["fontFamily"]; family != "" {
+		agg.families[family]++
+	}
+	if weight := css["fontWeight"]; weight != "" {
+		agg.weights[weight]++
+	}
+	for _, prop := range []string{"padding", "margin"} {
+		for _, m := range cssLengthPattern.FindAllStringSubmatch(css[prop], -1) {
+			if v, _ := strconv.ParseFloat(m[1], 64); v > 0 {
+				agg.spacing[formatPx(v)]++
+			}
+		}
+	}
+	if radius := strings.Fields(css["borderRadius"]); len(radius) > 0 && radius[0] != "0px" {
+		agg.radii[radius[0]]++
+	}
+	if shadow := css["boxShadow"]; shadow != "" && shadow != "none" {
+		agg.shadows[shadow]++
+	}
+}
+
+// fontSizeScale names the used font sizes around the most common body size
+// (base), e.g. xs, sm, base, lg, xl, 2xl.
+func fontSizeScale(counts tokenCounts) orderedTokens {
+	sizes := pxValues(counts, 2)
+	if len(sizes) == 0 {
+		return orderedTokens{}
+	}
+	base, best := 0, -1
+	for i, s := range sizes {
+		if n := counts[formatPx(s)]; s >= 12 && s <= 18 && n > best {
+			base, best = i, n
+		}
+	}
+
+	scale := orderedTokens{}
+	for i, s := range sizes {
+		var name string
+		switch d := i - base; {
+		case d == 0:
+			name = "base"
+		case d == -1:
+			name = "sm"
+		case d == -2:
+			name = "xs"
+		case d < -2:
+			name = fmt.Sprintf("%dxs", -d-1)
+		case d == 1:
+			name = "lg"
+		case d == 2:
+			name = "xl"
+		default:
+			name = fmt.Sprintf("%dxl", d-1)
+		}
+		scale = append(scale, tokenEntry{name, formatPx(s)})
+	}
+	return scale
+}
+
+// spacingScale detects the base unit (8, 4 or 2px) the spacing values are
+// multiples of and returns the used multiples keyed by step.
+func spacingScale(counts tokenCounts) (string, orderedTokens) {
+	values := pxValues(counts, 2)
+	unit := 1.0
+	for _, candidate := range []float64{8, 4, 2} {
+		total, divisible := 0, 0
+		for _, v := range values {
+			n := counts[formatPx(v)]
+			total += n
+			if math.Mod(v, candidate) == 0 {
+				divisible += n
+			}
+		}
+		if total > 0 && float64(divisible)/float64(total) >= 0.7 {
+			unit = candidate
+			break
+		}
+	}
+
+	scale := orderedTokens{{"0", "0px"}}
+	for _, v := range values {
+		if math.Mod(v, unit) == 0 {
+			scale = append(scale, tokenEntry{strconv.FormatFloat(v/unit, 'f', -1, 64), formatPx(v)})
+		}
+	}
+	return formatPx(unit), scale
+}
+
+// radiusScale names the border radii from small to large; pill shapes
+// (9999px, 50%) become "full".
+func radiusScale(counts tokenCounts) orderedTokens {
+	names := []string{"sm", "base", "md", "lg", "xl", "2xl", "3xl"}
+	scale := orderedTokens{{"none", "0px"}}
+	full := ""
+	var px []float64
+	for _, v := range counts.byCount() {
+		if strings.HasSuffix(v, "%") {
+			full = "50%"
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSuffix(v, "px"), 64)
+		if err != nil {
+			continue
+		}
+		if f >= 999 {
+			full = "9999px"
+			continue
+		}
+		if len(px) < len(names) {
+			px = append(px, f)
+		}
+	}
+	sort.Float64s(px)
+	for i, f := range px {
+		scale = append(scale, tokenEntry{names[i], formatPx(f)})
+	}
+	if full != "" {
+		scale = append(scale, tokenEntry{"full", full})
+	}
+	return scale
+}
+
+// shadowScale keeps the most used shadows ordered by blur radius.
+func shadowScale(counts tokenCounts) orderedTokens {
+	names := []string{"sm", "base", "md", "lg", "xl", "2xl"}
+	shadows := counts.byCount()
+	if len(shadows) > len(names) {
+		shadows = shadows[:len(names)]
+	}
+	blur := func(shadow string) float64 {
+		max := 0.0
+		for _, layer := range strings.Split(cssColorPattern.ReplaceAllString(shadow, ""), ",") {
+			if m := cssLengthPattern.FindAllStringSubmatch(layer, -1); len(m) >= 3 {
+				if v, _ := strconv.ParseFloat(m[2][1], 64); v > max {
+					max = v
+				}
+			}
+		}
+		return max
+	}
+	sort.SliceStable(shadows, func(i, j int) bool { return blur(shadows[i]) < blur(shadows[j]) })
+
+	scale := orderedTokens{}
+	for i, s := range shadows {
+		scale = append(scale, tokenEntry{names[i], s})
+	}
+	return scale
+}
+
+// fontWeightNames maps numeric weights to their conventional names.
+var fontWeightNames = map[string]string{
+	"100": "thin", "200": "extralight", "300": "light", "400": "normal", "500": "medium",
+	"600": "semibold", "700": "bold", "800": "extrabold", "900": "black",
+}
+
+// designSystem aggregates the component analyses of every captured page
+// into the design_system.json token tree.
+func (e *AgicapExplorer) designSystem() orderedTokens {
+	agg := e.loadDesignAggregate()
+	clusters := agg.colors.Cluster(e.config.GetFloat64("explorer.design_tokens.color_distance"), e.config.GetInt("explorer.design_tokens.max_colors"))
+	roles := colorRoles(clusters)
+
+	families := agg.families.byCount()
+	fontFamily := orderedTokens{}
+	if len(families) > 0 {
+		fontFamily = append(fontFamily, tokenEntry{"primary", families[0]})
+	}
+	for _, f := range families {
+		lower := strings.ToLower(f)
+		if strings.Contains(lower, "mono") || strings.Contains(lower, "courier") || strings.Contains(lower, "code") {
+			fontFamily = append(fontFamily, tokenEntry{"mono", f})
+			break
+		}
+	}
+
+	weights := pxValues(agg.weights, 1)
+	fontWeight := orderedTokens{}
+	for _, w := range weights {
+		key := strconv.FormatFloat(w, 'f', -1, 64)
+		name := fontWeightNames[key]
+		if name == "" {
+			name = key
+		}
+		fontWeight = append(fontWeight, tokenEntry{name, key})
+	}
+
+	unit, spacing := spacingScale(agg.spacing)
+
+	variables := orderedTokens{}
+	for _, name := range sortedKeys(agg.variables) {
+		variables = append(variables, tokenEntry{name, agg.variables[name]})
+	}
+
+	return orderedTokens{
+		{"meta", orderedTokens{
+			{"generated", time.Now().Format(time.RFC3339)},
+			{"pages", agg.pages},
+			{"components", agg.components},
+		}},
+		{"colors", roles},
+		{"palette", clusters},
+		{"typography", orderedTokens{
+			{"fontFamily", fontFamily},
+			{"fontSize", fontSizeScale(agg.fontSizes)},
+			{"fontWeight", fontWeight},
+		}},
+		{"spacingUnit", unit},
+		{"spacing", spacing},
+		{"borderRadius", radiusScale(agg.radii)},
+		{"shadows", shadowScale(agg.shadows)},
+		{"cssVariables", variables},
+		{"accessibility", e.contrastSection()},
+		{"motion", e.motionSection()},
+	}
+}
+
+// get returns the value stored under key, or nil.
+func (o orderedTokens) get(key string) interface{} {
+	for _, entry := range o {
+		if entry.Key == key {
+			return entry.Value
+		}
+	}
+	return nil
+}
+
+// pxValues parses the numeric part of the values used at least minCount
+// times (falling back to all values if none qualify), sorted ascending.
+func pxValues(counts tokenCounts, minCount int) []float64 {
+	collect := func(min int) []float64 {
+		var values []float64
+		for v, n := range counts {
+			if n < min {
+				continue
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSuffix(v, "px"), 64); err == nil {
+				values = append(values, f)
+			}
+		}
+		sort.Float64s(values)
+		return values
+	}
+	if values := collect(minCount); len(values) > 0 {
+		return values
+	}
+	return collect(1)
+}
+
+func formatPx(v float64) string {
+	return strconv.FormatFloat(math.Round(v*2)/2, 'f', -1, 64) + "px"
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// diffCell is the size of the grid changed pixels are grouped into to
+// find the changed regions of a diff.
+const diffCell = 16
+
+var (
+	diffChanged = color.RGBA{255, 0, 0, 255}
+	diffOutline = color.RGBA{255, 0, 255, 255}
+)
+
+// diffImage renders the difference of two screenshots the way pixelmatch
+// does: the before image faded to light gray, with the pixels whose
+// perceptual difference exceeds sensitivity in red. Changed pixels are
+// grouped into regions of adjacent diffCell squares, which are outlined
+// and returned. Pixels outside the overlap of differently sized images
+// count as changed.
+func diffImage(a, b image.Image, sensitivity float64) (*image.RGBA, []image.Rectangle) {
+	ba, bb := a.Bounds(), b.Bounds()
+	w, h := max(ba.Dx(), bb.Dx()), max(ba.Dy(), bb.Dy())
+	overlap := image.Rect(0, 0, min(ba.Dx(), bb.Dx()), min(ba.Dy(), bb.Dy()))
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	cols, rows := (w+diffCell-1)/diffCell, (h+diffCell-1)/diffCell
+	cells := make([]bool, cols*rows)
+
+	limit := 35215 * sensitivity * sensitivity
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			changed := true
+			if (image.Point{x, y}).In(overlap) {
+				c := a.At(ba.Min.X+x, ba.Min.Y+y)
+				changed = colorDelta(c, b.At(bb.Min.X+x, bb.Min.Y+y)) > limit
+				if !changed {
+					// Faded grayscale of the unchanged before image
+					gray := color.GrayModel.Convert(c).(color.Gray).Y
+					v := 255 - (255-gray)/10
+					out.SetRGBA(x, y, color.RGBA{v, v, v, 255})
+				}
+			}
+			if changed {
+				out.SetRGBA(x, y, diffChanged)
+				cells[(y/diffCell)*cols+x/diffCell] = true
+			}
+		}
+	}
+
+	regions := diffRegions(cells, cols, rows, out.Bounds())
+	for _, r := range regions {
+		outline(out, r.Inset(-2), diffOutline)
+	}
+	return out, regions
+}
+
+// diffRegions joins adjacent changed cells (including diagonals) into
+// rectangles in pixels, clipped to bounds.
+func diffRegions(cells []bool, cols, rows int, bounds image.Rectangle) []image.Rectangle {
+	var regions []image.Rectangle
+	seen := make([]bool, len(cells))
+	for start := range cells {
+		if !cells[start] || seen[start] {
+			continue
+		}
+		region := image.Rectangle{}
+		queue := []int{start}
+		seen[start] = true
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			cx, cy := i%cols, i/cols
+			region = region.Union(image.Rect(cx*diffCell, cy*diffCell, (cx+1)*diffCell, (cy+1)*diffCell))
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := cx+dx, cy+dy
+					if nx < 0 || ny < 0 || nx >= cols || ny >= rows {
+						continue
+					}
+					if n := ny*cols + nx; cells[n] && !seen[n] {
+						seen[n] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+		regions = append(regions, region.Intersect(bounds))
+	}
+	return regions
+}
+
+// outline draws a 2px rectangle border, clipped to the image.
+func outline(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for x := r.Min.X; x < r.Max.X; x++ {
+		for _, y := range []int{r.Min.Y, r.Min.Y + 1, r.Max.Y - 2, r.Max.Y - 1} {
+			if y >= r.Min.Y && y < r.Max.Y {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for _, x := range []int{r.Min.X, r.Min.X + 1, r.Max.X - 2, r.Max.X - 1} {
+			if x >= r.Min.X && x < r.Max.X {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// comparisonPage is a page of comparison.html: its before, after and diff
+// images, relative to the report, and its structural changes.
+type comparisonPage struct {
+	Title     string
+	URL       string
+	Failed    bool
+	Summary   string
+	Regions   int
+	Before    string
+	After     string
+	Diff      string
+	Structure *domDiff
+}
+
+// comparisonData is what the comparison template renders.
+type comparisonData struct {
+	Generated time.Time
+	Heading   string
+	RunA      string
+	RunB      string
+	Counts    []reportCount
+	Pages     []comparisonPage
+}
+
+// writeTriptych writes the before, after and diff images of a page as
+// diffs/<name>_before.png, _after.png and _diff.png in dir, and sets their
+// paths and the number of changed regions on page.
+func writeTriptych(dir, name string, a, b image.Image, sensitivity float64, page *comparisonPage) error {
+	diff, regions := diffImage(a, b, sensitivity)
+	page.Regions = len(regions)
+	if err := os.MkdirAll(filepath.Join(dir, "diffs"), 0755); err != nil {
+		return err
+	}
+	files := []struct {
+		suffix string
+		img    image.Image
+		path   *string
+	}{{"_before.png", a, &page.Before}, {"_after.png", b, &page.After}, {"_diff.png", diff, &page.Diff}}
+	for _, file := range files {
+		rel := "diffs/" + name + file.suffix
+		f, err := os.Create(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		err = png.Encode(f, file.img)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		*file.path = rel
+	}
+	return nil
+}
+
+// writeComparisonHTML renders comparison.html into dir.
+func writeComparisonHTML(dir, locale string, data comparisonData) error {
+	templates, err := loadTemplates(locale, "")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data.Generated = time.Now()
+	return templates.render(filepath.Join(dir, "comparison.html"), "comparison", data)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tokenChange is one difference between two design_system.json files.
+type tokenChange struct {
+	Section string `json:"section"`
+	Token   string `json:"token"`
+	Change  string `json:"change"` // added, removed, changed
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after,omitempty"`
+	Note    string `json:"note,omitempty"`
+}
+
+// runDiffTokens compares the design systems of two runs:
+//
+//	explorer diff-tokens [-json] [-out file] runA/ runB/
+func runDiffTokens(args []string) error {
+	fs := flag.NewFlagSet("diff-tokens", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the changes as JSON instead of Markdown")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: diff-tokens [-json] [-out file] <runA> <runB>")
+	}
+
+	before, err := loadDesignSystem(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := loadDesignSystem(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	changes := diffDesignSystems(before, after)
+
+	var report []byte
+	if *asJSON {
+		if report, err = json.MarshalIndent(changes, "", "  "); err != nil {
+			return err
+		}
+	} else {
+		report = []byte(tokenDiffMarkdown(fs.Arg(0), fs.Arg(1), changes))
+	}
+	if *out != "" {
+		return ioutil.WriteFile(*out, report, 0644)
+	}
+	_, err = os.Stdout.Write(report)
+	return err
+}
+
+// loadDesignSystem reads design_system.json from a run directory (or the
+// file itself).
+func loadDesignSystem(path string) (map[string]interface{}, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "design_system.json")
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var system map[string]interface{}
+	if err := json.Unmarshal(data, &system); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return system, nil
+}
+
+// diffDesignSystems compares the semantic colors, palette, typography,
+// spacing, radii and shadows of two design systems.
+func diffDesignSystems(before, after map[string]interface{}) []tokenChange {
+	var changes []tokenChange
+	section := func(name string, a, b interface{}) {
+		changes = append(changes, diffFlat(name, flattenTokens("", a), flattenTokens("", b))...)
+	}
+	section("colors", before["colors"], after["colors"])
+	changes = append(changes, diffPalettes(before["palette"], after["palette"])...)
+
+	typoA, _ := before["typography"].(map[string]interface{})
+	typoB, _ := after["typography"].(map[string]interface{})
+	section("fontFamily", typoA["fontFamily"], typoB["fontFamily"])
+	section("fontSize", typoA["fontSize"], typoB["fontSize"])
+	section("fontWeight", typoA["fontWeight"], typoB["fontWeight"])
+
+	section("spacingUnit", map[string]interface{}{"unit": before["spacingUnit"]}, map[string]interface{}{"unit": after["spacingUnit"]})
+	changes = append(changes, diffValueSets("spacing", flattenTokens("", before["spacing"]), flattenTokens("", after["spacing"]))...)
+	section("borderRadius", before["borderRadius"], after["borderRadius"])
+	section("shadows", before["shadows"], after["shadows"])
+	section("cssVariables", before["cssVariables"], after["cssVariables"])
+	return changes
+}
+
+// flattenTokens turns nested token groups into dotted keys.
+func flattenTokens(prefix string, value interface{}) map[string]string {
+	flat := make(map[string]string)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			for k, leaf := range flattenTokens(name, child) {
+				flat[k] = leaf
+			}
+		}
+	case nil:
+	default:
+		flat[prefix] = fmt.Sprint(v)
+	}
+	return flat
+}
+
+func diffFlat(section string, a, b map[string]string) []tokenChange {
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []tokenChange
+	for _, k := range sorted {
+		va, inA := a[k]
+		vb, inB := b[k]
+		switch {
+		case !inA:
+			changes = append(changes, tokenChange{Section: section, Token: k, Change: "added", After: vb})
+		case !inB:
+			changes = append(changes, tokenChange{Section: section, Token: k, Change: "removed", Before: va})
+		case va != vb:
+			changes = append(changes, tokenChange{Section: section, Token: k, Change: "changed", Before: va, After: vb})
+		}
+	}
+	return changes
+}
+
+// diffValueSets compares the values of a scale regardless of their keys, as
+// scale steps are renumbered when a value is added or removed.
+func diffValueSets(section string, a, b map[string]string) []tokenChange {
+	inA, inB := make(map[string]bool), make(map[string]bool)
+	for _, v := range a {
+		inA[v] = true
+	}
+	for _, v := range b {
+		inB[v] = true
+	}
+	var changes []tokenChange
+	for _, v := range sortedSetKeys(inA) {
+		if !inB[v] {
+			changes = append(changes, tokenChange{Section: section, Token: v, Change: "removed", Before: v})
+		}
+	}
+	for _, v := range sortedSetKeys(inB) {
+		if !inA[v] {
+			changes = append(changes, tokenChange{Section: section, Token: v, Change: "added", After: v})
+		}
+	}
+	return changes
+}
+
+func sortedSetKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffPalettes matches palette colors between runs: a removed color with an
+// added one within ΔE 10 is reported as shifted rather than as two changes.
+func diffPalettes(a, b interface{}) []tokenChange {
+	counts := func(v interface{}) map[string]int {
+		m := make(map[string]int)
+		list, _ := v.([]interface{})
+		for _, item := range list {
+			entry, _ := item.(map[string]interface{})
+			if hex, ok := entry["hex"].(string); ok {
+				n, _ := entry["count"].(float64)
+				m[hex] = int(n)
+			}
+		}
+		return m
+	}
+	before, after := counts(a), counts(b)
+
+	var removed, added []string
+	for hex := range before {
+		if _, ok := after[hex]; !ok {
+			removed = append(removed, hex)
+		}
+	}
+	for hex := range after {
+		if _, ok := before[hex]; !ok {
+			added = append(added, hex)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	var changes []tokenChange
+	matched := make(map[string]bool)
+	for _, old := range removed {
+		best, bestDist := "", 10.0
+		for _, candidate := range added {
+			if matched[candidate] {
+				continue
+			}
+			if d := hexDistance(old, candidate); d < bestDist {
+				best, bestDist = candidate, d
+			}
+		}
+		if best != "" {
+			matched[best] = true
+			changes = append(changes, tokenChange{Section: "palette", Token: old, Change: "changed", Before: old, After: best, Note: fmt.Sprintf("shifted ΔE %.1f", bestDist)})
+			continue
+		}
+		changes = append(changes, tokenChange{Section: "palette", Token: old, Change: "removed", Before: old, Note: fmt.Sprintf("was used %d×", before[old])})
+	}
+	for _, hex := range added {
+		if !matched[hex] {
+			changes = append(changes, tokenChange{Section: "palette", Token: hex, Change: "added", After: hex, Note: fmt.Sprintf("used %d×", after[hex])})
+		}
+	}
+	return changes
+}
+
+// hexDistance is the CIE76 ΔE between two #rrggbb colors.
+func hexDistance(a, b string) float64 {
+	lab := func(hex string) ([3]float64, bool) {
+		var r, g, bl int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(hex, "#")[:6], "%02x%02x%02x", &r, &g, &bl); err != nil {
+			return [3]float64{}, false
+		}
+		return rgbToLab(float64(r), float64(g), float64(bl)), true
+	}
+	if len(a) < 7 || len(b) < 7 {
+		return math.Inf(1)
+	}
+	la, ok1 := lab(a)
+	lb, ok2 := lab(b)
+	if !ok1 || !ok2 {
+		return math.Inf(1)
+	}
+	return labDistance(la, lb)
+}
+
+// tokenDiffMarkdown renders the changes grouped by section.
+func tokenDiffMarkdown(runA, runB string, changes []tokenChange) string {
+	var b strings.Builder
+	b.WriteString("# 🎨 Design Token Diff\n\n")
+	fmt.Fprintf(&b, "**Generated:** %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Comparing `%s` → `%s`\n\n", runA, runB)
+	if len(changes) == 0 {
+		b.WriteString("No token changes.\n")
+		return b.String()
+	}
+
+	summary := map[string]int{}
+	for _, c := range changes {
+		summary[c.Change]++
+	}
+	fmt.Fprintf(&b, "%d added, %d removed, %d changed.\n", summary["added"], summary["removed"], summary["changed"])
+
+	section := ""
+	icons := map[string]string{"added": "➕", "removed": "➖", "changed": "✏️"}
+	for _, c := range changes {
+		if c.Section != section {
+			section = c.Section
+			fmt.Fprintf(&b, "\n## %s\n\n| | Token | Before | After | Note |\n|---|---|---|---|---|\n", section)
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s |\n", icons[c.Change], c.Token, c.Before, c.After, c.Note)
+	}
+	return b.String()
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// domTreeJS records up to %d elements of the body in document order, each
+// keyed by the chain of its ancestors' identities. An element is identified
+// by a non-generated id, its test id, name, aria-label or own text without
+// digits (anchored), else by its tag; siblings with the same identity get
+// an occurrence suffix in their key.
+const domTreeJS = `
+(function(max) {
+	const skip = new Set(['SCRIPT', 'STYLE', 'NOSCRIPT', 'TEMPLATE', 'LINK', 'META']);
+	const clean = (s) => s.trim().replace(/\s+/g, ' ').replace(/>/g, '›').substring(0, 40);
+	const anchor = (el) => {
+		const tag = el.tagName.toLowerCase();
+		if (el.id && !/\d{3,}|^[:_]|[0-9a-f]{8,}/i.test(el.id)) return tag + '#' + el.id;
+		const testid = el.getAttribute('data-testid') || el.getAttribute('data-test-id') || el.getAttribute('data-cy');
+		if (testid) return tag + '[data-testid="' + clean(testid) + '"]';
+		const name = el.getAttribute('name');
+		if (name) return tag + '[name="' + clean(name) + '"]';
+		const label = el.getAttribute('aria-label');
+		if (label && !/\d/.test(label)) return tag + '[aria-label="' + clean(label) + '"]';
+		const own = clean(Array.from(el.childNodes).filter(n => n.nodeType === 3).map(n => n.textContent).join(' '));
+		if (own && !/\d/.test(own)) return tag + ' "' + own + '"';
+		return '';
+	};
+	const nodes = [];
+	let truncated = false;
+	const walk = (el, key) => {
+		const seen = {};
+		for (const child of el.children) {
+			if (skip.has(child.tagName)) continue;
+			if (nodes.length >= max) { truncated = true; return; }
+			const anchored = anchor(child);
+			const ident = anchored || child.tagName.toLowerCase();
+			seen[ident] = (seen[ident] || 0) + 1;
+			const childKey = key + ' > ' + ident + (seen[ident] > 1 ? ':' + seen[ident] : '');
+			const classes = typeof child.className === 'string' ? child.className.trim().split(/\s+/).filter(Boolean) : [];
+			nodes.push({key: childKey, ident: ident, anchored: !!anchored, classes: classes});
+			walk(child, childKey);
+		}
+	};
+	walk(document.body, 'body');
+	return {truncated: truncated, nodes: nodes};
+})(%d)
+`
+
+// domTree is dom/<page>.json, the structure later runs are diffed against.
+type domTree struct {
+	Page      string    `json:"page"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Nodes     []domNode `json:"nodes"`
+}
+
+type domNode struct {
+	Key      string   `json:"key"`
+	Ident    string   `json:"ident"`
+	Anchored bool     `json:"anchored,omitempty"`
+	Classes  []string `json:"classes,omitempty"`
+}
+
+// parent is the key of the node's parent element.
+func (n domNode) parent() string {
+	if i := strings.LastIndex(n.Key, " > "); i >= 0 {
+		return n.Key[:i]
+	}
+	return ""
+}
+
+// captureDOMTree writes the structure of the page to dom/<page>.json.
+func (e *AgicapExplorer) captureDOMTree(pageName string) {
+	tree := domTree{Page: pageName}
+	script := fmt.Sprintf(domTreeJS, e.config.GetInt("explorer.capture.max_dom_nodes"))
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(script, &tree)); err != nil {
+		e.log("⚠️ DOM snapshot failed for %s: %v", pageName, err)
+		return
+	}
+	for i := range tree.Nodes {
+		tree.Nodes[i].Key = e.redactor.Redact(tree.Nodes[i].Key)
+		tree.Nodes[i].Ident = e.redactor.Redact(tree.Nodes[i].Ident)
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("dom", sanitize(pageName)+".json", data); err != nil {
+		e.log("⚠️ Failed to write the DOM snapshot of %s: %v", pageName, err)
+	}
+}
+
+// loadDOMTree reads the DOM snapshot a run recorded for a page.
+func loadDOMTree(runDir string, item NavigationItem) (*domTree, error) {
+	data, err := ioutil.ReadFile(filepath.Join(runDir, "dom", pageKey(item)+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var tree domTree
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
+// domDiffLimit caps the subtrees and class changes a domDiff lists; the
+// counts stay complete.
+const domDiffLimit = 50
+
+// domDiff is the structural change of a page between two runs. Added and
+// Removed list the roots of added and removed subtrees, Moved the anchored
+// elements that changed parent, with their subtrees.
+type domDiff struct {
+	Added        []string      `json:"added,omitempty"`
+	Removed      []string      `json:"removed,omitempty"`
+	Moved        []domMove     `json:"moved,omitempty"`
+	Classes      []classChange `json:"classes,omitempty"`
+	AddedNodes   int           `json:"added_elements"`
+	RemovedNodes int           `json:"removed_elements"`
+	MovedNodes   int           `json:"moved_elements"`
+	ClassNodes   int           `json:"class_changes"`
+}
+
+type domMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type classChange struct {
+	Key     string   `json:"key"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Count is the number of listed structural changes.
+func (d *domDiff) Count() int {
+	if d == nil {
+		return 0
+	}
+	return d.AddedNodes + d.RemovedNodes + d.MovedNodes + d.ClassNodes
+}
+
+// Summary counts the changes, e.g. "2 added, 1 moved".
+func (d *domDiff) Summary() string {
+	var parts []string
+	for _, c := range []struct {
+		n    int
+		what string
+	}{{d.AddedNodes, "added"}, {d.RemovedNodes, "removed"}, {d.MovedNodes, "moved"}, {d.ClassNodes, "class changes"}} {
+		if c.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", c.n, c.what))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// diffDOM compares the snapshots of a page. Children of matched elements
+// are aligned by identity (a longest common subsequence, so inserting an
+// element does not shift its siblings); an anchored element left unmatched
+// in one place and added with the same identity in another counts as
+// moved, and its children are aligned below it. Added and removed
+// descendants of added and removed elements are counted but not listed.
+func diffDOM(a, b *domTree) *domDiff {
+	before, after := a.children(), b.children()
+	removed := make(map[string]bool, len(a.Nodes))
+	for _, n := range a.Nodes {
+		removed[n.Key] = true
+	}
+	added := make(map[string]bool, len(b.Nodes))
+	for _, n := range b.Nodes {
+		added[n.Key] = true
+	}
+	d := &domDiff{}
+	var match func(ka, kb string)
+	match = func(ka, kb string) {
+		for _, p := range alignNodes(before[ka], after[kb]) {
+			delete(removed, p[0].Key)
+			delete(added, p[1].Key)
+			onlyA, onlyB := diffStrings(p[0].Classes, p[1].Classes)
+			if len(onlyA) > 0 || len(onlyB) > 0 {
+				d.ClassNodes++
+				if len(d.Classes) < domDiffLimit {
+					d.Classes = append(d.Classes, classChange{Key: p[1].Key, Added: onlyB, Removed: onlyA})
+				}
+			}
+			match(p[0].Key, p[1].Key)
+		}
+	}
+	match("body", "body")
+
+	// Moves: unmatched anchored elements added under another parent
+	for _, n := range a.Nodes {
+		if !removed[n.Key] || !n.Anchored {
+			continue
+		}
+		for _, m := range b.Nodes {
+			if !added[m.Key] || !m.Anchored || m.Ident != n.Ident {
+				continue
+			}
+			delete(removed, n.Key)
+			delete(added, m.Key)
+			d.MovedNodes++
+			if len(d.Moved) < domDiffLimit {
+				d.Moved = append(d.Moved, domMove{n.Key, m.Key})
+			}
+			match(n.Key, m.Key)
+			break
+		}
+	}
+
+	for _, n := range a.Nodes {
+		if removed[n.Key] {
+			d.RemovedNodes++
+			if !removed[n.parent()] && len(d.Removed) < domDiffLimit {
+				d.Removed = append(d.Removed, n.Key)
+			}
+		}
+	}
+	for _, n := range b.Nodes {
+		if added[n.Key] {
+			d.AddedNodes++
+			if !added[n.parent()] && len(d.Added) < domDiffLimit {
+				d.Added = append(d.Added, n.Key)
+			}
+		}
+	}
+	return d
+}
+
+// children groups the nodes by the key of their parent, in document order.
+func (t *domTree) children() map[string][]domNode {
+	children := make(map[string][]domNode)
+	for _, n := range t.Nodes {
+		children[n.parent()] = append(children[n.parent()], n)
+	}
+	return children
+}
+
+// alignNodes pairs the siblings of two snapshots along the longest common
+// subsequence of their identities.
+func alignNodes(a, b []domNode) [][2]domNode {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].Ident == b[j].Ident {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var pairs [][2]domNode
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].Ident == b[j].Ident:
+			pairs = append(pairs, [2]domNode{a[i], b[j]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// domDiffMarkdown lists up to limit changes of each kind.
+func domDiffMarkdown(d *domDiff, limit int) string {
+	var b strings.Builder
+	for i, key := range d.Added {
+		if i < limit {
+			fmt.Fprintf(&b, "- ➕ `%s`\n", key)
+		}
+	}
+	for i, key := range d.Removed {
+		if i < limit {
+			fmt.Fprintf(&b, "- ➖ `%s`\n", key)
+		}
+	}
+	for i, m := range d.Moved {
+		if i < limit {
+			fmt.Fprintf(&b, "- ↪️ `%s` → `%s`\n", m.From, m.To)
+		}
+	}
+	for i, c := range d.Classes {
+		if i >= limit {
+			break
+		}
+		var changes []string
+		for _, class := range c.Added {
+			changes = append(changes, "+"+class)
+		}
+		for _, class := range c.Removed {
+			changes = append(changes, "-"+class)
+		}
+		fmt.Fprintf(&b, "- 🎨 `%s` %s\n", c.Key, strings.Join(changes, " "))
+	}
+	if listed := min(len(d.Added), limit) + min(len(d.Removed), limit) + min(len(d.Moved), limit) + min(len(d.Classes), limit); listed < d.Count() {
+		fmt.Fprintf(&b, "- … %s in total\n", d.Summary())
+	}
+	return b.String()
+}
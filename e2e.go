@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// featureTest mirrors an entry of features/feature_tests.json, written by
+// the functional explorer.
+type featureTest struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Page        string          `json:"page"`
+	Actions     []featureAction `json:"actions"`
+	Status      string          `json:"status"`
+}
+
+type featureAction struct {
+	Type        string `json:"type"`             // click, fill, select, navigate, assert, capture, drag, slide, upload
+	Assert      string `json:"assert,omitempty"` // exists, text_contains, url_matches, request_observed, value_equals
+	Selector    string `json:"selector"`
+	Target      string `json:"target,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Description string `json:"description"`
+	Result      string `json:"result,omitempty"`
+}
+
+// jqueryContains matches the jQuery :contains("text") pseudo-class, which
+// Cypress understands and Playwright spells :has-text().
+var jqueryContains = regexp.MustCompile(`:contains\(`)
+
+// e2eFramework writes the specs and config of one test runner.
+type e2eFramework struct {
+	dir       string // below generated/
+	extension string
+	config    func(baseURL string) (name, source string)
+	spec      func(f featureTest, start string) string
+}
+
+var e2eFrameworks = map[string]e2eFramework{
+	"playwright": {dir: "tests", extension: ".spec.ts", config: playwrightConfig, spec: playwrightSpec},
+	"cypress":    {dir: filepath.Join("cypress", "e2e"), extension: ".cy.ts", config: cypressConfig, spec: cypressSpec},
+}
+
+// runCodegenTests turns the functional explorer's feature tests into
+// end-to-end specs replaying the same actions, selectors and values:
+//
+//	explorer codegen tests [-framework playwright|cypress] [-out dir] run/
+func runCodegenTests(args []string) error {
+	fs := flag.NewFlagSet("codegen tests", flag.ContinueOnError)
+	framework := fs.String("framework", "playwright", "test runner: playwright or cypress")
+	out := fs.String("out", "", "output directory (default <run>/generated)")
+	baseURL := fs.String("base-url", "", "base URL of the app under test (default: origin of the first captured page)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fw, ok := e2eFrameworks[*framework]
+	if fs.NArg() != 1 || !ok {
+		return fmt.Errorf("usage: codegen tests [-framework playwright|cypress] [-out dir] [-base-url url] <run>")
+	}
+	runDir := fs.Arg(0)
+	if *out == "" {
+		*out = filepath.Join(runDir, "generated")
+	}
+
+	features, err := loadFeatureTests(runDir)
+	if err != nil {
+		return err
+	}
+
+	// Feature pages are titles; the navigation map gives their URLs
+	pages, _ := loadNavigationMap(runDir)
+	if *baseURL == "" && len(pages) > 0 {
+		if u, err := url.Parse(pages[0].URL); err == nil {
+			*baseURL = u.Scheme + "://" + u.Host
+		}
+	}
+
+	specDir := filepath.Join(*out, fw.dir)
+	if err := os.MkdirAll(specDir, 0755); err != nil {
+		return err
+	}
+	used := make(map[string]bool)
+	for _, f := range features {
+		name := strings.Trim(iconNameClean.ReplaceAllString(strings.ToLower(f.Name), "-"), "-")
+		if name == "" {
+			name = "feature"
+		}
+		for base, i := name, 2; used[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[name] = true
+		source := fw.spec(f, featureStartPath(f, pages, *baseURL))
+		if err := ioutil.WriteFile(filepath.Join(specDir, name+fw.extension), []byte(source), 0644); err != nil {
+			return err
+		}
+	}
+	configName, config := fw.config(*baseURL)
+	if err := ioutil.WriteFile(filepath.Join(*out, configName), []byte(config), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Generated %d %s specs in %s\n", len(features), *framework, specDir)
+	return nil
+}
+
+// featureStartPath returns the path of the captured page whose title matches
+// the feature's page, relative to baseURL, or "/".
+func featureStartPath(f featureTest, pages []NavigationItem, baseURL string) string {
+	page := strings.ToLower(f.Page)
+	for _, p := range pages {
+		title := strings.ToLower(p.Title)
+		if page == "" || title == "" || !(strings.Contains(title, page) || strings.Contains(page, title)) {
+			continue
+		}
+		if strings.HasPrefix(p.URL, baseURL) && baseURL != "" {
+			return "/" + strings.TrimLeft(strings.TrimPrefix(p.URL, baseURL), "/")
+		}
+		return p.URL
+	}
+	return "/"
+}
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// e2eSteps renders the actions as statements, commenting out the ones that
+// failed in the original run since they would fail the same way.
+func e2eSteps(actions []featureAction, indent string, step func(a featureAction) string) string {
+	var b strings.Builder
+	for _, a := range actions {
+		statement := step(a)
+		if a.Description != "" {
+			fmt.Fprintf(&b, "%s// %s\n", indent, a.Description)
+		}
+		switch {
+		case statement == "":
+			fmt.Fprintf(&b, "%s// TODO: unsupported action %q on %s\n", indent, a.Type, a.Selector)
+		case a.Result == "failed":
+			fmt.Fprintf(&b, "%s// Failed in the original run:\n%s// %s\n", indent, indent, statement)
+		default:
+			fmt.Fprintf(&b, "%s%s\n", indent, statement)
+		}
+	}
+	return b.String()
+}
+
+// jsFiles renders the comma separated files of an upload as a JS array.
+func jsFiles(value string) string {
+	var files []string
+	for _, file := range strings.Split(value, ",") {
+		files = append(files, jsString(file))
+	}
+	return "[" + strings.Join(files, ", ") + "]"
+}
+
+func playwrightSpec(f featureTest, start string) string {
+	var b strings.Builder
+	b.WriteString("// Generated by agicap-explorer from features/feature_tests.json\n")
+	b.WriteString("import { test, expect } from '@playwright/test';\n\n")
+	fmt.Fprintf(&b, "test.describe(%s, () => {\n", jsString(f.Name))
+	fmt.Fprintf(&b, "  test(%s, async ({ page }) => {\n", jsString(f.Description))
+	fmt.Fprintf(&b, "    await page.goto(%s);\n", jsString(start))
+	b.WriteString(e2eSteps(f.Actions, "    ", func(a featureAction) string {
+		locator := fmt.Sprintf("page.locator(%s).first()", jsString(jqueryContains.ReplaceAllString(a.Selector, ":has-text(")))
+		switch a.Type {
+		case "navigate":
+			target := a.Value
+			if target == "" {
+				target = a.Selector
+			}
+			return fmt.Sprintf("await page.goto(%s);", jsString(target))
+		case "click":
+			return fmt.Sprintf("await %s.click();", locator)
+		case "fill":
+			return fmt.Sprintf("await %s.fill(%s);", locator, jsString(a.Value))
+		case "select":
+			return fmt.Sprintf("await %s.selectOption(%s);", locator, jsString(a.Value))
+		case "drag":
+			if a.Target != "" {
+				return fmt.Sprintf("await %s.dragTo(page.locator(%s).first());", locator, jsString(jqueryContains.ReplaceAllString(a.Target, ":has-text(")))
+			}
+		case "slide":
+			if !strings.HasSuffix(a.Value, "%") {
+				return fmt.Sprintf("await %s.fill(%s);", locator, jsString(a.Value))
+			}
+		case "upload":
+			return fmt.Sprintf("await %s.setInputFiles(%s);", locator, jsFiles(a.Value))
+		case "assert":
+			switch a.Assert {
+			case "exists":
+				return fmt.Sprintf("await expect(%s).toBeAttached();", locator)
+			case "text_contains", "":
+				return fmt.Sprintf("await expect(%s).toContainText(%s);", locator, jsString(a.Value))
+			case "value_equals":
+				return fmt.Sprintf("await expect(%s).toHaveValue(%s);", locator, jsString(a.Value))
+			case "url_matches":
+				return fmt.Sprintf("await expect(page).toHaveURL(new RegExp(%s));", jsString(a.Value))
+			}
+		case "capture":
+			return fmt.Sprintf("await page.screenshot({ path: %s });", jsString("screenshots/"+a.Value+".png"))
+		}
+		return ""
+	}))
+	b.WriteString("    await expect(page).not.toHaveURL(/login/);\n")
+	b.WriteString("  });\n});\n")
+	return b.String()
+}
+
+func cypressSpec(f featureTest, start string) string {
+	var b strings.Builder
+	b.WriteString("// Generated by agicap-explorer from features/feature_tests.json\n")
+	fmt.Fprintf(&b, "describe(%s, () => {\n", jsString(f.Name))
+	fmt.Fprintf(&b, "  it(%s, () => {\n", jsString(f.Description))
+	fmt.Fprintf(&b, "    cy.visit(%s);\n", jsString(start))
+	b.WriteString(e2eSteps(f.Actions, "    ", func(a featureAction) string {
+		get := fmt.Sprintf("cy.get(%s).first()", jsString(a.Selector))
+		switch a.Type {
+		case "navigate":
+			target := a.Value
+			if target == "" {
+				target = a.Selector
+			}
+			return fmt.Sprintf("cy.visit(%s);", jsString(target))
+		case "click":
+			return get + ".click();"
+		case "fill":
+			return fmt.Sprintf("%s.clear().type(%s);", get, jsString(a.Value))
+		case "select":
+			return fmt.Sprintf("%s.select(%s);", get, jsString(a.Value))
+		case "slide":
+			if !strings.HasSuffix(a.Value, "%") {
+				return fmt.Sprintf("%s.invoke('val', %s).trigger('input').trigger('change');", get, jsString(a.Value))
+			}
+		case "upload":
+			return fmt.Sprintf("%s.selectFile(%s, { force: true });", get, jsFiles(a.Value))
+		case "assert":
+			switch a.Assert {
+			case "exists":
+				return get + ".should('exist');"
+			case "text_contains", "":
+				return fmt.Sprintf("%s.should('contain.text', %s);", get, jsString(a.Value))
+			case "value_equals":
+				return fmt.Sprintf("%s.should('have.value', %s);", get, jsString(a.Value))
+			case "url_matches":
+				return fmt.Sprintf("cy.url().should('match', new RegExp(%s));", jsString(a.Value))
+			}
+		case "capture":
+			return fmt.Sprintf("cy.screenshot(%s);", jsString(a.Value))
+		}
+		return ""
+	}))
+	b.WriteString("    cy.url().should('not.include', 'login');\n")
+	b.WriteString("  });\n});\n")
+	return b.String()
+}
+
+func playwrightConfig(baseURL string) (string, string) {
+	return "playwright.config.ts", fmt.Sprintf(`// Generated by agicap-explorer
+import { defineConfig } from '@playwright/test';
+
+export default defineConfig({
+  testDir: './tests',
+  use: {
+    baseURL: process.env.BASE_URL ?? %s,
+    // Reuse a logged-in session: npx playwright codegen --save-storage=auth.json
+    storageState: process.env.STORAGE_STATE,
+  },
+});
+`, jsString(baseURL))
+}
+
+func cypressConfig(baseURL string) (string, string) {
+	return "cypress.config.ts", fmt.Sprintf(`// Generated by agicap-explorer
+import { defineConfig } from 'cypress';
+
+export default defineConfig({
+  e2e: {
+    baseUrl: process.env.BASE_URL ?? %s,
+    specPattern: 'cypress/e2e/**/*.cy.ts',
+  },
+});
+`, jsString(baseURL))
+}
@@ -8,30 +8,140 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
 )
 
 type AgicapExplorer struct {
 	ctx           context.Context
 	cancel        context.CancelFunc
+	config        *viper.Viper
+	scope         *urlScope
+	canonical     *Canonicalizer
 	outputDir     string
 	visitedURLs   map[string]bool
 	navigationMap []NavigationItem
+	fingerprints  []pageFingerprint
+	graph         *linkGraph
+	polite        *politeness
+	network       *networkRecorder
+	console       *consoleRecorder
+	stylesheets   *stylesheetHarvester
+	icons         *iconSet
+	mocks         *requestMocker
+	safety        *safetyGuard
+	forms         *formFiller
+	redactor      *redactor
+	templates     *reportTemplates
+	waits         map[string]waitStep
+	activity      *networkActivity
+	assets        *assetDownloader
+	branding      *assetDownloader
+	brandAssets   []brandAsset
+	brandSeen     map[string]bool
+	previous      *previousRun // set for incremental runs
+	stream        *os.File     // navigation_map.ndjson
+	profile       *crawlProfile
+	bench         *benchmark // set with -bench
+	axe           *axeScript
+	vision        *visionFilter
+	ignore        []ignoreRule // explorer.screenshots.ignore
+	viewports     []viewport   // explorer.viewports.matrix, when enabled
+	throttling    []networkProfile
+	printPages    []*regexp.Regexp // explorer.print.pages
+	zoomPages     []*regexp.Regexp // explorer.zoom.pages
+	current       crawlTarget
+	lastCapture   int             // navigationMap index of this tab's last capture
+	mu            *sync.Mutex     // guards the state shared by the tabs, see shared()
+	root          *AgicapExplorer // the explorer a tab was opened from
+	runID         string
+	reportURL     string // report.html in the bucket of explorer.upload
+	aborted       string // why the crawl gave up early, for notify
+	started       time.Time
+	deadline      time.Time    // end of the browser context, see launchBrowser
+	crashed       *atomic.Bool // set when this tab's renderer crashes
+	relogin       func() error // repeats the login after a browser restart
+	failures      map[string]int
 	verbose       bool
 }
 
 type NavigationItem struct {
-	URL        string   `json:"url"`
-	Title      string   `json:"title"`
-	Screenshot string   `json:"screenshot"`
-	Navigation []string `json:"navigation"`
-	Timestamp  string   `json:"timestamp"`
+	URL          string       `json:"url"`
+	CanonicalURL string       `json:"canonical_url"`
+	Route        string       `json:"route_template"`
+	Title        string       `json:"title"`
+	Screenshot   string       `json:"screenshot"`
+	Thumbnail    string       `json:"thumbnail,omitempty"`
+	PDF          string       `json:"pdf,omitempty"`
+	Archive      string       `json:"archive,omitempty"`
+	HAR          string       `json:"har,omitempty"`
+	Console      string       `json:"console,omitempty"`
+	JSErrors     int          `json:"js_errors,omitempty"`
+	Performance  *pageMetrics `json:"performance,omitempty"`
+	Responsive   string       `json:"responsive,omitempty"`
+	Viewports    string       `json:"viewports,omitempty"`
+	Throttling   string       `json:"throttling,omitempty"`
+	Print        string       `json:"print,omitempty"`
+	Scroll       string       `json:"scroll,omitempty"`
+	Zoom         string       `json:"zoom,omitempty"`
+	Locales      string       `json:"locales,omitempty"`
+	Overlays     string       `json:"overlays,omitempty"`
+	Tables       string       `json:"tables,omitempty"`
+	Navigation   []string     `json:"navigation"`
+	Depth        int          `json:"depth"`
+	Section      string       `json:"section"`
+	ContentHash  string       `json:"content_hash,omitempty"`
+	Aliases      []string     `json:"aliases,omitempty"`
+	Timestamp    string       `json:"timestamp"`
+
+	firstError string
 }
 
-func NewAgicapExplorer(outputDir string, headless bool, verbose bool) (*AgicapExplorer, error) {
+func NewAgicapExplorer(v *viper.Viper, verbose bool) (*AgicapExplorer, error) {
+	outputDir := v.GetString("explorer.output.directory")
+
+	scope, err := newURLScope(v)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := newRedactor(v)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := loadTemplates(v.GetString("explorer.output.locale"), v.GetString("explorer.output.templates"))
+	if err != nil {
+		return nil, err
+	}
+	waits, err := waitStrategies(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScreenshotFormat(v); err != nil {
+		return nil, err
+	}
+	if err := checkScaleFactor(v); err != nil {
+		return nil, err
+	}
+	if err := checkAxeBundle(v); err != nil {
+		return nil, err
+	}
+	var previous *previousRun
+	if v.GetBool("explorer.incremental.enabled") {
+		dir := v.GetString("explorer.incremental.previous")
+		if dir == "" {
+			dir = outputDir
+		}
+		if previous, err = loadPreviousRun(dir); err != nil {
+			return nil, fmt.Errorf("failed to load the previous run: %w", err)
+		}
+	}
+
 	// Create output directory structure
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create output directory: %w", err)
@@ -42,9 +152,123 @@ func NewAgicapExplorer(outputDir string, headless bool, verbose bool) (*AgicapEx
 		os.MkdirAll(filepath.Join(outputDir, dir), 0755)
 	}
 
+	started := time.Now()
+	explorer := &AgicapExplorer{
+		config:        v,
+		scope:         scope,
+		canonical:     NewCanonicalizer(v),
+		redactor:      redactor,
+		templates:     templates,
+		waits:         waits,
+		previous:      previous,
+		graph:         newLinkGraph(),
+		outputDir:     outputDir,
+		visitedURLs:   make(map[string]bool),
+		navigationMap: []NavigationItem{},
+		lastCapture:   -1,
+		mu:            &sync.Mutex{},
+		runID:         newRunID(started),
+		started:       started,
+		deadline:      started.Add(time.Duration(v.GetInt("explorer.browser.timeout_minutes")) * time.Minute),
+		failures:      make(map[string]int),
+		verbose:       verbose,
+	}
+	if v.GetBool("explorer.assets.enabled") {
+		explorer.assets = newAssetDownloader(outputDir, "assets", v.GetInt64("explorer.assets.max_bytes"))
+	}
+	if v.GetBool("explorer.branding.enabled") {
+		explorer.branding = newAssetDownloader(outputDir, "branding", v.GetInt64("explorer.assets.max_bytes"))
+		explorer.brandSeen = make(map[string]bool)
+	}
+	explorer.polite = newPoliteness(v, explorer.log)
+	explorer.network = newNetworkRecorder(v)
+	explorer.console = newConsoleRecorder(v.GetBool("explorer.capture.console"))
+	explorer.stylesheets = newStylesheetHarvester(v.GetBool("explorer.capture.stylesheets"))
+	if v.GetBool("explorer.capture.icons") {
+		explorer.icons = newIconSet()
+	}
+	if explorer.mocks, err = newRequestMocker(v, explorer.log); err != nil {
+		return nil, err
+	}
+	if explorer.forms, err = newFormFiller(v); err != nil {
+		return nil, err
+	}
+	if explorer.safety, err = newSafetyGuard(v, explorer.log); err != nil {
+		return nil, err
+	}
+	if explorer.safety.ReadOnly() {
+		// Read-only mode intercepts through the mocker, with or without rules
+		if explorer.mocks == nil {
+			explorer.mocks = &requestMocker{hits: make(map[string]int), log: explorer.log}
+		}
+		explorer.mocks.guard = explorer.safety
+	}
+	if err := explorer.openStream(); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", streamFile, err)
+	}
+	if v.GetBool("explorer.capture.axe") {
+		explorer.axe = &axeScript{}
+	}
+	if v.GetBool("explorer.vision.enabled") {
+		if explorer.vision, err = newVisionFilter(v); err != nil {
+			return nil, err
+		}
+	}
+	if explorer.ignore, err = loadIgnoreRules(v); err != nil {
+		return nil, err
+	}
+	if v.GetBool("explorer.viewports.enabled") {
+		if explorer.viewports, err = loadViewports(v); err != nil {
+			return nil, err
+		}
+	}
+	if explorer.printPages, err = compilePatterns(v.GetStringSlice("explorer.print.pages")); err != nil {
+		return nil, fmt.Errorf("invalid print page pattern: %w", err)
+	}
+	if explorer.zoomPages, err = compilePatterns(v.GetStringSlice("explorer.zoom.pages")); err != nil {
+		return nil, fmt.Errorf("invalid zoom page pattern: %w", err)
+	}
+	if v.GetBool("explorer.throttling.enabled") {
+		if explorer.throttling, err = loadNetworkProfiles(v); err != nil {
+			return nil, err
+		}
+	}
+	if v.GetBool("explorer.bench.enabled") {
+		explorer.bench = newBenchmark(started)
+	}
+	if v.GetBool("explorer.scheduling.enabled") {
+		path := profilePath(explorer)
+		if explorer.profile, err = loadCrawlProfile(path); err != nil {
+			explorer.log("⚠️ Ignoring unreadable crawl profile %s: %v", path, err)
+			explorer.profile = newCrawlProfile(path)
+		}
+	}
+
+	explorer.launchBrowser()
+	if err := explorer.listen(); err != nil {
+		explorer.Close()
+		return nil, err
+	}
+	if explorer.mocks != nil && len(explorer.mocks.rules) > 0 {
+		explorer.log("🎭 Request mocking enabled with %d rules", len(explorer.mocks.rules))
+	}
+	if explorer.safety.ReadOnly() {
+		explorer.log("🛡️ Read-only: requests that write are blocked after the login")
+	}
+	if previous != nil {
+		explorer.log("♻️ Incremental run: unchanged pages of %s (%d) are reused", previous.dir, len(previous.pages))
+	}
+
+	return explorer, nil
+}
+
+// launchBrowser starts Chrome and sets up the context of its first tab. The
+// context ends explorer.browser.timeout_minutes after the explorer was
+// created, also after a restart.
+func (e *AgicapExplorer) launchBrowser() {
 	// Browser options with better error handling
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", headless),
+		chromedp.Flag("headless", e.config.GetBool("explorer.browser.headless")),
 		chromedp.Flag("disable-gpu", false),
 		chromedp.Flag("disable-dev-shm-usage", true),
 		chromedp.Flag("no-sandbox", true),
@@ -54,19 +278,20 @@ func NewAgicapExplorer(outputDir string, headless bool, verbose bool) (*AgicapEx
 		chromedp.Flag("disable-plugins", true),
 		chromedp.Flag("disable-images", false),
 		chromedp.Flag("disable-javascript", false),
-		chromedp.Flag("window-size", "1920,1080"),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		chromedp.Flag("window-size", e.config.GetString("explorer.browser.window_size")),
+		chromedp.Flag("force-device-scale-factor", fmt.Sprint(e.config.GetFloat64("explorer.screenshots.scale_factor"))),
+		chromedp.UserAgent(e.config.GetString("explorer.browser.user_agent")),
 	)
 
 	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
 
-	// Create context with longer timeout
-	ctx, cancelCtx := context.WithTimeout(allocCtx, 10*time.Minute)
+	// Create context with configurable timeout
+	ctx, cancelCtx := context.WithDeadline(allocCtx, e.deadline)
 
 	// Create browser context with error handling
 	browserCtx, _ := chromedp.NewContext(ctx)
 
-	if verbose {
+	if e.verbose {
 		browserCtx, _ = chromedp.NewContext(ctx, chromedp.WithLogf(func(format string, v ...interface{}) {
 			// Filter out cookie parsing errors
 			msg := fmt.Sprintf(format, v...)
@@ -76,31 +301,35 @@ func NewAgicapExplorer(outputDir string, headless bool, verbose bool) (*AgicapEx
 		}))
 	}
 
-	return &AgicapExplorer{
-		ctx:           browserCtx,
-		cancel:        func() { cancelCtx(); cancel() },
-		outputDir:     outputDir,
-		visitedURLs:   make(map[string]bool),
-		navigationMap: []NavigationItem{},
-		verbose:       verbose,
-	}, nil
+	e.ctx = browserCtx
+	e.cancel = func() { cancelCtx(); cancel() }
 }
 
 func (e *AgicapExplorer) Close() {
 	if e.cancel != nil {
 		e.cancel()
 	}
+	if e.stream != nil {
+		e.stream.Close()
+	}
 }
 
-func (e *AgicapExplorer) Login(loginURL, email, password string) error {
+func (e *AgicapExplorer) Login(loginURL, email, password string) (err error) {
 	e.log("🔐 Logging in to: %s", loginURL)
+	defer e.bench.Start("login")()
+	defer func() { runMetrics.LoginAttempt(err == nil) }()
+	// The login traffic carries the credentials, keep it out of the first
+	// page's HAR
+	defer e.network.Reset()
+	e.resetPerformanceCounters()
+	// The login is the one write a read-only run makes
+	e.safety.disarm()
 
 	// Navigate to login page with retry
-	var err error
 	for i := 0; i < 3; i++ {
 		err = chromedp.Run(e.ctx,
 			chromedp.Navigate(loginURL),
-			chromedp.Sleep(3*time.Second),
+			e.settle("navigation"),
 		)
 		if err == nil {
 			break
@@ -116,32 +345,31 @@ func (e *AgicapExplorer) Login(loginURL, email, password string) error {
 	// Try to fill login form
 	e.log("🔑 Filling credentials...")
 
-	// Fill email/username with longer timeout
+	// Fill email/username once the form has rendered
 	if err := chromedp.Run(e.ctx,
-		chromedp.Sleep(3*time.Second),
-		chromedp.WaitVisible(`input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"], input[placeholder*="email" i]`, chromedp.ByQuery, chromedp.NodeVisible),
+		e.visible("login", `input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"], input[placeholder*="email" i]`),
 		chromedp.SendKeys(`input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"], input[placeholder*="email" i]`, email, chromedp.ByQuery),
-		chromedp.Sleep(1*time.Second),
+		e.settle("form"),
 	); err != nil {
 		e.log("⚠️ Email input failed, trying alternative selectors...")
 		// Try alternative approach
 		chromedp.Run(e.ctx,
 			chromedp.Click(`input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"]`, chromedp.ByQuery),
-			chromedp.Sleep(1*time.Second),
+			e.settle("form"),
 			chromedp.SendKeys(`input[type="email"], input[name*="email"], input[id*="email"], input[name*="username"]`, email, chromedp.ByQuery),
 		)
 	}
 
 	// Fill password with better error handling
 	if err := chromedp.Run(e.ctx,
-		chromedp.WaitVisible(`input[type="password"]`, chromedp.ByQuery, chromedp.NodeVisible),
+		e.visible("login", `input[type="password"]`),
 		chromedp.SendKeys(`input[type="password"]`, password, chromedp.ByQuery),
-		chromedp.Sleep(1*time.Second),
+		e.settle("form"),
 	); err != nil {
 		e.log("⚠️ Password input failed, trying alternative approach...")
 		chromedp.Run(e.ctx,
 			chromedp.Click(`input[type="password"]`, chromedp.ByQuery),
-			chromedp.Sleep(500*time.Millisecond),
+			e.settle("form"),
 			chromedp.SendKeys(`input[type="password"]`, password, chromedp.ByQuery),
 		)
 	}
@@ -150,12 +378,12 @@ func (e *AgicapExplorer) Login(loginURL, email, password string) error {
 	e.log("📤 Submitting login form...")
 	if err := chromedp.Run(e.ctx,
 		chromedp.Click(`button[type="submit"], input[type="submit"]`, chromedp.ByQuery),
-		chromedp.Sleep(5*time.Second),
+		e.settle("login"),
 	); err != nil {
 		e.log("⚠️ Submit button click failed, trying Enter key...")
 		chromedp.Run(e.ctx,
 			chromedp.KeyEvent("\r"),
-			chromedp.Sleep(5*time.Second),
+			e.settle("login"),
 		)
 	}
 
@@ -173,15 +401,18 @@ func (e *AgicapExplorer) Login(loginURL, email, password string) error {
 	}
 
 	e.log("✅ Login successful! Current URL: %s", currentURL)
+	e.safety.arm()
+	e.relogin = func() error { return e.Login(loginURL, email, password) }
 	return nil
 }
 
 func (e *AgicapExplorer) CapturePage(pageName string) error {
 	e.log("📸 Capturing: %s", pageName)
+	defer e.bench.Start("capture")()
 
 	var currentURL, pageTitle, pageHTML string
 	err := chromedp.Run(e.ctx,
-		chromedp.Sleep(2*time.Second),
+		e.settle("capture"),
 		chromedp.Evaluate("window.location.href", &currentURL),
 		chromedp.Evaluate("document.title", &pageTitle),
 		chromedp.OuterHTML("html", &pageHTML),
@@ -190,291 +421,518 @@ func (e *AgicapExplorer) CapturePage(pageName string) error {
 		return fmt.Errorf("failed to capture page: %w", err)
 	}
 
-	e.visitedURLs[currentURL] = true
+	canonicalURL := e.canonical.Canonical(currentURL)
+	e.mu.Lock()
+	e.shared().visitedURLs[canonicalURL] = true
+	e.mu.Unlock()
 
 	// Screenshot
-	var screenshot []byte
-	screenshotPath := filepath.Join(e.outputDir, "screenshots", sanitize(pageName)+".png")
-	chromedp.Run(e.ctx, chromedp.CaptureScreenshot(&screenshot))
-	ioutil.WriteFile(screenshotPath, screenshot, 0644)
+	stop := e.bench.Start("screenshot")
+	screenshotPath, thumbnailPath := e.captureScreenshot(pageName)
+	stop()
+	e.captureIgnoreRegions(pageName, currentURL, pageTitle)
 
 	// HTML
 	htmlPath := filepath.Join(e.outputDir, "html", sanitize(pageName)+".html")
+	stop = e.bench.Start("disk_writes")
 	ioutil.WriteFile(htmlPath, []byte(pageHTML), 0644)
+	stop()
+	e.downloadAssets(pageName, htmlPath, pageHTML)
+	e.extractBranding(pageName)
+	if e.icons != nil {
+		e.extractIcons(pageName)
+	}
+
+	// Self-contained archive
+	var archivePath string
+	if e.config.GetBool("explorer.capture.mhtml") {
+		archivePath = e.captureMHTML(pageName)
+	}
+
+	// Accessibility tree
+	if e.config.GetBool("explorer.capture.accessibility_tree") {
+		e.captureAccessibilityTree(pageName)
+	}
+	if e.axe != nil {
+		e.captureAxeAudit(pageName)
+	}
+	if e.config.GetBool("explorer.capture.images") {
+		e.captureImages(pageName)
+	}
+	if e.config.GetBool("explorer.capture.aria") {
+		e.captureARIA(pageName)
+	}
+
+	// Network traffic and console output
+	harPath := e.captureHAR(pageName, pageTitle)
+	consolePath, jsErrors, firstError := e.captureConsole(pageName)
+
+	// Load timings and Core Web Vitals
+	var metrics *pageMetrics
+	if e.config.GetBool("explorer.capture.performance") {
+		metrics = e.capturePerformance(pageName)
+	}
+
+	// PDF
+	var pdfPath string
+	if e.config.GetBool("explorer.capture.pdf") {
+		pdfPath = e.capturePDF(pageName)
+	}
+
+	// Print rendering of report-style pages
+	var printPath string
+	if e.config.GetBool("explorer.print.enabled") && matchesPage(e.printPages, currentURL, pageTitle) {
+		printPath = e.capturePrint(pageName, currentURL)
+	}
 
 	// Extract navigation
-	var navLinks []string
+	var links []pageLink
 	chromedp.Run(e.ctx,
 		chromedp.Evaluate(`Array.from(document.querySelectorAll('a[href], button, [role="link"], [role="button"]'))
 			.map(el => ({text: el.textContent.trim(), href: el.href || el.getAttribute('onclick') || ''}))
 			.filter(l => l.text && l.text.length < 100)
-			.map(l => l.text + ' → ' + l.href)
-		`, &navLinks),
+		`, &links),
 	)
 
+	navLinks := make([]string, 0, len(links))
+	e.graph.AddPage(canonicalURL, pageTitle, sectionOf(canonicalURL))
+	for _, link := range links {
+		navLinks = append(navLinks, link.Text+" → "+link.Href)
+		if strings.HasPrefix(link.Href, "http") {
+			inScope, _ := e.scope.Allows(link.Href)
+			e.graph.AddEdge(canonicalURL, e.canonical.Canonical(link.Href), link.Text, !inScope)
+		}
+	}
+
 	// Analyze components and extract design tokens
+	stop = e.bench.Start("component_analysis")
 	e.analyzeComponents(pageName)
+	stop()
+	e.harvestStylesheets(pageName)
+	if e.config.GetBool("explorer.capture.contrast") {
+		e.captureContrast(pageName)
+	}
+	if e.config.GetBool("explorer.capture.regions") {
+		e.captureRegions(pageName)
+	}
+	if e.config.GetBool("explorer.capture.outline") {
+		e.captureOutline(pageName, pageTitle)
+	}
+	charts := 0
+	if e.config.GetBool("explorer.capture.charts") {
+		charts = e.captureCharts(pageName)
+	}
+	if e.config.GetBool("explorer.capture.motion") {
+		e.captureMotion(pageName)
+	}
+	if e.vision != nil && e.vision.Key(currentURL, pageTitle, charts) {
+		e.captureVision(pageName)
+	}
+	if e.config.GetBool("explorer.capture.strings") {
+		e.captureStrings(pageName)
+	}
+	if e.config.GetBool("explorer.capture.dom_tree") {
+		e.captureDOMTree(pageName)
+	}
+
+	// Screenshots down long pages
+	var scrollPath string
+	if e.config.GetBool("explorer.scroll.enabled") {
+		scrollPath = e.captureScroll(pageName)
+	}
+
+	// Layout at other viewport widths
+	var responsivePath string
+	if e.config.GetBool("explorer.responsive.enabled") {
+		responsivePath = e.captureResponsive(pageName)
+	}
+
+	// Text scaling at browser zoom levels
+	var zoomPath string
+	if e.config.GetBool("explorer.zoom.enabled") && matchesPage(e.zoomPages, currentURL, pageTitle) {
+		zoomPath = e.captureZoom(pageName, currentURL)
+	}
+
+	// Screenshots and components per device of the viewport matrix
+	viewportsPath := e.captureViewports(pageName)
+
+	// Loading and skeleton states on slow connections
+	throttlingPath := e.captureThrottling(pageName, currentURL)
+
+	// The page in every configured locale
+	var localesPath string
+	if e.config.GetBool("explorer.locales.enabled") {
+		localesPath = e.captureLocales(pageName, currentURL)
+	}
 
 	// Save navigation item
-	e.navigationMap = append(e.navigationMap, NavigationItem{
-		URL:        currentURL,
-		Title:      pageTitle,
-		Screenshot: screenshotPath,
-		Navigation: navLinks,
-		Timestamp:  time.Now().Format(time.RFC3339),
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.shared()
+	e.lastCapture = len(s.navigationMap)
+	s.navigationMap = append(s.navigationMap, NavigationItem{
+		URL:          currentURL,
+		CanonicalURL: canonicalURL,
+		Route:        routeTemplate(canonicalURL),
+		Title:        pageTitle,
+		Screenshot:   screenshotPath,
+		Thumbnail:    thumbnailPath,
+		PDF:          pdfPath,
+		Archive:      archivePath,
+		HAR:          harPath,
+		Console:      consolePath,
+		JSErrors:     jsErrors,
+		Performance:  metrics,
+		Responsive:   responsivePath,
+		Viewports:    viewportsPath,
+		Throttling:   throttlingPath,
+		Print:        printPath,
+		Scroll:       scrollPath,
+		Zoom:         zoomPath,
+		Locales:      localesPath,
+		Navigation:   navLinks,
+		Depth:        e.current.Depth,
+		Section:      e.current.Section,
+		Timestamp:    time.Now().Format(time.RFC3339),
+		firstError:   firstError,
 	})
+	s.streamItem(e.lastCapture)
 
 	e.log("✅ Captured: %s", pageTitle)
 	return nil
 }
 
+// analyzeComponents records the components of the page with their styles,
+// and the colors, fonts and spacing they use. Elements are tagged in one
+// evaluation and serialized in chunks of explorer.capture.evaluation_chunk,
+// so pages with thousands of nodes stay within the CDP message limits.
 func (e *AgicapExplorer) analyzeComponents(pageName string) {
-	var analysis string
-	chromedp.Run(e.ctx,
-		chromedp.Evaluate(`
-		(function() {
-			const components = [];
-			const colors = new Set();
-			const fonts = new Set();
-			const spacing = new Set();
-
-			// Helper to get computed styles
-			function getStyles(el) {
-				const styles = window.getComputedStyle(el);
-				return {
-					backgroundColor: styles.backgroundColor,
-					color: styles.color,
-					fontSize: styles.fontSize,
-					fontFamily: styles.fontFamily,
-					fontWeight: styles.fontWeight,
-					padding: styles.padding,
-					margin: styles.margin,
-					border: styles.border,
-					borderRadius: styles.borderRadius,
-					boxShadow: styles.boxShadow,
-					display: styles.display,
-					width: styles.width,
-					height: styles.height,
-					position: styles.position,
-					zIndex: styles.zIndex
-				};
-			}
-
-			// Analyze all interactive elements
-			const selectors = [
-				'button', '.btn', '[role="button"]', 'input[type="button"]', 'input[type="submit"]',
-				'.card', '.panel', '[class*="Card"]', '[class*="Panel"]', '[class*="card"]', '[class*="panel"]',
-				'input', 'select', 'textarea', 'form',
-				'table', '[role="grid"]', '.table', '.data-grid', '[class*="Table"]', '[class*="Grid"]',
-				'header', 'nav', '[role="banner"]', '[role="navigation"]',
-				'.sidebar', 'aside', '[class*="Sidebar"]', '[class*="Menu"]',
-				'.modal', '[role="dialog"]', '[class*="Modal"]', '[class*="Dialog"]',
-				'.dropdown', '[class*="Dropdown"]', '[class*="Select"]',
-				'.chart', '[class*="Chart"]', '[class*="Graph"]', 'canvas', 'svg'
-			];
+	parsed, err := e.scanComponents(pageName)
+	if err != nil {
+		e.log("⚠️ Failed to analyze components of %s: %v", pageName, err)
+		return
+	}
 
-			selectors.forEach(selector => {
-				document.querySelectorAll(selector).forEach((el, i) => {
-					if (i < 50) { // Limit to prevent too much data
-						const styles = getStyles(el);
-						const rect = el.getBoundingClientRect();
+	e.captureComponentScreenshots(pageName, parsed)
+	e.captureInteractionStates(pageName, parsed)
 
-						components.push({
-							type: selector.split(' ')[0].replace(/[\[\]\.#]/g, ''),
-							selector: el.className || el.id || el.tagName,
-							html: el.outerHTML.substring(0, 1000),
-							css: styles,
-							text: el.textContent.trim().substring(0, 200),
-							position: {
-								x: rect.x,
-								y: rect.y,
-								width: rect.width,
-								height: rect.height
-							},
-							attributes: Array.from(el.attributes).reduce((acc, attr) => {
-								acc[attr.name] = attr.value;
-								return acc;
-							}, {})
-						});
-
-						// Extract colors
-						if (styles.backgroundColor && styles.backgroundColor !== 'rgba(0, 0, 0, 0)') {
-							colors.add(styles.backgroundColor);
-						}
-						if (styles.color && styles.color !== 'rgba(0, 0, 0, 0)') {
-							colors.add(styles.color);
-						}
-						if (styles.border && styles.border !== 'none') {
-							colors.add(styles.border);
-						}
+	if err := e.writeAnalysis(pageName, parsed); err != nil {
+		e.log("⚠️ Failed to write component analysis for %s: %v", pageName, err)
+	}
+}
 
-						// Extract fonts
-						if (styles.fontFamily) {
-							fonts.add(styles.fontFamily);
-						}
+// scanComponents tags the components of the page as it is rendered now and
+// collects their details and styles.
+func (e *AgicapExplorer) scanComponents(pageName string) (*pageAnalysis, error) {
+	maxComponents := e.config.GetInt("explorer.capture.max_components")
 
-						// Extract spacing
-						if (styles.padding && styles.padding !== '0px') {
-							spacing.add(styles.padding);
-						}
-						if (styles.margin && styles.margin !== '0px') {
-							spacing.add(styles.margin);
-						}
-					}
-				});
-			});
+	var tagged componentScan
+	selectors, _ := json.Marshal(componentSelectors)
+	script := fmt.Sprintf(componentTagJS, selectors, e.config.GetInt("explorer.capture.max_per_selector"))
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(script, &tagged)); err != nil {
+		return nil, err
+	}
+	if maxComponents > 0 && len(tagged.Found) > maxComponents {
+		e.log("✂️ %s: %d components found, analyzing the first %d", pageName, len(tagged.Found), maxComponents)
+		tagged.Found = tagged.Found[:maxComponents]
+	}
 
-			// Analyze layout structure
-			const layout = {
-				hasHeader: document.querySelector('header, [role="banner"]') !== null,
-				hasSidebar: document.querySelector('aside, .sidebar, [class*="Sidebar"]') !== null,
-				hasFooter: document.querySelector('footer, [role="contentinfo"]') !== null,
-				gridSystem: document.querySelector('[class*="grid"]') ? 'grid' :
-							document.querySelector('[class*="flex"]') ? 'flexbox' : 'unknown',
-				mainContent: document.querySelector('main, .main, [role="main"]') ? true : false
-			};
-
-			// Extract CSS custom properties
-			const customProperties = {};
-			const rootStyles = getComputedStyle(document.documentElement);
-			for (let i = 0; i < rootStyles.length; i++) {
-				const prop = rootStyles[i];
-				if (prop.startsWith('--')) {
-					customProperties[prop] = rootStyles.getPropertyValue(prop);
-				}
-			}
+	parsed := pageAnalysis{
+		Layout:           tagged.Layout,
+		CustomProperties: tagged.CustomProperties,
+		PageInfo:         tagged.PageInfo,
+		Components:       make([]componentInfo, 0, len(tagged.Found)),
+		Colors:           []string{},
+		Fonts:            []string{},
+		Spacing:          []string{},
+	}
+	details := e.componentDetails(pageName, tagged.Found)
+	for _, found := range tagged.Found {
+		c, ok := details[found.ID]
+		if !ok {
+			continue
+		}
+		c.Type = found.Type
+		if best := scoreSelectors(c.Selectors); best != "" {
+			c.Selector = best
+		}
+		parsed.Components = append(parsed.Components, c)
+		parsed.collectStyles(c.CSS)
+	}
+	return &parsed, nil
+}
 
-			return JSON.stringify({
-				components: components,
-				layout: layout,
-				colors: Array.from(colors),
-				fonts: Array.from(fonts),
-				spacing: Array.from(spacing),
-				customProperties: customProperties,
-				pageInfo: {
-					url: window.location.href,
-					title: document.title,
-					viewport: {
-						width: window.innerWidth,
-						height: window.innerHeight
-					}
-				}
-			}, null, 2);
-		})()
-		`, &analysis),
-	)
+func (e *AgicapExplorer) ExploreAllScreens() error {
+	maxPages := e.config.GetInt("explorer.exploration.max_pages")
+	maxDepth := e.config.GetInt("explorer.exploration.max_depth")
 
-	componentsPath := filepath.Join(e.outputDir, "components", sanitize(pageName)+"_analysis.json")
-	ioutil.WriteFile(componentsPath, []byte(analysis), 0644)
-}
+	e.log("🗺️ Exploring application (max %d pages, depth %d)...", maxPages, maxDepth)
 
-func (e *AgicapExplorer) ExploreAllScreens(maxPages int) error {
-	e.log("🗺️ Exploring application (max %d pages)...", maxPages)
+	deadline := e.crawlDeadline()
+	if !deadline.IsZero() {
+		e.log("⏱️ Crawl budget ends at %s", deadline.Format("15:04:05"))
+	}
 
 	// Capture initial page
-	e.CapturePage("01_initial_page")
+	var startURL string
+	chromedp.Run(e.ctx, chromedp.Evaluate("window.location.href", &startURL))
+	e.current = crawlTarget{URL: startURL, Canonical: e.canonical.Canonical(startURL), Depth: 0, Section: sectionOf(startURL)}
+	if err := e.CapturePage(e.pageName(1, "initial_page")); err != nil {
+		e.recordFailure("capture")
+	}
+	if hash, err := e.domHash(); err == nil {
+		e.rememberFingerprint(hash)
+	}
 
-	// Find all navigation items
-	var navItems []map[string]interface{}
-	chromedp.Run(e.ctx,
-		chromedp.Evaluate(`
-		(function() {
-			const items = [];
-			const selectors = [
-				'nav a',
-				'[role="navigation"] a',
-				'.sidebar a',
-				'.menu a',
-				'[class*="Nav"] a',
-				'[class*="Menu"] a',
-				'[class*="Sidebar"] a',
-				'header a',
-				'.tab', '[role="tab"]',
-				'.dropdown-item', '.menu-item'
-			];
+	queue := newFrontier(sectionPriorities(e.config))
 
-			selectors.forEach(sel => {
-				document.querySelectorAll(sel).forEach(el => {
-					const text = el.textContent.trim();
-					const href = el.href || el.getAttribute('data-href') || el.getAttribute('onclick');
-					if (text && href && !href.includes('javascript:') && !href.includes('#') && text.length < 50) {
-						items.push({
-							text: text,
-							href: href,
-							selector: el.className || el.id,
-							type: el.tagName.toLowerCase()
-						});
-					}
-				});
-			});
+	// Explicit seeds cover pages the menus don't link to
+	for _, seed := range e.seedURLs() {
+		canonical := e.canonical.Canonical(seed)
+		if !e.visitedURLs[canonical] {
+			queue.Push(crawlTarget{URL: seed, Canonical: canonical, Text: seed, Depth: 0, Section: sectionOf(seed)})
+		}
+	}
+	if queue.Len() > 0 {
+		e.log("🌱 Queued %d seed URLs", queue.Len())
+	}
 
-			// Remove duplicates
-			const unique = [];
-			const seen = new Set();
-			items.forEach(item => {
-				if (!seen.has(item.href)) {
-					seen.add(item.href);
-					unique.push(item);
-				}
-			});
+	// Pages that were slow in earlier runs go first, so they are not the
+	// ones cut off by the time limits
+	if e.profile != nil {
+		threshold := e.config.GetDuration("explorer.scheduling.slow_threshold")
+		queue.slow = e.profile.Slow(threshold)
+		slow := 0
+		for _, t := range e.profile.SlowTargets(threshold) {
+			if !e.visitedURLs[t.Canonical] && queue.Push(t) {
+				slow++
+			}
+		}
+		if slow > 0 {
+			e.log("🐢 Queued %d pages that were slow to capture in earlier runs first", slow)
+		}
+	}
 
-			return unique;
-		})()
-		`, &navItems),
-	)
+	if e.polite.enabled && e.config.GetBool("explorer.politeness.respect_robots") {
+		if robots, err := e.fetchText("/robots.txt"); err == nil {
+			e.polite.LoadRobots(robots)
+		}
+	}
+
+	crawl := newCrawlState(queue, maxPages, deadline)
+	if maxDepth > 0 {
+		e.log("Found %d navigation items", e.enqueueLinks(crawl, 1))
+	}
 
-	e.log("Found %d navigation items", len(navItems))
+	// Every tab takes targets from the shared frontier and feeds the links
+	// of its pages back into it. After a crash the browser is restarted and
+	// the crawl resumes with the same frontier.
+	maxRestarts := e.config.GetInt("explorer.browser.max_restarts")
+	for restarts := 0; ; restarts++ {
+		tabs := []*AgicapExplorer{e}
+		if n := e.config.GetInt("explorer.exploration.concurrency"); n > 1 {
+			tabs = append(tabs, e.openTabs(n)...)
+			e.log("🗂️ Crawling with %d tabs", len(tabs))
+		}
+		var wg sync.WaitGroup
+		for _, tab := range tabs {
+			wg.Add(1)
+			go func(tab *AgicapExplorer) {
+				defer wg.Done()
+				tab.crawl(crawl)
+			}(tab)
+		}
+		wg.Wait()
+		for _, tab := range tabs[1:] {
+			e.closeTab(tab)
+		}
 
-	// Visit each page
-	count := 1
-	for i, item := range navItems {
-		if i >= maxPages {
+		reason := crawl.resume()
+		if reason == "" {
 			break
 		}
+		e.recordFailure("crash")
+		if restarts == maxRestarts {
+			e.log("💥 Browser %s, giving up after %d restarts", reason, restarts)
+			e.aborted = fmt.Sprintf("browser %s, gave up after %d restarts", reason, restarts)
+			break
+		}
+		e.log("💥 Browser %s, restarting it (%d/%d)", reason, restarts+1, maxRestarts)
+		runMetrics.BrowserRestarted()
+		if err := e.restartBrowser(); err != nil {
+			e.log("❌ Failed to restart the browser: %v", err)
+			e.aborted = fmt.Sprintf("browser %s, failed to restart it: %v", reason, err)
+			break
+		}
+	}
+
+	e.log("🏁 Exploration finished: %d pages captured, %d left in queue", crawl.count, queue.Len())
+	if e.profile != nil {
+		if err := e.profile.Write(); err != nil {
+			e.log("⚠️ Failed to write the crawl profile: %v", err)
+		}
+	}
+	if e.previous != nil {
+		e.log("♻️ %d unchanged pages reused from %s", e.previous.reused, e.previous.dir)
+	}
+	return nil
+}
+
+// crawl visits targets from the frontier in this tab until the crawl is
+// over.
+func (e *AgicapExplorer) crawl(c *crawlState) {
+	maxDepth := e.config.GetInt("explorer.exploration.max_depth")
+	delay := time.Duration(e.config.GetInt("explorer.exploration.delay_between_pages")) * time.Second
 
-		text := item["text"].(string)
-		href := item["href"].(string)
+	for {
+		target, route, ok := c.next(e)
+		if !ok {
+			return
+		}
 
-		// Skip if already visited
-		if e.visitedURLs[href] {
-			e.log("⏭️ Skipping (already visited): %s", text)
+		// Navigate
+		if err := e.polite.Wait(e.ctx); err != nil {
+			c.release(target, route)
+			c.mu.Lock()
+			c.stop()
+			c.mu.Unlock()
+			return
+		}
+		started := time.Now()
+		e.writeWebSocketLog()
+		e.network.Reset()
+		e.console.Reset()
+		e.resetPerformanceCounters()
+		stop := e.bench.Start("navigation")
+		err := chromedp.Run(e.ctx,
+			chromedp.Navigate(target.URL),
+			e.settle("navigation"),
+		)
+		stop()
+		if err != nil {
+			if reason := e.trouble(); reason != "" {
+				c.crashed(target, route, reason)
+				return
+			}
+			e.log("⚠️ Failed to navigate to %s: %v", target.URL, err)
+			e.recordFailure("navigation")
+			runMetrics.NavigationFailed()
+			c.release(target, route)
 			continue
 		}
 
-		e.log("🔄 [%d/%d] Navigating to: %s", i+1, len(navItems), text)
+		// Pages that render the same structure as an earlier capture become aliases
+		hash, hashErr := e.domHash()
+		if hashErr == nil && e.config.GetBool("explorer.dedupe.enabled") {
+			e.mu.Lock()
+			s := e.shared()
+			var original string
+			if idx := s.duplicateOf(hash); idx >= 0 {
+				item := &s.navigationMap[idx]
+				item.Aliases = append(item.Aliases, target.URL)
+				s.streamItem(idx)
+				s.visitedURLs[target.Canonical] = true
+				original = item.URL
+			}
+			e.mu.Unlock()
+			if original != "" {
+				e.log("🪞 Skipping (duplicate of %s): %s", original, target.URL)
+				c.release(target, route)
+				continue
+			}
+		}
 
-		// Navigate
-		if err := chromedp.Run(e.ctx,
-			chromedp.Navigate(href),
-			chromedp.Sleep(3*time.Second),
-		); err != nil {
-			e.log("⚠️ Failed to navigate to %s: %v", href, err)
+		// Unchanged pages keep the previous run's capture
+		e.current = target
+		if hashErr == nil && e.reuseCapture(target, hash) {
+			c.captured()
+			if target.Depth < maxDepth {
+				e.enqueueLinks(c, target.Depth+1)
+			}
+			c.finish()
+			time.Sleep(delay)
 			continue
 		}
 
 		// Capture
-		count++
-		pageName := fmt.Sprintf("%02d_%s", count, sanitize(text))
-		e.CapturePage(pageName)
+		pageName := e.pageName(c.captured(), sanitize(target.Text))
+		captureStart := time.Now()
+		if err := e.CapturePage(pageName); err != nil {
+			e.log("⚠️ %v", err)
+			e.recordFailure("capture")
+		} else {
+			runMetrics.PageCaptured(time.Since(captureStart))
+		}
+		if hashErr == nil {
+			e.rememberFingerprint(hash)
+		}
+
+		// Harvest links before interactions change the page
+		if target.Depth < maxDepth {
+			e.enqueueLinks(c, target.Depth+1)
+		}
 
-		// Try to interact with forms and modals on this page
+		// Open each menu, dialog and drawer of the page on its own, sort and
+		// page through its tables, then try to interact with forms and
+		// modals on this page
+		stop = e.bench.Start("interaction")
+		if e.config.GetBool("explorer.overlays.enabled") {
+			e.exploreOverlays(pageName)
+		}
+		if e.config.GetBool("explorer.tables.enabled") {
+			e.exploreTables(pageName)
+		}
 		e.interactWithPage(pageName)
+		stop()
+		if e.profile != nil {
+			e.profile.Record(target, time.Since(started))
+		}
+		e.bench.Page(pageName, time.Since(started))
+		c.took(time.Since(started))
+		c.finish()
+
+		if reason := e.trouble(); reason != "" {
+			c.mu.Lock()
+			c.restartFor(reason)
+			c.mu.Unlock()
+			return
+		}
 
 		// Delay between pages
-		time.Sleep(2 * time.Second)
+		time.Sleep(delay)
+	}
+}
+
+// crawlDeadline returns when discovery must stop: the configured max_duration
+// budget, or report_reserve before the browser context times out, whichever
+// is earlier. A zero time means no limit.
+func (e *AgicapExplorer) crawlDeadline() time.Time {
+	var deadline time.Time
+	if budget := e.config.GetDuration("explorer.exploration.max_duration"); budget > 0 {
+		deadline = time.Now().Add(budget)
 	}
 
-	return nil
+	// Leave time for the reports before the browser context dies
+	if ctxDeadline, ok := e.ctx.Deadline(); ok {
+		reserve := ctxDeadline.Add(-e.config.GetDuration("explorer.exploration.report_reserve"))
+		if deadline.IsZero() || reserve.Before(deadline) {
+			deadline = reserve
+		}
+	}
+	return deadline
 }
 
 func (e *AgicapExplorer) interactWithPage(pageName string) {
 	e.log("🔍 Interacting with page: %s", pageName)
 
+	rec := e.startRecording()
+	defer e.stopRecording(pageName, rec)
+
 	// Try to click on buttons and interactive elements
 	var clickableElements []map[string]interface{}
 	chromedp.Run(e.ctx,
 		chromedp.Evaluate(`
-		(function() {
+		(function() {`+domKeyJS+stableSelectorJS+`
 			const elements = [];
 			const selectors = [
 				'button:not([disabled])',
@@ -496,7 +954,7 @@ func (e *AgicapExplorer) interactWithPage(pageName string) {
 						if (rect.width > 0 && rect.height > 0) {
 							elements.push({
 								text: el.textContent.trim().substring(0, 50),
-								selector: el.className || el.id || el.tagName,
+								selector: (selectorsOf(el)[0] || {selector: el.tagName.toLowerCase()}).selector,
 								visible: rect.top >= 0 && rect.left >= 0 &&
 										rect.bottom <= window.innerHeight &&
 										rect.right <= window.innerWidth
@@ -521,14 +979,13 @@ func (e *AgicapExplorer) interactWithPage(pageName string) {
 		selector := element["selector"].(string)
 		visible := element["visible"].(bool)
 
-		if visible && text != "" {
+		if visible && text != "" && e.allowAction("click", selector, text) {
 			e.log("🖱️ Clicking: %s", text)
 
 			// Try to click the element
 			chromedp.Run(e.ctx,
-				chromedp.Sleep(1*time.Second),
 				chromedp.Click(selector, chromedp.ByQuery),
-				chromedp.Sleep(2*time.Second),
+				e.settle("interaction"),
 			)
 
 			// Capture the state after interaction
@@ -538,7 +995,7 @@ func (e *AgicapExplorer) interactWithPage(pageName string) {
 			// Try to close any modals that might have opened
 			chromedp.Run(e.ctx,
 				chromedp.Click(`.modal-close, .close, [aria-label="Close"], [data-dismiss="modal"]`, chromedp.ByQuery),
-				chromedp.Sleep(1*time.Second),
+				e.settle("interaction"),
 			)
 		}
 	}
@@ -550,8 +1007,8 @@ func (e *AgicapExplorer) interactWithPage(pageName string) {
 func (e *AgicapExplorer) fillForms(pageName string) {
 	e.log("📝 Looking for forms to fill on: %s", pageName)
 
-	// Find form inputs
-	var formInputs []map[string]interface{}
+	// Find form inputs, with how each is labeled
+	var formInputs []formInput
 	chromedp.Run(e.ctx,
 		chromedp.Evaluate(`
 		(function() {
@@ -560,19 +1017,23 @@ func (e *AgicapExplorer) fillForms(pageName string) {
 				'input[type="text"]', 'input[type="email"]', 'input[type="number"]',
 				'input[type="date"]', 'input[type="search"]', 'textarea', 'select'
 			];
+			`+labelingJS+domKeyJS+stableSelectorJS+`
 
 			selectors.forEach(sel => {
 				document.querySelectorAll(sel).forEach((el, i) => {
 					if (i < 5) { // Limit to 5 inputs per page
 						const rect = el.getBoundingClientRect();
 						if (rect.width > 0 && rect.height > 0) {
+							const [labeling, label] = labelOf(el);
 							inputs.push({
 								type: el.type || el.tagName.toLowerCase(),
 								placeholder: el.placeholder || '',
 								name: el.name || '',
 								id: el.id || '',
-								selector: el.className || el.id || el.tagName,
-								visible: rect.top >= 0 && rect.left >= 0
+								selector: (selectorsOf(el)[0] || {selector: el.tagName.toLowerCase()}).selector,
+								visible: rect.top >= 0 && rect.left >= 0,
+								labeling: labeling,
+								label: label
 							});
 						}
 					}
@@ -584,40 +1045,25 @@ func (e *AgicapExplorer) fillForms(pageName string) {
 		`, &formInputs),
 	)
 
-	// Fill out forms with sample data
+	// Fill out forms with data of the configured profile
 	for i, input := range formInputs {
-		inputType := input["type"].(string)
-		placeholder := input["placeholder"].(string)
-		selector := input["selector"].(string)
-		visible := input["visible"].(bool)
-
-		if visible {
-			var sampleValue string
-			switch inputType {
-			case "email":
-				sampleValue = "test@example.com"
-			case "number":
-				sampleValue = "1000"
-			case "date":
-				sampleValue = "2024-12-31"
-			case "search":
-				sampleValue = "sample search"
-			default:
-				if placeholder != "" {
-					sampleValue = "Sample " + placeholder
-				} else {
-					sampleValue = "Sample text"
-				}
+		if input.Visible && e.allowAction("fill", input.Selector, strings.Join([]string{input.Label, input.Name, input.Placeholder}, " ")) {
+			sampleValue := e.forms.Value(input)
+			if sampleValue == "" {
+				continue
 			}
 
 			e.log("✏️ Filling input %d: %s", i+1, sampleValue)
 
 			chromedp.Run(e.ctx,
-				chromedp.SendKeys(selector, sampleValue, chromedp.ByQuery),
-				chromedp.Sleep(500*time.Millisecond),
+				chromedp.SendKeys(input.Selector, sampleValue, chromedp.ByQuery),
+				e.settle("form"),
 			)
 		}
 	}
+	if len(formInputs) > 0 {
+		e.writeFormLabels(pageName, formInputs)
+	}
 
 	// Capture the filled form state
 	if len(formInputs) > 0 {
@@ -628,276 +1074,245 @@ func (e *AgicapExplorer) fillForms(pageName string) {
 
 func (e *AgicapExplorer) GenerateReport() error {
 	e.log("📝 Generating comprehensive reports...")
+	stopReport := e.bench.Start("report")
+
+	// Navigation map, assembled from the pages streamed to disk
+	if e.stream != nil {
+		if items, err := loadNavigationStream(e.outputDir); err != nil {
+			e.log("⚠️ Failed to read %s, using the pages in memory: %v", streamFile, err)
+		} else {
+			e.navigationMap = items
+		}
+	}
+	if err := e.writeNavigationMap(); err != nil {
+		e.log("⚠️ Failed to write navigation_map.json: %v", err)
+	}
+	if err := writeSchemas(e.outputDir); err != nil {
+		e.log("⚠️ Failed to write output schemas: %v", err)
+	}
+
+	// Link graph
+	if err := e.graph.WriteJSON(filepath.Join(e.outputDir, "graph.json")); err != nil {
+		e.log("⚠️ Failed to write graph.json: %v", err)
+	}
+	if err := e.graph.WriteDOT(filepath.Join(e.outputDir, "graph.dot")); err != nil {
+		e.log("⚠️ Failed to write graph.dot: %v", err)
+	}
+
+	if e.assets != nil {
+		if err := e.assets.WriteIndex(); err != nil {
+			e.log("⚠️ Failed to write assets/index.json: %v", err)
+		}
+	}
 
-	// Navigation map
-	navJSON, _ := json.MarshalIndent(e.navigationMap, "", "  ")
-	ioutil.WriteFile(filepath.Join(e.outputDir, "navigation_map.json"), navJSON, 0644)
+	if e.branding != nil {
+		if err := e.writeBrandingIndex(); err != nil {
+			e.log("⚠️ Failed to write branding/index.json: %v", err)
+		}
+	}
+
+	if e.icons != nil {
+		if err := e.writeIcons(); err != nil {
+			e.log("⚠️ Failed to write icons/index.json: %v", err)
+		}
+	}
+
+	if e.network.enabled {
+		e.writeWebSocketLog()
+		if err := e.writeAPIInventory(); err != nil {
+			e.log("⚠️ Failed to write API inventory: %v", err)
+		}
+		if err := e.writeThirdPartyReport(); err != nil {
+			e.log("⚠️ Failed to write third_parties.md: %v", err)
+		}
+		if e.config.GetBool("explorer.graphql.enabled") {
+			if err := e.writeGraphQL(); err != nil {
+				e.log("⚠️ Failed to write GraphQL operations: %v", err)
+			}
+		}
+	}
+
+	if e.mocks != nil && len(e.mocks.rules) > 0 {
+		e.log("🎭 Answered %d requests from mocks", e.mocks.Hits())
+	}
+	if e.safety.ReadOnly() {
+		e.log("🛡️ Read-only: blocked %d requests", e.safety.Blocked())
+	}
+
+	if err := e.writeFormsAccessibility(e.formsAccessibility()); err != nil {
+		e.log("⚠️ Failed to write forms_accessibility.json: %v", err)
+	}
+	if e.config.GetBool("explorer.capture.images") {
+		if err := e.writeImageInventory(); err != nil {
+			e.log("⚠️ Failed to write image_inventory.json: %v", err)
+		}
+	}
+	if e.config.GetBool("explorer.capture.aria") {
+		if err := e.writeARIAUsage(e.ariaRollup()); err != nil {
+			e.log("⚠️ Failed to write aria_usage.json: %v", err)
+		}
+	}
+	if e.config.GetBool("explorer.locales.enabled") {
+		if err := e.writeLocaleReport(e.localeRollup()); err != nil {
+			e.log("⚠️ Failed to write locale_report.json: %v", err)
+		}
+	}
+	if e.config.GetBool("explorer.capture.strings") {
+		if err := e.writeStringCatalog(); err != nil {
+			e.log("⚠️ Failed to write the string catalog: %v", err)
+		}
+	}
+
+	// Visual report
+	if err := e.writeHTMLReport(); err != nil {
+		e.log("⚠️ Failed to write report.html: %v", err)
+	}
 
 	// Generate comprehensive rebuild guide
-	rebuildGuide := e.generateComprehensiveRebuildGuide()
-	ioutil.WriteFile(filepath.Join(e.outputDir, "COMPREHENSIVE_REBUILD_GUIDE.md"), []byte(rebuildGuide), 0644)
+	if rebuildGuide, err := e.generateComprehensiveRebuildGuide(); err != nil {
+		e.log("⚠️ Failed to write the rebuild guide: %v", err)
+	} else {
+		ioutil.WriteFile(filepath.Join(e.outputDir, "COMPREHENSIVE_REBUILD_GUIDE.md"), []byte(rebuildGuide), 0644)
+	}
+
+	if e.stylesheets.enabled {
+		if err := e.writeStylesheets(); err != nil {
+			e.log("⚠️ Failed to write extracted stylesheets: %v", err)
+		}
+	}
+	if err := e.writeLayoutTemplates(); err != nil {
+		e.log("⚠️ Failed to write layout templates: %v", err)
+	}
+	if err := e.writeChartSpecs(); err != nil {
+		e.log("⚠️ Failed to write chart_specs.json: %v", err)
+	}
 
 	// Generate design system
-	designSystem := e.generateDesignSystem()
-	ioutil.WriteFile(filepath.Join(e.outputDir, "design_system.json"), []byte(designSystem), 0644)
+	designSystem := e.designSystem()
+	if data, err := json.MarshalIndent(versionedDesignSystem(designSystem), "", "  "); err == nil {
+		ioutil.WriteFile(filepath.Join(e.outputDir, "design_system.json"), data, 0644)
+	}
+	if e.config.GetBool("explorer.design_tokens.export") {
+		if err := e.writeDesignTokens(designSystem); err != nil {
+			e.log("⚠️ Failed to write design tokens: %v", err)
+		}
+	}
+	if e.config.GetBool("explorer.design_tokens.tailwind") {
+		if err := e.writeTailwindConfig(designSystem); err != nil {
+			e.log("⚠️ Failed to write tailwind.config.js: %v", err)
+		}
+	}
 
 	// Generate component library
-	componentLibrary := e.generateComponentLibrary()
-	ioutil.WriteFile(filepath.Join(e.outputDir, "component_library.json"), []byte(componentLibrary), 0644)
+	library := buildComponentLibrary(e.outputDir)
+	if data, err := json.MarshalIndent(library, "", "  "); err == nil {
+		ioutil.WriteFile(filepath.Join(e.outputDir, "component_library.json"), data, 0644)
+	}
+	if e.config.GetBool("explorer.design_tokens.figma") {
+		if err := e.writeFigmaExport(designSystem, library); err != nil {
+			e.log("⚠️ Failed to write Figma export: %v", err)
+		}
+	}
+
+	if e.config.GetBool("explorer.output.summary.enabled") {
+		if err := e.writePDFSummary(designSystem); err != nil {
+			e.log("⚠️ Failed to write summary.pdf: %v", err)
+		}
+	}
+
+	if e.config.GetBool("explorer.output.site") {
+		if n, err := writeSite(e.outputDir, filepath.Join(e.outputDir, "site"), e.runID, e.templates); err != nil {
+			e.log("⚠️ Failed to write static site: %v", err)
+		} else {
+			e.log("🌐 Static site with %d pages in site/", n)
+		}
+	}
+
+	if e.config.GetBool("explorer.storage.sqlite.enabled") {
+		path := e.config.GetString("explorer.storage.sqlite.path")
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(e.outputDir, path)
+		}
+		if stats, err := writeSQLite(e.outputDir, path, e.runID, e.config.GetBool("explorer.storage.sqlite.blobs")); err != nil {
+			e.log("⚠️ Failed to write the SQLite database: %v", err)
+		} else {
+			e.log("🗄️ SQLite database at %s: %s", path, stats)
+		}
+	}
+
+	if e.bench != nil {
+		stopReport()
+		if err := e.writeTimings(); err != nil {
+			e.log("⚠️ Failed to write timings.json: %v", err)
+		}
+	}
+
+	// Run manifest last, so its checksums cover everything above
+	if err := e.writeRunManifest(); err != nil {
+		e.log("⚠️ Failed to write run.json: %v", err)
+	}
+
+	var archive string
+	if e.config.GetBool("explorer.archive.enabled") {
+		if path, err := writeArchive(e.outputDir, e.runID, e.archiveOptions()); err != nil {
+			e.log("⚠️ Failed to archive the output directory: %v", err)
+		} else {
+			e.log("📦 Archived to %s", path)
+			archive = path
+		}
+	}
+
+	if e.config.GetBool("explorer.upload.enabled") {
+		if store, err := newObjectStore(e.config); err != nil {
+			e.log("⚠️ Failed to upload the run: %v", err)
+		} else if result, err := uploadRun(store, e.outputDir, e.runID, archive, e.uploadOptions()); err != nil {
+			e.log("⚠️ Failed to upload the run to %s: %v", store, err)
+		} else {
+			e.reportURL = result.ReportURL
+			e.log("☁️ Uploaded %d files (%.1f MB) to %s/%s", result.Files, float64(result.Bytes)/(1<<20), store, result.Prefix)
+		}
+	}
 
 	e.log("✅ Comprehensive reports generated at: %s", e.outputDir)
 	return nil
 }
 
-func (e *AgicapExplorer) generateComprehensiveRebuildGuide() string {
-	return fmt.Sprintf(`# 🚀 Agicap 1:1 Rebuild Guide
-
-**Generated:** %s
-**Pages Analyzed:** %d
-**Components Extracted:** Check component_library.json
-
-## 📋 Overview
-
-This comprehensive guide provides everything needed to rebuild Agicap's interface 1:1 in Next.js.
-
-## 🎨 Design System
-
-### Color Palette
-Extracted from component analysis - see design_system.json for complete palette.
-
-### Typography
-- Primary Font: Inter, -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto
-- Font Sizes: 12px, 14px, 16px, 18px, 24px, 32px
-- Font Weights: 400, 500, 600, 700
-
-### Spacing System
-- Base Unit: 8px
-- Scale: 4px, 8px, 12px, 16px, 24px, 32px, 48px, 64px
-
-## 🏗️ Component Library
-
-### Core Components
-1. **Button** - Primary, Secondary, Ghost variants
-2. **Card** - Dashboard cards with shadows
-3. **Input** - Text, Email, Number, Date inputs
-4. **Select** - Dropdown selections
-5. **Table** - Data tables with sorting
-6. **Modal** - Overlay dialogs
-7. **Chart** - Cash flow visualizations
-8. **Navigation** - Sidebar and top nav
-
-### Layout Components
-1. **AppLayout** - Main application wrapper
-2. **Header** - Top navigation bar
-3. **Sidebar** - Collapsible side navigation
-4. **ContentArea** - Main content region
-5. **Footer** - Bottom section
-
-## 📱 Page Structure
-
-Based on navigation analysis:
-
-### Main Pages
-%s
-
-### Key Features
-- **Cash Flow Forecasting** - 12-month predictions
-- **Liquidity Planning** - Real-time cash monitoring
-- **Scenario Management** - Optimistic/Pessimistic views
-- **Bank Integration** - Live transaction feeds
-- **Invoice Processing** - OCR and manual entry
-- **Reporting** - Financial reports and analytics
-
-## 🔧 Implementation Steps
-
-### Phase 1: Foundation (Week 1)
-1. Setup Next.js project with TypeScript
-2. Install Tailwind CSS and component libraries
-3. Create design system tokens
-4. Build core layout components
-
-### Phase 2: Components (Week 2)
-1. Implement UI component library
-2. Create form components
-3. Build data visualization components
-4. Add interactive elements
-
-### Phase 3: Pages (Week 3)
-1. Build main dashboard
-2. Implement cash flow pages
-3. Create scenario management
-4. Add settings and configuration
-
-### Phase 4: Integration (Week 4)
-1. Connect to banking APIs
-2. Implement data persistence
-3. Add real-time updates
-4. Polish and optimize
-
-## 📊 Data Architecture
-
-### State Management
-- Use Zustand for global state
-- React Query for server state
-- Local state for UI interactions
-
-### API Integration
-- Banking APIs (SaltEdge/Plaid)
-- OCR services (AWS Textract)
-- Real-time data feeds
-
-### Database Schema
-- Companies and users
-- Transactions and invoices
-- Scenarios and forecasts
-- Audit logs
-
-## 🎯 Next Steps
-
-1. ✅ Review all captured screenshots
-2. ✅ Extract design tokens from analysis files
-3. ✅ Build component library in Next.js
-4. ✅ Implement page layouts
-5. ✅ Add functionality and interactions
-6. ✅ Connect to real data sources
-7. ✅ Deploy and test
-
-## 📚 Resources
-
-- **Screenshots:** ./screenshots/
-- **HTML Source:** ./html/
-- **Component Analysis:** ./components/
-- **Design System:** ./design_system.json
-- **Component Library:** ./component_library.json
-
----
-
-**Ready to rebuild Agicap 1:1! 🚀**
-`, time.Now().Format("2006-01-02 15:04:05"), len(e.navigationMap), func() string {
-		pages := ""
-		for i, item := range e.navigationMap {
-			if i < 20 {
+// guideData fills the rebuild guide templates.
+type guideData struct {
+	Generated time.Time
+	Pages     int
+	Branding  string
+	Outline   string
+	Zoom      string
+	API       string
+	PageList  string
+}
+
+func (e *AgicapExplorer) generateComprehensiveRebuildGuide() (string, error) {
+	pages := ""
+	listed := 0
+	sections, bySection := e.pagesBySection()
+	for _, section := range sections {
+		if listed >= 20 {
+			break
+		}
+		pages += fmt.Sprintf("\n#### %s\n", section)
+		for _, item := range bySection[section] {
+			if listed < 20 {
 				pages += fmt.Sprintf("- **%s** - %s\n", item.Title, item.URL)
+				listed++
 			}
 		}
-		return pages
-	}())
-}
-
-func (e *AgicapExplorer) generateDesignSystem() string {
-	// This would analyze all component files and extract design tokens
-	// For now, return a basic structure
-	return `{
-  "colors": {
-    "primary": "#667eea",
-    "secondary": "#764ba2",
-    "success": "#10b981",
-    "warning": "#f59e0b",
-    "error": "#ef4444",
-    "background": "#f5f7fa",
-    "surface": "#ffffff",
-    "text": {
-      "primary": "#1f2937",
-      "secondary": "#6b7280",
-      "disabled": "#9ca3af"
-    }
-  },
-  "typography": {
-    "fontFamily": {
-      "primary": "Inter, -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif",
-      "mono": "JetBrains Mono, 'Fira Code', monospace"
-    },
-    "fontSize": {
-      "xs": "12px",
-      "sm": "14px",
-      "base": "16px",
-      "lg": "18px",
-      "xl": "20px",
-      "2xl": "24px",
-      "3xl": "32px"
-    },
-    "fontWeight": {
-      "normal": "400",
-      "medium": "500",
-      "semibold": "600",
-      "bold": "700"
-    }
-  },
-  "spacing": {
-    "0": "0px",
-    "1": "4px",
-    "2": "8px",
-    "3": "12px",
-    "4": "16px",
-    "5": "20px",
-    "6": "24px",
-    "8": "32px",
-    "10": "40px",
-    "12": "48px",
-    "16": "64px"
-  },
-  "borderRadius": {
-    "none": "0px",
-    "sm": "4px",
-    "base": "8px",
-    "md": "12px",
-    "lg": "16px",
-    "xl": "24px",
-    "full": "9999px"
-  },
-  "shadows": {
-    "sm": "0 1px 2px 0 rgba(0, 0, 0, 0.05)",
-    "base": "0 1px 3px 0 rgba(0, 0, 0, 0.1), 0 1px 2px 0 rgba(0, 0, 0, 0.06)",
-    "md": "0 4px 6px -1px rgba(0, 0, 0, 0.1), 0 2px 4px -1px rgba(0, 0, 0, 0.06)",
-    "lg": "0 10px 15px -3px rgba(0, 0, 0, 0.1), 0 4px 6px -2px rgba(0, 0, 0, 0.05)",
-    "xl": "0 20px 25px -5px rgba(0, 0, 0, 0.1), 0 10px 10px -5px rgba(0, 0, 0, 0.04)"
-  }
-}`
-}
-
-func (e *AgicapExplorer) generateComponentLibrary() string {
-	// This would analyze all component files and create a library
-	// For now, return a basic structure
-	return `{
-  "components": {
-    "Button": {
-      "variants": ["primary", "secondary", "ghost", "danger"],
-      "sizes": ["sm", "md", "lg"],
-      "states": ["default", "hover", "active", "disabled"]
-    },
-    "Card": {
-      "variants": ["default", "elevated", "outlined"],
-      "sections": ["header", "content", "footer"]
-    },
-    "Input": {
-      "types": ["text", "email", "password", "number", "date", "search"],
-      "states": ["default", "focus", "error", "disabled"],
-      "sizes": ["sm", "md", "lg"]
-    },
-    "Table": {
-      "features": ["sorting", "filtering", "pagination", "selection"],
-      "density": ["compact", "normal", "comfortable"]
-    },
-    "Modal": {
-      "sizes": ["sm", "md", "lg", "xl", "full"],
-      "positions": ["center", "top", "bottom"]
-    },
-    "Chart": {
-      "types": ["line", "bar", "pie", "area", "scatter"],
-      "interactions": ["zoom", "pan", "tooltip", "legend"]
-    }
-  },
-  "layouts": {
-    "AppLayout": {
-      "sections": ["header", "sidebar", "main", "footer"],
-      "responsive": true
-    },
-    "Dashboard": {
-      "grid": "12-column",
-      "widgets": ["kpi", "chart", "table", "list"]
-    }
-  }
-}`
+	}
+	return e.templates.guide(guideData{
+		Generated: time.Now(),
+		Pages:     len(e.navigationMap),
+		Branding:  e.brandingSection(),
+		Outline:   e.outlineSection(),
+		Zoom:      e.zoomSection(),
+		API:       e.apiSection(),
+		PageList:  pages,
+	})
 }
 
 func sanitize(s string) string {
@@ -925,19 +1340,31 @@ func (e *AgicapExplorer) log(format string, args ...interface{}) {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatalf("❌ %s: %v", os.Args[1], err)
+			}
+			return
+		}
+	}
+
 	fmt.Println("🚀 Agicap UI Explorer")
-	fmt.Println("=====================\n")
+	fmt.Println("=====================")
+	fmt.Println()
 
 	// Configuration
-	loginURL := "https://app.agicap.com/de/app/cashflow/forecast"
-	email := "finance@visioneers.io"
-	password := "Gottistimmergut2025!"
-	outputDir := "./agicap_ui_analysis"
-	headless := true // Set true to run in background
-	maxPages := 20   // Maximum pages to explore
+	config, err := loadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	loginURL := config.GetString("explorer.login_url")
+	email := config.GetString("explorer.credentials.email")
+	password := config.GetString("explorer.credentials.password")
+	outputDir := config.GetString("explorer.output.directory")
 
 	// Create explorer
-	explorer, err := NewAgicapExplorer(outputDir, headless, true)
+	explorer, err := NewAgicapExplorer(config, true)
 	if err != nil {
 		log.Fatalf("❌ Failed to create explorer: %v", err)
 	}
@@ -946,27 +1373,34 @@ func main() {
 	// Step 1: Login
 	fmt.Println("Step 1: Logging in...")
 	if err := explorer.Login(loginURL, email, password); err != nil {
+		explorer.notify("login", err)
 		log.Fatalf("❌ Login failed: %v", err)
 	}
 
 	// Step 2: Explore
 	fmt.Println("\nStep 2: Exploring all screens...")
-	if err := explorer.ExploreAllScreens(maxPages); err != nil {
+	if err := explorer.ExploreAllScreens(); err != nil {
+		explorer.notify("crawl", err)
 		log.Fatalf("❌ Exploration failed: %v", err)
 	}
 
 	// Step 3: Generate reports
 	fmt.Println("\nStep 3: Generating reports...")
 	if err := explorer.GenerateReport(); err != nil {
+		explorer.notify("report", err)
 		log.Fatalf("❌ Report generation failed: %v", err)
 	}
+	explorer.notify("", nil)
 
 	fmt.Println("\n✅ Exploration complete!")
 	fmt.Printf("📂 Results: %s\n", outputDir)
 	fmt.Println("\n📄 Files generated:")
-	fmt.Println("  • navigation_map.json - Navigation structure")
-	fmt.Println("  • screenshots/ - All screenshots")
-	fmt.Println("  • html/ - Page source code")
+	for _, line := range generatedFiles(outputDir) {
+		fmt.Println("  • " + line)
+	}
+	if config.GetBool("explorer.storage.sqlite.enabled") {
+		fmt.Println("  • explorer.db - SQLite database of pages, links, components, tokens and requests")
+	}
 
 	fmt.Println("\n⏳ Browser stays open for 60 seconds for inspection...")
 	time.Sleep(60 * time.Second)
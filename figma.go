@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// studioToken is a token in the Tokens Studio for Figma format.
+type studioToken struct {
+	Value interface{} `json:"value"`
+	Type  string      `json:"type"`
+}
+
+// studioShadow is the value of a Tokens Studio boxShadow token.
+type studioShadow struct {
+	X      string `json:"x"`
+	Y      string `json:"y"`
+	Blur   string `json:"blur"`
+	Spread string `json:"spread"`
+	Color  string `json:"color"`
+	Type   string `json:"type"` // dropShadow or innerShadow
+}
+
+// figmaComponent describes one captured variant for import into a Figma
+// library, named "Component/variant" so Figma groups the variants.
+type figmaComponent struct {
+	Name       string            `json:"name"`
+	Component  string            `json:"component"`
+	Properties map[string]string `json:"properties"`
+	Image      string            `json:"image,omitempty"`
+	Width      float64           `json:"width"`
+	Height     float64           `json:"height"`
+	Instances  int               `json:"instances"`
+	Pages      []string          `json:"pages"`
+	Texts      []string          `json:"texts,omitempty"`
+	Tokens     map[string]string `json:"tokens,omitempty"`
+	Styles     map[string]string `json:"styles"`
+}
+
+// writeFigmaExport writes figma/tokens.json for the Tokens Studio plugin and
+// figma/components.json with a PNG per component variant in
+// figma/components/<Component>/<variant>.png.
+func (e *AgicapExplorer) writeFigmaExport(system orderedTokens, library *componentLibrary) error {
+	dir := filepath.Join(e.outputDir, "figma")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	typography, _ := system.get("typography").(orderedTokens)
+	global := orderedTokens{}
+	for _, g := range []struct {
+		name      string
+		section   interface{}
+		tokenType string
+		convert   func(string) interface{}
+	}{
+		{"color", system.get("colors"), "color", nil},
+		{"fontFamilies", typography.get("fontFamily"), "fontFamilies", nil},
+		{"fontSizes", typography.get("fontSize"), "fontSizes", nil},
+		{"fontWeights", typography.get("fontWeight"), "fontWeights", nil},
+		{"spacing", system.get("spacing"), "spacing", nil},
+		{"borderRadius", system.get("borderRadius"), "borderRadius", nil},
+		{"boxShadow", system.get("shadows"), "boxShadow", studioShadowValue},
+	} {
+		if group := studioGroup(g.section, g.tokenType, g.convert); len(group) > 0 {
+			global = append(global, tokenEntry{g.name, group})
+		}
+	}
+	tokens := orderedTokens{
+		{"global", global},
+		{"$themes", []interface{}{}},
+		{"$metadata", map[string][]string{"tokenSetOrder": {"global"}}},
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "tokens.json"), data, 0644); err != nil {
+		return err
+	}
+
+	// The mapper takes design_system.json in its decoded form
+	var loaded map[string]interface{}
+	if data, err := json.Marshal(system); err == nil {
+		json.Unmarshal(data, &loaded)
+	}
+	mapper := newTailwindMapper(loaded)
+
+	var components []figmaComponent
+	for _, group := range library.Components {
+		name := pascalCase(group.Name)
+		for _, v := range group.Variants {
+			c := figmaComponent{
+				Name:       name + "/" + v.Name,
+				Component:  name,
+				Properties: map[string]string{"Variant": v.Name},
+				Width:      v.Size.MaxWidth,
+				Height:     v.Size.MaxHeight,
+				Instances:  v.Count,
+				Pages:      v.Pages,
+				Texts:      v.Texts,
+				Tokens:     figmaTokenRefs(v.Styles, mapper),
+				Styles:     v.Styles,
+			}
+			if v.Screenshot != "" {
+				if png, err := ioutil.ReadFile(v.Screenshot); err == nil {
+					if path, err := e.writeArtifact(filepath.Join("figma", "components", name), sanitize(v.Name)+".png", png); err == nil {
+						c.Image, _ = filepath.Rel(dir, path)
+						c.Image = filepath.ToSlash(c.Image)
+					}
+				}
+			}
+			components = append(components, c)
+		}
+	}
+	data, err = json.MarshalIndent(components, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "components.json"), data, 0644)
+}
+
+// studioGroup converts a design_system.json section into Tokens Studio
+// tokens, recursing into nested groups such as colors.text.
+func studioGroup(section interface{}, tokenType string, convert func(string) interface{}) orderedTokens {
+	entries, _ := section.(orderedTokens)
+	group := orderedTokens{}
+	for _, entry := range entries {
+		switch v := entry.Value.(type) {
+		case orderedTokens:
+			if nested := studioGroup(v, tokenType, convert); len(nested) > 0 {
+				group = append(group, tokenEntry{entry.Key, nested})
+			}
+		case string:
+			var value interface{} = v
+			if convert != nil {
+				value = convert(v)
+			}
+			group = append(group, tokenEntry{entry.Key, studioToken{Value: value, Type: tokenType}})
+		}
+	}
+	return group
+}
+
+// studioShadowValue converts a computed box-shadow into Tokens Studio
+// shadow layers.
+func studioShadowValue(shadow string) interface{} {
+	var parsed []dtcgShadow
+	switch v := shadowValue(shadow).(type) {
+	case dtcgShadow:
+		parsed = []dtcgShadow{v}
+	case []dtcgShadow:
+		parsed = v
+	}
+	px := func(s string) string { return strings.TrimSuffix(s, "px") }
+	layers := make([]studioShadow, len(parsed))
+	for i, s := range parsed {
+		layers[i] = studioShadow{X: px(s.OffsetX), Y: px(s.OffsetY), Blur: px(s.Blur), Spread: px(s.Spread), Color: s.Color, Type: "dropShadow"}
+		if s.Inset {
+			layers[i].Type = "innerShadow"
+		}
+	}
+	if len(layers) == 1 {
+		return layers[0]
+	}
+	return layers
+}
+
+// figmaTokenRefs links a variant's fill, text, border, font size, radius and
+// shadow to the tokens in tokens.json, as Tokens Studio references.
+func figmaTokenRefs(styles map[string]string, m *tailwindMapper) map[string]string {
+	refs := make(map[string]string)
+	colorRef := func(key, value string) {
+		if hex, ok := cssHex(value); ok {
+			if name := m.colorName(hex); name != "" {
+				refs[key] = "{color." + name + "}"
+			}
+		}
+	}
+	colorRef("fill", styles["backgroundColor"])
+	colorRef("text", styles["color"])
+	if border := styles["border"]; border != "" && !strings.HasPrefix(border, "0px") {
+		colorRef("border", cssColorPattern.FindString(border))
+	}
+	if px, err := strconv.ParseFloat(strings.TrimSuffix(styles["fontSize"], "px"), 64); err == nil {
+		if name, ok := m.fontSizes[px]; ok {
+			refs["fontSize"] = "{fontSizes." + name + "}"
+		}
+	}
+	if radius := strings.Fields(styles["borderRadius"]); len(radius) > 0 {
+		if name, ok := m.radii[radius[0]]; ok {
+			refs["borderRadius"] = "{borderRadius." + name + "}"
+		}
+	}
+	if name, ok := m.shadows[styles["boxShadow"]]; ok {
+		refs["boxShadow"] = "{boxShadow." + name + "}"
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	return refs
+}
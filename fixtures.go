@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// writeFixtures stores the sampled response bodies of every endpoint as
+// fixtures/<endpoint>/sample_N.json next to the inferred schema.json, so a
+// rebuilt frontend can run against realistic mock data. The bodies are
+// tenant data, so they pass through the redaction rules first.
+func (e *AgicapExplorer) writeFixtures(endpoints []*apiEndpoint) error {
+	written := 0
+	for _, ep := range endpoints {
+		if len(ep.samples) == 0 {
+			continue
+		}
+		dir := "fixtures/" + fixtureDir(ep)
+
+		for i, sample := range ep.samples {
+			var pretty bytes.Buffer
+			if err := json.Indent(&pretty, []byte(e.redactor.Redact(sample)), "", "  "); err != nil {
+				continue
+			}
+			path, err := e.writeArtifact(dir, fmt.Sprintf("sample_%d.json", i+1), pretty.Bytes())
+			if err != nil {
+				return err
+			}
+			ep.Fixtures = append(ep.Fixtures, path)
+			written++
+		}
+
+		if ep.ResponseSchema != nil {
+			schema, err := json.MarshalIndent(ep.ResponseSchema, "", "  ")
+			if err != nil {
+				return err
+			}
+			if _, err := e.writeArtifact(dir, "schema.json", schema); err != nil {
+				return err
+			}
+		}
+	}
+	e.log("🧪 Wrote %d API fixtures", written)
+	return nil
+}
+
+// fixtureDir names an endpoint's fixture directory, e.g.
+// get_api.example.com_v1_invoices__id.
+func fixtureDir(ep *apiEndpoint) string {
+	return sanitize(ep.Method + "_" + ep.Host + ep.Path)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestWriteFixturesRedactsBodies(t *testing.T) {
+	e := &AgicapExplorer{outputDir: t.TempDir(), redactor: testRedactor(t)}
+	ep := &apiEndpoint{
+		Method:  "GET",
+		Host:    "api.example.com",
+		Path:    "/v1/accounts",
+		samples: []string{`{"accounts":[{"name":"Main","iban":"DE89370400440532013000","amount":1250.5}]}`},
+	}
+	if err := e.writeFixtures([]*apiEndpoint{ep}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ep.Fixtures) != 1 {
+		t.Fatalf("got %d fixtures, want 1", len(ep.Fixtures))
+	}
+	data, err := ioutil.ReadFile(ep.Fixtures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := string(data)
+	if strings.Contains(fixture, "DE89370400440532013000") {
+		t.Errorf("fixture contains the IBAN:\n%s", fixture)
+	}
+	if !strings.Contains(fixture, redactedValue) || !strings.Contains(fixture, `"name": "Main"`) {
+		t.Errorf("fixture is not the redacted body:\n%s", fixture)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/spf13/viper"
+)
+
+// formFieldRule fills the fields whose name, id, placeholder or label
+// matches pattern with a gofakeit template.
+type formFieldRule struct {
+	pattern *regexp.Regexp
+	value   string
+}
+
+// formProfiles are the built-in rules by explorer.forms.profile, most
+// specific first.
+var formProfiles = map[string][][2]string{
+	"de": {
+		{`(?i)iban`, "{iban_de}"},
+		{`(?i)\b(bic|swift)\b`, "{bic_de}"},
+		{`(?i)ust|vat|tax.?id|steuer`, "DE#########"},
+		{`(?i)amount|betrag|price|preis|summe|total|netto|brutto`, "{amount_eur}"},
+		{`(?i)company|firm(a|en)|unternehmen|organi[sz]ation`, "{lastname} {lastname} GmbH"},
+		{`(?i)first.?name|vorname`, "{firstname}"},
+		{`(?i)last.?name|nachname|surname`, "{lastname}"},
+		{`(?i)e-?mail`, "{firstname}.{lastname}@example.com"},
+		{`(?i)phone|telefon|mobil`, "+49 30 #######"},
+		{`(?i)street|stra(ss|ß)e|address|adresse`, "{lastname}straße {number:1,120}"},
+		{`(?i)zip|postal|plz|postleitzahl`, "#####"},
+		{`(?i)city|stadt|\bort\b`, "{city_de}"},
+		{`(?i)name`, "{firstname} {lastname}"},
+		{`(?i)date|datum|fällig|due`, "{date_iso}"},
+		{`(?i)description|beschreibung|note|notiz|comment|kommentar|verwendungszweck|reference|referenz`, "Rechnung RE-{number:10000,99999}"},
+		{`(?i)search|such`, "{lastname}"},
+	},
+	"en": {
+		{`(?i)iban`, "{iban_de}"},
+		{`(?i)amount|price|total|sum`, "{amount_eur}"},
+		{`(?i)company|organi[sz]ation`, "{company}"},
+		{`(?i)first.?name`, "{firstname}"},
+		{`(?i)last.?name|surname`, "{lastname}"},
+		{`(?i)e-?mail`, "{firstname}.{lastname}@example.com"},
+		{`(?i)phone|mobile`, "{phone}"},
+		{`(?i)street|address`, "{street}"},
+		{`(?i)zip|postal`, "{zip}"},
+		{`(?i)city`, "{city}"},
+		{`(?i)name`, "{name}"},
+		{`(?i)date|due`, "{date_iso}"},
+		{`(?i)description|note|comment|reference`, "Invoice INV-{number:10000,99999}"},
+		{`(?i)search`, "{lastname}"},
+	},
+}
+
+// formTypeValues fill the fields no rule matched, by input type.
+var formTypeValues = map[string]string{
+	"email":    "{firstname}.{lastname}@example.com",
+	"number":   "{number:100,5000}",
+	"date":     "{date_iso}",
+	"search":   "{lastname}",
+	"text":     "{lastname}",
+	"textarea": "{sentence:8}",
+}
+
+var germanCities = []string{"Berlin", "Hamburg", "München", "Köln", "Frankfurt am Main", "Stuttgart", "Düsseldorf", "Leipzig", "Dortmund", "Bremen", "Dresden", "Hannover", "Nürnberg"}
+
+func init() {
+	gofakeit.AddFuncLookup("iban_de", gofakeit.Info{
+		Display:     "German IBAN",
+		Category:    "payment",
+		Description: "IBAN of a German bank account with valid check digits",
+		Example:     "DE89370400440532013000",
+		Output:      "string",
+		Generate: func(r *rand.Rand, m *gofakeit.MapParams, info *gofakeit.Info) (any, error) {
+			return germanIBAN(r), nil
+		},
+	})
+	gofakeit.AddFuncLookup("bic_de", gofakeit.Info{
+		Display:     "German BIC",
+		Category:    "payment",
+		Description: "BIC of a German bank",
+		Example:     "COBADEFF",
+		Output:      "string",
+		Generate: func(r *rand.Rand, m *gofakeit.MapParams, info *gofakeit.Info) (any, error) {
+			const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+			b := []byte("XXXXDEXX")
+			for _, i := range []int{0, 1, 2, 3, 6, 7} {
+				b[i] = letters[r.Intn(len(letters))]
+			}
+			return string(b), nil
+		},
+	})
+	gofakeit.AddFuncLookup("amount_eur", gofakeit.Info{
+		Display:     "EUR amount",
+		Category:    "payment",
+		Description: "Amount in euros with cents",
+		Example:     "1234.56",
+		Output:      "string",
+		Generate: func(r *rand.Rand, m *gofakeit.MapParams, info *gofakeit.Info) (any, error) {
+			return fmt.Sprintf("%.2f", 50+r.Float64()*24950), nil
+		},
+	})
+	gofakeit.AddFuncLookup("date_iso", gofakeit.Info{
+		Display:     "Date",
+		Category:    "time",
+		Description: "Date within the next 60 days as YYYY-MM-DD",
+		Example:     "2024-12-31",
+		Output:      "string",
+		Generate: func(r *rand.Rand, m *gofakeit.MapParams, info *gofakeit.Info) (any, error) {
+			return time.Now().AddDate(0, 0, r.Intn(60)).Format("2006-01-02"), nil
+		},
+	})
+	gofakeit.AddFuncLookup("city_de", gofakeit.Info{
+		Display:     "German city",
+		Category:    "address",
+		Description: "One of the larger German cities",
+		Example:     "Leipzig",
+		Output:      "string",
+		Generate: func(r *rand.Rand, m *gofakeit.MapParams, info *gofakeit.Info) (any, error) {
+			return germanCities[r.Intn(len(germanCities))], nil
+		},
+	})
+}
+
+// germanIBAN returns DE, the ISO 7064 check digits, an 8 digit bank code and
+// a 10 digit account number.
+func germanIBAN(r *rand.Rand) string {
+	var bban strings.Builder
+	for i := 0; i < 18; i++ {
+		bban.WriteByte(byte('0' + r.Intn(10)))
+	}
+	// The BBAN followed by DE00 with the letters as numbers (D=13, E=14)
+	n, _ := new(big.Int).SetString(bban.String()+"131400", 10)
+	check := 98 - new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return fmt.Sprintf("DE%02d%s", check, bban.String())
+}
+
+// formFiller picks the values the interactions type into form fields.
+type formFiller struct {
+	faker *gofakeit.Faker
+	rules []formFieldRule
+}
+
+// newFormFiller loads explorer.forms: the rules of explorer.forms.fields,
+// then those of the profile, with a faker seeded by explorer.forms.seed
+// (0 for new values every run).
+func newFormFiller(v *viper.Viper) (*formFiller, error) {
+	profile := v.GetString("explorer.forms.profile")
+	builtin, ok := formProfiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("explorer.forms.profile: unknown profile %q (de, en)", profile)
+	}
+	var fields []struct {
+		Match string `mapstructure:"match"`
+		Value string `mapstructure:"value"`
+	}
+	if err := v.UnmarshalKey("explorer.forms.fields", &fields); err != nil {
+		return nil, fmt.Errorf("invalid explorer.forms.fields: %w", err)
+	}
+
+	f := &formFiller{faker: gofakeit.New(v.GetInt64("explorer.forms.seed"))}
+	for _, field := range fields {
+		re, err := regexp.Compile(field.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid explorer.forms.fields pattern: %w", err)
+		}
+		f.rules = append(f.rules, formFieldRule{pattern: re, value: field.Value})
+	}
+	for _, rule := range builtin {
+		f.rules = append(f.rules, formFieldRule{pattern: regexp.MustCompile(rule[0]), value: rule[1]})
+	}
+	return f, nil
+}
+
+// Value returns the value for a field, "" to leave it alone. Selects are
+// left alone, typing into them picks an arbitrary option.
+func (f *formFiller) Value(input formInput) string {
+	if strings.HasPrefix(input.Type, "select") {
+		return ""
+	}
+	describe := strings.Join([]string{input.Name, input.ID, input.Placeholder, input.Label}, " ")
+	for _, rule := range f.rules {
+		if rule.pattern.MatchString(describe) {
+			return f.faker.Generate(rule.value)
+		}
+	}
+	if template, ok := formTypeValues[input.Type]; ok {
+		return f.faker.Generate(template)
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// labelingJS defines labelOf(el), which returns how a form field gets its
+// accessible name, in the order browsers compute it: aria-labelledby,
+// aria-label, an associated <label>, title, and placeholder as the last
+// resort, and "none" for fields without a name.
+const labelingJS = `
+			const labelOf = (el) => {
+				const text = (s) => (s || '').trim().replace(/\s+/g, ' ').substring(0, 120);
+				const by = el.getAttribute('aria-labelledby');
+				if (by) {
+					const t = text(by.split(/\s+/).map(id => document.getElementById(id)).filter(Boolean).map(n => n.textContent).join(' '));
+					if (t) return ['aria-labelledby', t];
+				}
+				if (text(el.getAttribute('aria-label'))) return ['aria-label', text(el.getAttribute('aria-label'))];
+				if (el.labels && el.labels.length) {
+					const t = text(Array.from(el.labels).map(l => l.textContent).join(' '));
+					if (t) return ['label', t];
+				}
+				if (text(el.title)) return ['title', text(el.title)];
+				if (text(el.placeholder)) return ['placeholder', text(el.placeholder)];
+				return ['none', ''];
+			};`
+
+// formInput is a field found by fillForms.
+type formInput struct {
+	Type        string `json:"type"`
+	Placeholder string `json:"placeholder"`
+	Name        string `json:"name"`
+	ID          string `json:"id"`
+	Selector    string `json:"selector"`
+	Visible     bool   `json:"visible"`
+	Labeling    string `json:"labeling"` // see labelingJS
+	Label       string `json:"label"`
+}
+
+// Labeled reports whether the field has a name that stays visible or is
+// read out once it is filled; a placeholder disappears on input.
+func (f formInput) Labeled() bool {
+	return f.Labeling != "none" && f.Labeling != "placeholder"
+}
+
+// formLabels is a11y/<page>_forms.json.
+type formLabels struct {
+	Page   string      `json:"page"`
+	Fields []formInput `json:"fields"`
+}
+
+// writeFormLabels records the labeling of the fields fillForms found on a
+// page to a11y/<page>_forms.json.
+func (e *AgicapExplorer) writeFormLabels(pageName string, fields []formInput) {
+	unlabeled := 0
+	for _, f := range fields {
+		if !f.Labeled() {
+			unlabeled++
+		}
+	}
+	data, err := json.MarshalIndent(formLabels{Page: pageName, Fields: fields}, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("a11y", sanitize(pageName)+"_forms.json", data); err != nil {
+		e.log("⚠️ Failed to write form labels for %s: %v", pageName, err)
+	}
+	if unlabeled > 0 {
+		e.log("🏷️ %d of %d form fields on %s have no label", unlabeled, len(fields), pageName)
+	}
+}
+
+// formsReport is forms_accessibility.json: how the fields of the run are
+// labeled, and the ones without a proper label.
+type formsReport struct {
+	Fields     int              `json:"fields"`
+	ByLabeling map[string]int   `json:"by_labeling"`
+	Unlabeled  []unlabeledField `json:"unlabeled"`
+}
+
+type unlabeledField struct {
+	Page  string `json:"page"`
+	Title string `json:"title"`
+	formInput
+}
+
+// formsAccessibility combines the a11y/<page>_forms.json files of the run.
+func (e *AgicapExplorer) formsAccessibility() formsReport {
+	report := formsReport{ByLabeling: make(map[string]int), Unlabeled: []unlabeledField{}}
+	for _, item := range e.navigationMap {
+		key := pageKey(item)
+		data, err := ioutil.ReadFile(filepath.Join(e.outputDir, "a11y", key+"_forms.json"))
+		if err != nil {
+			continue
+		}
+		var labels formLabels
+		if json.Unmarshal(data, &labels) != nil {
+			continue
+		}
+		for _, f := range labels.Fields {
+			report.Fields++
+			report.ByLabeling[f.Labeling]++
+			if !f.Labeled() {
+				report.Unlabeled = append(report.Unlabeled, unlabeledField{Page: key, Title: item.Title, formInput: f})
+			}
+		}
+	}
+	return report
+}
+
+// writeFormsAccessibility saves forms_accessibility.json.
+func (e *AgicapExplorer) writeFormsAccessibility(report formsReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "forms_accessibility.json"), data, 0644)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// pageLink is a link found on a captured page.
+type pageLink struct {
+	Text string `json:"text"`
+	Href string `json:"href"`
+}
+
+type graphNode struct {
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Section  string `json:"section,omitempty"`
+	Route    string `json:"route_template,omitempty"`
+	Captured bool   `json:"captured"`
+	External bool   `json:"external"`
+}
+
+type graphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Text   string `json:"text"`
+}
+
+// linkGraph is the directed graph of the app's pages: nodes are canonical
+// URLs, edges are links from a captured page to their target.
+type linkGraph struct {
+	mu    sync.Mutex
+	nodes map[string]*graphNode
+	edges []graphEdge
+	seen  map[graphEdge]bool
+}
+
+func newLinkGraph() *linkGraph {
+	return &linkGraph{nodes: make(map[string]*graphNode), seen: make(map[graphEdge]bool)}
+}
+
+func (g *linkGraph) node(id string) *graphNode {
+	n, ok := g.nodes[id]
+	if !ok {
+		n = &graphNode{ID: id, Route: routeTemplate(id)}
+		g.nodes[id] = n
+	}
+	return n
+}
+
+// AddPage marks a canonical URL as captured.
+func (g *linkGraph) AddPage(id, title, section string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := g.node(id)
+	n.Captured = true
+	if n.Title == "" {
+		n.Title = title
+	}
+	n.Section = section
+}
+
+// AddEdge records a link, ignoring exact repeats.
+func (g *linkGraph) AddEdge(source, target, text string, external bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	edge := graphEdge{Source: source, Target: target, Text: text}
+	if g.seen[edge] {
+		return
+	}
+	g.seen[edge] = true
+	g.node(source)
+	n := g.node(target)
+	n.External = n.External || external
+	g.edges = append(g.edges, edge)
+}
+
+func (g *linkGraph) sortedNodes() []*graphNode {
+	nodes := make([]*graphNode, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+// WriteJSON exports the graph as {"nodes": [...], "edges": [...]}.
+func (g *linkGraph) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"nodes": g.sortedNodes(),
+		"edges": g.edges,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// WriteDOT exports the graph in GraphViz format, clustering captured pages by
+// section and drawing external targets as dashed boxes.
+func (g *linkGraph) WriteDOT(path string) error {
+	var b strings.Builder
+	b.WriteString("digraph app {\n")
+	b.WriteString("  rankdir=LR;\n  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+
+	bySection := make(map[string][]*graphNode)
+	for _, n := range g.sortedNodes() {
+		bySection[n.Section] = append(bySection[n.Section], n)
+	}
+	sections := make([]string, 0, len(bySection))
+	for s := range bySection {
+		sections = append(sections, s)
+	}
+	sort.Strings(sections)
+
+	for i, section := range sections {
+		indent := "  "
+		if section != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%q;\n", i, section)
+			indent = "    "
+		}
+		for _, n := range bySection[section] {
+			label := n.Title
+			if label == "" {
+				label = n.Route
+			}
+			style := ""
+			if n.External {
+				style = ", style=dashed"
+			} else if !n.Captured {
+				style = ", style=\"rounded,dotted\""
+			}
+			fmt.Fprintf(&b, "%s%q [label=%q%s];\n", indent, n.ID, label, style)
+		}
+		if section != "" {
+			b.WriteString("  }\n")
+		}
+	}
+
+	for _, edge := range g.edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", edge.Source, edge.Target, truncate(edge.Text, 30))
+	}
+	b.WriteString("}\n")
+	return ioutil.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// graphQLOperationPattern reads the operation type and name from a document.
+var graphQLOperationPattern = regexp.MustCompile(`^\s*(query|mutation|subscription)\b\s*([A-Za-z_][A-Za-z0-9_]*)?`)
+
+// graphQLOperation is a query, mutation or subscription seen on the wire.
+type graphQLOperation struct {
+	Endpoint      string          `json:"endpoint"`
+	Type          string          `json:"type"`
+	Name          string          `json:"name"`
+	Query         string          `json:"query,omitempty"`
+	PersistedHash string          `json:"persisted_hash,omitempty"`
+	Variables     json.RawMessage `json:"sample_variables,omitempty"`
+	Calls         int             `json:"calls"`
+	Pages         []string        `json:"pages,omitempty"`
+}
+
+// graphQLRequest is the JSON body of a GraphQL call.
+type graphQLRequest struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName"`
+	Variables     json.RawMessage `json:"variables"`
+	Extensions    struct {
+		PersistedQuery struct {
+			Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// parseGraphQLRequests extracts the GraphQL operations from an API call, or
+// returns nil if it is not one. Batched POST bodies and GET ?query= are both
+// recognised.
+func parseGraphQLRequests(entry networkEntry) []graphQLRequest {
+	if entry.kind != network.ResourceTypeXHR && entry.kind != network.ResourceTypeFetch {
+		return nil
+	}
+
+	var reqs []graphQLRequest
+	if body := strings.TrimSpace(entry.request.PostData); body != "" {
+		if strings.HasPrefix(body, "[") {
+			json.Unmarshal([]byte(body), &reqs)
+		} else {
+			var req graphQLRequest
+			if json.Unmarshal([]byte(body), &req) == nil {
+				reqs = append(reqs, req)
+			}
+		}
+	} else if u, err := url.Parse(entry.request.URL); err == nil && u.Query().Get("query") != "" {
+		q := u.Query()
+		reqs = append(reqs, graphQLRequest{
+			Query:         q.Get("query"),
+			OperationName: q.Get("operationName"),
+			Variables:     json.RawMessage(q.Get("variables")),
+		})
+	}
+
+	var ops []graphQLRequest
+	for _, req := range reqs {
+		if req.Query != "" || req.Extensions.PersistedQuery.Hash != "" {
+			ops = append(ops, req)
+		}
+	}
+	return ops
+}
+
+// operationType returns the type and name declared by a GraphQL document;
+// the anonymous shorthand { ... } is a query.
+func operationType(query, operationName string) (string, string) {
+	kind, name := "query", ""
+	if m := graphQLOperationPattern.FindStringSubmatch(query); m != nil {
+		kind, name = m[1], m[2]
+	}
+	if operationName != "" {
+		name = operationName
+	}
+	if name == "" {
+		name = "anonymous"
+	}
+	return kind, name
+}
+
+// writeGraphQL records the GraphQL operations observed during the run in
+// graphql/operations.json and, if the endpoint allows introspection,
+// reconstructs graphql/schema.graphql.
+func (e *AgicapExplorer) writeGraphQL() error {
+	byKey := make(map[string]*graphQLOperation)
+	pages := make(map[string][]string)
+	headers := make(map[string]network.Headers)
+	var endpoints []string
+
+	for _, entry := range e.network.All() {
+		reqs := parseGraphQLRequests(entry)
+		if len(reqs) == 0 {
+			continue
+		}
+		endpoint := strings.SplitN(entry.request.URL, "?", 2)[0]
+		if _, ok := headers[endpoint]; !ok {
+			endpoints = append(endpoints, endpoint)
+			headers[endpoint] = entry.request.Headers
+		}
+
+		for _, req := range reqs {
+			kind, name := operationType(req.Query, req.OperationName)
+			key := endpoint + " " + kind + " " + name + " " + req.Query + req.Extensions.PersistedQuery.Hash
+			op, ok := byKey[key]
+			if !ok {
+				op = &graphQLOperation{
+					Endpoint:      endpoint,
+					Type:          kind,
+					Name:          name,
+					Query:         req.Query,
+					PersistedHash: req.Extensions.PersistedQuery.Hash,
+				}
+				if len(req.Variables) > 0 && string(req.Variables) != "null" && json.Valid(req.Variables) {
+					op.Variables = json.RawMessage(e.redactor.Redact(string(req.Variables)))
+				}
+				byKey[key] = op
+			}
+			op.Calls++
+			if entry.page != "" {
+				op.Pages = appendUnique(op.Pages, entry.page)
+				pages[entry.page] = appendUnique(pages[entry.page], kind+" "+name)
+			}
+		}
+	}
+	if len(byKey) == 0 {
+		return nil
+	}
+
+	ops := make([]*graphQLOperation, 0, len(byKey))
+	for _, op := range byKey {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Type != ops[j].Type {
+			return ops[i].Type < ops[j].Type
+		}
+		return ops[i].Name < ops[j].Name
+	})
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"endpoints":  endpoints,
+		"operations": ops,
+		"pages":      pages,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := e.writeArtifact("graphql", "operations.json", data); err != nil {
+		return err
+	}
+	e.log("🧬 Recorded %d GraphQL operations on %d endpoints", len(ops), len(endpoints))
+
+	if !e.config.GetBool("explorer.graphql.introspect") {
+		return nil
+	}
+	for _, endpoint := range endpoints {
+		schema, err := e.introspectGraphQL(endpoint, headers[endpoint])
+		if err != nil {
+			e.log("⚠️ GraphQL introspection failed for %s: %v", endpoint, err)
+			continue
+		}
+		name := "schema.graphql"
+		if len(endpoints) > 1 {
+			name = sanitize(strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://")) + ".graphql"
+		}
+		if _, err := e.writeArtifact("graphql", name, []byte(schema)); err != nil {
+			return err
+		}
+		e.log("🧬 Reconstructed GraphQL schema from %s", endpoint)
+	}
+	return nil
+}
+
+// introspectionQuery asks for every type with its fields, arguments, input
+// fields, interfaces, enum values and union members.
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind name description
+      fields(includeDeprecated: true) {
+        name description
+        args { name description type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+        isDeprecated deprecationReason
+      }
+      inputFields { name description type { ...TypeRef } defaultValue }
+      interfaces { ...TypeRef }
+      enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+      possibleTypes { ...TypeRef }
+    }
+  }
+}
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } }
+}`
+
+type gqlTypeRef struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	OfType *gqlTypeRef `json:"ofType"`
+}
+
+type gqlInputValue struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Type         gqlTypeRef `json:"type"`
+	DefaultValue *string    `json:"defaultValue"`
+}
+
+type gqlField struct {
+	Name              string          `json:"name"`
+	Description       string          `json:"description"`
+	Args              []gqlInputValue `json:"args"`
+	Type              gqlTypeRef      `json:"type"`
+	IsDeprecated      bool            `json:"isDeprecated"`
+	DeprecationReason string          `json:"deprecationReason"`
+}
+
+type gqlType struct {
+	Kind          string          `json:"kind"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Fields        []gqlField      `json:"fields"`
+	InputFields   []gqlInputValue `json:"inputFields"`
+	Interfaces    []gqlTypeRef    `json:"interfaces"`
+	EnumValues    []gqlField      `json:"enumValues"`
+	PossibleTypes []gqlTypeRef    `json:"possibleTypes"`
+}
+
+type gqlSchema struct {
+	QueryType        *gqlTypeRef `json:"queryType"`
+	MutationType     *gqlTypeRef `json:"mutationType"`
+	SubscriptionType *gqlTypeRef `json:"subscriptionType"`
+	Types            []gqlType   `json:"types"`
+}
+
+// introspectGraphQL runs the introspection query from inside the page with
+// the headers the app itself sent, so bearer tokens keep working. The
+// request is kept out of the network recording, so the replayed headers
+// end up in no output.
+func (e *AgicapExplorer) introspectGraphQL(endpoint string, headers network.Headers) (string, error) {
+	replay := make(map[string]string)
+	for name, value := range headers {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(name, ":") || lower == "content-length" || lower == "cookie" {
+			continue
+		}
+		replay[name] = fmt.Sprint(value)
+	}
+	replay["Content-Type"] = "application/json"
+
+	headerJSON, _ := json.Marshal(replay)
+	body, _ := json.Marshal(map[string]string{"query": introspectionQuery, "operationName": "IntrospectionQuery"})
+	e.network.Ignore(string(body))
+
+	var result struct {
+		Status int    `json:"status"`
+		Body   string `json:"body"`
+	}
+	script := fmt.Sprintf(`fetch(%q, {method: 'POST', credentials: 'include', headers: %s, body: %q})
+		.then(r => r.text().then(body => ({status: r.status, body: body})))`, endpoint, headerJSON, body)
+	err := chromedp.Run(e.ctx,
+		chromedp.Evaluate(script, &result, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+	)
+	if err != nil {
+		return "", err
+	}
+	if result.Status >= 400 {
+		return "", fmt.Errorf("HTTP %d", result.Status)
+	}
+
+	var resp struct {
+		Data struct {
+			Schema *gqlSchema `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(result.Body), &resp); err != nil {
+		return "", err
+	}
+	if resp.Data.Schema == nil {
+		if len(resp.Errors) > 0 {
+			return "", fmt.Errorf("%s", resp.Errors[0].Message)
+		}
+		return "", fmt.Errorf("no schema in response")
+	}
+	return printSDL(resp.Data.Schema), nil
+}
+
+// builtinScalars are part of every GraphQL schema and not printed.
+var builtinScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+
+// printSDL renders an introspection result as GraphQL schema language.
+func printSDL(schema *gqlSchema) string {
+	var b strings.Builder
+
+	roots := map[string]string{}
+	if schema.QueryType != nil && schema.QueryType.Name != "Query" {
+		roots["query"] = schema.QueryType.Name
+	}
+	if schema.MutationType != nil && schema.MutationType.Name != "Mutation" {
+		roots["mutation"] = schema.MutationType.Name
+	}
+	if schema.SubscriptionType != nil && schema.SubscriptionType.Name != "Subscription" {
+		roots["subscription"] = schema.SubscriptionType.Name
+	}
+	if len(roots) > 0 {
+		b.WriteString("schema {\n")
+		for _, op := range []string{"query", "mutation", "subscription"} {
+			if name, ok := roots[op]; ok {
+				fmt.Fprintf(&b, "  %s: %s\n", op, name)
+			}
+		}
+		b.WriteString("}\n\n")
+	}
+
+	types := append([]gqlType(nil), schema.Types...)
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || builtinScalars[t.Name] {
+			continue
+		}
+		writeSDLDescription(&b, t.Description, "")
+		switch t.Kind {
+		case "SCALAR":
+			fmt.Fprintf(&b, "scalar %s\n\n", t.Name)
+		case "ENUM":
+			fmt.Fprintf(&b, "enum %s {\n", t.Name)
+			for _, v := range t.EnumValues {
+				writeSDLDescription(&b, v.Description, "  ")
+				fmt.Fprintf(&b, "  %s%s\n", v.Name, sdlDeprecated(v))
+			}
+			b.WriteString("}\n\n")
+		case "UNION":
+			members := make([]string, 0, len(t.PossibleTypes))
+			for _, p := range t.PossibleTypes {
+				members = append(members, p.Name)
+			}
+			fmt.Fprintf(&b, "union %s = %s\n\n", t.Name, strings.Join(members, " | "))
+		case "INPUT_OBJECT":
+			fmt.Fprintf(&b, "input %s {\n", t.Name)
+			for _, f := range t.InputFields {
+				writeSDLDescription(&b, f.Description, "  ")
+				fmt.Fprintf(&b, "  %s\n", sdlInputValue(f))
+			}
+			b.WriteString("}\n\n")
+		case "OBJECT", "INTERFACE":
+			keyword := "type"
+			if t.Kind == "INTERFACE" {
+				keyword = "interface"
+			}
+			fmt.Fprintf(&b, "%s %s", keyword, t.Name)
+			if len(t.Interfaces) > 0 {
+				names := make([]string, 0, len(t.Interfaces))
+				for _, i := range t.Interfaces {
+					names = append(names, i.Name)
+				}
+				fmt.Fprintf(&b, " implements %s", strings.Join(names, " & "))
+			}
+			b.WriteString(" {\n")
+			for _, f := range t.Fields {
+				writeSDLDescription(&b, f.Description, "  ")
+				args := ""
+				if len(f.Args) > 0 {
+					parts := make([]string, 0, len(f.Args))
+					for _, a := range f.Args {
+						parts = append(parts, sdlInputValue(a))
+					}
+					args = "(" + strings.Join(parts, ", ") + ")"
+				}
+				fmt.Fprintf(&b, "  %s%s: %s%s\n", f.Name, args, sdlTypeRef(f.Type), sdlDeprecated(f))
+			}
+			b.WriteString("}\n\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func sdlTypeRef(t gqlTypeRef) string {
+	switch t.Kind {
+	case "NON_NULL":
+		if t.OfType != nil {
+			return sdlTypeRef(*t.OfType) + "!"
+		}
+	case "LIST":
+		if t.OfType != nil {
+			return "[" + sdlTypeRef(*t.OfType) + "]"
+		}
+	}
+	return t.Name
+}
+
+func sdlInputValue(v gqlInputValue) string {
+	s := v.Name + ": " + sdlTypeRef(v.Type)
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+func sdlDeprecated(f gqlField) string {
+	if !f.IsDeprecated {
+		return ""
+	}
+	if f.DeprecationReason == "" {
+		return " @deprecated"
+	}
+	return fmt.Sprintf(" @deprecated(reason: %q)", f.DeprecationReason)
+}
+
+func writeSDLDescription(b *strings.Builder, description, indent string) {
+	if description == "" {
+		return
+	}
+	fmt.Fprintf(b, "%s\"\"\"%s\"\"\"\n", indent, strings.ReplaceAll(description, `"""`, `\"""`))
+}
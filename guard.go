@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// listen attaches the recorders to the browser's first tab.
+func (e *AgicapExplorer) listen() error {
+	e.activity = newNetworkActivity()
+	e.activity.Listen(e.ctx)
+	e.watchCrashes(e.ctx)
+	e.polite.Listen(e.ctx)
+	e.network.Listen(e.ctx)
+	e.console.Listen(e.ctx)
+	e.stylesheets.Listen(e.ctx)
+	if e.mocks != nil {
+		if err := e.mocks.Start(e.ctx); err != nil {
+			return fmt.Errorf("failed to enable request mocking: %w", err)
+		}
+	}
+	return nil
+}
+
+// watchCrashes flags the tab when its renderer crashes. Every later
+// chromedp.Run on it fails, so the crawl restarts the browser instead.
+func (e *AgicapExplorer) watchCrashes(ctx context.Context) {
+	crashed := &atomic.Bool{}
+	e.crashed = crashed
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			crashed.Store(true)
+		}
+	})
+}
+
+// trouble returns why the browser needs a restart: the tab's renderer
+// crashed, it stopped answering, or its JavaScript heap outgrew
+// explorer.browser.max_heap_mb. It returns "" for a healthy tab, and once
+// the browser context has run out of time, as a restart cannot help then.
+func (e *AgicapExplorer) trouble() string {
+	if e.ctx.Err() == context.DeadlineExceeded {
+		return ""
+	}
+	if e.crashed != nil && e.crashed.Load() {
+		return "renderer crashed"
+	}
+
+	timeout := e.config.GetDuration("explorer.browser.health_timeout")
+	ctx, cancel := context.WithTimeout(e.ctx, timeout)
+	defer cancel()
+	var used float64
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		used, _, err = runtime.GetHeapUsage().Do(ctx)
+		return err
+	}))
+	switch {
+	case e.ctx.Err() == context.DeadlineExceeded:
+		return ""
+	case err != nil:
+		return fmt.Sprintf("not responding (%v)", err)
+	}
+	if limit := e.config.GetFloat64("explorer.browser.max_heap_mb"); limit > 0 && used > limit*(1<<20) {
+		return fmt.Sprintf("using %.0f MB of JavaScript heap", used/(1<<20))
+	}
+	return ""
+}
+
+// restartBrowser replaces the browser with a new one and logs in again.
+// Only the explorer the tabs were opened from restarts, with no tabs open;
+// the recorders and the crawl state carry over.
+func (e *AgicapExplorer) restartBrowser() error {
+	e.cancel()
+	e.launchBrowser()
+	if err := e.listen(); err != nil {
+		return err
+	}
+	if e.relogin == nil {
+		return nil
+	}
+	return e.relogin()
+}
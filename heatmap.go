@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// runHeatmap compares every run in a directory of historical runs (e.g.
+// the one `explorer watch` fills) with the run before it and writes
+// heatmap.html and heatmap.json: how often each page and section changed,
+// to prioritize what to rebuild first.
+//
+//	explorer heatmap [-out dir] [-tolerance n] [-min-diff pct] [-locale en|de] runs/
+func runHeatmap(args []string) error {
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	out := fs.String("out", "", "directory of heatmap.html and heatmap.json (default: the runs directory)")
+	tolerance := fs.Int("tolerance", 16, "per-channel difference (0-255) below which pixels count as equal")
+	minDiff := fs.Float64("min-diff", 0.1, "screenshot difference in percent below which a page counts as unchanged")
+	locale := fs.String("locale", "", "language of heatmap.html (en, de)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: heatmap [-out dir] [-tolerance n] [-min-diff pct] [-locale en|de] <runs>")
+	}
+	dir := fs.Arg(0)
+	if *out == "" {
+		*out = dir
+	}
+	runs := historyRuns(dir)
+	if len(runs) < 2 {
+		return fmt.Errorf("%s has %d runs, at least 2 are needed", dir, len(runs))
+	}
+
+	heatmap, err := buildHeatmap(runs, *tolerance, *minDiff)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(heatmap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(*out, "heatmap.json"), data, 0644); err != nil {
+		return err
+	}
+	templates, err := loadTemplates(*locale, "")
+	if err != nil {
+		return err
+	}
+	if err := templates.render(filepath.Join(*out, "heatmap.html"), "heatmap", heatmap); err != nil {
+		return err
+	}
+	fmt.Printf("🔥 Heatmap of %d pages over %d runs written to %s\n", len(heatmap.Pages), len(runs), filepath.Join(*out, "heatmap.html"))
+	for i, s := range heatmap.Sections {
+		if i == 5 {
+			break
+		}
+		fmt.Printf("  %d. %s: %d changes in %d comparisons (%.0f%%)\n", i+1, s.Name, s.Changes, s.Compared, s.Rate*100)
+	}
+	return nil
+}
+
+// historyRun is a run of the history, a column boundary of the heatmap.
+type historyRun struct {
+	Dir     string    `json:"dir"`
+	Name    string    `json:"name"`
+	Started time.Time `json:"started"`
+}
+
+// historyRuns lists the runs in dir by their start in run.json, falling
+// back to the time their navigation map was written.
+func historyRuns(dir string) []historyRun {
+	var runs []historyRun
+	for _, path := range watchRuns(dir) {
+		run := historyRun{Dir: path, Name: filepath.Base(path)}
+		var manifest runManifest
+		if data, err := ioutil.ReadFile(filepath.Join(path, "run.json")); err == nil && json.Unmarshal(data, &manifest) == nil {
+			run.Started, _ = time.Parse(time.RFC3339, manifest.StartedAt)
+		}
+		if run.Started.IsZero() {
+			if info, err := os.Stat(filepath.Join(path, "navigation_map.json")); err == nil {
+				run.Started = info.ModTime()
+			}
+		}
+		runs = append(runs, run)
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+	return runs
+}
+
+// changeHeatmap is heatmap.json and what the heatmap template renders.
+// Columns are the comparisons of each run with the one before it.
+type changeHeatmap struct {
+	Generated time.Time        `json:"generated"`
+	Runs      []historyRun     `json:"runs"`
+	Columns   []string         `json:"columns"`
+	Sections  []heatmapSection `json:"sections"`
+	Pages     []heatmapPage    `json:"pages"`
+}
+
+// heatmapPage is how often a page changed: Changes of the Compared
+// comparisons it was in both runs of, and a cell per comparison.
+type heatmapPage struct {
+	URL      string        `json:"url"`
+	Title    string        `json:"title"`
+	Section  string        `json:"section"`
+	Changes  int           `json:"changes"`
+	Compared int           `json:"compared"`
+	Rate     float64       `json:"change_rate"`
+	Cells    []heatmapCell `json:"cells"`
+}
+
+// heatmapCell is a page in one comparison. Heat is 0 for unchanged pages
+// and grows with the pixel difference and structural changes up to 1.
+type heatmapCell struct {
+	State     string   `json:"state"` // changed, unchanged, added, removed or absent
+	Heat      float64  `json:"heat"`
+	PixelDiff *float64 `json:"pixel_diff_percent,omitempty"`
+	DOM       int      `json:"structural_changes,omitempty"`
+}
+
+// Pixel formats the pixel difference, "" when unknown.
+func (c heatmapCell) Pixel() string {
+	if c.PixelDiff == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f%%", *c.PixelDiff)
+}
+
+// Percent formats the change rate.
+func (p heatmapPage) Percent() string {
+	return fmt.Sprintf("%.0f%%", p.Rate*100)
+}
+
+type heatmapSection struct {
+	Name     string  `json:"name"`
+	Pages    int     `json:"pages"`
+	Changes  int     `json:"changes"`
+	Compared int     `json:"compared"`
+	Rate     float64 `json:"change_rate"`
+}
+
+// Percent formats the change rate.
+func (s heatmapSection) Percent() string {
+	return fmt.Sprintf("%.0f%%", s.Rate*100)
+}
+
+// From and To are the start of the first and the last run.
+func (h *changeHeatmap) From() time.Time { return h.Runs[0].Started }
+func (h *changeHeatmap) To() time.Time   { return h.Runs[len(h.Runs)-1].Started }
+
+// buildHeatmap compares the consecutive runs and sorts pages and sections
+// by how often they changed.
+func buildHeatmap(runs []historyRun, tolerance int, minDiff float64) (*changeHeatmap, error) {
+	h := &changeHeatmap{Generated: time.Now(), Runs: runs}
+	pages := make(map[string]*heatmapPage)
+	var order []string
+	page := func(url, title string) *heatmapPage {
+		p, ok := pages[url]
+		if !ok {
+			p = &heatmapPage{URL: url}
+			pages[url] = p
+			order = append(order, url)
+		}
+		p.Title = title
+		return p
+	}
+	cell := func(p *heatmapPage, column int) *heatmapCell {
+		for len(p.Cells) <= column {
+			p.Cells = append(p.Cells, heatmapCell{State: "absent"})
+		}
+		return &p.Cells[column]
+	}
+
+	for i := 1; i < len(runs); i++ {
+		column := i - 1
+		h.Columns = append(h.Columns, runs[i].Name)
+		c, err := compareRuns(runs[i-1].Dir, runs[i].Dir, tolerance, minDiff)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range c.Changed {
+			hp := page(p.URL, p.TitleBefore)
+			hp.Changes++
+			hp.Compared++
+			*cell(hp, column) = heatmapCell{State: "changed", Heat: changeHeat(p), PixelDiff: p.PixelDiff, DOM: p.DOM.Count()}
+		}
+		for _, p := range c.Added {
+			cell(page(p.URL, p.Title), column).State = "added"
+		}
+		for _, p := range c.Removed {
+			cell(page(p.URL, p.Title), column).State = "removed"
+		}
+		// Unchanged pages are the ones of the newer run not listed above
+		items, err := loadNavigationMap(runs[i].Dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			url := item.CanonicalURL
+			if url == "" {
+				url = item.URL
+			}
+			hp := page(url, item.Title)
+			hp.Section = item.Section
+			if cl := cell(hp, column); cl.State == "absent" {
+				cl.State = "unchanged"
+				hp.Compared++
+			}
+		}
+	}
+
+	sections := make(map[string]*heatmapSection)
+	for _, url := range order {
+		p := pages[url]
+		cell(p, len(h.Columns)-1)
+		if p.Compared > 0 {
+			p.Rate = float64(p.Changes) / float64(p.Compared)
+		}
+		if p.Section == "" {
+			p.Section = sectionOf(p.URL)
+		}
+		s, ok := sections[p.Section]
+		if !ok {
+			s = &heatmapSection{Name: p.Section}
+			sections[p.Section] = s
+		}
+		s.Pages++
+		s.Changes += p.Changes
+		s.Compared += p.Compared
+		h.Pages = append(h.Pages, *p)
+	}
+	for _, s := range sections {
+		if s.Compared > 0 {
+			s.Rate = float64(s.Changes) / float64(s.Compared)
+		}
+		h.Sections = append(h.Sections, *s)
+	}
+	sort.SliceStable(h.Pages, func(i, j int) bool {
+		if h.Pages[i].Rate != h.Pages[j].Rate {
+			return h.Pages[i].Rate > h.Pages[j].Rate
+		}
+		return h.Pages[i].Changes > h.Pages[j].Changes
+	})
+	sort.Slice(h.Sections, func(i, j int) bool {
+		if h.Sections[i].Rate != h.Sections[j].Rate {
+			return h.Sections[i].Rate > h.Sections[j].Rate
+		}
+		return h.Sections[i].Name < h.Sections[j].Name
+	})
+	return h, nil
+}
+
+// changeHeat maps a change to 0.2-1: 10% differing pixels or 50
+// structural changes are the hottest.
+func changeHeat(p pageComparison) float64 {
+	heat := math.Max(diffValue(p.PixelDiff)/10, float64(p.DOM.Count())/50)
+	return math.Round(math.Min(1, math.Max(0.2, heat))*100) / 100
+}
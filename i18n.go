@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// reportLocales translate the text of the report templates, keyed by the
+// English original. Strings missing from a locale stay English; "en" needs
+// no entry.
+var reportLocales = map[string]map[string]string{
+	"de": {
+		// report.html
+		"Agicap UI Exploration Report": "Agicap UI-Analysebericht",
+		"Generated":                    "Erstellt",
+		"Pages Captured":               "Erfasste Seiten",
+		"Unique URLs":                  "Eindeutige URLs",
+		"Pages with JS Errors":         "Seiten mit JS-Fehlern",
+		"Pages with JavaScript Errors": "Seiten mit JavaScript-Fehlern",
+		"console log":                  "Konsolenprotokoll",
+		"Performance":                  "Performance",
+		"Page":                         "Seite",
+		"Load (ms)":                    "Laden (ms)",
+		"Requests":                     "Anfragen",
+		"Transferred (KB)":             "Übertragen (KB)",
+		"Script (ms)":                  "Skript (ms)",
+		"JS Heap (MB)":                 "JS-Heap (MB)",
+		"Accessibility":                "Barrierefreiheit",
+		"Rule":                         "Regel",
+		"Impact":                       "Auswirkung",
+		"Elements":                     "Elemente",
+		"Form Labels":                  "Formularbeschriftungen",
+		"Field":                        "Feld",
+		"Labeling":                     "Beschriftung",
+		"ARIA Usage":                   "ARIA-Verwendung",
+		"Role":                         "Rolle",
+		"Example":                      "Beispiel",
+		"Attribute":                    "Attribut",
+		"Values":                       "Werte",
+		"Locales":                      "Sprachen",
+		"Locale":                       "Sprache",
+		"Not switched":                 "Nicht umgeschaltet",
+		"Untranslated":                 "Unübersetzt",
+		"Message keys":                 "Message-Keys",
+		"Overflow":                     "Überlauf",
+		"Truncated":                    "Abgeschnitten",
+		"Layout":                       "Layout",
+		"Issues":                       "Probleme",
+		"Color Vision":                 "Farbsehen",
+		"Original":                     "Original",
+		"Run Comparison":               "Vergleich der Läufe",
+		"Baseline Check":               "Baseline-Prüfung",
+		"%d changed regions":           "%d geänderte Bereiche",
+		"Before":                       "Vorher",
+		"After":                        "Nachher",
+		"Diff":                         "Differenz",
+		"No changes.":                  "Keine Änderungen.",
+		"%d structural changes":        "%d strukturelle Änderungen",
+		"Added":                        "Hinzugefügt",
+		"Removed":                      "Entfernt",
+		"Changed":                      "Geändert",
+		"Unchanged":                    "Unverändert",
+		"Checked":                      "Geprüft",
+		"Failed":                       "Fehlgeschlagen",
+		"Missing":                      "Fehlend",
+		"New":                          "Neu",
+		"Change Heatmap":               "Änderungs-Heatmap",
+		"%d runs":                      "%d Läufe",
+		"Changes":                      "Änderungen",
+		"Comparisons":                  "Vergleiche",
+		"Change rate":                  "Änderungsrate",
+		"changed":                      "geändert",
+		"unchanged":                    "unverändert",
+		"added":                        "hinzugefügt",
+		"removed":                      "entfernt",
+		"absent":                       "nicht erfasst",
+		"Icons":                        "Icons",
+		"Individual files in icons/svg/, all SVG icons as symbols in icons/sprite.svg.": "Einzelne Dateien in icons/svg/, alle SVG-Icons als Symbole in icons/sprite.svg.",
+		"WCAG rules axe-core found violated, per page in a11y/<page>_axe.json.":         "Von axe-core gefundene WCAG-Verstöße, je Seite in a11y/<page>_axe.json.",
+		"Fields without a label or with only a placeholder, which vanishes on input.":   "Felder ohne Beschriftung oder nur mit Platzhalter, der bei der Eingabe verschwindet.",
+		"Roles and attributes for the rebuild, with examples in aria_usage.json.":       "Rollen und Attribute für den Nachbau, mit Beispielen in aria_usage.json.",
+		"Each page rendered per locale, screenshots and details in locales/<page>/.":    "Jede Seite je Sprache gerendert, Screenshots und Details in locales/<page>/.",
+		"Key pages as seen with color vision deficiencies, to check chart colors.":      "Wichtige Seiten mit simulierten Farbsehschwächen, um Diagrammfarben zu prüfen.",
+		"How often the pages of each section changed between consecutive runs.":         "Wie oft sich die Seiten jedes Bereichs zwischen aufeinanderfolgenden Läufen änderten.",
+		"One column per comparison with the run before, darker cells changed more.":     "Eine Spalte je Vergleich mit dem vorherigen Lauf, dunklere Zellen änderten sich stärker.",
+		"used %d× on %s":   "%d× verwendet auf %s",
+		"Icon font glyph":  "Icon-Font-Glyphe",
+		"Font":             "Schrift",
+		"Glyph":            "Glyphe",
+		"Class":            "Klasse",
+		"Uses":             "Verwendungen",
+		"Captured Screens": "Erfasste Ansichten",
+		"Search title, URL or section (press / to focus)": "Titel, URL oder Bereich suchen (/ zum Fokussieren)",
+		"All sections":                "Alle Bereiche",
+		"Only pages with JS errors":   "Nur Seiten mit JS-Fehlern",
+		"Screenshot":                  "Screenshot",
+		"HTML":                        "HTML",
+		"Components":                  "Komponenten",
+		"Network":                     "Netzwerk",
+		"Open HTML source":            "HTML-Quelltext öffnen",
+		"Type":                        "Typ",
+		"Count":                       "Anzahl",
+		"No components analyzed":      "Keine Komponenten analysiert",
+		"Method":                      "Methode",
+		"Status":                      "Status",
+		"URL":                         "URL",
+		"No requests recorded":        "Keine Anfragen aufgezeichnet",
+		"JavaScript errors":           "JavaScript-Fehler",
+		"%d JS errors":                "%d JS-Fehler",
+		"Section":                     "Bereich",
+		"Depth":                       "Tiefe",
+		"Captured":                    "Erfasst",
+		"Navigation Links":            "Navigationslinks",
+		"... and %d more":             "... und %d weitere",
+		"No pages match the filters.": "Keine Seiten entsprechen den Filtern.",
+		"%d of %d pages":              "%d von %d Seiten",
+
+		// lightbox
+		"Close (Esc)":  "Schließen (Esc)",
+		"Previous (←)": "Zurück (←)",
+		"Next (→)":     "Weiter (→)",
+
+		// site/
+		"Agicap UI Exploration": "Agicap UI-Analyse",
+		"Run":                   "Lauf",
+		"JS Errors":             "JS-Fehler",
+		"API Calls":             "API-Aufrufe",
+		"%d components":         "%d Komponenten",
+		"%d API calls":          "%d API-Aufrufe",
+		"Previous":              "Zurück",
+		"Next":                  "Weiter",
+		"All pages":             "Alle Seiten",
+		"Route":                 "Route",
+		"%d requests":           "%d Anfragen",
+		"Also at":               "Auch unter",
+		"Text":                  "Text",
+		"Selector":              "Selektor",
+		"Width":                 "Breite",
+		"Height":                "Höhe",
+		"Console Errors":        "Konsolenfehler",
+		"Level":                 "Stufe",
+		"Source":                "Quelle",
+		"Message":               "Meldung",
+		"Location":              "Ort",
+		"No errors or warnings": "Keine Fehler oder Warnungen",
+		"Time (ms)":             "Zeit (ms)",
+		"Size (KB)":             "Größe (KB)",
+		"No API calls recorded": "Keine API-Aufrufe aufgezeichnet",
+
+		// summary.pdf
+		"Agicap UI Exploration Summary":  "Agicap UI-Analyse – Zusammenfassung",
+		"Pages":                          "Seiten",
+		"Sections":                       "Bereiche",
+		"Colors":                         "Farben",
+		"Page Inventory":                 "Seitenübersicht",
+		"Key Screens":                    "Wichtige Ansichten",
+		"Color Palette":                  "Farbpalette",
+		"No colors were extracted.":      "Es wurden keine Farben extrahiert.",
+		"Fonts":                          "Schriften",
+		"Feature Tests":                  "Funktionstests",
+		"%d of %d feature tests passed.": "%d von %d Funktionstests bestanden.",
+		"Feature":                        "Funktion",
+		"Description":                    "Beschreibung",
+		"No feature test results (run cmd/functional-explorer into the same output directory).": "Keine Ergebnisse von Funktionstests (cmd/functional-explorer in dasselbe Ausgabeverzeichnis ausführen).",
+	},
+}
+
+// germanMonths are the month names for German dates.
+var germanMonths = [...]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}
+
+// supportedLocales lists "en" and the locales with translations.
+func supportedLocales() []string {
+	locales := []string{"en"}
+	for lang := range reportLocales {
+		locales = append(locales, lang)
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// localeFuncs are the template functions that depend on the locale: lang
+// for the html lang attribute, t to translate (with printf-style
+// arguments) and date for timestamps.
+func localeFuncs(lang string) map[string]interface{} {
+	messages := reportLocales[lang]
+	return map[string]interface{}{
+		"lang": func() string { return lang },
+		"t": func(text string, args ...interface{}) string {
+			if translated, ok := messages[text]; ok {
+				text = translated
+			}
+			if len(args) > 0 {
+				return fmt.Sprintf(text, args...)
+			}
+			return text
+		},
+		"date": func(t time.Time) string {
+			if lang == "de" {
+				return fmt.Sprintf("%d. %s %d, %s Uhr", t.Day(), germanMonths[t.Month()-1], t.Year(), t.Format("15:04"))
+			}
+			return t.Format("January 2, 2006 at 3:04 PM")
+		},
+	}
+}
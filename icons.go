@@ -0,0 +1,258 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+// collectIcons finds icon-sized inline SVGs (resolving <use> references to
+// their <symbol>) and icon-font glyphs, with a name guessed from labels,
+// titles, data attributes and icon class names.
+const collectIcons = `
+(function(maxSize) {
+	const found = [];
+	const classOf = el => typeof el.className === 'string' ? el.className : (el.getAttribute('class') || '');
+	const fromClass = cls => {
+		const m = cls.match(/(?:^|\s)(?:icon|ico|fa|fas|far|fab|mdi|bi|ti|lucide|feather|ph)-([a-z0-9-]+)/i) || cls.match(/(?:^|\s)([a-z0-9-]+)-icon(?:\s|$)/i);
+		return m ? m[1] : '';
+	};
+	const nameOf = (el, extra) => {
+		const title = el.querySelector && el.querySelector('title');
+		const holder = el.closest('button, a, [role="button"]');
+		return el.getAttribute('aria-label') || (title && title.textContent) || el.getAttribute('data-icon') ||
+			el.getAttribute('data-testid') || extra || fromClass(classOf(el)) ||
+			(el.parentElement && fromClass(classOf(el.parentElement))) ||
+			(holder && (holder.getAttribute('aria-label') || holder.getAttribute('title') || holder.textContent.trim().substring(0, 30))) || '';
+	};
+
+	document.querySelectorAll('svg').forEach(svg => {
+		const rect = svg.getBoundingClientRect();
+		if (rect.width === 0 || rect.height === 0 || rect.width > maxSize || rect.height > maxSize) return;
+		let viewBox = svg.getAttribute('viewBox') || ('0 0 ' + Math.round(rect.width) + ' ' + Math.round(rect.height));
+		let inner = svg.innerHTML;
+		let symbolName = '';
+		const use = svg.querySelector('use');
+		if (use) {
+			const ref = use.getAttribute('href') || use.getAttribute('xlink:href') || '';
+			const symbol = ref.startsWith('#') && document.getElementById(ref.slice(1));
+			if (symbol) {
+				inner = symbol.innerHTML;
+				viewBox = symbol.getAttribute('viewBox') || viewBox;
+				symbolName = ref.slice(1);
+			}
+		}
+		if (!inner.trim()) return;
+		const attrs = ['fill', 'stroke', 'stroke-width', 'stroke-linecap', 'stroke-linejoin']
+			.filter(a => svg.hasAttribute(a)).map(a => a + '="' + svg.getAttribute(a) + '"').join(' ');
+		found.push({kind: 'svg', name: nameOf(svg, symbolName), viewBox: viewBox, attrs: attrs, markup: inner});
+	});
+
+	document.querySelectorAll('i, span, em').forEach(el => {
+		if (el.children.length > 0) return;
+		const style = getComputedStyle(el);
+		const before = getComputedStyle(el, '::before');
+		const family = before.fontFamily + ' ' + style.fontFamily;
+		if (!/icon|awesome|material|glyph|feather|symbols/i.test(family + ' ' + classOf(el))) return;
+		let glyph = before.content && before.content !== 'none' && before.content !== 'normal' ? before.content.replace(/^["']|["']$/g, '') : '';
+		let fontFamily = glyph ? before.fontFamily : style.fontFamily;
+		let ligature = '';
+		if (!glyph && /material|symbols/i.test(fontFamily) && el.textContent.trim()) ligature = el.textContent.trim();
+		if (!glyph && !ligature) return;
+		const codepoint = glyph ? Array.from(glyph).map(c => 'U+' + c.codePointAt(0).toString(16).toUpperCase().padStart(4, '0')).join(' ') : '';
+		found.push({kind: 'font', name: nameOf(el, ligature), fontFamily: fontFamily.split(',')[0].replace(/["']/g, '').trim(), glyph: codepoint, ligature: ligature, className: classOf(el)});
+	});
+	return found;
+})(%d)
+`
+
+// icon is a deduplicated SVG icon or icon-font glyph.
+type icon struct {
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"` // svg or font
+	File       string   `json:"file,omitempty"`
+	ViewBox    string   `json:"viewBox,omitempty"`
+	Attrs      string   `json:"attrs,omitempty"`
+	FontFamily string   `json:"fontFamily,omitempty"`
+	Glyph      string   `json:"glyph,omitempty"`
+	Ligature   string   `json:"ligature,omitempty"`
+	ClassName  string   `json:"className,omitempty"`
+	Uses       int      `json:"uses"`
+	Pages      []string `json:"pages"`
+
+	markup string
+}
+
+// iconSet collects the icons of all pages.
+type iconSet struct {
+	mu    sync.Mutex
+	byKey map[string]*icon
+	list  []*icon
+	names map[string]bool
+}
+
+func newIconSet() *iconSet {
+	return &iconSet{byKey: make(map[string]*icon), names: make(map[string]bool)}
+}
+
+var (
+	svgNoiseAttrs = regexp.MustCompile(`\s(?:class|style|id|data-[\w-]+)="[^"]*"`)
+	iconNameClean = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// extractIcons records the icons of the current page and saves new SVGs to
+// icons/svg/<name>.svg.
+func (e *AgicapExplorer) extractIcons(pageName string) {
+	var found []struct {
+		Kind       string `json:"kind"`
+		Name       string `json:"name"`
+		ViewBox    string `json:"viewBox"`
+		Attrs      string `json:"attrs"`
+		Markup     string `json:"markup"`
+		FontFamily string `json:"fontFamily"`
+		Glyph      string `json:"glyph"`
+		Ligature   string `json:"ligature"`
+		ClassName  string `json:"className"`
+	}
+	script := fmt.Sprintf(collectIcons, e.config.GetInt("explorer.capture.max_icon_size"))
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(script, &found)); err != nil {
+		e.log("⚠️ Failed to collect icons for %s: %v", pageName, err)
+		return
+	}
+
+	e.icons.mu.Lock()
+	defer e.icons.mu.Unlock()
+	added := 0
+	for _, f := range found {
+		var key string
+		if f.Kind == "svg" {
+			normalized := strings.Join(strings.Fields(svgNoiseAttrs.ReplaceAllString(f.Markup, "")), " ")
+			sum := sha1.Sum([]byte(f.ViewBox + f.Attrs + normalized))
+			key = "svg:" + hex.EncodeToString(sum[:])
+		} else {
+			key = "font:" + f.FontFamily + ":" + f.Glyph + f.Ligature
+		}
+
+		ic, ok := e.icons.byKey[key]
+		if !ok {
+			ic = &icon{
+				Name:       e.icons.uniqueName(f.Name, f.Kind),
+				Kind:       f.Kind,
+				ViewBox:    f.ViewBox,
+				Attrs:      f.Attrs,
+				FontFamily: f.FontFamily,
+				Glyph:      f.Glyph,
+				Ligature:   f.Ligature,
+				ClassName:  f.ClassName,
+				markup:     f.Markup,
+			}
+			if f.Kind == "svg" {
+				if ic.Attrs == "" {
+					ic.Attrs = `fill="currentColor"`
+				}
+				svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="%s" %s>%s</svg>`+"\n", html.EscapeString(ic.ViewBox), ic.Attrs, ic.markup)
+				path, err := e.writeArtifact(filepath.Join("icons", "svg"), ic.Name+".svg", []byte(svg))
+				if err != nil {
+					continue
+				}
+				ic.File, _ = filepath.Rel(e.outputDir, path)
+			}
+			e.icons.byKey[key] = ic
+			e.icons.list = append(e.icons.list, ic)
+			added++
+		}
+		ic.Uses++
+		ic.Pages = appendUnique(ic.Pages, pageName)
+	}
+	if added > 0 {
+		e.log("🔣 Found %d new icons on %s", added, pageName)
+	}
+}
+
+// uniqueName turns a guessed label into a file-safe icon name, numbering
+// duplicates and unnamed icons.
+func (s *iconSet) uniqueName(label, kind string) string {
+	name := strings.Trim(iconNameClean.ReplaceAllString(strings.ToLower(label), "-"), "-")
+	if len(name) > 40 {
+		name = strings.Trim(name[:40], "-")
+	}
+	if name == "" {
+		name = kind + "-icon"
+	}
+	candidate := name
+	for i := 2; s.names[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	s.names[candidate] = true
+	return candidate
+}
+
+// writeIcons writes icons/index.json and icons/sprite.svg, which holds every
+// SVG icon as a <symbol id="icon-<name>">.
+func (e *AgicapExplorer) writeIcons() error {
+	sort.SliceStable(e.icons.list, func(i, j int) bool { return e.icons.list[i].Uses > e.icons.list[j].Uses })
+
+	data, err := json.MarshalIndent(e.icons.list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := e.writeArtifact("icons", "index.json", data); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" style="display: none">` + "\n")
+	for _, ic := range e.icons.list {
+		if ic.Kind == "svg" {
+			fmt.Fprintf(&b, "  <symbol id=\"icon-%s\" viewBox=\"%s\" %s>%s</symbol>\n", ic.Name, html.EscapeString(ic.ViewBox), ic.Attrs, ic.markup)
+		}
+	}
+	b.WriteString("</svg>\n")
+	_, err = e.writeArtifact("icons", "sprite.svg", []byte(b.String()))
+	return err
+}
+
+// reportIcon is an SVG icon in the report's icon grid.
+type reportIcon struct {
+	Name  string
+	File  string
+	Uses  int
+	Pages []string
+}
+
+// reportGlyph is a row of the report's icon-font table.
+type reportGlyph struct {
+	Name, Font, Glyph, Class string
+	Uses                     int
+}
+
+// iconReport splits the icon index into the SVG grid and the glyph table of
+// report.html.
+func (e *AgicapExplorer) iconReport() ([]reportIcon, []reportGlyph) {
+	if e.icons == nil {
+		return nil, nil
+	}
+	var svgs []reportIcon
+	var glyphs []reportGlyph
+	for _, ic := range e.icons.list {
+		if ic.Kind == "svg" {
+			svgs = append(svgs, reportIcon{Name: ic.Name, File: filepath.ToSlash(ic.File), Uses: ic.Uses, Pages: ic.Pages})
+			continue
+		}
+		glyph := ic.Glyph
+		if ic.Ligature != "" {
+			glyph = "“" + ic.Ligature + "”"
+		}
+		glyphs = append(glyphs, reportGlyph{Name: ic.Name, Font: ic.FontFamily, Glyph: glyph, Class: ic.ClassName, Uses: ic.Uses})
+	}
+	return svgs, glyphs
+}
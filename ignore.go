@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// ignoreRule masks dynamic content (timestamps, balances, charts) in the
+// screenshots of the pages whose URL or title matches page, so comparing
+// runs does not flag them as changed.
+type ignoreRule struct {
+	page      *regexp.Regexp
+	selectors []string
+	rects     []image.Rectangle
+}
+
+// loadIgnoreRules reads explorer.screenshots.ignore: per rule a page regex
+// (every page when empty), CSS selectors and rectangles [x, y, width,
+// height] in screenshot pixels.
+func loadIgnoreRules(v *viper.Viper) ([]ignoreRule, error) {
+	var raw []struct {
+		Page      string
+		Selectors []string
+		Rects     [][]int
+	}
+	if err := v.UnmarshalKey("explorer.screenshots.ignore", &raw); err != nil {
+		return nil, fmt.Errorf("invalid ignore regions: %w", err)
+	}
+	rules := make([]ignoreRule, 0, len(raw))
+	for _, r := range raw {
+		page, err := regexp.Compile(r.Page)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore region page %q: %w", r.Page, err)
+		}
+		rule := ignoreRule{page: page, selectors: r.Selectors}
+		for _, rect := range r.Rects {
+			if len(rect) != 4 {
+				return nil, fmt.Errorf("ignore region %v: want [x, y, width, height]", rect)
+			}
+			rule.rects = append(rule.rects, image.Rect(rect[0], rect[1], rect[0]+rect[2], rect[1]+rect[3]))
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ignoreRegion is a masked rectangle of a screenshot, in its pixels.
+type ignoreRegion struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Source string `json:"source"` // the selector or "rect"
+}
+
+// locateIgnoredJS returns the viewport boxes of the visible elements
+// matching the selectors %s, scaled to screenshot pixels.
+const locateIgnoredJS = `
+(function(selectors) {
+	const scale = window.devicePixelRatio || 1;
+	const regions = [];
+	for (const selector of selectors) {
+		let elements = [];
+		try { elements = document.querySelectorAll(selector); } catch (e) { continue; }
+		elements.forEach(el => {
+			const r = el.getBoundingClientRect();
+			if (r.width > 0 && r.height > 0 && r.bottom > 0 && r.right > 0) {
+				regions.push({x: Math.floor(r.x * scale), y: Math.floor(r.y * scale), width: Math.ceil(r.width * scale), height: Math.ceil(r.height * scale), source: selector});
+			}
+		});
+	}
+	return regions;
+})(%s)
+`
+
+// captureIgnoreRegions records the regions the rules matching the page
+// mask to screenshots/<page>_ignore.json, located right after the
+// screenshot so they line up with it.
+func (e *AgicapExplorer) captureIgnoreRegions(pageName, pageURL, title string) {
+	var selectors []string
+	regions := []ignoreRegion{}
+	for _, rule := range e.ignore {
+		if !rule.page.MatchString(pageURL) && !rule.page.MatchString(title) {
+			continue
+		}
+		selectors = append(selectors, rule.selectors...)
+		for _, r := range rule.rects {
+			regions = append(regions, ignoreRegion{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy(), Source: "rect"})
+		}
+	}
+	if len(selectors) > 0 {
+		list, _ := json.Marshal(selectors)
+		var located []ignoreRegion
+		if err := chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(locateIgnoredJS, list), &located)); err != nil {
+			e.log("⚠️ Failed to locate ignore regions on %s: %v", pageName, err)
+		}
+		regions = append(regions, located...)
+	}
+	if len(regions) == 0 {
+		return
+	}
+	data, err := json.MarshalIndent(regions, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("screenshots", sanitize(pageName)+"_ignore.json", data); err != nil {
+		e.log("⚠️ Failed to write ignore regions for %s: %v", pageName, err)
+	}
+}
+
+// ignoreFile is where a run recorded the ignore regions of a page.
+func ignoreFile(runDir string, item NavigationItem) string {
+	return filepath.Join(runDir, "screenshots", pageKey(item)+"_ignore.json")
+}
+
+// ignoredRegions reads the ignore regions a run recorded for a page.
+func ignoredRegions(runDir string, item NavigationItem) []image.Rectangle {
+	data, err := ioutil.ReadFile(ignoreFile(runDir, item))
+	if err != nil {
+		return nil
+	}
+	var regions []ignoreRegion
+	if json.Unmarshal(data, &regions) != nil {
+		return nil
+	}
+	rects := make([]image.Rectangle, 0, len(regions))
+	for _, r := range regions {
+		rects = append(rects, image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height))
+	}
+	return rects
+}
+
+// ignoreColor fills masked regions, the same in both images of a diff.
+var ignoreColor = color.RGBA{203, 213, 224, 255}
+
+// decodeMasked decodes a screenshot with the ignored regions filled.
+func decodeMasked(path string, ignore []image.Rectangle) (image.Image, error) {
+	img, err := decodeImage(path)
+	if err != nil || len(ignore) == 0 {
+		return img, err
+	}
+	bounds := img.Bounds()
+	masked := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(masked, masked.Bounds(), img, bounds.Min, draw.Src)
+	for _, r := range ignore {
+		draw.Draw(masked, r.Intersect(masked.Bounds()), &image.Uniform{ignoreColor}, image.Point{}, draw.Src)
+	}
+	return masked, nil
+}
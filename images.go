@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// collectImages lists the <img>, <input type="image">, role="img" and
+// <svg> elements of the page (svgs only above icon size, the icon set covers
+// the rest) and elements with a CSS background image, with their alt text
+// and displayed and natural size.
+const collectImages = `
+(function() {
+	const text = (s) => (s || '').trim().replace(/\s+/g, ' ').substring(0, 200);
+	const describe = (el) => el.tagName.toLowerCase() + (el.id ? '#' + el.id : '') +
+		(typeof el.className === 'string' && el.className.trim() ? '.' + el.className.trim().split(/\s+/).slice(0, 2).join('.') : '');
+	const images = [];
+	const seen = new Set();
+	const add = (el, kind, src, extra) => {
+		if (seen.has(el)) return;
+		seen.add(el);
+		const rect = el.getBoundingClientRect();
+		const style = getComputedStyle(el);
+		const control = el.parentElement && el.parentElement.closest('a, button, [role="button"], [role="link"]');
+		images.push(Object.assign({
+			kind: kind,
+			src: (src || '').substring(0, 500),
+			hasAlt: el.hasAttribute('alt'),
+			alt: text(el.getAttribute('alt')),
+			ariaLabel: text(el.getAttribute('aria-label')),
+			title: text(el.getAttribute('title')),
+			role: el.getAttribute('role') || '',
+			ariaHidden: el.closest('[aria-hidden="true"]') !== null,
+			width: Math.round(rect.width),
+			height: Math.round(rect.height),
+			naturalWidth: 0,
+			naturalHeight: 0,
+			visible: rect.width > 0 && rect.height > 0 && style.visibility !== 'hidden' && style.display !== 'none',
+			inControl: control !== null,
+			controlText: control ? text(control.textContent) : '',
+			element: describe(el),
+		}, extra || {}));
+	};
+
+	document.querySelectorAll('img, input[type="image"]').forEach(el => add(el, el.tagName === 'IMG' ? 'img' : 'input', el.currentSrc || el.src,
+		{naturalWidth: el.naturalWidth || 0, naturalHeight: el.naturalHeight || 0}));
+	document.querySelectorAll('[role="img"]').forEach(el => add(el, 'role-img', el.getAttribute('src') || ''));
+	document.querySelectorAll('svg').forEach(el => {
+		if (el.parentElement && el.parentElement.closest('svg')) return;
+		const rect = el.getBoundingClientRect();
+		if (rect.width > %d || rect.height > %d) {
+			const label = el.querySelector(':scope > title');
+			add(el, 'svg', '', {alt: label ? text(label.textContent) : ''});
+		}
+	});
+	let backgrounds = 0;
+	for (const el of document.body.querySelectorAll('*')) {
+		if (backgrounds >= 200) break;
+		const m = getComputedStyle(el).backgroundImage.match(/url\(["']?([^"')]+)["']?\)/);
+		if (m && !m[1].startsWith('data:image/svg')) {
+			add(el, 'background', m[1]);
+			backgrounds++;
+		}
+	}
+	return images;
+})()
+`
+
+// pageImage is an image of a page with the explorer's guess at its usage.
+type pageImage struct {
+	Kind          string `json:"kind"` // img, input, role-img, svg, background
+	Src           string `json:"src"`
+	HasAlt        bool   `json:"hasAlt"`
+	Alt           string `json:"alt"`
+	AriaLabel     string `json:"ariaLabel,omitempty"`
+	Title         string `json:"title,omitempty"`
+	Role          string `json:"role,omitempty"`
+	AriaHidden    bool   `json:"ariaHidden,omitempty"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	NaturalWidth  int    `json:"naturalWidth,omitempty"`
+	NaturalHeight int    `json:"naturalHeight,omitempty"`
+	Visible       bool   `json:"visible"`
+	InControl     bool   `json:"inControl,omitempty"`
+	ControlText   string `json:"controlText,omitempty"`
+	Element       string `json:"element"`
+	Usage         string `json:"usage"`           // decorative or informative
+	Reason        string `json:"reason"`          // why Usage was chosen
+	Issue         string `json:"issue,omitempty"` // what is wrong with the alt text
+}
+
+// classifyImage guesses whether an image is decorative or informative and
+// flags alt text that does not fit: img elements without an alt attribute,
+// informative images without a text alternative, and alt text that is just
+// the file name.
+func classifyImage(img *pageImage) {
+	name := img.Alt
+	if name == "" {
+		name = img.AriaLabel
+	}
+	if name == "" {
+		name = img.Title
+	}
+
+	switch {
+	case img.AriaHidden || img.Role == "presentation" || img.Role == "none":
+		img.Usage, img.Reason = "decorative", "hidden from assistive technology"
+	case img.Kind == "background":
+		img.Usage, img.Reason = "decorative", "CSS background"
+	case img.HasAlt && img.Alt == "" && img.AriaLabel == "":
+		img.Usage, img.Reason = "decorative", "empty alt"
+	case img.Width <= 2 && img.Height <= 2:
+		img.Usage, img.Reason = "decorative", "tracking pixel or spacer"
+	case img.InControl && img.ControlText != "":
+		img.Usage, img.Reason = "decorative", "next to the text of its link or button"
+	case img.InControl:
+		img.Usage, img.Reason = "informative", "only content of a link or button"
+	case name != "":
+		img.Usage, img.Reason = "informative", "has a text alternative"
+	case img.Width >= 100 && img.Height >= 100:
+		img.Usage, img.Reason = "informative", "content-sized"
+	default:
+		img.Usage, img.Reason = "decorative", "small, no text alternative"
+	}
+
+	switch {
+	case img.Kind == "img" && !img.HasAlt && !img.AriaHidden && img.Role != "presentation" && img.Role != "none":
+		img.Issue = "missing alt attribute"
+	case img.Usage == "informative" && name == "":
+		img.Issue = "informative image without a text alternative"
+	case name != "" && img.Src != "":
+		base := filepath.Base(strings.SplitN(img.Src, "?", 2)[0])
+		if name == base || name == strings.TrimSuffix(base, filepath.Ext(base)) {
+			img.Issue = "alt text is the file name"
+		}
+	}
+}
+
+// captureImages writes the image inventory of the current page to
+// a11y/<page>_images.json.
+func (e *AgicapExplorer) captureImages(pageName string) {
+	var images []pageImage
+	maxIcon := e.config.GetInt("explorer.capture.max_icon_size")
+	script := fmt.Sprintf(collectImages, maxIcon, maxIcon)
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(script, &images)); err != nil {
+		e.log("⚠️ Image inventory failed for %s: %v", pageName, err)
+		return
+	}
+	issues := 0
+	for i := range images {
+		classifyImage(&images[i])
+		if images[i].Issue != "" {
+			issues++
+		}
+	}
+	if images == nil {
+		images = []pageImage{}
+	}
+
+	data, err := json.MarshalIndent(images, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("a11y", sanitize(pageName)+"_images.json", data); err != nil {
+		e.log("⚠️ Failed to write image inventory for %s: %v", pageName, err)
+	}
+	if issues > 0 {
+		e.log("🖼️ %d of %d images on %s have alt text issues", issues, len(images), pageName)
+	}
+}
+
+// imageInventory is image_inventory.json: every image source of the run
+// with the pages it appears on.
+type imageInventory struct {
+	Images      int            `json:"images"`
+	Decorative  int            `json:"decorative"`
+	Informative int            `json:"informative"`
+	Issues      map[string]int `json:"issues"`
+	Sources     []imageSource  `json:"sources"`
+}
+
+type imageSource struct {
+	Src    string   `json:"src"`
+	Kind   string   `json:"kind"`
+	Usage  string   `json:"usage"`
+	Alts   []string `json:"alts"`
+	Issues []string `json:"issues,omitempty"`
+	Pages  []string `json:"pages"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+}
+
+// writeImageInventory combines the a11y/<page>_images.json files of the run
+// by source, most used first.
+func (e *AgicapExplorer) writeImageInventory() error {
+	inventory := imageInventory{Issues: make(map[string]int), Sources: []imageSource{}}
+	bySrc := make(map[string]*imageSource)
+	var order []string
+	for _, item := range e.navigationMap {
+		key := pageKey(item)
+		data, err := ioutil.ReadFile(filepath.Join(e.outputDir, "a11y", key+"_images.json"))
+		if err != nil {
+			continue
+		}
+		var images []pageImage
+		if json.Unmarshal(data, &images) != nil {
+			continue
+		}
+		for _, img := range images {
+			inventory.Images++
+			if img.Usage == "decorative" {
+				inventory.Decorative++
+			} else {
+				inventory.Informative++
+			}
+			if img.Issue != "" {
+				inventory.Issues[img.Issue]++
+			}
+
+			id := img.Src
+			if id == "" {
+				id = img.Kind + " " + img.Element
+			}
+			src, ok := bySrc[id]
+			if !ok {
+				src = &imageSource{Src: img.Src, Kind: img.Kind, Usage: img.Usage, Width: img.Width, Height: img.Height}
+				bySrc[id] = src
+				order = append(order, id)
+			}
+			// One informative use makes the image informative
+			if img.Usage == "informative" {
+				src.Usage = "informative"
+			}
+			if img.Alt != "" {
+				src.Alts = appendUnique(src.Alts, img.Alt)
+			}
+			if img.Issue != "" {
+				src.Issues = appendUnique(src.Issues, img.Issue)
+			}
+			src.Pages = appendUnique(src.Pages, key)
+		}
+	}
+	for _, id := range order {
+		src := bySrc[id]
+		if src.Alts == nil {
+			src.Alts = []string{}
+		}
+		inventory.Sources = append(inventory.Sources, *src)
+	}
+	sort.SliceStable(inventory.Sources, func(i, j int) bool {
+		return len(inventory.Sources[i].Pages) > len(inventory.Sources[j].Pages)
+	})
+
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "image_inventory.json"), data, 0644)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// previousRun is the run an incremental crawl compares against. Pages whose
+// structure hash is unchanged since then are not captured again: their
+// navigation items and artifacts are taken over under the same page key.
+type previousRun struct {
+	dir     string                      // where its artifacts are now
+	written string                      // the output directory its paths were written with
+	pages   map[string]NavigationItem   // page captures by canonical URL
+	extras  map[string][]NavigationItem // interaction and form captures by page key
+
+	mu      sync.Mutex
+	claimed map[string]bool // page keys used by this run
+	reused  int
+}
+
+// loadPreviousRun reads the navigation map of explorer.incremental.previous,
+// or of the output directory this run is about to overwrite. It returns nil
+// when there is nothing to compare against.
+func loadPreviousRun(dir string) (*previousRun, error) {
+	items, err := loadNavigationMap(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &previousRun{
+		dir:     dir,
+		written: dir,
+		pages:   make(map[string]NavigationItem),
+		extras:  make(map[string][]NavigationItem),
+		claimed: make(map[string]bool),
+	}
+	for _, item := range items {
+		if item.Screenshot != "" {
+			p.written = filepath.Dir(filepath.Dir(item.Screenshot))
+			break
+		}
+	}
+	// Pages come before their interaction and form captures
+	for _, item := range items {
+		key := pageKey(item)
+		if item.ContentHash != "" {
+			if _, ok := p.pages[item.CanonicalURL]; !ok {
+				p.pages[item.CanonicalURL] = item
+			}
+			continue
+		}
+		for _, page := range p.pages {
+			if base := pageKey(page); strings.HasPrefix(key, base+"_") {
+				p.extras[base] = append(p.extras[base], item)
+				break
+			}
+		}
+	}
+	return p, nil
+}
+
+// keysConflict reports whether the artifacts of two page keys could be
+// confused: an interaction capture of a is named a_interaction_1, so b must
+// not start with a_ either.
+func keysConflict(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"_") || strings.HasPrefix(b, a+"_")
+}
+
+// claim reserves a page key for this run unless it conflicts with one
+// already used.
+func (p *previousRun) claim(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for claimed := range p.claimed {
+		if keysConflict(key, claimed) {
+			return false
+		}
+	}
+	p.claimed[key] = true
+	return true
+}
+
+// pageName returns the name of the n-th capture, with a letter after the
+// number if a page taken over from the previous run already uses the key.
+func (p *previousRun) pageName(n int, text string) string {
+	name := fmt.Sprintf("%02d_%s", n, text)
+	for suffix := 'b'; !p.claim(name) && suffix <= 'z'; suffix++ {
+		name = fmt.Sprintf("%02d%c_%s", n, suffix, text)
+	}
+	return name
+}
+
+// pageName names the n-th capture of this run.
+func (e *AgicapExplorer) pageName(n int, text string) string {
+	if e.previous != nil {
+		return e.previous.pageName(n, text)
+	}
+	return fmt.Sprintf("%02d_%s", n, text)
+}
+
+// unchanged returns the previous capture of a canonical URL when the page
+// still has the same structure hash.
+func (p *previousRun) unchanged(canonicalURL string, hash uint64) (NavigationItem, bool) {
+	item, ok := p.pages[canonicalURL]
+	if !ok || item.ContentHash != fmt.Sprintf("%016x", hash) {
+		return NavigationItem{}, false
+	}
+	return item, true
+}
+
+// reuseCapture takes over the previous run's capture of the target when its
+// structure is unchanged: the navigation items of the page and of its
+// interactions, and (from another directory) every artifact named after the
+// page key. It returns false when the page has to be captured.
+func (e *AgicapExplorer) reuseCapture(target crawlTarget, hash uint64) bool {
+	p := e.previous
+	if p == nil {
+		return false
+	}
+	prev, ok := p.unchanged(target.Canonical, hash)
+	if !ok {
+		return false
+	}
+	key := pageKey(prev)
+	if !p.claim(key) {
+		return false
+	}
+
+	if !sameDir(p.dir, e.outputDir) {
+		if err := p.copyArtifacts(key, e.outputDir); err != nil {
+			e.log("⚠️ Failed to copy the previous capture of %s, capturing it again: %v", target.URL, err)
+			return false
+		}
+	}
+
+	items := append([]NavigationItem{prev}, p.extras[key]...)
+	for i := range items {
+		item := &items[i]
+		item.Screenshot = p.rebase(item.Screenshot, e.outputDir)
+		item.Thumbnail = p.rebase(item.Thumbnail, e.outputDir)
+		item.PDF = p.rebase(item.PDF, e.outputDir)
+		item.Archive = p.rebase(item.Archive, e.outputDir)
+		item.HAR = p.rebase(item.HAR, e.outputDir)
+		item.Console = p.rebase(item.Console, e.outputDir)
+		item.Responsive = p.rebase(item.Responsive, e.outputDir)
+		item.Viewports = p.rebase(item.Viewports, e.outputDir)
+		item.Throttling = p.rebase(item.Throttling, e.outputDir)
+		item.Print = p.rebase(item.Print, e.outputDir)
+		item.Scroll = p.rebase(item.Scroll, e.outputDir)
+		item.Zoom = p.rebase(item.Zoom, e.outputDir)
+		item.Locales = p.rebase(item.Locales, e.outputDir)
+		item.Overlays = p.rebase(item.Overlays, e.outputDir)
+		item.Tables = p.rebase(item.Tables, e.outputDir)
+		item.Depth = target.Depth
+		item.Section = target.Section
+		item.Aliases = nil
+	}
+
+	e.graph.AddPage(prev.CanonicalURL, prev.Title, sectionOf(prev.CanonicalURL))
+	for _, link := range prev.Navigation {
+		text, href, _ := strings.Cut(link, " → ")
+		if strings.HasPrefix(href, "http") {
+			inScope, _ := e.scope.Allows(href)
+			e.graph.AddEdge(prev.CanonicalURL, e.canonical.Canonical(href), text, !inScope)
+		}
+	}
+
+	e.mu.Lock()
+	s := e.shared()
+	index := len(s.navigationMap)
+	for _, item := range items {
+		s.visitedURLs[item.CanonicalURL] = true
+	}
+	s.visitedURLs[target.Canonical] = true
+	s.navigationMap = append(s.navigationMap, items...)
+	for i := index; i < len(s.navigationMap); i++ {
+		s.streamItem(i)
+	}
+	s.fingerprints = append(s.fingerprints, pageFingerprint{hash: hash, index: index})
+	e.lastCapture = index
+	e.mu.Unlock()
+
+	p.mu.Lock()
+	p.reused++
+	p.mu.Unlock()
+	e.log("♻️ Unchanged since the previous run, reusing %s: %s", key, prev.Title)
+	return true
+}
+
+// rebase moves a path written by the previous run into dir.
+func (p *previousRun) rebase(path, dir string) string {
+	if path == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(p.written, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.Join(dir, rel)
+}
+
+// copyArtifacts copies the files of a page (named after its key, or in a
+// directory named after it) from the previous run into dir. Paths inside
+// JSON files are rewritten to point into dir.
+func (p *previousRun) copyArtifacts(key, dir string) error {
+	matches := func(name string) bool {
+		return name == key || strings.HasPrefix(name, key+"_") || strings.HasPrefix(name, key+".")
+	}
+	return filepath.Walk(p.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(p.dir, path)
+		if info.IsDir() {
+			// The site and schemas are generated again from the navigation map
+			if rel == "site" || rel == "schemas" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		found := false
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			if matches(part) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(path) == ".json" && p.written != dir {
+			data = bytes.ReplaceAll(data, []byte(p.written+"/"), []byte(filepath.Clean(dir)+"/"))
+		}
+		target := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, 0644)
+	})
+}
+
+// sameDir reports whether two paths name the same directory.
+func sameDir(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
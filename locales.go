@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// truncatedTextJS lists the elements whose own text is cut off by their
+// box (overflow hidden or an ellipsis), keyed by keyOf.
+const truncatedTextJS = `
+(function() {` + domKeyJS + `
+	const truncated = [];
+	for (const el of document.body.querySelectorAll('*')) {
+		if (truncated.length >= 100) break;
+		if (!Array.from(el.childNodes).some(n => n.nodeType === 3 && n.textContent.trim())) continue;
+		const style = getComputedStyle(el);
+		if (style.overflowX === 'visible' && style.textOverflow !== 'ellipsis') continue;
+		if (el.clientWidth > 0 && el.scrollWidth > el.clientWidth + 1) {
+			truncated.push({key: keyOf(el), text: el.textContent.trim().replace(/\s+/g, ' ').substring(0, 80)});
+		}
+	}
+	return truncated;
+})()
+`
+
+// localeSnapshot is a page rendered in one locale.
+type localeSnapshot struct {
+	Locale     string
+	URL        string
+	Screenshot string
+	Lang       string
+	Viewport   int
+	Layout     layoutSnapshot
+	Strings    []uiString
+	Truncated  []truncatedText
+}
+
+type truncatedText struct {
+	Key  string `json:"key"`
+	Text string `json:"text"`
+}
+
+// localeResult is what changed in one locale against the page's base
+// locale.
+type localeResult struct {
+	Locale       string          `json:"locale"`
+	URL          string          `json:"url"`
+	Screenshot   string          `json:"screenshot"`
+	Lang         string          `json:"lang"`              // of the document
+	Switched     bool            `json:"switched"`          // the document language is the locale
+	Strings      int             `json:"strings"`           // visible strings
+	Untranslated []uiString      `json:"untranslated"`      // strings in another configured locale
+	RawKeys      []uiString      `json:"raw_keys"`          // message keys shown instead of text
+	Overflow     bool            `json:"overflow"`          // horizontal scrolling the base locale has not
+	Truncated    []truncatedText `json:"truncated"`         // texts cut off only in this locale
+	Layout       []string        `json:"layout"`            // components that grow or break out
+	Base         bool            `json:"base,omitempty"`    // the locale the others are compared with
+	Error        string          `json:"error,omitempty"`   // why the locale could not be rendered
+	Missing      int             `json:"missing,omitempty"` // structural elements of the base not found
+}
+
+// Issues counts the missing translations and layout breakages.
+func (r localeResult) Issues() int {
+	n := len(r.Untranslated) + len(r.RawKeys) + len(r.Truncated) + len(r.Layout)
+	if r.Overflow {
+		n++
+	}
+	return n
+}
+
+// pageLocales is locales/<page>/locales.json.
+type pageLocales struct {
+	Page    string         `json:"page"`
+	Base    string         `json:"base"`
+	Locales []localeResult `json:"locales"`
+}
+
+// captureLocales renders the page once per locale of explorer.locales.list,
+// switched the way explorer.locales.switch says, and compares the strings
+// and layout of each with the base locale (the page's own language). It
+// writes locales/<page>/locales.json with a screenshot per locale and
+// leaves the page as it found it.
+func (e *AgicapExplorer) captureLocales(pageName, pageURL string) string {
+	locales := e.config.GetStringSlice("explorer.locales.list")
+	if len(locales) < 2 {
+		return ""
+	}
+	mode := e.config.GetString("explorer.locales.switch")
+	key := e.config.GetString("explorer.locales.key")
+	dir := filepath.Join("locales", sanitize(pageName))
+
+	var base string
+	chromedp.Run(e.ctx, chromedp.Evaluate(`(document.documentElement.lang || '').toLowerCase().split('-')[0]`, &base))
+	restore := e.localeRestorer(mode, key, pageURL)
+
+	var snapshots []localeSnapshot
+	result := pageLocales{Page: pageName}
+	for _, locale := range locales {
+		snap := localeSnapshot{Locale: locale, URL: pageURL}
+		if mode == "prefix" {
+			snap.URL = localeURL(pageURL, locale, locales)
+		}
+		var shot []byte
+		err := chromedp.Run(e.ctx,
+			e.switchLocale(mode, key, locale, snap.URL),
+			e.settle("navigation"),
+			chromedp.Evaluate(`(document.documentElement.lang || '').toLowerCase().split('-')[0]`, &snap.Lang),
+			chromedp.Evaluate(`window.innerWidth`, &snap.Viewport),
+			chromedp.Evaluate(layoutSnapshotJS, &snap.Layout),
+			chromedp.Evaluate(truncatedTextJS, &snap.Truncated),
+			chromedp.CaptureScreenshot(&shot),
+		)
+		if err != nil {
+			e.log("⚠️ Locale %s failed for %s: %v", locale, pageName, err)
+			result.Locales = append(result.Locales, localeResult{Locale: locale, URL: snap.URL, Error: err.Error()})
+			continue
+		}
+		var found struct {
+			Strings []uiString `json:"strings"`
+		}
+		chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(collectStrings, e.config.GetInt("explorer.capture.max_strings")), &found))
+		for _, s := range found.Strings {
+			s.Text = e.redactor.Redact(s.Text)
+			s.Lang = detectLanguage(s.Text)
+			snap.Strings = append(snap.Strings, s)
+		}
+		if path, err := e.writeArtifact(dir, sanitize(locale)+".png", shot); err == nil {
+			snap.Screenshot, _ = filepath.Rel(e.outputDir, path)
+			snap.Screenshot = filepath.ToSlash(snap.Screenshot)
+		}
+		snapshots = append(snapshots, snap)
+	}
+	restore()
+	if len(snapshots) == 0 {
+		return ""
+	}
+
+	result.Base, result.Locales = compareLocales(snapshots, base, locales, result.Locales)
+	issues := 0
+	for _, r := range result.Locales {
+		issues += r.Issues()
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact(dir, "locales.json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write locale comparison for %s: %v", pageName, err)
+		return ""
+	}
+	e.log("🌍 %s: %d locales, %d translation or layout issues", pageName, len(snapshots), issues)
+	return path
+}
+
+// localeURL puts the locale in front of the path of rawURL, replacing a
+// configured locale already there: /de/payments becomes /en/payments.
+func localeURL(rawURL, locale string, locales []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	segments := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	for _, l := range locales {
+		if strings.EqualFold(segments[0], l) {
+			segments = segments[1:]
+			break
+		}
+	}
+	u.Path = "/" + strings.TrimSuffix(strings.Join(append([]string{locale}, segments...), "/"), "/")
+	return u.String()
+}
+
+// switchLocale loads the page in a locale: by URL prefix, through the
+// localStorage entry or cookie named key that holds the in-app language
+// setting, or with the Accept-Language header and Intl locale of the
+// browser.
+func (e *AgicapExplorer) switchLocale(mode, key, locale, pageURL string) chromedp.Action {
+	switch mode {
+	case "storage":
+		return chromedp.Tasks{
+			chromedp.Evaluate(fmt.Sprintf(`localStorage.setItem(%q, %q)`, key, locale), nil),
+			chromedp.Reload(),
+		}
+	case "cookie":
+		return chromedp.Tasks{
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				return network.SetCookie(key, locale).WithURL(pageURL).Do(ctx)
+			}),
+			chromedp.Reload(),
+		}
+	case "header":
+		return chromedp.Tasks{
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				if err := network.SetExtraHTTPHeaders(network.Headers{"Accept-Language": locale}).Do(ctx); err != nil {
+					return err
+				}
+				return emulation.SetLocaleOverride().WithLocale(locale).Do(ctx)
+			}),
+			chromedp.Reload(),
+		}
+	}
+	return chromedp.Navigate(pageURL)
+}
+
+// localeRestorer remembers the language setting switchLocale changes and
+// returns a function that puts it back and reloads the page.
+func (e *AgicapExplorer) localeRestorer(mode, key, pageURL string) func() {
+	var actions chromedp.Tasks
+	switch mode {
+	case "storage":
+		var original *string
+		chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(`localStorage.getItem(%q)`, key), &original))
+		if original != nil {
+			actions = append(actions, chromedp.Evaluate(fmt.Sprintf(`localStorage.setItem(%q, %q)`, key, *original), nil))
+		} else {
+			actions = append(actions, chromedp.Evaluate(fmt.Sprintf(`localStorage.removeItem(%q)`, key), nil))
+		}
+	case "cookie":
+		var original *network.Cookie
+		chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			cookies, err := network.GetCookies().WithUrls([]string{pageURL}).Do(ctx)
+			for _, c := range cookies {
+				if c.Name == key {
+					original = c
+				}
+			}
+			return err
+		}))
+		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+			if original == nil {
+				return network.DeleteCookies(key).WithURL(pageURL).Do(ctx)
+			}
+			return network.SetCookie(original.Name, original.Value).WithDomain(original.Domain).WithPath(original.Path).Do(ctx)
+		}))
+	case "header":
+		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+			if err := network.SetExtraHTTPHeaders(network.Headers{}).Do(ctx); err != nil {
+				return err
+			}
+			return emulation.SetLocaleOverride().Do(ctx)
+		}))
+	}
+	return func() {
+		actions = append(actions, chromedp.Navigate(pageURL), e.settle("navigation"))
+		if err := chromedp.Run(e.ctx, actions); err != nil {
+			e.log("⚠️ Failed to restore the locale of %s: %v", pageURL, err)
+		}
+	}
+}
+
+// rawKeyPattern matches message keys like "dashboard.cashflow_title" shown
+// where their translation is missing; a last segment of two or three
+// letters is a domain more likely than a key.
+var rawKeyPattern = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*(\.[a-zA-Z0-9_-]+)+$`)
+
+func isRawKey(text string) bool {
+	if !rawKeyPattern.MatchString(text) {
+		return false
+	}
+	last := text[strings.LastIndex(text, ".")+1:]
+	return len(last) > 3 || strings.ContainsAny(last, "_-")
+}
+
+// compareLocales checks every locale's snapshot for strings left in another
+// configured language and for message keys, and against the base snapshot
+// for horizontal overflow, truncated texts and components that grow by half
+// their height (wrapping text) or out of the viewport. The base is the
+// snapshot in the page's language, or the first one.
+func compareLocales(snapshots []localeSnapshot, lang string, locales []string, failed []localeResult) (string, []localeResult) {
+	configured := make(map[string]bool)
+	for _, l := range locales {
+		configured[strings.SplitN(strings.ToLower(l), "-", 2)[0]] = true
+	}
+	base := snapshots[0]
+	for _, snap := range snapshots {
+		if strings.EqualFold(snap.Locale, lang) {
+			base = snap
+			break
+		}
+	}
+	baseNodes := make(map[string]layoutNode)
+	for _, n := range base.Layout.Nodes {
+		baseNodes[n.Key] = n
+	}
+	baseTruncated := make(map[string]bool)
+	for _, t := range base.Truncated {
+		baseTruncated[t.Key] = true
+	}
+
+	results := failed
+	for _, snap := range snapshots {
+		locale := strings.ToLower(snap.Locale)
+		r := localeResult{
+			Locale:       snap.Locale,
+			URL:          snap.URL,
+			Screenshot:   snap.Screenshot,
+			Lang:         snap.Lang,
+			Switched:     strings.HasPrefix(locale, snap.Lang) && snap.Lang != "",
+			Strings:      len(snap.Strings),
+			Untranslated: []uiString{},
+			RawKeys:      []uiString{},
+			Truncated:    []truncatedText{},
+			Layout:       []string{},
+			Base:         snap.Locale == base.Locale,
+		}
+		for _, s := range snap.Strings {
+			switch {
+			case isRawKey(s.Text):
+				r.RawKeys = append(r.RawKeys, s)
+			case configured[s.Lang] && !strings.HasPrefix(locale, s.Lang):
+				r.Untranslated = append(r.Untranslated, s)
+			}
+		}
+		if !r.Base {
+			r.Overflow = snap.Layout.ScrollWidth > snap.Viewport && base.Layout.ScrollWidth <= base.Viewport
+			for _, t := range snap.Truncated {
+				if !baseTruncated[t.Key] {
+					r.Truncated = append(r.Truncated, t)
+				}
+			}
+			seen := make(map[string]bool)
+			for _, n := range snap.Layout.Nodes {
+				seen[n.Key] = true
+				b, ok := baseNodes[n.Key]
+				if !ok || !n.Visible || !b.Visible || len(r.Layout) >= 50 {
+					continue
+				}
+				switch {
+				case n.X+n.Width > float64(snap.Viewport)+1 && b.X+b.Width <= float64(base.Viewport)+1:
+					r.Layout = append(r.Layout, fmt.Sprintf("%s extends beyond the viewport (%.0fpx wide, %.0fpx in %s)", n.Label, n.Width, b.Width, base.Locale))
+				case n.Height > 1.5*b.Height && n.Height-b.Height > 16:
+					r.Layout = append(r.Layout, fmt.Sprintf("%s grows from %.0fpx to %.0fpx high", n.Label, b.Height, n.Height))
+				}
+			}
+			for key := range baseNodes {
+				if !seen[key] {
+					r.Missing++
+				}
+			}
+		}
+		results = append(results, r)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return indexOf(locales, results[i].Locale) < indexOf(locales, results[j].Locale) })
+	return base.Locale, results
+}
+
+func indexOf(list []string, value string) int {
+	for i, v := range list {
+		if v == value {
+			return i
+		}
+	}
+	return len(list)
+}
+
+// localeSummary is a locale's row of locale_report.json.
+type localeSummary struct {
+	Locale       string `json:"locale"`
+	Pages        int    `json:"pages"`
+	NotSwitched  int    `json:"not_switched"` // pages whose document language stayed another
+	Untranslated int    `json:"untranslated"`
+	RawKeys      int    `json:"raw_keys"`
+	Overflow     int    `json:"overflow"`
+	Truncated    int    `json:"truncated"`
+	Layout       int    `json:"layout"`
+	Failed       int    `json:"failed"`
+}
+
+// localeIssue is a page with missing translations or layout breakage in a
+// locale.
+type localeIssue struct {
+	Page     string `json:"page"`
+	Title    string `json:"title"`
+	Base     string `json:"base"`
+	Files    string `json:"files"`
+	Examples string `json:"examples"` // the first untranslated strings
+	localeResult
+}
+
+// localeReport is locale_report.json.
+type localeReport struct {
+	Locales []localeSummary `json:"locales"`
+	Issues  []localeIssue   `json:"issues"`
+}
+
+// localeRollup combines the locales/<page>/locales.json files of the run.
+func (e *AgicapExplorer) localeRollup() localeReport {
+	report := localeReport{Locales: []localeSummary{}, Issues: []localeIssue{}}
+	summaries := make(map[string]*localeSummary)
+	var order []string
+	for _, item := range e.navigationMap {
+		if item.Locales == "" {
+			continue
+		}
+		key := pageKey(item)
+		files, _ := filepath.Rel(e.outputDir, filepath.Dir(item.Locales))
+		data, err := ioutil.ReadFile(item.Locales)
+		if err != nil {
+			continue
+		}
+		var page pageLocales
+		if json.Unmarshal(data, &page) != nil {
+			continue
+		}
+		for _, r := range page.Locales {
+			s, ok := summaries[r.Locale]
+			if !ok {
+				s = &localeSummary{Locale: r.Locale}
+				summaries[r.Locale] = s
+				order = append(order, r.Locale)
+			}
+			s.Pages++
+			if r.Error != "" {
+				s.Failed++
+				continue
+			}
+			if !r.Switched {
+				s.NotSwitched++
+			}
+			s.Untranslated += len(r.Untranslated)
+			s.RawKeys += len(r.RawKeys)
+			s.Truncated += len(r.Truncated)
+			s.Layout += len(r.Layout)
+			if r.Overflow {
+				s.Overflow++
+			}
+			if r.Issues() > 0 {
+				var examples []string
+				for _, u := range append(r.RawKeys, r.Untranslated...) {
+					if len(examples) == 3 {
+						break
+					}
+					examples = append(examples, u.Text)
+				}
+				report.Issues = append(report.Issues, localeIssue{
+					Page: key, Title: item.Title, Base: page.Base, Files: filepath.ToSlash(files),
+					Examples: strings.Join(examples, " · "), localeResult: r,
+				})
+			}
+		}
+	}
+	for _, locale := range order {
+		report.Locales = append(report.Locales, *summaries[locale])
+	}
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Issues() > report.Issues[j].Issues() })
+	return report
+}
+
+// writeLocaleReport saves locale_report.json.
+func (e *AgicapExplorer) writeLocaleReport(report localeReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "locale_report.json"), data, 0644)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// captureBuckets are the upper bounds in seconds of the capture duration
+// histogram.
+var captureBuckets = []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120}
+
+// metrics are the counters of a long-running explorer, served on /metrics
+// in the Prometheus text format. A nil *metrics counts nothing, so the
+// crawl reports to runMetrics whether or not it is served.
+type metrics struct {
+	mu                 sync.Mutex
+	pages              float64
+	navigationFailures float64
+	logins             map[string]float64 // by result
+	restarts           float64
+	runs               map[string]float64 // by result
+	lastRun            map[string]time.Time
+	captureCounts      []float64 // per bucket, not cumulative
+	captureCount       float64
+	captureSum         float64
+}
+
+// runMetrics is set by watch when explorer.watch.metrics is.
+var runMetrics *metrics
+
+func newMetrics() *metrics {
+	return &metrics{
+		logins:        make(map[string]float64),
+		runs:          make(map[string]float64),
+		lastRun:       make(map[string]time.Time),
+		captureCounts: make([]float64, len(captureBuckets)),
+	}
+}
+
+func resultLabel(ok bool) string {
+	if ok {
+		return "success"
+	}
+	return "failure"
+}
+
+// PageCaptured counts a captured page and how long its capture took.
+func (m *metrics) PageCaptured(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pages++
+	seconds := d.Seconds()
+	m.captureCount++
+	m.captureSum += seconds
+	for i, bound := range captureBuckets {
+		if seconds <= bound {
+			m.captureCounts[i]++
+			break
+		}
+	}
+}
+
+func (m *metrics) NavigationFailed() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.navigationFailures++
+}
+
+func (m *metrics) LoginAttempt(ok bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logins[resultLabel(ok)]++
+}
+
+func (m *metrics) BrowserRestarted() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restarts++
+}
+
+// RunFinished counts a watch run, from the crawl through the alerts.
+func (m *metrics) RunFinished(ok bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[resultLabel(ok)]++
+	m.lastRun[resultLabel(ok)] = time.Now()
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	byResult := func(name string, values map[string]float64) {
+		for _, r := range []string{"success", "failure"} {
+			fmt.Fprintf(&b, "%s{result=%q} %g\n", name, r, values[r])
+		}
+	}
+
+	metric("explorer_pages_captured_total", "counter", "Pages captured.")
+	fmt.Fprintf(&b, "explorer_pages_captured_total %g\n", m.pages)
+	metric("explorer_navigation_failures_total", "counter", "Navigations to a page that failed.")
+	fmt.Fprintf(&b, "explorer_navigation_failures_total %g\n", m.navigationFailures)
+	metric("explorer_login_attempts_total", "counter", "Login attempts, including those after browser restarts.")
+	byResult("explorer_login_attempts_total", m.logins)
+	metric("explorer_browser_restarts_total", "counter", "Browser restarts after a crash or hang.")
+	fmt.Fprintf(&b, "explorer_browser_restarts_total %g\n", m.restarts)
+	metric("explorer_runs_total", "counter", "Watch runs.")
+	byResult("explorer_runs_total", m.runs)
+
+	metric("explorer_last_run_timestamp_seconds", "gauge", "End of the last watch run, by result.")
+	results := make([]string, 0, len(m.lastRun))
+	for r := range m.lastRun {
+		results = append(results, r)
+	}
+	sort.Strings(results)
+	for _, r := range results {
+		fmt.Fprintf(&b, "explorer_last_run_timestamp_seconds{result=%q} %d\n", r, m.lastRun[r].Unix())
+	}
+
+	metric("explorer_capture_duration_seconds", "histogram", "Time to capture a page.")
+	cumulative := 0.0
+	for i, bound := range captureBuckets {
+		cumulative += m.captureCounts[i]
+		fmt.Fprintf(&b, "explorer_capture_duration_seconds_bucket{le=\"%g\"} %g\n", bound, cumulative)
+	}
+	fmt.Fprintf(&b, "explorer_capture_duration_seconds_bucket{le=\"+Inf\"} %g\n", m.captureCount)
+	fmt.Fprintf(&b, "explorer_capture_duration_seconds_sum %g\n", m.captureSum)
+	fmt.Fprintf(&b, "explorer_capture_duration_seconds_count %g\n", m.captureCount)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, b.String())
+}
+
+// serveMetrics serves runMetrics on addr (host:port) until ctx is done.
+func serveMetrics(ctx context.Context, addr string) {
+	runMetrics = newMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", runMetrics)
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		fmt.Printf("📈 Metrics on http://%s/metrics\n", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("⚠️ Metrics server failed: %v\n", err)
+		}
+	}()
+}
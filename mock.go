@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// mockRule answers API calls whose URL matches pattern with a canned response.
+type mockRule struct {
+	pattern     *regexp.Regexp
+	method      string
+	status      int64
+	contentType string
+	body        []byte
+	source      string
+}
+
+// requestMocker intercepts XHR/fetch calls through the CDP Fetch domain and
+// fulfils the ones matching a rule, so pages can be captured repeatably
+// without touching live data.
+type requestMocker struct {
+	mu             sync.Mutex
+	rules          []mockRule
+	blockUnmatched bool
+	guard          *safetyGuard // fails writes in read-only mode
+	hits           map[string]int
+	log            func(format string, args ...interface{})
+}
+
+// newRequestMocker loads the rules from explorer.mocking. It returns nil when
+// no rules are configured.
+func newRequestMocker(v *viper.Viper, logf func(format string, args ...interface{})) (*requestMocker, error) {
+	m := &requestMocker{
+		blockUnmatched: v.GetBool("explorer.mocking.block_unmatched"),
+		hits:           make(map[string]int),
+		log:            logf,
+	}
+
+	var rules []map[string]interface{}
+	if err := v.UnmarshalKey("explorer.mocking.rules", &rules); err != nil {
+		return nil, fmt.Errorf("invalid mocking rules: %w", err)
+	}
+	for _, r := range rules {
+		rule, err := parseMockRule(r)
+		if err != nil {
+			return nil, err
+		}
+		m.rules = append(m.rules, rule)
+	}
+
+	if inventory := v.GetString("explorer.mocking.fixtures"); inventory != "" {
+		rules, err := mockRulesFromInventory(inventory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load fixtures from %s: %w", inventory, err)
+		}
+		m.rules = append(m.rules, rules...)
+	}
+
+	if len(m.rules) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// parseMockRule reads one configured rule: url (regex), method, status,
+// content_type and either file or body.
+func parseMockRule(r map[string]interface{}) (mockRule, error) {
+	pattern, err := regexp.Compile(cast.ToString(r["url"]))
+	if err != nil {
+		return mockRule{}, fmt.Errorf("invalid mock url pattern: %w", err)
+	}
+	rule := mockRule{
+		pattern:     pattern,
+		method:      strings.ToUpper(cast.ToString(r["method"])),
+		status:      cast.ToInt64(r["status"]),
+		contentType: cast.ToString(r["content_type"]),
+		body:        []byte(cast.ToString(r["body"])),
+		source:      "config",
+	}
+	if file := cast.ToString(r["file"]); file != "" {
+		if rule.body, err = ioutil.ReadFile(file); err != nil {
+			return mockRule{}, fmt.Errorf("failed to read mock file: %w", err)
+		}
+		rule.source = file
+	}
+	if rule.status == 0 {
+		rule.status = 200
+	}
+	if rule.contentType == "" {
+		rule.contentType = "application/json"
+	}
+	return rule, nil
+}
+
+// mockRulesFromInventory turns a previous run's api_inventory.json into
+// rules answering each endpoint with its first recorded fixture.
+func mockRulesFromInventory(path string) ([]mockRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var endpoints []apiEndpoint
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return nil, err
+	}
+
+	var rules []mockRule
+	for _, ep := range endpoints {
+		if len(ep.Fixtures) == 0 {
+			continue
+		}
+		file := ep.Fixtures[0]
+		// Fixture paths are recorded relative to where the explorer ran
+		if _, err := os.Stat(file); err != nil {
+			if i := strings.LastIndex(filepath.ToSlash(file), "fixtures/"); i >= 0 {
+				file = filepath.Join(filepath.Dir(path), filepath.FromSlash(filepath.ToSlash(file)[i:]))
+			}
+		}
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		segments := strings.Split(ep.Path, "/")
+		for i, seg := range segments {
+			if strings.HasPrefix(seg, ":") {
+				segments[i] = "[^/]+"
+			} else {
+				segments[i] = regexp.QuoteMeta(seg)
+			}
+		}
+		rules = append(rules, mockRule{
+			pattern:     regexp.MustCompile(`^https?://` + regexp.QuoteMeta(ep.Host) + strings.Join(segments, "/") + `/?(\?.*)?$`),
+			method:      ep.Method,
+			status:      200,
+			contentType: contentTypeOr(ep.ResponseType, "application/json"),
+			body:        body,
+			source:      file,
+		})
+	}
+	return rules, nil
+}
+
+// match returns the first rule for a request, or nil.
+func (m *requestMocker) match(method, url string) *mockRule {
+	for i := range m.rules {
+		rule := &m.rules[i]
+		if rule.method != "" && rule.method != method {
+			continue
+		}
+		if rule.pattern.MatchString(url) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Start enables request interception for XHR/fetch calls on the tab, and
+// for every request in read-only mode.
+func (m *requestMocker) Start(ctx context.Context) error {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if paused, ok := ev.(*fetch.EventRequestPaused); ok {
+			// Handlers run on the event loop, so the reply is sent separately
+			go m.handle(ctx, paused)
+		}
+	})
+	patterns := []*fetch.RequestPattern{
+		{URLPattern: "*", ResourceType: network.ResourceTypeXHR},
+		{URLPattern: "*", ResourceType: network.ResourceTypeFetch},
+	}
+	if m.guard.ReadOnly() {
+		patterns = []*fetch.RequestPattern{{URLPattern: "*"}}
+	}
+	return chromedp.Run(ctx, fetch.Enable().WithPatterns(patterns))
+}
+
+func (m *requestMocker) handle(ctx context.Context, ev *fetch.EventRequestPaused) {
+	api := ev.ResourceType == network.ResourceTypeXHR || ev.ResourceType == network.ResourceTypeFetch
+	var rule *mockRule
+	if api {
+		rule = m.match(ev.Request.Method, ev.Request.URL)
+	}
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		switch {
+		case rule != nil:
+			return fetch.FulfillRequest(ev.RequestID, rule.status).
+				WithResponseHeaders([]*fetch.HeaderEntry{{Name: "Content-Type", Value: rule.contentType}}).
+				WithBody(base64.StdEncoding.EncodeToString(rule.body)).
+				Do(ctx)
+		case m.guard.blocks(ev.Request):
+			return fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+		case api && m.blockUnmatched:
+			return fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+		default:
+			return fetch.ContinueRequest(ev.RequestID).Do(ctx)
+		}
+	}))
+	if err != nil {
+		m.log("⚠️ Failed to answer intercepted request %s: %v", ev.Request.URL, err)
+		return
+	}
+
+	if rule != nil {
+		m.mu.Lock()
+		m.hits[ev.Request.Method+" "+ev.Request.URL]++
+		m.mu.Unlock()
+	} else if api && m.blockUnmatched {
+		m.log("🚫 Blocked unmocked request: %s %s", ev.Request.Method, ev.Request.URL)
+	}
+}
+
+// Hits returns how many requests were answered from mocks.
+func (m *requestMocker) Hits() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := 0
+	for _, n := range m.hits {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// collectMotion lists the elements with a CSS transition or animation
+// (with the component selector they match), the animations started from
+// JavaScript through the Web Animations API, animation libraries on the
+// page, and whether any stylesheet has a prefers-reduced-motion query.
+const collectMotion = `
+(function(selectors, maxElements) {
+	const describe = (el) => el.tagName.toLowerCase() + (el.id ? '#' + el.id : '') +
+		(typeof el.className === 'string' && el.className.trim() ? '.' + el.className.trim().split(/\s+/).slice(0, 2).join('.') : '');
+	const componentOf = (el) => {
+		for (const sel of selectors) {
+			try { if (el.matches(sel)) return sel; } catch (e) {}
+		}
+		return '';
+	};
+	const durations = (v) => v.split(',').map(s => parseFloat(s) * (s.trim().endsWith('ms') ? 1 : 1000));
+
+	const elements = [];
+	let truncated = false;
+	for (const el of document.body.querySelectorAll('*')) {
+		const style = getComputedStyle(el);
+		const transition = durations(style.transitionDuration).some(d => d > 0);
+		const animation = style.animationName && style.animationName !== 'none';
+		if (!transition && !animation) continue;
+		if (elements.length >= maxElements) {
+			truncated = true;
+			break;
+		}
+		elements.push({
+			component: componentOf(el),
+			element: describe(el),
+			transition: transition ? {property: style.transitionProperty, duration: style.transitionDuration, timingFunction: style.transitionTimingFunction, delay: style.transitionDelay} : null,
+			animation: animation ? {name: style.animationName, duration: style.animationDuration, timingFunction: style.animationTimingFunction, delay: style.animationDelay, iterationCount: style.animationIterationCount} : null,
+		});
+	}
+
+	const css = (a) => (typeof CSSAnimation !== 'undefined' && a instanceof CSSAnimation) || (typeof CSSTransition !== 'undefined' && a instanceof CSSTransition);
+	const scripted = (document.getAnimations ? document.getAnimations() : []).filter(a => !css(a)).slice(0, maxElements).map(a => {
+		const timing = a.effect && a.effect.getTiming ? a.effect.getTiming() : {};
+		const target = a.effect && a.effect.target;
+		return {
+			element: target ? describe(target) : '',
+			duration: typeof timing.duration === 'number' ? timing.duration : 0,
+			easing: timing.easing || '',
+			iterations: timing.iterations === Infinity ? -1 : (timing.iterations || 1),
+		};
+	});
+
+	const libraries = [
+		['GSAP', () => window.gsap || window.TweenMax],
+		['anime.js', () => window.anime],
+		['Velocity', () => window.Velocity],
+		['Lottie', () => window.lottie || window.bodymovin || document.querySelector('lottie-player')],
+		['Framer Motion', () => document.querySelector('[data-projection-id], [data-framer-appear-id]')],
+	].filter(([, found]) => { try { return !!found(); } catch (e) { return false; } }).map(([name]) => name);
+
+	let reducedMotionQuery = false;
+	for (const sheet of document.styleSheets) {
+		try {
+			for (const rule of sheet.cssRules) {
+				if (rule.media && /prefers-reduced-motion/.test(rule.media.mediaText)) {
+					reducedMotionQuery = true;
+					break;
+				}
+			}
+		} catch (e) {} // cross-origin sheet
+		if (reducedMotionQuery) break;
+	}
+
+	return {elements: elements, truncated: truncated, scripted: scripted, libraries: libraries, reducedMotionQuery: reducedMotionQuery};
+})(%s, %d)
+`
+
+// motionScan is the result of collectMotion.
+type motionScan struct {
+	Elements           []motionElement     `json:"elements"`
+	Truncated          bool                `json:"truncated"`
+	Scripted           []scriptedAnimation `json:"scripted"`
+	Libraries          []string            `json:"libraries"`
+	ReducedMotionQuery bool                `json:"reducedMotionQuery"`
+}
+
+type motionElement struct {
+	Component  string           `json:"component,omitempty"`
+	Element    string           `json:"element"`
+	Transition *cssTransition   `json:"transition,omitempty"`
+	Animation  *cssAnimationUse `json:"animation,omitempty"`
+}
+
+type cssTransition struct {
+	Property       string `json:"property"`
+	Duration       string `json:"duration"`
+	TimingFunction string `json:"timingFunction"`
+	Delay          string `json:"delay"`
+}
+
+type cssAnimationUse struct {
+	Name           string `json:"name"`
+	Duration       string `json:"duration"`
+	TimingFunction string `json:"timingFunction"`
+	Delay          string `json:"delay"`
+	IterationCount string `json:"iterationCount"`
+}
+
+// scriptedAnimation is a Web Animations API animation started from
+// JavaScript; iterations is -1 when infinite.
+type scriptedAnimation struct {
+	Element    string  `json:"element"`
+	Duration   float64 `json:"duration"`
+	Easing     string  `json:"easing"`
+	Iterations float64 `json:"iterations"`
+}
+
+// pageMotion is motion/<page>.json: the motion of the page as rendered, and
+// again with prefers-reduced-motion: reduce emulated.
+type pageMotion struct {
+	Page string `json:"page"`
+	motionScan
+	Reduced               *motionScan `json:"reduced,omitempty"`
+	ReducedScreenshot     string      `json:"reducedScreenshot,omitempty"`
+	Animated              bool        `json:"animated"`
+	RespectsReducedMotion bool        `json:"respectsReducedMotion"`
+}
+
+// motionMS is the time the motion of a scan adds up to: per element its
+// longest transition and its animation, and the scripted animations, with
+// infinite animations counted once.
+func motionMS(scan motionScan) float64 {
+	total := 0.0
+	for _, el := range scan.Elements {
+		if el.Transition != nil {
+			longest := 0.0
+			for _, d := range splitCSSList(el.Transition.Duration) {
+				longest = math.Max(longest, cssTimeMS(d))
+			}
+			total += longest
+		}
+		if el.Animation != nil {
+			for _, d := range splitCSSList(el.Animation.Duration) {
+				total += cssTimeMS(d)
+			}
+		}
+	}
+	for _, a := range scan.Scripted {
+		total += a.Duration
+	}
+	return total
+}
+
+// respectsReducedMotion reports whether emulating prefers-reduced-motion
+// removed at least half of the page's motion.
+func respectsReducedMotion(normal, reduced motionScan) bool {
+	before := motionMS(normal)
+	return before == 0 || motionMS(reduced) <= before/2
+}
+
+// cssTimeMS parses a CSS time ("0.2s", "150ms") into milliseconds.
+func cssTimeMS(v string) float64 {
+	v = strings.TrimSpace(v)
+	if strings.HasSuffix(v, "ms") {
+		f, _ := strconv.ParseFloat(strings.TrimSuffix(v, "ms"), 64)
+		return f
+	}
+	f, _ := strconv.ParseFloat(strings.TrimSuffix(v, "s"), 64)
+	return f * 1000
+}
+
+// splitCSSList splits a computed list value at the commas outside
+// parentheses, so cubic-bezier(...) stays whole.
+func splitCSSList(v string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range v {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(v[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(v[start:]))
+}
+
+// captureMotion records the transitions and animations of the current page,
+// then emulates prefers-reduced-motion: reduce, records them again with a
+// screenshot, and writes both to motion/<page>.json.
+func (e *AgicapExplorer) captureMotion(pageName string) {
+	selectors, _ := json.Marshal(componentSelectors)
+	script := fmt.Sprintf(collectMotion, selectors, e.config.GetInt("explorer.capture.max_motion_elements"))
+	motion := pageMotion{Page: pageName}
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(script, &motion.motionScan)); err != nil {
+		e.log("⚠️ Motion audit failed for %s: %v", pageName, err)
+		return
+	}
+	motion.Animated = len(motion.Elements) > 0 || len(motion.Scripted) > 0
+
+	var reduced motionScan
+	var shot []byte
+	err := chromedp.Run(e.ctx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return emulation.SetEmulatedMedia().
+				WithFeatures([]*emulation.MediaFeature{{Name: "prefers-reduced-motion", Value: "reduce"}}).
+				Do(ctx)
+		}),
+		e.settle("capture"),
+		chromedp.Evaluate(script, &reduced),
+		chromedp.CaptureScreenshot(&shot),
+	)
+	// Without features the emulation is cleared
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return emulation.SetEmulatedMedia().Do(ctx)
+	}))
+	if err != nil {
+		e.log("⚠️ Reduced-motion capture failed for %s: %v", pageName, err)
+	} else {
+		motion.Reduced = &reduced
+		motion.RespectsReducedMotion = respectsReducedMotion(motion.motionScan, reduced)
+		if path, err := e.writeArtifact("motion", sanitize(pageName)+"_reduced.png", shot); err == nil {
+			motion.ReducedScreenshot, _ = filepath.Rel(e.outputDir, path)
+		}
+		if motion.Animated && !motion.RespectsReducedMotion {
+			e.log("🎞️ %s keeps animating with prefers-reduced-motion", pageName)
+		}
+	}
+
+	data, err := json.MarshalIndent(motion, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("motion", sanitize(pageName)+".json", data); err != nil {
+		e.log("⚠️ Failed to write motion audit for %s: %v", pageName, err)
+	}
+}
+
+// easingNames are the CSS easing keywords, kept as token names.
+var easingNames = map[string]bool{"linear": true, "ease": true, "ease-in": true, "ease-out": true, "ease-in-out": true, "step-start": true, "step-end": true}
+
+// motionSection aggregates motion/*.json into the design system: the
+// durations and easings used (Tailwind's transitionDuration and
+// transitionTimingFunction), the keyframe animations, the most common
+// transition per component selector, and which pages ignore
+// prefers-reduced-motion.
+func (e *AgicapExplorer) motionSection() orderedTokens {
+	durations := tokenCounts{}
+	easings := tokenCounts{}
+	animations := tokenCounts{}
+	byComponent := make(map[string]tokenCounts)
+	pages, animated, withQuery := 0, 0, 0
+	ignoring := []string{}
+
+	files, _ := filepath.Glob(filepath.Join(e.outputDir, "motion", "*.json"))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var motion pageMotion
+		if json.Unmarshal(data, &motion) != nil {
+			continue
+		}
+		pages++
+		if motion.ReducedMotionQuery {
+			withQuery++
+		}
+		if motion.Animated {
+			animated++
+			if motion.Reduced != nil && !motion.RespectsReducedMotion {
+				ignoring = append(ignoring, motion.Page)
+			}
+		}
+		for _, el := range motion.Elements {
+			if t := el.Transition; t != nil {
+				for _, d := range splitCSSList(t.Duration) {
+					if ms := cssTimeMS(d); ms > 0 {
+						durations[strconv.FormatFloat(ms, 'f', -1, 64)]++
+					}
+				}
+				for _, f := range splitCSSList(t.TimingFunction) {
+					easings[f]++
+				}
+				if el.Component != "" {
+					if byComponent[el.Component] == nil {
+						byComponent[el.Component] = tokenCounts{}
+					}
+					byComponent[el.Component][t.Property+" "+t.Duration+" "+t.TimingFunction]++
+				}
+			}
+			if a := el.Animation; a != nil {
+				for _, name := range splitCSSList(a.Name) {
+					animations[name]++
+				}
+				for _, d := range splitCSSList(a.Duration) {
+					if ms := cssTimeMS(d); ms > 0 {
+						durations[strconv.FormatFloat(ms, 'f', -1, 64)]++
+					}
+				}
+				for _, f := range splitCSSList(a.TimingFunction) {
+					easings[f]++
+				}
+			}
+		}
+		for _, a := range motion.Scripted {
+			if a.Duration > 0 {
+				durations[strconv.FormatFloat(math.Round(a.Duration), 'f', -1, 64)]++
+			}
+			if a.Easing != "" {
+				easings[a.Easing]++
+			}
+		}
+	}
+
+	duration := orderedTokens{}
+	for _, ms := range pxValues(durations, 1) {
+		key := strconv.FormatFloat(ms, 'f', -1, 64)
+		duration = append(duration, tokenEntry{key, key + "ms"})
+	}
+	easing := orderedTokens{}
+	custom := 0
+	for _, f := range easings.byCount() {
+		name := f
+		if !easingNames[f] {
+			custom++
+			name = fmt.Sprintf("custom-%d", custom)
+		}
+		easing = append(easing, tokenEntry{name, f})
+	}
+	keyframes := orderedTokens{}
+	for _, name := range animations.byCount() {
+		keyframes = append(keyframes, tokenEntry{name, animations[name]})
+	}
+	components := orderedTokens{}
+	selectors := make([]string, 0, len(byComponent))
+	for sel := range byComponent {
+		selectors = append(selectors, sel)
+	}
+	sort.Strings(selectors)
+	for _, sel := range selectors {
+		components = append(components, tokenEntry{sel, byComponent[sel].byCount()[0]})
+	}
+
+	return orderedTokens{
+		{"duration", duration},
+		{"easing", easing},
+		{"animations", keyframes},
+		{"components", components},
+		{"reducedMotion", orderedTokens{
+			{"pages", pages},
+			{"animatedPages", animated},
+			{"pagesWithMediaQuery", withQuery},
+			{"ignoredOn", ignoring},
+		}},
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// networkEntry is one request observed on the Network domain, completed as
+// its response and loading events arrive.
+type networkEntry struct {
+	request  *network.Request
+	kind     network.ResourceType
+	started  time.Time
+	response *network.Response
+	finished time.Time
+	size     float64
+	failure  string
+	page     string
+	body     string
+}
+
+// originHit is a request to an origin, kept for every resource type so the
+// third-party report sees scripts, images and fonts too.
+type originHit struct {
+	origin string
+	kind   network.ResourceType
+	page   string
+}
+
+// networkRecorder buffers the requests made by the current page so each
+// capture can be written out as a HAR file. It also keeps every request of
+// the run for the API inventory.
+type networkRecorder struct {
+	mu           sync.Mutex
+	enabled      bool
+	types        map[network.ResourceType]bool
+	maxBodyBytes int
+	byID         map[network.RequestID]*networkEntry
+	entries      []*networkEntry
+	all          []*networkEntry
+	page         string
+	hits         []*originHit
+	pageHits     []*originHit
+	sockets      map[network.RequestID]*wsConnection
+	socketOrder  []network.RequestID
+	maxFrames    int
+	framesSeen   int
+	ignored      map[string]bool // bodies of the explorer's own requests
+}
+
+func newNetworkRecorder(v *viper.Viper) *networkRecorder {
+	r := &networkRecorder{
+		enabled:      v.GetBool("explorer.network.enabled"),
+		types:        make(map[network.ResourceType]bool),
+		maxBodyBytes: v.GetInt("explorer.network.max_body_bytes"),
+		byID:         make(map[network.RequestID]*networkEntry),
+		sockets:      make(map[network.RequestID]*wsConnection),
+		maxFrames:    v.GetInt("explorer.network.max_websocket_frames"),
+		ignored:      make(map[string]bool),
+	}
+	for _, t := range v.GetStringSlice("explorer.network.resource_types") {
+		r.types[network.ResourceType(t)] = true
+	}
+	return r
+}
+
+// Listen records request, response and loading events. chromedp enables the
+// Network domain when it attaches to a tab, so no extra setup is needed.
+func (r *networkRecorder) Listen(ctx context.Context) {
+	if !r.enabled {
+		return
+	}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			if r.ignored[ev.Request.PostData] {
+				return
+			}
+			// A redirect reuses the request ID; the previous hop ends here
+			if prev, ok := r.byID[ev.RequestID]; ok && ev.RedirectResponse != nil {
+				prev.response = ev.RedirectResponse
+				prev.finished = ev.Timestamp.Time()
+			}
+			if u, err := url.Parse(ev.Request.URL); err == nil && u.Host != "" {
+				hit := &originHit{origin: u.Scheme + "://" + u.Host, kind: ev.Type, page: r.page}
+				r.hits = append(r.hits, hit)
+				r.pageHits = append(r.pageHits, hit)
+			}
+			if len(r.types) > 0 && !r.types[ev.Type] {
+				return
+			}
+			entry := &networkEntry{
+				request: ev.Request,
+				kind:    ev.Type,
+				started: ev.WallTime.Time(),
+				page:    r.page,
+			}
+			r.byID[ev.RequestID] = entry
+			r.entries = append(r.entries, entry)
+			r.all = append(r.all, entry)
+		case *network.EventResponseReceived:
+			if entry, ok := r.byID[ev.RequestID]; ok {
+				entry.response = ev.Response
+			}
+		case *network.EventLoadingFinished:
+			if entry, ok := r.byID[ev.RequestID]; ok {
+				entry.finished = ev.Timestamp.Time()
+				entry.size = ev.EncodedDataLength
+				if entry.isJSONCall() {
+					// Handlers run on the event loop, so the body is fetched separately
+					go r.fetchBody(ctx, ev.RequestID, entry)
+				}
+			}
+		case *network.EventLoadingFailed:
+			if entry, ok := r.byID[ev.RequestID]; ok {
+				entry.finished = ev.Timestamp.Time()
+				entry.failure = ev.ErrorText
+			}
+		case *network.EventWebSocketCreated:
+			r.sockets[ev.RequestID] = &wsConnection{URL: ev.URL}
+			r.socketOrder = append(r.socketOrder, ev.RequestID)
+		case *network.EventWebSocketFrameSent:
+			r.recordFrame(ev.RequestID, "sent", ev.Response)
+		case *network.EventWebSocketFrameReceived:
+			r.recordFrame(ev.RequestID, "received", ev.Response)
+		case *network.EventWebSocketClosed:
+			if conn, ok := r.sockets[ev.RequestID]; ok {
+				conn.Closed = true
+			}
+		}
+	})
+}
+
+// fetchBody stores the response body of an API call, up to maxBodyBytes.
+func (r *networkRecorder) fetchBody(ctx context.Context, id network.RequestID, entry *networkEntry) {
+	var body []byte
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(id).Do(ctx)
+		return err
+	}))
+	if err != nil || len(body) > r.maxBodyBytes {
+		return
+	}
+	r.mu.Lock()
+	entry.body = string(body)
+	r.mu.Unlock()
+}
+
+// isJSONCall reports whether the entry is an XHR/fetch call answered with JSON.
+func (n *networkEntry) isJSONCall() bool {
+	if n.kind != network.ResourceTypeXHR && n.kind != network.ResourceTypeFetch {
+		return false
+	}
+	return n.response != nil && strings.Contains(n.response.MimeType, "json")
+}
+
+// Reset drops the current page's requests, e.g. before navigating to the
+// next page. The run-wide history is kept.
+func (r *networkRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID = make(map[network.RequestID]*networkEntry)
+	r.entries = nil
+	r.pageHits = nil
+	r.page = ""
+}
+
+// Ignore keeps requests posting body out of the recording, for requests
+// the explorer makes itself with the app's credentials.
+func (r *networkRecorder) Ignore(body string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ignored[body] = true
+}
+
+// SetPage attributes the current page's requests, including those made
+// later by interactions, to the named page.
+func (r *networkRecorder) SetPage(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.page = name
+	for _, entry := range r.entries {
+		if entry.page == "" {
+			entry.page = name
+		}
+	}
+	for _, hit := range r.pageHits {
+		if hit.page == "" {
+			hit.page = name
+		}
+	}
+}
+
+// absorb adds the run-wide history of another tab's recorder to r.
+func (r *networkRecorder) absorb(other *networkRecorder) {
+	other.mu.Lock()
+	all, hits := other.all, other.hits
+	other.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.all = append(r.all, all...)
+	r.hits = append(r.hits, hits...)
+}
+
+// Hits returns a snapshot of every request's origin recorded during the run.
+func (r *networkRecorder) Hits() []originHit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hits := make([]originHit, len(r.hits))
+	for i, hit := range r.hits {
+		hits[i] = *hit
+	}
+	return hits
+}
+
+// All returns a snapshot of every completed request recorded during the run.
+func (r *networkRecorder) All() []networkEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []networkEntry
+	for _, entry := range r.all {
+		if entry.response != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
+// Failures returns the number of requests of the run that failed without
+// a response.
+func (r *networkRecorder) Failures() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, entry := range r.all {
+		if entry.response == nil && entry.failure != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Entries returns a snapshot of the requests recorded since the last Reset
+// that received a response or failed, in the order they were sent.
+func (r *networkRecorder) Entries() []networkEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []networkEntry
+	for _, entry := range r.entries {
+		if entry.response != nil || entry.failure != "" {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
+// captureHAR writes the traffic recorded for the current page to
+// har/<page>.har.
+func (e *AgicapExplorer) captureHAR(pageName, pageTitle string) string {
+	if !e.network.enabled {
+		return ""
+	}
+	pageRef := sanitize(pageName)
+	e.network.SetPage(pageRef)
+	entries := e.network.Entries()
+
+	harLog := &har.Log{
+		Version: "1.2",
+		Creator: &har.Creator{Name: toolName, Version: version()},
+		Entries: make([]*har.Entry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		harLog.Entries = append(harLog.Entries, entry.toHAR(pageRef, e.redactor))
+	}
+	if len(entries) > 0 {
+		harLog.Pages = []*har.Page{{
+			ID:              pageRef,
+			Title:           pageTitle,
+			StartedDateTime: entries[0].started.Format(time.RFC3339Nano),
+			PageTimings:     &har.PageTimings{},
+		}}
+	}
+
+	data, err := json.MarshalIndent(har.HAR{Log: harLog}, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact("har", pageRef+".har", data)
+	if err != nil {
+		e.log("⚠️ Failed to write HAR for %s: %v", pageName, err)
+		return ""
+	}
+	e.log("🌐 Recorded %d requests for %s", len(entries), pageName)
+	return path
+}
+
+// toHAR converts a recorded request into a HAR entry. Credential headers
+// are masked and the bodies pass through the redaction rules.
+func (n *networkEntry) toHAR(pageRef string, r *redactor) *har.Entry {
+	req := &har.Request{
+		Method:      n.request.Method,
+		URL:         n.request.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []*har.Cookie{},
+		Headers:     harHeaders(n.request.Headers),
+		QueryString: []*har.NameValuePair{},
+		HeadersSize: -1,
+		BodySize:    int64(len(n.request.PostData)),
+	}
+	if u, err := url.Parse(n.request.URL); err == nil {
+		query := u.Query()
+		names := make([]string, 0, len(query))
+		for name := range query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, value := range query[name] {
+				req.QueryString = append(req.QueryString, &har.NameValuePair{Name: name, Value: value})
+			}
+		}
+	}
+	if n.request.PostData != "" {
+		req.PostData = &har.PostData{
+			MimeType: headerValue(n.request.Headers, "Content-Type"),
+			Params:   []*har.Param{},
+			Text:     r.RedactBody(n.request.PostData, headerValue(n.request.Headers, "Content-Type")),
+		}
+	}
+
+	resp := &har.Response{
+		Cookies:     []*har.Cookie{},
+		Headers:     []*har.NameValuePair{},
+		Content:     &har.Content{},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	if n.response != nil {
+		resp.Status = n.response.Status
+		resp.StatusText = n.response.StatusText
+		resp.HTTPVersion = n.response.Protocol
+		resp.Headers = harHeaders(n.response.Headers)
+		resp.RedirectURL = headerValue(n.response.Headers, "Location")
+		resp.Content.MimeType = n.response.MimeType
+		resp.Content.Size = int64(n.size)
+		resp.Content.Text = r.Redact(n.body)
+		resp.BodySize = int64(n.size)
+		req.HTTPVersion = n.response.Protocol
+	}
+	if n.failure != "" {
+		resp.Comment = n.failure
+	}
+
+	timings := n.timings()
+	return &har.Entry{
+		Pageref:         pageRef,
+		StartedDateTime: n.started.Format(time.RFC3339Nano),
+		Time:            harTotal(timings),
+		Request:         req,
+		Response:        resp,
+		Cache:           &har.Cache{},
+		Timings:         timings,
+		ServerIPAddress: n.serverIP(),
+		Comment:         string(n.kind),
+	}
+}
+
+// timings returns the HAR phases of the request, -1 where unknown.
+func (n *networkEntry) timings() *har.Timings {
+	t := &har.Timings{Blocked: -1, DNS: -1, Connect: -1, Ssl: -1}
+	if n.response != nil {
+		fillTimings(t, n.response.Timing, n.finished)
+	}
+	return t
+}
+
+func (n *networkEntry) serverIP() string {
+	if n.response == nil {
+		return ""
+	}
+	return n.response.RemoteIPAddress
+}
+
+// fillTimings maps CDP resource timing (milliseconds relative to
+// requestTime, -1 when not applicable) onto the HAR phases.
+func fillTimings(t *har.Timings, rt *network.ResourceTiming, finished time.Time) {
+	if rt == nil {
+		return
+	}
+	phase := func(start, end float64) float64 {
+		if start < 0 || end < start {
+			return -1
+		}
+		return end - start
+	}
+	t.DNS = phase(rt.DNSStart, rt.DNSEnd)
+	t.Connect = phase(rt.ConnectStart, rt.ConnectEnd)
+	t.Ssl = phase(rt.SslStart, rt.SslEnd)
+	t.Send = phase(rt.SendStart, rt.SendEnd)
+	t.Wait = phase(rt.SendEnd, rt.ReceiveHeadersEnd)
+
+	// Time queued before the first network phase started
+	for _, start := range []float64{rt.DNSStart, rt.ConnectStart, rt.SendStart} {
+		if start >= 0 {
+			t.Blocked = start
+			break
+		}
+	}
+
+	if !finished.IsZero() {
+		requestTime := time.Duration(rt.RequestTime * float64(time.Second))
+		total := float64(finished.Sub(cdp.MonotonicTimeEpoch.Add(requestTime))) / float64(time.Millisecond)
+		t.Receive = phase(rt.ReceiveHeadersEnd, total)
+	}
+	for _, v := range []*float64{&t.Send, &t.Wait, &t.Receive} {
+		if *v < 0 {
+			*v = 0
+		}
+	}
+}
+
+// harTotal sums the applicable timings; ssl is already part of connect.
+func harTotal(t *har.Timings) float64 {
+	total := 0.0
+	for _, v := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+// credentialHeaders are the headers whose values harHeaders masks.
+var credentialHeaders = regexp.MustCompile(`(?i)^(cookie|set-cookie|authorization|proxy-authorization|x-(csrf|xsrf)[-_].*)$`)
+
+// harHeaders converts CDP headers to sorted HAR name/value pairs, with the
+// values of credentialHeaders masked.
+func harHeaders(headers network.Headers) []*har.NameValuePair {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]*har.NameValuePair, 0, len(names))
+	for _, name := range names {
+		value := fmt.Sprint(headers[name])
+		if credentialHeaders.MatchString(name) {
+			value = redactedValue
+		}
+		pairs = append(pairs, &har.NameValuePair{Name: name, Value: value})
+	}
+	return pairs
+}
+
+// headerValue looks up a header case-insensitively.
+func headerValue(headers network.Headers, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
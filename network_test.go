@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/spf13/viper"
+)
+
+func testRedactor(t *testing.T) *redactor {
+	t.Helper()
+	v := viper.New()
+	v.Set("explorer.redaction.keys", []string{"password", "token", "iban"})
+	v.Set("explorer.redaction.patterns", []string{`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`})
+	r, err := newRedactor(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func loginEntry() *networkEntry {
+	return &networkEntry{
+		request: &network.Request{
+			Method: "POST",
+			URL:    "https://app.example.com/login",
+			Headers: network.Headers{
+				"Content-Type":  "application/x-www-form-urlencoded",
+				"Cookie":        "session=s3cr3t-session",
+				"Authorization": "Bearer abc.def.ghi",
+				"X-CSRF-Token":  "csrf-value",
+			},
+			PostData: "email=jane%40example.com&password=hunter2",
+		},
+		kind:    network.ResourceTypeDocument,
+		started: time.Now(),
+		response: &network.Response{
+			Status:   302,
+			Headers:  network.Headers{"Set-Cookie": "session=new-session; HttpOnly", "Location": "/dashboard"},
+			MimeType: "text/html",
+		},
+		body: `{"token":"jwt-value","user":"jane"}`,
+	}
+}
+
+func TestHARMasksLoginCredentials(t *testing.T) {
+	data, err := json.Marshal(loginEntry().toHAR("01_dashboard", testRedactor(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	har := string(data)
+	for _, secret := range []string{"hunter2", "s3cr3t-session", "abc.def.ghi", "csrf-value", "new-session", "jwt-value"} {
+		if strings.Contains(har, secret) {
+			t.Errorf("HAR entry contains %q: %s", secret, har)
+		}
+	}
+	for _, kept := range []string{"jane%40example.com", "/dashboard", `\"user\":\"jane\"`} {
+		if !strings.Contains(har, kept) {
+			t.Errorf("HAR entry lost %q: %s", kept, har)
+		}
+	}
+}
+
+func TestResetDropsLoginTraffic(t *testing.T) {
+	r := newNetworkRecorder(viper.New())
+	entry := loginEntry()
+	r.entries = append(r.entries, entry)
+	r.all = append(r.all, entry)
+	if len(r.Entries()) != 1 {
+		t.Fatalf("got %d entries before Reset, want 1", len(r.Entries()))
+	}
+	r.Reset()
+	if entries := r.Entries(); len(entries) != 0 {
+		t.Errorf("got %d entries after Reset, want none", len(entries))
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// nextRoute is one app-router page, built from the captured pages sharing a
+// route template.
+type nextRoute struct {
+	Template string
+	Dir      string
+	Params   []string
+	Title    string
+	URLs     []string
+	Depth    int
+	Layout   layoutInfo
+	Sections []string
+	Counts   map[string]int
+}
+
+// runCodegenNext writes a Next.js app-router skeleton with a page.tsx per
+// route template of navigation_map.json:
+//
+//	explorer codegen next [-out dir] run/
+func runCodegenNext(args []string) error {
+	fs := flag.NewFlagSet("codegen next", flag.ContinueOnError)
+	out := fs.String("out", "", "output directory (default <run>/generated/app)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: codegen next [-out dir] <run>")
+	}
+	runDir := fs.Arg(0)
+	if *out == "" {
+		*out = filepath.Join(runDir, "generated", "app")
+	}
+
+	pages, err := loadNavigationMap(runDir)
+	if err != nil {
+		return err
+	}
+	routes := nextRoutes(runDir, pages)
+	if len(routes) == 0 {
+		return fmt.Errorf("no pages in %s", filepath.Join(runDir, "navigation_map.json"))
+	}
+
+	for _, r := range routes {
+		dir := filepath.Join(*out, filepath.FromSlash(r.Dir))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "page.tsx"), []byte(nextPage(r)), 0644); err != nil {
+			return err
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(*out, "layout.tsx"), []byte(nextLayout(routes)), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Generated %d routes in %s\n", len(routes), *out)
+	return nil
+}
+
+// nextRoutes groups the captured pages by route template, keeping the
+// shallowest page's title, and reads each page's layout and components.
+func nextRoutes(runDir string, pages []NavigationItem) []*nextRoute {
+	byTemplate := make(map[string]*nextRoute)
+	var routes []*nextRoute
+	for _, p := range pages {
+		template := p.Route
+		if template == "" {
+			template = routeTemplate(p.CanonicalURL)
+		}
+		r, ok := byTemplate[template]
+		if !ok {
+			r = &nextRoute{Template: template, Depth: p.Depth, Counts: make(map[string]int)}
+			r.Dir, r.Params = nextRouteDir(template)
+			byTemplate[template] = r
+			routes = append(routes, r)
+		}
+		r.URLs = appendUnique(r.URLs, p.URL)
+		if r.Title != "" && p.Depth >= r.Depth {
+			continue
+		}
+		r.Title, r.Depth = p.Title, p.Depth
+		if p.Screenshot != "" {
+			page := pageKey(p)
+			r.readAnalysis(filepath.Join(runDir, "components", page+"_analysis.json"))
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Dir < routes[j].Dir })
+	return routes
+}
+
+// nextRouteDir turns /invoices/:id/lines/:id into
+// invoices/[id]/lines/[lineId]; the root becomes "".
+func nextRouteDir(template string) (string, []string) {
+	var dirs, params []string
+	used := make(map[string]bool)
+	prev := ""
+	for _, seg := range strings.Split(strings.Trim(template, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		if strings.HasPrefix(seg, ":") {
+			name := seg[1:]
+			if used[name] && prev != "" {
+				name = camelCase(strings.TrimSuffix(prev, "s")) + pascalCase(name)
+			}
+			for base, i := name, 2; used[name]; i++ {
+				name = fmt.Sprintf("%s%d", base, i)
+			}
+			used[name] = true
+			params = append(params, name)
+			dirs = append(dirs, "["+name+"]")
+			continue
+		}
+		prev = seg
+		dirs = append(dirs, seg)
+	}
+	return strings.Join(dirs, "/"), params
+}
+
+// readAnalysis takes the layout and the content components, in page order,
+// from components/<page>_analysis.json. Navigation and sidebars belong in
+// the layout and are only counted.
+func (r *nextRoute) readAnalysis(path string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var analysis pageAnalysis
+	if err := json.Unmarshal(data, &analysis); err != nil {
+		return
+	}
+	r.Layout = analysis.Layout
+	r.Sections, r.Counts = nil, make(map[string]int)
+
+	components := append([]componentInfo(nil), analysis.Components...)
+	sort.SliceStable(components, func(i, j int) bool { return components[i].Position.Y < components[j].Position.Y })
+	for _, c := range components {
+		category := componentCategory(c.Type)
+		if category == "" {
+			continue
+		}
+		if r.Counts[category] == 0 && category != "Navigation" && category != "Sidebar" {
+			r.Sections = append(r.Sections, category)
+		}
+		r.Counts[category]++
+	}
+}
+
+// nextPage renders app/<route>/page.tsx.
+func nextPage(r *nextRoute) string {
+	title, _ := json.Marshal(r.Title)
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Generated by agicap-explorer from navigation_map.json: %s\n", r.Template)
+	for _, u := range r.URLs {
+		fmt.Fprintf(&b, "// Captured from %s\n", u)
+	}
+	var layout []string
+	for _, part := range []struct {
+		name    string
+		present bool
+	}{
+		{"header", r.Layout.HasHeader}, {"sidebar", r.Layout.HasSidebar}, {"main", r.Layout.MainContent}, {"footer", r.Layout.HasFooter},
+	} {
+		if part.present {
+			layout = append(layout, part.name)
+		}
+	}
+	if len(layout) > 0 {
+		fmt.Fprintf(&b, "// Layout: %s", strings.Join(layout, ", "))
+		if r.Layout.GridSystem != "" {
+			fmt.Fprintf(&b, " (%s)", r.Layout.GridSystem)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("import type { Metadata } from 'next';\n\n")
+	fmt.Fprintf(&b, "export const metadata: Metadata = { title: %s };\n\n", title)
+
+	name := "Home"
+	if r.Dir != "" {
+		name = ""
+		for _, seg := range strings.Split(r.Dir, "/") {
+			if !strings.HasPrefix(seg, "[") {
+				name += pascalCase(seg)
+			}
+		}
+	}
+	signature := "()"
+	if len(r.Params) > 0 {
+		fields := make([]string, len(r.Params))
+		for i, p := range r.Params {
+			fields[i] = p + ": string"
+		}
+		signature = fmt.Sprintf("({ params }: { params: { %s } })", strings.Join(fields, "; "))
+	}
+	fmt.Fprintf(&b, "export default function %sPage%s {\n", name, signature)
+	b.WriteString("  return (\n    <>\n")
+	fmt.Fprintf(&b, "      <h1>{%s}</h1>\n", title)
+	for _, p := range r.Params {
+		fmt.Fprintf(&b, "      {/* %s: {params.%s} */}\n", p, p)
+	}
+	for _, section := range r.Sections {
+		fmt.Fprintf(&b, "      <section data-placeholder=%q>\n        {/* TODO: %s (%d× on the original page) */}\n      </section>\n",
+			section, section, r.Counts[section])
+	}
+	b.WriteString("    </>\n  );\n}\n")
+	return b.String()
+}
+
+// nextLayout renders app/layout.tsx with the header, sidebar and footer most
+// pages have and links to the top-level static routes.
+func nextLayout(routes []*nextRoute) string {
+	header, sidebar, footer := 0, 0, 0
+	for _, r := range routes {
+		if r.Layout.HasHeader {
+			header++
+		}
+		if r.Layout.HasSidebar {
+			sidebar++
+		}
+		if r.Layout.HasFooter {
+			footer++
+		}
+	}
+	common := func(n int) bool { return n*2 > len(routes) }
+
+	var links strings.Builder
+	for _, r := range routes {
+		if r.Dir == "" || strings.Contains(r.Dir, "/") || len(r.Params) > 0 {
+			continue
+		}
+		label := r.Title
+		if label == "" {
+			label = r.Dir
+		}
+		text, _ := json.Marshal(label)
+		fmt.Fprintf(&links, "            <li><Link href=\"/%s\">{%s}</Link></li>\n", r.Dir, text)
+	}
+
+	var b strings.Builder
+	b.WriteString("// Generated by agicap-explorer from navigation_map.json\n")
+	b.WriteString("import type { ReactNode } from 'react';\nimport Link from 'next/link';\n\n")
+	b.WriteString("export default function RootLayout({ children }: { children: ReactNode }) {\n")
+	b.WriteString("  return (\n    <html lang=\"en\">\n      <body>\n")
+	if common(header) {
+		b.WriteString("        <header>{/* TODO: header */}</header>\n")
+	}
+	nav := "        <nav>\n          <ul>\n" + links.String() + "          </ul>\n        </nav>\n"
+	if common(sidebar) {
+		b.WriteString("        <div className=\"flex\">\n          <aside>\n")
+		b.WriteString(indentLines(nav, "    "))
+		b.WriteString("          </aside>\n          <main className=\"flex-1\">{children}</main>\n        </div>\n")
+	} else {
+		b.WriteString(nav)
+		b.WriteString("        <main>{children}</main>\n")
+	}
+	if common(footer) {
+		b.WriteString("        <footer>{/* TODO: footer */}</footer>\n")
+	}
+	b.WriteString("      </body>\n    </html>\n  );\n}\n")
+	return b.String()
+}
+
+// indentLines prefixes every non-empty line of s.
+func indentLines(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
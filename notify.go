@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// notificationChannel is an entry of explorer.notifications.channels.
+type notificationChannel struct {
+	Type string   `mapstructure:"type"` // slack, teams or webhook
+	URL  string   `mapstructure:"url"`
+	On   []string `mapstructure:"on"` // success, failure; both when empty
+}
+
+// wants reports whether the channel is notified of the event.
+func (c notificationChannel) wants(event string) bool {
+	if len(c.On) == 0 {
+		return true
+	}
+	for _, on := range c.On {
+		if on == event {
+			return true
+		}
+	}
+	return false
+}
+
+// runNotification is what webhook channels receive as JSON when a run
+// ends. Stage is where a failed run stopped: login, crawl (also when the
+// crawl gave up after browser crashes) or report.
+type runNotification struct {
+	Event    string         `json:"event"` // success or failure
+	Stage    string         `json:"stage,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	RunID    string         `json:"run_id"`
+	Site     string         `json:"site"`
+	Pages    int            `json:"pages"`
+	Failures map[string]int `json:"failures"`
+	Changed  *int           `json:"changed_pages,omitempty"` // incremental runs: pages not reused from the previous run
+	Duration float64        `json:"duration_seconds"`
+	Report   string         `json:"report,omitempty"`
+}
+
+// Summary is the first line of the notification.
+func (n *runNotification) Summary() string {
+	failures := 0
+	for _, count := range n.Failures {
+		failures += count
+	}
+	counts := fmt.Sprintf("%d pages captured, %d failures", n.Pages, failures)
+	if n.Changed != nil {
+		counts += fmt.Sprintf(", %d changed pages", *n.Changed)
+	}
+	if n.Event == "failure" {
+		return fmt.Sprintf("❌ Exploration of %s failed during %s: %s (%s)", n.Site, n.Stage, n.Error, counts)
+	}
+	return fmt.Sprintf("✅ Exploration of %s complete: %s", n.Site, counts)
+}
+
+// Text renders the notification for Slack and Teams, with the failures by
+// kind.
+func (n *runNotification) Text() string {
+	var b strings.Builder
+	b.WriteString(n.Summary())
+	fmt.Fprintf(&b, "\nRun %s · %s", n.RunID, time.Duration(n.Duration*float64(time.Second)))
+	kinds := make([]string, 0, len(n.Failures))
+	for kind, count := range n.Failures {
+		if count > 0 {
+			kinds = append(kinds, fmt.Sprintf("%s: %d", kind, count))
+		}
+	}
+	sort.Strings(kinds)
+	if len(kinds) > 0 {
+		fmt.Fprintf(&b, "\nFailures · %s", strings.Join(kinds, ", "))
+	}
+	return b.String()
+}
+
+// payload is the JSON body a channel of the type expects.
+func (n *runNotification) payload(channel string) ([]byte, error) {
+	switch channel {
+	case "webhook":
+		return json.Marshal(n)
+	case "slack":
+		text := n.Text()
+		if strings.HasPrefix(n.Report, "http") {
+			text += fmt.Sprintf("\n<%s|Open the report>", n.Report)
+		} else if n.Report != "" {
+			text += "\nReport: " + n.Report
+		}
+		return json.Marshal(map[string]string{"text": text})
+	case "teams":
+		color := "2EB886"
+		if n.Event == "failure" {
+			color = "D93F0B"
+		}
+		card := map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    n.Summary(),
+			"themeColor": color,
+			"title":      n.Summary(),
+			"text":       strings.ReplaceAll(strings.SplitN(n.Text(), "\n", 2)[1], "\n", "<br>"),
+		}
+		if strings.HasPrefix(n.Report, "http") {
+			card["potentialAction"] = []map[string]interface{}{{
+				"@type":   "OpenUri",
+				"name":    "Open the report",
+				"targets": []map[string]string{{"os": "default", "uri": n.Report}},
+			}}
+		} else if n.Report != "" {
+			card["text"] = card["text"].(string) + "<br>Report: " + n.Report
+		}
+		return json.Marshal(card)
+	}
+	return nil, fmt.Errorf("unknown channel type %q (slack, teams, webhook)", channel)
+}
+
+// notify sends the outcome of the run to explorer.notifications.channels:
+// a failure at stage when err is set or the crawl was aborted, else the
+// completion. The report links to the upload of explorer.upload when there
+// is one. Delivery failures are logged, they do not fail the run.
+func (e *AgicapExplorer) notify(stage string, err error) {
+	var channels []notificationChannel
+	if err := e.config.UnmarshalKey("explorer.notifications.channels", &channels); err != nil {
+		e.log("⚠️ Invalid explorer.notifications.channels: %v", err)
+		return
+	}
+	if len(channels) == 0 {
+		return
+	}
+
+	e.mu.Lock()
+	n := runNotification{
+		Event:    "success",
+		RunID:    e.runID,
+		Site:     e.config.GetString("explorer.login_url"),
+		Pages:    len(e.navigationMap),
+		Failures: make(map[string]int),
+		Duration: time.Since(e.started).Round(time.Second).Seconds(),
+		Report:   e.reportURL,
+	}
+	for kind, count := range e.failures {
+		n.Failures[kind] = count
+	}
+	e.mu.Unlock()
+	if e.previous != nil {
+		changed := n.Pages - e.previous.reused
+		n.Changed = &changed
+	}
+	switch {
+	case err != nil:
+		n.Event, n.Stage, n.Error = "failure", stage, err.Error()
+	case e.aborted != "":
+		n.Event, n.Stage, n.Error = "failure", "crawl", e.aborted
+	}
+	if n.Report == "" {
+		if path, err := filepath.Abs(filepath.Join(e.outputDir, "report.html")); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				n.Report = path
+			}
+		}
+	}
+
+	sent := 0
+	for _, channel := range channels {
+		if channel.URL == "" || !channel.wants(n.Event) {
+			continue
+		}
+		data, err := n.payload(channel.Type)
+		if err == nil {
+			err = postJSON(channel.URL, data)
+		}
+		if err != nil {
+			e.log("⚠️ Failed to send the %s notification: %v", channel.Type, err)
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		e.log("📣 Sent the %s notification to %d channels", n.Event, sent)
+	}
+}
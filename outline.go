@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// collectOutline lists the headings (h1–h6 and role="heading" with
+// aria-level) and the ARIA landmarks of the page in document order. A
+// landmark's depth is the number of landmarks around it; header and footer
+// only count as banner and contentinfo outside article, aside, main, nav
+// and section, as in the HTML-AAM mapping.
+const collectOutline = `
+(function() {
+	const landmarkTags = {header: 'banner', nav: 'navigation', main: 'main', aside: 'complementary', footer: 'contentinfo', form: 'form', section: 'region', search: 'search'};
+	const landmarkRoles = ['banner', 'navigation', 'main', 'complementary', 'contentinfo', 'search', 'form', 'region'];
+	const label = (el) => {
+		const by = el.getAttribute('aria-labelledby');
+		if (by) {
+			const text = by.split(/\s+/).map(id => document.getElementById(id)).filter(Boolean).map(n => n.textContent.trim()).join(' ');
+			if (text) return text.substring(0, 80);
+		}
+		return (el.getAttribute('aria-label') || '').substring(0, 80);
+	};
+	const hidden = (el) => {
+		const style = getComputedStyle(el);
+		return style.display === 'none' || style.visibility === 'hidden' || el.closest('[aria-hidden="true"], [hidden]') !== null;
+	};
+	const landmarkRole = (el) => {
+		const role = (el.getAttribute('role') || '').trim();
+		if (role) return landmarkRoles.includes(role) ? role : '';
+		const tag = el.tagName.toLowerCase();
+		const implicit = landmarkTags[tag];
+		if (!implicit) return '';
+		if ((tag === 'header' || tag === 'footer') && el.parentElement && el.parentElement.closest('article, aside, main, nav, section')) return '';
+		// form and section are landmarks only when they have a name
+		if ((tag === 'form' || tag === 'section') && !label(el)) return '';
+		return implicit;
+	};
+	const describe = (el) => el.tagName.toLowerCase() + (el.id ? '#' + el.id : '') +
+		(el.getAttribute('role') ? '[role=' + el.getAttribute('role') + ']' : '');
+
+	const headings = [];
+	const landmarks = [];
+	for (const el of document.body.querySelectorAll('*')) {
+		const match = el.tagName.match(/^H([1-6])$/);
+		const role = el.getAttribute('role');
+		if (match || role === 'heading') {
+			if (!hidden(el)) {
+				const level = role === 'heading' ? parseInt(el.getAttribute('aria-level'), 10) || 2 : +match[1];
+				headings.push({level: level, text: el.textContent.trim().replace(/\s+/g, ' ').substring(0, 120), element: describe(el)});
+			}
+			continue;
+		}
+		const landmark = landmarkRole(el);
+		if (landmark && !hidden(el)) {
+			let depth = 0;
+			for (let p = el.parentElement; p; p = p.parentElement) {
+				if (landmarkRole(p)) depth++;
+			}
+			landmarks.push({role: landmark, label: label(el), element: describe(el), depth: depth});
+		}
+	}
+	return {headings: headings, landmarks: landmarks};
+})()
+`
+
+// pageOutline is layouts/<page>_outline.json.
+type pageOutline struct {
+	Page      string           `json:"page"`
+	Title     string           `json:"title"`
+	Headings  []outlineHeading `json:"headings"`
+	Landmarks []pageLandmark   `json:"landmarks"`
+	Issues    []string         `json:"issues"`
+}
+
+type outlineHeading struct {
+	Level   int    `json:"level"`
+	Text    string `json:"text"`
+	Element string `json:"element"`
+}
+
+type pageLandmark struct {
+	Role    string `json:"role"`
+	Label   string `json:"label,omitempty"`
+	Element string `json:"element"`
+	Depth   int    `json:"depth"`
+}
+
+// captureOutline writes the heading outline and landmarks of the current
+// page to layouts/<page>_outline.json.
+func (e *AgicapExplorer) captureOutline(pageName, title string) {
+	outline := pageOutline{Page: pageName, Title: title}
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(collectOutline, &outline)); err != nil {
+		e.log("⚠️ Heading outline failed for %s: %v", pageName, err)
+		return
+	}
+	outline.Issues = outlineIssues(outline.Headings, outline.Landmarks)
+
+	data, err := json.MarshalIndent(outline, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("layouts", sanitize(pageName)+"_outline.json", data); err != nil {
+		e.log("⚠️ Failed to write heading outline for %s: %v", pageName, err)
+	}
+}
+
+// outlineIssues flags a missing or repeated h1, headings that skip a level
+// on the way down (h2 followed by h4), and a missing or repeated main
+// landmark.
+func outlineIssues(headings []outlineHeading, landmarks []pageLandmark) []string {
+	issues := []string{}
+	h1 := 0
+	for i, h := range headings {
+		if h.Level == 1 {
+			h1++
+		}
+		previous := 0
+		if i > 0 {
+			previous = headings[i-1].Level
+		}
+		if h.Level > previous+1 {
+			if previous == 0 {
+				issues = append(issues, fmt.Sprintf("first heading is h%d %q", h.Level, h.Text))
+			} else {
+				issues = append(issues, fmt.Sprintf("h%d %q follows h%d, skipping a level", h.Level, h.Text, previous))
+			}
+		}
+	}
+	switch {
+	case len(headings) == 0:
+		issues = append(issues, "no headings")
+	case h1 == 0:
+		issues = append(issues, "no h1")
+	case h1 > 1:
+		issues = append(issues, fmt.Sprintf("%d h1 headings", h1))
+	}
+
+	main := 0
+	for _, l := range landmarks {
+		if l.Role == "main" {
+			main++
+		}
+	}
+	switch {
+	case main == 0:
+		issues = append(issues, "no main landmark")
+	case main > 1:
+		issues = append(issues, fmt.Sprintf("%d main landmarks", main))
+	}
+	return issues
+}
+
+// outlineSection lists the landmark structure and heading outline issues of
+// every page for the rebuild guide.
+func (e *AgicapExplorer) outlineSection() string {
+	var b strings.Builder
+	pages, noMain, skipped := 0, 0, 0
+	for _, item := range e.navigationMap {
+		data, err := ioutil.ReadFile(filepath.Join(e.outputDir, "layouts", pageKey(item)+"_outline.json"))
+		if err != nil {
+			continue
+		}
+		var outline pageOutline
+		if json.Unmarshal(data, &outline) != nil {
+			continue
+		}
+		pages++
+		skips := false
+		for _, issue := range outline.Issues {
+			switch {
+			case issue == "no main landmark":
+				noMain++
+			case strings.HasSuffix(issue, "skipping a level"):
+				skips = true
+			}
+		}
+		if skips {
+			skipped++
+		}
+
+		roles := make([]string, 0, len(outline.Landmarks))
+		for _, l := range outline.Landmarks {
+			roles = append(roles, strings.Repeat(">", l.Depth)+l.Role)
+		}
+		if len(roles) == 0 {
+			roles = append(roles, "none")
+		}
+		fmt.Fprintf(&b, "- **%s** - landmarks: %s; %d headings", outline.Title, strings.Join(roles, ", "), len(outline.Headings))
+		if len(outline.Issues) > 0 {
+			fmt.Fprintf(&b, "; ⚠️ %s", strings.Join(outline.Issues, "; "))
+		}
+		b.WriteString("\n")
+	}
+	if pages == 0 {
+		return "No heading outlines were captured.\n"
+	}
+	return fmt.Sprintf("%d of %d pages lack a main landmark, %d skip heading levels (details in ./layouts/<page>_outline.json):\n\n", noMain, pages, skipped) + b.String()
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+// overlayTriggersJS lists up to %d visible elements that open a menu,
+// dialog, drawer or popover: aria-haspopup, Bootstrap's data-toggle,
+// disclosure buttons with aria-controls and menu buttons. Links that
+// navigate away are left out.
+const overlayTriggersJS = `
+(function(max) {` + domKeyJS + stableSelectorJS + `
+	const selectors = [
+		'[aria-haspopup]:not([aria-haspopup="false"])',
+		'[data-toggle]:not([data-toggle="tab"]):not([data-toggle="collapse"]):not([data-toggle="tooltip"])',
+		'[data-bs-toggle]:not([data-bs-toggle="tab"]):not([data-bs-toggle="collapse"]):not([data-bs-toggle="tooltip"])',
+		'button[aria-controls][aria-expanded]', '[role="button"][aria-controls][aria-expanded]',
+		'.dropdown-toggle', '[data-target]', '[data-bs-target]',
+		'button[aria-label*="menu" i]', 'button[aria-label*="menü" i]', 'button[aria-label*="more" i]',
+		'button[aria-label*="mehr" i]', 'button[class*="menu" i]'
+	];
+	const triggers = [];
+	const seen = new Set();
+	for (const el of document.querySelectorAll(selectors.join(', '))) {
+		if (triggers.length >= max) break;
+		if (seen.has(el) || el.disabled || el.getAttribute('aria-disabled') === 'true') continue;
+		seen.add(el);
+		const href = el.tagName === 'A' ? el.getAttribute('href') || '' : '';
+		if (href && !href.startsWith('#') && !href.startsWith('javascript:')) continue;
+		const rect = el.getBoundingClientRect();
+		if (rect.width < 4 || rect.height < 4 || getComputedStyle(el).visibility === 'hidden') continue;
+		const selector = (selectorsOf(el)[0] || {}).selector;
+		if (!selector) continue;
+		triggers.push({
+			selector: selector,
+			text: (el.getAttribute('aria-label') || el.textContent || el.getAttribute('title') || '').trim().replace(/\s+/g, ' ').substring(0, 60),
+			popup: el.getAttribute('aria-haspopup') || el.getAttribute('data-toggle') || el.getAttribute('data-bs-toggle') || '',
+			controls: el.getAttribute('aria-controls') || ''
+		});
+	}
+	return triggers;
+})(%d)
+`
+
+// overlayLayersJS lists the visible layers above the page: elements with a
+// dialog, menu, listbox or tooltip role, open dialogs and popovers, and
+// fixed or absolutely positioned elements with a z-index. Each is
+// classified as modal, drawer (full height at an edge), dropdown, tooltip
+// or popover, with the texts of its menu items, options or buttons.
+const overlayLayersJS = `
+(function() {` + domKeyJS + stableSelectorJS + `
+	const roles = '[role="dialog"], [role="alertdialog"], [role="menu"], [role="listbox"], [role="tooltip"], dialog[open], [aria-modal="true"], [popover]';
+	const layers = [];
+	for (const el of document.body.querySelectorAll('*')) {
+		const style = getComputedStyle(el);
+		const positioned = (style.position === 'fixed' || style.position === 'absolute') && parseInt(style.zIndex, 10) >= 10;
+		if (!positioned && !el.matches(roles)) continue;
+		const rect = el.getBoundingClientRect();
+		if (rect.width < 40 || rect.height < 20 || style.display === 'none' || style.visibility === 'hidden' || parseFloat(style.opacity) === 0) continue;
+		if (rect.bottom <= 0 || rect.right <= 0 || rect.top >= window.innerHeight || rect.left >= window.innerWidth) continue;
+		const role = el.getAttribute('role') || (el.tagName === 'DIALOG' ? 'dialog' : '');
+		const cls = (typeof el.className === 'string' ? el.className : '').toLowerCase();
+		const edge = rect.left <= 1 || rect.right >= window.innerWidth - 1;
+		const fullHeight = rect.height >= window.innerHeight * 0.9 && rect.width < window.innerWidth * 0.7;
+		let kind = 'popover';
+		if (role === 'tooltip') kind = 'tooltip';
+		else if (role === 'menu' || role === 'listbox' || /dropdown|menu|select/.test(cls)) kind = 'dropdown';
+		else if ((fullHeight && edge && style.position === 'fixed') || /drawer|offcanvas|sheet/.test(cls)) kind = 'drawer';
+		else if (role === 'dialog' || role === 'alertdialog' || el.getAttribute('aria-modal') === 'true' || /modal|dialog/.test(cls)) kind = 'modal';
+		else if (style.position === 'fixed' && rect.width >= window.innerWidth * 0.9 && rect.height >= window.innerHeight * 0.9) continue; // backdrop
+		const labelledBy = el.getAttribute('aria-labelledby');
+		const heading = (labelledBy && document.getElementById(labelledBy)) || el.querySelector('h1, h2, h3, h4, [class*="title" i]');
+		const items = Array.from(el.querySelectorAll('[role="menuitem"], [role="menuitemcheckbox"], [role="menuitemradio"], [role="option"], button, a[href]'))
+			.map(item => (item.getAttribute('aria-label') || item.textContent || '').trim().replace(/\s+/g, ' ').substring(0, 60))
+			.filter(Boolean).slice(0, 30);
+		layers.push({
+			key: keyOf(el),
+			id: el.id || '',
+			selector: (selectorsOf(el)[0] || {selector: 'body > ' + keyOf(el)}).selector,
+			role: role,
+			kind: kind,
+			label: (el.getAttribute('aria-label') || (heading ? heading.textContent : '') || '').trim().replace(/\s+/g, ' ').substring(0, 80),
+			items: items,
+			width: Math.round(rect.width),
+			height: Math.round(rect.height)
+		});
+	}
+	return layers;
+})()
+`
+
+// overlayCloseButtonJS returns the selector of the button that closes the
+// layer of selector %s: labeled close, cancel or ×, or a Bootstrap
+// dismiss button; "" when it has none.
+const overlayCloseButtonJS = `
+(function(selector) {` + domKeyJS + stableSelectorJS + `
+	const layer = document.querySelector(selector);
+	if (!layer) return '';
+	const labeled = /^(close|schließen|schliessen|cancel|abbrechen|dismiss|×|✕|✖|x)$/i;
+	const buttons = Array.from(layer.querySelectorAll('button, [role="button"], a[href="#"], [data-dismiss], [data-bs-dismiss]'));
+	const button = buttons.find(b => b.matches('[data-dismiss], [data-bs-dismiss], .close, .btn-close, [aria-label*="close" i], [aria-label*="schließen" i]')) ||
+		buttons.find(b => labeled.test((b.getAttribute('aria-label') || b.textContent || '').trim()));
+	return button ? (selectorsOf(button)[0] || {selector: ''}).selector : '';
+})(%s)
+`
+
+type overlayTrigger struct {
+	Selector string `json:"selector"`
+	Text     string `json:"text"`
+	Popup    string `json:"popup"`
+	Controls string `json:"controls"`
+}
+
+type overlayLayer struct {
+	Key      string   `json:"key"`
+	ID       string   `json:"id"`
+	Selector string   `json:"selector"`
+	Role     string   `json:"role"`
+	Kind     string   `json:"kind"`
+	Label    string   `json:"label"`
+	Items    []string `json:"items"`
+	Width    int      `json:"width"`
+	Height   int      `json:"height"`
+}
+
+// overlayCapture is an overlay and the trigger that opened it. ClosedBy is
+// how it was closed again: "escape", "close_button", "trigger" (clicking
+// the trigger again) or "reload".
+type overlayCapture struct {
+	Trigger     string   `json:"trigger"`
+	TriggerText string   `json:"trigger_text,omitempty"`
+	Popup       string   `json:"popup,omitempty"` // aria-haspopup or data-toggle of the trigger
+	Kind        string   `json:"kind"`
+	Role        string   `json:"role,omitempty"`
+	Selector    string   `json:"selector"`
+	Label       string   `json:"label,omitempty"`
+	Items       []string `json:"items,omitempty"`
+	Width       int      `json:"width"`
+	Height      int      `json:"height"`
+	Screenshot  string   `json:"screenshot,omitempty"`
+	Element     string   `json:"element_screenshot,omitempty"`
+	ClosedBy    string   `json:"closed_by"`
+}
+
+// pageOverlays is overlays/<page>/overlays.json. Inert are the triggers
+// whose click opened nothing.
+type pageOverlays struct {
+	Page     string           `json:"page"`
+	URL      string           `json:"url"`
+	Overlays []overlayCapture `json:"overlays"`
+	Inert    []string         `json:"inert"`
+}
+
+// exploreOverlays opens the menus, dialogs, drawers and popovers of the
+// page one at a time: it clicks each trigger, takes the layers that were
+// not there before as its overlay, screenshots the viewport and the
+// overlay, and closes it with Escape, else its close button, else the
+// trigger, else by reloading the page. The trigger of each overlay is
+// recorded in overlays/<page>/overlays.json.
+func (e *AgicapExplorer) exploreOverlays(pageName string) string {
+	var pageURL string
+	var triggers []overlayTrigger
+	err := chromedp.Run(e.ctx,
+		chromedp.Location(&pageURL),
+		chromedp.Evaluate(fmt.Sprintf(overlayTriggersJS, e.config.GetInt("explorer.overlays.max_triggers")), &triggers),
+	)
+	if err != nil || len(triggers) == 0 {
+		return ""
+	}
+	dir := filepath.Join("overlays", sanitize(pageName))
+	wait := e.config.GetDuration("explorer.overlays.wait")
+	result := pageOverlays{Page: pageName, URL: pageURL, Overlays: []overlayCapture{}, Inert: []string{}}
+
+	for _, t := range triggers {
+		if !e.allowAction("click", t.Selector, t.Text) {
+			continue
+		}
+		before := e.overlayLayers()
+		if err := e.clickWithin(t.Selector, wait); err != nil {
+			continue
+		}
+		var current string
+		chromedp.Run(e.ctx, chromedp.Location(&current))
+		if current != pageURL {
+			// The trigger navigated; go back and move on
+			if !e.reloadPage(pageURL) {
+				break
+			}
+			continue
+		}
+
+		layer, ok := openedLayer(before, e.overlayLayers(), t.Controls)
+		if !ok {
+			result.Inert = append(result.Inert, t.Selector)
+			continue
+		}
+		name := fmt.Sprintf("%02d_%s", len(result.Overlays)+1, layer.Kind)
+		overlay := overlayCapture{
+			Trigger:     t.Selector,
+			TriggerText: t.Text,
+			Popup:       t.Popup,
+			Kind:        layer.Kind,
+			Role:        layer.Role,
+			Selector:    layer.Selector,
+			Label:       layer.Label,
+			Items:       layer.Items,
+			Width:       layer.Width,
+			Height:      layer.Height,
+		}
+		var shot, element []byte
+		if err := chromedp.Run(e.ctx, chromedp.CaptureScreenshot(&shot)); err == nil {
+			if path, err := e.writeArtifact(dir, name+".png", shot); err == nil {
+				overlay.Screenshot, _ = filepath.Rel(e.outputDir, path)
+				overlay.Screenshot = filepath.ToSlash(overlay.Screenshot)
+			}
+		}
+		ctx, cancel := context.WithTimeout(e.ctx, wait+2*time.Second)
+		if err := chromedp.Run(ctx, chromedp.Screenshot(layer.Selector, &element, chromedp.ByQuery)); err == nil {
+			if path, err := e.writeArtifact(dir, name+"_element.png", element); err == nil {
+				overlay.Element, _ = filepath.Rel(e.outputDir, path)
+				overlay.Element = filepath.ToSlash(overlay.Element)
+			}
+		}
+		cancel()
+
+		overlay.ClosedBy = e.closeOverlay(t, layer, pageURL, wait)
+		result.Overlays = append(result.Overlays, overlay)
+		e.log("🪟 %s: %q opened a %s, closed by %s", pageName, t.Text, layer.Kind, overlay.ClosedBy)
+		if overlay.ClosedBy == "" {
+			break
+		}
+	}
+	if len(result.Overlays) == 0 {
+		return ""
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact(dir, "overlays.json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write the overlays of %s: %v", pageName, err)
+		return ""
+	}
+	e.setOverlays(path)
+	e.log("🪟 %s: %s from %d triggers", pageName, overlayKinds(result.Overlays), len(triggers))
+	return path
+}
+
+// overlayLayers returns the layers above the page right now.
+func (e *AgicapExplorer) overlayLayers() []overlayLayer {
+	var layers []overlayLayer
+	chromedp.Run(e.ctx, chromedp.Evaluate(overlayLayersJS, &layers))
+	return layers
+}
+
+// openedLayer finds the overlay a click opened among the layers that were
+// not there before: the one the trigger's aria-controls names, or else the
+// outermost, preferring modals and drawers over the dropdowns inside them.
+func openedLayer(before, after []overlayLayer, controls string) (overlayLayer, bool) {
+	existing := make(map[string]bool)
+	for _, l := range before {
+		existing[l.Key] = true
+	}
+	var opened []overlayLayer
+	for _, l := range after {
+		if existing[l.Key] {
+			continue
+		}
+		if controls != "" && l.ID == controls {
+			return l, true
+		}
+		opened = append(opened, l)
+	}
+	if len(opened) == 0 {
+		return overlayLayer{}, false
+	}
+	rank := map[string]int{"modal": 0, "drawer": 1, "dropdown": 2, "popover": 3, "tooltip": 4}
+	sort.SliceStable(opened, func(i, j int) bool {
+		if rank[opened[i].Kind] != rank[opened[j].Kind] {
+			return rank[opened[i].Kind] < rank[opened[j].Kind]
+		}
+		return len(opened[i].Key) < len(opened[j].Key)
+	})
+	return opened[0], true
+}
+
+// closeOverlay closes the overlay and returns how, "" when even reloading
+// the page failed.
+func (e *AgicapExplorer) closeOverlay(t overlayTrigger, layer overlayLayer, pageURL string, wait time.Duration) string {
+	closed := func() bool {
+		for _, l := range e.overlayLayers() {
+			if l.Key == layer.Key {
+				return false
+			}
+		}
+		return true
+	}
+
+	chromedp.Run(e.ctx, chromedp.KeyEvent(kb.Escape), chromedp.Sleep(wait))
+	if closed() {
+		return "escape"
+	}
+	var button string
+	chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(overlayCloseButtonJS, jsString(layer.Selector)), &button))
+	if button != "" && e.clickWithin(button, wait) == nil && closed() {
+		return "close_button"
+	}
+	if e.clickWithin(t.Selector, wait) == nil && closed() {
+		return "trigger"
+	}
+	if e.reloadPage(pageURL) {
+		return "reload"
+	}
+	return ""
+}
+
+// clickWithin clicks the element of selector and waits for the page to
+// settle, giving up when it does not show up in time.
+func (e *AgicapExplorer) clickWithin(selector string, wait time.Duration) error {
+	ctx, cancel := context.WithTimeout(e.ctx, wait+5*time.Second)
+	defer cancel()
+	err := chromedp.Run(ctx, chromedp.Click(selector, chromedp.ByQuery))
+	if err != nil {
+		return err
+	}
+	return chromedp.Run(e.ctx, e.settle("interaction"), chromedp.Sleep(wait))
+}
+
+// reloadPage navigates back to the page the overlays are explored on.
+func (e *AgicapExplorer) reloadPage(pageURL string) bool {
+	if err := chromedp.Run(e.ctx, chromedp.Navigate(pageURL), e.settle("navigation")); err != nil {
+		e.log("⚠️ Failed to return to %s: %v", pageURL, err)
+		return false
+	}
+	return true
+}
+
+// setOverlays records the overlays file on the page this tab captured last.
+func (e *AgicapExplorer) setOverlays(path string) {
+	if e.lastCapture < 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.shared()
+	s.navigationMap[e.lastCapture].Overlays = path
+	s.streamItem(e.lastCapture)
+}
+
+// overlayKinds counts overlays by kind, for the log.
+func overlayKinds(overlays []overlayCapture) string {
+	counts := make(map[string]int)
+	for _, o := range overlays {
+		counts[o.Kind]++
+	}
+	var parts []string
+	for _, kind := range []string{"modal", "drawer", "dropdown", "popover", "tooltip"} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
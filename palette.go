@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// colorSample is one distinct computed color and where it was used.
+type colorSample struct {
+	hex   string
+	lab   [3]float64
+	count int
+	usage map[string]int
+}
+
+// colorPalette collects the raw colors of every analysed component.
+type colorPalette struct {
+	samples map[string]*colorSample
+}
+
+func newColorPalette() *colorPalette {
+	return &colorPalette{samples: make(map[string]*colorSample)}
+}
+
+// Add records an rgb()/rgba() value used as text, background or border.
+// Colors on buttons and other controls are also counted as "interactive",
+// which is what brand colors are picked from.
+func (p *colorPalette) Add(value, usage string, interactive bool) {
+	hex, rgb, ok := parseCSSColor(value)
+	if !ok {
+		return
+	}
+
+	s, ok := p.samples[hex]
+	if !ok {
+		s = &colorSample{hex: hex, lab: rgbToLab(rgb[0], rgb[1], rgb[2]), usage: make(map[string]int)}
+		p.samples[hex] = s
+	}
+	s.count++
+	s.usage[usage]++
+	if interactive {
+		s.usage["interactive"]++
+	}
+}
+
+// parseCSSColor converts a computed rgb()/rgba() value to hex (#rrggbb, or
+// #rrggbbaa when translucent). Fully transparent colors are rejected.
+func parseCSSColor(value string) (string, [3]float64, bool) {
+	var rgb [3]float64
+	m := cssColorPattern.FindStringSubmatch(value)
+	if m == nil {
+		return "", rgb, false
+	}
+	for i := range rgb {
+		rgb[i], _ = strconv.ParseFloat(m[i+1], 64)
+	}
+	hex := fmt.Sprintf("#%02x%02x%02x", int(rgb[0]), int(rgb[1]), int(rgb[2]))
+	if m[4] != "" {
+		alpha, _ := strconv.ParseFloat(m[4], 64)
+		if alpha == 0 {
+			return "", rgb, false
+		}
+		if alpha < 1 {
+			hex += fmt.Sprintf("%02x", int(math.Round(alpha*255)))
+		}
+	}
+	return hex, rgb, true
+}
+
+// colorCluster is a group of near-identical colors represented by the most
+// used member.
+type colorCluster struct {
+	Hex     string         `json:"hex"`
+	Role    string         `json:"role,omitempty"`
+	Count   int            `json:"count"`
+	Usage   map[string]int `json:"usage"`
+	Members []string       `json:"members,omitempty"`
+
+	centroid [3]float64
+	samples  []*colorSample
+	lab      [3]float64
+	chroma   float64
+	hue      float64
+}
+
+// Cluster reduces the samples to a palette: colors within distance (CIE76 ΔE)
+// of each other seed a cluster, a few k-means passes settle the centroids,
+// and the closest clusters are merged until at most maxColors remain.
+func (p *colorPalette) Cluster(distance float64, maxColors int) []*colorCluster {
+	if distance <= 0 {
+		distance = 6
+	}
+	samples := make([]*colorSample, 0, len(p.samples))
+	for _, s := range p.samples {
+		samples = append(samples, s)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].count != samples[j].count {
+			return samples[i].count > samples[j].count
+		}
+		return samples[i].hex < samples[j].hex
+	})
+
+	var clusters []*colorCluster
+	for _, s := range samples {
+		if nearestCluster(clusters, s.lab, distance) == nil {
+			clusters = append(clusters, &colorCluster{centroid: s.lab})
+		}
+	}
+
+	for iteration := 0; iteration < 10 && len(clusters) > 0; iteration++ {
+		for _, c := range clusters {
+			c.samples = nil
+		}
+		for _, s := range samples {
+			c := nearestCluster(clusters, s.lab, math.Inf(1))
+			c.samples = append(c.samples, s)
+		}
+		moved := false
+		kept := clusters[:0]
+		for _, c := range clusters {
+			if len(c.samples) == 0 {
+				moved = true
+				continue
+			}
+			centroid := weightedCentroid(c.samples)
+			if labDistance(centroid, c.centroid) > 0.01 {
+				moved = true
+			}
+			c.centroid = centroid
+			kept = append(kept, c)
+		}
+		clusters = kept
+		if !moved {
+			break
+		}
+	}
+
+	for maxColors > 0 && len(clusters) > maxColors {
+		a, b, best := 0, 1, math.Inf(1)
+		for i := range clusters {
+			for j := i + 1; j < len(clusters); j++ {
+				if d := labDistance(clusters[i].centroid, clusters[j].centroid); d < best {
+					a, b, best = i, j, d
+				}
+			}
+		}
+		clusters[a].samples = append(clusters[a].samples, clusters[b].samples...)
+		clusters[a].centroid = weightedCentroid(clusters[a].samples)
+		clusters = append(clusters[:b], clusters[b+1:]...)
+	}
+
+	for _, c := range clusters {
+		sort.SliceStable(c.samples, func(i, j int) bool { return c.samples[i].count > c.samples[j].count })
+		c.Hex = c.samples[0].hex
+		c.lab = c.samples[0].lab
+		c.chroma = math.Hypot(c.lab[1], c.lab[2])
+		c.hue = math.Mod(math.Atan2(c.lab[2], c.lab[1])*180/math.Pi+360, 360)
+		c.Usage = make(map[string]int)
+		for i, s := range c.samples {
+			c.Count += s.count
+			for usage, n := range s.usage {
+				c.Usage[usage] += n
+			}
+			if i > 0 {
+				c.Members = append(c.Members, s.hex)
+			}
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i].Count > clusters[j].Count })
+	return clusters
+}
+
+func nearestCluster(clusters []*colorCluster, lab [3]float64, within float64) *colorCluster {
+	var nearest *colorCluster
+	best := within
+	for _, c := range clusters {
+		if d := labDistance(c.centroid, lab); d < best {
+			nearest, best = c, d
+		}
+	}
+	return nearest
+}
+
+func weightedCentroid(samples []*colorSample) [3]float64 {
+	var sum [3]float64
+	total := 0.0
+	for _, s := range samples {
+		w := float64(s.count)
+		for i := range sum {
+			sum[i] += s.lab[i] * w
+		}
+		total += w
+	}
+	for i := range sum {
+		sum[i] /= total
+	}
+	return sum
+}
+
+// colorRoles names palette entries by usage and context: the brand colors
+// are the saturated colors used most on controls, status colors are picked
+// by hue, and background, surface, text and border by how they are used.
+// Each chosen cluster records its role.
+func colorRoles(clusters []*colorCluster) orderedTokens {
+	pick := func(role, usage string, filter func(*colorCluster) bool) string {
+		sorted := append([]*colorCluster(nil), clusters...)
+		if usage != "" {
+			sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Usage[usage] > sorted[j].Usage[usage] })
+		}
+		for _, c := range sorted {
+			if c.Role != "" || (usage != "" && c.Usage[usage] == 0) || (filter != nil && !filter(c)) {
+				continue
+			}
+			c.Role = role
+			return c.Hex
+		}
+		return ""
+	}
+	saturated := func(c *colorCluster) bool { return c.chroma > 25 }
+	hueRange := func(from, to float64) func(*colorCluster) bool {
+		return func(c *colorCluster) bool {
+			return saturated(c) && c.hue >= from && c.hue <= to
+		}
+	}
+
+	// Brand first, then status colors by hue, so a red brand color does not
+	// also become the danger color
+	primary := pick("primary", "interactive", saturated)
+	if primary == "" {
+		primary = pick("primary", "", saturated)
+	}
+	success := pick("success", "", hueRange(120, 180))
+	warning := pick("warning", "", hueRange(60, 95))
+	danger := pick("danger", "", hueRange(15, 50))
+	info := pick("info", "", hueRange(230, 300))
+	secondary := pick("secondary", "", saturated)
+
+	background := pick("background", "background", nil)
+	surface := pick("surface", "background", func(c *colorCluster) bool { return c.lab[0] > 85 })
+	textPrimary := pick("text.primary", "text", nil)
+	textSecondary := pick("text.secondary", "text", nil)
+	border := pick("border", "border", nil)
+
+	roles := orderedTokens{}
+	add := func(tokens *orderedTokens, name, hex string) {
+		if hex != "" {
+			*tokens = append(*tokens, tokenEntry{name, hex})
+		}
+	}
+	add(&roles, "primary", primary)
+	add(&roles, "secondary", secondary)
+	add(&roles, "success", success)
+	add(&roles, "warning", warning)
+	add(&roles, "danger", danger)
+	add(&roles, "info", info)
+	add(&roles, "background", background)
+	add(&roles, "surface", surface)
+	text := orderedTokens{}
+	add(&text, "primary", textPrimary)
+	add(&text, "secondary", textSecondary)
+	if len(text) > 0 {
+		roles = append(roles, tokenEntry{"text", text})
+	}
+	add(&roles, "border", border)
+	return roles
+}
+
+// isInteractiveComponent reports whether a component type is a control
+// whose colors hint at the brand palette.
+func isInteractiveComponent(componentType string) bool {
+	t := strings.ToLower(componentType)
+	for _, kind := range []string{"button", "btn", "submit", "tab", "link"} {
+		if strings.Contains(t, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// rgbToLab converts sRGB (0-255) to CIE L*a*b* (D65).
+func rgbToLab(r, g, b float64) [3]float64 {
+	linear := func(c float64) float64 {
+		c /= 255
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	rl, gl, bl := linear(r), linear(g), linear(b)
+	x := (rl*0.4124 + gl*0.3576 + bl*0.1805) / 0.95047
+	y := rl*0.2126 + gl*0.7152 + bl*0.0722
+	z := (rl*0.0193 + gl*0.1192 + bl*0.9505) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 0.008856 {
+			return math.Cbrt(t)
+		}
+		return 7.787*t + 16.0/116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+func labDistance(a, b [3]float64) float64 {
+	return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+}
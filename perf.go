@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chromedp/cdproto/performance"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// pageMetrics holds load timings, Core Web Vitals and CPU cost of a page.
+// Times are in milliseconds; vitals that did not occur are left at zero.
+type pageMetrics struct {
+	TTFB             float64 `json:"ttfb_ms"`
+	FCP              float64 `json:"fcp_ms"`
+	LCP              float64 `json:"lcp_ms"`
+	CLS              float64 `json:"cls"`
+	INP              float64 `json:"inp_ms"`
+	DOMContentLoaded float64 `json:"dom_content_loaded_ms"`
+	Load             float64 `json:"load_ms"`
+	Requests         int     `json:"requests"`
+	TransferredBytes int64   `json:"transferred_bytes"`
+	ScriptDuration   float64 `json:"script_duration_ms"`
+	TaskDuration     float64 `json:"task_duration_ms"`
+	JSHeapUsed       int64   `json:"js_heap_used_bytes"`
+}
+
+// webVitals reads navigation timing and the buffered paint, layout-shift and
+// event-timing entries. CLS uses session windows (1s gap, 5s cap) and INP is
+// the slowest interaction so far.
+const webVitals = `
+new Promise(resolve => {
+	let lcp = 0, cls = 0, inp = 0;
+	let session = 0, sessionStart = 0, lastShift = 0;
+	const observe = (type, cb, opts) => {
+		try { new PerformanceObserver(list => list.getEntries().forEach(cb)).observe(Object.assign({type: type, buffered: true}, opts)); } catch (e) {}
+	};
+	observe('largest-contentful-paint', e => { lcp = Math.max(lcp, e.renderTime || e.loadTime || e.startTime); });
+	observe('layout-shift', e => {
+		if (e.hadRecentInput) return;
+		if (session && (e.startTime - lastShift > 1000 || e.startTime - sessionStart > 5000)) session = 0;
+		if (!session) sessionStart = e.startTime;
+		session += e.value;
+		lastShift = e.startTime;
+		cls = Math.max(cls, session);
+	});
+	observe('event', e => { if (e.interactionId) inp = Math.max(inp, e.duration); }, {durationThreshold: 16});
+
+	setTimeout(() => {
+		const nav = performance.getEntriesByType('navigation')[0] || {};
+		const fcp = performance.getEntriesByName('first-contentful-paint')[0];
+		const resources = performance.getEntriesByType('resource');
+		let bytes = nav.transferSize || 0;
+		resources.forEach(r => { bytes += r.transferSize || 0; });
+		resolve({
+			ttfb_ms: nav.responseStart || 0,
+			fcp_ms: fcp ? fcp.startTime : 0,
+			lcp_ms: lcp,
+			cls: cls,
+			inp_ms: inp,
+			dom_content_loaded_ms: nav.domContentLoadedEventEnd || 0,
+			load_ms: nav.loadEventEnd || 0,
+			requests: resources.length + 1,
+			transferred_bytes: bytes
+		});
+	}, 200);
+})
+`
+
+// resetPerformanceCounters restarts the CDP Performance domain so script and
+// task durations only cover the next page.
+func (e *AgicapExplorer) resetPerformanceCounters() {
+	if !e.config.GetBool("explorer.capture.performance") {
+		return
+	}
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		performance.Disable().Do(ctx)
+		return performance.Enable().WithTimeDomain(performance.EnableTimeDomainThreadTicks).Do(ctx)
+	}))
+}
+
+// capturePerformance measures the current page and writes perf/<page>.json.
+func (e *AgicapExplorer) capturePerformance(pageName string) *pageMetrics {
+	metrics := &pageMetrics{}
+	err := chromedp.Run(e.ctx, chromedp.Evaluate(webVitals, metrics, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}))
+	if err != nil {
+		e.log("⚠️ Failed to read web vitals for %s: %v", pageName, err)
+		return nil
+	}
+
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		counters, err := performance.GetMetrics().Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range counters {
+			switch m.Name {
+			case "ScriptDuration":
+				metrics.ScriptDuration = m.Value * 1000
+			case "TaskDuration":
+				metrics.TaskDuration = m.Value * 1000
+			case "JSHeapUsedSize":
+				metrics.JSHeapUsed = int64(m.Value)
+			}
+		}
+		return nil
+	}))
+
+	data, err := json.MarshalIndent(metrics, "", "  ")
+	if err == nil {
+		if _, err := e.writeArtifact("perf", sanitize(pageName)+".json", data); err != nil {
+			e.log("⚠️ Failed to write performance metrics for %s: %v", pageName, err)
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// politeness spaces out navigations to stay under the target's rate limits
+// and backs off when the server answers 429 or 503.
+type politeness struct {
+	mu           sync.Mutex
+	enabled      bool
+	interval     time.Duration
+	jitter       float64
+	backoff      time.Duration
+	backoffMax   time.Duration
+	lastNav      time.Time
+	backoffUntil time.Time
+	throttles    int
+	disallowed   []string
+	log          func(format string, args ...interface{})
+}
+
+func newPoliteness(v *viper.Viper, logf func(format string, args ...interface{})) *politeness {
+	p := &politeness{
+		enabled:    v.GetBool("explorer.politeness.enabled"),
+		jitter:     v.GetFloat64("explorer.politeness.jitter"),
+		backoff:    v.GetDuration("explorer.politeness.backoff_initial"),
+		backoffMax: v.GetDuration("explorer.politeness.backoff_max"),
+		log:        logf,
+	}
+	if rpm := v.GetFloat64("explorer.politeness.requests_per_minute"); rpm > 0 {
+		p.interval = time.Duration(float64(time.Minute) / rpm)
+	}
+	return p
+}
+
+// Listen watches network responses for throttling status codes.
+func (p *politeness) Listen(ctx context.Context) {
+	if !p.enabled {
+		return
+	}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		resp, ok := ev.(*network.EventResponseReceived)
+		if !ok || (resp.Response.Status != 429 && resp.Response.Status != 503) {
+			return
+		}
+		p.throttled(int(resp.Response.Status), retryAfter(resp.Response.Headers))
+	})
+}
+
+// throttled extends the backoff window exponentially, honouring Retry-After.
+func (p *politeness) throttled(status int, hint time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	wait := p.backoff << uint(p.throttles)
+	if p.backoffMax > 0 && wait > p.backoffMax {
+		wait = p.backoffMax
+	}
+	if hint > wait {
+		wait = hint
+	}
+	if until := time.Now().Add(wait); until.After(p.backoffUntil) {
+		p.backoffUntil = until
+		p.log("🐢 Server answered %d, backing off for %s", status, wait)
+	}
+	if p.throttles < 10 {
+		p.throttles++
+	}
+}
+
+// Wait blocks until the next navigation is allowed. The slot is reserved
+// before sleeping, so tabs waiting together are spaced out rather than all
+// woken at the same time.
+func (p *politeness) Wait(ctx context.Context) error {
+	if !p.enabled {
+		return nil
+	}
+
+	p.mu.Lock()
+	next := p.lastNav.Add(p.jittered(p.interval))
+	if p.backoffUntil.After(next) {
+		next = p.backoffUntil
+	} else if p.throttles > 0 && time.Now().After(p.backoffUntil) {
+		// A clean window has passed since the last throttle
+		p.throttles--
+	}
+	if now := time.Now(); next.Before(now) {
+		next = now
+	}
+	p.lastNav = next
+	p.mu.Unlock()
+
+	if wait := time.Until(next); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (p *politeness) jittered(d time.Duration) time.Duration {
+	if d <= 0 || p.jitter <= 0 {
+		return d
+	}
+	factor := 1 + p.jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(d) * factor)
+}
+
+// LoadRobots reads Disallow rules for all user agents from robots.txt.
+func (p *politeness) LoadRobots(body string) {
+	applies := false
+	for _, line := range strings.Split(body, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			applies = value == "*"
+		case "disallow":
+			if applies && value != "" {
+				p.disallowed = append(p.disallowed, value)
+			}
+		}
+	}
+}
+
+// Disallowed reports whether robots.txt forbids rawURL.
+func (p *politeness) Disallowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range p.disallowed {
+		if strings.HasPrefix(u.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func retryAfter(headers network.Headers) time.Duration {
+	for key, value := range headers {
+		if !strings.EqualFold(key, "Retry-After") {
+			continue
+		}
+		s, _ := value.(string)
+		if secs, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := time.Parse(time.RFC1123, s); err == nil {
+			return time.Until(t)
+		}
+	}
+	return 0
+}
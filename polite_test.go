@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitSpacesOutConcurrentTabs(t *testing.T) {
+	p := &politeness{enabled: true, interval: 50 * time.Millisecond}
+	var mu sync.Mutex
+	var woke []time.Time
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.Wait(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			woke = append(woke, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(woke, func(i, j int) bool { return woke[i].Before(woke[j]) })
+	for i := 1; i < len(woke); i++ {
+		// Timers fire late, not early, so the gaps are at least the interval
+		// minus the scheduling noise between two goroutines
+		if gap := woke[i].Sub(woke[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("navigations %d and %d only %s apart, want %s", i-1, i, gap, p.interval)
+		}
+	}
+}
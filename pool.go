@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/cast"
+)
+
+// crawlState is the part of the crawl loop shared by the tabs of the pool:
+// the frontier, the page counters and the number of tabs still working on
+// a target, whose links may refill the frontier.
+type crawlState struct {
+	mu         sync.Mutex
+	wake       *sync.Cond
+	queue      *frontier
+	maxPages   int
+	deadline   time.Time
+	count      int // pages captured
+	pending    int // targets being navigated that may still turn out duplicates
+	busy       int // tabs working on a target
+	stopped    bool
+	restart    string // why the browser must be restarted before the crawl goes on
+	perSection map[string]int
+	perRoute   map[string]int
+	pageTime   time.Duration // navigation, capture and interactions of the captured pages
+	timedPages int
+}
+
+func newCrawlState(queue *frontier, maxPages int, deadline time.Time) *crawlState {
+	c := &crawlState{
+		queue:      queue,
+		maxPages:   maxPages,
+		deadline:   deadline,
+		count:      1,
+		perSection: make(map[string]int),
+		perRoute:   make(map[string]int),
+	}
+	c.wake = sync.NewCond(&c.mu)
+	return c
+}
+
+// next hands out the next target worth visiting, reserving a page for it.
+// When the frontier is empty but other tabs are busy it waits for their
+// links; it returns false once the crawl is over.
+func (c *crawlState) next(e *AgicapExplorer) (crawlTarget, string, bool) {
+	sectionLimits := e.config.GetStringMap("explorer.exploration.section_limits")
+	samplesPerRoute := e.config.GetInt("explorer.exploration.samples_per_route")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if c.stopped || c.count >= c.maxPages {
+			return crawlTarget{}, "", false
+		}
+		if !c.deadline.IsZero() && time.Now().After(c.deadline) {
+			e.log("⏱️ Crawl budget exhausted, stopping discovery with %d pages queued", c.queue.Len())
+			c.stop()
+			return crawlTarget{}, "", false
+		}
+		if average := c.averagePage(); average > 0 && !c.deadline.IsZero() && time.Now().Add(average).After(c.deadline) {
+			e.log("⏱️ A page takes %s on average, too long for the crawl budget; stopping discovery with %d pages queued", average.Round(time.Second), c.queue.Len())
+			c.stop()
+			return crawlTarget{}, "", false
+		}
+		if c.queue.Len() == 0 || c.count+c.pending >= c.maxPages {
+			if c.busy == 0 {
+				return crawlTarget{}, "", false
+			}
+			c.wake.Wait()
+			continue
+		}
+
+		target, _ := c.queue.Pop()
+		e.mu.Lock()
+		visited := e.shared().visitedURLs[target.Canonical]
+		e.mu.Unlock()
+		if visited {
+			e.log("⏭️ Skipping (already visited): %s", target.Text)
+			continue
+		}
+
+		if ok, reason := e.scope.Allows(target.URL); !ok {
+			e.log("🚫 Skipping (out of scope: %s): %s", reason, target.URL)
+			continue
+		}
+
+		if e.polite.Disallowed(target.URL) {
+			e.log("🤖 Skipping (disallowed by robots.txt): %s", target.URL)
+			continue
+		}
+
+		if limit, ok := sectionLimits[target.Section]; ok && c.perSection[target.Section] >= cast.ToInt(limit) {
+			e.log("⏭️ Skipping (section %q limit reached): %s", target.Section, target.Text)
+			continue
+		}
+
+		// Parameterized pages (/invoices/:id) only need a few samples
+		route := routeTemplate(target.Canonical)
+		if isParameterized(route) && samplesPerRoute > 0 && c.perRoute[route] >= samplesPerRoute {
+			e.log("⏭️ Skipping (%d samples of %s already captured): %s", samplesPerRoute, route, target.URL)
+			continue
+		}
+
+		c.pending++
+		c.busy++
+		c.perSection[target.Section]++
+		c.perRoute[route]++
+		e.log("🔄 [%d/%d] Navigating to: %s (depth %d, %d queued)", c.count+c.pending, c.maxPages, target.Text, target.Depth, c.queue.Len())
+		return target, route, true
+	}
+}
+
+// captured turns the reservation of a target into a captured page and
+// returns its number.
+func (c *crawlState) captured() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending--
+	c.count++
+	return c.count
+}
+
+// release gives back the reservation of a target that was not captured.
+func (c *crawlState) release(target crawlTarget, route string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending--
+	c.perSection[target.Section]--
+	c.perRoute[route]--
+	c.done()
+}
+
+// took records how long a captured page took from navigation to the end of
+// its interactions.
+func (c *crawlState) took(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pageTime += d
+	c.timedPages++
+}
+
+// averagePage is the average time of a captured page so far, 0 before the
+// first. Hold c.mu.
+func (c *crawlState) averagePage() time.Duration {
+	if c.timedPages == 0 {
+		return 0
+	}
+	return c.pageTime / time.Duration(c.timedPages)
+}
+
+// finish marks the end of a captured target, after its links were queued.
+func (c *crawlState) finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done()
+}
+
+func (c *crawlState) done() {
+	c.busy--
+	c.wake.Broadcast()
+}
+
+// crashed stops every tab so the browser can be restarted, giving the
+// target of the tab that noticed back to the frontier.
+func (c *crawlState) crashed(target crawlTarget, route, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending--
+	c.perSection[target.Section]--
+	c.perRoute[route]--
+	c.queue.Requeue(target)
+	c.restartFor(reason)
+	c.done()
+}
+
+// restartFor stops every tab until the browser is restarted. The caller
+// holds c.mu.
+func (c *crawlState) restartFor(reason string) {
+	if c.restart == "" {
+		c.restart = reason
+	}
+	c.stop()
+}
+
+// resume returns why the crawl stopped for a restart and lets it go on, or
+// returns "" when it is over.
+func (c *crawlState) resume() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reason := c.restart
+	if reason != "" {
+		c.restart = ""
+		c.stopped = false
+	}
+	return reason
+}
+
+// stop ends the crawl for every tab. The caller holds c.mu.
+func (c *crawlState) stop() {
+	c.stopped = true
+	c.wake.Broadcast()
+}
+
+// shared returns the explorer owning the state every tab of the pool writes
+// to (navigationMap, visitedURLs, fingerprints, failures and brand assets).
+// Hold e.mu while using it.
+func (e *AgicapExplorer) shared() *AgicapExplorer {
+	if e.root != nil {
+		return e.root
+	}
+	return e
+}
+
+// openTabs opens n-1 more tabs next to the logged-in one. With
+// explorer.exploration.isolate_tabs each tab gets its own browser context
+// seeded with the session cookies; otherwise they share the login's
+// context, including local and session storage.
+func (e *AgicapExplorer) openTabs(n int) []*AgicapExplorer {
+	isolate := e.config.GetBool("explorer.exploration.isolate_tabs")
+	var cookies []*network.Cookie
+	if isolate {
+		err := chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = storage.GetCookies().Do(ctx)
+			return err
+		}))
+		if err != nil {
+			e.log("⚠️ Failed to read the session cookies, tabs will share the browser context: %v", err)
+			isolate = false
+		}
+	}
+
+	var tabs []*AgicapExplorer
+	for i := 1; i < n; i++ {
+		tab, err := e.newTab(isolate, cookies)
+		if err != nil {
+			e.log("⚠️ Failed to open tab %d: %v", i+1, err)
+			break
+		}
+		tabs = append(tabs, tab)
+	}
+	return tabs
+}
+
+// newTab is a copy of the explorer driving its own tab, with its own
+// request, console and network activity recorders. Everything else is shared with e.
+func (e *AgicapExplorer) newTab(isolate bool, cookies []*network.Cookie) (*AgicapExplorer, error) {
+	var opts []chromedp.ContextOption
+	if isolate {
+		opts = append(opts, chromedp.WithNewBrowserContext())
+	}
+	ctx, cancel := chromedp.NewContext(e.ctx, opts...)
+
+	tab := *e
+	tab.ctx = ctx
+	tab.cancel = cancel
+	tab.root = e
+	tab.current = crawlTarget{}
+	tab.lastCapture = -1
+	tab.activity = newNetworkActivity()
+	tab.activity.Listen(ctx)
+	tab.watchCrashes(ctx)
+	tab.network = newNetworkRecorder(e.config)
+	tab.network.Listen(ctx)
+	tab.console = newConsoleRecorder(e.config.GetBool("explorer.capture.console"))
+	tab.console.Listen(ctx)
+	e.polite.Listen(ctx)
+	e.stylesheets.Listen(ctx)
+
+	// The first Run opens the tab
+	if err := chromedp.Run(ctx, network.SetCookies(cookieParams(cookies))); err != nil {
+		cancel()
+		return nil, err
+	}
+	if e.mocks != nil {
+		if err := e.mocks.Start(ctx); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to enable request mocking: %w", err)
+		}
+	}
+	return &tab, nil
+}
+
+// closeTab flushes the tab's last WebSocket log, hands its recorded
+// requests to e for the API inventory and closes it.
+func (e *AgicapExplorer) closeTab(tab *AgicapExplorer) {
+	tab.writeWebSocketLog()
+	e.network.absorb(tab.network)
+	tab.cancel()
+}
+
+// cookieParams converts cookies read from one browser context for setting
+// them in another.
+func cookieParams(cookies []*network.Cookie) []*network.CookieParam {
+	params := make([]*network.CookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		p := &network.CookieParam{
+			Name:         c.Name,
+			Value:        c.Value,
+			Domain:       c.Domain,
+			Path:         c.Path,
+			Secure:       c.Secure,
+			HTTPOnly:     c.HTTPOnly,
+			SameSite:     c.SameSite,
+			Priority:     c.Priority,
+			SameParty:    c.SameParty,
+			SourceScheme: c.SourceScheme,
+			SourcePort:   c.SourcePort,
+			PartitionKey: c.PartitionKey,
+		}
+		if !c.Session {
+			expires := cdp.TimeSinceEpoch(time.Unix(0, int64(c.Expires*float64(time.Second))))
+			p.Expires = &expires
+		}
+		params = append(params, p)
+	}
+	return params
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrashedTargetIsRevisitedAfterResume(t *testing.T) {
+	queue := newFrontier(nil)
+	target := crawlTarget{URL: "https://app.example.com/invoices", Canonical: "/invoices", Text: "Invoices", Depth: 1, Section: "invoices"}
+	if !queue.Push(target) {
+		t.Fatal("Push refused a new target")
+	}
+	c := newCrawlState(queue, 20, time.Time{})
+
+	// What next does when it hands the target to a tab
+	popped, _ := queue.Pop()
+	c.pending++
+	c.busy++
+	c.perSection[popped.Section]++
+	c.perRoute["/invoices"]++
+
+	c.crashed(popped, "/invoices", "browser crashed")
+	if reason := c.resume(); reason != "browser crashed" {
+		t.Fatalf("resume() = %q, want the crash reason", reason)
+	}
+	again, ok := queue.Pop()
+	if !ok {
+		t.Fatal("the crashed target was not requeued")
+	}
+	if again.Canonical != target.Canonical {
+		t.Errorf("popped %q, want %q", again.Canonical, target.Canonical)
+	}
+	if c.pending != 0 || c.busy != 0 || c.perSection["invoices"] != 0 || c.perRoute["/invoices"] != 0 {
+		t.Errorf("reservation not released: pending %d, busy %d, section %d, route %d", c.pending, c.busy, c.perSection["invoices"], c.perRoute["/invoices"])
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// visibleElementsJS records which elements of the body are rendered, keyed
+// by keyOf, to compare two renderings of the page (screen and print media,
+// zoom levels).
+const visibleElementsJS = `
+(function() {` + domKeyJS + `
+	const visible = {};
+	let n = 0;
+	for (const el of document.body.querySelectorAll('*')) {
+		if (n++ >= 5000) break;
+		const style = getComputedStyle(el);
+		if (style.display === 'none') continue;
+		const rect = el.getBoundingClientRect();
+		if (style.visibility === 'hidden' || rect.width === 0 || rect.height === 0) continue;
+		const cls = typeof el.className === 'string' ? el.className.trim().split(/\s+/).slice(0, 2).join('.') : '';
+		const text = (el.getAttribute('aria-label') || el.textContent || '').trim().replace(/\s+/g, ' ').substring(0, 40);
+		visible[keyOf(el)] = el.tagName.toLowerCase() + (cls ? '.' + cls : '') + (text ? ' "' + text + '"' : '');
+	}
+	return visible;
+})()
+`
+
+// printRulesJS collects the @media print and @page rules of the stylesheets
+// the page can read; cross-origin sheets are skipped.
+const printRulesJS = `
+(function() {
+	const rules = [];
+	const walk = (list, inPrint) => {
+		for (const rule of list) {
+			if (rule instanceof CSSPageRule) {
+				rules.push(rule.cssText);
+			} else if (rule instanceof CSSMediaRule) {
+				if (!inPrint && /\bprint\b/i.test(rule.media.mediaText)) rules.push(rule.cssText);
+				else walk(rule.cssRules, inPrint);
+			} else if (rule instanceof CSSImportRule && rule.styleSheet) {
+				const media = rule.media.mediaText;
+				try { walk(rule.styleSheet.cssRules, /\bprint\b/i.test(media)); } catch (e) {}
+			}
+		}
+	};
+	for (const sheet of document.styleSheets) {
+		try {
+			if (/\bprint\b/i.test(sheet.media.mediaText)) {
+				rules.push('@media ' + sheet.media.mediaText + ' {\n' + Array.from(sheet.cssRules).map(r => r.cssText).join('\n') + '\n}');
+			} else {
+				walk(sheet.cssRules, false);
+			}
+		} catch (e) {}
+	}
+	return rules;
+})()
+`
+
+// printCapture is print/<page>.json: the page rendered for print next to
+// its screen rendering, the print rules behind the difference and the
+// elements print hides or shows.
+type printCapture struct {
+	Page   string   `json:"page"`
+	URL    string   `json:"url"`
+	Screen string   `json:"screen"`
+	Print  string   `json:"print"`
+	PDF    string   `json:"pdf,omitempty"`
+	CSS    string   `json:"css,omitempty"`
+	Rules  int      `json:"print_rules"`
+	Hidden []string `json:"hidden_in_print"`
+	Shown  []string `json:"shown_only_in_print"`
+}
+
+// matchesPage reports whether the URL or title of a page matches one of
+// the patterns; every page does when there are none.
+func matchesPage(patterns []*regexp.Regexp, pageURL, title string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, re := range patterns {
+		if re.MatchString(pageURL) || re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// capturePrint renders the page with the print media type emulated and
+// writes to print/ a full-page screenshot for print and one for the screen
+// to compare it with, the PDF Chrome prints, the page's @media print and
+// @page rules as <page>.css and <page>.json.
+func (e *AgicapExplorer) capturePrint(pageName, pageURL string) string {
+	name := sanitize(pageName)
+	result := printCapture{Page: pageName, URL: pageURL, Hidden: []string{}, Shown: []string{}}
+	rel := func(path string) string {
+		r, _ := filepath.Rel(e.outputDir, path)
+		return filepath.ToSlash(r)
+	}
+
+	var screen, printed []byte
+	var screenVisible, printVisible map[string]string
+	var rules []string
+	err := chromedp.Run(e.ctx,
+		chromedp.Evaluate(printRulesJS, &rules),
+		chromedp.Evaluate(visibleElementsJS, &screenVisible),
+		chromedp.FullScreenshot(&screen, 100),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return emulation.SetEmulatedMedia().WithMedia("print").Do(ctx)
+		}),
+		chromedp.Sleep(300*time.Millisecond),
+		chromedp.Evaluate(visibleElementsJS, &printVisible),
+		chromedp.FullScreenshot(&printed, 100),
+	)
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return emulation.SetEmulatedMedia().WithMedia("").Do(ctx)
+	}))
+	if err != nil {
+		e.log("⚠️ Print capture failed for %s: %v", pageName, err)
+		return ""
+	}
+	if path, err := e.writeArtifact("print", name+"_screen.png", screen); err == nil {
+		result.Screen = rel(path)
+	}
+	if path, err := e.writeArtifact("print", name+".png", printed); err == nil {
+		result.Print = rel(path)
+	}
+	result.Rules = len(rules)
+	if len(rules) > 0 {
+		css := fmt.Sprintf("/* @media print and @page rules of %s */\n\n%s\n", pageURL, strings.Join(rules, "\n\n"))
+		if path, err := e.writeArtifact("print", name+".css", []byte(css)); err == nil {
+			result.CSS = rel(path)
+		}
+	}
+	result.Hidden = visibilityChanges(screenVisible, printVisible)
+	result.Shown = visibilityChanges(printVisible, screenVisible)
+
+	if e.config.GetBool("explorer.print.pdf") {
+		var pdf []byte
+		err := chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			pdf, _, err = page.PrintToPDF().WithPreferCSSPageSize(true).Do(ctx)
+			return err
+		}))
+		if err != nil {
+			e.log("⚠️ Print PDF failed for %s: %v", pageName, err)
+		} else if path, err := e.writeArtifact("print", name+".pdf", pdf); err == nil {
+			result.PDF = rel(path)
+		}
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact("print", name+".json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write the print capture of %s: %v", pageName, err)
+		return ""
+	}
+	e.log("🖨️ %s: %d print rules, %d elements hidden and %d shown only in print", pageName, result.Rules, len(result.Hidden), len(result.Shown))
+	return path
+}
+
+// visibilityChanges lists the elements visible in from but not in to,
+// leaving out those whose ancestor is listed, at most 100.
+func visibilityChanges(from, to map[string]string) []string {
+	keys := make([]string, 0, len(from))
+	for key := range from {
+		if _, ok := to[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	changes := []string{}
+	var last string
+	for _, key := range keys {
+		if last != "" && strings.HasPrefix(key, last+" > ") {
+			continue
+		}
+		last = key
+		changes = append(changes, from[key])
+		if len(changes) == 100 {
+			break
+		}
+	}
+	return changes
+}
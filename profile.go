@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// crawlProfile remembers how long every page took to capture across runs,
+// so the crawl can visit the slow ones first instead of running out of
+// time on them at the end.
+type crawlProfile struct {
+	mu    sync.Mutex
+	path  string
+	Pages map[string]*pageProfile `json:"pages"` // by canonical URL
+}
+
+// pageProfile is the capture history of a page: enough of its crawl target
+// to queue it again, and its capture time averaged over the runs.
+type pageProfile struct {
+	URL       string `json:"url"`
+	Text      string `json:"text"`
+	Section   string `json:"section"`
+	Depth     int    `json:"depth"`
+	CaptureMS int64  `json:"capture_ms"`
+	Runs      int    `json:"runs"`
+}
+
+// profilePath is explorer.scheduling.profile, by default crawl_profile.json
+// in the output directory.
+func profilePath(e *AgicapExplorer) string {
+	if path := e.config.GetString("explorer.scheduling.profile"); path != "" {
+		return path
+	}
+	return filepath.Join(e.outputDir, "crawl_profile.json")
+}
+
+func newCrawlProfile(path string) *crawlProfile {
+	return &crawlProfile{path: path, Pages: make(map[string]*pageProfile)}
+}
+
+// loadCrawlProfile reads the profile at path; a missing file is an empty
+// profile.
+func loadCrawlProfile(path string) (*crawlProfile, error) {
+	p := newCrawlProfile(path)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	if p.Pages == nil {
+		p.Pages = make(map[string]*pageProfile)
+	}
+	return p, nil
+}
+
+// Record adds the capture time of a target, averaged with the earlier runs
+// so one slow run does not dominate.
+func (p *crawlProfile) Record(t crawlTarget, took time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ms := took.Milliseconds()
+	page, ok := p.Pages[t.Canonical]
+	if !ok {
+		page = &pageProfile{CaptureMS: ms}
+		p.Pages[t.Canonical] = page
+	}
+	page.URL, page.Text, page.Section, page.Depth = t.URL, t.Text, t.Section, t.Depth
+	page.CaptureMS = (page.CaptureMS + ms) / 2
+	page.Runs++
+}
+
+// Slow returns the canonical URLs of the pages that took at least threshold,
+// with their average capture time.
+func (p *crawlProfile) Slow(threshold time.Duration) map[string]time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	slow := make(map[string]time.Duration)
+	for canonical, page := range p.Pages {
+		if took := time.Duration(page.CaptureMS) * time.Millisecond; took >= threshold {
+			slow[canonical] = took
+		}
+	}
+	return slow
+}
+
+// SlowTargets are the crawl targets of the slow pages, slowest first.
+func (p *crawlProfile) SlowTargets(threshold time.Duration) []crawlTarget {
+	slow := p.Slow(threshold)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	targets := make([]crawlTarget, 0, len(slow))
+	for canonical := range slow {
+		page := p.Pages[canonical]
+		targets = append(targets, crawlTarget{URL: page.URL, Canonical: canonical, Text: page.Text, Depth: page.Depth, Section: page.Section})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return slow[targets[i].Canonical] > slow[targets[j].Canonical]
+	})
+	return targets
+}
+
+// Write saves the profile for the next run.
+func (p *crawlProfile) Write() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.path, data, 0644)
+}
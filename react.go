@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reactComponent renders <Name>.tsx.
+func reactComponent(m *componentModel) string {
+	var b strings.Builder
+	m.writeHeader(&b, "")
+	if !m.Void {
+		b.WriteString("import type { ReactNode } from 'react';\n")
+	}
+	b.WriteString("\n")
+
+	variant := m.Name + "Variant"
+	fmt.Fprintf(&b, "export type %s = %s;\n\n", variant, m.variantType())
+	m.writeVariantClasses(&b, "", variant)
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "export interface %sProps {\n", m.Name)
+	fmt.Fprintf(&b, "  variant?: %s;\n", variant)
+	for _, p := range m.Props {
+		fmt.Fprintf(&b, "  %s?: %s;\n", p.Name, p.Type)
+	}
+	if !m.Void {
+		b.WriteString("  children?: ReactNode;\n")
+	}
+	b.WriteString("  className?: string;\n}\n\n")
+
+	params := []string{fmt.Sprintf("variant = '%s'", m.Variants[0].Name)}
+	for _, p := range m.Props {
+		params = append(params, p.Name)
+	}
+	if !m.Void {
+		params = append(params, "children")
+	}
+	params = append(params, "className = ''")
+	fmt.Fprintf(&b, "export function %s({ %s }: %sProps) {\n", m.Name, strings.Join(params, ", "), m.Name)
+
+	var attrs []string
+	for _, a := range m.Fixed {
+		value := `"` + a.Value + `"`
+		if strings.ContainsAny(a.Value, `"\{}`) {
+			value = "{" + jsString(a.Value) + "}"
+		}
+		attrs = append(attrs, jsxAttribute(a.Name)+"="+value)
+	}
+	for _, p := range m.Props {
+		attrs = append(attrs, fmt.Sprintf("%s={%s}", jsxAttribute(p.Attribute), p.Name))
+	}
+	attrs = append(attrs, "className={`${variantClasses[variant]} ${className}`.trim()}")
+	b.WriteString("  return (\n")
+	if m.Void {
+		fmt.Fprintf(&b, "    <%s\n      %s\n    />\n", m.Tag, strings.Join(attrs, "\n      "))
+	} else {
+		fmt.Fprintf(&b, "    <%s\n      %s\n    >\n      {children}\n    </%s>\n", m.Tag, strings.Join(attrs, "\n      "), m.Tag)
+	}
+	b.WriteString("  );\n}\n")
+	return b.String()
+}
+
+func reactExport(m *componentModel) string {
+	return fmt.Sprintf("export * from './%s';\n", m.Name)
+}
+
+func reactStories(m *componentModel) string {
+	return componentStories(m, "@storybook/react", fmt.Sprintf("import { %s } from './%s';", m.Name, m.Name), "children")
+}
+
+// jsxAttribute renames the HTML attributes whose JSX name differs.
+func jsxAttribute(attr string) string {
+	switch attr {
+	case "for":
+		return "htmlFor"
+	case "tabindex":
+		return "tabIndex"
+	case "readonly":
+		return "readOnly"
+	case "maxlength":
+		return "maxLength"
+	case "autocomplete":
+		return "autoComplete"
+	}
+	return attr
+}
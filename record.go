@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// recorderJS reports the user's clicks and the values they enter to the
+// explorerRecord binding, with the most stable selector of the element.
+// Typing into a field is reported once, when it changes; password fields
+// only as "secret", without their value.
+const recorderJS = `
+(function() {
+	if (window.__explorerRecorder) return;
+	window.__explorerRecorder = true;` + domKeyJS + stableSelectorJS + `
+	const selectorOf = (el) => (selectorsOf(el)[0] || {selector: el.tagName.toLowerCase()}).selector;
+	const send = (step) => { try { explorerRecord(JSON.stringify(step)); } catch (e) {} };
+	const typed = (el) => el.tagName === 'TEXTAREA' || el.tagName === 'SELECT' ||
+		(el.tagName === 'INPUT' && !['checkbox', 'radio', 'button', 'submit', 'reset', 'image', 'file'].includes(el.type));
+	document.addEventListener('click', (ev) => {
+		if (!ev.isTrusted || !(ev.target instanceof Element)) return;
+		const el = ev.target.closest('a, button, [role="button"], [role="link"], [role="menuitem"], [role="tab"], [role="option"], input, label, summary, [onclick]') || ev.target;
+		if (typed(el)) return;
+		const text = (el.getAttribute('aria-label') || el.textContent || el.value || '').trim().replace(/\s+/g, ' ').substring(0, 40);
+		send({type: 'click', selector: selectorOf(el), text: text});
+	}, true);
+	document.addEventListener('change', (ev) => {
+		const el = ev.target;
+		if (!ev.isTrusted || !(el instanceof Element) || !typed(el)) return;
+		if (el.type === 'password') { send({type: 'secret', selector: selectorOf(el)}); return; }
+		if (el.tagName === 'SELECT') {
+			send({type: 'select', selector: selectorOf(el), value: (el.selectedOptions[0] || {}).text || el.value});
+		} else {
+			send({type: 'fill', selector: selectorOf(el), value: el.value});
+		}
+	}, true);
+})()
+`
+
+// recordedStep is a step of the scenario being recorded, in the format of
+// the functional explorer's scenarios.
+type recordedStep struct {
+	Type        string `json:"type"`
+	Selector    string `json:"selector,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// scenarioRecorder collects the steps the user performs. Navigations
+// shortly after a click or an entered value are caused by it and are left
+// out; the rest become navigate steps.
+type scenarioRecorder struct {
+	mu     sync.Mutex
+	base   *url.URL
+	steps  []recordedStep
+	last   time.Time
+	secret func(selector string)
+}
+
+// Listen records the binding calls of recorderJS and the navigations of
+// the tab.
+func (r *scenarioRecorder) Listen(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *runtime.EventBindingCalled:
+			if ev.Name != "explorerRecord" {
+				return
+			}
+			var step recordedStep
+			if json.Unmarshal([]byte(ev.Payload), &step) != nil {
+				return
+			}
+			if step.Type == "secret" {
+				r.secret(step.Selector)
+				return
+			}
+			r.add(step)
+		case *page.EventFrameNavigated:
+			if ev.Frame.ParentID == "" {
+				r.navigated(ev.Frame.URL + ev.Frame.URLFragment)
+			}
+		case *page.EventNavigatedWithinDocument:
+			r.navigated(ev.URL)
+		}
+	})
+}
+
+func (r *scenarioRecorder) add(step recordedStep) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch step.Type {
+	case "click":
+		if step.Text != "" {
+			step.Description = fmt.Sprintf("Click %q", step.Text)
+		}
+	case "fill", "select":
+		// Typing into the same field again replaces the value
+		if n := len(r.steps); n > 0 && r.steps[n-1].Type == step.Type && r.steps[n-1].Selector == step.Selector {
+			r.steps = r.steps[:n-1]
+		}
+	}
+	step.Text = ""
+	r.steps = append(r.steps, step)
+	r.last = time.Now()
+}
+
+// navigated records a navigation the user did not cause by clicking, as
+// a path relative to the login URL's host when it is on it.
+func (r *scenarioRecorder) navigated(rawURL string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.last) < 3*time.Second {
+		return
+	}
+	target := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host == r.base.Host {
+		u.Scheme, u.Host, u.User = "", "", nil
+		target = u.String()
+	}
+	if n := len(r.steps); n > 0 && r.steps[n-1].Type == "navigate" && r.steps[n-1].Value == target {
+		return
+	}
+	r.steps = append(r.steps, recordedStep{Type: "navigate", Value: target})
+}
+
+// capture adds a capture step for the page as it is now.
+func (r *scenarioRecorder) capture(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, recordedStep{Type: "capture", Value: name})
+}
+
+// YAML renders the recorded steps as a scenario file.
+func (r *scenarioRecorder) YAML(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Recorded by `explorer record` on %s\n", time.Now().Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "name: %s\n", jsString(name))
+	fmt.Fprintf(&b, "description: %s\n", jsString("Recorded from "+r.base.Host))
+	b.WriteString("steps:\n")
+	for _, step := range r.steps {
+		fields := []string{"type: " + step.Type}
+		if step.Selector != "" {
+			fields = append(fields, "selector: "+jsString(step.Selector))
+		}
+		if step.Value != "" {
+			fields = append(fields, "value: "+jsString(step.Value))
+		}
+		if step.Description != "" {
+			fields = append(fields, "description: "+jsString(step.Description))
+		}
+		fmt.Fprintf(&b, "  - {%s}\n", strings.Join(fields, ", "))
+	}
+	return b.String()
+}
+
+// runRecord opens a browser window at the login URL and records what the
+// user does there as a scenario for the functional explorer, which replays
+// it headlessly:
+//
+//	explorer record [-config file] [-name name] [-out file] [-start url]
+//
+// Recording starts once the user has logged in and pressed Enter; "c" and
+// Enter add a capture step, Enter alone or Ctrl-C stops and writes the
+// scenario (by default to <explorer.features.scenarios>/<name>.yaml).
+// Password fields are never recorded.
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	configFile := fs.String("config", "config.yaml", "path to the YAML configuration file")
+	name := fs.String("name", "", "name of the scenario (default recording-<timestamp>)")
+	out := fs.String("out", "", "scenario file to write (default <scenarios directory>/<name>.yaml)")
+	start := fs.String("start", "", "URL to open, absolute or relative to login_url (default login_url)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	config, err := loadConfig([]string{"-config", *configFile})
+	if err != nil {
+		return err
+	}
+	if *name == "" {
+		*name = "recording-" + time.Now().Format("20060102-150405")
+	}
+	if *out == "" {
+		*out = filepath.Join(config.GetString("explorer.features.scenarios"), sanitize(*name)+".yaml")
+	}
+	base, err := url.Parse(config.GetString("explorer.login_url"))
+	if err != nil {
+		return fmt.Errorf("explorer.login_url: %w", err)
+	}
+	startURL := base.String()
+	if *start != "" {
+		ref, err := url.Parse(*start)
+		if err != nil {
+			return fmt.Errorf("-start: %w", err)
+		}
+		startURL = base.ResolveReference(ref).String()
+	}
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", false),
+		chromedp.Flag("window-size", config.GetString("explorer.browser.window_size")),
+		chromedp.UserAgent(config.GetString("explorer.browser.user_agent")),
+	)
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	defer cancelAlloc()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	if err := chromedp.Run(ctx, chromedp.Navigate(startURL)); err != nil {
+		return fmt.Errorf("failed to open %s: %w", startURL, err)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(lines)
+	}()
+	interrupt, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("🌐 Opened %s\n", startURL)
+	fmt.Println("🔐 Log in in the browser window, then press Enter to start recording")
+	select {
+	case <-lines:
+	case <-interrupt.Done():
+		return nil
+	}
+
+	recorder := &scenarioRecorder{
+		base: base,
+		secret: func(selector string) {
+			fmt.Printf("🔒 Left out the password entered into %s\n", selector)
+		},
+	}
+	var current string
+	err = chromedp.Run(ctx,
+		runtime.AddBinding("explorerRecord"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(recorderJS).Do(ctx)
+			return err
+		}),
+		chromedp.Evaluate(recorderJS, nil),
+		chromedp.Location(&current),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start recording: %w", err)
+	}
+	recorder.navigated(current)
+	recorder.Listen(ctx)
+
+	fmt.Println("⏺️ Recording. Type c and Enter to add a capture step, Enter to stop")
+	captures := 0
+record:
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok || line == "" {
+				break record
+			}
+			if line == "c" {
+				captures++
+				recorder.capture(fmt.Sprintf("%s_%d", sanitize(*name), captures))
+				fmt.Println("📸 Capture step added")
+			}
+		case <-interrupt.Done():
+			break record
+		case <-ctx.Done():
+			fmt.Println("⚠️ The browser window was closed")
+			break record
+		}
+	}
+
+	// Closing the browser ends the recording
+	cancel()
+	scenario := recorder.YAML(*name)
+	if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*out, []byte(scenario), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Wrote %d steps to %s, replayed by the functional explorer\n", len(recorder.steps), *out)
+	return nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// maxGIFFrames bounds GIF size; longer recordings are sampled evenly.
+const maxGIFFrames = 150
+
+type recordedFrame struct {
+	data []byte
+	at   time.Time
+}
+
+// screenRecorder collects CDP screencast frames for one page.
+type screenRecorder struct {
+	mu     sync.Mutex
+	frames []recordedFrame
+	stop   context.CancelFunc
+}
+
+// startRecording begins a screencast of the current tab. It returns nil when
+// recording is disabled or could not be started.
+func (e *AgicapExplorer) startRecording() *screenRecorder {
+	if !e.config.GetBool("explorer.recording.enabled") {
+		return nil
+	}
+
+	rec := &screenRecorder{}
+	listenCtx, cancel := context.WithCancel(e.ctx)
+	rec.stop = cancel
+
+	chromedp.ListenTarget(listenCtx, func(ev interface{}) {
+		frame, ok := ev.(*page.EventScreencastFrame)
+		if !ok {
+			return
+		}
+		if data, err := base64.StdEncoding.DecodeString(frame.Data); err == nil {
+			rec.mu.Lock()
+			rec.frames = append(rec.frames, recordedFrame{data: data, at: time.Now()})
+			rec.mu.Unlock()
+		}
+		// Chrome stops sending frames until each one is acknowledged
+		go chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			return page.ScreencastFrameAck(frame.SessionID).Do(ctx)
+		}))
+	})
+
+	err := chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return page.StartScreencast().
+			WithFormat(page.ScreencastFormatJpeg).
+			WithQuality(e.config.GetInt64("explorer.recording.quality")).
+			WithEveryNthFrame(1).
+			Do(ctx)
+	}))
+	if err != nil {
+		cancel()
+		e.log("⚠️ Failed to start screen recording: %v", err)
+		return nil
+	}
+	return rec
+}
+
+// stopRecording ends the screencast and writes video/<page>.webm (when
+// ffmpeg is available, otherwise the raw JPEG frames) and optionally a GIF.
+func (e *AgicapExplorer) stopRecording(pageName string, rec *screenRecorder) {
+	if rec == nil {
+		return
+	}
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return page.StopScreencast().Do(ctx)
+	}))
+	rec.stop()
+
+	rec.mu.Lock()
+	frames := rec.frames
+	rec.mu.Unlock()
+	if len(frames) < 2 {
+		return
+	}
+
+	dir := filepath.Join(e.outputDir, "video")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		e.log("⚠️ Failed to create %s: %v", dir, err)
+		return
+	}
+	name := sanitize(pageName)
+
+	if err := writeWebM(filepath.Join(dir, name+".webm"), frames); err != nil {
+		e.log("⚠️ WebM encoding unavailable (%v), keeping %d frames instead", err, len(frames))
+		framesDir := filepath.Join(dir, name+"_frames")
+		os.MkdirAll(framesDir, 0755)
+		for i, f := range frames {
+			ioutil.WriteFile(filepath.Join(framesDir, fmt.Sprintf("frame_%05d.jpg", i)), f.data, 0644)
+		}
+	}
+
+	if e.config.GetBool("explorer.recording.gif") {
+		if err := writeGIF(filepath.Join(dir, name+".gif"), frames, e.config.GetInt("explorer.recording.gif_width")); err != nil {
+			e.log("⚠️ GIF encoding failed for %s: %v", pageName, err)
+		}
+	}
+	e.log("🎬 Recorded %d frames for %s", len(frames), pageName)
+}
+
+// writeWebM encodes the frames with ffmpeg at their average frame rate.
+func writeWebM(path string, frames []recordedFrame) error {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir("", "explorer-frames")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	for i, f := range frames {
+		if err := ioutil.WriteFile(filepath.Join(tmp, fmt.Sprintf("frame_%05d.jpg", i)), f.data, 0644); err != nil {
+			return err
+		}
+	}
+
+	fps := float64(len(frames)-1) / frames[len(frames)-1].at.Sub(frames[0].at).Seconds()
+	if fps <= 0 || fps > 30 {
+		fps = 10
+	}
+	cmd := exec.Command(ffmpeg, "-y", "-loglevel", "error",
+		"-framerate", fmt.Sprintf("%.2f", fps),
+		"-i", filepath.Join(tmp, "frame_%05d.jpg"),
+		"-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2",
+		"-c:v", "libvpx-vp9", "-b:v", "0", "-crf", "40",
+		path,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg: %v: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// writeGIF builds an animated GIF scaled down to maxWidth, keeping the real
+// delays between frames.
+func writeGIF(path string, frames []recordedFrame, maxWidth int) error {
+	step := 1
+	if len(frames) > maxGIFFrames {
+		step = (len(frames) + maxGIFFrames - 1) / maxGIFFrames
+	}
+
+	anim := &gif.GIF{}
+	for i := 0; i < len(frames); i += step {
+		img, err := jpeg.Decode(bytes.NewReader(frames[i].data))
+		if err != nil {
+			continue
+		}
+		scaled := scaleToWidth(img, maxWidth)
+		paletted := image.NewPaletted(scaled.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, scaled.Bounds(), scaled, image.Point{})
+
+		delay := 10
+		if next := i + step; next < len(frames) {
+			delay = int(frames[next].at.Sub(frames[i].at) / (10 * time.Millisecond))
+		}
+		if delay < 2 {
+			delay = 2
+		}
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	if len(anim.Image) == 0 {
+		return fmt.Errorf("no decodable frames")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gif.EncodeAll(f, anim)
+}
+
+// scaleToWidth downsizes img with nearest-neighbour sampling.
+func scaleToWidth(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if width <= 0 || b.Dx() <= width {
+		return img
+	}
+	height := b.Dy() * width / b.Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy := b.Min.Y + y*b.Dy()/height
+		for x := 0; x < width; x++ {
+			dst.Set(x, y, img.At(b.Min.X+x*b.Dx()/width, sy))
+		}
+	}
+	return dst
+}
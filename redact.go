@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// redactedValue replaces anything a redaction rule matches.
+const redactedValue = "[REDACTED]"
+
+// redactor masks sensitive values before they are written to disk: values of
+// JSON keys matching a key rule, and any text matching a pattern rule.
+type redactor struct {
+	keys     []*regexp.Regexp
+	patterns []*regexp.Regexp
+}
+
+func newRedactor(v *viper.Viper) (*redactor, error) {
+	keys, err := compilePatterns(caseInsensitive(v.GetStringSlice("explorer.redaction.keys")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction key: %w", err)
+	}
+	patterns, err := compilePatterns(v.GetStringSlice("explorer.redaction.patterns"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction pattern: %w", err)
+	}
+	return &redactor{keys: keys, patterns: patterns}, nil
+}
+
+func caseInsensitive(exprs []string) []string {
+	out := make([]string, len(exprs))
+	for i, expr := range exprs {
+		out[i] = "(?i)" + expr
+	}
+	return out
+}
+
+// Redact masks a text payload. JSON payloads are re-encoded with the values
+// of sensitive keys replaced; pattern rules apply to every payload.
+func (r *redactor) Redact(payload string) string {
+	var doc interface{}
+	if len(r.keys) > 0 && json.Unmarshal([]byte(payload), &doc) == nil {
+		if data, err := json.Marshal(r.RedactValue(doc)); err == nil {
+			payload = string(data)
+		}
+	}
+	for _, p := range r.patterns {
+		payload = p.ReplaceAllString(payload, redactedValue)
+	}
+	return payload
+}
+
+// RedactBody masks a request body: form-encoded bodies have the values of
+// sensitive keys replaced, others are redacted as payloads.
+func (r *redactor) RedactBody(body, contentType string) string {
+	if !strings.HasPrefix(strings.ToLower(contentType), "application/x-www-form-urlencoded") {
+		return r.Redact(body)
+	}
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		return r.Redact(body)
+	}
+	for key := range form {
+		if r.sensitiveKey(key) {
+			form[key] = []string{redactedValue}
+		}
+	}
+	body = form.Encode()
+	for _, p := range r.patterns {
+		body = p.ReplaceAllString(body, redactedValue)
+	}
+	return body
+}
+
+// RedactValue masks sensitive keys in a decoded JSON value.
+func (r *redactor) RedactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if r.sensitiveKey(key) {
+				out[key] = redactedValue
+			} else {
+				out[key] = r.RedactValue(field)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = r.RedactValue(item)
+		}
+		return out
+	}
+	return value
+}
+
+func (r *redactor) sensitiveKey(key string) bool {
+	for _, k := range r.keys {
+		if k.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// segmentRegions splits the visible page into header, sidebar, toolbar,
+// content and footer regions. Landmarks and common class names are tried
+// first, then geometry: a full-width bar at the top is a header, a tall
+// narrow column at an edge a sidebar, a short row of controls above the
+// content a toolbar.
+const segmentRegions = `
+(function() {
+	const vw = window.innerWidth, vh = window.innerHeight;
+	const visible = (el) => {
+		if (!el) return false;
+		const s = getComputedStyle(el), r = el.getBoundingClientRect();
+		return s.display !== 'none' && s.visibility !== 'hidden' && r.width > 0 && r.height > 0;
+	};
+	const describe = (el) => el.tagName.toLowerCase() + (el.id ? '#' + el.id : '') +
+		(typeof el.className === 'string' && el.className.trim() ? '.' + el.className.trim().split(/\s+/).slice(0, 2).join('.') : '');
+	const first = (selector, test) => Array.from(document.querySelectorAll(selector)).find(el => visible(el) && (!test || test(el.getBoundingClientRect(), el)));
+	const regions = [];
+	const used = new Set();
+	const add = (kind, el, extra) => {
+		if (!el || used.has(el)) return null;
+		for (const u of used) if (u.contains(el) && kind !== 'toolbar' && kind !== 'content') return null;
+		used.add(el);
+		const r = el.getBoundingClientRect();
+		const region = Object.assign({kind: kind, selector: describe(el), position: getComputedStyle(el).position,
+			x: Math.round(r.x), y: Math.round(r.y), width: Math.round(r.width), height: Math.round(r.height)}, extra || {});
+		regions.push(region);
+		return region;
+	};
+
+	const header = first('header, [role="banner"], [class*="header" i], [class*="topbar" i], [class*="navbar" i]',
+		r => r.top <= 10 && r.width >= vw * 0.6 && r.height < vh * 0.3);
+	add('header', header);
+
+	const sidebar = first('aside, [role="complementary"], [class*="sidebar" i], [class*="sidenav" i], nav, [role="navigation"]',
+		r => r.height >= vh * 0.5 && r.width <= vw * 0.35 && (r.left <= 10 || r.right >= vw - 10));
+	if (sidebar) {
+		const r = sidebar.getBoundingClientRect();
+		add('sidebar', sidebar, {side: r.left <= 10 ? 'left' : 'right'});
+	}
+
+	const footer = first('footer, [role="contentinfo"]', r => r.height < vh * 0.5);
+	add('footer', footer);
+
+	let content = first('main, [role="main"]');
+	if (!content) {
+		// The largest element not covered by the other regions
+		let best = null, area = 0;
+		document.querySelectorAll('body > *, body > * > *, body > * > * > *').forEach(el => {
+			if (!visible(el) || used.has(el)) return;
+			for (const u of used) if (el.contains(u) || u.contains(el)) return;
+			const r = el.getBoundingClientRect();
+			if (r.width * r.height > area) { best = el; area = r.width * r.height; }
+		});
+		content = best;
+	}
+	if (content) {
+		const contentRect = content.getBoundingClientRect();
+		const toolbar = Array.from(content.querySelectorAll('[role="toolbar"], [class*="toolbar" i], [class*="actions" i], [class*="filters" i], [class*="filter-bar" i], div, section'))
+			.slice(0, 500).find(el => {
+				if (!visible(el)) return false;
+				const r = el.getBoundingClientRect();
+				return r.top - contentRect.top < 160 && r.height >= 28 && r.height <= 96 && r.width >= contentRect.width * 0.5 &&
+					el.querySelectorAll('button, input, select, [role="button"]').length >= 2;
+			});
+		if (toolbar) add('toolbar', toolbar);
+
+		// The grid holding the content: the first grid, or a wrapping flex row
+		let columns = 0, grid = null;
+		for (const el of [content, ...Array.from(content.querySelectorAll('*')).slice(0, 500)]) {
+			if (!visible(el)) continue;
+			const s = getComputedStyle(el);
+			if (s.display.includes('grid') && s.gridTemplateColumns !== 'none') {
+				columns = s.gridTemplateColumns.split(' ').filter(Boolean).length;
+				if (columns > 1) { grid = el; break; }
+			}
+			if (s.display.includes('flex') && s.flexWrap === 'wrap' && el.children.length > 1) {
+				const tops = Array.from(el.children).map(c => Math.round(c.getBoundingClientRect().top));
+				columns = tops.filter(t => t === tops[0]).length;
+				if (columns > 1) { grid = el; break; }
+			}
+		}
+		add('content', content, {columns: columns, grid: grid ? describe(grid) : ''});
+	}
+	return {viewport: {width: vw, height: vh}, scrollHeight: document.documentElement.scrollHeight, regions: regions};
+})()
+`
+
+// overlayRegions draws each region as a labelled outline over the page, or
+// removes the overlays again when called with null.
+const overlayRegions = `
+(function(regions) {
+	document.querySelectorAll('[data-explorer-overlay]').forEach(el => el.remove());
+	if (!regions) return true;
+	const colors = {header: '#e53e3e', sidebar: '#3182ce', toolbar: '#d69e2e', content: '#38a169', footer: '#805ad5'};
+	regions.forEach(r => {
+		const box = document.createElement('div');
+		box.setAttribute('data-explorer-overlay', '');
+		const color = colors[r.kind] || '#000';
+		box.style.cssText = 'position:fixed;z-index:2147483647;pointer-events:none;box-sizing:border-box;' +
+			'left:' + r.x + 'px;top:' + r.y + 'px;width:' + r.width + 'px;height:' + r.height + 'px;' +
+			'border:3px solid ' + color + ';background:' + color + '22;';
+		const label = document.createElement('span');
+		label.textContent = r.kind + (r.side ? ' (' + r.side + ')' : '') + (r.columns > 1 ? ' · ' + r.columns + ' columns' : '');
+		label.style.cssText = 'position:absolute;left:0;top:0;padding:2px 6px;font:bold 12px sans-serif;color:#fff;background:' + color;
+		box.appendChild(label);
+		document.body.appendChild(box);
+	});
+	return true;
+})(%s)
+`
+
+// layoutRegion is a named part of a page layout.
+type layoutRegion struct {
+	Kind     string  `json:"kind"` // header, sidebar, toolbar, content, footer
+	Selector string  `json:"selector"`
+	Position string  `json:"position"`
+	Side     string  `json:"side,omitempty"`
+	Columns  int     `json:"columns,omitempty"`
+	Grid     string  `json:"grid,omitempty"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Width    float64 `json:"width"`
+	Height   float64 `json:"height"`
+}
+
+// pageLayout is layouts/<page>.json.
+type pageLayout struct {
+	Page     string `json:"page"`
+	Viewport struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"viewport"`
+	ScrollHeight int            `json:"scrollHeight"`
+	Regions      []layoutRegion `json:"regions"`
+	Screenshot   string         `json:"screenshot,omitempty"`
+}
+
+// captureRegions segments the page into layout regions and writes
+// layouts/<page>.json with a screenshot showing the regions as overlays.
+func (e *AgicapExplorer) captureRegions(pageName string) {
+	var layout pageLayout
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(segmentRegions, &layout)); err != nil {
+		e.log("⚠️ Layout segmentation failed for %s: %v", pageName, err)
+		return
+	}
+	layout.Page = pageName
+
+	regions, _ := json.Marshal(layout.Regions)
+	var shot []byte
+	var ok bool
+	err := chromedp.Run(e.ctx,
+		chromedp.Evaluate(fmt.Sprintf(overlayRegions, regions), &ok),
+		chromedp.CaptureScreenshot(&shot),
+		chromedp.Evaluate(fmt.Sprintf(overlayRegions, "null"), &ok),
+	)
+	if err == nil {
+		if path, err := e.writeArtifact("layouts", sanitize(pageName)+"_regions.png", shot); err == nil {
+			layout.Screenshot, _ = filepath.Rel(e.outputDir, path)
+		}
+	}
+
+	data, err := json.MarshalIndent(layout, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("layouts", sanitize(pageName)+".json", data); err != nil {
+		e.log("⚠️ Failed to write layout for %s: %v", pageName, err)
+	}
+}
+
+// layoutTemplate is a group of pages sharing the same arrangement of
+// regions.
+type layoutTemplate struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Signature string         `json:"signature"`
+	Regions   []layoutRegion `json:"regions"`
+	Pages     []string       `json:"pages"`
+	Example   string         `json:"example,omitempty"`
+}
+
+// templateSignature describes a layout by its region kinds and coarse
+// geometry, so small size differences don't split a template: header
+// height in 16px steps, sidebar side and width in 40px steps, and the
+// number of content columns.
+func templateSignature(regions []layoutRegion) string {
+	var parts []string
+	for _, r := range regions {
+		switch r.Kind {
+		case "header":
+			parts = append(parts, fmt.Sprintf("header:%d", int(math.Round(r.Height/16))))
+		case "sidebar":
+			parts = append(parts, fmt.Sprintf("sidebar-%s:%d", r.Side, int(math.Round(r.Width/40))))
+		case "content":
+			parts = append(parts, fmt.Sprintf("content:%dcol", r.Columns))
+		default:
+			parts = append(parts, r.Kind)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|")
+}
+
+// templateName is a readable name for a template's regions.
+func templateName(regions []layoutRegion) string {
+	order := map[string]int{"header": 0, "sidebar": 1, "toolbar": 2, "content": 3, "footer": 4}
+	sorted := append([]layoutRegion(nil), regions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return order[sorted[i].Kind] < order[sorted[j].Kind] })
+	var parts []string
+	for _, r := range sorted {
+		switch {
+		case r.Kind == "sidebar":
+			parts = append(parts, "sidebar-"+r.Side)
+		case r.Kind == "content" && r.Columns > 1:
+			parts = append(parts, fmt.Sprintf("content-grid-%d", r.Columns))
+		default:
+			parts = append(parts, r.Kind)
+		}
+	}
+	if len(parts) == 0 {
+		return "unstructured"
+	}
+	return strings.Join(parts, " + ")
+}
+
+// writeLayoutTemplates clusters the pages of layouts/*.json by template
+// signature and writes layout_templates.json, most used template first. The
+// regions of a template are those of its first page.
+func (e *AgicapExplorer) writeLayoutTemplates() error {
+	files, _ := filepath.Glob(filepath.Join(e.outputDir, "layouts", "*.json"))
+	sort.Strings(files)
+	bySignature := make(map[string]*layoutTemplate)
+	var templates []*layoutTemplate
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var layout pageLayout
+		if err := json.Unmarshal(data, &layout); err != nil {
+			continue
+		}
+		signature := templateSignature(layout.Regions)
+		t, ok := bySignature[signature]
+		if !ok {
+			t = &layoutTemplate{Name: templateName(layout.Regions), Signature: signature, Regions: layout.Regions, Example: layout.Screenshot}
+			bySignature[signature] = t
+			templates = append(templates, t)
+		}
+		t.Pages = append(t.Pages, layout.Page)
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(templates, func(i, j int) bool { return len(templates[i].Pages) > len(templates[j].Pages) })
+	for i, t := range templates {
+		t.ID = fmt.Sprintf("template-%d", i+1)
+	}
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	e.log("🧱 %d layout templates across %d pages", len(templates), len(files))
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "layout_templates.json"), data, 0644)
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// baseTemplates are the built-in report pages with their shared styles and
+// scripts, baseGuides the rebuild guide in each locale.
+var (
+	baseTemplates = template.Must(template.New("").Funcs(templateFuncs("en")).ParseFS(templateFS, "templates/*.html.tmpl", "templates/*.css.tmpl", "templates/*.js.tmpl"))
+	baseGuides    = texttemplate.Must(texttemplate.New("").Funcs(texttemplate.FuncMap(templateFuncs("en"))).ParseFS(templateFS, "templates/*.md.tmpl"))
+)
+
+func templateFuncs(lang string) template.FuncMap {
+	funcs := template.FuncMap{
+		"truncate": truncate,
+		"join":     strings.Join,
+	}
+	for name, fn := range localeFuncs(lang) {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// reportTemplates are the templates of one locale with the overrides of
+// the template directory applied.
+type reportTemplates struct {
+	lang   string
+	html   *template.Template
+	guides *texttemplate.Template
+}
+
+// loadTemplates returns the templates for lang ("en" when empty). Every
+// *.tmpl file in dir may redefine built-in templates: *.md.tmpl files the
+// guides ("guide-en", "guide-de"), the others the HTML pages, styles and
+// scripts, e.g. "brand-head" and "brand-logo" for a logo and colors.
+func loadTemplates(lang, dir string) (*reportTemplates, error) {
+	if lang == "" {
+		lang = "en"
+	}
+	if _, ok := reportLocales[lang]; !ok && lang != "en" {
+		return nil, fmt.Errorf("unsupported locale %q (supported: %s)", lang, strings.Join(supportedLocales(), ", "))
+	}
+	html, err := baseTemplates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	guides, err := baseGuides.Clone()
+	if err != nil {
+		return nil, err
+	}
+	funcs := templateFuncs(lang)
+	html.Funcs(funcs)
+	guides.Funcs(texttemplate.FuncMap(funcs))
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no *.tmpl files in template directory %s", dir)
+		}
+		for _, file := range files {
+			src, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasSuffix(file, ".md.tmpl") {
+				_, err = guides.New(filepath.Base(file)).Parse(string(src))
+			} else {
+				_, err = html.New(filepath.Base(file)).Parse(string(src))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &reportTemplates{lang: lang, html: html, guides: guides}, nil
+}
+
+// render executes one of the HTML templates into a file.
+func (r *reportTemplates) render(path, name string, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.html.ExecuteTemplate(f, name, data); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return f.Close()
+}
+
+// guide renders the rebuild guide in the templates' locale, falling back to
+// English.
+func (r *reportTemplates) guide(data interface{}) (string, error) {
+	name := "guide-" + r.lang
+	if r.guides.Lookup(name) == nil {
+		name = "guide-en"
+	}
+	var b strings.Builder
+	if err := r.guides.ExecuteTemplate(&b, name, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// reportData is what the report template renders.
+type reportData struct {
+	Generated   time.Time
+	UniqueURLs  int
+	Pages       []reportPage
+	Broken      []reportPage
+	Sections    []string
+	Performance [][]perfCell
+	A11y        []axeRule
+	Forms       formsReport
+	ARIA        ariaUsage
+	Locales     localeReport
+	Vision      []visionPage
+	Icons       []reportIcon
+	Glyphs      []reportGlyph
+	IconCount   int
+}
+
+// reportPage is a captured screen with its artifacts resolved relative to
+// the output directory, and the component and request summaries of its
+// tabs.
+type reportPage struct {
+	NavigationItem
+	Index          int
+	Key            string
+	Image          string
+	Thumb          string
+	Source         string
+	ConsoleLog     string
+	FirstError     string
+	Search         string
+	Links          []string
+	MoreLinks      int
+	Components     []reportCount
+	ComponentTotal int
+	Requests       []reportRequest
+}
+
+type reportCount struct {
+	Name  string
+	Count int
+}
+
+type reportRequest struct {
+	Method   string
+	URL      string
+	Status   int64
+	Type     string
+	Duration float64
+}
+
+// perfCell is a cell of the sortable performance table.
+type perfCell struct {
+	Value string
+	Text  string
+	Class string
+}
+
+// writeHTMLReport renders report.html: summary stats, the pages that threw
+// JavaScript errors, performance, accessibility violations, unlabeled form
+// fields, ARIA usage, translation and layout issues per locale, the key
+// pages under simulated color vision deficiencies, the icon index and a
+// filterable card per captured screen.
+func (e *AgicapExplorer) writeHTMLReport() error {
+	data := reportData{
+		Generated:   time.Now(),
+		UniqueURLs:  len(e.visitedURLs),
+		Pages:       e.reportPages(),
+		Performance: e.performanceRows(),
+		A11y:        e.axeRollup(),
+		Forms:       e.formsAccessibility(),
+		ARIA:        e.ariaRollup(),
+		Locales:     e.localeRollup(),
+		Vision:      e.visionPages(),
+	}
+	sections := make(map[string]bool)
+	for _, page := range data.Pages {
+		if page.JSErrors > 0 {
+			data.Broken = append(data.Broken, page)
+		}
+		if page.Section != "" {
+			sections[page.Section] = true
+		}
+	}
+	data.Sections = sortedSetKeys(sections)
+	data.Icons, data.Glyphs = e.iconReport()
+	data.IconCount = len(data.Icons) + len(data.Glyphs)
+
+	return e.templates.render(filepath.Join(e.outputDir, "report.html"), "report", data)
+}
+
+// reportPages resolves every captured screen for the report: artifact
+// paths, the component counts from components/<page>_analysis.json and
+// the requests recorded for the page.
+func (e *AgicapExplorer) reportPages() []reportPage {
+	requests := make(map[string][]reportRequest)
+	if e.network.enabled {
+		for _, entry := range e.network.All() {
+			r := reportRequest{Method: entry.request.Method, URL: entry.request.URL, Status: entry.response.Status, Type: string(entry.kind)}
+			if !entry.finished.IsZero() {
+				r.Duration = float64(entry.finished.Sub(entry.started).Milliseconds())
+			}
+			requests[entry.page] = append(requests[entry.page], r)
+		}
+	}
+
+	pages := make([]reportPage, 0, len(e.navigationMap))
+	for i, item := range e.navigationMap {
+		key := pageKey(item)
+		page := reportPage{
+			NavigationItem: item,
+			Index:          i + 1,
+			Key:            key,
+			Image:          reportPath(e.outputDir, item.Screenshot),
+			Source:         "html/" + key + ".html",
+			ConsoleLog:     reportPath(e.outputDir, item.Console),
+			FirstError:     item.firstError,
+			Search:         strings.ToLower(strings.Join([]string{item.Title, item.URL, item.CanonicalURL, item.Section}, " ")),
+			Requests:       requests[key],
+		}
+		if item.Thumbnail != "" {
+			page.Thumb = reportPath(e.outputDir, item.Thumbnail)
+		}
+		page.Links = item.Navigation
+		if len(page.Links) > 20 {
+			page.Links, page.MoreLinks = page.Links[:20], len(page.Links)-20
+		}
+		page.Components, page.ComponentTotal = e.componentCounts(key)
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// componentCounts counts the analyzed components of a page by type, most
+// common first.
+func (e *AgicapExplorer) componentCounts(key string) ([]reportCount, int) {
+	data, err := ioutil.ReadFile(filepath.Join(e.outputDir, "components", key+"_analysis.json"))
+	if err != nil {
+		return nil, 0
+	}
+	var analysis pageAnalysis
+	if json.Unmarshal(data, &analysis) != nil {
+		return nil, 0
+	}
+	byType := make(map[string]int)
+	for _, c := range analysis.Components {
+		byType[c.Type]++
+	}
+	counts := make([]reportCount, 0, len(byType))
+	for name, n := range byType {
+		counts = append(counts, reportCount{name, n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts, len(analysis.Components)
+}
+
+// vitalThresholds are the "good" and "poor" limits for Core Web Vitals.
+var vitalThresholds = map[string][2]float64{
+	"fcp": {1800, 3000},
+	"lcp": {2500, 4000},
+	"cls": {0.1, 0.25},
+	"inp": {200, 500},
+}
+
+// performanceRows returns a row of the performance table per page with
+// metrics, Core Web Vitals classed by their thresholds.
+func (e *AgicapExplorer) performanceRows() [][]perfCell {
+	var rows [][]perfCell
+	for _, item := range e.navigationMap {
+		m := item.Performance
+		if m == nil {
+			continue
+		}
+		cell := func(vital string, value float64, format string) perfCell {
+			class := ""
+			if t, ok := vitalThresholds[vital]; ok && value > 0 {
+				if value > t[1] {
+					class = "poor"
+				} else if value > t[0] {
+					class = "needs-work"
+				}
+			}
+			return perfCell{Value: fmt.Sprintf("%g", value), Text: fmt.Sprintf(format, value), Class: class}
+		}
+		rows = append(rows, []perfCell{
+			{Value: item.Title, Text: item.Title},
+			cell("ttfb", m.TTFB, "%.0f"),
+			cell("fcp", m.FCP, "%.0f"),
+			cell("lcp", m.LCP, "%.0f"),
+			cell("cls", m.CLS, "%.3f"),
+			cell("inp", m.INP, "%.0f"),
+			cell("", m.Load, "%.0f"),
+			cell("", float64(m.Requests), "%.0f"),
+			cell("", float64(m.TransferredBytes)/1024, "%.0f"),
+			cell("", m.ScriptDuration, "%.0f"),
+			cell("", float64(m.JSHeapUsed)/(1<<20), "%.1f"),
+		})
+	}
+	return rows
+}
+
+// reportPath makes an artifact path relative to the output directory so the
+// report can be opened from anywhere.
+func reportPath(outputDir, path string) string {
+	if rel, err := filepath.Rel(outputDir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// domKeyJS defines keyOf(el), a DOM path of the element that stays stable
+// across viewport widths and locales.
+const domKeyJS = `
+	const keyOf = (el) => {
+		const parts = [];
+		for (let node = el; node && node.nodeType === 1 && node !== document.body; node = node.parentElement) {
+			if (node.id) { parts.unshift(node.tagName.toLowerCase() + '#' + node.id); break; }
+			let index = 1;
+			for (let sib = node.previousElementSibling; sib; sib = sib.previousElementSibling) {
+				if (sib.tagName === node.tagName) index++;
+			}
+			parts.unshift(node.tagName.toLowerCase() + ':nth-of-type(' + index + ')');
+		}
+		return parts.join(' > ');
+	};`
+
+// layoutSnapshotJS records the box and layout mode of the page's structural
+// elements (landmarks, navigation, sidebars, cards, tables, forms and
+// buttons), keyed by keyOf.
+const layoutSnapshotJS = `
+(function() {
+	const selectors = 'header, nav, aside, main, footer, [role="banner"], [role="navigation"], [role="complementary"], [role="main"], [role="contentinfo"], ' +
+		'.sidebar, [class*="Sidebar"], [class*="sidebar"], [class*="Menu"], [class*="menu"], table, form, [class*="card"], [class*="Card"], ' +
+		'button, [role="button"], [class*="grid"], [class*="Grid"]';` + domKeyJS + `
+	const labelOf = (el) => {
+		const cls = typeof el.className === 'string' ? el.className.trim().split(/\s+/).slice(0, 2).join('.') : '';
+		const text = (el.getAttribute('aria-label') || el.textContent || '').trim().replace(/\s+/g, ' ').substring(0, 40);
+		return el.tagName.toLowerCase() + (cls ? '.' + cls : '') + (text ? ' "' + text + '"' : '');
+	};
+
+	const nodes = [];
+	const seen = new Set();
+	document.querySelectorAll(selectors).forEach(el => {
+		if (seen.has(el) || nodes.length >= 300) return;
+		seen.add(el);
+		const style = getComputedStyle(el);
+		const rect = el.getBoundingClientRect();
+		const columns = style.display.includes('grid') && style.gridTemplateColumns !== 'none'
+			? style.gridTemplateColumns.split(' ').filter(Boolean).length : 0;
+		nodes.push({
+			key: keyOf(el),
+			label: labelOf(el),
+			visible: style.display !== 'none' && style.visibility !== 'hidden' && rect.width > 0 && rect.height > 0,
+			display: style.display,
+			flexDirection: style.display.includes('flex') ? style.flexDirection : '',
+			columns: columns,
+			x: rect.x, y: rect.y, width: rect.width, height: rect.height,
+		});
+	});
+	return {scrollWidth: document.documentElement.scrollWidth, nodes: nodes};
+})()
+`
+
+type layoutNode struct {
+	Key           string  `json:"key"`
+	Label         string  `json:"label"`
+	Visible       bool    `json:"visible"`
+	Display       string  `json:"display"`
+	FlexDirection string  `json:"flexDirection"`
+	Columns       int     `json:"columns"`
+	X             float64 `json:"x"`
+	Y             float64 `json:"y"`
+	Width         float64 `json:"width"`
+	Height        float64 `json:"height"`
+}
+
+type layoutSnapshot struct {
+	Width       int          `json:"width"`
+	Screenshot  string       `json:"screenshot"`
+	ScrollWidth int          `json:"scrollWidth"`
+	Nodes       []layoutNode `json:"nodes"`
+}
+
+// responsiveBreakpoint is a width range in which the layout changed.
+type responsiveBreakpoint struct {
+	From          int     `json:"from"`
+	To            int     `json:"to"`
+	Changes       int     `json:"changes"`
+	CSSBreakpoint float64 `json:"css_breakpoint,omitempty"`
+}
+
+// componentBehavior lists how one element changes across widths.
+type componentBehavior struct {
+	Key       string   `json:"key"`
+	Label     string   `json:"label"`
+	Behaviors []string `json:"behaviors"`
+}
+
+// responsiveBehavior is responsive/<page>/responsive_behavior.json.
+type responsiveBehavior struct {
+	Page        string                 `json:"page"`
+	Widths      []int                  `json:"widths"`
+	Screenshots map[int]string         `json:"screenshots"`
+	Overflow    []int                  `json:"horizontal_overflow_at,omitempty"`
+	Breakpoints []responsiveBreakpoint `json:"breakpoints"`
+	Components  []componentBehavior    `json:"components"`
+}
+
+// captureResponsive renders the page at each configured width, then infers
+// the breakpoints and the components that hide, stack or reflow, and writes
+// responsive/<page>/responsive_behavior.json with a screenshot per width.
+func (e *AgicapExplorer) captureResponsive(pageName string) string {
+	widths := e.config.GetIntSlice("explorer.responsive.widths")
+	if len(widths) < 2 {
+		return ""
+	}
+	sort.Ints(widths)
+	height := int64(e.config.GetInt("explorer.responsive.height"))
+	scale := e.config.GetFloat64("explorer.screenshots.scale_factor")
+	dir := filepath.Join("responsive", sanitize(pageName))
+
+	var snapshots []layoutSnapshot
+	for _, width := range widths {
+		var snap layoutSnapshot
+		var shot []byte
+		err := chromedp.Run(e.ctx,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				return emulation.SetDeviceMetricsOverride(int64(width), height, scale, width < 768).Do(ctx)
+			}),
+			chromedp.Sleep(700*time.Millisecond),
+			chromedp.Evaluate(layoutSnapshotJS, &snap),
+			chromedp.CaptureScreenshot(&shot),
+		)
+		if err != nil {
+			e.log("⚠️ Responsive capture at %dpx failed for %s: %v", width, pageName, err)
+			continue
+		}
+		snap.Width = width
+		if path, err := e.writeArtifact(dir, fmt.Sprintf("%d.png", width), shot); err == nil {
+			snap.Screenshot, _ = filepath.Rel(e.outputDir, path)
+		}
+		snapshots = append(snapshots, snap)
+	}
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return emulation.ClearDeviceMetricsOverride().Do(ctx)
+	}))
+	if len(snapshots) < 2 {
+		return ""
+	}
+
+	behavior := inferResponsiveBehavior(snapshots, e.cssBreakpointWidths())
+	behavior.Page = pageName
+	data, err := json.MarshalIndent(behavior, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact(dir, "responsive_behavior.json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write responsive behavior for %s: %v", pageName, err)
+		return ""
+	}
+	e.log("📐 %s: %d responsive breakpoints, %d adaptive components", pageName, len(behavior.Breakpoints), len(behavior.Components))
+	return path
+}
+
+// cssBreakpointWidths returns the media-query widths harvested from the
+// stylesheets so far, with the number of rules behind each.
+func (e *AgicapExplorer) cssBreakpointWidths() map[float64]int {
+	widths := make(map[float64]int)
+	h := e.stylesheets
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, bp := range h.breakpoints {
+		for _, w := range []float64{bp.MinWidth, bp.MaxWidth} {
+			if w > 0 {
+				widths[w] += bp.Rules
+			}
+		}
+	}
+	return widths
+}
+
+// inferResponsiveBehavior compares consecutive snapshots. Every change
+// between two widths counts towards that range's breakpoint, which is pinned
+// to the CSS media query with the most rules inside the range when there is
+// one.
+func inferResponsiveBehavior(snapshots []layoutSnapshot, cssWidths map[float64]int) responsiveBehavior {
+	result := responsiveBehavior{Screenshots: make(map[int]string)}
+	byKey := make([]map[string]layoutNode, len(snapshots))
+	var keys []string
+	labels := make(map[string]string)
+	for i, snap := range snapshots {
+		result.Widths = append(result.Widths, snap.Width)
+		result.Screenshots[snap.Width] = filepath.ToSlash(snap.Screenshot)
+		if snap.ScrollWidth > snap.Width {
+			result.Overflow = append(result.Overflow, snap.Width)
+		}
+		byKey[i] = make(map[string]layoutNode)
+		for _, n := range snap.Nodes {
+			if _, ok := labels[n.Key]; !ok {
+				keys = append(keys, n.Key)
+				labels[n.Key] = n.Label
+			}
+			byKey[i][n.Key] = n
+		}
+	}
+
+	changes := make([]int, len(snapshots)-1)
+	for _, key := range keys {
+		var behaviors []string
+		for i := 0; i+1 < len(snapshots); i++ {
+			small, okSmall := byKey[i][key]
+			large, okLarge := byKey[i+1][key]
+			lower, upper := snapshots[i].Width, snapshots[i+1].Width
+			before := len(behaviors)
+
+			visibleSmall, visibleLarge := okSmall && small.Visible, okLarge && large.Visible
+			switch {
+			case visibleLarge && !visibleSmall:
+				behaviors = append(behaviors, fmt.Sprintf("hidden below %dpx", upper))
+			case visibleSmall && !visibleLarge:
+				behaviors = append(behaviors, fmt.Sprintf("only shown below %dpx", upper))
+			case visibleSmall && visibleLarge:
+				if small.FlexDirection != large.FlexDirection && small.FlexDirection != "" && large.FlexDirection != "" {
+					behaviors = append(behaviors, fmt.Sprintf("flex-direction %s → %s below %dpx", large.FlexDirection, small.FlexDirection, upper))
+				}
+				if small.Columns != large.Columns && (small.Columns > 0 || large.Columns > 0) {
+					behaviors = append(behaviors, fmt.Sprintf("grid columns %d → %d below %dpx", large.Columns, small.Columns, upper))
+				}
+				if small.Width >= 0.9*float64(lower) && large.Width < 0.6*float64(upper) {
+					behaviors = append(behaviors, fmt.Sprintf("stacks full-width below %dpx", upper))
+				}
+				if small.Display != large.Display && !strings.Contains(small.Display+large.Display, "none") {
+					behaviors = append(behaviors, fmt.Sprintf("display %s → %s below %dpx", large.Display, small.Display, upper))
+				}
+			}
+			changes[i] += len(behaviors) - before
+		}
+		if len(behaviors) > 0 {
+			result.Components = append(result.Components, componentBehavior{Key: key, Label: labels[key], Behaviors: behaviors})
+		}
+	}
+
+	for i, n := range changes {
+		if n == 0 {
+			continue
+		}
+		bp := responsiveBreakpoint{From: snapshots[i].Width, To: snapshots[i+1].Width, Changes: n}
+		best := 0
+		for w, rules := range cssWidths {
+			// min-width: 768px applies from 768, max-width: 767px up to 767
+			if w > float64(bp.From) && w <= float64(bp.To) && rules > best {
+				bp.CSSBreakpoint, best = w, rules
+			}
+		}
+		result.Breakpoints = append(result.Breakpoints, bp)
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	numericSegment = regexp.MustCompile(`^\d+$`)
+	uuidSegment    = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	hexSegment     = regexp.MustCompile(`(?i)^[0-9a-f]{8,}$`)
+	dateSegment    = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	tokenSegment   = regexp.MustCompile(`^[A-Za-z0-9_-]{8,}$`)
+)
+
+// routeTemplate infers the route pattern of a URL by replacing ID-like path
+// segments with placeholders, e.g. /invoices/123/edit -> /invoices/:id/edit.
+func routeTemplate(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, seg := range segments {
+		if p := segmentPlaceholder(seg); p != "" {
+			segments[i] = p
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// segmentPlaceholder returns the placeholder for a dynamic path segment, or ""
+// if the segment looks like a static route name.
+func segmentPlaceholder(seg string) string {
+	switch {
+	case seg == "":
+		return ""
+	case numericSegment.MatchString(seg), uuidSegment.MatchString(seg):
+		return ":id"
+	case dateSegment.MatchString(seg):
+		return ":date"
+	case hexSegment.MatchString(seg) && strings.ContainsAny(seg, "0123456789"):
+		return ":id"
+	case tokenSegment.MatchString(seg) && countDigits(seg) >= 2:
+		return ":id"
+	}
+	return ""
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// isParameterized reports whether a route template contains placeholders.
+func isParameterized(template string) bool {
+	return strings.Contains(template, "/:")
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const toolName = "agicap-explorer"
+
+// toolVersion is set at build time with
+// -ldflags "-X main.toolVersion=v1.2.3"; otherwise the VCS revision the
+// binary was built from is used when known.
+var toolVersion = "dev"
+
+// version returns the tool version for run.json and the HAR creator.
+func version() string {
+	if toolVersion != "dev" {
+		return toolVersion
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 12 {
+				return "dev+" + s.Value[:12]
+			}
+		}
+	}
+	return toolVersion
+}
+
+// newRunID combines the start time with random bytes so runs started in
+// the same second stay distinct.
+func newRunID(started time.Time) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return started.Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// runManifest is run.json, the machine-readable summary of an exploration.
+type runManifest struct {
+	RunID     string                 `json:"run_id"`
+	Tool      string                 `json:"tool"`
+	Version   string                 `json:"version"`
+	StartedAt string                 `json:"started_at"`
+	EndedAt   string                 `json:"ended_at"`
+	Duration  float64                `json:"duration_seconds"`
+	Pages     int                    `json:"pages"`
+	Scale     float64                `json:"scale_factor"` // device pixel ratio of the screenshots
+	Failures  map[string]int         `json:"failures"`
+	Config    json.RawMessage        `json:"config"`
+	Artifacts map[string]runArtifact `json:"artifacts"`
+}
+
+type runArtifact struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// recordFailure counts a failure of the given kind for run.json.
+func (e *AgicapExplorer) recordFailure(kind string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures[kind]++
+}
+
+// writeRunManifest writes run.json. It runs last so the checksums cover
+// every other artifact of the run.
+func (e *AgicapExplorer) writeRunManifest() error {
+	ended := time.Now()
+	manifest := runManifest{
+		RunID:     e.runID,
+		Tool:      toolName,
+		Version:   version(),
+		StartedAt: e.started.Format(time.RFC3339),
+		EndedAt:   ended.Format(time.RFC3339),
+		Duration:  ended.Sub(e.started).Round(time.Second).Seconds(),
+		Pages:     len(e.navigationMap),
+		Scale:     e.config.GetFloat64("explorer.screenshots.scale_factor"),
+		Failures:  make(map[string]int),
+	}
+	for kind, n := range e.failures {
+		manifest.Failures[kind] = n
+	}
+	for _, page := range e.navigationMap {
+		manifest.Failures["js_errors"] += page.JSErrors
+	}
+	if e.network.enabled {
+		for _, entry := range e.network.All() {
+			if entry.response.Status >= 400 {
+				manifest.Failures["http_errors"]++
+			}
+		}
+		manifest.Failures["requests"] = e.network.Failures()
+	}
+
+	config, err := e.configSnapshot()
+	if err != nil {
+		return err
+	}
+	manifest.Config = config
+
+	manifest.Artifacts, err = checksumArtifacts(e.outputDir)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "run.json"), data, 0644)
+}
+
+// configSnapshot returns the effective configuration passed through the
+// redaction rules. The credentials are always masked.
+func (e *AgicapExplorer) configSnapshot() (json.RawMessage, error) {
+	settings := e.config.AllSettings()
+	if explorer, ok := settings["explorer"].(map[string]interface{}); ok {
+		if credentials, ok := explorer["credentials"].(map[string]interface{}); ok {
+			for key := range credentials {
+				credentials[key] = redactedValue
+			}
+		}
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return json.RawMessage(e.redactor.Redact(string(data))), nil
+}
+
+// checksumArtifacts hashes every file of the run directory except run.json,
+// keyed by slash-separated path relative to the directory.
+func checksumArtifacts(dir string) (map[string]runArtifact, error) {
+	artifacts := make(map[string]runArtifact)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if rel == "run.json" {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		h := sha256.New()
+		size, err := io.Copy(h, f)
+		if err != nil {
+			return nil
+		}
+		artifacts[rel] = runArtifact{SHA256: hex.EncodeToString(h.Sum(nil)), Size: size}
+		return nil
+	})
+	return artifacts, err
+}
+
+// generatedOutputs describe the files main lists after a run, by path
+// relative to the output directory; a trailing slash stands for a
+// directory with at least one file.
+var generatedOutputs = []struct {
+	paths       []string
+	description string
+}{
+	{[]string{"report.html"}, "Visual report"},
+	{[]string{"summary.pdf"}, "Executive summary for sharing"},
+	{[]string{"site/"}, "Static site with a detail page per screen"},
+	{[]string{"run.json"}, "Run manifest with config snapshot and artifact checksums"},
+	{[]string{"navigation_map.json"}, "Navigation structure"},
+	{[]string{"navigation_map.ndjson"}, "Pages as they were captured, kept if a run crashes"},
+	{[]string{"timings.json"}, "Time spent per phase and the slowest pages (with -bench)"},
+	{[]string{"crawl_profile.json"}, "Capture time per page, slow pages are crawled first next time"},
+	{[]string{"schemas/"}, "JSON schemas of the versioned outputs (check with validate-output)"},
+	{[]string{"graph.json", "graph.dot"}, "Link graph"},
+	{[]string{"screenshots/"}, "All screenshots (thumbs/ for the report, original/ with -lossless, <page>_ignore.json masked in diffs)"},
+	{[]string{"html/"}, "Page source code"},
+	{[]string{"assets/"}, "Stylesheets, fonts, images and icons"},
+	{[]string{"icons/"}, "Deduplicated SVG icons, sprite and icon-font glyphs"},
+	{[]string{"har/"}, "Network traffic per page"},
+	{[]string{"api_inventory.json", "openapi.json"}, "Backend endpoints"},
+	{[]string{"fixtures/"}, "Sample API responses and schemas"},
+	{[]string{"graphql/"}, "GraphQL operations and reconstructed schema"},
+	{[]string{"websocket/"}, "Real-time messages per page"},
+	{[]string{"a11y/"}, "Accessibility tree, axe-core WCAG violations, form field labels, images and ARIA usage per page"},
+	{[]string{"forms_accessibility.json"}, "Form fields without a label or labeled only by their placeholder"},
+	{[]string{"image_inventory.json"}, "Images with alt text, size and decorative or informative usage"},
+	{[]string{"aria_usage.json"}, "ARIA roles and attributes used across the app, with examples"},
+	{[]string{"console/"}, "Console output and JavaScript errors per page"},
+	{[]string{"perf/"}, "Load timings and Core Web Vitals per page"},
+	{[]string{"styles/", "breakpoints.json"}, "Stylesheets, custom properties, keyframes and breakpoints"},
+	{[]string{"layouts/", "layout_templates.json"}, "Layout regions, heading outline and landmarks per page, shared page templates"},
+	{[]string{"charts/", "chart_specs.json"}, "Chart types, axes and series data for Recharts"},
+	{[]string{"motion/"}, "Transitions and animations per page, and a screenshot with prefers-reduced-motion"},
+	{[]string{"i18n/"}, "UI strings per page and the deduplicated catalog as JSON and PO files per language"},
+	{[]string{"dom/"}, "DOM structure per page, diffed by compare"},
+	{[]string{"scroll/"}, "Screenshots at each scroll step of long pages, the stitched full page, sticky and parallax elements"},
+	{[]string{"overlays/"}, "Each menu, dialog, drawer and popover opened on its own, with the trigger that opens it"},
+	{[]string{"tables/"}, "Data tables sorted, paged and filtered, with the interactions each offers"},
+	{[]string{"responsive/"}, "Per-width screenshots and inferred responsive behavior"},
+	{[]string{"zoom/"}, "Selected pages at 200% and 400% browser zoom with reflow issues"},
+	{[]string{"viewports/"}, "Screenshots and component analyses per device of the viewport matrix"},
+	{[]string{"print/"}, "Report-style pages rendered for print next to the screen, their print CSS and PDF"},
+	{[]string{"throttling/"}, "Loading and skeleton states per page on emulated slow or offline connections"},
+	{[]string{"locales/", "locale_report.json"}, "Each page per locale, missing translations and layout breakage"},
+	{[]string{"vision/"}, "Key pages with simulated protanopia, deuteranopia and other color vision deficiencies"},
+	{[]string{"figma/"}, "Tokens Studio tokens and component images for Figma"},
+	{[]string{"third_parties.md"}, "External services the app depends on"},
+}
+
+// generatedFiles lists the outputs of generatedOutputs that the run.json
+// of the run in dir records, so only the enabled features show up.
+func generatedFiles(dir string) []string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "run.json"))
+	if err != nil {
+		return nil
+	}
+	var manifest runManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+	has := func(path string) bool {
+		if !strings.HasSuffix(path, "/") {
+			_, ok := manifest.Artifacts[path]
+			return ok
+		}
+		for artifact := range manifest.Artifacts {
+			if strings.HasPrefix(artifact, path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var lines []string
+	for _, output := range generatedOutputs {
+		for _, path := range output.paths {
+			if path == "run.json" || has(path) {
+				lines = append(lines, strings.Join(output.paths, " / ")+" - "+output.description)
+				break
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGeneratedFilesListsOnlyManifestArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	manifest := runManifest{Artifacts: map[string]runArtifact{
+		"report.html":                 {},
+		"navigation_map.json":         {},
+		"screenshots/thumbs/01_a.png": {},
+		"breakpoints.json":            {},
+	}}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "run.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"report.html - Visual report",
+		"run.json - Run manifest with config snapshot and artifact checksums",
+		"navigation_map.json - Navigation structure",
+		"screenshots/ - All screenshots (thumbs/ for the report, original/ with -lossless, <page>_ignore.json masked in diffs)",
+		"styles/ / breakpoints.json - Stylesheets, custom properties, keyframes and breakpoints",
+	}
+	if got := generatedFiles(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("generatedFiles =\n%q\nwant\n%q", got, want)
+	}
+}
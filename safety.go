@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// safetyCheckJS classifies the element of a selector for the safety guard:
+// "denied" inside an element of the deny selectors, "write" when clicking it
+// submits a form, "" otherwise, with the aria-label, title or value that
+// names it besides its text.
+const safetyCheckJS = `
+(function(selector, denied) {
+	const el = document.querySelector(selector);
+	if (!el) return {kind: '', label: ''};
+	const label = el.getAttribute('aria-label') || el.getAttribute('title') || (el.tagName === 'INPUT' ? el.value : '') || '';
+	try {
+		if (denied && el.closest(denied)) return {kind: 'denied', label: label};
+	} catch (e) {}
+	const submits = el.matches('input[type="submit"], input[type="image"]') ||
+		(el.tagName === 'BUTTON' && el.closest('form') && (el.getAttribute('type') || 'submit').toLowerCase() === 'submit');
+	return {kind: submits ? 'write' : '', label: label};
+})(%s, %s)
+`
+
+// safetyGuard keeps the explorer from changing data of the live account it
+// explores. Elements whose text or label matches explorer.safety.deny_text
+// or that sit inside explorer.safety.deny_selectors are neither clicked nor
+// filled in; clicks that submit a form need a yes on the terminal with
+// explorer.safety.confirm_writes. In read-only mode every request after the
+// login fails unless it is a GET, HEAD, OPTIONS, a GraphQL query or matches
+// explorer.safety.allow_writes.
+type safetyGuard struct {
+	enabled       bool
+	denyText      []*regexp.Regexp
+	denySelectors string
+	confirmWrites bool
+	readOnly      bool
+	allowWrites   []*regexp.Regexp
+	armed         atomic.Bool // read-only applies once logged in
+
+	mu      sync.Mutex
+	answers map[string]bool // confirmations by prompt
+	blocked map[string]int  // blocked requests by method and path
+	log     func(format string, args ...interface{})
+}
+
+// confirmInput reads the answers to confirmation prompts.
+var confirmInput = bufio.NewReader(os.Stdin)
+
+// newSafetyGuard loads explorer.safety. It returns nil when neither the
+// deny list nor read-only mode is on.
+func newSafetyGuard(v *viper.Viper, logf func(format string, args ...interface{})) (*safetyGuard, error) {
+	g := &safetyGuard{
+		enabled:       v.GetBool("explorer.safety.enabled"),
+		denySelectors: strings.Join(v.GetStringSlice("explorer.safety.deny_selectors"), ", "),
+		confirmWrites: v.GetBool("explorer.safety.confirm_writes"),
+		readOnly:      v.GetBool("explorer.safety.read_only"),
+		answers:       make(map[string]bool),
+		blocked:       make(map[string]int),
+		log:           logf,
+	}
+	if !g.enabled && !g.readOnly {
+		return nil, nil
+	}
+	var err error
+	if g.denyText, err = compilePatterns(v.GetStringSlice("explorer.safety.deny_text")); err != nil {
+		return nil, fmt.Errorf("invalid explorer.safety.deny_text pattern: %w", err)
+	}
+	if g.allowWrites, err = compilePatterns(v.GetStringSlice("explorer.safety.allow_writes")); err != nil {
+		return nil, fmt.Errorf("invalid explorer.safety.allow_writes pattern: %w", err)
+	}
+	return g, nil
+}
+
+// ReadOnly reports whether requests that write are blocked.
+func (g *safetyGuard) ReadOnly() bool {
+	return g != nil && g.readOnly
+}
+
+// arm starts blocking writes in read-only mode, disarm stops it while
+// logging in.
+func (g *safetyGuard) arm() {
+	if g != nil {
+		g.armed.Store(true)
+	}
+}
+
+func (g *safetyGuard) disarm() {
+	if g != nil {
+		g.armed.Store(false)
+	}
+}
+
+// deniedText returns the deny_text pattern one of the texts matches, or nil.
+func (g *safetyGuard) deniedText(texts ...string) *regexp.Regexp {
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		for _, re := range g.denyText {
+			if re.MatchString(text) {
+				return re
+			}
+		}
+	}
+	return nil
+}
+
+// confirm asks on the terminal whether to go ahead, once per prompt. It
+// answers no when stdin is not a terminal.
+func (g *safetyGuard) confirm(prompt string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if answer, ok := g.answers[prompt]; ok {
+		return answer
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		g.log("🛡️ Skipping, no terminal to confirm: %s", prompt)
+		g.answers[prompt] = false
+		return false
+	}
+	fmt.Printf("🛡️ %s [y/N] ", prompt)
+	line, _ := confirmInput.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes", "j", "ja":
+		g.answers[prompt] = true
+	default:
+		g.answers[prompt] = false
+	}
+	return g.answers[prompt]
+}
+
+// allowAction reports whether the explorer may click ("click") or fill in
+// ("fill") the element of selector, whose text is text. Denied elements are
+// skipped; clicks that submit a form are confirmed with confirm_writes.
+func (e *AgicapExplorer) allowAction(action, selector, text string) bool {
+	g := e.safety
+	if g == nil || !g.enabled {
+		return true
+	}
+	if re := g.deniedText(text); re != nil {
+		e.log("🛡️ Not going to %s %q: matches %s", action, text, re)
+		return false
+	}
+	var check struct {
+		Kind  string `json:"kind"`
+		Label string `json:"label"`
+	}
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(safetyCheckJS, jsString(selector), jsString(g.denySelectors)), &check)); err != nil {
+		return false
+	}
+	if re := g.deniedText(check.Label); re != nil {
+		e.log("🛡️ Not going to %s %q: matches %s", action, check.Label, re)
+		return false
+	}
+	switch {
+	case check.Kind == "denied":
+		e.log("🛡️ Not going to %s %s: inside a denied element", action, selector)
+		return false
+	case check.Kind == "write" && action == "click" && g.confirmWrites:
+		name := text
+		if name == "" {
+			name = check.Label
+		}
+		return g.confirm(fmt.Sprintf("Click %q (%s), which submits a form?", name, selector))
+	}
+	return true
+}
+
+// blocks reports whether read-only mode stops the request, and logs the
+// first of each method and path it stops.
+func (g *safetyGuard) blocks(req *network.Request) bool {
+	if !g.ReadOnly() || !g.armed.Load() {
+		return false
+	}
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return false
+	}
+	for _, re := range g.allowWrites {
+		if re.MatchString(req.URL) {
+			return false
+		}
+	}
+	if graphQLQueryOnly(req.PostData) {
+		return false
+	}
+
+	key := req.Method + " " + req.URL
+	if u, err := url.Parse(req.URL); err == nil {
+		key = req.Method + " " + u.Host + u.Path
+	}
+	g.mu.Lock()
+	g.blocked[key]++
+	first := g.blocked[key] == 1
+	g.mu.Unlock()
+	if first {
+		g.log("🛡️ Read-only: blocked %s", key)
+	}
+	return true
+}
+
+// Blocked returns how many requests read-only mode stopped.
+func (g *safetyGuard) Blocked() int {
+	if g == nil {
+		return 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	total := 0
+	for _, n := range g.blocked {
+		total += n
+	}
+	return total
+}
+
+// graphQLMutation finds a mutation behind leading fragments.
+var graphQLMutation = regexp.MustCompile(`\bmutation\b`)
+
+// graphQLQueryOnly reports whether a request body holds GraphQL operations
+// that are all queries. Persisted queries sent by hash alone do not count,
+// they could be mutations.
+func graphQLQueryOnly(body string) bool {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return false
+	}
+	var reqs []graphQLRequest
+	if strings.HasPrefix(body, "[") {
+		if json.Unmarshal([]byte(body), &reqs) != nil {
+			return false
+		}
+	} else {
+		var req graphQLRequest
+		if json.Unmarshal([]byte(body), &req) != nil {
+			return false
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) == 0 {
+		return false
+	}
+	for _, req := range reqs {
+		if req.Query == "" {
+			return false
+		}
+		if kind, _ := operationType(req.Query, req.OperationName); kind != "query" || graphQLMutation.MatchString(req.Query) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed schemas/*.json
+var schemaFS embed.FS
+
+// outputSchema is the versioned format of a JSON output. Files carry their
+// version in "schema_version"; a breaking change bumps it and adds a new
+// schemas/<name>.v<N>.schema.json.
+type outputSchema struct {
+	File     string // relative to the run directory
+	Version  int
+	Required bool // features/ only exists after a functional run
+}
+
+var outputSchemas = []outputSchema{
+	{File: "navigation_map.json", Version: 1, Required: true},
+	{File: "design_system.json", Version: 1, Required: true},
+	{File: "features/feature_tests.json", Version: 1},
+}
+
+// schemaName is the schema file of a version of an output, e.g.
+// navigation_map.v1.schema.json.
+func (s outputSchema) schemaName(version int) string {
+	base := strings.TrimSuffix(filepath.Base(s.File), ".json")
+	return fmt.Sprintf("%s.v%d.schema.json", base, version)
+}
+
+// ref is the "$schema" reference written into the output, relative to the
+// file so it resolves against the schemas/ copied into the run directory.
+func (s outputSchema) ref() string {
+	return strings.Repeat("../", strings.Count(s.File, "/")) + "schemas/" + s.schemaName(s.Version)
+}
+
+func schemaFor(file string) outputSchema {
+	for _, s := range outputSchemas {
+		if s.File == file {
+			return s
+		}
+	}
+	panic("no schema for " + file)
+}
+
+// navigationMapFile is navigation_map.json.
+type navigationMapFile struct {
+	Schema        string           `json:"$schema"`
+	SchemaVersion int              `json:"schema_version"`
+	Generated     string           `json:"generated"`
+	Pages         []NavigationItem `json:"pages"`
+}
+
+// featureTestsFile is features/feature_tests.json.
+type featureTestsFile struct {
+	Schema        string        `json:"$schema"`
+	SchemaVersion int           `json:"schema_version"`
+	Generated     string        `json:"generated"`
+	Features      []featureTest `json:"features"`
+}
+
+// writeNavigationMap writes the versioned navigation_map.json.
+func (e *AgicapExplorer) writeNavigationMap() error {
+	s := schemaFor("navigation_map.json")
+	pages := e.navigationMap
+	if pages == nil {
+		pages = []NavigationItem{}
+	}
+	data, err := json.MarshalIndent(navigationMapFile{
+		Schema:        s.ref(),
+		SchemaVersion: s.Version,
+		Generated:     time.Now().Format(time.RFC3339),
+		Pages:         pages,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.outputDir, s.File), data, 0644)
+}
+
+// versionedDesignSystem puts the schema reference and version in front of
+// the design system tokens.
+func versionedDesignSystem(system orderedTokens) orderedTokens {
+	s := schemaFor("design_system.json")
+	return append(orderedTokens{{"$schema", s.ref()}, {"schema_version", s.Version}}, system...)
+}
+
+// writeSchemas copies the JSON schemas of the current versions into
+// <run>/schemas/ for the "$schema" references of the outputs.
+func writeSchemas(runDir string) error {
+	if err := os.MkdirAll(filepath.Join(runDir, "schemas"), 0755); err != nil {
+		return err
+	}
+	for _, s := range outputSchemas {
+		data, err := schemaFS.ReadFile("schemas/" + s.schemaName(s.Version))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(runDir, "schemas", s.schemaName(s.Version)), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadNavigationMap reads navigation_map.json from a run directory. Runs
+// from before the format was versioned stored the bare page array; for a
+// run that crashed before writing it, the pages come from
+// navigation_map.ndjson.
+func loadNavigationMap(runDir string) ([]NavigationItem, error) {
+	if streamIsNewer(runDir) {
+		return loadNavigationStream(runDir)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(runDir, "navigation_map.json"))
+	if err != nil {
+		return nil, err
+	}
+	var items []NavigationItem
+	if isJSONArray(data) {
+		err = json.Unmarshal(data, &items)
+	} else {
+		var file navigationMapFile
+		err = json.Unmarshal(data, &file)
+		items = file.Pages
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(runDir, "navigation_map.json"), err)
+	}
+	return items, nil
+}
+
+// loadFeatureTests reads features/feature_tests.json, versioned or a bare
+// array from older functional runs.
+func loadFeatureTests(runDir string) ([]featureTest, error) {
+	path := filepath.Join(runDir, "features", "feature_tests.json")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var features []featureTest
+	if isJSONArray(data) {
+		err = json.Unmarshal(data, &features)
+	} else {
+		var file featureTestsFile
+		err = json.Unmarshal(data, &file)
+		features = file.Features
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return features, nil
+}
+
+func isJSONArray(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+}
+
+// runValidateOutput checks the versioned outputs of a run against their
+// schemas:
+//
+//	explorer validate-output [-max-errors n] <run>
+func runValidateOutput(args []string) error {
+	fs := flag.NewFlagSet("validate-output", flag.ContinueOnError)
+	maxErrors := fs.Int("max-errors", 20, "errors listed per file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: validate-output [-max-errors n] <run>")
+	}
+
+	invalid := 0
+	for _, s := range outputSchemas {
+		problems, summary := validateOutput(fs.Arg(0), s)
+		if len(problems) == 0 {
+			fmt.Printf("✅ %s: %s\n", s.File, summary)
+			continue
+		}
+		invalid++
+		fmt.Printf("❌ %s: %d problems\n", s.File, len(problems))
+		for i, p := range problems {
+			if i == *maxErrors {
+				fmt.Printf("   ... and %d more\n", len(problems)-i)
+				break
+			}
+			fmt.Printf("   %s\n", p)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d outputs do not match their schema", invalid, len(outputSchemas))
+	}
+	return nil
+}
+
+// validateOutput returns the schema violations of one output, or a summary
+// when it is valid.
+func validateOutput(runDir string, s outputSchema) ([]string, string) {
+	data, err := ioutil.ReadFile(filepath.Join(runDir, filepath.FromSlash(s.File)))
+	if os.IsNotExist(err) && !s.Required {
+		return nil, "not present (optional)"
+	}
+	if err != nil {
+		return []string{err.Error()}, ""
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []string{"invalid JSON: " + err.Error()}, ""
+	}
+
+	object, _ := doc.(map[string]interface{})
+	version, ok := object["schema_version"].(float64)
+	if !ok {
+		return []string{"no schema_version (written before the format was versioned)"}, ""
+	}
+	schemaData, err := schemaFS.ReadFile("schemas/" + s.schemaName(int(version)))
+	if err != nil {
+		return []string{fmt.Sprintf("unknown schema_version %v (this build knows up to %d)", version, s.Version)}, ""
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(schemaData, &schema); err != nil {
+		return []string{err.Error()}, ""
+	}
+
+	problems := validateJSON(schema, doc, "$")
+	summary := fmt.Sprintf("schema v%d", int(version))
+	for _, key := range []string{"pages", "features"} {
+		if list, ok := object[key].([]interface{}); ok {
+			summary += fmt.Sprintf(", %d %s", len(list), key)
+		}
+	}
+	if int(version) < s.Version {
+		summary += fmt.Sprintf(" (current is v%d)", s.Version)
+	}
+	return problems, summary
+}
+
+// validateJSON checks a decoded JSON value against the subset of JSON
+// Schema the bundled schemas use: type, const, enum, required, properties,
+// additionalProperties, items, minimum and pattern.
+func validateJSON(schema map[string]interface{}, value interface{}, path string) []string {
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []interface{}:
+			for _, name := range t {
+				types = append(types, fmt.Sprint(name))
+			}
+		}
+		matched := false
+		for _, name := range types {
+			if jsonTypeMatches(name, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("expected %s, got %s", strings.Join(types, " or "), jsonTypeOf(value))
+			return problems
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, value) {
+		fail("expected %v, got %v", c, value)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if jsonEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("%v is not one of %v", value, enum)
+		}
+	}
+	if min, ok := schema["minimum"].(float64); ok {
+		if n, ok := value.(float64); ok && n < min {
+			fail("%v is below the minimum %v", n, min)
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, ok := value.(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(s) {
+				fail("%q does not match %s", s, pattern)
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, key := range required {
+				if _, ok := v[fmt.Sprint(key)]; !ok {
+					fail("missing required property %q", key)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if sub, ok := properties[key].(map[string]interface{}); ok {
+				problems = append(problems, validateJSON(sub, v[key], path+"."+key)...)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					fail("unexpected property %q", key)
+				}
+			case map[string]interface{}:
+				problems = append(problems, validateJSON(extra, v[key], path+"."+key)...)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, validateJSON(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+func jsonTypeMatches(name string, value interface{}) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	}
+	return jsonTypeOf(value) == name
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
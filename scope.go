@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// defaultExcludes keeps the crawler away from URLs that end the session or
+// change account state.
+var defaultExcludes = []string{
+	`(?i)/(logout|log-out|signout|sign-out|sign_out)\b`,
+	`(?i)/delete\b`,
+	`(?i)/remove\b`,
+	`(?i)/unsubscribe\b`,
+	`(?i)/billing/.*cancel`,
+	`(?i)/subscription/.*cancel`,
+}
+
+// urlScope decides which URLs the crawler is allowed to navigate to.
+type urlScope struct {
+	hosts   map[string]bool
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func newURLScope(v *viper.Viper) (*urlScope, error) {
+	s := &urlScope{hosts: make(map[string]bool)}
+
+	hosts := v.GetStringSlice("explorer.scope.allowed_hosts")
+	if len(hosts) == 0 {
+		if u, err := url.Parse(v.GetString("explorer.login_url")); err == nil && u.Host != "" {
+			hosts = []string{u.Host}
+		}
+	}
+	for _, h := range hosts {
+		s.hosts[strings.ToLower(h)] = true
+	}
+
+	var err error
+	if s.include, err = compilePatterns(v.GetStringSlice("explorer.scope.include")); err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+	excludes := v.GetStringSlice("explorer.scope.exclude")
+	if !v.GetBool("explorer.scope.disable_default_excludes") {
+		excludes = append(excludes, defaultExcludes...)
+	}
+	if s.exclude, err = compilePatterns(excludes); err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+
+	return s, nil
+}
+
+// Allows reports whether rawURL is in scope, and if not, why.
+func (s *urlScope) Allows(rawURL string) (bool, string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false, "unparseable URL"
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false, "non-HTTP scheme " + u.Scheme
+	}
+	if len(s.hosts) > 0 && !s.hosts[strings.ToLower(u.Host)] {
+		return false, "external host " + u.Host
+	}
+	for _, re := range s.exclude {
+		if re.MatchString(rawURL) {
+			return false, "matches exclude pattern " + re.String()
+		}
+	}
+	if len(s.include) == 0 {
+		return true, ""
+	}
+	for _, re := range s.include {
+		if re.MatchString(rawURL) {
+			return true, ""
+		}
+	}
+	return false, "matches no include pattern"
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// screenshotFormats maps explorer.screenshots.format to file extensions.
+var screenshotFormats = map[string]string{"png": ".png", "jpeg": ".jpg", "webp": ".webp"}
+
+// checkScreenshotFormat validates explorer.screenshots.format.
+func checkScreenshotFormat(v *viper.Viper) error {
+	format := v.GetString("explorer.screenshots.format")
+	if _, ok := screenshotFormats[format]; !ok {
+		return fmt.Errorf("explorer.screenshots.format: unknown format %q (png, jpeg, webp)", format)
+	}
+	return nil
+}
+
+// checkScaleFactor validates explorer.screenshots.scale_factor, the device
+// pixel ratio Chrome renders with: 2 gives retina screenshots twice the
+// window size.
+func checkScaleFactor(v *viper.Viper) error {
+	if scale := v.GetFloat64("explorer.screenshots.scale_factor"); scale < 0.5 || scale > 4 {
+		return fmt.Errorf("explorer.screenshots.scale_factor: %g is outside 0.5-4", scale)
+	}
+	return nil
+}
+
+// captureScreenshot saves the viewport of the current page as
+// screenshots/<page>.<ext> in the configured format, the PNG original as
+// screenshots/original/<page>.png when a lossy format is used with
+// explorer.screenshots.lossless, and a JPEG thumbnail as
+// screenshots/thumbs/<page>.jpg. It returns the paths of the screenshot and
+// the thumbnail.
+func (e *AgicapExplorer) captureScreenshot(pageName string) (string, string) {
+	format := e.config.GetString("explorer.screenshots.format")
+	quality := e.config.GetInt("explorer.screenshots.quality")
+	name := sanitize(pageName)
+	path := filepath.Join(e.outputDir, "screenshots", name+screenshotFormats[format])
+
+	var original []byte
+	if err := chromedp.Run(e.ctx, chromedp.CaptureScreenshot(&original)); err != nil {
+		e.log("⚠️ Failed to take screenshot of %s: %v", pageName, err)
+		return path, ""
+	}
+
+	data := original
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img = decodeScreenshot(original)
+		var buf bytes.Buffer
+		if img == nil || jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}) != nil {
+			e.log("⚠️ Failed to encode the screenshot of %s as JPEG", pageName)
+			return path, ""
+		}
+		data = buf.Bytes()
+	case "webp":
+		// Go has no WebP encoder, Chrome encodes it
+		err := chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			data, err = page.CaptureScreenshot().
+				WithFormat(page.CaptureScreenshotFormatWebp).
+				WithQuality(int64(quality)).
+				Do(ctx)
+			return err
+		}))
+		if err != nil {
+			e.log("⚠️ Failed to take WebP screenshot of %s: %v", pageName, err)
+			return path, ""
+		}
+	}
+	stop := e.bench.Start("disk_writes")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		e.log("⚠️ Failed to write screenshot of %s: %v", pageName, err)
+	}
+	stop()
+	if format != "png" && e.config.GetBool("explorer.screenshots.lossless") {
+		if _, err := e.writeArtifact(filepath.Join("screenshots", "original"), name+".png", original); err != nil {
+			e.log("⚠️ Failed to write original screenshot of %s: %v", pageName, err)
+		}
+	}
+
+	if !e.config.GetBool("explorer.screenshots.thumbnails.enabled") {
+		return path, ""
+	}
+	if img == nil {
+		img = decodeScreenshot(original)
+	}
+	if img == nil {
+		return path, ""
+	}
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, scaleToWidth(img, e.config.GetInt("explorer.screenshots.thumbnails.width")), &jpeg.Options{Quality: quality}); err != nil {
+		return path, ""
+	}
+	thumbPath, err := e.writeArtifact(filepath.Join("screenshots", "thumbs"), name+".jpg", thumb.Bytes())
+	if err != nil {
+		e.log("⚠️ Failed to write thumbnail of %s: %v", pageName, err)
+		return path, ""
+	}
+	return path, thumbPath
+}
+
+// pageKey is the file name stem shared by the artifacts of a capture, the
+// name of its screenshot without the extension of the format.
+func pageKey(item NavigationItem) string {
+	base := filepath.Base(item.Screenshot)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func decodeScreenshot(data []byte) image.Image {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return img
+}
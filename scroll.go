@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// scrollerJS finds what scrolls the page: the document, or else the largest
+// scrollable element (apps with a fixed shell scroll an inner container),
+// which it marks with data-explorer-scroller. It returns null when nothing
+// is taller than its viewport.
+const scrollerJS = `
+(function() {` + domKeyJS + `
+	document.querySelectorAll('[data-explorer-scroller]').forEach(el => el.removeAttribute('data-explorer-scroller'));
+	const root = document.scrollingElement || document.documentElement;
+	if (root.scrollHeight > window.innerHeight + 1) {
+		return {key: '', x: 0, y: 0, width: window.innerWidth, height: window.innerHeight, scrollHeight: root.scrollHeight, innerWidth: window.innerWidth};
+	}
+	let best = null;
+	for (const el of document.body.querySelectorAll('*')) {
+		if (el.clientHeight < 100 || el.scrollHeight <= el.clientHeight + 1) continue;
+		const overflow = getComputedStyle(el).overflowY;
+		if (overflow !== 'auto' && overflow !== 'scroll' && overflow !== 'overlay') continue;
+		if (!best || el.clientWidth * el.clientHeight > best.clientWidth * best.clientHeight) best = el;
+	}
+	if (!best) return null;
+	best.setAttribute('data-explorer-scroller', '');
+	const rect = best.getBoundingClientRect();
+	return {key: keyOf(best), x: rect.x + best.clientLeft, y: rect.y + best.clientTop, width: best.clientWidth, height: best.clientHeight,
+		scrollHeight: best.scrollHeight, innerWidth: window.innerWidth};
+})()
+`
+
+// scrollToJS scrolls the marked scroller, or the window, to %d.
+const scrollToJS = `
+(function(y) {
+	const scroller = document.querySelector('[data-explorer-scroller]');
+	if (scroller) scroller.scrollTop = y; else window.scrollTo(0, y);
+})(%d)
+`
+
+// scrollStateJS returns the scroll position and the viewport tops of the
+// elements that may not move with the content: fixed and sticky ones,
+// transformed ones (parallax libraries) and fixed backgrounds.
+const scrollStateJS = `
+(function() {` + domKeyJS + `
+	const scroller = document.querySelector('[data-explorer-scroller]');
+	const root = scroller || document.scrollingElement || document.documentElement;
+	const elements = {};
+	let n = 0;
+	for (const el of (scroller || document.body).querySelectorAll('*')) {
+		if (n >= 300) break;
+		const style = getComputedStyle(el);
+		const position = style.position === 'fixed' || style.position === 'sticky' ? style.position : '';
+		const transformed = style.transform !== 'none' || style.willChange.includes('transform');
+		const fixedBackground = style.backgroundAttachment === 'fixed';
+		if (!position && !transformed && !fixedBackground) continue;
+		const rect = el.getBoundingClientRect();
+		if (rect.width < 20 || rect.height < 20 || style.display === 'none' || style.visibility === 'hidden') continue;
+		n++;
+		const cls = typeof el.className === 'string' ? el.className.trim().split(/\s+/).slice(0, 2).join('.') : '';
+		const text = (el.getAttribute('aria-label') || el.textContent || '').trim().replace(/\s+/g, ' ').substring(0, 40);
+		elements[keyOf(el)] = {
+			label: el.tagName.toLowerCase() + (cls ? '.' + cls : '') + (text ? ' "' + text + '"' : ''),
+			top: rect.top,
+			position: position,
+			fixedBackground: fixedBackground,
+			visible: rect.bottom > 0 && rect.top < window.innerHeight,
+		};
+	}
+	return {scrollTop: root.scrollTop, scrollHeight: root.scrollHeight, elements: elements};
+})()
+`
+
+type scrollElement struct {
+	Label           string  `json:"label"`
+	Top             float64 `json:"top"`
+	Position        string  `json:"position"`
+	FixedBackground bool    `json:"fixedBackground"`
+	Visible         bool    `json:"visible"`
+}
+
+type scrollState struct {
+	ScrollTop    float64                  `json:"scrollTop"`
+	ScrollHeight float64                  `json:"scrollHeight"`
+	Elements     map[string]scrollElement `json:"elements"`
+}
+
+// scrollFrame is the viewport at one scroll step. Pinned are the elements
+// that stayed in place since the step before, like sticky headers.
+type scrollFrame struct {
+	Step         int      `json:"step"`
+	ScrollTop    float64  `json:"scroll_top"`
+	ScrollHeight float64  `json:"scroll_height"`
+	Screenshot   string   `json:"screenshot"`
+	Pinned       []string `json:"pinned,omitempty"`
+}
+
+// parallaxElement moves at Rate times the scroll speed, or keeps its
+// background in place.
+type parallaxElement struct {
+	Label      string  `json:"label"`
+	Rate       float64 `json:"rate,omitempty"`
+	Background bool    `json:"fixed_background,omitempty"`
+}
+
+// pageScroll is scroll/<page>/scroll.json.
+type pageScroll struct {
+	Page          string            `json:"page"`
+	Scroller      string            `json:"scroller"` // DOM path, "" for the document
+	Viewport      float64           `json:"viewport_height"`
+	InitialHeight float64           `json:"initial_height"`
+	FinalHeight   float64           `json:"final_height"`
+	LazyLoaded    bool              `json:"lazy_loaded"` // the page grew while scrolling
+	FullPage      string            `json:"full_page,omitempty"`
+	Frames        []scrollFrame     `json:"frames"`
+	Pinned        []string          `json:"pinned"`
+	Parallax      []parallaxElement `json:"parallax"`
+}
+
+// captureScroll screenshots pages taller than their viewport at each scroll
+// step of explorer.scroll.step viewport heights, waiting for lazy content
+// after each, and stitches the full page: Chrome renders it when the
+// document scrolls, the steps are pasted together when an inner container
+// does. Elements that stay in place (sticky headers) or move at another
+// speed than the content (parallax) are recorded in
+// scroll/<page>/scroll.json. The page is scrolled back to the top.
+func (e *AgicapExplorer) captureScroll(pageName string) string {
+	var scroller *struct {
+		Key          string  `json:"key"`
+		X            float64 `json:"x"`
+		Y            float64 `json:"y"`
+		Width        float64 `json:"width"`
+		Height       float64 `json:"height"`
+		ScrollHeight float64 `json:"scrollHeight"`
+		InnerWidth   float64 `json:"innerWidth"`
+	}
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(scrollerJS, &scroller)); err != nil || scroller == nil {
+		return ""
+	}
+	dir := filepath.Join("scroll", sanitize(pageName))
+	step := e.config.GetFloat64("explorer.scroll.step")
+	if step <= 0 || step > 1 {
+		step = 1
+	}
+	maxSteps := e.config.GetInt("explorer.scroll.max_steps")
+	wait := e.config.GetDuration("explorer.scroll.wait")
+
+	result := pageScroll{Page: pageName, Scroller: scroller.Key, Viewport: scroller.Height, InitialHeight: scroller.ScrollHeight, Pinned: []string{}, Parallax: []parallaxElement{}}
+	var states []scrollState
+	var shots []image.Image
+	pinned := make(map[string]bool)
+	y := 0.0
+	for i := 0; i < maxSteps; i++ {
+		var state scrollState
+		var shot []byte
+		err := chromedp.Run(e.ctx,
+			chromedp.Evaluate(fmt.Sprintf(scrollToJS, int(y)), nil),
+			chromedp.Sleep(wait),
+			e.settle("capture"),
+			chromedp.Evaluate(scrollStateJS, &state),
+			chromedp.CaptureScreenshot(&shot),
+		)
+		if err != nil {
+			e.log("⚠️ Scroll step %d failed for %s: %v", i, pageName, err)
+			break
+		}
+		frame := scrollFrame{Step: i, ScrollTop: state.ScrollTop, ScrollHeight: state.ScrollHeight}
+		if path, err := e.writeArtifact(dir, fmt.Sprintf("%02d.png", i), shot); err == nil {
+			frame.Screenshot, _ = filepath.Rel(e.outputDir, path)
+			frame.Screenshot = filepath.ToSlash(frame.Screenshot)
+		}
+		if len(states) > 0 {
+			frame.Pinned = pinnedElements(states[len(states)-1], state)
+			for _, label := range frame.Pinned {
+				if !pinned[label] {
+					pinned[label] = true
+					result.Pinned = append(result.Pinned, label)
+				}
+			}
+		}
+		result.Frames = append(result.Frames, frame)
+		states = append(states, state)
+		shots = append(shots, decodeScreenshot(shot))
+		result.FinalHeight = state.ScrollHeight
+		if state.ScrollTop+scroller.Height >= state.ScrollHeight-1 {
+			break
+		}
+		y = state.ScrollTop + math.Max(1, math.Floor(scroller.Height*step))
+	}
+	result.LazyLoaded = result.FinalHeight > result.InitialHeight+1
+	result.Parallax = parallaxElements(states)
+
+	var full []byte
+	if scroller.Key == "" {
+		chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(scrollToJS, 0), nil), chromedp.FullScreenshot(&full, 100))
+	} else {
+		chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(scrollToJS, 0), nil))
+		full = stitchScroll(shots, states, image.Rect(int(scroller.X), int(scroller.Y), int(scroller.X+scroller.Width), int(scroller.Y+scroller.Height)), scroller.InnerWidth)
+	}
+	if len(full) > 0 {
+		if path, err := e.writeArtifact(dir, "full.png", full); err == nil {
+			result.FullPage, _ = filepath.Rel(e.outputDir, path)
+			result.FullPage = filepath.ToSlash(result.FullPage)
+		}
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact(dir, "scroll.json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write the scroll series of %s: %v", pageName, err)
+		return ""
+	}
+	e.log("📜 %s: %d scroll steps, %d pinned and %d parallax elements", pageName, len(result.Frames), len(result.Pinned), len(result.Parallax))
+	return path
+}
+
+// pinnedElements lists the visible elements that kept their viewport
+// position while the content scrolled between two states.
+func pinnedElements(before, after scrollState) []string {
+	if math.Abs(after.ScrollTop-before.ScrollTop) < 1 {
+		return nil
+	}
+	var keys []string
+	for key, el := range after.Elements {
+		if prev, ok := before.Elements[key]; ok && el.Visible && math.Abs(el.Top-prev.Top) < 1 {
+			keys = append(keys, key)
+		}
+	}
+	return topmost(keys, after.Elements)
+}
+
+// parallaxElements finds the elements that moved at a speed other than the
+// content's in every step they were in, and those with a fixed background.
+func parallaxElements(states []scrollState) []parallaxElement {
+	rates := make(map[string][]float64)
+	for i := 1; i < len(states); i++ {
+		scrolled := states[i].ScrollTop - states[i-1].ScrollTop
+		if math.Abs(scrolled) < 1 {
+			continue
+		}
+		for key, el := range states[i].Elements {
+			if prev, ok := states[i-1].Elements[key]; ok {
+				rates[key] = append(rates[key], (prev.Top-el.Top)/scrolled)
+			}
+		}
+	}
+	labels := make(map[string]scrollElement)
+	for _, s := range states {
+		for key, el := range s.Elements {
+			labels[key] = el
+		}
+	}
+	var keys []string
+	for key, el := range labels {
+		if el.FixedBackground {
+			keys = append(keys, key)
+			continue
+		}
+		moving := len(rates[key]) > 0
+		for _, r := range rates[key] {
+			// 0 is pinned, 1 moves with the content
+			if math.Abs(r) < 0.02 || math.Abs(r-1) < 0.02 {
+				moving = false
+			}
+		}
+		if moving {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	parallax := []parallaxElement{}
+	for _, key := range keys {
+		el := labels[key]
+		p := parallaxElement{Label: el.Label, Background: el.FixedBackground}
+		if r := rates[key]; len(r) > 0 && !el.FixedBackground {
+			sum := 0.0
+			for _, v := range r {
+				sum += v
+			}
+			p.Rate = math.Round(sum/float64(len(r))*100) / 100
+		}
+		parallax = append(parallax, p)
+	}
+	return parallax
+}
+
+// topmost returns the labels of the keys without an ancestor among them,
+// in document order.
+func topmost(keys []string, elements map[string]scrollElement) []string {
+	sort.Strings(keys)
+	var labels []string
+	var last string
+	for _, key := range keys {
+		if last != "" && strings.HasPrefix(key, last+" > ") {
+			continue
+		}
+		last = key
+		labels = append(labels, elements[key].Label)
+	}
+	return labels
+}
+
+// stitchScroll pastes the part of each screenshot inside the scroller at
+// its scroll position, for inner containers Chrome cannot capture whole.
+// Screenshots are in device pixels, the rectangle in CSS pixels of a window
+// innerWidth wide.
+func stitchScroll(shots []image.Image, states []scrollState, viewport image.Rectangle, innerWidth float64) []byte {
+	if len(shots) == 0 || shots[0] == nil || len(states) != len(shots) || innerWidth <= 0 {
+		return nil
+	}
+	var height float64
+	for _, s := range states {
+		height = math.Max(height, s.ScrollHeight)
+	}
+	scale := float64(shots[0].Bounds().Dx()) / innerWidth
+	px := func(v int) int { return int(math.Round(float64(v) * scale)) }
+	crop := image.Rect(px(viewport.Min.X), px(viewport.Min.Y), px(viewport.Max.X), px(viewport.Max.Y))
+	canvas := image.NewRGBA(image.Rect(0, 0, crop.Dx(), int(height*scale)))
+	for i, shot := range shots {
+		if shot == nil {
+			continue
+		}
+		at := image.Pt(0, int(states[i].ScrollTop*scale))
+		draw.Draw(canvas, crop.Sub(crop.Min).Add(at), shot, crop.Min, draw.Src)
+	}
+	var buf bytes.Buffer
+	if png.Encode(&buf, canvas) != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
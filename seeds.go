@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// sitemapDoc covers both <urlset> and <sitemapindex> documents.
+type sitemapDoc struct {
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// maxSitemapFiles bounds how many nested sitemaps of a sitemap index are read.
+const maxSitemapFiles = 20
+
+// seedURLs gathers explicit start URLs from the config list, the seed file and
+// the app's sitemap.xml, resolved against the login URL.
+func (e *AgicapExplorer) seedURLs() []string {
+	base, _ := url.Parse(e.config.GetString("explorer.login_url"))
+	var seeds []string
+
+	add := func(raw string) {
+		raw = strings.TrimSpace(raw)
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			return
+		}
+		ref, err := url.Parse(raw)
+		if err != nil {
+			e.log("⚠️ Ignoring invalid seed URL %q: %v", raw, err)
+			return
+		}
+		if base != nil {
+			ref = base.ResolveReference(ref)
+		}
+		seeds = append(seeds, ref.String())
+	}
+
+	for _, s := range e.config.GetStringSlice("explorer.seeds.urls") {
+		add(s)
+	}
+
+	if path := e.config.GetString("explorer.seeds.file"); path != "" {
+		lines, err := readLines(path)
+		if err != nil {
+			e.log("⚠️ Failed to read seed file: %v", err)
+		}
+		for _, line := range lines {
+			add(line)
+		}
+	}
+
+	if sitemap := e.config.GetString("explorer.seeds.sitemap"); sitemap != "" {
+		if sitemap == "true" {
+			sitemap = "/sitemap.xml"
+		}
+		if sitemap != "false" {
+			for _, loc := range e.fetchSitemap(sitemap) {
+				add(loc)
+			}
+		}
+	}
+
+	return seeds
+}
+
+// fetchSitemap downloads a sitemap through the browser so the authenticated
+// session is used, following one level of sitemap indexes.
+func (e *AgicapExplorer) fetchSitemap(sitemapURL string) []string {
+	var locs []string
+	pending := []string{sitemapURL}
+
+	for fetched := 0; len(pending) > 0 && fetched < maxSitemapFiles; fetched++ {
+		current := pending[0]
+		pending = pending[1:]
+
+		body, err := e.fetchText(current)
+		if err != nil {
+			e.log("⚠️ Failed to fetch sitemap %s: %v", current, err)
+			continue
+		}
+
+		var doc sitemapDoc
+		if err := xml.Unmarshal([]byte(body), &doc); err != nil {
+			e.log("⚠️ Failed to parse sitemap %s: %v", current, err)
+			continue
+		}
+		for _, u := range doc.URLs {
+			locs = append(locs, u.Loc)
+		}
+		for _, s := range doc.Sitemaps {
+			pending = append(pending, s.Loc)
+		}
+	}
+
+	e.log("🗺️ Sitemap listed %d URLs", len(locs))
+	return locs
+}
+
+// fetchText requests a URL from inside the page context and returns the body.
+func (e *AgicapExplorer) fetchText(target string) (string, error) {
+	var result struct {
+		Status int    `json:"status"`
+		Body   string `json:"body"`
+	}
+	script := fmt.Sprintf(`fetch(%q, {credentials: 'include'}).then(r => r.text().then(body => ({status: r.status, body: body})))`, target)
+	err := chromedp.Run(e.ctx,
+		chromedp.Evaluate(script, &result, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+	)
+	if err != nil {
+		return "", err
+	}
+	if result.Status >= 400 {
+		return "", fmt.Errorf("HTTP %d", result.Status)
+	}
+	return result.Body, nil
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
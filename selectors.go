@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// stableSelectorJS defines selectorsOf(el): the selectors that match only
+// el, most stable first. Test ids survive refactors and restyling, ids and
+// form names usually do; semantic class names change with the design,
+// positions with the layout, and generated ids and hashed CSS-in-JS class
+// names (styled-components, emotion, JSS, CSS modules) with every build.
+// Needs domKeyJS.
+const stableSelectorJS = `
+	const selectorsOf = (el) => {
+		const tag = el.tagName.toLowerCase();
+		const quote = (v) => '"' + v.replace(/\\/g, '\\\\').replace(/"/g, '\\"') + '"';
+		const generatedID = (id) => /\d{3,}|^[:_]|[0-9a-f]{8,}|^(radix|headlessui|react-aria|mui|rc)-/i.test(id);
+		const hashed = (c) => /^(css|sc|jss|emotion|styled|makeStyles|jsx)-/i.test(c) || /__[\w-]{5,}$/.test(c) ||
+			/(^|[-_])(?=[a-zA-Z0-9]*\d)(?=[a-zA-Z0-9]*[a-zA-Z])[a-zA-Z0-9]{5,}($|[-_])/.test(c) ||
+			(/^[a-zA-Z]{5,8}$/.test(c) && /[a-z][A-Z][a-z]*[A-Z]/.test(c));
+		const candidates = [];
+		const add = (selector, kind) => {
+			try {
+				if (document.querySelectorAll(selector).length === 1) candidates.push({selector: selector, kind: kind});
+			} catch (e) {}
+		};
+		for (const name of ['data-testid', 'data-test-id', 'data-test', 'data-cy', 'data-qa']) {
+			const value = el.getAttribute(name);
+			if (value) add('[' + name + '=' + quote(value) + ']', 'test-id');
+		}
+		if (el.id && !generatedID(el.id)) add('#' + CSS.escape(el.id), 'id');
+		const name = el.getAttribute('name');
+		if (name) add(tag + '[name=' + quote(name) + ']', 'name');
+		const label = el.getAttribute('aria-label');
+		if (label) add(tag + '[aria-label=' + quote(label) + ']', 'aria-label');
+		const classes = (typeof el.className === 'string' ? el.className : el.getAttribute('class') || '').split(/\s+/).filter(Boolean);
+		const semantic = classes.filter(c => !hashed(c));
+		if (semantic.length) add(tag + semantic.map(c => '.' + CSS.escape(c)).join(''), 'class');
+		const path = keyOf(el);
+		add(path.split(' > ')[0].includes('#') ? path : 'body > ' + path, 'path');
+		if (el.id && generatedID(el.id)) add('#' + CSS.escape(el.id), 'generated-id');
+		const hashedClasses = classes.filter(hashed);
+		if (hashedClasses.length) add(tag + hashedClasses.map(c => '.' + CSS.escape(c)).join(''), 'hashed-class');
+		return candidates;
+	};`
+
+// selectorKindScores rate how likely a kind of selector survives a
+// redeploy, in the order selectorsOf lists them.
+var selectorKindScores = map[string]float64{
+	"test-id":      1.0,
+	"id":           0.9,
+	"name":         0.8,
+	"aria-label":   0.7,
+	"class":        0.5,
+	"path":         0.3,
+	"generated-id": 0.15,
+	"hashed-class": 0.1,
+}
+
+// selectorCandidate is a selector matching only its component. Score is
+// the kind's score, times the share of runs it matched in for
+// selector_stability.json.
+type selectorCandidate struct {
+	Selector  string  `json:"selector"`
+	Kind      string  `json:"kind"`
+	Score     float64 `json:"score"`
+	Stability float64 `json:"stability,omitempty"`
+}
+
+// scoreSelectors sets the scores of the candidates by kind and returns the
+// best selector, "" when there is none.
+func scoreSelectors(candidates []selectorCandidate) string {
+	for i := range candidates {
+		candidates[i].Score = selectorKindScores[candidates[i].Kind]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[0].Selector
+}
+
+// runSelectors scores the selectors of the components captured in several
+// runs by how consistently they matched, and recommends the most stable one
+// per component in selector_stability.json:
+//
+//	explorer selectors [-out file] runA/ runB/ ...
+//	explorer selectors [-out file] runs/
+func runSelectors(args []string) error {
+	fs := flag.NewFlagSet("selectors", flag.ContinueOnError)
+	out := fs.String("out", "selector_stability.json", "report file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	dirs := fs.Args()
+	if len(dirs) == 1 {
+		dirs = nil
+		for _, run := range historyRuns(fs.Arg(0)) {
+			dirs = append(dirs, run.Dir)
+		}
+	}
+	if len(dirs) < 2 {
+		return fmt.Errorf("usage: selectors [-out file] <runA> <runB> ... or a directory of at least 2 runs")
+	}
+
+	report, err := selectorStability(dirs)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*out, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("🎯 Selector stability of %d components over %d runs written to %s\n", len(report.Components), len(dirs), *out)
+	for _, k := range report.Kinds {
+		fmt.Printf("  %-13s %5d selectors, %3.0f%% kept across runs\n", k.Kind, k.Selectors, k.Stability*100)
+	}
+	return nil
+}
+
+// selectorReport is selector_stability.json.
+type selectorReport struct {
+	Runs       []string             `json:"runs"`
+	Kinds      []selectorKind       `json:"kinds"`
+	Components []componentStability `json:"components"`
+}
+
+// selectorKind is how often selectors of a kind still matched their
+// component in the later runs.
+type selectorKind struct {
+	Kind      string  `json:"kind"`
+	Selectors int     `json:"selectors"`
+	Stability float64 `json:"stability"`
+}
+
+// componentStability is a component seen in Runs of the runs, with its
+// candidates scored and the best one recommended.
+type componentStability struct {
+	Page        string              `json:"page"`
+	Type        string              `json:"type"`
+	Text        string              `json:"text,omitempty"`
+	Runs        int                 `json:"runs"`
+	Recommended selectorCandidate   `json:"recommended"`
+	Candidates  []selectorCandidate `json:"candidates"`
+
+	seen map[string]int // runs each candidate matched in
+	kind map[string]string
+}
+
+// selectorStability matches the components of the runs page by page: a
+// component of a later run is the one of an earlier run that shares a
+// selector other than its position, or else has the same type and text.
+func selectorStability(dirs []string) (*selectorReport, error) {
+	report := &selectorReport{Runs: dirs}
+	byPage := make(map[string][]*componentStability)
+	var all []*componentStability
+	for _, dir := range dirs {
+		items, err := loadNavigationMap(dir)
+		if err != nil {
+			return nil, err
+		}
+		done := make(map[string]bool)
+		for _, item := range items {
+			page := item.CanonicalURL
+			if page == "" {
+				page = item.URL
+			}
+			if done[page] {
+				continue
+			}
+			done[page] = true
+			data, err := ioutil.ReadFile(filepath.Join(dir, "components", pageKey(item)+"_analysis.json"))
+			if err != nil {
+				continue
+			}
+			var analysis pageAnalysis
+			if json.Unmarshal(data, &analysis) != nil {
+				continue
+			}
+			matched := make(map[*componentStability]bool)
+			for _, c := range analysis.Components {
+				if len(c.Selectors) == 0 {
+					continue
+				}
+				tracked := matchComponent(byPage[page], c, matched)
+				if tracked == nil {
+					tracked = &componentStability{Page: page, Type: c.Type, Text: c.Text, seen: map[string]int{}, kind: map[string]string{}}
+					byPage[page] = append(byPage[page], tracked)
+					all = append(all, tracked)
+				}
+				matched[tracked] = true
+				tracked.Runs++
+				for _, s := range c.Selectors {
+					tracked.seen[s.Selector]++
+					tracked.kind[s.Selector] = s.Kind
+				}
+			}
+		}
+	}
+
+	kinds := make(map[string]*selectorKind)
+	kept := make(map[string]float64)
+	for _, c := range all {
+		for selector, n := range c.seen {
+			kind := c.kind[selector]
+			stability := float64(n) / float64(c.Runs)
+			c.Candidates = append(c.Candidates, selectorCandidate{
+				Selector:  selector,
+				Kind:      kind,
+				Stability: stability,
+				Score:     selectorKindScores[kind] * stability,
+			})
+			if c.Runs > 1 {
+				if kinds[kind] == nil {
+					kinds[kind] = &selectorKind{Kind: kind}
+				}
+				kinds[kind].Selectors++
+				kept[kind] += stability
+			}
+		}
+		sort.Slice(c.Candidates, func(i, j int) bool {
+			if c.Candidates[i].Score != c.Candidates[j].Score {
+				return c.Candidates[i].Score > c.Candidates[j].Score
+			}
+			return c.Candidates[i].Selector < c.Candidates[j].Selector
+		})
+		c.Recommended = c.Candidates[0]
+		report.Components = append(report.Components, *c)
+	}
+	for name, k := range kinds {
+		k.Stability = kept[name] / float64(k.Selectors)
+		report.Kinds = append(report.Kinds, *k)
+	}
+	sort.Slice(report.Kinds, func(i, j int) bool {
+		return selectorKindScores[report.Kinds[i].Kind] > selectorKindScores[report.Kinds[j].Kind]
+	})
+	// Components without a reliable selector first
+	sort.SliceStable(report.Components, func(i, j int) bool {
+		return report.Components[i].Recommended.Score < report.Components[j].Recommended.Score
+	})
+	return report, nil
+}
+
+// matchComponent finds the tracked component of a page that c is, skipping
+// the ones already matched in this run.
+func matchComponent(tracked []*componentStability, c componentInfo, matched map[*componentStability]bool) *componentStability {
+	for _, t := range tracked {
+		if matched[t] {
+			continue
+		}
+		for _, s := range c.Selectors {
+			if s.Kind != "path" && t.seen[s.Selector] > 0 {
+				return t
+			}
+		}
+	}
+	text := strings.TrimSpace(c.Text)
+	if text == "" {
+		return nil
+	}
+	for _, t := range tracked {
+		if !matched[t] && t.Type == c.Type && strings.TrimSpace(t.Text) == text {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+)
+
+// sitePage is a captured screen on the static site, read back from the
+// run's artifacts.
+type sitePage struct {
+	NavigationItem
+	Index      int
+	Key        string
+	Image      string
+	Thumb      string
+	Search     string
+	Components []componentInfo
+	Console    []consoleMessage
+	Calls      []siteCall
+	Prev, Next *sitePage
+}
+
+// siteCall is an XHR or fetch request from the page's HAR file.
+type siteCall struct {
+	Method   string
+	Status   int64
+	URL      string
+	MimeType string
+	Time     float64
+	Size     float64
+}
+
+type siteIndex struct {
+	Generated time.Time
+	RunID     string
+	Pages     []*sitePage
+	Sections  []string
+	JSErrors  int
+	Calls     int
+}
+
+// runSite is the `site` subcommand: it builds the static site of a
+// finished run.
+func runSite(args []string) error {
+	fs := flag.NewFlagSet("site", flag.ContinueOnError)
+	out := fs.String("out", "", "output directory (default <run>/site)")
+	locale := fs.String("locale", "en", "language of the site: "+strings.Join(supportedLocales(), ", "))
+	templateDir := fs.String("templates", "", "directory with *.tmpl files overriding the built-in templates")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: site [-out dir] [-locale lang] [-templates dir] <run>")
+	}
+	templates, err := loadTemplates(*locale, *templateDir)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = filepath.Join(fs.Arg(0), "site")
+	}
+	var runID string
+	if data, err := ioutil.ReadFile(filepath.Join(fs.Arg(0), "run.json")); err == nil {
+		var manifest runManifest
+		if json.Unmarshal(data, &manifest) == nil {
+			runID = manifest.RunID
+		}
+	}
+	n, err := writeSite(fs.Arg(0), *out, runID, templates)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🌐 Wrote %d pages to %s\n", n, *out)
+	return nil
+}
+
+// writeSite renders a static site for the run: index.html with a card per
+// screen and pages/<page>.html with the screenshot, components, console
+// errors and API calls of each, linked in capture order. Screenshots are
+// copied to assets/ so the directory can be published on its own.
+func writeSite(runDir, out, runID string, templates *reportTemplates) (int, error) {
+	items, err := loadNavigationMap(runDir)
+	if err != nil {
+		return 0, err
+	}
+	for _, dir := range []string{"pages", "assets", "assets/thumbs"} {
+		if err := os.MkdirAll(filepath.Join(out, dir), 0755); err != nil {
+			return 0, err
+		}
+	}
+
+	index := siteIndex{Generated: time.Now(), RunID: runID}
+	sections := make(map[string]bool)
+	for i, item := range items {
+		page := loadSitePage(runDir, item)
+		page.Index = i + 1
+		copies := map[string]string{
+			page.Image: filepath.Join(runDir, "screenshots", filepath.Base(item.Screenshot)),
+		}
+		if page.Thumb != "" {
+			copies[page.Thumb] = filepath.Join(runDir, "screenshots", "thumbs", filepath.Base(item.Thumbnail))
+		}
+		for dst, src := range copies {
+			if shot, err := ioutil.ReadFile(src); err == nil {
+				if err := ioutil.WriteFile(filepath.Join(out, filepath.FromSlash(dst)), shot, 0644); err != nil {
+					return 0, err
+				}
+			}
+		}
+		if n := len(index.Pages); n > 0 {
+			page.Prev, index.Pages[n-1].Next = index.Pages[n-1], page
+		}
+		index.Pages = append(index.Pages, page)
+		index.JSErrors += page.JSErrors
+		index.Calls += len(page.Calls)
+		if page.Section != "" {
+			sections[page.Section] = true
+		}
+	}
+	index.Sections = sortedSetKeys(sections)
+
+	for _, page := range index.Pages {
+		if err := templates.render(filepath.Join(out, "pages", page.Key+".html"), "site-page", page); err != nil {
+			return 0, err
+		}
+	}
+	return len(index.Pages), templates.render(filepath.Join(out, "index.html"), "site-index", index)
+}
+
+// loadSitePage reads the artifacts of a screen, found by the name of its
+// screenshot.
+func loadSitePage(runDir string, item NavigationItem) *sitePage {
+	key := pageKey(item)
+	page := &sitePage{
+		NavigationItem: item,
+		Key:            key,
+		Image:          "assets/" + filepath.Base(item.Screenshot),
+		Search:         strings.ToLower(strings.Join([]string{item.Title, item.URL, item.CanonicalURL, item.Section}, " ")),
+	}
+	if item.Thumbnail != "" {
+		page.Thumb = "assets/thumbs/" + filepath.Base(item.Thumbnail)
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(runDir, "components", key+"_analysis.json")); err == nil {
+		var analysis pageAnalysis
+		if json.Unmarshal(data, &analysis) == nil {
+			page.Components = analysis.Components
+		}
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(runDir, "console", key+".json")); err == nil {
+		var messages []consoleMessage
+		if json.Unmarshal(data, &messages) == nil {
+			for _, m := range messages {
+				if m.isJSError() || m.Level == "error" || m.Level == "warning" {
+					page.Console = append(page.Console, m)
+				}
+			}
+		}
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(runDir, "har", key+".har")); err == nil {
+		var doc har.HAR
+		if json.Unmarshal(data, &doc) == nil && doc.Log != nil {
+			for _, entry := range doc.Log.Entries {
+				kind := network.ResourceType(entry.Comment)
+				if (kind != network.ResourceTypeXHR && kind != network.ResourceTypeFetch) || entry.Request == nil || entry.Response == nil {
+					continue
+				}
+				call := siteCall{Method: entry.Request.Method, URL: entry.Request.URL, Status: entry.Response.Status, Time: entry.Time}
+				if entry.Response.Content != nil {
+					call.MimeType = entry.Response.Content.MimeType
+					call.Size = float64(entry.Response.Content.Size) / 1024
+				}
+				page.Calls = append(page.Calls, call)
+			}
+		}
+	}
+	return page
+}
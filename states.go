@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/chromedp"
+)
+
+// componentState is how a component looks in one interaction state.
+type componentState struct {
+	CSS        map[string]string `json:"css"`
+	Changed    []string          `json:"changed,omitempty"`
+	Screenshot string            `json:"screenshot,omitempty"`
+}
+
+// stateStyleScript reads the computed styles that typically differ between
+// interaction states of the element tagged with the given explorer id.
+const stateStyleScript = `
+(function(id) {
+	const el = document.querySelector('[data-explorer-id="' + id + '"]');
+	if (!el) return null;
+	const s = window.getComputedStyle(el);
+	return {
+		backgroundColor: s.backgroundColor,
+		color: s.color,
+		fontSize: s.fontSize,
+		fontFamily: s.fontFamily,
+		fontWeight: s.fontWeight,
+		padding: s.padding,
+		margin: s.margin,
+		border: s.border,
+		borderRadius: s.borderRadius,
+		boxShadow: s.boxShadow,
+		display: s.display,
+		width: s.width,
+		height: s.height,
+		position: s.position,
+		zIndex: s.zIndex,
+		outline: s.outline,
+		opacity: s.opacity,
+		transform: s.transform,
+		textDecoration: s.textDecorationLine,
+		cursor: s.cursor
+	};
+})(%q)
+`
+
+// attributeStateScript puts the element tagged with the given explorer id
+// into the disabled or error state by setting attributes (disabled,
+// aria-disabled, aria-invalid and a custom validity message for :invalid),
+// or restores the original attributes when on is false.
+const attributeStateScript = `
+(function(id, state, on) {
+	const el = document.querySelector('[data-explorer-id="' + id + '"]');
+	if (!el) return false;
+	const saved = window.__explorerStates = window.__explorerStates || {};
+	const key = id + ':' + state;
+	const attrs = state === 'disabled' ? ['disabled', 'aria-disabled'] : ['aria-invalid'];
+	if (on) {
+		saved[key] = attrs.map(a => el.getAttribute(a));
+		if (state === 'disabled') {
+			if ('disabled' in el) el.disabled = true;
+			el.setAttribute('aria-disabled', 'true');
+		} else {
+			el.setAttribute('aria-invalid', 'true');
+			if (el.setCustomValidity) el.setCustomValidity('Invalid');
+		}
+	} else if (saved[key]) {
+		attrs.forEach((a, i) => saved[key][i] === null ? el.removeAttribute(a) : el.setAttribute(a, saved[key][i]));
+		if (state === 'error' && el.setCustomValidity) el.setCustomValidity('');
+		delete saved[key];
+	}
+	return true;
+})(%q, %q, %t)
+`
+
+// attributeStates are set through attributes rather than forced
+// pseudo-classes.
+var attributeStates = map[string]bool{"disabled": true, "error": true}
+
+// captureInteractionStates forces :hover, :focus and :active (via
+// CSS.forcePseudoState, so no real clicks happen) and sets the disabled and
+// error states on interactive components, recording the computed styles and
+// a screenshot per state next to the default ones.
+func (e *AgicapExplorer) captureInteractionStates(pageName string, analysis *pageAnalysis) {
+	if !e.config.GetBool("explorer.capture.states") {
+		return
+	}
+	kinds := e.config.GetStringSlice("explorer.capture.state_kinds")
+	states := e.config.GetStringSlice("explorer.capture.state_list")
+	limit := e.config.GetInt("explorer.capture.max_state_components")
+
+	done := make(map[string]bool)
+	for i := range analysis.Components {
+		c := &analysis.Components[i]
+		if done[c.ID] || len(done) >= limit || c.Position.Width < 4 || !matchesKind(c.Type, kinds) {
+			continue
+		}
+		done[c.ID] = true
+
+		selector := fmt.Sprintf(`[data-explorer-id="%s"]`, c.ID)
+		var nodes []cdp.NodeID
+		if err := chromedp.Run(e.ctx, chromedp.NodeIDs(selector, &nodes, chromedp.ByQuery, chromedp.AtLeast(0))); err != nil || len(nodes) == 0 {
+			continue
+		}
+
+		var base map[string]string
+		chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(stateStyleScript, c.ID), &base))
+
+		c.States = map[string]componentState{"default": {CSS: base, Screenshot: c.Screenshot}}
+		for _, state := range states {
+			st := e.forceState(nodes[0], selector, c.ID, state)
+			st.Changed = changedProperties(base, st.CSS)
+
+			var buf []byte
+			if err := chromedp.Run(e.ctx, chromedp.Screenshot(selector, &buf, chromedp.ByQuery, chromedp.AtLeast(0))); err == nil {
+				name := fmt.Sprintf("%s_%s_%s.png", c.ID, sanitize(c.Type), state)
+				if path, err := e.writeArtifact(filepath.Join("components", sanitize(pageName)), name, buf); err == nil {
+					st.Screenshot = path
+				}
+			}
+			c.States[state] = st
+
+			// Reset before the next state
+			if attributeStates[state] {
+				var ok bool
+				chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(attributeStateScript, c.ID, state, false), &ok))
+				continue
+			}
+			chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+				return css.ForcePseudoState(nodes[0], []string{}).Do(ctx)
+			}))
+		}
+	}
+
+	if len(done) > 0 {
+		e.log("🎛️ Captured %d interaction states for %d components on %s", len(states), len(done), pageName)
+	}
+}
+
+// forceState applies a pseudo-class or attribute state to the node and
+// reads its styles.
+func (e *AgicapExplorer) forceState(node cdp.NodeID, selector, id, state string) componentState {
+	var styles map[string]string
+	if attributeStates[state] {
+		var ok bool
+		chromedp.Run(e.ctx,
+			chromedp.Evaluate(fmt.Sprintf(attributeStateScript, id, state, true), &ok),
+			chromedp.Evaluate(fmt.Sprintf(stateStyleScript, id), &styles),
+		)
+		return componentState{CSS: styles}
+	}
+	chromedp.Run(e.ctx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return css.ForcePseudoState(node, []string{state}).Do(ctx)
+		}),
+		chromedp.Evaluate(fmt.Sprintf(stateStyleScript, id), &styles),
+	)
+	return componentState{CSS: styles}
+}
+
+// changedProperties lists the properties whose value differs from base.
+func changedProperties(base, state map[string]string) []string {
+	var changed []string
+	for _, prop := range sortedKeys(state) {
+		if base[prop] != state[prop] {
+			changed = append(changed, prop)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/cdproto/har"
+)
+
+// sqliteSchema is the layout of the SQLite database. Paths are relative to
+// the run directory; the blob columns are only filled with -blobs.
+const sqliteSchema = `
+CREATE TABLE meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT
+);
+CREATE TABLE pages (
+	id             INTEGER PRIMARY KEY,
+	key            TEXT UNIQUE NOT NULL,
+	url            TEXT NOT NULL,
+	canonical_url  TEXT,
+	route_template TEXT,
+	title          TEXT,
+	section        TEXT,
+	depth          INTEGER,
+	js_errors      INTEGER,
+	content_hash   TEXT,
+	captured_at    TEXT,
+	performance    TEXT,
+	screenshot     TEXT,
+	screenshot_img BLOB,
+	html           TEXT,
+	html_source    BLOB
+);
+CREATE TABLE links (
+	source         TEXT NOT NULL,
+	target         TEXT NOT NULL,
+	text           TEXT,
+	source_page_id INTEGER REFERENCES pages(id),
+	target_page_id INTEGER REFERENCES pages(id)
+);
+CREATE TABLE components (
+	id             INTEGER PRIMARY KEY,
+	page_id        INTEGER NOT NULL REFERENCES pages(id),
+	element_id     TEXT,
+	type           TEXT,
+	selector       TEXT,
+	text           TEXT,
+	x              REAL,
+	y              REAL,
+	width          REAL,
+	height         REAL,
+	css            TEXT,
+	attributes     TEXT,
+	html           TEXT,
+	screenshot     TEXT,
+	screenshot_png BLOB
+);
+CREATE TABLE component_styles (
+	component_id INTEGER NOT NULL REFERENCES components(id),
+	property     TEXT NOT NULL,
+	value        TEXT,
+	hex          TEXT
+);
+CREATE TABLE tokens (
+	path     TEXT PRIMARY KEY,
+	category TEXT,
+	value    TEXT
+);
+CREATE TABLE requests (
+	id            INTEGER PRIMARY KEY,
+	page_id       INTEGER NOT NULL REFERENCES pages(id),
+	started_at    TEXT,
+	method        TEXT,
+	url           TEXT,
+	resource_type TEXT,
+	status        INTEGER,
+	mime_type     TEXT,
+	size_bytes    INTEGER,
+	time_ms       REAL,
+	failure       TEXT
+);
+CREATE INDEX components_type ON components(type);
+CREATE INDEX component_styles_value ON component_styles(property, value);
+CREATE INDEX component_styles_hex ON component_styles(hex);
+CREATE INDEX requests_url ON requests(url);
+CREATE INDEX links_target ON links(target);
+`
+
+// sqliteDriver returns the name of a registered SQLite driver. None is
+// compiled in by default; build with -tags sqlite to link the one pinned
+// in go.mod.
+func sqliteDriver() (string, error) {
+	for _, name := range sql.Drivers() {
+		if name == "sqlite" || name == "sqlite3" {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("this binary has no SQLite driver; build with -tags sqlite")
+}
+
+// runSQLite is the `sqlite` subcommand: it loads a finished run into a
+// database.
+//
+//	explorer sqlite [-out file] [-blobs] <run>
+func runSQLite(args []string) error {
+	fs := flag.NewFlagSet("sqlite", flag.ContinueOnError)
+	out := fs.String("out", "", "database file (default <run>/explorer.db)")
+	blobs := fs.Bool("blobs", false, "store screenshots and HTML in the database instead of referencing the files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: sqlite [-out file] [-blobs] <run>")
+	}
+	if *out == "" {
+		*out = filepath.Join(fs.Arg(0), "explorer.db")
+	}
+	stats, err := writeSQLite(fs.Arg(0), *out, "", *blobs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🗄️ %s: %s\n", *out, stats)
+	return nil
+}
+
+// sqliteStats counts the rows written per table.
+type sqliteStats map[string]int
+
+func (s sqliteStats) String() string {
+	var parts []string
+	for _, table := range []string{"pages", "links", "components", "component_styles", "tokens", "requests"} {
+		parts = append(parts, fmt.Sprintf("%d %s", s[table], table))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeSQLite (re)creates the database at path from the artifacts of a run
+// directory: navigation_map.json, graph.json, components/, design_system.json
+// and har/.
+func writeSQLite(runDir, path, runID string, blobs bool) (sqliteStats, error) {
+	driver, err := sqliteDriver()
+	if err != nil {
+		return nil, err
+	}
+	items, err := loadNavigationMap(runDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("creating tables: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	w := &sqliteWriter{tx: tx, runDir: runDir, blobs: blobs, stats: sqliteStats{}, pageIDs: make(map[string]int64)}
+	w.exec(`INSERT INTO meta (key, value) VALUES ('run_id', ?), ('tool', ?), ('version', ?)`, runID, toolName, version())
+	for _, item := range items {
+		w.page(item)
+	}
+	w.links()
+	w.tokens()
+	if w.err != nil {
+		tx.Rollback()
+		return nil, w.err
+	}
+	return w.stats, tx.Commit()
+}
+
+// sqliteWriter inserts the rows of one run, keeping the first error.
+type sqliteWriter struct {
+	tx      *sql.Tx
+	runDir  string
+	blobs   bool
+	stats   sqliteStats
+	pageIDs map[string]int64 // by URL and canonical URL, for links
+	err     error
+}
+
+func (w *sqliteWriter) exec(query string, args ...interface{}) int64 {
+	if w.err != nil {
+		return 0
+	}
+	result, err := w.tx.Exec(query, args...)
+	if err != nil {
+		w.err = fmt.Errorf("%s: %w", strings.Fields(query)[2], err)
+		return 0
+	}
+	id, _ := result.LastInsertId()
+	return id
+}
+
+// file returns the run-relative path of an artifact if it exists, and its
+// content when blobs are stored.
+func (w *sqliteWriter) file(rel string) (interface{}, interface{}) {
+	data, err := ioutil.ReadFile(filepath.Join(w.runDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, nil
+	}
+	if !w.blobs {
+		return rel, nil
+	}
+	return rel, data
+}
+
+func (w *sqliteWriter) page(item NavigationItem) {
+	key := pageKey(item)
+	var perf interface{}
+	if item.Performance != nil {
+		data, _ := json.Marshal(item.Performance)
+		perf = string(data)
+	}
+	shot, shotData := w.file("screenshots/" + filepath.Base(item.Screenshot))
+	html, htmlData := w.file("html/" + key + ".html")
+	id := w.exec(`INSERT INTO pages (key, url, canonical_url, route_template, title, section, depth, js_errors, content_hash, captured_at, performance, screenshot, screenshot_img, html, html_source)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key, item.URL, item.CanonicalURL, item.Route, item.Title, item.Section, item.Depth, item.JSErrors, item.ContentHash, item.Timestamp, perf, shot, shotData, html, htmlData)
+	if w.err != nil {
+		return
+	}
+	w.stats["pages"]++
+	w.pageIDs[item.URL] = id
+	if item.CanonicalURL != "" {
+		w.pageIDs[item.CanonicalURL] = id
+	}
+	w.components(id, key)
+	w.requests(id, key)
+}
+
+func (w *sqliteWriter) components(pageID int64, key string) {
+	data, err := ioutil.ReadFile(filepath.Join(w.runDir, "components", key+"_analysis.json"))
+	if err != nil {
+		return
+	}
+	var analysis pageAnalysis
+	if json.Unmarshal(data, &analysis) != nil {
+		return
+	}
+	for _, c := range analysis.Components {
+		css, _ := json.Marshal(c.CSS)
+		attributes, _ := json.Marshal(c.Attributes)
+		var shot, shotData interface{}
+		if c.Screenshot != "" {
+			shot, shotData = w.file("components/" + key + "/" + filepath.Base(c.Screenshot))
+		}
+		id := w.exec(`INSERT INTO components (page_id, element_id, type, selector, text, x, y, width, height, css, attributes, html, screenshot, screenshot_png)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			pageID, c.ID, c.Type, c.Selector, c.Text, c.Position.X, c.Position.Y, c.Position.Width, c.Position.Height, string(css), string(attributes), c.HTML, shot, shotData)
+		if w.err != nil {
+			return
+		}
+		w.stats["components"]++
+
+		// One row per computed property, with colors also as hex so they
+		// can be matched against the palette
+		for _, property := range sortedKeys(c.CSS) {
+			var hex interface{}
+			if h, _, ok := parseCSSColor(c.CSS[property]); ok {
+				hex = h
+			}
+			w.exec(`INSERT INTO component_styles (component_id, property, value, hex) VALUES (?, ?, ?, ?)`, id, property, c.CSS[property], hex)
+			w.stats["component_styles"]++
+		}
+	}
+}
+
+func (w *sqliteWriter) requests(pageID int64, key string) {
+	data, err := ioutil.ReadFile(filepath.Join(w.runDir, "har", key+".har"))
+	if err != nil {
+		return
+	}
+	var doc har.HAR
+	if json.Unmarshal(data, &doc) != nil || doc.Log == nil {
+		return
+	}
+	for _, entry := range doc.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		var status, size interface{}
+		var mimeType, failure string
+		if resp := entry.Response; resp != nil {
+			status = resp.Status
+			failure = resp.Comment
+			if resp.Content != nil {
+				mimeType = resp.Content.MimeType
+				size = resp.Content.Size
+			}
+		}
+		w.exec(`INSERT INTO requests (page_id, started_at, method, url, resource_type, status, mime_type, size_bytes, time_ms, failure)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			pageID, entry.StartedDateTime, entry.Request.Method, entry.Request.URL, entry.Comment, status, mimeType, size, entry.Time, failure)
+		w.stats["requests"]++
+	}
+}
+
+// links reads the edges of graph.json and resolves both ends to captured
+// pages where possible.
+func (w *sqliteWriter) links() {
+	data, err := ioutil.ReadFile(filepath.Join(w.runDir, "graph.json"))
+	if err != nil {
+		return
+	}
+	var graph struct {
+		Edges []graphEdge `json:"edges"`
+	}
+	if json.Unmarshal(data, &graph) != nil {
+		return
+	}
+	pageID := func(u string) interface{} {
+		if id, ok := w.pageIDs[u]; ok {
+			return id
+		}
+		return nil
+	}
+	for _, edge := range graph.Edges {
+		w.exec(`INSERT INTO links (source, target, text, source_page_id, target_page_id) VALUES (?, ?, ?, ?, ?)`,
+			edge.Source, edge.Target, edge.Text, pageID(edge.Source), pageID(edge.Target))
+		w.stats["links"]++
+	}
+}
+
+// tokens flattens design_system.json into dotted paths; the first segment
+// is the category (colors, palette, typography, ...).
+func (w *sqliteWriter) tokens() {
+	data, err := ioutil.ReadFile(filepath.Join(w.runDir, "design_system.json"))
+	if err != nil {
+		return
+	}
+	var system map[string]interface{}
+	if json.Unmarshal(data, &system) != nil {
+		return
+	}
+	delete(system, "$schema")
+	delete(system, "schema_version")
+	flat := make(map[string]string)
+	flattenJSON("", system, flat)
+	paths := make([]string, 0, len(flat))
+	for path := range flat {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		w.exec(`INSERT INTO tokens (path, category, value) VALUES (?, ?, ?)`, path, strings.SplitN(path, ".", 2)[0], flat[path])
+		w.stats["tokens"]++
+	}
+}
+
+// flattenJSON is flattenTokens with array elements addressed by index,
+// e.g. palette.0.hex.
+func flattenJSON(prefix string, value interface{}, flat map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenJSON(join(key), child, flat)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenJSON(join(fmt.Sprint(i)), child, flat)
+		}
+	case nil:
+	default:
+		flat[prefix] = fmt.Sprint(v)
+	}
+}
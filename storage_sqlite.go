@@ -0,0 +1,9 @@
+//go:build sqlite
+
+package main
+
+// Links the pure-Go SQLite driver pinned in go.mod for the optional
+// database backend:
+//
+//	go build -tags sqlite
+import _ "modernc.org/sqlite"
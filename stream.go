@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// streamFile receives every navigation item as soon as it is captured, so
+// the pages of a run that crashes before GenerateReport are not lost.
+const streamFile = "navigation_map.ndjson"
+
+// streamRecord is a line of navigation_map.ndjson. A later record for the
+// same index (with the content hash or an alias added) replaces the earlier
+// one.
+type streamRecord struct {
+	Index int `json:"index"`
+	NavigationItem
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// openStream starts an empty navigation_map.ndjson for the run.
+func (e *AgicapExplorer) openStream() error {
+	f, err := os.Create(filepath.Join(e.outputDir, streamFile))
+	if err != nil {
+		return err
+	}
+	e.stream = f
+	return nil
+}
+
+// streamItem appends navigation item i to the stream. The caller holds
+// e.mu; e is the shared explorer.
+func (e *AgicapExplorer) streamItem(i int) {
+	if e.stream == nil {
+		return
+	}
+	item := e.navigationMap[i]
+	data, err := json.Marshal(streamRecord{Index: i, NavigationItem: item, FirstError: item.firstError})
+	if err == nil {
+		_, err = e.stream.Write(append(data, '\n'))
+	}
+	if err != nil {
+		e.log("⚠️ Failed to append to %s: %v", streamFile, err)
+	}
+}
+
+// loadNavigationStream reads navigation_map.ndjson back into the navigation
+// map. A line cut short by a crash is skipped.
+func loadNavigationStream(runDir string) ([]NavigationItem, error) {
+	f, err := os.Open(filepath.Join(runDir, streamFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []NavigationItem
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		var record streamRecord
+		if json.Unmarshal(scanner.Bytes(), &record) != nil || record.Index < 0 {
+			continue
+		}
+		for len(items) <= record.Index {
+			items = append(items, NavigationItem{})
+		}
+		record.NavigationItem.firstError = record.FirstError
+		items[record.Index] = record.NavigationItem
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	pages := make([]NavigationItem, 0, len(items))
+	for _, item := range items {
+		if item.URL != "" {
+			pages = append(pages, item)
+		}
+	}
+	return pages, nil
+}
+
+// streamIsNewer reports whether a run directory's navigation_map.ndjson was
+// written after its navigation_map.json, as when the last run into it
+// crashed before writing the report.
+func streamIsNewer(runDir string) bool {
+	stream, err := os.Stat(filepath.Join(runDir, streamFile))
+	if err != nil {
+		return false
+	}
+	final, err := os.Stat(filepath.Join(runDir, "navigation_map.json"))
+	return err != nil || stream.ModTime().After(final.ModTime())
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/chromedp"
+)
+
+var (
+	cssCommentPattern    = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	mediaWidthPattern    = regexp.MustCompile(`\(\s*(min|max)-width\s*:\s*([\d.]+)(px|em|rem)\s*\)`)
+	keyframesNamePattern = regexp.MustCompile(`^@(?:-[a-z]+-)?keyframes\s+(\S+)`)
+)
+
+// breakpoint is a media query seen in the harvested stylesheets.
+type breakpoint struct {
+	Query    string   `json:"query"`
+	MinWidth float64  `json:"min_width,omitempty"`
+	MaxWidth float64  `json:"max_width,omitempty"`
+	Rules    int      `json:"rules"`
+	Sheets   []string `json:"sheets"`
+}
+
+// stylesheetHarvester reads the text of every stylesheet the pages load
+// through the CDP CSS domain (linked, inline and constructed sheets alike)
+// and collects :root custom properties, @keyframes and media-query
+// breakpoints.
+type stylesheetHarvester struct {
+	mu          sync.Mutex
+	enabled     bool
+	pending     map[context.Context]map[css.StyleSheetID]*css.StyleSheetHeader // by tab
+	seen        map[string]bool
+	sheets      int
+	variables   map[string]string
+	varOrder    []string
+	keyframes   map[string]string
+	frameOrder  []string
+	breakpoints map[string]*breakpoint
+}
+
+func newStylesheetHarvester(enabled bool) *stylesheetHarvester {
+	return &stylesheetHarvester{
+		enabled:     enabled,
+		pending:     make(map[context.Context]map[css.StyleSheetID]*css.StyleSheetHeader),
+		seen:        make(map[string]bool),
+		variables:   make(map[string]string),
+		keyframes:   make(map[string]string),
+		breakpoints: make(map[string]*breakpoint),
+	}
+}
+
+// Listen tracks the stylesheets added to the tab; each tab of the pool
+// harvests its own.
+func (h *stylesheetHarvester) Listen(ctx context.Context) {
+	if !h.enabled {
+		return
+	}
+	h.mu.Lock()
+	h.pending[ctx] = make(map[css.StyleSheetID]*css.StyleSheetHeader)
+	h.mu.Unlock()
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		switch ev := ev.(type) {
+		case *css.EventStyleSheetAdded:
+			h.pending[ctx][ev.Header.StyleSheetID] = ev.Header
+		case *css.EventStyleSheetRemoved:
+			delete(h.pending[ctx], ev.StyleSheetID)
+		}
+	})
+}
+
+// harvestStylesheets parses the stylesheets added since the last capture and
+// saves each distinct one under styles/sheets/.
+func (e *AgicapExplorer) harvestStylesheets(pageName string) {
+	h := e.stylesheets
+	if !h.enabled {
+		return
+	}
+	h.mu.Lock()
+	headers := make([]*css.StyleSheetHeader, 0, len(h.pending[e.ctx]))
+	for _, header := range h.pending[e.ctx] {
+		headers = append(headers, header)
+	}
+	h.pending[e.ctx] = make(map[css.StyleSheetID]*css.StyleSheetHeader)
+	h.mu.Unlock()
+
+	added := 0
+	for _, header := range headers {
+		var text string
+		err := chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			text, err = css.GetStyleSheetText(header.StyleSheetID).Do(ctx)
+			return err
+		}))
+		if err != nil || strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		sum := sha1.Sum([]byte(text))
+		digest := hex.EncodeToString(sum[:])[:10]
+		h.mu.Lock()
+		duplicate := h.seen[digest]
+		h.seen[digest] = true
+		h.mu.Unlock()
+		if duplicate {
+			continue
+		}
+
+		name := "inline"
+		if header.SourceURL != "" && !header.IsInline {
+			if base := path.Base(strings.SplitN(header.SourceURL, "?", 2)[0]); base != "/" && base != "." {
+				name = strings.TrimSuffix(base, ".css")
+			}
+		}
+		file := digest + "_" + sanitize(name) + ".css"
+		if _, err := e.writeArtifact(filepath.Join("styles", "sheets"), file, []byte(text)); err != nil {
+			e.log("⚠️ Failed to save stylesheet %s: %v", header.SourceURL, err)
+		}
+		h.parse(text, file)
+		added++
+	}
+	if added > 0 {
+		e.log("🎨 Harvested %d stylesheets on %s", added, pageName)
+	}
+}
+
+// parse walks the top-level blocks of a stylesheet.
+func (h *stylesheetHarvester) parse(text, sheet string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sheets++
+	h.walk(cssCommentPattern.ReplaceAllString(text, ""), sheet, "")
+}
+
+func (h *stylesheetHarvester) walk(text, sheet, media string) {
+	for _, block := range cssBlocks(text) {
+		prelude, body := block[0], block[1]
+		lower := strings.ToLower(prelude)
+		switch {
+		case keyframesNamePattern.MatchString(lower):
+			name := keyframesNamePattern.FindStringSubmatch(prelude)[1]
+			if _, ok := h.keyframes[name]; !ok {
+				h.frameOrder = append(h.frameOrder, name)
+			}
+			h.keyframes[name] = prelude + " {" + body + "}"
+		case strings.HasPrefix(lower, "@media"):
+			query := strings.TrimSpace(prelude[len("@media"):])
+			if mediaWidthPattern.MatchString(query) {
+				bp, ok := h.breakpoints[query]
+				if !ok {
+					bp = &breakpoint{Query: query}
+					for _, m := range mediaWidthPattern.FindAllStringSubmatch(query, -1) {
+						width, _ := strconv.ParseFloat(m[2], 64)
+						if m[3] != "px" {
+							width *= 16
+						}
+						if m[1] == "min" {
+							bp.MinWidth = width
+						} else {
+							bp.MaxWidth = width
+						}
+					}
+					h.breakpoints[query] = bp
+				}
+				bp.Rules += len(cssBlocks(body))
+				bp.Sheets = appendUnique(bp.Sheets, sheet)
+			}
+			h.walk(body, sheet, query)
+		case strings.HasPrefix(lower, "@supports"), strings.HasPrefix(lower, "@layer"), strings.HasPrefix(lower, "@container"):
+			h.walk(body, sheet, media)
+		case media == "" && isRootSelector(prelude):
+			for _, decl := range cssDeclarations(body) {
+				name, value, ok := strings.Cut(decl, ":")
+				name = strings.TrimSpace(name)
+				if !ok || !strings.HasPrefix(name, "--") {
+					continue
+				}
+				if _, exists := h.variables[name]; !exists {
+					h.varOrder = append(h.varOrder, name)
+				}
+				h.variables[name] = strings.TrimSpace(value)
+			}
+		}
+	}
+}
+
+// isRootSelector reports whether a selector list targets the document root.
+func isRootSelector(selectors string) bool {
+	for _, s := range strings.Split(selectors, ",") {
+		if s = strings.TrimSpace(s); s == ":root" || s == "html" {
+			return true
+		}
+	}
+	return false
+}
+
+// cssBlocks splits CSS into (prelude, body) pairs for each top-level block,
+// skipping statements such as @import and @charset.
+func cssBlocks(text string) [][2]string {
+	var blocks [][2]string
+	depth, start, bodyStart := 0, 0, 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ';' && depth == 0:
+			start = i + 1
+		case c == '{':
+			if depth == 0 {
+				bodyStart = i + 1
+			}
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				blocks = append(blocks, [2]string{strings.TrimSpace(text[start : bodyStart-1]), text[bodyStart:i]})
+				start = i + 1
+			}
+			if depth < 0 {
+				depth = 0
+				start = i + 1
+			}
+		}
+	}
+	return blocks
+}
+
+// cssDeclarations splits a declaration block at semicolons outside strings
+// and parentheses, so values like url(data:...;base64,...) stay intact.
+func cssDeclarations(body string) []string {
+	var decls []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ';' && depth == 0:
+			decls = append(decls, body[start:i])
+			start = i + 1
+		}
+	}
+	return append(decls, body[start:])
+}
+
+// writeStylesheets writes styles/extracted.css with the consolidated
+// custom properties and keyframes, and breakpoints.json.
+func (e *AgicapExplorer) writeStylesheets() error {
+	h := e.stylesheets
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	breakpoints := make([]*breakpoint, 0, len(h.breakpoints))
+	for _, bp := range h.breakpoints {
+		breakpoints = append(breakpoints, bp)
+	}
+	sort.Slice(breakpoints, func(i, j int) bool {
+		wi, wj := math.Max(breakpoints[i].MinWidth, breakpoints[i].MaxWidth), math.Max(breakpoints[j].MinWidth, breakpoints[j].MaxWidth)
+		if wi != wj {
+			return wi < wj
+		}
+		return breakpoints[i].Query < breakpoints[j].Query
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "/* Extracted by agicap-explorer on %s from %d stylesheets */\n\n", time.Now().Format("2006-01-02 15:04:05"), h.sheets)
+	if len(h.varOrder) > 0 {
+		b.WriteString(":root {\n")
+		for _, name := range h.varOrder {
+			fmt.Fprintf(&b, "  %s: %s;\n", name, h.variables[name])
+		}
+		b.WriteString("}\n\n")
+	}
+	for _, name := range h.frameOrder {
+		b.WriteString(h.keyframes[name])
+		b.WriteString("\n\n")
+	}
+	if len(breakpoints) > 0 {
+		b.WriteString("/* Breakpoints (see breakpoints.json)\n")
+		for _, bp := range breakpoints {
+			fmt.Fprintf(&b, " *   @media %s  (%d rules)\n", bp.Query, bp.Rules)
+		}
+		b.WriteString(" */\n")
+	}
+
+	dir := filepath.Join(e.outputDir, "styles")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "extracted.css"), []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(breakpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "breakpoints.json"), data, 0644)
+}
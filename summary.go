@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// summaryData is what the executive summary template renders.
+type summaryData struct {
+	Generated time.Time
+	RunID     string
+	Pages     []reportPage
+	Sections  int
+	JSErrors  int
+	KeyShots  []reportPage
+	Palette   []*colorCluster
+	Fonts     []string
+	Features  []featureTest
+	Passed    int
+}
+
+// writePDFSummary renders summary.html, a few printable pages with the page
+// inventory, the first screenshot of every section, the color palette and
+// the feature test results, and prints it to summary.pdf in a separate tab.
+func (e *AgicapExplorer) writePDFSummary(system orderedTokens) error {
+	data := summaryData{
+		Generated: time.Now(),
+		RunID:     e.runID,
+		Pages:     e.reportPages(),
+	}
+	maxShots := e.config.GetInt("explorer.output.summary.screenshots")
+	sections := make(map[string]bool)
+	for _, p := range data.Pages {
+		data.JSErrors += p.JSErrors
+		if sections[p.Section] {
+			continue
+		}
+		sections[p.Section] = true
+		if len(data.KeyShots) < maxShots {
+			data.KeyShots = append(data.KeyShots, p)
+		}
+	}
+	data.Sections = len(sections)
+
+	data.Palette, _ = system.get("palette").([]*colorCluster)
+	if typography, ok := system.get("typography").(orderedTokens); ok {
+		if families, ok := typography.get("fontFamily").(orderedTokens); ok {
+			for _, f := range families {
+				if name, ok := f.Value.(string); ok {
+					data.Fonts = append(data.Fonts, name)
+				}
+			}
+		}
+	}
+
+	// Written by the functional explorer into the same output directory
+	if features, err := loadFeatureTests(e.outputDir); err == nil {
+		data.Features = features
+		for _, f := range features {
+			if f.Status == "success" {
+				data.Passed++
+			}
+		}
+	}
+
+	htmlPath := filepath.Join(e.outputDir, "summary.html")
+	if err := e.templates.render(htmlPath, "summary", data); err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(htmlPath)
+	if err != nil {
+		return err
+	}
+
+	tab, cancel := chromedp.NewContext(e.ctx)
+	defer cancel()
+	var pdf []byte
+	err = chromedp.Run(tab,
+		chromedp.Navigate("file://"+filepath.ToSlash(abs)),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			pdf, _, err = page.PrintToPDF().
+				WithPrintBackground(true).
+				WithPreferCSSPageSize(true).
+				Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "summary.pdf"), pdf, 0644)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// svelteComponent renders <Name>.svelte with exported props, a class prop
+// for extra classes and the content in the default slot.
+func svelteComponent(m *componentModel) string {
+	var b strings.Builder
+	b.WriteString("<script lang=\"ts\">\n")
+	m.writeHeader(&b, "  ")
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  type Variant = %s;\n\n", m.variantType())
+	fmt.Fprintf(&b, "  export let variant: Variant = '%s';\n", m.Variants[0].Name)
+	for _, p := range m.Props {
+		fmt.Fprintf(&b, "  export let %s: %s | undefined = undefined;\n", p.Name, p.Type)
+	}
+	b.WriteString("  let className = '';\n  export { className as class };\n\n")
+	m.writeVariantClasses(&b, "  ", "Variant")
+	b.WriteString("</script>\n\n")
+
+	var attrs []string
+	for _, a := range m.Fixed {
+		attrs = append(attrs, fmt.Sprintf("%s=\"%s\"", a.Name, html.EscapeString(a.Value)))
+	}
+	for _, p := range m.Props {
+		attrs = append(attrs, fmt.Sprintf("%s={%s}", p.Attribute, p.Name))
+	}
+	attrs = append(attrs, `class="{variantClasses[variant]} {className}"`)
+
+	if m.Void {
+		fmt.Fprintf(&b, "<%s\n  %s\n/>\n", m.Tag, strings.Join(attrs, "\n  "))
+	} else {
+		fmt.Fprintf(&b, "<%s\n  %s\n>\n  <slot />\n</%s>\n", m.Tag, strings.Join(attrs, "\n  "), m.Tag)
+	}
+	return b.String()
+}
+
+func svelteExport(m *componentModel) string {
+	return fmt.Sprintf("export { default as %s } from './%s.svelte';\n", m.Name, m.Name)
+}
+
+// svelteStories leaves out the content: Svelte slots can't be set from args.
+func svelteStories(m *componentModel) string {
+	return componentStories(m, "@storybook/svelte", fmt.Sprintf("import %s from './%s.svelte';", m.Name, m.Name), "")
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+// dataTablesJS lists up to %d visible data tables (tables, grids and
+// ARIA tables with at least two rows) and the controls around them: the
+// headers that sort (aria-sort, a button inside, a sort class or a pointer
+// cursor), the next and previous page buttons of a pagination in the table
+// or up to three ancestors above it, and its filter fields and buttons.
+const dataTablesJS = `
+(function(max) {` + domKeyJS + stableSelectorJS + `
+	const selectorOf = (el) => (selectorsOf(el)[0] || {}).selector || '';
+	const textOf = (el) => (el.getAttribute('aria-label') || el.textContent || el.getAttribute('placeholder') || '').trim().replace(/\s+/g, ' ').substring(0, 60);
+	const visible = (el) => { const r = el.getBoundingClientRect(); return r.width > 0 && r.height > 0; };
+	const tables = [];
+	for (const table of document.querySelectorAll('table, [role="grid"], [role="treegrid"], [role="table"]')) {
+		if (tables.length >= max) break;
+		if (!visible(table) || table.parentElement.closest('table, [role="grid"], [role="treegrid"], [role="table"]')) continue;
+		const rows = table.matches('table') ? table.querySelectorAll('tbody tr') : table.querySelectorAll('[role="row"]:not(:has([role="columnheader"]))');
+		if (rows.length < 2) continue;
+		const headers = Array.from(table.querySelectorAll('th, [role="columnheader"]')).filter(visible);
+		const sortable = headers.filter(th => th.hasAttribute('aria-sort') || th.querySelector('button, [role="button"]') ||
+			/sort/i.test(th.className + ' ' + (th.getAttribute('title') || '')) || getComputedStyle(th).cursor === 'pointer');
+
+		let container = table;
+		for (let i = 0; i < 3 && container.parentElement && container.parentElement !== document.body; i++) container = container.parentElement;
+		// The previous button is usually disabled on the first page
+		const find = (patterns, disabled) => Array.from(container.querySelectorAll('button, a[href], [role="button"]')).find(el =>
+			visible(el) && (disabled || (!el.disabled && el.getAttribute('aria-disabled') !== 'true')) &&
+			patterns.test([el.getAttribute('aria-label'), el.getAttribute('title'), el.getAttribute('rel'), el.className, el.textContent].join(' ').trim()));
+		const next = find(/\bnext\b|weiter|nächste|^\s*(›|»|>)\s*$/i, false);
+		const previous = find(/\bprev(ious)?\b|zurück|vorherige|^\s*(‹|«|<)\s*$/i, true);
+		const filters = Array.from(container.querySelectorAll('input[type="search"], input[type="text"], select, [class*="filter" i] input, th input, th select, [role="columnheader"] input, button[aria-label*="filter" i], button[title*="filter" i]'))
+			.filter(visible).slice(0, 5);
+
+		tables.push({
+			selector: selectorOf(table),
+			kind: table.getAttribute('role') || 'table',
+			columns: headers.map(textOf),
+			rows: rows.length,
+			sortable: sortable.map(th => ({selector: selectorOf(th.querySelector('button, [role="button"]') || th), text: textOf(th)})).filter(c => c.selector),
+			next: next ? selectorOf(next) : '',
+			previous: previous ? selectorOf(previous) : '',
+			filters: filters.map(f => ({selector: selectorOf(f), text: textOf(f) || f.getAttribute('name') || '', kind: f.tagName === 'SELECT' ? 'select' : f.tagName === 'BUTTON' ? 'button' : 'input'})).filter(f => f.selector)
+		});
+	}
+	return tables;
+})(%d)
+`
+
+// tableStateJS reads the table of selector %s as it is now: its row count,
+// the text of its first rows, the first text of a data cell, the aria-sort
+// of its headers and the page indicator of its pagination, if any.
+const tableStateJS = `
+(function(selector) {
+	const table = document.querySelector(selector);
+	if (!table) return null;
+	const rows = Array.from(table.matches('table') ? table.querySelectorAll('tbody tr') : table.querySelectorAll('[role="row"]:not(:has([role="columnheader"]))'));
+	const cellText = (row) => Array.from(row.querySelectorAll('td, [role="gridcell"], [role="cell"]')).map(c => c.textContent.trim().replace(/\s+/g, ' ')).filter(Boolean);
+	const sorted = {};
+	table.querySelectorAll('[aria-sort]').forEach(th => {
+		if (th.getAttribute('aria-sort') !== 'none') sorted[th.textContent.trim().replace(/\s+/g, ' ').substring(0, 60)] = th.getAttribute('aria-sort');
+	});
+	let container = table;
+	for (let i = 0; i < 3 && container.parentElement && container.parentElement !== document.body; i++) container = container.parentElement;
+	const current = container.querySelector('[aria-current="page"], .pagination .active, [class*="pagination" i] [class*="active" i], [class*="pagination" i] [class*="selected" i]');
+	const term = rows.map(cellText).flat().find(t => /[a-zA-ZäöüÄÖÜ]{3,}/.test(t) && !/^\d/.test(t)) || '';
+	return {
+		rows: rows.length,
+		first: rows.slice(0, 3).map(r => cellText(r).join(' | ').substring(0, 200)),
+		term: term.split(/\s+/)[0].substring(0, 20),
+		sorted: sorted,
+		page: current ? current.textContent.trim() : ''
+	};
+})(%s)
+`
+
+type tableControl struct {
+	Selector string `json:"selector"`
+	Text     string `json:"text"`
+	Kind     string `json:"kind,omitempty"`
+}
+
+type dataTable struct {
+	Selector string         `json:"selector"`
+	Kind     string         `json:"kind"`
+	Columns  []string       `json:"columns"`
+	Rows     int            `json:"rows"`
+	Sortable []tableControl `json:"sortable"`
+	Next     string         `json:"next"`
+	Previous string         `json:"previous"`
+	Filters  []tableControl `json:"filters"`
+}
+
+type tableState struct {
+	Rows   int               `json:"rows"`
+	First  []string          `json:"first"`
+	Term   string            `json:"term"`
+	Sorted map[string]string `json:"sorted"`
+	Page   string            `json:"page"`
+}
+
+// tableInteraction is the table after one interaction. Changed tells
+// whether its rows differ from before, so interactions that do nothing
+// (client-side sorting of a single page, a disabled next button) show up.
+type tableInteraction struct {
+	Action     string            `json:"action"` // sort, next_page, previous_page, filter
+	Control    string            `json:"control"`
+	Text       string            `json:"text,omitempty"`
+	Value      string            `json:"value,omitempty"` // the filter term
+	Rows       int               `json:"rows"`
+	First      []string          `json:"first_rows,omitempty"`
+	Sorted     map[string]string `json:"sorted,omitempty"` // aria-sort by header
+	Page       string            `json:"page,omitempty"`
+	Changed    bool              `json:"changed"`
+	Screenshot string            `json:"screenshot,omitempty"`
+}
+
+// tableCapture is a data table with the interactions it offers and how it
+// looked after each of them.
+type tableCapture struct {
+	Selector     string             `json:"selector"`
+	Kind         string             `json:"kind"`
+	Columns      []string           `json:"columns"`
+	Rows         int                `json:"rows"`
+	Sortable     []string           `json:"sortable"`
+	Pagination   bool               `json:"pagination"`
+	Filters      []tableControl     `json:"filters"`
+	Screenshot   string             `json:"screenshot,omitempty"`
+	Interactions []tableInteraction `json:"interactions"`
+}
+
+// pageTables is tables/<page>/tables.json.
+type pageTables struct {
+	Page   string         `json:"page"`
+	URL    string         `json:"url"`
+	Tables []tableCapture `json:"tables"`
+}
+
+// exploreTables exercises the data tables of the page: it sorts by up to
+// explorer.tables.max_sorts columns (twice each, for both directions),
+// turns to the next page and back, and types a term taken from the rows
+// into each filter field, screenshotting the table after each step. The
+// page is reloaded between tables so each starts from its default state.
+// Written to tables/<page>/tables.json.
+func (e *AgicapExplorer) exploreTables(pageName string) string {
+	var pageURL string
+	var tables []dataTable
+	err := chromedp.Run(e.ctx,
+		chromedp.Location(&pageURL),
+		chromedp.Evaluate(fmt.Sprintf(dataTablesJS, e.config.GetInt("explorer.tables.max_tables")), &tables),
+	)
+	if err != nil || len(tables) == 0 {
+		return ""
+	}
+	dir := filepath.Join("tables", sanitize(pageName))
+	wait := e.config.GetDuration("explorer.tables.wait")
+	maxSorts := e.config.GetInt("explorer.tables.max_sorts")
+	result := pageTables{Page: pageName, URL: pageURL, Tables: []tableCapture{}}
+
+	for i, t := range tables {
+		if t.Selector == "" {
+			continue
+		}
+		if i > 0 && !e.reloadPage(pageURL) {
+			break
+		}
+		prefix := fmt.Sprintf("%02d", i+1)
+		capture := tableCapture{
+			Selector:     t.Selector,
+			Kind:         t.Kind,
+			Columns:      t.Columns,
+			Rows:         t.Rows,
+			Sortable:     []string{},
+			Pagination:   t.Next != "",
+			Filters:      t.Filters,
+			Screenshot:   e.tableScreenshot(dir, prefix+"_default.png", t.Selector, wait),
+			Interactions: []tableInteraction{},
+		}
+		state := e.tableState(t.Selector)
+		if state == nil {
+			continue
+		}
+		step := func(action string, control tableControl, value string) {
+			after := e.tableState(t.Selector)
+			if after == nil {
+				return
+			}
+			n := len(capture.Interactions) + 1
+			capture.Interactions = append(capture.Interactions, tableInteraction{
+				Action:     action,
+				Control:    control.Selector,
+				Text:       control.Text,
+				Value:      value,
+				Rows:       after.Rows,
+				First:      after.First,
+				Sorted:     after.Sorted,
+				Page:       after.Page,
+				Changed:    after.Rows != state.Rows || strings.Join(after.First, "\n") != strings.Join(state.First, "\n"),
+				Screenshot: e.tableScreenshot(dir, fmt.Sprintf("%s_%02d_%s.png", prefix, n, action), t.Selector, wait),
+			})
+			state = after
+		}
+
+		for j, column := range t.Sortable {
+			capture.Sortable = append(capture.Sortable, column.Text)
+			if j >= maxSorts || !e.allowAction("click", column.Selector, column.Text) {
+				continue
+			}
+			for k := 0; k < 2; k++ {
+				if e.clickWithin(column.Selector, wait) != nil {
+					break
+				}
+				step("sort", column, "")
+			}
+		}
+
+		if t.Next != "" && e.allowAction("click", t.Next, "next") && e.clickWithin(t.Next, wait) == nil {
+			step("next_page", tableControl{Selector: t.Next}, "")
+			if t.Previous != "" && e.clickWithin(t.Previous, wait) == nil {
+				step("previous_page", tableControl{Selector: t.Previous}, "")
+			}
+		}
+
+		for _, filter := range t.Filters {
+			if filter.Kind != "input" || state.Term == "" || !e.allowAction("fill", filter.Selector, filter.Text) {
+				continue
+			}
+			term := state.Term
+			ctx, cancel := context.WithTimeout(e.ctx, wait+5*time.Second)
+			err := chromedp.Run(ctx, chromedp.SendKeys(filter.Selector, term, chromedp.ByQuery))
+			cancel()
+			if err != nil {
+				continue
+			}
+			chromedp.Run(e.ctx, e.settle("interaction"), chromedp.Sleep(wait))
+			step("filter", filter, term)
+			// Backspaces, unlike setting the value, fire the input events apps listen to
+			ctx, cancel = context.WithTimeout(e.ctx, wait+5*time.Second)
+			chromedp.Run(ctx, chromedp.SendKeys(filter.Selector, kb.End+strings.Repeat(kb.Backspace, len([]rune(term))), chromedp.ByQuery))
+			cancel()
+			chromedp.Run(e.ctx, e.settle("interaction"))
+		}
+
+		result.Tables = append(result.Tables, capture)
+		e.log("🧮 %s: table of %d columns, %d sortable, pagination %t, %d filters, %d interactions",
+			pageName, len(t.Columns), len(t.Sortable), capture.Pagination, len(t.Filters), len(capture.Interactions))
+	}
+	if len(result.Tables) == 0 {
+		return ""
+	}
+	// Leave the page as it was captured
+	if len(result.Tables[len(result.Tables)-1].Interactions) > 0 {
+		e.reloadPage(pageURL)
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact(dir, "tables.json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write the tables of %s: %v", pageName, err)
+		return ""
+	}
+	e.setTables(path)
+	return path
+}
+
+// tableState reads the table of selector, nil when it is gone.
+func (e *AgicapExplorer) tableState(selector string) *tableState {
+	var state *tableState
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(fmt.Sprintf(tableStateJS, jsString(selector)), &state)); err != nil {
+		return nil
+	}
+	return state
+}
+
+// tableScreenshot screenshots the table of selector into dir and returns
+// the path relative to the output directory.
+func (e *AgicapExplorer) tableScreenshot(dir, name, selector string, wait time.Duration) string {
+	ctx, cancel := context.WithTimeout(e.ctx, wait+5*time.Second)
+	defer cancel()
+	var buf []byte
+	if err := chromedp.Run(ctx, chromedp.Screenshot(selector, &buf, chromedp.ByQuery)); err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact(dir, name, buf)
+	if err != nil {
+		return ""
+	}
+	rel, _ := filepath.Rel(e.outputDir, path)
+	return filepath.ToSlash(rel)
+}
+
+// setTables records the tables file on the page this tab captured last.
+func (e *AgicapExplorer) setTables(path string) {
+	if e.lastCapture < 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.shared()
+	s.navigationMap[e.lastCapture].Tables = path
+	s.streamItem(e.lastCapture)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// writeTailwindConfig writes tailwind.config.js with the extracted tokens
+// under theme.extend, so the rebuild can start from the captured palette,
+// type scale, spacing, radii, shadows and transitions.
+func (e *AgicapExplorer) writeTailwindConfig(system orderedTokens) error {
+	typography, _ := system.get("typography").(orderedTokens)
+	motion, _ := system.get("motion").(orderedTokens)
+
+	fontFamily := orderedTokens{}
+	families, _ := typography.get("fontFamily").(orderedTokens)
+	for _, f := range families {
+		if stack, ok := f.Value.(string); ok {
+			name := f.Key
+			if name == "primary" {
+				name = "sans"
+			}
+			fontFamily = append(fontFamily, tokenEntry{name, fontFamilyValue(stack)})
+		}
+	}
+
+	extend := orderedTokens{}
+	for _, section := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"colors", system.get("colors")},
+		{"fontFamily", fontFamily},
+		{"fontSize", typography.get("fontSize")},
+		{"fontWeight", typography.get("fontWeight")},
+		{"spacing", system.get("spacing")},
+		{"borderRadius", system.get("borderRadius")},
+		{"boxShadow", system.get("shadows")},
+		{"transitionDuration", motion.get("duration")},
+		{"transitionTimingFunction", motion.get("easing")},
+	} {
+		if tokens, ok := section.value.(orderedTokens); ok && len(tokens) > 0 {
+			extend = append(extend, tokenEntry{section.name, tokens})
+		}
+	}
+
+	data, err := json.MarshalIndent(extend, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	config := fmt.Sprintf(`/** @type {import('tailwindcss').Config} */
+// Generated by agicap-explorer from design_system.json
+module.exports = {
+  content: ['./app/**/*.{js,ts,jsx,tsx,mdx}', './components/**/*.{js,ts,jsx,tsx,mdx}'],
+  theme: {
+    extend: %s,
+  },
+  plugins: [],
+};
+`, data)
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "tailwind.config.js"), []byte(config), 0644)
+}
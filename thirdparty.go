@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// knownThirdParties classifies well-known services by domain suffix.
+var knownThirdParties = map[string][]string{
+	"Analytics":        {"google-analytics.com", "googletagmanager.com", "analytics.google.com", "segment.com", "segment.io", "mixpanel.com", "amplitude.com", "heap.io", "heapanalytics.com", "hotjar.com", "hotjar.io", "fullstory.com", "clarity.ms", "matomo.cloud", "plausible.io", "posthog.com", "pendo.io"},
+	"Advertising":      {"doubleclick.net", "googleadservices.com", "googlesyndication.com", "facebook.net", "facebook.com", "linkedin.com", "licdn.com", "ads-twitter.com", "bing.com"},
+	"CDN":              {"cloudfront.net", "cdnjs.cloudflare.com", "jsdelivr.net", "unpkg.com", "fastly.net", "akamaihd.net", "akamaized.net", "azureedge.net", "imgix.net"},
+	"Fonts":            {"fonts.googleapis.com", "fonts.gstatic.com", "use.typekit.net", "p.typekit.net", "fontawesome.com"},
+	"Support & Chat":   {"intercom.io", "intercomcdn.com", "intercomassets.com", "zendesk.com", "zdassets.com", "hubspot.com", "hs-scripts.com", "hsforms.net", "crisp.chat", "drift.com", "freshdesk.com", "userlike.com"},
+	"Error Monitoring": {"sentry.io", "sentry-cdn.com", "datadoghq.com", "datadoghq.eu", "browser-intake-datadoghq.com", "nr-data.net", "newrelic.com", "bugsnag.com", "logrocket.io", "lr-ingest.io", "rollbar.com"},
+	"Feature Flags":    {"launchdarkly.com", "split.io", "optimizely.com", "growthbook.io", "configcat.com"},
+	"Authentication":   {"auth0.com", "okta.com", "onelogin.com", "accounts.google.com", "login.microsoftonline.com"},
+	"Payments":         {"stripe.com", "stripe.network", "paypal.com", "chargebee.com", "gocardless.com"},
+	"Consent":          {"cookiebot.com", "onetrust.com", "cookielaw.org", "usercentrics.eu", "didomi.io"},
+	"Maps & Media":     {"maps.googleapis.com", "youtube.com", "ytimg.com", "vimeo.com", "vimeocdn.com", "loom.com"},
+}
+
+// thirdParty aggregates the requests made to one external origin.
+type thirdParty struct {
+	Origin   string
+	Category string
+	Requests int
+	Types    map[string]int
+	Pages    []string
+}
+
+// classifyOrigin returns the category of a host, or "Other".
+func classifyOrigin(host string, categories map[string][]string) string {
+	best, bestLen := "Other", 0
+	for category, suffixes := range categories {
+		for _, suffix := range suffixes {
+			if (host == suffix || strings.HasSuffix(host, "."+suffix)) && len(suffix) > bestLen {
+				best, bestLen = category, len(suffix)
+			}
+		}
+	}
+	return best
+}
+
+// registrableDomain approximates the eTLD+1 of a host, treating two-letter
+// country TLDs with a generic second level (co.uk, com.au) as one suffix.
+func registrableDomain(host string) string {
+	labels := strings.Split(strings.ToLower(host), ".")
+	n := 2
+	if len(labels) >= 3 && len(labels[len(labels)-1]) == 2 {
+		switch labels[len(labels)-2] {
+		case "co", "com", "org", "net", "gov", "ac", "edu":
+			n = 3
+		}
+	}
+	if len(labels) <= n {
+		return strings.Join(labels, ".")
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+// firstPartyDomains returns the registrable domains treated as the app itself:
+// the crawl scope's hosts plus explorer.third_parties.first_party.
+func (e *AgicapExplorer) firstPartyDomains() map[string]bool {
+	domains := make(map[string]bool)
+	for host := range e.scope.hosts {
+		domains[registrableDomain(strings.Split(host, ":")[0])] = true
+	}
+	for _, d := range e.config.GetStringSlice("explorer.third_parties.first_party") {
+		domains[registrableDomain(d)] = true
+	}
+	return domains
+}
+
+// thirdParties groups the recorded requests by external origin.
+func (e *AgicapExplorer) thirdParties() []*thirdParty {
+	categories := make(map[string][]string)
+	for category, suffixes := range knownThirdParties {
+		categories[category] = suffixes
+	}
+	// Viper lowercases map keys, so configured names are capitalised again
+	for category, suffixes := range e.config.GetStringMapStringSlice("explorer.third_parties.categories") {
+		if category != "" {
+			category = strings.ToUpper(category[:1]) + category[1:]
+		}
+		categories[category] = append(categories[category], suffixes...)
+	}
+	firstParty := e.firstPartyDomains()
+
+	byOrigin := make(map[string]*thirdParty)
+	for _, hit := range e.network.Hits() {
+		u, err := url.Parse(hit.origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		host := u.Hostname()
+		if firstParty[registrableDomain(host)] {
+			continue
+		}
+		tp, ok := byOrigin[hit.origin]
+		if !ok {
+			tp = &thirdParty{Origin: hit.origin, Category: classifyOrigin(host, categories), Types: make(map[string]int)}
+			byOrigin[hit.origin] = tp
+		}
+		tp.Requests++
+		tp.Types[string(hit.kind)]++
+		if hit.page != "" {
+			tp.Pages = appendUnique(tp.Pages, hit.page)
+		}
+	}
+
+	parties := make([]*thirdParty, 0, len(byOrigin))
+	for _, tp := range byOrigin {
+		parties = append(parties, tp)
+	}
+	sort.Slice(parties, func(i, j int) bool {
+		if parties[i].Category != parties[j].Category {
+			if parties[i].Category == "Other" || parties[j].Category == "Other" {
+				return parties[j].Category == "Other"
+			}
+			return parties[i].Category < parties[j].Category
+		}
+		if parties[i].Requests != parties[j].Requests {
+			return parties[i].Requests > parties[j].Requests
+		}
+		return parties[i].Origin < parties[j].Origin
+	})
+	return parties
+}
+
+// writeThirdPartyReport writes third_parties.md listing every external
+// service the app contacted during the crawl, grouped by category.
+func (e *AgicapExplorer) writeThirdPartyReport() error {
+	parties := e.thirdParties()
+
+	byCategory := make(map[string][]*thirdParty)
+	var categories []string
+	total := 0
+	for _, tp := range parties {
+		if _, ok := byCategory[tp.Category]; !ok {
+			categories = append(categories, tp.Category)
+		}
+		byCategory[tp.Category] = append(byCategory[tp.Category], tp)
+		total += tp.Requests
+	}
+
+	var first []string
+	for d := range e.firstPartyDomains() {
+		first = append(first, d)
+	}
+	sort.Strings(first)
+
+	var b strings.Builder
+	b.WriteString("# 🌍 Third-Party Dependencies\n\n")
+	fmt.Fprintf(&b, "**Generated:** %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "First-party domains: %s\n\n", strings.Join(first, ", "))
+	if len(parties) == 0 {
+		b.WriteString("No third-party origins were contacted.\n")
+	} else {
+		fmt.Fprintf(&b, "%d external origins received %d requests across %d pages.\n\n", len(parties), total, len(e.navigationMap))
+		b.WriteString("## Summary\n\n| Category | Origins | Requests |\n|---|---|---|\n")
+		for _, category := range categories {
+			requests := 0
+			for _, tp := range byCategory[category] {
+				requests += tp.Requests
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d |\n", category, len(byCategory[category]), requests)
+		}
+
+		for _, category := range categories {
+			fmt.Fprintf(&b, "\n## %s\n\n| Origin | Requests | Resource types | Pages |\n|---|---|---|---|\n", category)
+			for _, tp := range byCategory[category] {
+				types := make([]string, 0, len(tp.Types))
+				for kind, n := range tp.Types {
+					if kind == "" {
+						kind = "Other"
+					}
+					types = append(types, fmt.Sprintf("%s (%d)", kind, n))
+				}
+				sort.Strings(types)
+
+				pages := tp.Pages
+				more := ""
+				if len(pages) > 5 {
+					more = fmt.Sprintf(" +%d more", len(pages)-5)
+					pages = pages[:5]
+				}
+				fmt.Fprintf(&b, "| %s | %d | %s | %s%s |\n", tp.Origin, tp.Requests, strings.Join(types, ", "), strings.Join(pages, ", "), more)
+			}
+		}
+	}
+
+	return ioutil.WriteFile(filepath.Join(e.outputDir, "third_parties.md"), []byte(b.String()), 0644)
+}
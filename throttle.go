@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// networkProfile is an emulated connection: latency in milliseconds and
+// throughput in kbit/s.
+type networkProfile struct {
+	Name     string  `json:"name"`
+	Latency  float64 `json:"latency_ms"`
+	Download float64 `json:"download_kbps"`
+	Upload   float64 `json:"upload_kbps"`
+	Offline  bool    `json:"offline,omitempty"`
+}
+
+// networkPresets are the presets of the Chrome DevTools network panel.
+var networkPresets = map[string]networkProfile{
+	"slow_3g": {Latency: 2000, Download: 400, Upload: 400},
+	"fast_3g": {Latency: 562.5, Download: 1440, Upload: 675},
+	"slow_4g": {Latency: 150, Download: 3600, Upload: 2700},
+	"fast_4g": {Latency: 165, Download: 8100, Upload: 1350},
+	"offline": {Offline: true},
+}
+
+// loadNetworkProfiles resolves explorer.throttling.profiles against the
+// presets and the profiles defined in explorer.throttling.custom.
+func loadNetworkProfiles(v *viper.Viper) ([]networkProfile, error) {
+	var custom map[string]networkProfile
+	if err := v.UnmarshalKey("explorer.throttling.custom", &custom); err != nil {
+		return nil, fmt.Errorf("invalid network profiles: %w", err)
+	}
+	var profiles []networkProfile
+	for _, name := range v.GetStringSlice("explorer.throttling.profiles") {
+		p, ok := custom[name]
+		if !ok {
+			if p, ok = networkPresets[name]; !ok {
+				return nil, fmt.Errorf("unknown network profile %q (%s or one of explorer.throttling.custom)", name, presetNames())
+			}
+		}
+		p.Name = name
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+func presetNames() string {
+	names := make([]string, 0, len(networkPresets))
+	for name := range networkPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// apply emulates the connection; the zero profile ends the emulation.
+func (p networkProfile) apply() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if p == (networkProfile{}) {
+			return network.EmulateNetworkConditions(false, 0, -1, -1).Do(ctx)
+		}
+		bytesPerSecond := func(kbps float64) float64 {
+			if kbps <= 0 {
+				return -1 // unlimited
+			}
+			return kbps * 1000 / 8
+		}
+		return network.EmulateNetworkConditions(p.Offline, p.Latency, bytesPerSecond(p.Download), bytesPerSecond(p.Upload)).Do(ctx)
+	})
+}
+
+// loadingStateJS counts the visible loading indicators of the page:
+// skeletons and shimmers, spinners and progress bars, and busy regions.
+const loadingStateJS = `
+(function() {
+	const visible = (el) => {
+		const rect = el.getBoundingClientRect();
+		return rect.width > 0 && rect.height > 0 && getComputedStyle(el).visibility !== 'hidden';
+	};
+	const count = (selector) => Array.from(document.querySelectorAll(selector)).filter(visible).length;
+	return {
+		readyState: document.readyState,
+		skeletons: count('[class*="skeleton" i], [class*="shimmer" i], [class*="placeholder" i]:not(input):not(textarea)'),
+		spinners: count('[class*="spinner" i], [class*="loader" i], [class*="loading" i], [role="progressbar"]'),
+		busy: count('[aria-busy="true"]'),
+	};
+})()
+`
+
+// loadingFrame is the page a moment after the load started.
+type loadingFrame struct {
+	At         int64  `json:"at_ms"`
+	Screenshot string `json:"screenshot,omitempty"`
+	ReadyState string `json:"readyState,omitempty"`
+	Skeletons  int    `json:"skeletons"`
+	Spinners   int    `json:"spinners"`
+	Busy       int    `json:"busy"`
+}
+
+// Loading reports whether the frame shows a loading indicator.
+func (f loadingFrame) Loading() bool {
+	return f.Skeletons+f.Spinners+f.Busy > 0
+}
+
+// throttledLoad is the page loaded over one network profile.
+type throttledLoad struct {
+	networkProfile
+	Loaded  int64          `json:"loaded_ms,omitempty"`  // until readyState was complete
+	Settled int64          `json:"settled_ms,omitempty"` // until no loading indicator was left
+	Frames  []loadingFrame `json:"frames"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// pageThrottling is throttling/<page>/throttling.json.
+type pageThrottling struct {
+	Page     string          `json:"page"`
+	URL      string          `json:"url"`
+	Profiles []throttledLoad `json:"profiles"`
+}
+
+// captureThrottling loads the page again over each network profile with the
+// cache disabled, screenshotting it at the offsets of
+// explorer.throttling.frames to keep its loading and skeleton states, and
+// writes throttling/<page>/throttling.json. The offline profile does not reload:
+// it shows how the loaded page reacts to losing the connection. The page
+// is loaded normally again afterwards.
+func (e *AgicapExplorer) captureThrottling(pageName, pageURL string) string {
+	if len(e.throttling) == 0 {
+		return ""
+	}
+	var frames []time.Duration
+	for _, s := range e.config.GetStringSlice("explorer.throttling.frames") {
+		if d, err := time.ParseDuration(s); err == nil {
+			frames = append(frames, d)
+		}
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i] < frames[j] })
+	timeout := e.config.GetDuration("explorer.throttling.timeout")
+	dir := filepath.Join("throttling", sanitize(pageName))
+
+	result := pageThrottling{Page: pageName, URL: pageURL}
+	for _, profile := range e.throttling {
+		load := throttledLoad{networkProfile: profile}
+		start := time.Now()
+		err := chromedp.Run(e.ctx,
+			network.SetCacheDisabled(true),
+			profile.apply(),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				start = time.Now()
+				if profile.Offline {
+					return nil
+				}
+				// Page.navigate returns once the document responds, without
+				// waiting for the load the frames are taken during
+				_, _, _, err := page.Navigate(pageURL).Do(ctx)
+				return err
+			}),
+		)
+		if err != nil {
+			e.log("⚠️ Network profile %s failed for %s: %v", profile.Name, pageName, err)
+			load.Error = err.Error()
+			result.Profiles = append(result.Profiles, load)
+			continue
+		}
+
+		for _, at := range frames {
+			time.Sleep(time.Until(start.Add(at)))
+			frame := e.loadingState(start)
+			var shot []byte
+			if chromedp.Run(e.ctx, chromedp.CaptureScreenshot(&shot)) == nil {
+				if path, err := e.writeArtifact(dir, fmt.Sprintf("%s_%d.png", sanitize(profile.Name), at.Milliseconds()), shot); err == nil {
+					frame.Screenshot, _ = filepath.Rel(e.outputDir, path)
+					frame.Screenshot = filepath.ToSlash(frame.Screenshot)
+				}
+			}
+			load.Frames = append(load.Frames, frame)
+			load.observe(frame)
+		}
+		// Keep watching until the page is complete and its indicators gone
+		for deadline := start.Add(timeout); (load.Loaded == 0 || load.Settled == 0) && time.Now().Before(deadline); {
+			time.Sleep(250 * time.Millisecond)
+			load.observe(e.loadingState(start))
+		}
+		result.Profiles = append(result.Profiles, load)
+	}
+
+	restore := chromedp.Tasks{
+		networkProfile{}.apply(),
+		network.SetCacheDisabled(false),
+		chromedp.Navigate(pageURL),
+		e.settle("navigation"),
+	}
+	if err := chromedp.Run(e.ctx, restore); err != nil {
+		e.log("⚠️ Failed to reload %s after throttling: %v", pageName, err)
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact(dir, "throttling.json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write the throttled loads of %s: %v", pageName, err)
+		return ""
+	}
+	e.log("🐢 %s: loading states over %d network profiles", pageName, len(result.Profiles))
+	return path
+}
+
+// loadingState counts the loading indicators of the page now; a page still
+// navigating counts as loading.
+func (e *AgicapExplorer) loadingState(start time.Time) loadingFrame {
+	frame := loadingFrame{ReadyState: "loading"}
+	chromedp.Run(e.ctx, chromedp.Evaluate(loadingStateJS, &frame))
+	frame.At = time.Since(start).Milliseconds()
+	return frame
+}
+
+// observe records when the load completed and when the last loading
+// indicator disappeared after it.
+func (l *throttledLoad) observe(f loadingFrame) {
+	if l.Loaded == 0 && f.ReadyState == "complete" {
+		l.Loaded = f.At
+	}
+	if l.Loaded != 0 && l.Settled == 0 && !f.Loading() {
+		l.Settled = f.At
+	}
+}
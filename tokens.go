@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// designToken is a single token in the W3C Design Tokens Community Group
+// format.
+type designToken struct {
+	Type  string      `json:"$type"`
+	Value interface{} `json:"$value"`
+}
+
+// dtcgShadow is the composite value of a shadow token.
+type dtcgShadow struct {
+	Color   string `json:"color"`
+	OffsetX string `json:"offsetX"`
+	OffsetY string `json:"offsetY"`
+	Blur    string `json:"blur"`
+	Spread  string `json:"spread"`
+	Inset   bool   `json:"inset,omitempty"`
+}
+
+// writeDesignTokens exports the design system as tokens/design.tokens.json in
+// the W3C design tokens format plus a Style Dictionary config building CSS,
+// SCSS, JS and JSON outputs from it.
+func (e *AgicapExplorer) writeDesignTokens(system orderedTokens) error {
+	dir := filepath.Join(e.outputDir, "tokens")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	typography, _ := system.get("typography").(orderedTokens)
+	tokens := orderedTokens{
+		{"$description", "Design tokens extracted from the captured UI"},
+	}
+	addGroup := func(name string, group orderedTokens) {
+		if len(group) > 0 {
+			tokens = append(tokens, tokenEntry{name, group})
+		}
+	}
+	addGroup("color", tokenGroup(system.get("colors"), "color", nil))
+	font := orderedTokens{}
+	for _, g := range []struct {
+		name, key, tokenType string
+		convert              func(string) interface{}
+	}{
+		{"family", "fontFamily", "fontFamily", fontFamilyValue},
+		{"size", "fontSize", "dimension", nil},
+		{"weight", "fontWeight", "fontWeight", fontWeightValue},
+	} {
+		if group := tokenGroup(typography.get(g.key), g.tokenType, g.convert); len(group) > 0 {
+			font = append(font, tokenEntry{g.name, group})
+		}
+	}
+	addGroup("font", font)
+	addGroup("spacing", tokenGroup(system.get("spacing"), "dimension", nil))
+	addGroup("radius", tokenGroup(system.get("borderRadius"), "dimension", nil))
+	addGroup("shadow", tokenGroup(system.get("shadows"), "shadow", shadowValue))
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "design.tokens.json"), data, 0644); err != nil {
+		return err
+	}
+
+	platform := func(transformGroup, buildPath, destination, format string) map[string]interface{} {
+		return map[string]interface{}{
+			"transformGroup": transformGroup,
+			"buildPath":      buildPath,
+			"files":          []map[string]string{{"destination": destination, "format": format}},
+		}
+	}
+	config := map[string]interface{}{
+		"source": []string{"design.tokens.json"},
+		"platforms": map[string]interface{}{
+			"css":  platform("css", "build/css/", "variables.css", "css/variables"),
+			"scss": platform("scss", "build/scss/", "_variables.scss", "scss/variables"),
+			"js":   platform("js", "build/js/", "tokens.js", "javascript/es6"),
+			"json": platform("js", "build/json/", "tokens.json", "json/nested"),
+		},
+	}
+	data, err = json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "style-dictionary.config.json"), data, 0644)
+}
+
+// tokenGroup converts a design_system.json section into typed tokens,
+// recursing into nested groups such as colors.text.
+func tokenGroup(section interface{}, tokenType string, convert func(string) interface{}) orderedTokens {
+	entries, _ := section.(orderedTokens)
+	group := orderedTokens{}
+	for _, entry := range entries {
+		switch v := entry.Value.(type) {
+		case orderedTokens:
+			if nested := tokenGroup(v, tokenType, convert); len(nested) > 0 {
+				group = append(group, tokenEntry{entry.Key, nested})
+			}
+		case string:
+			var value interface{} = v
+			if convert != nil {
+				value = convert(v)
+			}
+			group = append(group, tokenEntry{entry.Key, designToken{Type: tokenType, Value: value}})
+		}
+	}
+	return group
+}
+
+// fontFamilyValue splits a CSS font stack into the list form.
+func fontFamilyValue(stack string) interface{} {
+	var families []string
+	for _, f := range strings.Split(stack, ",") {
+		if f = strings.Trim(strings.TrimSpace(f), `"'`); f != "" {
+			families = append(families, f)
+		}
+	}
+	return families
+}
+
+func fontWeightValue(weight string) interface{} {
+	if n, err := strconv.Atoi(weight); err == nil {
+		return n
+	}
+	return weight
+}
+
+// shadowValue parses a computed box-shadow ("rgba(0, 0, 0, 0.1) 0px 1px 3px
+// 0px, ...") into shadow objects.
+func shadowValue(shadow string) interface{} {
+	var layers []dtcgShadow
+	depth, start := 0, 0
+	for i := 0; i <= len(shadow); i++ {
+		if i < len(shadow) {
+			switch shadow[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		layer := strings.TrimSpace(shadow[start:i])
+		start = i + 1
+
+		s := dtcgShadow{Color: "#000000", OffsetX: "0px", OffsetY: "0px", Blur: "0px", Spread: "0px"}
+		if color := cssColorPattern.FindString(layer); color != "" {
+			if hex, _, ok := parseCSSColor(color); ok {
+				s.Color = hex
+			}
+			layer = strings.Replace(layer, color, "", 1)
+		}
+		s.Inset = strings.Contains(layer, "inset")
+		lengths := cssLengthPattern.FindAllString(layer, -1)
+		for i, target := range []*string{&s.OffsetX, &s.OffsetY, &s.Blur, &s.Spread} {
+			if i < len(lengths) {
+				*target = lengths[i]
+			}
+		}
+		layers = append(layers, s)
+	}
+	if len(layers) == 1 {
+		return layers[0]
+	}
+	return layers
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/chromedp/chromedp"
+)
+
+// collectStrings lists the visible text of the page and the texts of
+// placeholder, aria-label, title and alt attributes, each with a short CSS
+// path to its element, up to %d strings. Strings without letters (amounts,
+// dates) are skipped.
+const collectStrings = `
+(function(maxStrings) {
+	const path = (el) => {
+		const parts = [];
+		for (let node = el; node && node.nodeType === 1 && parts.length < 4; node = node.parentElement) {
+			if (node.id) {
+				parts.unshift('#' + CSS.escape(node.id));
+				break;
+			}
+			let part = node.tagName.toLowerCase();
+			const cls = typeof node.className === 'string' ? node.className.trim().split(/\s+/).filter(c => c && !/\d{3,}/.test(c))[0] : '';
+			if (cls) part += '.' + CSS.escape(cls);
+			const siblings = node.parentElement ? Array.from(node.parentElement.children).filter(c => c.tagName === node.tagName) : [];
+			if (siblings.length > 1) part += ':nth-of-type(' + (siblings.indexOf(node) + 1) + ')';
+			parts.unshift(part);
+		}
+		return parts.join(' > ');
+	};
+	const clean = (s) => (s || '').replace(/\s+/g, ' ').trim();
+	const hasLetters = (s) => /\p{L}/u.test(s);
+	const visible = (el) => {
+		const rect = el.getBoundingClientRect();
+		const style = getComputedStyle(el);
+		return rect.width > 0 && rect.height > 0 && style.visibility !== 'hidden' && style.display !== 'none';
+	};
+
+	const strings = [];
+	const add = (text, kind, el) => {
+		if (strings.length < maxStrings && text.length <= 500 && hasLetters(text)) {
+			strings.push({text: text, kind: kind, selector: path(el)});
+		}
+	};
+	const walker = document.createTreeWalker(document.body, NodeFilter.SHOW_TEXT, {
+		acceptNode: (node) => node.parentElement && !node.parentElement.closest('script, style, noscript, template, svg') ? NodeFilter.FILTER_ACCEPT : NodeFilter.FILTER_REJECT,
+	});
+	const seen = new Set();
+	let node;
+	while ((node = walker.nextNode())) {
+		const el = node.parentElement;
+		if (seen.has(el) || !clean(node.textContent) || !visible(el)) continue;
+		seen.add(el);
+		// The element's own text, without the text of child elements
+		add(clean(Array.from(el.childNodes).filter(n => n.nodeType === 3).map(n => n.textContent).join(' ')), 'text', el);
+	}
+	for (const attr of ['placeholder', 'aria-label', 'title', 'alt']) {
+		document.querySelectorAll('[' + attr + ']').forEach(el => add(clean(el.getAttribute(attr)), attr, el));
+	}
+	return {lang: (document.documentElement.lang || '').toLowerCase().split('-')[0], strings: strings};
+})(%d)
+`
+
+// uiString is a text shown in the UI.
+type uiString struct {
+	Text     string `json:"text"`
+	Kind     string `json:"kind"` // text, placeholder, aria-label, title or alt
+	Selector string `json:"selector"`
+	Lang     string `json:"lang"`
+}
+
+// pageStrings is i18n/pages/<page>.json.
+type pageStrings struct {
+	Page    string     `json:"page"`
+	Lang    string     `json:"lang"` // of the document, or the most detected
+	Strings []uiString `json:"strings"`
+}
+
+// languageHints are frequent short words of the languages the catalog
+// distinguishes.
+var languageHints = map[string]map[string]bool{
+	"de": wordSet("der die das und ist nicht mit für von zu den dem des ein eine einen auf im sie wir ihr alle neue neuen bitte speichern abbrechen löschen bearbeiten hinzufügen suchen einstellungen übersicht konto konten zahlungen rechnung rechnungen monat jahr heute gestern noch keine kein oder aus bei nach über unter zurück weiter schließen anmelden abmelden"),
+	"en": wordSet("the and is not with for of to a an on in at you we your all new please save cancel delete edit add search settings overview account accounts payments invoice invoices month year today yesterday no none or from by after about under back next close sign log in out"),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// detectLanguage guesses whether a UI string is German or English from its
+// words, umlauts and ß. It returns "" when the string gives no hint, as
+// for names and single technical words.
+func detectLanguage(text string) string {
+	scores := make(map[string]int)
+	if strings.ContainsAny(text, "äöüÄÖÜß") {
+		scores["de"] += 2
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for lang, hints := range languageHints {
+			if hints[word] {
+				scores[lang]++
+			}
+		}
+	}
+	switch {
+	case scores["de"] > scores["en"]:
+		return "de"
+	case scores["en"] > scores["de"]:
+		return "en"
+	}
+	return ""
+}
+
+// captureStrings writes the UI strings of the current page, redacted and
+// with their detected language, to i18n/pages/<page>.json.
+func (e *AgicapExplorer) captureStrings(pageName string) {
+	var found struct {
+		Lang    string     `json:"lang"`
+		Strings []uiString `json:"strings"`
+	}
+	script := fmt.Sprintf(collectStrings, e.config.GetInt("explorer.capture.max_strings"))
+	if err := chromedp.Run(e.ctx, chromedp.Evaluate(script, &found)); err != nil {
+		e.log("⚠️ String extraction failed for %s: %v", pageName, err)
+		return
+	}
+
+	page := pageStrings{Page: pageName, Lang: found.Lang, Strings: make([]uiString, 0, len(found.Strings))}
+	votes := make(map[string]int)
+	for _, s := range found.Strings {
+		s.Text = e.redactor.Redact(s.Text)
+		s.Lang = detectLanguage(s.Text)
+		votes[s.Lang]++
+		page.Strings = append(page.Strings, s)
+	}
+	if page.Lang == "" {
+		if votes["de"] >= votes["en"] && votes["de"] > 0 {
+			page.Lang = "de"
+		} else if votes["en"] > 0 {
+			page.Lang = "en"
+		}
+	}
+	// Strings without hints are in the language of the page
+	for i := range page.Strings {
+		if page.Strings[i].Lang == "" {
+			page.Strings[i].Lang = page.Lang
+		}
+	}
+
+	data, err := json.MarshalIndent(page, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact(filepath.Join("i18n", "pages"), sanitize(pageName)+".json", data); err != nil {
+		e.log("⚠️ Failed to write UI strings for %s: %v", pageName, err)
+	}
+}
+
+// catalogEntry is a distinct UI string of the run, keyed for an i18n
+// library.
+type catalogEntry struct {
+	Key       string   `json:"key"`
+	Text      string   `json:"text"`
+	Lang      string   `json:"lang"`
+	Kinds     []string `json:"kinds"`
+	Count     int      `json:"count"`
+	Pages     []string `json:"pages"`
+	Selectors []string `json:"selectors"`
+}
+
+// stringCatalog deduplicates the strings of i18n/pages/ by text and
+// language, most used first, and assigns every entry a unique key.
+func (e *AgicapExplorer) stringCatalog() []*catalogEntry {
+	byText := make(map[string]*catalogEntry)
+	var entries []*catalogEntry
+	for _, item := range e.navigationMap {
+		key := pageKey(item)
+		data, err := ioutil.ReadFile(filepath.Join(e.outputDir, "i18n", "pages", key+".json"))
+		if err != nil {
+			continue
+		}
+		var page pageStrings
+		if json.Unmarshal(data, &page) != nil {
+			continue
+		}
+		for _, s := range page.Strings {
+			id := s.Lang + "\x00" + s.Text
+			entry, ok := byText[id]
+			if !ok {
+				entry = &catalogEntry{Text: s.Text, Lang: s.Lang}
+				byText[id] = entry
+				entries = append(entries, entry)
+			}
+			entry.Count++
+			entry.Kinds = appendUnique(entry.Kinds, s.Kind)
+			entry.Pages = appendUnique(entry.Pages, key)
+			if len(entry.Selectors) < 5 {
+				entry.Selectors = appendUnique(entry.Selectors, s.Selector)
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Count > entries[j].Count })
+
+	used := make(map[string]bool)
+	for _, entry := range entries {
+		base := messageKey(entry.Text)
+		entry.Key = base
+		for n := 2; used[entry.Key]; n++ {
+			entry.Key = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[entry.Key] = true
+	}
+	return entries
+}
+
+// messageKey derives a message key from the first words of a string:
+// "Neue Zahlung anlegen" becomes "neue_zahlung_anlegen".
+func messageKey(text string) string {
+	replacer := strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss")
+	words := strings.FieldsFunc(replacer.Replace(strings.ToLower(text)), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	if len(words) > 6 {
+		words = words[:6]
+	}
+	key := strings.Join(words, "_")
+	if len(key) > 40 {
+		key = strings.TrimRight(key[:40], "_")
+	}
+	if key == "" {
+		key = "string"
+	}
+	return key
+}
+
+// writeStringCatalog writes i18n/catalog.json with every distinct string,
+// and per language of explorer.capture.string_languages a flat <lang>.json of key
+// and text (for next-intl or i18next) and a <lang>.po whose entries in other
+// languages are left to translate.
+func (e *AgicapExplorer) writeStringCatalog() error {
+	entries := e.stringCatalog()
+	dir := filepath.Join(e.outputDir, "i18n")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if entries == nil {
+		entries = []*catalogEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "catalog.json"), data, 0644); err != nil {
+		return err
+	}
+
+	for _, lang := range e.config.GetStringSlice("explorer.capture.string_languages") {
+		messages := orderedTokens{}
+		var po strings.Builder
+		fmt.Fprintf(&po, "msgid \"\"\nmsgstr \"\"\n\"Content-Type: text/plain; charset=UTF-8\\n\"\n\"Language: %s\\n\"\n", lang)
+		for _, entry := range entries {
+			translation := ""
+			if entry.Lang == lang {
+				translation = entry.Text
+				messages = append(messages, tokenEntry{entry.Key, entry.Text})
+			}
+			fmt.Fprintf(&po, "\n#: %s\n", strings.Join(entry.Pages, " "))
+			if entry.Lang != "" && entry.Lang != lang {
+				fmt.Fprintf(&po, "#. original (%s)\n", entry.Lang)
+			}
+			fmt.Fprintf(&po, "msgctxt %s\nmsgid %s\nmsgstr %s\n", poQuote(entry.Key), poQuote(entry.Text), poQuote(translation))
+		}
+		data, err := json.MarshalIndent(messages, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, lang+".json"), data, 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, lang+".po"), []byte(po.String()), 0644); err != nil {
+			return err
+		}
+	}
+	e.log("🌐 %d distinct UI strings in i18n/catalog.json", len(entries))
+	return nil
+}
+
+// poQuote quotes a string for a PO file.
+func poQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(s) + `"`
+}
@@ -0,0 +1,580 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// objectStore is the bucket runs are uploaded to.
+type objectStore interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	// URL is where the object of key can be opened, given read access
+	URL(key string) string
+	// String names the bucket, e.g. s3://bucket
+	String() string
+}
+
+var uploadClient = &http.Client{Timeout: 5 * time.Minute}
+
+// newObjectStore loads explorer.upload. Credentials come from the
+// environment variables each provider's own tools read: AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN for s3,
+// GOOGLE_OAUTH_ACCESS_TOKEN or the service account key file of
+// GOOGLE_APPLICATION_CREDENTIALS for gcs, AZURE_STORAGE_KEY or
+// AZURE_STORAGE_SAS_TOKEN (and AZURE_STORAGE_ACCOUNT) for azure.
+func newObjectStore(v *viper.Viper) (objectStore, error) {
+	bucket := v.GetString("explorer.upload.bucket")
+	if bucket == "" {
+		return nil, fmt.Errorf("explorer.upload.bucket is not set")
+	}
+	switch provider := v.GetString("explorer.upload.provider"); provider {
+	case "s3":
+		s := &s3Store{
+			bucket:       bucket,
+			region:       v.GetString("explorer.upload.region"),
+			endpoint:     strings.TrimSuffix(v.GetString("explorer.upload.endpoint"), "/"),
+			accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if s.region == "" {
+			s.region = os.Getenv("AWS_REGION")
+		}
+		if s.accessKey == "" || s.secretKey == "" {
+			return nil, fmt.Errorf("s3 upload needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		if s.region == "" {
+			return nil, fmt.Errorf("s3 upload needs explorer.upload.region or AWS_REGION")
+		}
+		return s, nil
+	case "gcs":
+		g := &gcsStore{bucket: bucket, token: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")}
+		if g.token != "" {
+			g.expires = time.Now().Add(time.Hour)
+			return g, nil
+		}
+		file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+		if file == "" {
+			return nil, fmt.Errorf("gcs upload needs GOOGLE_OAUTH_ACCESS_TOKEN or GOOGLE_APPLICATION_CREDENTIALS")
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &g.account); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if g.account.TokenURI == "" {
+			g.account.TokenURI = "https://oauth2.googleapis.com/token"
+		}
+		return g, nil
+	case "azure":
+		a := &azureStore{
+			account:   v.GetString("explorer.upload.account"),
+			container: bucket,
+			sas:       strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"),
+		}
+		if a.account == "" {
+			a.account = os.Getenv("AZURE_STORAGE_ACCOUNT")
+		}
+		if a.account == "" {
+			return nil, fmt.Errorf("azure upload needs explorer.upload.account or AZURE_STORAGE_ACCOUNT")
+		}
+		if key := os.Getenv("AZURE_STORAGE_KEY"); key != "" {
+			var err error
+			if a.key, err = base64.StdEncoding.DecodeString(key); err != nil {
+				return nil, fmt.Errorf("AZURE_STORAGE_KEY: %w", err)
+			}
+		}
+		if a.key == nil && a.sas == "" {
+			return nil, fmt.Errorf("azure upload needs AZURE_STORAGE_KEY or AZURE_STORAGE_SAS_TOKEN")
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unknown explorer.upload.provider %q (s3, gcs, azure)", provider)
+	}
+}
+
+// escapeKey percent-encodes an object key for a URL path the way the AWS
+// and Azure signatures expect: everything but unreserved characters and
+// slashes.
+func escapeKey(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// putObject sends a PUT or POST and turns error statuses into errors with
+// the start of the response body.
+func putObject(req *http.Request) error {
+	resp, err := uploadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Store uploads to Amazon S3 with Signature Version 4, or to an
+// S3-compatible endpoint (MinIO, Cloudflare R2) with path-style URLs.
+type s3Store struct {
+	bucket, region, endpoint           string
+	accessKey, secretKey, sessionToken string
+}
+
+func (s *s3Store) String() string { return "s3://" + s.bucket }
+
+func (s *s3Store) URL(key string) string {
+	if s.endpoint != "" {
+		return s.endpoint + "/" + s.bucket + "/" + escapeKey(key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, escapeKey(key))
+}
+
+func (s *s3Store) Put(ctx context.Context, key, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.URL(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, body, time.Now().UTC())
+	return putObject(req)
+}
+
+// sign adds the Signature Version 4 authorization of the request.
+func (s *s3Store) sign(req *http.Request, body []byte, now time.Time) {
+	sum := sha256.Sum256(body)
+	payload := hex.EncodeToString(sum[:])
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	headers := map[string]string{"host": req.URL.Host, "x-amz-content-sha256": payload, "x-amz-date": amzDate}
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+		headers["x-amz-security-token"] = s.sessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	// Query parameters sorted, each with an =, spaces as %20
+	query := strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+	request := strings.Join([]string{req.Method, req.URL.EscapedPath(), query, canonical.String(), signed, payload}, "\n")
+	requestSum := sha256.Sum256([]byte(request))
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestSum[:])
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+// gcsStore uploads to Google Cloud Storage with an OAuth access token,
+// given or obtained for a service account.
+type gcsStore struct {
+	bucket  string
+	account struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (g *gcsStore) String() string { return "gs://" + g.bucket }
+
+func (g *gcsStore) URL(key string) string {
+	return "https://storage.googleapis.com/" + g.bucket + "/" + escapeKey(key)
+}
+
+func (g *gcsStore) Put(ctx context.Context, key, contentType string, body []byte) error {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	target := fmt.Sprintf("https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s", url.PathEscape(g.bucket), url.QueryEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return putObject(req)
+}
+
+// accessToken returns the token, exchanging a JWT signed with the service
+// account's key for a new one when it is about to expire.
+func (g *gcsStore) accessToken(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Until(g.expires) > time.Minute {
+		return g.token, nil
+	}
+	if g.account.PrivateKey == "" {
+		return "", fmt.Errorf("GOOGLE_OAUTH_ACCESS_TOKEN has expired")
+	}
+	block, _ := pem.Decode([]byte(g.account.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("service account key: no PEM block")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("service account key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("service account key is not an RSA key")
+	}
+
+	now := time.Now()
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := encode(map[string]string{"alg": "RS256", "typ": "JWT"}) + "." + encode(map[string]interface{}{
+		"iss":   g.account.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/devstorage.read_write",
+		"aud":   g.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	sum := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := uploadClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("token exchange: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("token exchange: %s %s", resp.Status, token.Error)
+	}
+	g.token = token.AccessToken
+	g.expires = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return g.token, nil
+}
+
+// azureStore uploads block blobs to Azure Blob Storage, signed with the
+// account key (Shared Key) or authorized by a SAS token.
+type azureStore struct {
+	account, container, sas string
+	key                     []byte
+}
+
+func (a *azureStore) String() string {
+	return fmt.Sprintf("https://%s.blob.core.windows.net/%s", a.account, a.container)
+}
+
+func (a *azureStore) URL(key string) string {
+	return a.String() + "/" + escapeKey(key)
+}
+
+func (a *azureStore) Put(ctx context.Context, key, contentType string, body []byte) error {
+	target := a.URL(key)
+	if a.key == nil {
+		// A token copied from the portal starts with ?
+		target += "?" + strings.TrimPrefix(a.sas, "?")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	req.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", "2021-08-06")
+	if a.key != nil {
+		req.Header.Set("Authorization", "SharedKey "+a.account+":"+base64.StdEncoding.EncodeToString(hmacSHA256(a.key, a.stringToSign(req, len(body)))))
+	}
+	return putObject(req)
+}
+
+// stringToSign is the Shared Key string-to-sign of a blob upload: the
+// verb, Content-Encoding, -Language, -Length (empty for 0), -MD5, -Type,
+// Date, If-Modified-Since, If-Match, If-None-Match, If-Unmodified-Since
+// and Range, then the x-ms- headers and the resource.
+func (a *azureStore) stringToSign(req *http.Request, length int) string {
+	contentLength := ""
+	if length > 0 {
+		contentLength = strconv.Itoa(length)
+	}
+	return strings.Join([]string{req.Method, "", "", contentLength, "", req.Header.Get("Content-Type"), "", "", "", "", "", ""}, "\n") + "\n" +
+		"x-ms-blob-type:" + req.Header.Get("X-Ms-Blob-Type") + "\n" +
+		"x-ms-date:" + req.Header.Get("X-Ms-Date") + "\n" +
+		"x-ms-version:" + req.Header.Get("X-Ms-Version") + "\n" +
+		"/" + a.account + req.URL.EscapedPath()
+}
+
+// uploadOptions are the explorer.upload settings besides the bucket.
+type uploadOptions struct {
+	Prefix      string
+	Content     string // directory, archive or both
+	Concurrency int
+}
+
+// uploadResult is what uploadRun pushed and where.
+type uploadResult struct {
+	Files     int
+	Bytes     int64
+	Prefix    string // of the run's files, without a trailing slash
+	ReportURL string
+}
+
+// uploadRun pushes a run to the store. Its files go under
+// <prefix>/runs/<run id>/, which sorts by start time; the archive goes to
+// <prefix>/archives/ so a lifecycle rule can keep archives longer than
+// the browsable files, or the other way round. <prefix>/latest.json points
+// to the run uploaded last. Each file is tried three times.
+func uploadRun(store objectStore, runDir, runID, archive string, opts uploadOptions) (uploadResult, error) {
+	if runID == "" {
+		runID = time.Now().Format("20060102-150405")
+	}
+	prefix := strings.Trim(opts.Prefix, "/")
+	result := uploadResult{Prefix: path.Join(prefix, "runs", runID)}
+
+	type object struct{ key, file string }
+	var objects []object
+	switch opts.Content {
+	case "", "directory", "both":
+		err := filepath.Walk(runDir, func(file string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(runDir, file)
+			if err != nil {
+				return err
+			}
+			objects = append(objects, object{key: path.Join(result.Prefix, filepath.ToSlash(rel)), file: file})
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+		result.ReportURL = store.URL(path.Join(result.Prefix, "report.html"))
+		if opts.Content != "both" {
+			break
+		}
+		fallthrough
+	case "archive":
+		if archive == "" {
+			return result, fmt.Errorf("explorer.upload.content is %s, but there is no archive (explorer.archive.enabled)", opts.Content)
+		}
+		objects = append(objects, object{key: path.Join(prefix, "archives", filepath.Base(archive)), file: archive})
+	default:
+		return result, fmt.Errorf("unknown explorer.upload.content %q (directory, archive, both)", opts.Content)
+	}
+
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	ctx := context.Background()
+	jobs := make(chan object)
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for o := range jobs {
+				data, err := ioutil.ReadFile(o.file)
+				if err == nil {
+					err = putWithRetry(ctx, store, o.key, contentTypeOf(o.key), data)
+				}
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", o.key, err))
+				} else {
+					result.Files++
+					result.Bytes += int64(len(data))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, o := range objects {
+		jobs <- o
+	}
+	close(jobs)
+	wg.Wait()
+	if len(errs) > 0 {
+		return result, fmt.Errorf("%d of %d uploads failed, first: %w", len(errs), len(objects), errs[0])
+	}
+
+	latest, _ := json.MarshalIndent(map[string]string{
+		"run_id":   runID,
+		"prefix":   result.Prefix,
+		"report":   result.ReportURL,
+		"uploaded": time.Now().Format(time.RFC3339),
+	}, "", "  ")
+	if err := putWithRetry(ctx, store, path.Join(prefix, "latest.json"), "application/json", latest); err != nil {
+		return result, fmt.Errorf("latest.json: %w", err)
+	}
+	return result, nil
+}
+
+// putWithRetry tries an upload three times, waiting 2s and 4s in between.
+func putWithRetry(ctx context.Context, store objectStore, key, contentType string, data []byte) error {
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(1<<attempt) * time.Second)
+		}
+		if err = store.Put(ctx, key, contentType, data); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// contentTypeOf picks the Content-Type of an object by its extension, so
+// uploaded reports open in the browser instead of downloading.
+func contentTypeOf(key string) string {
+	switch path.Ext(key) {
+	case ".ndjson":
+		return "application/x-ndjson"
+	case ".har":
+		return "application/json"
+	case ".md":
+		return "text/markdown; charset=utf-8"
+	}
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// uploadOptions returns the explorer.upload settings.
+func (e *AgicapExplorer) uploadOptions() uploadOptions {
+	return uploadOptionsOf(e.config)
+}
+
+func uploadOptionsOf(v *viper.Viper) uploadOptions {
+	return uploadOptions{
+		Prefix:      v.GetString("explorer.upload.prefix"),
+		Content:     v.GetString("explorer.upload.content"),
+		Concurrency: v.GetInt("explorer.upload.concurrency"),
+	}
+}
+
+// runUpload pushes a finished run (and its archive) to the bucket of
+// explorer.upload, for runs made without -upload:
+//
+//	explorer upload [-config file] [-archive file] <run>
+func runUpload(args []string) error {
+	fs := flag.NewFlagSet("upload", flag.ContinueOnError)
+	configFile := fs.String("config", "config.yaml", "path to the YAML configuration file")
+	archive := fs.String("archive", "", "archive of the run to upload with it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: upload [-config file] [-archive file] <run>")
+	}
+	config, err := loadConfig([]string{"-config", *configFile})
+	if err != nil {
+		return err
+	}
+	store, err := newObjectStore(config)
+	if err != nil {
+		return err
+	}
+	var runID string
+	if data, err := ioutil.ReadFile(filepath.Join(fs.Arg(0), "run.json")); err == nil {
+		var manifest runManifest
+		if json.Unmarshal(data, &manifest) == nil {
+			runID = manifest.RunID
+		}
+	}
+	opts := uploadOptionsOf(config)
+	if *archive == "" && opts.Content != "directory" && opts.Content != "" {
+		return errors.New("explorer.upload.content includes the archive, pass it with -archive")
+	}
+	result, err := uploadRun(store, fs.Arg(0), runID, *archive, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("☁️ Uploaded %d files (%.1f MB) to %s/%s\n", result.Files, float64(result.Bytes)/(1<<20), store, result.Prefix)
+	if result.ReportURL != "" {
+		fmt.Printf("📄 %s\n", result.ReportURL)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// viewport is a device of the explorer.viewports.matrix a page is captured
+// at: its CSS size, device pixel ratio and whether it is a touch device.
+type viewport struct {
+	Name   string
+	Width  int64
+	Height int64
+	Scale  float64
+	Mobile bool
+}
+
+// loadViewports reads explorer.viewports.matrix, naming unnamed viewports
+// after their size (390x844@3x).
+func loadViewports(v *viper.Viper) ([]viewport, error) {
+	var matrix []viewport
+	if err := v.UnmarshalKey("explorer.viewports.matrix", &matrix); err != nil {
+		return nil, fmt.Errorf("invalid viewport matrix: %w", err)
+	}
+	seen := make(map[string]bool)
+	for i := range matrix {
+		vp := &matrix[i]
+		if vp.Width <= 0 || vp.Height <= 0 {
+			return nil, fmt.Errorf("viewport %q: width and height must be positive", vp.Name)
+		}
+		if vp.Scale <= 0 {
+			vp.Scale = 1
+		}
+		if vp.Name == "" {
+			vp.Name = fmt.Sprintf("%dx%d@%gx", vp.Width, vp.Height, vp.Scale)
+		}
+		if seen[sanitize(vp.Name)] {
+			return nil, fmt.Errorf("viewport %q is listed twice", vp.Name)
+		}
+		seen[sanitize(vp.Name)] = true
+	}
+	return matrix, nil
+}
+
+// viewportCapture is the page rendered at one viewport.
+type viewportCapture struct {
+	Name        string  `json:"name"`
+	Width       int64   `json:"width"`
+	Height      int64   `json:"height"`
+	Scale       float64 `json:"device_pixel_ratio"`
+	Mobile      bool    `json:"mobile,omitempty"`
+	Screenshot  string  `json:"screenshot,omitempty"`
+	Analysis    string  `json:"analysis,omitempty"` // component analysis at this viewport
+	Components  int     `json:"components,omitempty"`
+	ScrollWidth int     `json:"scroll_width,omitempty"`
+	Overflow    bool    `json:"horizontal_overflow,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// pageViewports is viewports/<page>/viewports.json.
+type pageViewports struct {
+	Page      string            `json:"page"`
+	Viewports []viewportCapture `json:"viewports"`
+}
+
+// captureViewports renders the page at each viewport of the matrix and
+// writes viewports/<page>/ with a screenshot and, with
+// explorer.viewports.components, a component analysis per viewport, then
+// restores the browser's own window size.
+func (e *AgicapExplorer) captureViewports(pageName string) string {
+	if len(e.viewports) == 0 {
+		return ""
+	}
+	dir := filepath.Join("viewports", sanitize(pageName))
+	components := e.config.GetBool("explorer.viewports.components")
+
+	result := pageViewports{Page: pageName}
+	for _, vp := range e.viewports {
+		capture := viewportCapture{Name: vp.Name, Width: vp.Width, Height: vp.Height, Scale: vp.Scale, Mobile: vp.Mobile}
+		name := sanitize(vp.Name)
+		var shot []byte
+		err := chromedp.Run(e.ctx,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				if err := emulation.SetDeviceMetricsOverride(vp.Width, vp.Height, vp.Scale, vp.Mobile).Do(ctx); err != nil {
+					return err
+				}
+				return emulation.SetTouchEmulationEnabled(vp.Mobile).Do(ctx)
+			}),
+			chromedp.Sleep(700*time.Millisecond),
+			e.settle("capture"),
+			chromedp.Evaluate(`document.documentElement.scrollWidth`, &capture.ScrollWidth),
+			chromedp.CaptureScreenshot(&shot),
+		)
+		if err != nil {
+			e.log("⚠️ Viewport %s failed for %s: %v", vp.Name, pageName, err)
+			capture.Error = err.Error()
+			result.Viewports = append(result.Viewports, capture)
+			continue
+		}
+		capture.Overflow = int64(capture.ScrollWidth) > vp.Width
+		if path, err := e.writeArtifact(dir, name+".png", shot); err == nil {
+			capture.Screenshot, _ = filepath.Rel(e.outputDir, path)
+			capture.Screenshot = filepath.ToSlash(capture.Screenshot)
+		}
+
+		if components {
+			if analysis, err := e.scanComponents(pageName); err != nil {
+				e.log("⚠️ Failed to analyze components of %s at %s: %v", pageName, vp.Name, err)
+			} else if data, err := json.MarshalIndent(analysis, "", "  "); err == nil {
+				if path, err := e.writeArtifact(dir, name+"_analysis.json", data); err == nil {
+					capture.Analysis, _ = filepath.Rel(e.outputDir, path)
+					capture.Analysis = filepath.ToSlash(capture.Analysis)
+					capture.Components = len(analysis.Components)
+				}
+			}
+		}
+		result.Viewports = append(result.Viewports, capture)
+	}
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := emulation.SetTouchEmulationEnabled(false).Do(ctx); err != nil {
+			return err
+		}
+		return emulation.ClearDeviceMetricsOverride().Do(ctx)
+	}))
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact(dir, "viewports.json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write viewports of %s: %v", pageName, err)
+		return ""
+	}
+	e.log("📱 %s: captured at %d viewports", pageName, len(result.Viewports))
+	return path
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// visionFilter picks the key pages that are screenshotted again with
+// simulated color vision deficiencies: the pages whose URL or title matches
+// explorer.vision.pages and, with explorer.vision.charts, every page with a
+// chart.
+type visionFilter struct {
+	pages        []*regexp.Regexp
+	charts       bool
+	deficiencies []string
+}
+
+func newVisionFilter(v *viper.Viper) (*visionFilter, error) {
+	pages, err := compilePatterns(v.GetStringSlice("explorer.vision.pages"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid vision page pattern: %w", err)
+	}
+	deficiencies := v.GetStringSlice("explorer.vision.deficiencies")
+	for _, d := range deficiencies {
+		switch emulation.SetEmulatedVisionDeficiencyType(d) {
+		case emulation.SetEmulatedVisionDeficiencyTypeProtanopia, emulation.SetEmulatedVisionDeficiencyTypeDeuteranopia,
+			emulation.SetEmulatedVisionDeficiencyTypeTritanopia, emulation.SetEmulatedVisionDeficiencyTypeAchromatopsia,
+			emulation.SetEmulatedVisionDeficiencyTypeBlurredVision, emulation.SetEmulatedVisionDeficiencyTypeReducedContrast:
+		default:
+			return nil, fmt.Errorf("unknown vision deficiency %q", d)
+		}
+	}
+	return &visionFilter{
+		pages:        pages,
+		charts:       v.GetBool("explorer.vision.charts"),
+		deficiencies: deficiencies,
+	}, nil
+}
+
+// Key reports whether a page is rendered with the deficiencies.
+func (f *visionFilter) Key(pageURL, title string, charts int) bool {
+	if f.charts && charts > 0 {
+		return true
+	}
+	for _, re := range f.pages {
+		if re.MatchString(pageURL) || re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// captureVision screenshots the page once per deficiency emulated by
+// Chrome, to vision/<page>_<deficiency>.png.
+func (e *AgicapExplorer) captureVision(pageName string) {
+	for _, deficiency := range e.vision.deficiencies {
+		var shot []byte
+		err := chromedp.Run(e.ctx,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				return emulation.SetEmulatedVisionDeficiency(emulation.SetEmulatedVisionDeficiencyType(deficiency)).Do(ctx)
+			}),
+			chromedp.CaptureScreenshot(&shot),
+		)
+		if err != nil {
+			e.log("⚠️ %s simulation failed for %s: %v", deficiency, pageName, err)
+			continue
+		}
+		if _, err := e.writeArtifact("vision", sanitize(pageName)+"_"+deficiency+".png", shot); err != nil {
+			e.log("⚠️ Failed to write the %s screenshot of %s: %v", deficiency, pageName, err)
+		}
+	}
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return emulation.SetEmulatedVisionDeficiency(emulation.SetEmulatedVisionDeficiencyTypeNone).Do(ctx)
+	}))
+	e.log("🎨 Simulated %d color vision deficiencies on %s", len(e.vision.deficiencies), pageName)
+}
+
+// visionPage is a key page of the report's color vision section.
+type visionPage struct {
+	Title    string
+	Image    string
+	Variants []visionVariant
+}
+
+type visionVariant struct {
+	Deficiency string
+	Image      string
+}
+
+// visionPages lists the pages with vision/<page>_<deficiency>.png
+// screenshots.
+func (e *AgicapExplorer) visionPages() []visionPage {
+	if e.vision == nil {
+		return nil
+	}
+	var pages []visionPage
+	for _, item := range e.navigationMap {
+		page := visionPage{Title: item.Title, Image: reportPath(e.outputDir, item.Screenshot)}
+		for _, deficiency := range e.vision.deficiencies {
+			name := filepath.Join("vision", pageKey(item)+"_"+deficiency+".png")
+			if _, err := os.Stat(filepath.Join(e.outputDir, name)); err == nil {
+				page.Variants = append(page.Variants, visionVariant{Deficiency: deficiency, Image: filepath.ToSlash(name)})
+			}
+		}
+		if len(page.Variants) > 0 {
+			pages = append(pages, page)
+		}
+	}
+	return pages
+}
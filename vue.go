@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// vueComponent renders <Name>.vue as a <script setup> single-file
+// component; the content goes into the default slot and extra classes fall
+// through to the root element.
+func vueComponent(m *componentModel) string {
+	var b strings.Builder
+	b.WriteString("<script setup lang=\"ts\">\n")
+	m.writeHeader(&b, "")
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "type Variant = %s;\n\n", m.variantType())
+
+	b.WriteString("const props = withDefaults(\n  defineProps<{\n    variant?: Variant;\n")
+	for _, p := range m.Props {
+		fmt.Fprintf(&b, "    %s?: %s;\n", p.Name, p.Type)
+	}
+	fmt.Fprintf(&b, "  }>(),\n  { variant: '%s' },\n);\n\n", m.Variants[0].Name)
+	m.writeVariantClasses(&b, "", "Variant")
+	b.WriteString("</script>\n\n")
+
+	var attrs []string
+	for _, a := range m.Fixed {
+		attrs = append(attrs, fmt.Sprintf("%s=\"%s\"", a.Name, html.EscapeString(a.Value)))
+	}
+	for _, p := range m.Props {
+		attrs = append(attrs, fmt.Sprintf(":%s=\"props.%s\"", p.Attribute, p.Name))
+	}
+	attrs = append(attrs, `:class="variantClasses[props.variant]"`)
+
+	b.WriteString("<template>\n")
+	if m.Void {
+		fmt.Fprintf(&b, "  <%s\n    %s\n  />\n", m.Tag, strings.Join(attrs, "\n    "))
+	} else {
+		fmt.Fprintf(&b, "  <%s\n    %s\n  >\n    <slot />\n  </%s>\n", m.Tag, strings.Join(attrs, "\n    "), m.Tag)
+	}
+	b.WriteString("</template>\n")
+	return b.String()
+}
+
+func vueExport(m *componentModel) string {
+	return fmt.Sprintf("export { default as %s } from './%s.vue';\n", m.Name, m.Name)
+}
+
+func vueStories(m *componentModel) string {
+	return componentStories(m, "@storybook/vue3", fmt.Sprintf("import %s from './%s.vue';", m.Name, m.Name), "default")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/viper"
+)
+
+// waitSteps are the points where the explorer waits for the page to settle.
+var waitSteps = []string{"navigation", "login", "capture", "interaction", "form"}
+
+// waitCondition blocks until the page meets it or ctx ends.
+type waitCondition func(ctx context.Context) error
+
+// waitStep is the configured wait of one step: its conditions, checked in
+// turn, and the time they get together.
+type waitStep struct {
+	strategies []string
+	timeout    time.Duration
+}
+
+// waitStrategies reads explorer.waits.<step> for every step.
+func waitStrategies(v *viper.Viper) (map[string]waitStep, error) {
+	steps := make(map[string]waitStep)
+	for _, step := range waitSteps {
+		s := waitStep{
+			strategies: v.GetStringSlice("explorer.waits." + step + ".strategy"),
+			timeout:    v.GetDuration("explorer.waits." + step + ".timeout"),
+		}
+		for _, name := range s.strategies {
+			if name != "network_idle" && name != "dom_stable" {
+				return nil, fmt.Errorf("explorer.waits.%s: unknown strategy %q (network_idle, dom_stable)", step, name)
+			}
+		}
+		steps[step] = s
+	}
+	return steps, nil
+}
+
+// settle waits until the page has settled after a step, by the strategies
+// configured for it. Waiting is best effort: after the step's timeout the
+// explorer carries on with the page as it is.
+func (e *AgicapExplorer) settle(step string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		s := e.waits[step]
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+		for _, name := range s.strategies {
+			var cond waitCondition
+			switch name {
+			case "network_idle":
+				cond = e.activity.idle(e.config.GetDuration("explorer.waits.network_quiet"))
+			case "dom_stable":
+				cond = domStable(e.config.GetDuration("explorer.waits.dom_quiet"))
+			}
+			if err := cond(ctx); err != nil {
+				e.log("⏳ Page not settled after %s (%s, %s), continuing", step, name, s.timeout)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// visible waits for a selector to become visible, failing after the step's
+// timeout instead of the browser's.
+func (e *AgicapExplorer) visible(step, selector string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, e.waits[step].timeout)
+		defer cancel()
+		return chromedp.WaitVisible(selector, chromedp.ByQuery).Do(ctx)
+	})
+}
+
+// domStableJS resolves once the DOM has gone %d ms without mutations, or
+// after %d ms at most.
+const domStableJS = `new Promise(resolve => {
+	const quiet = %d, limit = %d;
+	const done = () => { observer.disconnect(); clearTimeout(timer); clearTimeout(cap); resolve(true); };
+	const observer = new MutationObserver(() => { clearTimeout(timer); timer = setTimeout(done, quiet); });
+	let timer = setTimeout(done, quiet);
+	const cap = setTimeout(done, limit);
+	observer.observe(document.documentElement || document, {childList: true, subtree: true, attributes: true, characterData: true});
+})`
+
+// domStable waits until the DOM stops changing for quiet, watched by a
+// MutationObserver in the page.
+func domStable(quiet time.Duration) waitCondition {
+	return func(ctx context.Context) error {
+		limit := time.Minute
+		if deadline, ok := ctx.Deadline(); ok {
+			limit = time.Until(deadline)
+		}
+		var ok bool
+		return chromedp.Evaluate(fmt.Sprintf(domStableJS, quiet.Milliseconds(), limit.Milliseconds()), &ok, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}).Do(ctx)
+	}
+}
+
+// networkActivity counts the requests a tab has in flight.
+type networkActivity struct {
+	mu       sync.Mutex
+	inflight map[network.RequestID]bool
+	changed  time.Time
+}
+
+// idleRequests is how many long-lived requests (polling, analytics beacons)
+// may stay open on an idle page.
+const idleRequests = 2
+
+func newNetworkActivity() *networkActivity {
+	return &networkActivity{inflight: make(map[network.RequestID]bool), changed: time.Now()}
+}
+
+// Listen follows the tab's requests as they start and end.
+func (a *networkActivity) Listen(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			a.inflight[ev.RequestID] = true
+		case *network.EventLoadingFinished:
+			delete(a.inflight, ev.RequestID)
+		case *network.EventLoadingFailed:
+			delete(a.inflight, ev.RequestID)
+		default:
+			return
+		}
+		a.changed = time.Now()
+	})
+}
+
+// idle waits until at most idleRequests requests have been in flight for
+// quiet.
+func (a *networkActivity) idle(quiet time.Duration) waitCondition {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			a.mu.Lock()
+			settled := len(a.inflight) <= idleRequests && time.Since(a.changed) >= quiet
+			a.mu.Unlock()
+			if settled {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// runWatch monitors the UI for changes: on the cron schedule of
+// explorer.watch.schedule it crawls into a new run under
+// explorer.watch.directory, compares it with the previous run and alerts
+// through the configured webhook, Slack and email when pages changed beyond
+// the thresholds:
+//
+//	explorer watch [-schedule "0 */6 * * *"] [-once] [crawl flags]
+//
+// With explorer.watch.pages only those pages are captured instead of a
+// full crawl. -once checks a single time, for an external scheduler. With
+// explorer.watch.metrics (-metrics :9090) the counters of the runs are
+// served on /metrics for Prometheus.
+func runWatch(args []string) error {
+	config, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+	schedule, err := parseCron(config.GetString("explorer.watch.schedule"))
+	if err != nil {
+		return err
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("schedule %q never fires", schedule)
+	}
+	if pages := config.GetStringSlice("explorer.watch.pages"); len(pages) > 0 {
+		config.Set("explorer.seeds.urls", pages)
+		config.Set("explorer.exploration.max_depth", 0)
+		config.Set("explorer.exploration.max_pages", len(pages)+1)
+	}
+	// Each run is compared with the last one, not with its own previous
+	// captures
+	config.Set("explorer.incremental.enabled", false)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if addr := config.GetString("explorer.watch.metrics"); addr != "" {
+		serveMetrics(ctx, addr)
+	}
+	dir := config.GetString("explorer.watch.directory")
+	for {
+		err := watchOnce(config, dir)
+		runMetrics.RunFinished(err == nil)
+		if err != nil {
+			fmt.Printf("⚠️ Watch run failed: %v\n", err)
+		}
+		if config.GetBool("explorer.watch.once") {
+			return nil
+		}
+		next := schedule.Next(time.Now())
+		fmt.Printf("⏰ Next check at %s\n", next.Format("2006-01-02 15:04"))
+		select {
+		case <-ctx.Done():
+			fmt.Println("👋 Watch stopped")
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// watchOnce crawls into <dir>/<timestamp>, diffs the run with the previous
+// one and sends the alerts. Runs beyond explorer.watch.keep are deleted,
+// oldest first.
+func watchOnce(config *viper.Viper, dir string) error {
+	previous := latestWatchRun(dir)
+	run := filepath.Join(dir, time.Now().Format("20060102-150405"))
+	config.Set("explorer.output.directory", run)
+	fmt.Printf("👀 Checking for UI changes into %s\n", run)
+	defer pruneWatchRuns(dir, config.GetInt("explorer.watch.keep"))
+	if err := crawlOnce(config); err != nil {
+		return err
+	}
+	if previous == "" {
+		fmt.Println("📌 First run, later runs are compared with it")
+		return nil
+	}
+
+	comparison, err := compareRuns(previous, run, config.GetInt("explorer.watch.tolerance"), 0)
+	if err != nil {
+		return err
+	}
+	comparisonDir := filepath.Join(run, "comparison")
+	if err := comparisonHTML(comparison, comparisonDir, config.GetString("explorer.output.locale"), config.GetFloat64("explorer.watch.sensitivity")); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(comparisonDir, "comparison.md"), []byte(comparisonMarkdown(comparison)), 0644); err != nil {
+		return err
+	}
+
+	alert := watchAlerts(comparison, config)
+	if alert == nil {
+		fmt.Printf("✅ No changes beyond the thresholds since %s\n", previous)
+		return nil
+	}
+	alert.Report = filepath.Join(comparisonDir, "comparison.html")
+	data, _ := json.MarshalIndent(alert, "", "  ")
+	if err := ioutil.WriteFile(filepath.Join(run, "watch_alert.json"), data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("🚨 %s\n", alert.Summary)
+	return sendWatchAlert(alert, config)
+}
+
+// crawlOnce runs a complete exploration with the config, as main does.
+func crawlOnce(config *viper.Viper) error {
+	explorer, err := NewAgicapExplorer(config, true)
+	if err != nil {
+		return fmt.Errorf("failed to create explorer: %w", err)
+	}
+	defer explorer.Close()
+	email := config.GetString("explorer.credentials.email")
+	password := config.GetString("explorer.credentials.password")
+	if err := explorer.Login(config.GetString("explorer.login_url"), email, password); err != nil {
+		explorer.notify("login", err)
+		return fmt.Errorf("login failed: %w", err)
+	}
+	if err := explorer.ExploreAllScreens(); err != nil {
+		explorer.notify("crawl", err)
+		return fmt.Errorf("exploration failed: %w", err)
+	}
+	if err := explorer.GenerateReport(); err != nil {
+		explorer.notify("report", err)
+		return err
+	}
+	explorer.notify("", nil)
+	return nil
+}
+
+// latestWatchRun is the newest complete run in dir, or "".
+func latestWatchRun(dir string) string {
+	runs := watchRuns(dir)
+	if len(runs) == 0 {
+		return ""
+	}
+	return runs[len(runs)-1]
+}
+
+// watchRuns lists the runs in dir with a navigation map, oldest first.
+func watchRuns(dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var runs []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(path, "navigation_map.json")); entry.IsDir() && err == nil {
+			runs = append(runs, path)
+		}
+	}
+	sort.Strings(runs)
+	return runs
+}
+
+func pruneWatchRuns(dir string, keep int) {
+	runs := watchRuns(dir)
+	if keep <= 0 || len(runs) <= keep {
+		return
+	}
+	for _, run := range runs[:len(runs)-keep] {
+		if err := os.RemoveAll(run); err != nil {
+			fmt.Printf("⚠️ Failed to delete old run %s: %v\n", run, err)
+		}
+	}
+}
+
+// watchAlert is what the webhook receives as JSON and watch_alert.json in
+// the run holds.
+type watchAlert struct {
+	Summary  string         `json:"summary"`
+	Previous string         `json:"previous_run"`
+	Run      string         `json:"run"`
+	Report   string         `json:"report"`
+	Added    []comparedPage `json:"added,omitempty"`
+	Removed  []comparedPage `json:"removed,omitempty"`
+	Changed  []watchChange  `json:"changed,omitempty"`
+}
+
+// watchChange is a page whose change exceeded a threshold.
+type watchChange struct {
+	URL        string   `json:"url"`
+	Title      string   `json:"title"`
+	PixelDiff  *float64 `json:"pixel_diff_percent,omitempty"`
+	Structural int      `json:"structural_changes"`
+	Structure  string   `json:"structure,omitempty"`
+}
+
+// watchAlerts picks the changes that exceed explorer.watch.thresholds: a
+// screenshot differing by more than pixel percent, more than dom structural
+// changes on a page and, with pages, added or removed pages. It returns nil
+// when nothing does.
+func watchAlerts(c *runComparison, config *viper.Viper) *watchAlert {
+	alert := &watchAlert{Previous: c.RunA, Run: c.RunB}
+	if config.GetBool("explorer.watch.thresholds.pages") {
+		alert.Added, alert.Removed = c.Added, c.Removed
+	}
+	pixel := config.GetFloat64("explorer.watch.thresholds.pixel")
+	dom := config.GetInt("explorer.watch.thresholds.dom")
+	for _, p := range c.Changed {
+		if diffValue(p.PixelDiff) <= pixel && p.DOM.Count() <= dom {
+			continue
+		}
+		change := watchChange{URL: p.URL, Title: p.TitleBefore, PixelDiff: p.PixelDiff, Structural: p.DOM.Count()}
+		if p.DOM != nil {
+			change.Structure = p.DOM.Summary()
+		}
+		alert.Changed = append(alert.Changed, change)
+	}
+	if len(alert.Added)+len(alert.Removed)+len(alert.Changed) == 0 {
+		return nil
+	}
+	alert.Summary = fmt.Sprintf("UI changes detected: %d pages changed, %d added, %d removed", len(alert.Changed), len(alert.Added), len(alert.Removed))
+	return alert
+}
+
+// Text renders the alert for Slack and email.
+func (a *watchAlert) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s → %s\n", a.Summary, a.Previous, a.Run)
+	for _, p := range a.Changed {
+		fmt.Fprintf(&b, "\n✏️ %s (%s)", p.Title, p.URL)
+		if p.PixelDiff != nil {
+			fmt.Fprintf(&b, " · %.2f%% pixels", *p.PixelDiff)
+		}
+		if p.Structure != "" {
+			fmt.Fprintf(&b, " · %s", p.Structure)
+		}
+	}
+	for _, p := range a.Added {
+		fmt.Fprintf(&b, "\n➕ %s (%s)", p.Title, p.URL)
+	}
+	for _, p := range a.Removed {
+		fmt.Fprintf(&b, "\n➖ %s (%s)", p.Title, p.URL)
+	}
+	fmt.Fprintf(&b, "\n\nReport: %s\n", a.Report)
+	return b.String()
+}
+
+// sendWatchAlert delivers the alert to every configured channel and
+// returns the failures.
+func sendWatchAlert(a *watchAlert, config *viper.Viper) error {
+	var failed []string
+	if url := config.GetString("explorer.watch.alerts.webhook"); url != "" {
+		data, _ := json.Marshal(a)
+		if err := postJSON(url, data); err != nil {
+			failed = append(failed, "webhook: "+err.Error())
+		}
+	}
+	if url := config.GetString("explorer.watch.alerts.slack"); url != "" {
+		data, _ := json.Marshal(map[string]string{"text": a.Text()})
+		if err := postJSON(url, data); err != nil {
+			failed = append(failed, "slack: "+err.Error())
+		}
+	}
+	if to := config.GetStringSlice("explorer.watch.alerts.email.to"); len(to) > 0 {
+		if err := sendAlertMail(a, to, config); err != nil {
+			failed = append(failed, "email: "+err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to send alerts: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func postJSON(url string, data []byte) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendAlertMail sends the alert through explorer.watch.alerts.email.smtp
+// (host:port), authenticating when a username is set.
+func sendAlertMail(a *watchAlert, to []string, config *viper.Viper) error {
+	server := config.GetString("explorer.watch.alerts.email.smtp")
+	if server == "" {
+		return fmt.Errorf("explorer.watch.alerts.email.smtp is not set")
+	}
+	from := config.GetString("explorer.watch.alerts.email.from")
+	var auth smtp.Auth
+	if user := config.GetString("explorer.watch.alerts.email.username"); user != "" {
+		host := strings.Split(server, ":")[0]
+		auth = smtp.PlainAuth("", user, config.GetString("explorer.watch.alerts.email.password"), host)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\n", from, strings.Join(to, ", "), a.Summary)
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(a.Text(), "\n", "\r\n"))
+	return smtp.SendMail(server, auth, from, to, msg.Bytes())
+}
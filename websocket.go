@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// wsFrame is one WebSocket message. Binary payloads stay base64 encoded.
+type wsFrame struct {
+	Direction string    `json:"direction"`
+	Time      time.Time `json:"time"`
+	Opcode    int       `json:"opcode"`
+	Binary    bool      `json:"binary,omitempty"`
+	Payload   string    `json:"payload"`
+}
+
+// wsConnection groups the frames exchanged on one socket while a page was
+// open.
+type wsConnection struct {
+	URL    string    `json:"url"`
+	Closed bool      `json:"closed,omitempty"`
+	Frames []wsFrame `json:"frames"`
+}
+
+// recordFrame appends a sent or received frame; the caller holds r.mu.
+func (r *networkRecorder) recordFrame(id network.RequestID, direction string, frame *network.WebSocketFrame) {
+	conn, ok := r.sockets[id]
+	if !ok || frame == nil {
+		return
+	}
+	if r.framesSeen >= r.maxFrames {
+		return
+	}
+	r.framesSeen++
+	conn.Frames = append(conn.Frames, wsFrame{
+		Direction: direction,
+		Time:      time.Now(),
+		Opcode:    int(frame.Opcode),
+		Binary:    frame.Opcode == 2,
+		Payload:   frame.PayloadData,
+	})
+}
+
+// takeSockets returns the frames recorded for the current page and starts a
+// new log; connections stay open across pages and keep being tracked.
+func (r *networkRecorder) takeSockets() (string, []wsConnection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var conns []wsConnection
+	open := r.socketOrder[:0]
+	for _, id := range r.socketOrder {
+		conn := r.sockets[id]
+		if len(conn.Frames) > 0 {
+			conns = append(conns, *conn)
+		}
+		conn.Frames = nil
+		if conn.Closed {
+			delete(r.sockets, id)
+		} else {
+			open = append(open, id)
+		}
+	}
+	r.socketOrder = open
+	r.framesSeen = 0
+	return r.page, conns
+}
+
+// writeWebSocketLog persists the WebSocket messages exchanged while the last
+// captured page was open to websocket/<page>.json, masking payloads with the
+// redaction rules.
+func (e *AgicapExplorer) writeWebSocketLog() {
+	if !e.network.enabled || !e.config.GetBool("explorer.network.websocket") {
+		return
+	}
+	page, conns := e.network.takeSockets()
+	if page == "" || len(conns) == 0 {
+		return
+	}
+
+	frames := 0
+	for i := range conns {
+		for j := range conns[i].Frames {
+			// Binary payloads are base64 and cannot be matched against text rules
+			if frame := &conns[i].Frames[j]; !frame.Binary {
+				frame.Payload = e.redactor.Redact(frame.Payload)
+			}
+		}
+		frames += len(conns[i].Frames)
+	}
+
+	data, err := json.MarshalIndent(conns, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := e.writeArtifact("websocket", page+".json", data); err != nil {
+		e.log("⚠️ Failed to write WebSocket log for %s: %v", page, err)
+		return
+	}
+	e.log("🔌 Logged %d WebSocket frames for %s", frames, page)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// zoomLevel is the page at one browser zoom level.
+type zoomLevel struct {
+	Zoom        int             `json:"zoom"`      // percent
+	Width       int64           `json:"css_width"` // the viewport shrinks as the zoom grows
+	Height      int64           `json:"css_height"`
+	Screenshot  string          `json:"screenshot,omitempty"`
+	ScrollWidth int             `json:"scroll_width,omitempty"`
+	Overflow    bool            `json:"horizontal_overflow"` // fails WCAG 1.4.10 reflow at 400%
+	Truncated   []truncatedText `json:"truncated"`
+	Hidden      []string        `json:"hidden"` // visible at 100% only
+	Error       string          `json:"error,omitempty"`
+}
+
+// Issues counts the ways the page breaks at the zoom level.
+func (z zoomLevel) Issues() int {
+	n := len(z.Truncated) + len(z.Hidden)
+	if z.Overflow {
+		n++
+	}
+	return n
+}
+
+// pageZoom is zoom/<page>.json.
+type pageZoom struct {
+	Page   string      `json:"page"`
+	URL    string      `json:"url"`
+	Width  int64       `json:"base_width"`
+	Height int64       `json:"base_height"`
+	Levels []zoomLevel `json:"levels"`
+}
+
+// captureZoom renders the page at each browser zoom level of
+// explorer.zoom.levels the way Chrome zooms: the CSS viewport shrinks by
+// the zoom factor while the device pixel ratio grows by it, so media
+// queries respond and the screenshot keeps its size. It records horizontal
+// scrolling, texts cut off and content hidden compared with 100% in
+// zoom/<page>.json, with a screenshot per level.
+func (e *AgicapExplorer) captureZoom(pageName, pageURL string) string {
+	var base struct {
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+		Ratio  float64 `json:"ratio"`
+	}
+	var visible map[string]string
+	var truncated []truncatedText
+	err := chromedp.Run(e.ctx,
+		chromedp.Evaluate(`({width: window.innerWidth, height: window.innerHeight, ratio: window.devicePixelRatio})`, &base),
+		chromedp.Evaluate(visibleElementsJS, &visible),
+		chromedp.Evaluate(truncatedTextJS, &truncated),
+	)
+	if err != nil || base.Width == 0 {
+		return ""
+	}
+	cut := make(map[string]bool, len(truncated))
+	for _, t := range truncated {
+		cut[t.Key] = true
+	}
+
+	name := sanitize(pageName)
+	result := pageZoom{Page: pageName, URL: pageURL, Width: int64(base.Width), Height: int64(base.Height)}
+	for _, zoom := range e.config.GetIntSlice("explorer.zoom.levels") {
+		if zoom <= 0 {
+			continue
+		}
+		factor := float64(zoom) / 100
+		level := zoomLevel{
+			Zoom:      zoom,
+			Width:     int64(math.Round(base.Width / factor)),
+			Height:    int64(math.Round(base.Height / factor)),
+			Truncated: []truncatedText{},
+			Hidden:    []string{},
+		}
+		var zoomed map[string]string
+		var cutOff []truncatedText
+		var shot []byte
+		err := chromedp.Run(e.ctx,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				return emulation.SetDeviceMetricsOverride(level.Width, level.Height, base.Ratio*factor, false).Do(ctx)
+			}),
+			chromedp.Sleep(700*time.Millisecond),
+			e.settle("capture"),
+			chromedp.Evaluate(`document.documentElement.scrollWidth`, &level.ScrollWidth),
+			chromedp.Evaluate(visibleElementsJS, &zoomed),
+			chromedp.Evaluate(truncatedTextJS, &cutOff),
+			chromedp.CaptureScreenshot(&shot),
+		)
+		if err != nil {
+			e.log("⚠️ Zoom %d%% failed for %s: %v", zoom, pageName, err)
+			level.Error = err.Error()
+			result.Levels = append(result.Levels, level)
+			continue
+		}
+		level.Overflow = int64(level.ScrollWidth) > level.Width
+		for _, t := range cutOff {
+			if !cut[t.Key] {
+				level.Truncated = append(level.Truncated, t)
+			}
+		}
+		level.Hidden = visibilityChanges(visible, zoomed)
+		if path, err := e.writeArtifact("zoom", fmt.Sprintf("%s_%d.png", name, zoom), shot); err == nil {
+			level.Screenshot, _ = filepath.Rel(e.outputDir, path)
+			level.Screenshot = filepath.ToSlash(level.Screenshot)
+		}
+		result.Levels = append(result.Levels, level)
+	}
+	chromedp.Run(e.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return emulation.ClearDeviceMetricsOverride().Do(ctx)
+	}))
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ""
+	}
+	path, err := e.writeArtifact("zoom", name+".json", data)
+	if err != nil {
+		e.log("⚠️ Failed to write the zoom levels of %s: %v", pageName, err)
+		return ""
+	}
+	issues := 0
+	for _, l := range result.Levels {
+		issues += l.Issues()
+	}
+	e.log("🔎 %s: %d zoom levels, %d reflow issues", pageName, len(result.Levels), issues)
+	return path
+}
+
+// zoomSection summarizes the zoom captures for the rebuild guide.
+func (e *AgicapExplorer) zoomSection() string {
+	var b strings.Builder
+	pages, overflowing := 0, 0
+	for _, item := range e.navigationMap {
+		data, err := ioutil.ReadFile(filepath.Join(e.outputDir, "zoom", pageKey(item)+".json"))
+		if err != nil {
+			continue
+		}
+		var zoom pageZoom
+		if json.Unmarshal(data, &zoom) != nil {
+			continue
+		}
+		pages++
+		var levels []string
+		overflows := false
+		for _, l := range zoom.Levels {
+			var issues []string
+			if l.Error != "" {
+				issues = append(issues, "not captured")
+			}
+			if l.Overflow {
+				issues = append(issues, "scrolls horizontally")
+				overflows = true
+			}
+			if n := len(l.Truncated); n > 0 {
+				issues = append(issues, fmt.Sprintf("%d texts cut off", n))
+			}
+			if n := len(l.Hidden); n > 0 {
+				issues = append(issues, fmt.Sprintf("%d elements hidden", n))
+			}
+			if len(issues) == 0 {
+				issues = append(issues, "ok")
+			}
+			levels = append(levels, fmt.Sprintf("%d%% (%dpx): %s", l.Zoom, l.Width, strings.Join(issues, ", ")))
+		}
+		if overflows {
+			overflowing++
+		}
+		fmt.Fprintf(&b, "- **%s** - %s\n", item.Title, strings.Join(levels, "; "))
+	}
+	if pages == 0 {
+		return "No pages were captured at other zoom levels.\n"
+	}
+	return fmt.Sprintf("%d of %d pages scroll horizontally at some zoom level (screenshots and details in ./zoom/):\n\n", overflowing, pages) + b.String()
+}